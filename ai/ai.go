@@ -3,7 +3,9 @@
 
 // Package ai provides support for application unit behaviour.
 // This is an experimental package that currently provides a behaviour
-// tree implementation.
+// tree, with decorators and a blackboard, a goal oriented action
+// planner, navigation meshes for finding paths over 3D levels, and
+// crowds that keep agents from running into each other.
 //
 // Package ai is provided as part of the vu (virtual universe) 3D engine.
 package ai
@@ -15,7 +15,21 @@ import (
 )
 
 // BehaviourTree processes behaviours. Multiple behaviours may be started
-// where each is a tree of behaviours composed of Sequences and/or Selectors.
+// where each is a tree of behaviours composed of Sequences, Selectors,
+// decorators like Inverters and Repeaters, and application leaf behaviours.
+// Application leaf behaviours can also be created from functions using
+// NewAction and NewCondition. For example:
+//
+//    bt := ai.NewBehaviourTree()
+//    board := bt.Blackboard()
+//    attack := ai.NewSequence(bt, []ai.Behaviour{
+//        ai.NewCondition(func() bool { return board.Bool("enemy") }),
+//        ai.NewAction(unit.shoot),
+//        ai.NewWait(bt, 0.5),
+//    })
+//    bt.Start(ai.NewRepeater(bt, attack, 0), nil)
+//    ...
+//    bt.Update(in.Dt) // once per App.Update.
 type BehaviourTree interface {
 
 	// Start processing behaviour b and associate its completion status
@@ -23,20 +37,32 @@ type BehaviourTree interface {
 	Start(b Behaviour, bo BehaviourObserver) // Process a behaviour.
 
 	// Stop informs the given behaviours observer of completion without
-	// waiting for the next update tick. The behaviour itself will be
-	// removed next update tick.
+	// waiting for the next update tick. The behaviour itself is
+	// removed so that its observer is only informed once.
 	Stop(b Behaviour) // Stop processing a behaviour
 
 	// Tick updates each active behaviour. Completed behaviours
 	// send notifications through their observers.
 	Tick() // Expected to be called each regular update cycle.
+
+	// Update records the elapsed time dt, in seconds, on the blackboard
+	// and then calls Tick. Expected to be called once per App.Update
+	// with the Input.Dt time delta.
+	Update(dt float64)
+
+	// Blackboard returns the data shared by the tree behaviours.
+	Blackboard() *Blackboard
 }
 
 // NewBehaviourTree creates an empty behaviour tree. It must be initialized
 // with behaviours using the Start method and then updated regularly using
-// the Tick method.
+// the Tick or Update methods.
 func NewBehaviourTree() BehaviourTree {
-	return &behaviourTree{behaviours: list.New()}
+	return &behaviourTree{
+		behaviours: list.New(),
+		queued:     map[Behaviour]*list.Element{},
+		board:      NewBlackboard(),
+	}
 }
 
 // =============================================================================
@@ -44,31 +70,54 @@ func NewBehaviourTree() BehaviourTree {
 // behaviourTree implements BehaviourTree.
 type behaviourTree struct {
 	behaviours *list.List
+	queued     map[Behaviour]*list.Element // Behaviours in the list.
+	board      *Blackboard                 // Shared behaviour data.
 }
 
+// Blackboard returns the shared behaviour data.
+func (bt *behaviourTree) Blackboard() *Blackboard { return bt.board }
+
 // Start pushes a behaviour onto the processing list and associates
 // it with the given observer.
 func (bt *behaviourTree) Start(b Behaviour, bo BehaviourObserver) {
 	if bo != nil {
 		b.SetObserver(bo)
 	}
-	bt.behaviours.PushFront(b)
+	bt.remove(b) // don't process a restarted behaviour twice.
+	bt.queued[b] = bt.behaviours.PushFront(b)
 }
 
 // Stop immediately propogrates completion status to the parent behaviour
-// observer. Completed behaviours will be removed from the tree next tick.
+// observer. Completed behaviours are removed from the tree.
 func (bt *behaviourTree) Stop(b Behaviour) {
 	status := b.Status()
 	if status != FAILURE && status != SUCCESS {
 		log.Printf("behaviourTree.Stop: status must be FAILURE or SUCCESS %d.", status)
 	}
 
-	// Inform the behaviour observer of the completion.
+	// Inform the behaviour observer of the completion. The behaviour
+	// is removed so that the observer is not informed again.
+	bt.remove(b)
 	if b.Observer() != nil {
 		b.Observer().Complete(b)
 	}
 }
 
+// remove takes the behaviour off the processing list.
+func (bt *behaviourTree) remove(b Behaviour) {
+	if elem, ok := bt.queued[b]; ok {
+		bt.behaviours.Remove(elem)
+		delete(bt.queued, b)
+	}
+}
+
+// Update tracks the elapsed time and updates all active behaviours.
+func (bt *behaviourTree) Update(dt float64) {
+	bt.board.Dt = dt
+	bt.board.Elapsed += dt
+	bt.Tick()
+}
+
 // Tick updates all active behaviours.
 func (bt *behaviourTree) Tick() {
 	bt.behaviours.PushBack(nil) // Nil marker for this update tick.
@@ -87,14 +136,17 @@ func (bt *behaviourTree) step() bool {
 		return false // Found the nil marker. This update tick is done.
 	}
 	behaviour := elem.Value.(Behaviour)
+	delete(bt.queued, behaviour)
 	tick(behaviour)
-	if behaviour.Status() != RUNNING && behaviour.Observer() != nil {
-		behaviour.Observer().Complete(behaviour)
+	if behaviour.Status() != RUNNING {
+		if behaviour.Observer() != nil {
+			behaviour.Observer().Complete(behaviour)
+		}
 	} else {
 
 		// Still running to put it back in the queue for processing next tick.
 		// It will be behind the nil marker.
-		bt.behaviours.PushBack(behaviour)
+		bt.queued[behaviour] = bt.behaviours.PushBack(behaviour)
 	}
 	return true
 }
@@ -222,3 +274,162 @@ func (sel *selector) Complete(b Behaviour) {
 	sel.current++ // Process next behaviour.
 	sel.bt.Start(sel.behaviours[sel.current], sel)
 }
+
+// =============================================================================
+// decorator is a Behaviour.
+
+// NewInverter creates a Behaviour that runs the given behaviour and
+// inverts its result. The inverter fails if its behaviour succeeds
+// and succeeds if its behaviour fails.
+func NewInverter(bt BehaviourTree, b Behaviour) Behaviour {
+	return &decorator{bt: bt, behaviour: b, done: func(d *decorator, status BehaviourState) BehaviourState {
+		if status == SUCCESS {
+			return FAILURE
+		}
+		return SUCCESS
+	}}
+}
+
+// NewSucceeder creates a Behaviour that runs the given behaviour
+// and then succeeds whether or not its behaviour succeeded.
+func NewSucceeder(bt BehaviourTree, b Behaviour) Behaviour {
+	return &decorator{bt: bt, behaviour: b, done: func(d *decorator, status BehaviourState) BehaviourState {
+		return SUCCESS
+	}}
+}
+
+// NewRepeater creates a Behaviour that runs the given behaviour count
+// times, starting it again on the update after it completes.
+// The repeater succeeds after count runs, or repeats forever when count
+// is 0. A repeater fails if its behaviour fails.
+func NewRepeater(bt BehaviourTree, b Behaviour, count int) Behaviour {
+	return &decorator{bt: bt, behaviour: b, done: func(d *decorator, status BehaviourState) BehaviourState {
+		d.runs++
+		switch {
+		case status == FAILURE:
+			return FAILURE
+		case count > 0 && d.runs >= count:
+			return SUCCESS
+		}
+		return RUNNING
+	}}
+}
+
+// NewUntilFail creates a Behaviour that keeps running the given
+// behaviour, starting it again on the update after it succeeds.
+// The decorator succeeds once its behaviour fails.
+func NewUntilFail(bt BehaviourTree, b Behaviour) Behaviour {
+	return &decorator{bt: bt, behaviour: b, done: func(d *decorator, status BehaviourState) BehaviourState {
+		if status == FAILURE {
+			return SUCCESS
+		}
+		return RUNNING
+	}}
+}
+
+// decorator implements Behaviours that control a single child Behaviour.
+type decorator struct {
+	BehaviourBase
+	bt        BehaviourTree // Injected on creation.
+	behaviour Behaviour     // Decorated behaviour.
+	runs      int           // Number of completed child runs.
+	restart   bool          // True to run the child again next update.
+
+	// done returns the decorator status for a completed child status.
+	// RUNNING means run the child behaviour again.
+	done func(d *decorator, status BehaviourState) BehaviourState
+}
+
+// A decorator is running while it is processing its child behaviour.
+func (d *decorator) Init() {
+	d.State = RUNNING
+	d.runs = 0
+	d.restart = false
+	d.bt.Start(d.behaviour, d)
+}
+
+// Update restarts the child behaviour when it is being repeated.
+// Restarting on the next update ensures that repeating quick
+// behaviours doesn't stall the current update.
+func (d *decorator) Update() (status BehaviourState) {
+	if d.State == RUNNING && d.restart {
+		d.restart = false
+		d.behaviour.Reset()
+		d.bt.Start(d.behaviour, d)
+	}
+	return d.State
+}
+func (d *decorator) Reset() {
+	d.State = INVALID
+	d.behaviour.Reset()
+}
+
+// Complete handles child completion through the BehaviourObserver interface.
+// Either the decorator completes or the child is run again.
+func (d *decorator) Complete(b Behaviour) {
+	if b.Status() != SUCCESS && b.Status() != FAILURE {
+		log.Printf("decorator.Complete: invalid completion status %d", b.Status())
+	}
+	if d.State = d.done(d, b.Status()); d.State == RUNNING {
+		d.restart = true
+		return
+	}
+	d.bt.Stop(d)
+}
+
+// =============================================================================
+// leaf is a Behaviour.
+
+// NewAction creates a leaf Behaviour from an application function.
+// The function is called each update and returns RUNNING until the
+// action is complete, then SUCCESS or FAILURE.
+func NewAction(update func() BehaviourState) Behaviour {
+	return &leaf{update: update}
+}
+
+// NewCondition creates a leaf Behaviour that succeeds if the
+// given test returns true and fails otherwise. Conditions are
+// often the first behaviour of a sequence, guarding the rest
+// of the sequence.
+func NewCondition(test func() bool) Behaviour {
+	return &leaf{update: func() BehaviourState {
+		if test() {
+			return SUCCESS
+		}
+		return FAILURE
+	}}
+}
+
+// NewWait creates a leaf Behaviour that succeeds once the given number
+// of seconds have passed. Time is tracked by BehaviourTree.Update.
+func NewWait(bt BehaviourTree, seconds float64) Behaviour {
+	w := &leaf{}
+	w.init = func() { w.start = bt.Blackboard().Elapsed }
+	w.update = func() BehaviourState {
+		if bt.Blackboard().Elapsed-w.start >= seconds {
+			return SUCCESS
+		}
+		return RUNNING
+	}
+	return w
+}
+
+// leaf implements Behaviours using functions.
+type leaf struct {
+	BehaviourBase
+	init   func()                // Optional initialization.
+	update func() BehaviourState // Called each update.
+	start  float64               // Elapsed time when initialized.
+}
+
+// A leaf is running until its update function completes.
+func (l *leaf) Init() {
+	l.State = RUNNING
+	if l.init != nil {
+		l.init()
+	}
+}
+func (l *leaf) Update() (status BehaviourState) {
+	l.State = l.update()
+	return l.State
+}
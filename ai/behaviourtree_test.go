@@ -148,6 +148,105 @@ func TestSequenceSelector(t *testing.T) {
 	}
 }
 
+// A sequence nested in a sequence only completes once.
+func TestSequenceNested(t *testing.T) {
+	mo.status = INVALID
+	bt := NewBehaviourTree()
+	last := &mockBehaviour{stopat: 1, finalStatus: SUCCESS}
+	inner := NewSequence(bt, []Behaviour{&mockBehaviour{stopat: 1, finalStatus: SUCCESS}})
+	outer := NewSequence(bt, []Behaviour{inner, last, &mockBehaviour{stopat: 2, finalStatus: SUCCESS}})
+	bt.Start(outer, mo)
+	bt.Tick()
+	if mo.status != INVALID || last.counter != 1 {
+		t.Errorf("Expected running sequence, got %d %d", mo.status, last.counter)
+	}
+	bt.Tick()
+	if mo.status != SUCCESS || last.counter != 1 {
+		t.Errorf("Expected %d got %d %d", SUCCESS, mo.status, last.counter)
+	}
+}
+
+func TestInverter(t *testing.T) {
+	mo.status = INVALID
+	bt := NewBehaviourTree()
+	bt.Start(NewInverter(bt, &mockBehaviour{stopat: 1, finalStatus: SUCCESS}), mo)
+	bt.Tick()
+	if mo.status != FAILURE {
+		t.Errorf("Expected %d got %d", FAILURE, mo.status)
+	}
+	mo.status = INVALID
+	bt.Start(NewSucceeder(bt, &mockBehaviour{stopat: 1, finalStatus: FAILURE}), mo)
+	bt.Tick()
+	if mo.status != SUCCESS {
+		t.Errorf("Expected %d got %d", SUCCESS, mo.status)
+	}
+}
+
+// Repeated behaviours are started again each update.
+func TestRepeater(t *testing.T) {
+	mo.status = INVALID
+	bt := NewBehaviourTree()
+	count := 0
+	action := NewAction(func() BehaviourState { count++; return SUCCESS })
+	bt.Start(NewRepeater(bt, action, 3), mo)
+	for cnt := 0; cnt < 5; cnt++ {
+		bt.Tick()
+	}
+	if mo.status != SUCCESS || count != 3 {
+		t.Errorf("Expected %d got %d after %d runs", SUCCESS, mo.status, count)
+	}
+
+	// repeat until the condition fails.
+	mo.status = INVALID
+	bt.Blackboard().Set("runs", 0)
+	check := NewCondition(func() bool {
+		runs := bt.Blackboard().Int("runs") + 1
+		bt.Blackboard().Set("runs", runs)
+		return runs < 4
+	})
+	bt.Start(NewUntilFail(bt, check), mo)
+	for cnt := 0; cnt < 3; cnt++ {
+		bt.Tick()
+	}
+	if mo.status != INVALID {
+		t.Errorf("Expected running repeater, got %d", mo.status)
+	}
+	bt.Tick()
+	if mo.status != SUCCESS || bt.Blackboard().Int("runs") != 4 {
+		t.Errorf("Expected %d got %d", SUCCESS, mo.status)
+	}
+}
+
+// Waits complete after the time passed to the tree updates.
+func TestWait(t *testing.T) {
+	mo.status = INVALID
+	bt := NewBehaviourTree()
+	bt.Start(NewSequence(bt, []Behaviour{NewWait(bt, 0.25), NewWait(bt, 0.25)}), mo)
+	for cnt := 0; cnt < 6; cnt++ {
+		bt.Update(0.1)
+	}
+	if mo.status != INVALID {
+		t.Errorf("Expected waiting sequence, got %d", mo.status)
+	}
+	bt.Update(0.1)
+	if mo.status != SUCCESS || bt.Blackboard().Dt != 0.1 {
+		t.Errorf("Expected %d got %d", SUCCESS, mo.status)
+	}
+}
+
+func TestBlackboard(t *testing.T) {
+	bb := NewBlackboard()
+	bb.Set("alert", true)
+	bb.Set("speed", 2.5)
+	if !bb.Bool("alert") || bb.Float("speed") != 2.5 || bb.Int("speed") != 0 {
+		t.Errorf("Expected blackboard values")
+	}
+	bb.Delete("alert")
+	if _, ok := bb.Get("alert"); ok || bb.Bool("alert") {
+		t.Errorf("Expected deleted blackboard value")
+	}
+}
+
 // =============================================================================
 // Utility methods.
 
@@ -0,0 +1,54 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package ai
+
+// Blackboard holds the data shared by the behaviours of a behaviour tree.
+// Behaviours use the blackboard to pass information to each other
+// instead of referencing each other directly, ie: one behaviour sets
+// the current target and another behaviour moves towards it.
+// The blackboard also tracks the time passed in by BehaviourTree.Update.
+type Blackboard struct {
+	Dt      float64 // Seconds since the last update.
+	Elapsed float64 // Total seconds of all updates.
+	values  map[string]interface{}
+}
+
+// NewBlackboard creates an empty blackboard.
+func NewBlackboard() *Blackboard {
+	return &Blackboard{values: map[string]interface{}{}}
+}
+
+// Set saves a value under the given key, replacing any previous value.
+func (bb *Blackboard) Set(key string, value interface{}) { bb.values[key] = value }
+
+// Get returns the value for the given key. False is returned if
+// there is no value for the key.
+func (bb *Blackboard) Get(key string) (value interface{}, ok bool) {
+	value, ok = bb.values[key]
+	return value, ok
+}
+
+// Delete removes the value for the given key.
+func (bb *Blackboard) Delete(key string) { delete(bb.values, key) }
+
+// Bool returns the bool value for the given key. False is returned
+// if there is no value or the value is not a bool.
+func (bb *Blackboard) Bool(key string) bool {
+	value, _ := bb.values[key].(bool)
+	return value
+}
+
+// Int returns the int value for the given key. 0 is returned
+// if there is no value or the value is not an int.
+func (bb *Blackboard) Int(key string) int {
+	value, _ := bb.values[key].(int)
+	return value
+}
+
+// Float returns the float64 value for the given key. 0 is returned
+// if there is no value or the value is not a float64.
+func (bb *Blackboard) Float(key string) float64 {
+	value, _ := bb.values[key].(float64)
+	return value
+}
@@ -0,0 +1,506 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package ai
+
+// crowd keeps agents from running into each other using optimal
+// reciprocal collision avoidance (ORCA). See:
+//     http://gamma.cs.unc.edu/ORCA/
+//     https://github.com/snape/RVO2
+//
+// Design Notes:
+//   • Each agent wants to move at its preferred velocity, ie: towards the
+//     next corner of its path. Each neighbour limits the agent velocities
+//     to one side of a line so that the two agents won't collide within
+//     the time horizon, assuming both share the responsibility. Agents
+//     closer to their next corner take less of the responsibility so
+//     that agents take turns going through doorways.
+//   • The new velocity is the velocity closest to the preferred velocity
+//     that satisfies all the lines. When the lines can't all be satisfied,
+//     ie: in dense crowds, the velocity that least violates them is used.
+//   • Walls are treated as unmoving neighbours at their closest points
+//     and are always satisfied before agents.
+//   • Neighbours are found using a grid of buckets so that agents only
+//     check the agents around them.
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Crowd moves groups of agents so that they don't run into each other
+// or walls. Agents are moved on the X,Z plane, ie: along paths found
+// by NavMesh.FindPath. Agent heights are left to the application.
+// For example:
+//    crowd := ai.NewCrowd(ai.NewCrowdConfig())
+//    id := crowd.Add(&unit.At, 0.6, 3)
+//    crowd.Follow(id, nav.FindPath(&unit.At, &target.At))
+//    ...
+//    crowd.Step(in.Dt) // once per App.Update.
+//    at, _ := crowd.Agent(id)
+type Crowd interface {
+
+	// Add an agent at the given location with the given radius and
+	// top speed. Returns the agent identifier.
+	Add(at *lin.V3, radius, speed float64) (id int)
+	Remove(id int) // Remove an agent. The identifier is not reused.
+
+	// Wall adds a wall from a to b that agents move around.
+	Wall(a, b *lin.V3)
+
+	// Follow moves the agent along the given path, ie: the corner
+	// points of a path found by a NavMesh. The agent stops at the
+	// last point.
+	Follow(id int, path []lin.V3)
+
+	// Steer sets the preferred velocity for the agent, replacing
+	// any path the agent was following.
+	Steer(id int, velocity *lin.V3)
+
+	// Step moves all the agents for the given elapsed time in seconds.
+	Step(dt float64) // Expected to be called each regular update cycle.
+
+	// Agent returns the agent location and velocity.
+	Agent(id int) (at, velocity lin.V3)
+
+	// Arrived returns true if the agent has reached the end of its path.
+	Arrived(id int) bool
+}
+
+// CrowdConfig controls how far ahead agents look to avoid each other.
+type CrowdConfig struct {
+	Horizon     float64 // Seconds ahead to avoid agents. Default 2.
+	WallHorizon float64 // Seconds ahead to avoid walls. Default 1.
+	Neighbours  int     // Most agents avoided by each agent. Default 10.
+}
+
+// NewCrowdConfig returns the configuration for a crowd of people.
+func NewCrowdConfig() *CrowdConfig {
+	return &CrowdConfig{Horizon: 2, WallHorizon: 1, Neighbours: 10}
+}
+
+// NewCrowd creates a crowd without any agents.
+func NewCrowd(c *CrowdConfig) Crowd { return &crowd{config: *c, buckets: map[[2]int][]int{}} }
+
+// =============================================================================
+
+// crowd implements Crowd.
+type crowd struct {
+	config  CrowdConfig
+	agents  []*agent         // Removed agents are nil.
+	walls   [][2]v2          // Wall end points.
+	buckets map[[2]int][]int // Agents by grid location.
+	size    float64          // Bucket width and depth.
+	lines   []orcaLine       // Scratch for velocity constraints.
+}
+
+// agent is one member of the crowd.
+type agent struct {
+	at, velocity v2      // Current location and velocity.
+	prefer       v2      // Preferred velocity.
+	y            float64 // Height is kept as is.
+	radius       float64 // Agent size.
+	speed        float64 // Agent top speed.
+	path         []v2    // Path points.
+	corner       int     // Path point being moved towards.
+	arrived      bool    // True once the path end is reached.
+	next         v2      // New velocity for this step.
+}
+
+// Add creates a new agent.
+func (c *crowd) Add(at *lin.V3, radius, speed float64) (id int) {
+	c.agents = append(c.agents, &agent{at: v2{at.X, at.Z}, y: at.Y, radius: radius, speed: speed, arrived: true})
+	return len(c.agents) - 1
+}
+
+// Remove discards the given agent.
+func (c *crowd) Remove(id int) {
+	if id >= 0 && id < len(c.agents) {
+		c.agents[id] = nil
+	}
+}
+
+// Wall adds a wall segment.
+func (c *crowd) Wall(a, b *lin.V3) { c.walls = append(c.walls, [2]v2{{a.X, a.Z}, {b.X, b.Z}}) }
+
+// Follow sets the agent path.
+func (c *crowd) Follow(id int, path []lin.V3) {
+	if a := c.agent(id); a != nil {
+		a.path, a.corner = nil, 0
+		for _, p := range path {
+			a.path = append(a.path, v2{p.X, p.Z})
+		}
+		a.arrived = len(a.path) == 0
+	}
+}
+
+// Steer sets the agent preferred velocity.
+func (c *crowd) Steer(id int, velocity *lin.V3) {
+	if a := c.agent(id); a != nil {
+		a.path, a.arrived = nil, true
+		a.prefer = v2{velocity.X, velocity.Z}
+	}
+}
+
+// Agent returns the agent location and velocity.
+func (c *crowd) Agent(id int) (at, velocity lin.V3) {
+	if a := c.agent(id); a != nil {
+		return lin.V3{X: a.at.x, Y: a.y, Z: a.at.z}, lin.V3{X: a.velocity.x, Z: a.velocity.z}
+	}
+	return at, velocity
+}
+
+// Arrived returns true if the agent is not following a path.
+func (c *crowd) Arrived(id int) bool {
+	a := c.agent(id)
+	return a == nil || a.arrived
+}
+
+// agent returns the agent for the given identifier, or nil.
+func (c *crowd) agent(id int) *agent {
+	if id < 0 || id >= len(c.agents) {
+		return nil
+	}
+	return c.agents[id]
+}
+
+// Step calculates new velocities for all agents and then
+// moves the agents.
+func (c *crowd) Step(dt float64) {
+	if dt <= 0 {
+		return
+	}
+	c.bucket()
+	for id, a := range c.agents {
+		if a != nil {
+			c.steer(a, dt)
+			a.next = c.avoid(id, a, dt)
+		}
+	}
+	for _, a := range c.agents {
+		if a != nil {
+			a.velocity = a.next
+			a.at = a.at.add(a.velocity.scale(dt))
+		}
+	}
+}
+
+// steer sets the preferred velocity towards the next path point,
+// slowing down to stop at the last point. Agents that are pushed away
+// from the last point move back to it. Agents that are pushed back
+// around a corner, ie: in a crowded doorway, return to the corner.
+func (c *crowd) steer(a *agent, dt float64) {
+	if len(a.path) == 0 {
+		return // steered by the application.
+	}
+	last := len(a.path) - 1
+	for a.corner < last && a.path[a.corner].sub(a.at).len() < a.radius && c.visible(a.at, a.path[a.corner+1]) {
+		a.corner++ // close enough to turn the corner.
+	}
+	if a.corner > 0 && !c.visible(a.at, a.path[a.corner]) {
+		a.corner-- // pushed back around the corner.
+	}
+	to := a.path[a.corner].sub(a.at)
+	dist := to.len()
+	switch {
+	case a.corner == last && dist < a.radius*0.05:
+		a.arrived, a.prefer = true, v2{} // stay at the end of the path.
+	case a.corner == last && dist < a.speed*dt:
+		a.prefer = to.scale(1 / dt) // arrive this step.
+	default:
+		a.prefer = to.scale(a.speed / dist)
+	}
+
+	// turning slightly right breaks ties between agents that are
+	// exactly facing each other.
+	sin, cos := math.Sincos(-0.01)
+	a.prefer = v2{a.prefer.x*cos - a.prefer.z*sin, a.prefer.x*sin + a.prefer.z*cos}
+}
+
+// visible returns true if there are no walls between a and b.
+func (c *crowd) visible(a, b v2) bool {
+	for _, w := range c.walls {
+		ab, ww := b.sub(a), w[1].sub(w[0])
+		if denom := ab.det(ww); denom != 0 {
+			s := w[0].sub(a).det(ww) / denom // along a, b.
+			t := w[0].sub(a).det(ab) / denom // along the wall.
+			if s >= 0 && s <= 1 && t >= 0 && t <= 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// bucket sorts the agents into grid buckets that are large enough
+// that agents only need to check neighbouring buckets.
+func (c *crowd) bucket() {
+	radius, speed := 0.0, 0.0
+	for _, a := range c.agents {
+		if a != nil {
+			radius, speed = math.Max(radius, a.radius), math.Max(speed, a.speed)
+		}
+	}
+	c.size = math.Max(2*speed*c.config.Horizon+2*radius, 1e-3)
+	for key := range c.buckets {
+		delete(c.buckets, key)
+	}
+	for id, a := range c.agents {
+		if a != nil {
+			key := c.key(a.at)
+			c.buckets[key] = append(c.buckets[key], id)
+		}
+	}
+}
+
+// key returns the bucket for the given location.
+func (c *crowd) key(p v2) [2]int {
+	return [2]int{int(math.Floor(p.x / c.size)), int(math.Floor(p.z / c.size))}
+}
+
+// avoid returns the new velocity for agent a. The velocity is limited
+// by one constraint line for each nearby wall and agent.
+func (c *crowd) avoid(id int, a *agent, dt float64) v2 {
+	c.lines = c.lines[:0]
+
+	// walls are avoided using their closest points. Agents already
+	// touching a wall can't move further into the wall.
+	reach := a.speed*c.config.WallHorizon + a.radius
+	for _, w := range c.walls {
+		p := closestOnSegment(w[0], w[1], a.at)
+		switch d := p.sub(a.at); {
+		case d.len() > 0 && d.len() < a.radius:
+			c.lines = append(c.lines, orcaLine{dir: v2{-d.z, d.x}.unit()})
+		case d.len() < reach:
+			c.lines = append(c.lines, orca(a, d, a.velocity, a.radius, 1/c.config.WallHorizon, dt, 1))
+		}
+	}
+	walls := len(c.lines)
+
+	// agents are avoided starting with the closest.
+	type near struct {
+		id   int
+		dist float64
+	}
+	neighbours := []near{}
+	k := c.key(a.at)
+	for x := k[0] - 1; x <= k[0]+1; x++ {
+		for z := k[1] - 1; z <= k[1]+1; z++ {
+			for _, other := range c.buckets[[2]int{x, z}] {
+				if other != id {
+					dist := c.agents[other].at.sub(a.at).len()
+					if dist < c.size {
+						neighbours = append(neighbours, near{other, dist})
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(neighbours, func(i, j int) bool {
+		ni, nj := neighbours[i], neighbours[j]
+		return ni.dist < nj.dist || (ni.dist == nj.dist && ni.id < nj.id)
+	})
+	if len(neighbours) > c.config.Neighbours {
+		neighbours = neighbours[:c.config.Neighbours]
+	}
+	for _, n := range neighbours {
+		b := c.agents[n.id]
+		c.lines = append(c.lines, orca(a, b.at.sub(a.at), a.velocity.sub(b.velocity), a.radius+b.radius, 1/c.config.Horizon, dt, share(a, b)))
+	}
+
+	// find the allowed velocity closest to the preferred velocity.
+	velocity, failed := solve2(c.lines, a.speed, a.prefer, false)
+	if failed < len(c.lines) {
+		velocity = solve3(c.lines, walls, failed, a.speed, velocity)
+	}
+	return velocity
+}
+
+// share returns the part of the responsibility agent a takes for
+// avoiding agent b. Agents closer to the corner they are moving
+// towards get to go first, so that agents going opposite ways take
+// turns at doorways instead of blocking each other.
+func share(a, b *agent) float64 {
+	da, db := a.toCorner(), b.toCorner()
+	switch {
+	case da < db:
+		return 0.25
+	case db < da:
+		return 0.75
+	}
+	return 0.5
+}
+
+// toCorner returns the distance to the path point that the
+// agent is moving towards.
+func (a *agent) toCorner() float64 {
+	if len(a.path) == 0 {
+		return math.Inf(1)
+	}
+	return a.path[a.corner].sub(a.at).len()
+}
+
+// =============================================================================
+
+// v2 is a location or velocity on the X,Z plane.
+type v2 struct{ x, z float64 }
+
+func (a v2) add(b v2) v2             { return v2{a.x + b.x, a.z + b.z} }
+func (a v2) sub(b v2) v2             { return v2{a.x - b.x, a.z - b.z} }
+func (a v2) scale(s float64) v2      { return v2{a.x * s, a.z * s} }
+func (a v2) dot(b v2) float64        { return a.x*b.x + a.z*b.z }
+func (a v2) det(b v2) float64        { return a.x*b.z - a.z*b.x }
+func (a v2) len() float64            { return math.Hypot(a.x, a.z) }
+func (a v2) unit() v2                { return a.scale(1 / a.len()) }
+func closestOnSegment(a, b, p v2) v2 { return a.add(b.sub(a).scale(segmentT(a, b, p))) }
+
+// segmentT returns how far along segment a, b the point closest to p is.
+func segmentT(a, b, p v2) float64 {
+	ab := b.sub(a)
+	if lsq := ab.dot(ab); lsq > 0 {
+		return math.Max(0, math.Min(1, p.sub(a).dot(ab)/lsq))
+	}
+	return 0
+}
+
+// orcaLine allows velocities on the left side of the line.
+type orcaLine struct {
+	point, dir v2
+}
+
+// orca returns the constraint line that avoids an obstacle that is at
+// relative location at and moving at relative velocity rv. The agent
+// takes the given share of the responsibility for avoiding the obstacle:
+// 1 for walls and half for other agents. The inverse time horizon
+// limits how far ahead collisions are avoided.
+func orca(a *agent, at, rv v2, radius, invHorizon, dt, share float64) orcaLine {
+	distSq, rsq := at.dot(at), radius*radius
+	var dir, u v2
+	if distSq > rsq {
+		w := rv.sub(at.scale(invHorizon)) // from cut-off circle center to rv.
+		wlen := w.len()
+		if dot := w.dot(at); dot < 0 && dot*dot > rsq*wlen*wlen {
+			unit := w.scale(1 / wlen) // project on the cut-off circle.
+			dir = v2{unit.z, -unit.x}
+			u = unit.scale(radius*invHorizon - wlen)
+		} else {
+			leg := math.Sqrt(distSq - rsq) // project on the closest leg.
+			if at.det(w) > 0 {
+				dir = v2{at.x*leg - at.z*radius, at.x*radius + at.z*leg}.scale(1 / distSq)
+			} else {
+				dir = v2{at.x*leg + at.z*radius, -at.x*radius + at.z*leg}.scale(-1 / distSq)
+			}
+			u = dir.scale(rv.dot(dir)).sub(rv)
+		}
+	} else {
+		// already overlapping: separate the agents this step.
+		w := rv.sub(at.scale(1 / dt))
+		wlen := math.Max(w.len(), 1e-9)
+		unit := w.scale(1 / wlen)
+		dir = v2{unit.z, -unit.x}
+		u = unit.scale(radius/dt - wlen)
+	}
+	return orcaLine{point: a.velocity.add(u.scale(share)), dir: dir}
+}
+
+// solve1 finds the velocity on line lines[n] closest to prefer that
+// satisfies the earlier lines and the speed limit. When optimizing for
+// a direction, the velocity furthest along prefer is found instead.
+func solve1(lines []orcaLine, n int, speed float64, prefer v2, direction bool) (v v2, ok bool) {
+	l := lines[n]
+	dot := l.point.dot(l.dir)
+	disc := dot*dot + speed*speed - l.point.dot(l.point)
+	if disc < 0 {
+		return v, false // speed limit circle misses the line.
+	}
+	tleft, tright := -dot-math.Sqrt(disc), -dot+math.Sqrt(disc)
+	for _, prev := range lines[:n] {
+		denom := l.dir.det(prev.dir)
+		numer := prev.dir.det(l.point.sub(prev.point))
+		if math.Abs(denom) <= 1e-9 {
+			if numer < 0 {
+				return v, false // parallel lines facing away.
+			}
+			continue
+		}
+		t := numer / denom
+		if denom >= 0 {
+			tright = math.Min(tright, t)
+		} else {
+			tleft = math.Max(tleft, t)
+		}
+		if tleft > tright {
+			return v, false
+		}
+	}
+	t := l.dir.dot(prefer.sub(l.point))
+	switch {
+	case direction && prefer.dot(l.dir) > 0, !direction && t > tright:
+		t = tright
+	case direction, t < tleft:
+		t = tleft
+	}
+	return l.point.add(l.dir.scale(t)), true
+}
+
+// solve2 finds the velocity closest to prefer that satisfies all the
+// lines and the speed limit. Returns the index of the first line that
+// can't be satisfied, or len(lines) if all the lines are satisfied.
+func solve2(lines []orcaLine, speed float64, prefer v2, direction bool) (v v2, failed int) {
+	switch {
+	case direction:
+		v = prefer.scale(speed) // prefer is a unit direction.
+	case prefer.dot(prefer) > speed*speed:
+		v = prefer.unit().scale(speed)
+	default:
+		v = prefer
+	}
+	for cnt, l := range lines {
+		if l.dir.det(l.point.sub(v)) > 0 {
+			next, ok := solve1(lines, cnt, speed, prefer, direction)
+			if !ok {
+				return v, cnt
+			}
+			v = next
+		}
+	}
+	return v, len(lines)
+}
+
+// solve3 finds the velocity that least violates the agent lines when
+// they can't all be satisfied. Wall lines are always satisfied, so
+// solving starts after the walls even when a wall line failed.
+func solve3(lines []orcaLine, walls, begin int, speed float64, v v2) v2 {
+	distance := 0.0
+	if begin < walls {
+		begin = walls
+	}
+	for cnt := begin; cnt < len(lines); cnt++ {
+		l := lines[cnt]
+		if l.dir.det(l.point.sub(v)) <= distance {
+			continue
+		}
+		projected := append([]orcaLine{}, lines[:walls]...)
+		for _, prev := range lines[walls:cnt] {
+			var p orcaLine
+			det := l.dir.det(prev.dir)
+			if math.Abs(det) <= 1e-9 {
+				if l.dir.dot(prev.dir) > 0 {
+					continue // same direction.
+				}
+				p.point = l.point.add(prev.point).scale(0.5)
+			} else {
+				p.point = l.point.add(l.dir.scale(prev.dir.det(l.point.sub(prev.point)) / det))
+			}
+			p.dir = prev.dir.sub(l.dir).unit()
+			projected = append(projected, p)
+		}
+		if next, failed := solve2(projected, speed, v2{-l.dir.z, l.dir.x}, true); failed == len(projected) {
+			v = next
+		}
+		distance = l.dir.det(l.point.sub(v))
+	}
+	return v
+}
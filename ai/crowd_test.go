@@ -0,0 +1,82 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package ai
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Agents walking straight at each other pass without touching.
+func TestCrowdSwap(t *testing.T) {
+	c := NewCrowd(NewCrowdConfig())
+	a, b := &lin.V3{X: 0, Z: 0}, &lin.V3{X: 10, Z: 0}
+	ida, idb := c.Add(a, 0.5, 2), c.Add(b, 0.5, 2)
+	c.Follow(ida, []lin.V3{*b})
+	c.Follow(idb, []lin.V3{*a})
+	for step := 0; step < 200; step++ {
+		c.Step(0.1)
+		pa, _ := c.Agent(ida)
+		pb, _ := c.Agent(idb)
+		if pa.Dist(&pb) < 0.99 {
+			t.Fatalf("Expected agents to stay apart %v %v", pa, pb)
+		}
+	}
+	pa, _ := c.Agent(ida)
+	if !c.Arrived(ida) || !c.Arrived(idb) || pa.Dist(b) > 0.05 {
+		t.Errorf("Expected agents to swap places %v", pa)
+	}
+}
+
+// An agent touching a wall and walking into it stays out of the wall.
+func TestCrowdTouchWall(t *testing.T) {
+	c := NewCrowd(NewCrowdConfig())
+	c.Wall(&lin.V3{X: -5}, &lin.V3{X: 5})
+	id := c.Add(&lin.V3{Z: 0.2}, 0.5, 1)
+	c.Follow(id, []lin.V3{{Z: -5}})
+	for step := 0; step < 20; step++ {
+		c.Step(0.1)
+	}
+	if at, _ := c.Agent(id); at.Z < 0 {
+		t.Errorf("Expected agent to stay out of the wall %v", at)
+	}
+}
+
+// Groups going opposite ways through a doorway take turns
+// instead of getting stuck or going through the wall.
+func TestCrowdDoorway(t *testing.T) {
+	c := NewCrowd(NewCrowdConfig())
+	c.Wall(&lin.V3{X: 5, Z: -10}, &lin.V3{X: 5, Z: -0.8})
+	c.Wall(&lin.V3{X: 5, Z: 0.8}, &lin.V3{X: 5, Z: 10})
+	door := lin.V3{X: 5}
+	ids := []int{}
+	for cnt := 0; cnt < 3; cnt++ {
+		z := float64(cnt-1) * 1.5
+		left := c.Add(&lin.V3{X: 1, Z: z}, 0.3, 1.5)
+		c.Follow(left, []lin.V3{door, {X: 9, Z: z}})
+		right := c.Add(&lin.V3{X: 9, Z: z}, 0.3, 1.5)
+		c.Follow(right, []lin.V3{door, {X: 1, Z: z}})
+		ids = append(ids, left, right)
+	}
+	for step := 0; step < 400; step++ {
+		c.Step(0.05)
+		for i, a := range ids {
+			pa, _ := c.Agent(a)
+			if pa.X > 4.8 && pa.X < 5.2 && (pa.Z < -0.6 || pa.Z > 0.6) {
+				t.Fatalf("Expected agent to stay out of the wall %v", pa)
+			}
+			for _, b := range ids[i+1:] {
+				if pb, _ := c.Agent(b); pa.Dist(&pb) < 0.55 {
+					t.Fatalf("Expected agents to stay apart %v %v", pa, pb)
+				}
+			}
+		}
+	}
+	for _, id := range ids {
+		if at, _ := c.Agent(id); !c.Arrived(id) {
+			t.Errorf("Expected agent %d to get through the door %v", id, at)
+		}
+	}
+}
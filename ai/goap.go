@@ -0,0 +1,210 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package ai
+
+// Goal oriented action planning (GOAP) is based on the planner used in
+// the game F.E.A.R. Actions are searched with A* to find the cheapest
+// sequence of actions that changes the current world state into one
+// that meets a goal. See:
+//    http://alumni.media.mit.edu/~jorkin/goap.html
+//    http://alumni.media.mit.edu/~jorkin/gdc2006_orkin_jeff_fear.pdf
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+)
+
+// State is a set of named world facts, ie: "hasWeapon", that are either
+// true or false. Facts that are not in the state are false.
+type State map[string]bool
+
+// Meets returns true if every fact in goal has the same value in s.
+func (s State) Meets(goal State) bool {
+	for fact, value := range goal {
+		if s[fact] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// unmet returns the number of facts in goal that differ in s.
+func (s State) unmet(goal State) (count int) {
+	for fact, value := range goal {
+		if s[fact] != value {
+			count++
+		}
+	}
+	return count
+}
+
+// apply returns a new state that is s changed by the given effects.
+func (s State) apply(effects State) State {
+	next := State{}
+	for fact, value := range s {
+		if value {
+			next[fact] = true
+		}
+	}
+	for fact, value := range effects {
+		if value {
+			next[fact] = true
+		} else {
+			delete(next, fact)
+		}
+	}
+	return next
+}
+
+// key returns a unique id for the state. States with the same true
+// facts have the same key.
+func (s State) key() string {
+	facts := []string{}
+	for fact, value := range s {
+		if value {
+			facts = append(facts, fact)
+		}
+	}
+	sort.Strings(facts)
+	return strings.Join(facts, "\x00")
+}
+
+// Action is something a unit can do. An action can be done when the
+// world state meets its preconditions, and changes the world state
+// with its effects.
+type Action struct {
+	Name    string  // Identifies the action, ie: "reload".
+	Cost    float64 // Expected cost to perform the action. Must be positive.
+	Pre     State   // Facts needed for the action.
+	Effects State   // Facts changed by the action.
+}
+
+// Planner finds the actions needed to reach a goal. It is an alternative
+// to BehaviourTree for units that need to react to changing situations
+// without having every behaviour laid out ahead of time. A plan is found
+// when a decision is needed and the actions in the plan are performed
+// until the plan is done or the world changes enough to need a new plan.
+type Planner interface {
+	Add(actions ...*Action) // Add possible actions.
+
+	// Plan returns the cheapest list of actions that change start into
+	// a state that meets goal. An empty plan is returned if start already
+	// meets the goal. Nil is returned if there is no plan.
+	Plan(start, goal State) []*Action
+}
+
+// NewPlanner creates a planner for the given actions.
+func NewPlanner(actions ...*Action) Planner {
+	p := &planner{}
+	p.Add(actions...)
+	return p
+}
+
+// =============================================================================
+
+// planner is the default implementation of Planner.
+type planner struct {
+	actions []*Action
+}
+
+// planLimit is the maximum number of states searched when looking for
+// a plan. This stops unreachable goals from searching forever.
+const planLimit = 10000
+
+// Add implements Planner.
+func (p *planner) Add(actions ...*Action) { p.actions = append(p.actions, actions...) }
+
+// Plan implements Planner. The A* estimate is the fewest actions that
+// could change the unmet goal facts times the cheapest action cost.
+// It never overestimates so the cheapest plan is always found.
+func (p *planner) Plan(start, goal State) []*Action {
+	type visit struct {
+		state  State
+		cost   float64
+		from   string  // Previous state key.
+		action *Action // Action taken from the previous state.
+		closed bool
+	}
+	estimate := p.estimator(goal)
+	from := start.apply(nil)
+	sk := from.key()
+	visits := map[string]*visit{sk: {state: from}}
+	open := &planQueue{{key: sk, estimate: estimate(from)}}
+	for searched := 0; open.Len() > 0 && searched < planLimit; searched++ {
+		current := heap.Pop(open).(planEstimate).key
+		v := visits[current]
+		if v.closed {
+			continue
+		}
+		v.closed = true
+		if v.state.Meets(goal) {
+			plan := []*Action{}
+			for at := current; at != sk; at = visits[at].from {
+				plan = append(plan, visits[at].action)
+			}
+			for i, j := 0, len(plan)-1; i < j; i, j = i+1, j-1 {
+				plan[i], plan[j] = plan[j], plan[i]
+			}
+			return plan
+		}
+		for _, a := range p.actions {
+			if !v.state.Meets(a.Pre) {
+				continue
+			}
+			next := v.state.apply(a.Effects)
+			nk, cost := next.key(), v.cost+a.Cost
+			if n, ok := visits[nk]; ok && (n.closed || n.cost <= cost) {
+				continue
+			}
+			visits[nk] = &visit{state: next, cost: cost, from: current, action: a}
+			heap.Push(open, planEstimate{key: nk, estimate: cost + estimate(next)})
+		}
+	}
+	return nil
+}
+
+// estimator returns the A* estimate of the remaining plan cost from a
+// state to the goal. An action changes at most the facts in its effects,
+// so the unmet goal facts need at least unmet/most actions, each costing
+// at least the cheapest action.
+func (p *planner) estimator(goal State) func(s State) float64 {
+	most, cheapest := 0, 0.0
+	for i, a := range p.actions {
+		if len(a.Effects) > most {
+			most = len(a.Effects)
+		}
+		if i == 0 || a.Cost < cheapest {
+			cheapest = a.Cost
+		}
+	}
+	return func(s State) float64 {
+		if most == 0 || cheapest <= 0 {
+			return 0
+		}
+		unmet := s.unmet(goal)
+		return float64((unmet+most-1)/most) * cheapest
+	}
+}
+
+// planEstimate is a world state and its estimated plan cost.
+type planEstimate struct {
+	key      string
+	estimate float64
+}
+
+// planQueue is a lowest estimate first priority queue.
+type planQueue []planEstimate
+
+// Implement heap.Interface.
+func (q planQueue) Len() int            { return len(q) }
+func (q planQueue) Less(i, j int) bool  { return q[i].estimate < q[j].estimate }
+func (q planQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *planQueue) Push(x interface{}) { *q = append(*q, x.(planEstimate)) }
+func (q *planQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,71 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package ai
+
+import (
+	"testing"
+)
+
+// soldier actions for planning an attack.
+func soldier() Planner {
+	return NewPlanner(
+		&Action{Name: "getGun", Cost: 2, Pre: State{"nearGun": true}, Effects: State{"hasGun": true}},
+		&Action{Name: "gotoGun", Cost: 1, Effects: State{"nearGun": true}},
+		&Action{Name: "load", Cost: 1, Pre: State{"hasGun": true}, Effects: State{"loaded": true}},
+		&Action{Name: "shoot", Cost: 1, Pre: State{"loaded": true}, Effects: State{"targetDead": true, "loaded": false}},
+		&Action{Name: "stab", Cost: 3, Pre: State{"hasKnife": true}, Effects: State{"targetDead": true}},
+	)
+}
+
+// names returns the names of the planned actions.
+func names(plan []*Action) (s string) {
+	for _, a := range plan {
+		s += a.Name + " "
+	}
+	return s
+}
+
+func TestPlan(t *testing.T) {
+	p, goal := soldier(), State{"targetDead": true}
+	if plan := names(p.Plan(State{}, goal)); plan != "gotoGun getGun load shoot " {
+		t.Errorf("Expected the gun plan, got %s", plan)
+	}
+	if plan := names(p.Plan(State{"hasKnife": true, "hasGun": true}, goal)); plan != "load shoot " {
+		t.Errorf("Expected the cheaper gun plan, got %s", plan)
+	}
+	if plan := names(p.Plan(State{"hasKnife": true, "nearGun": true}, goal)); plan != "stab " {
+		t.Errorf("Expected the cheaper stab plan, got %s", plan)
+	}
+	if plan := p.Plan(State{"targetDead": true}, goal); plan == nil || len(plan) != 0 {
+		t.Errorf("Expected an empty plan, got %v", plan)
+	}
+	if plan := p.Plan(State{}, State{"flying": true}); plan != nil {
+		t.Errorf("Expected no plan, got %s", names(plan))
+	}
+}
+
+// An action that meets several goal facts at once must not make the
+// planner prefer a more expensive plan.
+func TestPlanCheapest(t *testing.T) {
+	p := NewPlanner(
+		&Action{Name: "A", Cost: 1.1, Effects: State{"a": true}},
+		&Action{Name: "B", Cost: 1.1, Effects: State{"b": true}},
+		&Action{Name: "P", Cost: 1, Effects: State{"p": true}},
+		&Action{Name: "Q", Cost: 1, Pre: State{"p": true}, Effects: State{"a": true, "b": true}},
+	)
+	if plan := names(p.Plan(State{}, State{"a": true, "b": true})); plan != "P Q " {
+		t.Errorf("Expected the cheaper P Q plan, got %s", plan)
+	}
+}
+
+func TestState(t *testing.T) {
+	s := State{"a": true, "b": false}
+	if !s.Meets(State{"a": true, "c": false}) || s.Meets(State{"b": true}) {
+		t.Errorf("Expected missing facts to be false")
+	}
+	next := s.apply(State{"a": false, "c": true})
+	if next.key() != "c" || !s["a"] {
+		t.Errorf("Expected a new state with only c, got %v %v", next, s)
+	}
+}
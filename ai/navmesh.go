@@ -0,0 +1,624 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package ai
+
+// navmesh builds navigation meshes from level geometry using the same
+// steps as Recast. See:
+//     https://github.com/recastnavigation/recastnavigation
+//     http://digestingduck.blogspot.com/2010/03/simple-stupid-funnel-algorithm.html
+//
+// Design Notes:
+//   • The level triangles are voxelized into columns of solid spans.
+//     The tops of the spans with enough headroom are the walkable cells.
+//   • Walkable cells are joined to neighbouring cells that are within
+//     a step up or down, and then eroded away from walls and ledges by
+//     the agent radius.
+//   • The walkable cells are merged into rectangles instead of tracing
+//     region contours and triangulating them. Rectangles keep the cell
+//     heights so that ramps and stairs keep their shape.
+//   • Paths are found using A* over the rectangles and then straightened
+//     using the simple stupid funnel algorithm.
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// NavMesh finds paths over the walkable surfaces of 3D levels.
+// Navigation meshes are created once from the level geometry using
+// NewNavMesh. For example:
+//     nav := ai.NewNavMesh(level.V, level.F, ai.NewNavConfig())
+//     path := nav.FindPath(&unit.At, &target.At)
+type NavMesh interface {
+
+	// FindPath returns the path from one location to another. The path
+	// starts and ends at the walkable spots nearest to from and to, with
+	// corner points where the path turns. The returned path is empty if
+	// there is no way to get to the destination.
+	FindPath(from, to *lin.V3) (path []lin.V3)
+
+	// Nearest returns the walkable spot closest to p. False is returned
+	// if there are no walkable spots.
+	Nearest(p *lin.V3) (spot lin.V3, ok bool)
+
+	// Polygons returns the corners of the walkable polygons, ie: for
+	// debug drawing. Corners are counter clockwise when viewed from above.
+	Polygons() [][]lin.V3
+}
+
+// NavConfig describes the agents that use a navigation mesh.
+// Distances are in level units.
+type NavConfig struct {
+	Cell       float64 // Voxel width and depth. Default 0.3.
+	CellHeight float64 // Voxel height. Default 0.1.
+	Height     float64 // Agent height. Default 2.
+	Radius     float64 // Agent radius. Default 0.6.
+	Climb      float64 // Highest step up or down. Default 0.9.
+	Slope      float64 // Steepest walkable slope in degrees. Default 45.
+}
+
+// NewNavConfig returns the configuration for human sized agents.
+func NewNavConfig() *NavConfig {
+	return &NavConfig{Cell: 0.3, CellHeight: 0.1, Height: 2, Radius: 0.6, Climb: 0.9, Slope: 45}
+}
+
+// NewNavMesh creates a navigation mesh from level triangles. The vertex
+// positions and triangle faces are in the same form as mesh data, ie:
+// load.MshData V and F. Triangles are walkable when they are counter
+// clockwise when viewed from above and no steeper than the config slope.
+func NewNavMesh(vb []float32, fb []uint16, c *NavConfig) NavMesh {
+	nm := &navMesh{cs: c.Cell}
+	if len(vb) < 9 || len(fb) < 3 || c.Cell <= 0 || c.CellHeight <= 0 {
+		return nm
+	}
+	hf := newHeightfield(vb, c)
+	vertex := func(i uint16) lin.V3 {
+		j := int(i) * 3
+		return lin.V3{X: float64(vb[j]), Y: float64(vb[j+1]), Z: float64(vb[j+2])}
+	}
+	verts := len(vb) / 3
+	for f := 0; f+2 < len(fb); f += 3 {
+		if int(fb[f]) >= verts || int(fb[f+1]) >= verts || int(fb[f+2]) >= verts {
+			continue // ignore faces with missing vertices.
+		}
+		hf.rasterize(vertex(fb[f]), vertex(fb[f+1]), vertex(fb[f+2]))
+	}
+	nodes := hf.walkable(c)
+	nodes = erode(nodes, int(math.Ceil(c.Radius/c.Cell)))
+	nm.ox, nm.oy, nm.oz, nm.ch = hf.ox, hf.oy, hf.oz, hf.ch
+	nm.polygonize(nodes)
+	return nm
+}
+
+// =============================================================================
+
+// heightfield holds the solid spans of the voxelized level.
+type heightfield struct {
+	w, d       int      // Number of columns along x and z.
+	ox, oy, oz float64  // World location of the first column.
+	cs, ch     float64  // Voxel width and height.
+	walk       float64  // Lowest walkable triangle normal y.
+	spans      [][]span // Solid spans for each column, lowest first.
+}
+
+// span is a solid part of a heightfield column in voxel heights.
+type span struct {
+	min, max int  // Bottom and top.
+	walkable bool // True if the top can be walked on.
+}
+
+// newHeightfield creates an empty heightfield that covers the vertices.
+func newHeightfield(vb []float32, c *NavConfig) *heightfield {
+	min := lin.V3{X: math.MaxFloat64, Y: math.MaxFloat64, Z: math.MaxFloat64}
+	max := lin.V3{X: -math.MaxFloat64, Y: -math.MaxFloat64, Z: -math.MaxFloat64}
+	for v := 0; v+2 < len(vb); v += 3 {
+		p := lin.V3{X: float64(vb[v]), Y: float64(vb[v+1]), Z: float64(vb[v+2])}
+		min.Min(&min, &p)
+		max.Max(&max, &p)
+	}
+	hf := &heightfield{ox: min.X, oy: min.Y, oz: min.Z, cs: c.Cell, ch: c.CellHeight}
+	hf.w = int((max.X-min.X)/c.Cell) + 1
+	hf.d = int((max.Z-min.Z)/c.Cell) + 1
+	hf.walk = math.Cos(lin.Rad(c.Slope))
+	hf.spans = make([][]span, hf.w*hf.d)
+	return hf
+}
+
+// rasterize adds the solid spans for the triangle a, b, c by clipping
+// the triangle to each column that it overlaps.
+func (hf *heightfield) rasterize(a, b, c lin.V3) {
+	ab, ac, n := lin.V3{}, lin.V3{}, lin.V3{}
+	n.Cross(ab.Sub(&b, &a), ac.Sub(&c, &a))
+	walkable := n.Len() > 0 && n.Y/n.Len() >= hf.walk
+	x0 := int((math.Min(a.X, math.Min(b.X, c.X)) - hf.ox) / hf.cs)
+	x1 := int((math.Max(a.X, math.Max(b.X, c.X)) - hf.ox) / hf.cs)
+	z0 := int((math.Min(a.Z, math.Min(b.Z, c.Z)) - hf.oz) / hf.cs)
+	z1 := int((math.Max(a.Z, math.Max(b.Z, c.Z)) - hf.oz) / hf.cs)
+	tri := [][3]float64{{a.X, a.Y, a.Z}, {b.X, b.Y, b.Z}, {c.X, c.Y, c.Z}}
+	for z := z0; z <= z1 && z < hf.d; z++ {
+		cz := hf.oz + float64(z)*hf.cs
+		row := clip(clip(tri, 2, cz, 1), 2, cz+hf.cs, -1)
+		for x := x0; x <= x1 && x < hf.w && len(row) > 0; x++ {
+			cx := hf.ox + float64(x)*hf.cs
+			cell := clip(clip(row, 0, cx, 1), 0, cx+hf.cs, -1)
+			if len(cell) == 0 {
+				continue
+			}
+			ymin, ymax := cell[0][1], cell[0][1]
+			for _, p := range cell {
+				ymin, ymax = math.Min(ymin, p[1]), math.Max(ymax, p[1])
+			}
+			smin := int(math.Floor((ymin - hf.oy) / hf.ch))
+			smax := int(math.Ceil((ymax - hf.oy) / hf.ch))
+			hf.add(x, z, span{min: smin, max: smax, walkable: walkable})
+		}
+	}
+}
+
+// add merges the span into a heightfield column. Merged spans are
+// walkable if the top most span is walkable.
+func (hf *heightfield) add(x, z int, s span) {
+	col := hf.spans[z*hf.w+x]
+	merged := make([]span, 0, len(col)+1)
+	for _, c := range col {
+		if c.max < s.min || c.min > s.max {
+			merged = append(merged, c)
+			continue
+		}
+		switch {
+		case c.max-s.max <= 1 && s.max-c.max <= 1:
+			s.walkable = s.walkable || c.walkable
+		case c.max > s.max:
+			s.walkable = c.walkable
+		}
+		s.min, s.max = imin(s.min, c.min), imax(s.max, c.max)
+	}
+	at := len(merged)
+	for at > 0 && merged[at-1].min > s.min {
+		at--
+	}
+	merged = append(merged, span{})
+	copy(merged[at+1:], merged[at:])
+	merged[at] = s
+	hf.spans[z*hf.w+x] = merged
+}
+
+// walkable returns the walkable cells: the tops of walkable spans with
+// enough headroom for the agent. Cells are linked to neighbouring cells
+// that are within climbing distance.
+func (hf *heightfield) walkable(c *NavConfig) (nodes []navNode) {
+	height := int(math.Ceil(c.Height / hf.ch))
+	climb := int(math.Floor(c.Climb / hf.ch))
+	columns := make([][]int, len(hf.spans))
+	for z := 0; z < hf.d; z++ {
+		for x := 0; x < hf.w; x++ {
+			col := hf.spans[z*hf.w+x]
+			for i, s := range col {
+				top := math.MaxInt32
+				if i+1 < len(col) {
+					top = col[i+1].min
+				}
+				if s.walkable && top-s.max >= height {
+					columns[z*hf.w+x] = append(columns[z*hf.w+x], len(nodes))
+					nodes = append(nodes, navNode{x: x, z: z, y: s.max, top: top, links: [4]int{-1, -1, -1, -1}})
+				}
+			}
+		}
+	}
+	for id := range nodes {
+		n := &nodes[id]
+		for dir, step := range navSteps {
+			x, z := n.x+step[0], n.z+step[1]
+			if x < 0 || z < 0 || x >= hf.w || z >= hf.d {
+				continue
+			}
+			for _, m := range columns[z*hf.w+x] {
+				other := &nodes[m]
+				if iabs(other.y-n.y) <= climb && imin(other.top, n.top)-imax(other.y, n.y) >= height {
+					n.links[dir] = m
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+// navNode is a walkable heightfield cell.
+type navNode struct {
+	x, z   int    // Heightfield column.
+	y, top int    // Floor and ceiling in voxel heights.
+	links  [4]int // Neighbour along each navSteps direction, -1 for none.
+}
+
+// navSteps are the directions to neighbouring columns: +x, +z, -x, -z.
+var navSteps = [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+// erode removes the walkable cells that are closer than radius cells to
+// a wall or ledge. Cells without all 4 neighbours are on the edge.
+func erode(nodes []navNode, radius int) []navNode {
+	if radius <= 0 {
+		return nodes
+	}
+	dist, todo := make([]int, len(nodes)), []int{}
+	for id, n := range nodes {
+		dist[id] = math.MaxInt32
+		for _, link := range n.links {
+			if link < 0 {
+				dist[id] = 0
+				todo = append(todo, id)
+				break
+			}
+		}
+	}
+	for len(todo) > 0 {
+		id := todo[0]
+		todo = todo[1:]
+		for _, link := range nodes[id].links {
+			if link >= 0 && dist[link] > dist[id]+1 {
+				dist[link] = dist[id] + 1
+				todo = append(todo, link)
+			}
+		}
+	}
+
+	// keep the remaining cells and their links.
+	kept, ids := []navNode{}, make([]int, len(nodes))
+	for id, n := range nodes {
+		ids[id] = -1
+		if dist[id] >= radius {
+			ids[id] = len(kept)
+			kept = append(kept, n)
+		}
+	}
+	for id := range kept {
+		for dir, link := range kept[id].links {
+			if link >= 0 {
+				kept[id].links[dir] = ids[link]
+			}
+		}
+	}
+	return kept
+}
+
+// clip returns the part of the polygon on one side of the plane where
+// the given axis equals value. Side 1 keeps the larger values.
+func clip(poly [][3]float64, axis int, value, side float64) (out [][3]float64) {
+	for i := range poly {
+		p, q := poly[i], poly[(i+1)%len(poly)]
+		dp, dq := side*(p[axis]-value), side*(q[axis]-value)
+		if dp >= 0 {
+			out = append(out, p)
+		}
+		if (dp >= 0) != (dq >= 0) {
+			t := dp / (dp - dq)
+			out = append(out, [3]float64{p[0] + (q[0]-p[0])*t, p[1] + (q[1]-p[1])*t, p[2] + (q[2]-p[2])*t})
+		}
+	}
+	return out
+}
+
+// =============================================================================
+
+// navMesh is the default implementation of NavMesh.
+type navMesh struct {
+	polys      []*navPoly // Walkable rectangles.
+	ox, oy, oz float64    // World location of the first column.
+	cs, ch     float64    // Voxel width and height.
+}
+
+// navPoly is a walkable rectangle of cells.
+type navPoly struct {
+	x0, z0, w, d int         // Cells covered by the rectangle.
+	heights      []float64   // World height of each cell.
+	portals      []navPortal // Edges shared with neighbouring rectangles.
+}
+
+// navPortal is an edge shared by two rectangles.
+type navPortal struct {
+	to          int    // Neighbouring rectangle.
+	left, right lin.V3 // Edge end points when crossing to the neighbour.
+}
+
+// polygonize merges the walkable cells into rectangles by growing
+// each unused cell along x and then along z.
+func (nm *navMesh) polygonize(nodes []navNode) {
+	owner := make([]int, len(nodes))
+	for id := range owner {
+		owner[id] = -1
+	}
+	for id := range nodes {
+		if owner[id] >= 0 {
+			continue
+		}
+		row := []int{id}
+		for at := nodes[id].links[0]; at >= 0 && owner[at] < 0; at = nodes[at].links[0] {
+			row = append(row, at)
+		}
+		rows := [][]int{row}
+		for grow := true; grow; {
+			prev, next := rows[len(rows)-1], make([]int, len(row))
+			for k, at := range prev {
+				up := nodes[at].links[1]
+				if up < 0 || owner[up] >= 0 || (k > 0 && nodes[up].links[2] != next[k-1]) {
+					grow = false
+					break
+				}
+				next[k] = up
+			}
+			if grow {
+				rows = append(rows, next)
+			}
+		}
+		p := &navPoly{x0: nodes[id].x, z0: nodes[id].z, w: len(row), d: len(rows)}
+		for _, r := range rows {
+			for _, at := range r {
+				owner[at] = len(nm.polys)
+				p.heights = append(p.heights, nm.oy+float64(nodes[at].y)*nm.ch)
+			}
+		}
+		nm.polys = append(nm.polys, p)
+	}
+
+	// join rectangles with portals along their shared cell edges.
+	portals := map[[3]int]int{} // portal index by rectangle, neighbour, direction.
+	for id, n := range nodes {
+		for dir, link := range n.links {
+			if link < 0 || owner[link] == owner[id] {
+				continue
+			}
+			p := nm.polys[owner[id]]
+			left, right := nm.edge(n, nodes[link], dir)
+			key := [3]int{owner[id], owner[link], dir}
+			if at, ok := portals[key]; ok {
+				nm.extend(&p.portals[at], left, right, dir)
+				continue
+			}
+			portals[key] = len(p.portals)
+			p.portals = append(p.portals, navPortal{to: owner[link], left: left, right: right})
+		}
+	}
+}
+
+// edge returns the end points of the cell edge crossed when moving
+// from cell a to neighbouring cell b in the given direction. The left
+// end point is on the left when facing the direction.
+func (nm *navMesh) edge(a, b navNode, dir int) (left, right lin.V3) {
+	x0, z0 := nm.ox+float64(a.x)*nm.cs, nm.oz+float64(a.z)*nm.cs
+	x1, z1 := x0+nm.cs, z0+nm.cs
+	y := nm.oy + float64(a.y+b.y)*nm.ch/2
+	switch dir {
+	case 0: // +x: left is -z.
+		return lin.V3{X: x1, Y: y, Z: z0}, lin.V3{X: x1, Y: y, Z: z1}
+	case 1: // +z: left is +x.
+		return lin.V3{X: x1, Y: y, Z: z1}, lin.V3{X: x0, Y: y, Z: z1}
+	case 2: // -x: left is +z.
+		return lin.V3{X: x0, Y: y, Z: z1}, lin.V3{X: x0, Y: y, Z: z0}
+	}
+	return lin.V3{X: x0, Y: y, Z: z0}, lin.V3{X: x1, Y: y, Z: z0} // -z: left is -x.
+}
+
+// extend widens the portal to include the cell edge left, right.
+func (nm *navMesh) extend(p *navPortal, left, right lin.V3, dir int) {
+	switch dir {
+	case 0:
+		if left.Z < p.left.Z {
+			p.left = left
+		}
+		if right.Z > p.right.Z {
+			p.right = right
+		}
+	case 1:
+		if left.X > p.left.X {
+			p.left = left
+		}
+		if right.X < p.right.X {
+			p.right = right
+		}
+	case 2:
+		if left.Z > p.left.Z {
+			p.left = left
+		}
+		if right.Z < p.right.Z {
+			p.right = right
+		}
+	case 3:
+		if left.X < p.left.X {
+			p.left = left
+		}
+		if right.X > p.right.X {
+			p.right = right
+		}
+	}
+}
+
+// Polygons implements NavMesh.
+func (nm *navMesh) Polygons() [][]lin.V3 {
+	polys := [][]lin.V3{}
+	for _, p := range nm.polys {
+		x0, z0 := nm.ox+float64(p.x0)*nm.cs, nm.oz+float64(p.z0)*nm.cs
+		x1, z1 := x0+float64(p.w)*nm.cs, z0+float64(p.d)*nm.cs
+		polys = append(polys, []lin.V3{
+			{X: x0, Y: p.heights[0], Z: z0},
+			{X: x0, Y: p.heights[(p.d-1)*p.w], Z: z1},
+			{X: x1, Y: p.heights[p.d*p.w-1], Z: z1},
+			{X: x1, Y: p.heights[p.w-1], Z: z0},
+		})
+	}
+	return polys
+}
+
+// Nearest implements NavMesh.
+func (nm *navMesh) Nearest(p *lin.V3) (spot lin.V3, ok bool) {
+	spot, poly := nm.nearest(p)
+	return spot, poly >= 0
+}
+
+// nearest returns the walkable spot closest to p and its rectangle,
+// or -1 if there are no rectangles.
+func (nm *navMesh) nearest(p *lin.V3) (spot lin.V3, poly int) {
+	best, poly := math.MaxFloat64, -1
+	for id, r := range nm.polys {
+		x0, z0 := nm.ox+float64(r.x0)*nm.cs, nm.oz+float64(r.z0)*nm.cs
+		x := math.Max(x0, math.Min(x0+float64(r.w)*nm.cs, p.X))
+		z := math.Max(z0, math.Min(z0+float64(r.d)*nm.cs, p.Z))
+		cx := imin(r.w-1, int((x-x0)/nm.cs))
+		cz := imin(r.d-1, int((z-z0)/nm.cs))
+		at := lin.V3{X: x, Y: r.heights[cz*r.w+cx], Z: z}
+		if d := at.DistSqr(p); d < best {
+			best, spot, poly = d, at, id
+		}
+	}
+	return spot, poly
+}
+
+// FindPath implements NavMesh.
+func (nm *navMesh) FindPath(from, to *lin.V3) (path []lin.V3) {
+	start, sp := nm.nearest(from)
+	goal, gp := nm.nearest(to)
+	if sp < 0 || gp < 0 {
+		return path
+	}
+	if sp == gp {
+		return append(path, start, goal)
+	}
+	portals := nm.search(sp, gp, &start, &goal)
+	if len(portals) == 0 {
+		return path
+	}
+	return funnel(&start, &goal, portals)
+}
+
+// search uses A* to find the portals crossed going from rectangle
+// sp to rectangle gp. Rectangles are entered at their portal centres.
+func (nm *navMesh) search(sp, gp int, start, goal *lin.V3) []navPortal {
+	type visit struct {
+		at     lin.V3 // Entry location.
+		cost   float64
+		from   int // Previous rectangle.
+		portal int // Portal index in the previous rectangle.
+		closed bool
+	}
+	visits := map[int]*visit{sp: {at: *start, from: -1}}
+	open := &navQueue{{poly: sp, estimate: start.Dist(goal)}}
+	for open.Len() > 0 {
+		current := heap.Pop(open).(navEstimate).poly
+		v := visits[current]
+		if v.closed {
+			continue
+		}
+		v.closed = true
+		if current == gp {
+			portals := []navPortal{}
+			for at := gp; visits[at].from >= 0; at = visits[at].from {
+				prev := visits[at]
+				portals = append([]navPortal{nm.polys[prev.from].portals[prev.portal]}, portals...)
+			}
+			return portals
+		}
+		for index, p := range nm.polys[current].portals {
+			mid := lin.V3{}
+			mid.Lerp(&p.left, &p.right, 0.5)
+			cost := v.cost + v.at.Dist(&mid)
+			if next, ok := visits[p.to]; ok && (next.closed || next.cost <= cost) {
+				continue
+			}
+			visits[p.to] = &visit{at: mid, cost: cost, from: current, portal: index}
+			heap.Push(open, navEstimate{poly: p.to, estimate: cost + mid.Dist(goal)})
+		}
+	}
+	return nil
+}
+
+// navEstimate is a rectangle and its estimated path cost.
+type navEstimate struct {
+	poly     int
+	estimate float64
+}
+
+// navQueue is a lowest estimate first priority queue.
+type navQueue []navEstimate
+
+// Implement heap.Interface.
+func (q navQueue) Len() int            { return len(q) }
+func (q navQueue) Less(i, j int) bool  { return q[i].estimate < q[j].estimate }
+func (q navQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *navQueue) Push(x interface{}) { *q = append(*q, x.(navEstimate)) }
+func (q *navQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// funnel straightens the path through the portals using the simple
+// stupid funnel algorithm. The funnel narrows as the portals are
+// crossed, adding a corner to the path each time a side of the
+// funnel crosses over the other side.
+func funnel(start, goal *lin.V3, portals []navPortal) (path []lin.V3) {
+	portals = append(append([]navPortal{{left: *start, right: *start}}, portals...), navPortal{left: *goal, right: *goal})
+	path = append(path, *start)
+	apex, left, right := *start, *start, *start
+	apexAt, leftAt, rightAt := 0, 0, 0
+	for i := 1; i < len(portals); i++ {
+		l, r := portals[i].left, portals[i].right
+
+		// narrow the right side of the funnel.
+		if side(&apex, &right, &r) >= 0 {
+			if apex.Aeq(&right) || side(&apex, &left, &r) < 0 {
+				right, rightAt = r, i
+			} else {
+				path = append(path, left)
+				apex, apexAt = left, leftAt
+				left, right, leftAt, rightAt = apex, apex, apexAt, apexAt
+				i = apexAt
+				continue
+			}
+		}
+
+		// narrow the left side of the funnel.
+		if side(&apex, &left, &l) <= 0 {
+			if apex.Aeq(&left) || side(&apex, &right, &l) > 0 {
+				left, leftAt = l, i
+			} else {
+				path = append(path, right)
+				apex, apexAt = right, rightAt
+				left, right, leftAt, rightAt = apex, apex, apexAt, apexAt
+				i = apexAt
+				continue
+			}
+		}
+	}
+	if !path[len(path)-1].Aeq(goal) {
+		path = append(path, *goal)
+	}
+	return path
+}
+
+// side returns a positive value if c is on the left when looking
+// from a to b from above, and a negative value if c is on the right.
+func side(a, b, c *lin.V3) float64 {
+	return (c.X-a.X)*(b.Z-a.Z) - (c.Z-a.Z)*(b.X-a.X)
+}
+
+// integer helpers.
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+func iabs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,94 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package ai
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Paths go around obstacles that are too high to climb, keeping
+// the agent radius away from the obstacle.
+func TestNavMeshPath(t *testing.T) {
+	nav := NewNavMesh(level())
+	from, to := &lin.V3{X: 2, Y: 0, Z: 2}, &lin.V3{X: 8, Y: 0, Z: 2}
+	path := nav.FindPath(from, to)
+	if len(path) < 4 || !path[0].Aeq(from) || !path[len(path)-1].Aeq(to) {
+		t.Fatalf("Expected path around the box %v", path)
+	}
+	length, around := 0.0, false
+	for cnt, p := range path {
+		dx, dz := math.Max(0, math.Max(4-p.X, p.X-6)), math.Max(0, p.Z-7)
+		if math.Hypot(dx, dz) < 0.55 || math.Abs(p.Y) > 0.01 {
+			t.Errorf("Expected path to stay on the floor and off the box %v", p)
+		}
+		if cnt > 0 {
+			length += p.Dist(&path[cnt-1])
+		}
+		around = around || p.Z > 7
+	}
+	if !around || length < 14 || length > 15 {
+		t.Errorf("Expected short path around the box %f %v", length, path)
+	}
+}
+
+// The top of the box is walkable, but can't be reached from the floor.
+func TestNavMeshNearest(t *testing.T) {
+	nav := NewNavMesh(level())
+	top, ok := nav.Nearest(&lin.V3{X: 5, Y: 2.5, Z: 3})
+	if !ok || top.Y != 2 || top.X != 5 || top.Z != 3 {
+		t.Fatalf("Expected spot on top of the box %v", top)
+	}
+	floor, _ := nav.Nearest(&lin.V3{X: -1, Y: 0, Z: 5})
+	if floor.X < 0.6 || floor.Y != 0 || floor.Z != 5 {
+		t.Errorf("Expected spot away from the floor edge %v", floor)
+	}
+	if path := nav.FindPath(&top, &floor); len(path) != 0 {
+		t.Errorf("Expected no path off the box %v", path)
+	}
+	if len(nav.Polygons()) < 4 {
+		t.Errorf("Expected floor and box polygons, got %d", len(nav.Polygons()))
+	}
+}
+
+// Large meshes index vertices past the range of uint16*3, and faces
+// with missing vertices are ignored.
+func TestNavMeshLarge(t *testing.T) {
+	lvb, lfb, c := level()
+	pad := 22000 // unused vertices before the level vertices.
+	vb := make([]float32, pad*3, pad*3+len(lvb))
+	vb = append(vb, lvb...)
+	fb := []uint16{}
+	for _, f := range lfb {
+		fb = append(fb, f+uint16(pad))
+	}
+	fb = append(fb, 0, 1, math.MaxUint16)
+	nav := NewNavMesh(vb, fb, c)
+	if top, ok := nav.Nearest(&lin.V3{X: 5, Y: 2.5, Z: 3}); !ok || top.Y != 2 {
+		t.Errorf("Expected spot on top of the box %v", top)
+	}
+	if path := nav.FindPath(&lin.V3{X: 2, Z: 2}, &lin.V3{X: 8, Z: 2}); len(path) < 4 {
+		t.Errorf("Expected path around the box %v", path)
+	}
+}
+
+// level returns a 10x10 floor with a 2x7x2 high box in the middle
+// touching one side of the floor.
+func level() (vb []float32, fb []uint16, c *NavConfig) {
+	quad := func(corners ...[3]float32) {
+		base := uint16(len(vb) / 3)
+		for _, p := range corners {
+			vb = append(vb, p[0], p[1], p[2])
+		}
+		fb = append(fb, base, base+1, base+2, base, base+2, base+3)
+	}
+	quad([3]float32{0, 0, 0}, [3]float32{0, 0, 10}, [3]float32{10, 0, 10}, [3]float32{10, 0, 0})
+	quad([3]float32{4, 2, 0}, [3]float32{4, 2, 7}, [3]float32{6, 2, 7}, [3]float32{6, 2, 0})
+	quad([3]float32{4, 0, 0}, [3]float32{4, 2, 0}, [3]float32{4, 2, 7}, [3]float32{4, 0, 7})
+	quad([3]float32{6, 0, 0}, [3]float32{6, 2, 0}, [3]float32{6, 2, 7}, [3]float32{6, 0, 7})
+	quad([3]float32{4, 0, 7}, [3]float32{4, 2, 7}, [3]float32{6, 2, 7}, [3]float32{6, 0, 7})
+	return vb, fb, NewNavConfig()
+}
@@ -24,8 +24,38 @@ type Animator interface {
 	Animate(action, frame int) bool        // Return true if available.
 	Action() (action, frame, maxFrame int) // Current movement info.
 	Actions() []string                     // Animation sequence names.
+	Joints() []string                      // Joint (bone) names.
+	Joint(name string) int                 // Joint index or -1 if missing.
+
+	// JointAt returns the posed location of a joint in model space.
+	// Useful for placing IK targets. Returns 0,0,0 for animations
+	// without joint bind locations.
+	JointAt(joint int) (x, y, z float64)
+	AddIK(ik *IK)    // Solve IK chain after each animation update.
+	RemoveIK(ik *IK) // Stop solving the IK chain.
+
+	// AddEvent names a frame of an action, ie: a footstep or the hit
+	// frame of an attack. The OnEvent callback is called in the update
+	// that poses the event frame, or the first pose past it, so the
+	// callback happens as the event frame is drawn. An action started
+	// with Animate calls its start frame events on its first pose.
+	// Frames are counted from 0 like Animate, and may be fractional.
+	// Events are per model.
+	AddEvent(action int, frame float64, name string)
+	OnEvent(call AnimEvent) // Called for each passed event.
+
+	// SetRootMotion moves the Pov along the ground using the horizontal
+	// motion of the given top level joint, ie: the hips of a walk cycle,
+	// so the model moves as far as its feet do. The joint stays in place
+	// within the model. A Pov with a solid physics body has its body
+	// velocity set instead. Use -1 to turn off root motion, the default.
+	SetRootMotion(joint int)
 }
 
+// AnimEvent is called when a playing animation action passes
+// a named event frame. See Animator.AddEvent.
+type AnimEvent func(action int, name string)
+
 // Animator
 // =============================================================================
 // animation underlyes Animator and is controlled through Model.
@@ -38,12 +68,15 @@ type animation struct {
 	jointCnt int        // number of joints.
 	frames   []lin.M4   // nFrames*nPoses transform bone positions.
 	joints   []int32    // joint parent indicies.
+	jnames   []string   // joint names. Optional.
+	binds    []lin.V3   // joint bind pose locations. Optional, needed for IK.
 	moves    []movement // frames where animations start and end.
 	mnames   []string   // movement names for easy reference.
 
 	// Per-frame scratch value for playing animations.
-	jnt0 *lin.M4 // Reused each update to calculate joint (bone) positions.
-	jnt1 *lin.M4 // Ditto.
+	jnt0  *lin.M4 // Reused each update to calculate joint (bone) positions.
+	jnt1  *lin.M4 // Ditto.
+	below []bool  // Reused by IK to find the joints below a joint.
 }
 
 // newAnimation allocates space for animation data and the data structures
@@ -63,8 +96,10 @@ func (a *animation) label() string { return a.name } // asset name
 // during loading/initialization.
 //    frames  : gives the 3D position of all joints.
 //    joints  : number of joints and their parent joints.
+//    names   : joint names. May be empty.
+//    binds   : joint bind pose locations. May be empty.
 //    movement: range of frames forming a unique motion.
-func (a *animation) setData(frames []*lin.M4, joints []int32, movements []movement) {
+func (a *animation) setData(frames []*lin.M4, joints []int32, names []string, binds []lin.V3, movements []movement) {
 	a.jointCnt = len(joints)
 	a.moves = movements
 	a.mnames = []string{}
@@ -77,6 +112,12 @@ func (a *animation) setData(frames []*lin.M4, joints []int32, movements []moveme
 	}
 	a.joints = a.joints[:0]
 	a.joints = append(a.joints, joints...)
+	a.jnames = make([]string, len(joints))
+	copy(a.jnames, names)
+	a.binds = a.binds[:0]
+	if len(binds) == len(joints) {
+		a.binds = append(a.binds, binds...)
+	}
 }
 
 // setRate changes the number of frames per second for the given
@@ -94,6 +135,20 @@ func (a *animation) setRate(movement int, rate float64) {
 // used as the movement parameter in other methods.
 func (a *animation) moveNames() []string { return a.mnames }
 
+// jointNames returns the joint names indexed by joint.
+// Unnamed joints have empty names.
+func (a *animation) jointNames() []string { return a.jnames }
+
+// joint returns the index of the named joint, or -1.
+func (a *animation) joint(name string) int {
+	for cnt, jname := range a.jnames {
+		if jname == name {
+			return cnt
+		}
+	}
+	return -1
+}
+
 // isMovement returns the movement index if it is valid.
 // Otherwise 0 is returned.
 func (a *animation) isMovement(movement int) int {
@@ -157,6 +212,29 @@ func (a *animation) animate(dt, frame float64, movement int, pose []lin.M4) floa
 // =============================================================================
 // movement
 
+// animEvent is a named frame in an animation movement.
+type animEvent struct {
+	move  int     // Movement index.
+	frame float64 // Frame within the movement.
+	name  string  // Application supplied event name.
+}
+
+// rootMotion tracks the root joint for moving a Pov.
+type rootMotion struct {
+	joint int     // Top level joint that drives the motion.
+	move  int     // Movement of the previous pose.
+	frame float64 // Frame of the previous pose.
+	at    lin.V3  // Root location of the previous pose.
+}
+
+// rootAt updates v to be the location of the top level joint at the
+// given whole frame of a movement. The updated vector v is returned.
+func (a *animation) rootAt(movement, frame, joint int, v *lin.V3) *lin.V3 {
+	mv := a.moves[movement]
+	m := &a.frames[(frame%mv.fn+mv.f0)*a.jointCnt+joint]
+	return a.posedAt(joint, m, v)
+}
+
 // movement is part of an Animation. It allows multiple animated motions to
 // be associated with a single model. Each movement refences a sequence of
 // frames from the overall animation.
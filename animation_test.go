@@ -0,0 +1,99 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// walker creates a model whose hips walk 1 unit along X each frame.
+// The last of the 5 frames matches the first frame of the next loop.
+func walker() *model {
+	a := newAnimation("walk")
+	frames := []*lin.M4{}
+	for cnt := 0; cnt < 5; cnt++ {
+		hips, feet := lin.NewM4I(), lin.NewM4I()
+		hips.Wx = float64(cnt)
+		frames = append(frames, hips, feet)
+	}
+	binds := []lin.V3{{Y: 1}, {}}
+	a.setData(frames, []int32{-1, 0}, nil, binds, []movement{{name: "walk", f0: 0, fn: 5, rate: 30}})
+	return &model{anm: a, pose: make([]lin.M4, 2), nFrames: 5}
+}
+
+// Events are reported once each time the action passes their frame,
+// including events at the start of a looping action.
+func TestAnimEvents(t *testing.T) {
+	m := walker()
+	passed := map[string]int{}
+	m.OnEvent(func(action int, name string) { passed[name]++ })
+	m.AddEvent(0, 0, "start")
+	m.AddEvent(0, 2, "step")
+	m.AddEvent(1, 2, "other")
+	if m.animate(1.0 / 60); passed["start"] != 1 {
+		t.Fatalf("Expected start event on the first pose, got %v", passed)
+	}
+	for cnt := 1; cnt < 16; cnt++ { // two loops at half a frame a tick.
+		if m.animate(1.0 / 60); cnt == 3 && passed["step"] != 0 {
+			t.Fatalf("Expected step event when frame 2 is posed, not before")
+		}
+	}
+	if passed["start"] != 2 || passed["step"] != 2 || passed["other"] != 0 {
+		t.Errorf("Expected each event twice, got %v", passed)
+	}
+
+	// restarting an action part way through calls its start events.
+	m.animate(1.0 / 60) // third loop.
+	m.animate(1.0 / 60)
+	m.Animate(0, 0)
+	if m.animate(1.0 / 60); passed["start"] != 4 {
+		t.Errorf("Expected start event on restart, got %v", passed)
+	}
+}
+
+// Root motion keeps the hips in place and reports the distance walked.
+func TestRootMotion(t *testing.T) {
+	m := walker()
+	m.SetRootMotion(0)
+	walked := 0.0
+	for cnt := 0; cnt < 16; cnt++ {
+		dx, dz := m.animate(1.0 / 60)
+		if x, _, _ := m.JointAt(0); !lin.Aeq(x, 0) || dz != 0 {
+			t.Fatalf("Expected hips in place, got %f", x)
+		}
+		walked += dx
+	}
+	if !lin.Aeq(walked, 7.5) { // no motion on the first pose.
+		t.Errorf("Expected to walk 7.5, got %f", walked)
+	}
+
+	// restarting part way through the walk does not move the model.
+	m.animate(1.0 / 60)
+	m.animate(1.0 / 60)
+	m.Animate(0, 0)
+	if dx, _ := m.animate(1.0 / 60); dx != 0 {
+		t.Errorf("Expected no motion on restart, got %f", dx)
+	}
+	if dx, _ := m.animate(1.0 / 60); !lin.Aeq(dx, 0.5) {
+		t.Errorf("Expected walking after restart, got %f", dx)
+	}
+	m.Animate(0, 3)
+	if dx, _ := m.animate(1.0 / 60); dx != 0 {
+		t.Errorf("Expected no motion on jump, got %f", dx)
+	}
+	if m.SetRootMotion(-1); m.motion != nil {
+		t.Errorf("Expected root motion off")
+	}
+
+	// root motion moves the Pov in the direction it faces.
+	eng := newEngine(nil)
+	p := eng.root().NewPov().SetScale(2, 2, 2)
+	p.T.Rot.SetAa(0, 1, 0, lin.Rad(90))
+	eng.models.move(p.id, 1, 0, 0.02)
+	if x, _, z := p.At(); !lin.Aeq(x, 0) || !lin.Aeq(z, -2) {
+		t.Errorf("Expected pov moved forward, got %f %f", x, z)
+	}
+}
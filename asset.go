@@ -33,6 +33,8 @@ const (
 	tex        // texture
 	snd        // sound
 	anm        // animation
+	cub        // cube map texture
+	dgn        // loader diagnostics
 )
 
 // =============================================================================
@@ -42,6 +44,20 @@ const (
 // asset type t, and asset name.
 func assetID(t int, name string) aid { return aid(t) + aid(stringHash(name))<<32 }
 
+// assetName returns the asset name as used in Model.Load, ie: "msh:box".
+func assetName(id aid, name string) string {
+	if prefix, ok := assetPrefixes[id.dataType()]; ok {
+		return prefix + ":" + name
+	}
+	return name
+}
+
+// assetPrefixes are the Model.Load attribute names for asset data types.
+var assetPrefixes = map[uint32]string{
+	fnt: "fnt", shd: "shd", mat: "mat", msh: "msh",
+	tex: "tex", snd: "snd", anm: "mod", cub: "cube",
+}
+
 // stringHash turns a string into a number.
 // Algorithm based on java String.hashCode().
 //     s[0]*31^(n-1) + s[1]*31^(n-2) + ... + s[n-1]
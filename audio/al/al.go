@@ -291,6 +291,13 @@ package al
 // ALC_API void         ALC_APIENTRY wrap_alcCaptureStop( uintptr_t device ) { (*pfn_alcCaptureStop)( (ALCdevice *)device ); }
 // ALC_API void         ALC_APIENTRY wrap_alcCaptureSamples( uintptr_t device, ALCvoid *buffer, int samples ) { (*pfn_alcCaptureSamples)( (ALCdevice *)device, buffer, samples ); }
 //
+// // ALC_SOFT_HRTF extension is looked up when needed.
+// typedef ALCboolean (ALC_APIENTRY *pfn_alcResetDeviceSOFT)( ALCdevice *device, const ALCint *attribs );
+// ALC_API ALCboolean   ALC_APIENTRY wrap_alcResetDeviceSOFT( uintptr_t device, const int *attribs ) {
+//    pfn_alcResetDeviceSOFT reset = (pfn_alcResetDeviceSOFT)(*pfn_alcGetProcAddress)( (ALCdevice *)device, "alcResetDeviceSOFT" );
+//    return reset ? (*reset)( (ALCdevice *)device, attribs ) : 0;
+// }
+//
 // void al_init() {
 //    // AL/al.h
 //    pfn_alEnable                  = bindMethod("alEnable");
@@ -442,6 +449,7 @@ const (
 	UNUSED                    = 0x2010
 	PENDING                   = 0x2011
 	PROCESSED                 = 0x2012
+	LOOP_POINTS_SOFT          = 0x2015
 	INVALID_NAME              = 0xA001
 	INVALID_ENUM              = 0xA002
 	INVALID_VALUE             = 0xA003
@@ -488,6 +496,7 @@ const (
 	C_CAPTURE_DEVICE_SPECIFIER         = 0x310
 	C_CAPTURE_DEFAULT_DEVICE_SPECIFIER = 0x311
 	C_CAPTURE_SAMPLES                  = 0x312
+	C_HRTF_SOFT                        = 0x1992
 )
 
 // bind the methods to the function pointers
@@ -698,6 +707,9 @@ func IsDeviceExtensionPresent(device Device, extname string) bool {
 	defer C.free(unsafe.Pointer(cstr))
 	return cbool(uint(C.wrap_alcIsExtensionPresent((C.uintptr_t)(device), cstr)))
 }
+func ResetDeviceSOFT(device Device, attrlist *int32) bool {
+	return cbool(uint(C.wrap_alcResetDeviceSOFT((C.uintptr_t)(device), (*C.int)(attrlist))))
+}
 func GetDeviceProcAddress(device Device, fname string) Pointer {
 	cstr := C.CString(fname)
 	defer C.free(unsafe.Pointer(cstr))
@@ -5,16 +5,33 @@
 // The expected usage is to initialize the audio system and load sound data.
 // Then play sounds that are close enough to the sound listener to be audible.
 //
+// OpenAL is used by default. Building with the nativeaudio tag uses a
+// software mixer that plays through the platform audio instead: CoreAudio
+// on OS X, WASAPI on Windows, and ALSA on Linux. The native backends do
+// not support HRTF, and only ALSA supports Capture.
+//
 // Package audio is provided as part of the vu (virtual universe) 3D engine.
 package audio
 
+import "io"
+
 // Audio interacts with the underlying audio layer which in turn interfaces
 // to the sound drivers and hardware. Audio must be initialized once before
 // sounds can be bound and played.
 type Audio interface {
 	Init() error          // Get the audio layer up and running.
 	Dispose()             // Closes and cleans up the audio layer.
-	SetGain(gain float64) // Volume control: valid values are 0->1.
+	SetGain(gain float64) // Master bus volume: valid values are 0->1.
+
+	// Sounds are mixed using buses. Each bus scales the gain of its
+	// sounds and the Master bus scales all sounds. Muted buses are
+	// silent. Paused buses hold their sounds, including sounds played
+	// while paused, until the bus is resumed. Bound sounds start on the
+	// Sfx bus and music is always on the Music bus.
+	SetBus(sound uint64, bus int)  // Move a bound sound to a bus.
+	BusGain(bus int, gain float64) // Bus volume: valid values are 0->1.
+	MuteBus(bus int, mute bool)    // Silence or restore a bus.
+	PauseBus(bus int, pause bool)  // Pause or resume a bus.
 
 	// BindSound copies the sound data to the sound card and returns
 	// references that can be used to dispose of the sound with ReleaseSound.
@@ -24,11 +41,122 @@ type Audio interface {
 	BindSound(sound, buff *uint64, d *Data) error
 	ReleaseSound(sound uint64)
 
+	// BindStream creates a sound that is read from s as it plays, ie: a
+	// Generator. Data d describes the format of the stream and does not
+	// need AudioData. Streamed sounds are controlled like bound sounds
+	// and are disposed of with ReleaseSound, which also closes s. Looping
+	// and loop points are ignored for streamed sounds.
+	BindStream(sound *uint64, d *Data, s io.ReadCloser) error
+
 	// Control sounds by setting the x,y,z locations for a listener
 	// and the played sounds. While there is only ever one listener,
-	// there can be many sounds.
-	PlaceListener(x, y, z float64)           // Only ever one listener.
-	PlaySound(sound uint64, x, y, z float64) // Play the bound sound.
+	// there can be many sounds. Mono sounds are panned and attenuated
+	// based on their location relative to the listener. Stereo sounds
+	// are played as is.
+	PlaceListener(x, y, z float64)            // Only ever one listener.
+	PlaySound(sound uint64, x, y, z float64)  // Play the bound sound.
+	PlaceSound(sound uint64, x, y, z float64) // Move a playing sound.
+
+	// OrientListener sets the listener forward and up directions.
+	OrientListener(fx, fy, fz, ux, uy, uz float64)
+
+	// Sound and listener velocities in units per second are used to
+	// shift the pitch of sounds that are moving towards or away from the
+	// listener. The doppler factor exaggerates, or reduces, the effect
+	// where 0 turns it off. The speed of sound is in the same units as
+	// the velocities. The defaults are 1 and 343.3.
+	ListenerVelocity(vx, vy, vz float64)
+	SoundVelocity(sound uint64, vx, vy, vz float64)
+	SetDoppler(factor, speedOfSound float64)
+
+	// SetRolloff sets how a sound fades with distance. Sounds are full
+	// volume up to the reference distance and then fade according to the
+	// rolloff factor. Sounds do not fade further past the max distance.
+	SetRolloff(sound uint64, ref, max, rolloff float64)
+
+	// EnableHRTF turns head related transfer functions on or off. HRTF
+	// makes sounds appear above, below, or behind when using headphones.
+	// An error is returned if HRTF is not supported by the audio layer.
+	EnableHRTF(enable bool) error
+
+	// SetSound changes how a bound sound is played. Gain is the sound
+	// volume from 0 to 1, pitch is a multiplier where 1 is unchanged,
+	// and loop repeats the sound until it is played again.
+	SetSound(sound uint64, gain, pitch float64, loop bool)
+
+	// LoopPoints sets the part of a looping sound that repeats. Sounds
+	// play through the loop start and then repeat from the loop end back
+	// to the loop start. Points are in seconds and are applied to the
+	// nearest sample. A loop end of 0 is the end of the sound. The whole
+	// sound loops if the audio layer does not support loop points.
+	LoopPoints(sound uint64, start, end float64)
+
+	// PlayMusic streams a long sound, like a music track, that is read
+	// from s as it plays rather than being bound all at once. Data d
+	// describes the format and looping of the stream and does not need
+	// AudioData. Looping tracks need a stream that is also an io.Seeker.
+	// Any playing track fades out while the new track fades in over
+	// fadeIn seconds. Streams are closed when the track ends or is
+	// replaced. Music is played as is, without a location.
+	PlayMusic(d *Data, s io.ReadCloser, fadeIn float64) error
+	StopMusic(fadeOut float64) // Fade out and stop the playing track.
+
+	// Control bound sounds after they have been played. Paused sounds
+	// continue from where they were paused when resumed. Faded sounds
+	// lower their volume to zero over the given seconds and then stop.
+	// Stopped sounds start from the beginning when played again.
+	StopSound(sound uint64)
+	PauseSound(sound uint64)
+	ResumeSound(sound uint64)
+	FadeSound(sound uint64, seconds float64)
+	SoundGain(sound uint64, gain float64)   // Change the volume from 0 to 1.
+	SoundPitch(sound uint64, pitch float64) // Change the pitch multiplier.
+
+	// Capture records sound from the default input device, ie: a
+	// microphone, using the format described by d. Recorded samples are
+	// held for up to the given seconds until they are read. Reads return
+	// whole sample frames as they are recorded and do not block, so a read
+	// may return no bytes. Captures can be read from any goroutine and
+	// are stopped and released using Close.
+	Capture(d *Data, seconds float64) (io.ReadCloser, error)
+
+	// SetVoices limits the number of sounds that play at once. Playing a
+	// sound over the limit stops the lowest priority playing sound, the
+	// oldest of equal priority sounds, unless all playing sounds have a
+	// higher priority, in which case the new sound is not played. Music
+	// is not limited. The default limit is 32 voices and 0 is no limit.
+	// SoundPriority sets the priority of a bound sound, default 0, where
+	// higher priority sounds are kept.
+	SetVoices(limit int)
+	SoundPriority(sound uint64, priority int)
+
+	// Update streams music and fades sounds. Expected to be called
+	// regularly, ie: each update tick.
+	Update()
+}
+
+// Audio buses used to group sounds for volume, mute, and pause control.
+const (
+	Master = iota // Scales all other buses.
+	Music         // Streamed music tracks.
+	Sfx           // Sound effects. The default bus.
+	Voice         // Dialog.
+	Buses         // Number of buses.
+)
+
+// defaultVoices is the default limit for sounds playing at once.
+const defaultVoices = 32
+
+// bus holds the mixer settings for a group of sounds.
+type bus struct {
+	gain  float64 // Volume from 0 to 1.
+	mute  bool    // True to silence the bus.
+	pause bool    // True to hold the bus sounds.
+}
+
+// frames returns the nearest sample frame for the given seconds.
+func frames(seconds float64, freq uint32) int64 {
+	return int64(seconds*float64(freq) + 0.5)
 }
 
 // Audio
@@ -1,11 +1,14 @@
 // Copyright © 2013-2016 Galvanized Logic Inc.
 // Use is governed by a BSD-style license found in the LICENSE file.
 
+// +build !nativeaudio
+
 package audio
 
 import (
+	"io"
 	"testing"
-	// "time"
+	"time"
 
 	"github.com/gazed/vu/load"
 )
@@ -27,8 +30,121 @@ func TestAudio(t *testing.T) {
 	}
 
 	// Don't play noises during normal testing, but if you're interested...
-	// ... then uncomment and "import time" (need to sleep for the sound to happen).
+	// ... then uncomment (need to sleep for the sound to happen).
 	// a.PlaySound(snd, 0, 0, 0)
 	// time.Sleep(1000 * time.Millisecond)
 	a.Dispose()
 }
+
+// test that music can be streamed and crossfaded. Depends on sound
+// resources from the examples directory.
+func TestMusic(t *testing.T) {
+	a := audioWrapper().(*openal)
+	if err := a.Init(); err != nil {
+		t.Skip(err)
+	}
+	defer a.Dispose()
+	loc := load.NewLocator().Dir("WAV", "../eg/audio")
+	play := func(fadeIn float64) {
+		s, err := load.OpenSnd("bloop", loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := s.Attrs
+		d := &Data{Name: "bloop", Channels: at.Channels, SampleBits: at.SampleBits, Frequency: at.Frequency}
+		if err := a.PlayMusic(d, s, fadeIn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	play(0)
+	if len(a.tracks) != 1 || a.tracks[0].gain != 1 {
+		t.Fatalf("Expected one full volume track")
+	}
+	play(2)
+	if len(a.tracks) != 2 || a.tracks[0].fade >= 0 || a.tracks[1].fade <= 0 {
+		t.Fatalf("Expected track to crossfade")
+	}
+	a.StopMusic(0)
+	if len(a.tracks) != 0 {
+		t.Errorf("Expected stopped tracks to be released")
+	}
+
+	// looping tracks stop reading at the loop end.
+	s, err := load.OpenSnd("bloop", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Data{Name: "bloop", Channels: 1, SampleBits: 8, Frequency: s.Attrs.Frequency}
+	d.Loop, d.LoopStart, d.LoopEnd = true, 0.05, 0.15
+	if err := a.PlayMusic(d, s, 0); err != nil {
+		t.Fatal(err)
+	}
+	if tr := a.tracks[0]; tr.seeker == nil || tr.loopEnd != 1654 || tr.pos > tr.loopEnd {
+		t.Errorf("Expected looping track got %d %d", tr.loopEnd, tr.pos)
+	}
+}
+
+// test that sounds played on a paused bus wait for the bus to resume.
+func TestBuses(t *testing.T) {
+	a := audioWrapper().(*openal)
+	if err := a.Init(); err != nil {
+		t.Skip(err)
+	}
+	defer a.Dispose()
+	s := &load.SndData{}
+	if err := s.Load("bloop", load.NewLocator().Dir("WAV", "../eg/audio")); err != nil {
+		t.Fatal(err)
+	}
+	d := &Data{Name: "bloop"}
+	d.Set(s.Attrs.Channels, s.Attrs.SampleBits, s.Attrs.Frequency, s.Attrs.DataSize, s.Data)
+	snd, buff := uint64(0), uint64(0)
+	if err := a.BindSound(&snd, &buff, d); err != nil {
+		t.Fatal(err)
+	}
+	a.SetBus(snd, Voice)
+	a.PauseBus(Master, true)
+	a.PlaySound(snd, 0, 0, 0)
+	if v := a.voices[snd]; v.bus != Voice || !v.paused {
+		t.Errorf("Expected paused voice sound %+v", v)
+	}
+	a.PauseBus(Master, false)
+	if a.voices[snd].paused {
+		t.Errorf("Expected sound to resume")
+	}
+
+	// held sounds wait for their own resume.
+	a.PauseSound(snd)
+	a.PauseBus(Voice, true)
+	a.PauseBus(Voice, false)
+	if v := a.voices[snd]; !v.held {
+		t.Errorf("Expected sound to stay paused")
+	}
+	a.ResumeSound(snd)
+	a.FadeSound(snd, 1)
+	a.last = a.last.Add(-2 * time.Second)
+	a.Update()
+	if v := a.voices[snd]; v.held || v.fade != 0 || v.level != 1 {
+		t.Errorf("Expected faded sound to be stopped %+v", v)
+	}
+}
+
+// Captures read whole frames without blocking.
+func TestCapture(t *testing.T) {
+	a := audioWrapper().(*openal)
+	if err := a.Init(); err != nil {
+		t.Skip(err)
+	}
+	defer a.Dispose()
+	c, err := a.Capture(&Data{Channels: 1, SampleBits: 16, Frequency: 16000}, 0.5)
+	if err != nil {
+		t.Skip(err) // no microphone.
+	}
+	p := make([]byte, 1001)
+	if n, err := c.Read(p); err != nil || n%2 != 0 || n > 1000 {
+		t.Errorf("Expected whole frames got %d %v", n, err)
+	}
+	c.Close()
+	if _, err := c.Read(p); err != io.EOF {
+		t.Errorf("Expected closed capture to end got %v", err)
+	}
+}
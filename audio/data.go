@@ -12,6 +12,13 @@ type Data struct {
 	SampleBits uint16 // 8 bits = 8, 16 bits = 16, etc.
 	Frequency  uint32 // 8000, 44100, etc.
 	DataSize   uint32 // Audio data size: total file size minus header size.
+
+	// Streamed music tracks can loop. Tracks play through the loop start
+	// and then repeat from the loop end back to the loop start. A loop
+	// end of 0 is the end of the track.
+	Loop      bool    // True to repeat a streamed track.
+	LoopStart float64 // Loop start in seconds.
+	LoopEnd   float64 // Loop end in seconds.
 }
 
 // Set is a convenience method that populates sound data with the
@@ -0,0 +1,72 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// Generator produces sound samples while the sound is playing, ie: an
+// engine hum that follows the engine RPM, or retro sound effects. The
+// fill function is called with a slice of samples to be set to values
+// from -1 to 1. Stereo samples alternate between left and right.
+// Fill is called from the audio thread so values shared with the
+// application need to be synchronized, ie: using sync/atomic.
+//
+// Generators implement io.ReadCloser, returning 16 bit samples, so they
+// can be played using Audio.BindStream.
+type Generator struct {
+	Channels  uint16 // 1 for mono or 2 for stereo. Defaults to 1.
+	Frequency uint32 // Samples per second. Defaults to 44100.
+
+	fill    func(out []float32) // Application sample generator.
+	samples []float32           // Reused for each fill.
+	closed  bool                // Closed generators return io.EOF.
+}
+
+// NewGenerator creates a mono 44100 samples per second generator that
+// calls fill for more samples as they are needed. The channels and
+// frequency can be changed before the generator is played.
+func NewGenerator(fill func(out []float32)) *Generator {
+	return &Generator{Channels: 1, Frequency: 44100, fill: fill}
+}
+
+// Data returns the sound format of the generated samples.
+func (g *Generator) Data() *Data {
+	return &Data{Channels: g.Channels, SampleBits: 16, Frequency: g.Frequency}
+}
+
+// Read implements io.Reader by filling p with generated samples.
+// Samples outside the -1 to 1 range are clipped.
+func (g *Generator) Read(p []byte) (n int, err error) {
+	if g.closed {
+		return 0, io.EOF
+	}
+	frame := 2 * int(g.Channels)
+	count := len(p) / frame * int(g.Channels)
+	if count == 0 {
+		return 0, nil
+	}
+	if cap(g.samples) < count {
+		g.samples = make([]float32, count)
+	}
+	samples := g.samples[:count]
+	for cnt := range samples {
+		samples[cnt] = 0
+	}
+	g.fill(samples)
+	for cnt, s := range samples {
+		v := math.Max(-1, math.Min(1, float64(s)))
+		binary.LittleEndian.PutUint16(p[cnt*2:], uint16(int16(v*math.MaxInt16)))
+	}
+	return count * 2, nil
+}
+
+// Close implements io.Closer. Closed generators stop producing samples.
+func (g *Generator) Close() error {
+	g.closed = true
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// Generated samples are clipped and converted to 16 bit samples.
+func TestGenerator(t *testing.T) {
+	calls := 0
+	g := NewGenerator(func(out []float32) {
+		calls++
+		for cnt := range out {
+			out[cnt] = []float32{0, 0.5, -1, 2}[cnt%4]
+		}
+	})
+	g.Channels = 2
+	if d := g.Data(); d.Channels != 2 || d.SampleBits != 16 || d.Frequency != 44100 {
+		t.Errorf("Expected 16 bit stereo data got %+v", d)
+	}
+	p := make([]byte, 18) // 4 stereo frames and a partial frame.
+	if n, err := g.Read(p); n != 16 || err != nil || calls != 1 {
+		t.Fatalf("Expected 16 bytes got %d %v", n, err)
+	}
+	want := []int16{0, 16383, -32767, 32767}
+	for cnt, w := range want {
+		if s := int16(binary.LittleEndian.Uint16(p[cnt*2:])); s != w {
+			t.Errorf("Expected sample %d to be %d got %d", cnt, w, s)
+		}
+	}
+	g.Close()
+	if _, err := g.Read(p); err != io.EOF {
+		t.Errorf("Expected closed generator to end got %v", err)
+	}
+}
@@ -0,0 +1,830 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package audio
+
+// mixer.go is the software audio layer used by the native backends.
+// DESIGN: OpenAL mixes, spatializes, and resamples sounds for the default
+//         audio layer. The native platform backends only play, or record,
+//         16 bit samples so the mixer does the rest on its own goroutine.
+//         Sounds are attenuated using the OpenAL inverse distance clamped
+//         model, panned between the left and right speakers, and pitch
+//         shifted using the OpenAL doppler formula so that sounds are
+//         heard the same way with either audio layer.
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// sink plays mixed samples using a platform audio device.
+// Each native backend provides a sink.
+type sink interface {
+	open(frequency, channels int) error // Start the output device.
+	write(samples []int16) error        // Blocks until the samples are queued.
+	close()                             // Stop and release the output device.
+
+	// record opens an input device. See Audio.Capture.
+	record(d *Data, seconds float64) (io.ReadCloser, error)
+}
+
+// Mixer output format.
+const (
+	mixFrequency = 44100 // Output samples per second.
+	mixChannels  = 2     // Stereo output.
+	mixFrames    = 512   // Frames mixed at a time.
+)
+
+// mixer implements Audio by mixing the played sounds in software and
+// sending the result to a platform sink.
+type mixer struct {
+	out  sink          // Platform output device.
+	mu   sync.Mutex    // Guards the mixer state from the mixing goroutine.
+	done chan bool     // Closed to stop mixing. Nil when not mixing.
+	quit chan bool     // Closed once mixing has stopped.
+	last time.Time     // Last fade update.
+	mix  []float32     // Reused for each mixed block.
+	pcm  []int16       // Reused for the device samples.
+	fill []mixFill     // Reused for stream reads. Only used by run.
+	ids  uint64        // Last sound reference.
+	hear [4][3]float64 // Listener location, velocity, forward, and up.
+
+	// mixer settings for bound sounds.
+	buses  [Buses]bus           // Settings for each bus.
+	voices map[uint64]*mixVoice // Bound sounds by sound reference.
+	tracks []*mixVoice          // Music. The last track is the current track.
+	limit  int                  // Maximum playing sounds. 0 for no limit.
+	plays  uint64               // Number of sounds played.
+
+	// doppler settings.
+	doppler, speed float64
+}
+
+// newMixer creates a software audio layer that plays using the given sink.
+func newMixer(out sink) *mixer {
+	m := &mixer{out: out, voices: map[uint64]*mixVoice{}, limit: defaultVoices}
+	for cnt := range m.buses {
+		m.buses[cnt].gain = 1
+	}
+	m.hear[2], m.hear[3] = [3]float64{0, 0, -1}, [3]float64{0, 1, 0}
+	m.doppler, m.speed = 1, 343.3
+	m.mix = make([]float32, mixFrames*mixChannels)
+	m.pcm = make([]int16, mixFrames*mixChannels)
+	return m
+}
+
+// Implement Audio by starting the output device and the mixing goroutine.
+func (m *mixer) Init() error {
+	if err := m.out.open(mixFrequency, mixChannels); err != nil {
+		return err
+	}
+	m.last = time.Now()
+	m.done, m.quit = make(chan bool), make(chan bool)
+	go m.run()
+	return nil
+}
+
+// run mixes and plays sounds until Dispose is called.
+func (m *mixer) run() {
+	defer close(m.quit)
+	for {
+		select {
+		case <-m.done:
+			return
+		default:
+		}
+		m.stream()
+		m.mu.Lock()
+		m.render(m.mix)
+		m.mu.Unlock()
+		for cnt, s := range m.mix {
+			m.pcm[cnt] = int16(math.Max(-1, math.Min(1, float64(s))) * math.MaxInt16)
+		}
+		if err := m.out.write(m.pcm); err != nil {
+			log.Printf("mixer: %s", err)
+			return
+		}
+	}
+}
+
+// Implement Audio.
+func (m *mixer) Dispose() {
+	if m.done != nil {
+		close(m.done)
+		<-m.quit
+		m.out.close()
+		m.done = nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.voices {
+		v.release()
+	}
+	for _, t := range m.tracks {
+		t.release()
+	}
+	m.voices, m.tracks = map[uint64]*mixVoice{}, nil
+}
+
+// Implement Audio. Values outside the 0 to 1 range are ignored.
+func (m *mixer) SetGain(zeroToOne float64) { m.BusGain(Master, zeroToOne) }
+
+// Implement Audio.
+func (m *mixer) SetBus(snd uint64, bus int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok && bus > Master && bus < Buses {
+		v.bus = bus
+	}
+}
+
+// Implement Audio. Values outside the 0 to 1 range are ignored.
+func (m *mixer) BusGain(bus int, zeroToOne float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if bus >= Master && bus < Buses && zeroToOne >= 0 && zeroToOne <= 1 {
+		m.buses[bus].gain = zeroToOne
+	}
+}
+
+// Implement Audio.
+func (m *mixer) MuteBus(bus int, mute bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if bus >= Master && bus < Buses {
+		m.buses[bus].mute = mute
+	}
+}
+
+// Implement Audio.
+func (m *mixer) PauseBus(bus int, pause bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if bus >= Master && bus < Buses {
+		m.buses[bus].pause = pause
+	}
+}
+
+// Implement Audio. The sound data is converted to float samples and
+// kept by the mixer. There is no separate data buffer reference.
+func (m *mixer) BindSound(snd, buff *uint64, d *Data) error {
+	v, err := newMixVoice(d)
+	if err != nil {
+		return err
+	}
+	size := int(d.DataSize)
+	if size > len(d.AudioData) {
+		size = len(d.AudioData)
+	}
+	v.samples = v.decode(d.AudioData[:size], nil)
+	v.loop = [2]int64{0, v.frames()}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ids++
+	*snd, *buff = m.ids, 0
+	m.voices[*snd] = v
+	return nil
+}
+
+// Implement Audio.
+func (m *mixer) BindStream(snd *uint64, d *Data, s io.ReadCloser) error {
+	v, err := newMixVoice(d)
+	if err != nil {
+		s.Close()
+		return err
+	}
+	v.setStream(s)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ids++
+	*snd = m.ids
+	m.voices[*snd] = v
+	return nil
+}
+
+// Implement Audio.
+func (m *mixer) ReleaseSound(snd uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.release()
+		delete(m.voices, snd)
+	}
+}
+
+// Implement Audio.
+func (m *mixer) PlaceListener(x, y, z float64) {
+	m.mu.Lock()
+	m.hear[0] = [3]float64{x, y, z}
+	m.mu.Unlock()
+}
+
+// Implement Audio.
+func (m *mixer) OrientListener(fx, fy, fz, ux, uy, uz float64) {
+	m.mu.Lock()
+	m.hear[2], m.hear[3] = [3]float64{fx, fy, fz}, [3]float64{ux, uy, uz}
+	m.mu.Unlock()
+}
+
+// Implement Audio.
+func (m *mixer) ListenerVelocity(vx, vy, vz float64) {
+	m.mu.Lock()
+	m.hear[1] = [3]float64{vx, vy, vz}
+	m.mu.Unlock()
+}
+
+// Implement Audio.
+func (m *mixer) SoundVelocity(snd uint64, vx, vy, vz float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.vel = [3]float64{vx, vy, vz}
+	}
+}
+
+// Implement Audio. Invalid values are ignored.
+func (m *mixer) SetDoppler(factor, speedOfSound float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if factor >= 0 && speedOfSound > 0 {
+		m.doppler, m.speed = factor, speedOfSound
+	}
+}
+
+// Implement Audio.
+func (m *mixer) PlaceSound(snd uint64, x, y, z float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.at = [3]float64{x, y, z}
+	}
+}
+
+// Implement Audio.
+func (m *mixer) SetRolloff(snd uint64, ref, max, rolloff float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.ref, v.max, v.rolloff = ref, max, rolloff
+	}
+}
+
+// Implement Audio. The mixer does not support HRTF.
+func (m *mixer) EnableHRTF(enable bool) error {
+	return fmt.Errorf("mixer: HRTF is not supported")
+}
+
+// Implement Audio.
+func (m *mixer) SetSound(snd uint64, gain, pitch float64, loop bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.gain, v.pitch, v.looping = gain, pitch, loop && v.stream == nil
+	}
+}
+
+// Implement Audio.
+func (m *mixer) LoopPoints(snd uint64, start, end float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.voices[snd]
+	if !ok || v.stream != nil {
+		return
+	}
+	loop := [2]int64{frames(start, v.freq), v.frames()}
+	if end > 0 && frames(end, v.freq) < loop[1] {
+		loop[1] = frames(end, v.freq)
+	}
+	if loop[0] >= 0 && loop[0] < loop[1] {
+		v.loop = loop
+	}
+}
+
+// Implement Audio.
+func (m *mixer) PlayMusic(d *Data, s io.ReadCloser, fadeIn float64) error {
+	t, err := newMixVoice(d)
+	if err != nil {
+		s.Close()
+		return err
+	}
+	t.setStream(s)
+	t.relative, t.playing, t.bus = true, true, Music
+	if seeker, ok := s.(io.Seeker); ok && d.Loop {
+		t.seeker = seeker
+		t.loop = [2]int64{frames(d.LoopStart, d.Frequency), 0}
+		if d.LoopEnd > d.LoopStart {
+			t.loop[1] = frames(d.LoopEnd, d.Frequency)
+		}
+	}
+	m.StopMusic(fadeIn)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if fadeIn > 0 {
+		t.level, t.fade = 0, -1/fadeIn // negative fades in.
+	}
+	m.tracks = append(m.tracks, t)
+	return nil
+}
+
+// Implement Audio.
+func (m *mixer) StopMusic(fadeOut float64) {
+	m.mu.Lock()
+	for _, t := range m.tracks {
+		switch {
+		case fadeOut <= 0:
+			t.playing = false
+		case t.fade <= 0:
+			t.fade = 1 / fadeOut // keep the rate of fading tracks.
+		}
+	}
+	m.mu.Unlock()
+	m.Update()
+}
+
+// Implement Audio. Playing a sound that is playing restarts it.
+func (m *mixer) PlaySound(snd uint64, x, y, z float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.voices[snd]
+	if !ok || !m.steal(v) {
+		return
+	}
+	m.plays++
+	v.at, v.played = [3]float64{x, y, z}, m.plays
+	v.playing, v.held, v.level, v.fade = true, false, 1, 0
+	if v.stream == nil {
+		v.pos = 0
+	}
+}
+
+// Implement Audio.
+func (m *mixer) SetVoices(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if limit >= 0 {
+		m.limit = limit
+	}
+}
+
+// Implement Audio.
+func (m *mixer) SoundPriority(snd uint64, priority int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.priority = priority
+	}
+}
+
+// steal stops playing sounds until there is a voice for the given sound.
+// Returns false if the playing sounds have a higher priority. Sounds
+// that are already playing keep their voice. Expected to be called
+// with the mixer locked.
+func (m *mixer) steal(v *mixVoice) bool {
+	if m.limit <= 0 || v.playing {
+		return true
+	}
+	for {
+		count, low := 0, (*mixVoice)(nil)
+		for _, other := range m.voices {
+			if !other.playing {
+				continue
+			}
+			count++
+			if low == nil || other.priority < low.priority ||
+				(other.priority == low.priority && other.played < low.played) {
+				low = other
+			}
+		}
+		if count < m.limit {
+			return true
+		}
+		if low.priority > v.priority {
+			return false
+		}
+		low.playing = false
+	}
+}
+
+// Implement Audio.
+func (m *mixer) StopSound(snd uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.playing, v.held, v.level, v.fade = false, false, 1, 0
+	}
+}
+
+// Implement Audio.
+func (m *mixer) PauseSound(snd uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok && v.playing {
+		v.held = true
+	}
+}
+
+// Implement Audio.
+func (m *mixer) ResumeSound(snd uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		v.held = false
+	}
+}
+
+// Implement Audio. Fading a sound that is already fading changes
+// the fade rate.
+func (m *mixer) FadeSound(snd uint64, seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok {
+		if seconds <= 0 {
+			v.playing, v.level, v.fade = false, 1, 0
+			return
+		}
+		v.fade = v.level / seconds
+	}
+}
+
+// Implement Audio.
+func (m *mixer) SoundGain(snd uint64, gain float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok && gain >= 0 && gain <= 1 {
+		v.gain = gain
+	}
+}
+
+// Implement Audio.
+func (m *mixer) SoundPitch(snd uint64, pitch float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.voices[snd]; ok && pitch > 0 {
+		v.pitch = pitch
+	}
+}
+
+// Implement Audio by recording with the platform sink.
+func (m *mixer) Capture(d *Data, seconds float64) (io.ReadCloser, error) {
+	if d.SampleBits != 8 && d.SampleBits != 16 || d.Channels < 1 || d.Channels > 2 {
+		return nil, fmt.Errorf("mixer: cannot recognize capture format")
+	}
+	if frames(seconds, d.Frequency) < 1 {
+		return nil, fmt.Errorf("mixer: invalid capture buffer %f seconds", seconds)
+	}
+	return m.out.record(d, seconds)
+}
+
+// Implement Audio. Fades sounds and music by the time since the last
+// update and releases finished music tracks. Sounds and music are
+// streamed by the mixing goroutine.
+func (m *mixer) Update() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	dt := now.Sub(m.last).Seconds()
+	m.last = now
+	for _, v := range m.voices {
+		if v.playing && !v.held && v.fade > 0 && !m.paused(v.bus) {
+			if v.level -= v.fade * dt; v.level <= 0 {
+				v.playing, v.level, v.fade = false, 1, 0
+			}
+		}
+	}
+	playing := m.tracks[:0]
+	for _, t := range m.tracks {
+		if t.playing && t.fade != 0 && !m.paused(Music) {
+			switch t.level -= t.fade * dt; {
+			case t.level >= 1:
+				t.level, t.fade = 1, 0 // faded in.
+			case t.level <= 0:
+				t.playing = false // faded out.
+			}
+		}
+		if !t.playing {
+			t.release()
+			continue
+		}
+		playing = append(playing, t)
+	}
+	m.tracks = playing
+}
+
+// busGain returns the gain for the sounds on the given bus.
+func (m *mixer) busGain(bus int) float64 {
+	if b := m.buses[bus]; !b.mute {
+		return b.gain
+	}
+	return 0
+}
+
+// paused returns true if sounds on the given bus are paused.
+func (m *mixer) paused(bus int) bool { return m.buses[bus].pause || m.buses[Master].pause }
+
+// mixFill is a stream read by the mixing goroutine without the mixer lock.
+type mixFill struct {
+	v       *mixVoice // Voice with the stream.
+	need    int64     // Frames needed to stay ahead of the play position.
+	samples []float32 // Samples decoded from the stream.
+	ended   bool      // True once the stream has been read.
+}
+
+// stream reads and decodes the playing streams that are running low on
+// samples. Streams are read without the mixer lock so that a slow decode
+// doesn't block the application. Only the mixing goroutine reads the
+// streams and streams are only closed with the mixer locked.
+func (m *mixer) stream() {
+	m.mu.Lock()
+	m.fill = m.fill[:0]
+	for _, v := range m.voices {
+		m.fill = v.needs(m.fill)
+	}
+	for _, t := range m.tracks {
+		m.fill = t.needs(m.fill)
+	}
+	m.mu.Unlock()
+	for cnt := range m.fill {
+		f := &m.fill[cnt]
+		for read := int64(0); read < f.need && !f.ended; {
+			size := len(f.samples)
+			f.samples, f.ended = f.v.fill(f.samples)
+			read += int64((len(f.samples) - size) / f.v.ch)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for cnt := range m.fill {
+		f := &m.fill[cnt]
+		v := f.v
+		v.samples = append(v.samples, f.samples...)
+		v.filling = false
+		if f.ended || v.closing {
+			v.stream.Close()
+			v.stream, v.closing = nil, false
+		}
+		f.v, f.samples = nil, nil
+	}
+}
+
+// render mixes the playing sounds and music into out, which holds
+// interleaved stereo samples. Expected to be called with the mixer locked.
+func (m *mixer) render(out []float32) {
+	for cnt := range out {
+		out[cnt] = 0
+	}
+	master := m.busGain(Master)
+	for _, v := range m.voices {
+		if v.playing && !v.held && !m.paused(v.bus) {
+			left, right, shift := m.place(v)
+			gain := v.gain * v.level * m.busGain(v.bus) * master
+			v.render(out, float32(gain*left), float32(gain*right), shift)
+		}
+	}
+	for _, t := range m.tracks {
+		if t.playing && !m.paused(Music) {
+			gain := float32(t.level * m.busGain(Music) * master)
+			t.render(out, gain, gain, 1)
+		}
+	}
+}
+
+// place returns the left and right speaker gains for a sound and its
+// doppler pitch shift. Stereo sounds are played as is.
+func (m *mixer) place(v *mixVoice) (left, right, shift float64) {
+	if v.relative || v.ch != 1 {
+		return 1, 1, 1
+	}
+	sl := [3]float64{} // sound to listener.
+	for cnt := range sl {
+		sl[cnt] = m.hear[0][cnt] - v.at[cnt]
+	}
+	dist := math.Sqrt(mixDot(sl, sl))
+
+	// inverse distance clamped attenuation.
+	gain, d := 1.0, math.Max(v.ref, math.Min(dist, v.max))
+	if scale := v.ref + v.rolloff*(d-v.ref); scale > 0 {
+		gain = v.ref / scale
+	}
+	if dist <= 0 {
+		return gain, gain, 1
+	}
+
+	// pan using the direction to the sound relative to the listener right.
+	fwd, up := m.hear[2], m.hear[3]
+	rt := [3]float64{fwd[1]*up[2] - fwd[2]*up[1], fwd[2]*up[0] - fwd[0]*up[2], fwd[0]*up[1] - fwd[1]*up[0]}
+	pan := 0.0
+	if rlen := math.Sqrt(mixDot(rt, rt)); rlen > 0 {
+		pan = -mixDot(sl, rt) / (rlen * dist)
+	}
+	left, right = gain*math.Min(1, 1-pan), gain*math.Min(1, 1+pan)
+
+	// doppler shift, where velocities are limited to the speed of sound.
+	shift = 1
+	if m.doppler > 0 {
+		limit := m.speed / m.doppler
+		vls := math.Min(mixDot(sl, m.hear[1])/dist, limit)
+		vss := math.Min(mixDot(sl, v.vel)/dist, limit)
+		if den := m.speed - m.doppler*vss; den > 0 {
+			shift = math.Max(0, (m.speed-m.doppler*vls)/den)
+		}
+	}
+	return left, right, shift
+}
+
+// mixDot returns the dot product of two vectors.
+func mixDot(a, b [3]float64) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+
+// =============================================================================
+
+// mixVoice is a bound sound, streamed sound, or music track.
+type mixVoice struct {
+	ch      int       // Channels: 1 mono or 2 stereo.
+	bits    int       // Sample bits: 8 or 16.
+	freq    uint32    // Samples per second.
+	samples []float32 // Interleaved samples. Buffered samples for streams.
+	pos     float64   // Play position in frames, from the start of samples.
+	loop    [2]int64  // Loop start and end frames.
+	looping bool      // True to repeat the loop.
+
+	// streams are read into samples as the voice plays.
+	stream  io.ReadCloser // Nil for bound sounds and finished streams.
+	seeker  io.Seeker     // Looping music seeks to the loop start.
+	read    int64         // Frames read from the stream.
+	block   []byte        // Reused buffer for reading the stream.
+	filling bool          // True while the stream is read without the lock.
+	closing bool          // True to close the stream once it has been read.
+
+	// play settings.
+	playing  bool       // True from PlaySound until stopped or finished.
+	held     bool       // True if paused using PauseSound.
+	relative bool       // True for music which is not spatialized.
+	bus      int        // Mixer bus.
+	gain     float64    // Sound volume before applying the bus gain.
+	level    float64    // Fade volume from 0 to 1.
+	fade     float64    // Fade volume change per second.
+	pitch    float64    // Pitch multiplier.
+	priority int        // Higher priority sounds are kept.
+	played   uint64     // Play order. Lower is older.
+	at, vel  [3]float64 // Location and velocity.
+
+	// Distance attenuation: reference distance, max distance, rolloff.
+	ref, max, rolloff float64
+}
+
+// newMixVoice creates a voice with the default play settings
+// for the given sound format.
+func newMixVoice(d *Data) (*mixVoice, error) {
+	if d.SampleBits != 8 && d.SampleBits != 16 || d.Channels < 1 || d.Channels > 2 || d.Frequency == 0 {
+		return nil, fmt.Errorf("mixer: cannot recognize audio format")
+	}
+	v := &mixVoice{ch: int(d.Channels), bits: int(d.SampleBits), freq: d.Frequency}
+	v.bus, v.gain, v.level, v.pitch = Sfx, 1, 1, 1
+	v.ref, v.max, v.rolloff = 1, math.MaxFloat32, 1
+	return v, nil
+}
+
+// frames returns the number of frames in samples.
+func (v *mixVoice) frames() int64 { return int64(len(v.samples) / v.ch) }
+
+// decode appends the given 8 or 16 bit samples as float samples.
+func (v *mixVoice) decode(data []byte, samples []float32) []float32 {
+	if v.bits == 8 {
+		for _, b := range data {
+			samples = append(samples, float32(int(b)-128)/128)
+		}
+		return samples
+	}
+	for cnt := 0; cnt+1 < len(data); cnt += 2 {
+		samples = append(samples, float32(int16(uint16(data[cnt])|uint16(data[cnt+1])<<8))/32768)
+	}
+	return samples
+}
+
+// setStream makes the voice play the given stream.
+func (v *mixVoice) setStream(s io.ReadCloser) {
+	v.stream = s
+	v.block = make([]byte, mixFrames*v.ch*v.bits/8)
+}
+
+// render adds the voice samples to out using the given speaker gains
+// and pitch shift. Samples are resampled to the mixer frequency using
+// linear interpolation. The voice stops playing when it runs out of
+// samples. Streams that have not been read far enough are quiet until
+// more of the stream is read.
+func (v *mixVoice) render(out []float32, left, right float32, shift float64) {
+	step := float64(v.freq) / mixFrequency * v.pitch * shift
+	for cnt := 0; cnt+1 < len(out); cnt += 2 {
+		at := int64(v.pos)
+		if v.stream != nil && at+1 >= v.frames() {
+			break // wait for the stream to be read.
+		}
+		if at >= v.frames() {
+			v.playing = false
+			break
+		}
+		frac := float32(v.pos - float64(at))
+		l0, r0 := v.frame(at)
+		l1, r1 := v.frame(at + 1)
+		out[cnt] += (l0 + (l1-l0)*frac) * left
+		out[cnt+1] += (r0 + (r1-r0)*frac) * right
+		if v.pos += step; v.looping && v.pos >= float64(v.loop[1]) {
+			v.pos -= float64(v.loop[1] - v.loop[0])
+		}
+	}
+	if v.block != nil {
+		v.drop(int64(v.pos)) // streams only keep the unplayed samples.
+	}
+}
+
+// frame returns the left and right samples of a frame. The frame after
+// a looping sound loop end is the loop start. Mono samples are used
+// for both speakers.
+func (v *mixVoice) frame(at int64) (left, right float32) {
+	if v.looping && at >= v.loop[1] {
+		at -= v.loop[1] - v.loop[0]
+	}
+	if at >= v.frames() {
+		at = v.frames() - 1 // last frame interpolates with itself.
+	}
+	if v.ch == 1 {
+		return v.samples[at], v.samples[at]
+	}
+	return v.samples[at*2], v.samples[at*2+1]
+}
+
+// needs adds the voice to fill if it is a playing stream that has less
+// than two mixed blocks of samples ahead of the play position.
+// Expected to be called with the mixer locked.
+func (v *mixVoice) needs(fill []mixFill) []mixFill {
+	if v.stream == nil || v.filling || !v.playing || v.held {
+		return fill
+	}
+	ahead := v.frames() - int64(v.pos)
+	want := int64(2 * mixFrames * math.Max(1, float64(v.freq)/mixFrequency*v.pitch))
+	if ahead >= want {
+		return fill
+	}
+	v.filling = true
+	return append(fill, mixFill{v: v, need: want - ahead})
+}
+
+// fill reads the next part of the stream and appends the decoded samples.
+// Returns true once the stream has been read. Looping streams stop reading
+// at the loop end and continue from the loop start. Called without the
+// mixer lock, so only the stream read state is used.
+func (v *mixVoice) fill(samples []float32) ([]float32, bool) {
+	frame := v.ch * v.bits / 8
+	block := v.block
+	if v.seeker != nil && v.loop[1] > v.read && (v.loop[1]-v.read)*int64(frame) < int64(len(block)) {
+		block = block[:(v.loop[1]-v.read)*int64(frame)]
+	}
+	n, err := io.ReadFull(v.stream, block)
+	n -= n % frame
+	samples = v.decode(block[:n], samples)
+	v.read += int64(n / frame)
+	ended := err == io.EOF || err == io.ErrUnexpectedEOF || (v.seeker != nil && v.read == v.loop[1])
+	switch {
+	case ended && v.seeker != nil && v.read > v.loop[0]:
+		if _, err = v.seeker.Seek(v.loop[0]*int64(frame), io.SeekStart); err == nil {
+			v.read = v.loop[0]
+			return samples, false
+		}
+		log.Printf("mixer: music loop %s", err)
+	case err == nil && n > 0:
+		return samples, false
+	case err == nil:
+		ended = true // no samples.
+	}
+	if !ended {
+		log.Printf("mixer: stream %s", err)
+	}
+	return samples, true
+}
+
+// drop discards the stream samples before the given frame.
+func (v *mixVoice) drop(at int64) {
+	if at > v.frames() {
+		at = v.frames()
+	}
+	if at > 0 {
+		v.samples = append(v.samples[:0], v.samples[at*int64(v.ch):]...)
+		v.pos -= float64(at)
+	}
+}
+
+// release closes the voice stream, if any. A stream that is being
+// read is closed once the read is done.
+func (v *mixVoice) release() {
+	switch {
+	case v.filling:
+		v.closing = true
+	case v.stream != nil:
+		v.stream.Close()
+		v.stream = nil
+	}
+	v.playing = false
+}
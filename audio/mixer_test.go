@@ -0,0 +1,208 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Played sounds are mixed into both speakers until they run out.
+func TestMixerPlay(t *testing.T) {
+	m := newMixer(&testSink{})
+	snd := mixerSound(t, m, 1, 0.5, 100)
+	m.PlaySound(snd, 0, 0, 0)
+	out := make([]float32, mixFrames*mixChannels)
+	m.render(out)
+	if !near(out[0], 0.5) || !near(out[1], 0.5) || out[200] != 0 {
+		t.Errorf("Expected 100 frames at half volume got %f %f %f", out[0], out[1], out[200])
+	}
+	if m.voices[snd].playing {
+		t.Errorf("Expected sound to finish")
+	}
+	m.SetSound(snd, 1, 1, true)
+	m.PlaySound(snd, 0, 0, 0)
+	if m.render(out); !near(out[len(out)-1], 0.5) {
+		t.Errorf("Expected looping sound to repeat")
+	}
+	m.MuteBus(Sfx, true)
+	if m.render(out); out[0] != 0 {
+		t.Errorf("Expected muted sound")
+	}
+	m.MuteBus(Sfx, false)
+	m.PauseSound(snd)
+	if m.render(out); out[0] != 0 {
+		t.Errorf("Expected paused sound")
+	}
+	if err := m.Init(); err != nil {
+		t.Fatal(err)
+	}
+	m.ResumeSound(snd)
+	m.Dispose()
+	if len(m.voices) != 0 {
+		t.Errorf("Expected sounds to be released")
+	}
+}
+
+// Mono sounds are attenuated and panned by their listener location.
+func TestMixerPlace(t *testing.T) {
+	m := newMixer(&testSink{})
+	snd := mixerSound(t, m, 1, 1, 100)
+	m.PlaySound(snd, 2, 0, 0) // right of a listener looking down -z.
+	left, right, shift := m.place(m.voices[snd])
+	if left != 0 || !near(float32(right), 0.5) || shift != 1 {
+		t.Errorf("Expected attenuated right sound got %f %f %f", left, right, shift)
+	}
+	m.SoundVelocity(snd, -10, 0, 0) // towards the listener.
+	if _, _, shift = m.place(m.voices[snd]); shift <= 1 {
+		t.Errorf("Expected higher doppler pitch got %f", shift)
+	}
+	stereo := mixerSound(t, m, 2, 1, 100)
+	m.PlaySound(stereo, 100, 0, 0)
+	if left, right, _ := m.place(m.voices[stereo]); left != 1 || right != 1 {
+		t.Errorf("Expected stereo sound as is got %f %f", left, right)
+	}
+}
+
+// Voices are stolen from lower priority and older sounds.
+func TestMixerVoices(t *testing.T) {
+	m := newMixer(&testSink{})
+	m.SetVoices(1)
+	a, b := mixerSound(t, m, 1, 1, 100), mixerSound(t, m, 1, 1, 100)
+	m.SoundPriority(a, 1)
+	m.PlaySound(a, 0, 0, 0)
+	if m.PlaySound(b, 0, 0, 0); m.voices[b].playing || !m.voices[a].playing {
+		t.Errorf("Expected lower priority sound to be dropped")
+	}
+	m.SoundPriority(b, 1)
+	if m.PlaySound(b, 0, 0, 0); !m.voices[b].playing || m.voices[a].playing {
+		t.Errorf("Expected oldest sound to be stolen")
+	}
+}
+
+// Streams and music are read as they play.
+func TestMixerStream(t *testing.T) {
+	m := newMixer(&testSink{})
+	g := NewGenerator(func(out []float32) {
+		for cnt := range out {
+			out[cnt] = 0.25
+		}
+	})
+	g.Frequency = mixFrequency
+	var snd uint64
+	if err := m.BindStream(&snd, g.Data(), g); err != nil {
+		t.Fatal(err)
+	}
+	m.PlaySound(snd, 0, 0, 0)
+	out := make([]float32, mixFrames*mixChannels)
+	for cnt := 0; cnt < 3; cnt++ {
+		m.stream()
+		if m.render(out); !near(out[len(out)-1], 0.25) {
+			t.Fatalf("Expected generated samples got %f", out[len(out)-1])
+		}
+	}
+	if frames := m.voices[snd].frames(); frames > 2*mixFrames {
+		t.Errorf("Expected played stream samples to be dropped %d", frames)
+	}
+	music := &Data{Channels: 1, SampleBits: 8, Frequency: 22050}
+	if err := m.PlayMusic(music, ioutil.NopCloser(bytes.NewReader(make([]byte, 50))), 0); err != nil {
+		t.Fatal(err)
+	}
+	m.stream()
+	m.render(out)
+	if m.Update(); len(m.tracks) != 0 {
+		t.Errorf("Expected finished music to be released")
+	}
+	if err := m.BindStream(&snd, &Data{Channels: 3, SampleBits: 16, Frequency: 1}, g); err == nil {
+		t.Errorf("Expected unknown format error")
+	}
+}
+
+// Slow streams are read without blocking the application.
+func TestMixerSlowStream(t *testing.T) {
+	m := newMixer(&testSink{})
+	slow := &slowStream{read: make(chan bool), wait: make(chan bool)}
+	var snd uint64
+	d := &Data{Channels: 1, SampleBits: 16, Frequency: mixFrequency}
+	if err := m.BindStream(&snd, d, slow); err != nil {
+		t.Fatal(err)
+	}
+	m.PlaySound(snd, 0, 0, 0)
+	done := make(chan bool)
+	go func() {
+		m.stream()
+		close(done)
+	}()
+	<-slow.read // the stream is being read.
+	locked := make(chan bool)
+	go func() {
+		m.StopSound(snd)
+		m.ReleaseSound(snd)
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("Expected sounds to be controlled while the stream is read")
+	}
+	if slow.closed {
+		t.Error("Expected the stream to stay open while it is read")
+	}
+	close(slow.wait)
+	<-done
+	if !slow.closed {
+		t.Error("Expected the released stream to be closed after the read")
+	}
+}
+
+// slowStream blocks reads until wait is closed.
+type slowStream struct {
+	read   chan bool // Closed when the first read starts.
+	wait   chan bool // Closed to finish reading.
+	once   sync.Once // Closes read once.
+	closed bool      // True once the stream is closed.
+}
+
+func (s *slowStream) Read(p []byte) (int, error) {
+	s.once.Do(func() { close(s.read) })
+	<-s.wait
+	return 0, io.EOF
+}
+func (s *slowStream) Close() error { s.closed = true; return nil }
+
+// mixerSound binds a constant sound with the given channels,
+// sample value, and number of frames.
+func mixerSound(t *testing.T, m *mixer, channels int, value float64, frames int) (snd uint64) {
+	data := []byte{}
+	sample := int16(value * math.MaxInt16)
+	for cnt := 0; cnt < frames*channels; cnt++ {
+		data = append(data, byte(sample), byte(sample>>8))
+	}
+	d := &Data{Channels: uint16(channels), SampleBits: 16, Frequency: mixFrequency}
+	d.Set(d.Channels, d.SampleBits, d.Frequency, uint32(len(data)), data)
+	var buff uint64
+	if err := m.BindSound(&snd, &buff, d); err != nil {
+		t.Fatal(err)
+	}
+	return snd
+}
+
+// near returns true if the sample is close to the expected value.
+func near(s float32, want float64) bool { return math.Abs(float64(s)-want) < 0.001 }
+
+// testSink discards mixed samples.
+type testSink struct{}
+
+func (s *testSink) open(frequency, channels int) error { return nil }
+func (s *testSink) write(samples []int16) error        { return nil }
+func (s *testSink) close()                             {}
+func (s *testSink) record(d *Data, seconds float64) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("no capture")
+}
@@ -0,0 +1,11 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build nativeaudio
+// Use the platform audio with the nativeaudio build tag.
+
+package audio
+
+// audioWrapper gets a reference to the underlying audio wrapper.
+// Compiling ensures there will only be one that matches.
+func audioWrapper() Audio { return newMixer(newSink()) }
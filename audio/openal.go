@@ -1,15 +1,17 @@
 // Copyright © 2013-2016 Galvanized Logic Inc.
 // Use is governed by a BSD-style license found in the LICENSE file.
 
-// +build !dx
+// +build !dx,!nativeaudio
 // Use OpenAL by default.
 
 package audio
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/gazed/vu/audio/al"
 )
@@ -25,11 +27,32 @@ import (
 type openal struct {
 	dev al.Device  // created on initialization.
 	ctx al.Context // created on initialization.
+
+	// music tracks. The last track is the current track.
+	// Earlier tracks are fading out.
+	tracks []*track
+	last   time.Time // Last music and fade update.
+
+	// mixer settings for bound sounds.
+	buses  [Buses]bus        // Settings for each bus.
+	voices map[uint64]*voice // Bound sounds by sound reference.
+
+	loopPoints bool // True if sample accurate loop points are supported.
+
+	// voice limit for sounds playing at once.
+	limit int    // Maximum playing sounds. 0 for no limit.
+	plays uint64 // Number of sounds played. Orders the voices by age.
 }
 
 // audioWrapper gets a reference to the underlying audio wrapper.
 // Compiling ensures there will only be one that matches.
-func audioWrapper() Audio { return &openal{} }
+func audioWrapper() Audio {
+	a := &openal{voices: map[uint64]*voice{}, limit: defaultVoices}
+	for cnt := range a.buses {
+		a.buses[cnt].gain = 1
+	}
+	return a
+}
 
 // Init runs the one time openal library initialization. It is expected to
 // be called once by the engine on startup.
@@ -43,6 +66,9 @@ func (a *openal) Init() (err error) {
 	if a.dev = al.OpenDevice(""); a.dev != 0 {
 		if a.ctx = al.CreateContext(a.dev, nil); a.ctx != 0 {
 			al.MakeContextCurrent(a.ctx)
+			al.DistanceModel(al.INVERSE_DISTANCE_CLAMPED)
+			a.last = time.Now()
+			a.loopPoints = al.IsExtensionPresent("AL_SOFT_loop_points")
 			return // success
 		}
 	}
@@ -66,6 +92,15 @@ func (a *openal) validate() error {
 // Dispose closes down the openal library. This is expected
 // to be called once by the engine when it is shutting down.
 func (a *openal) Dispose() {
+	for _, t := range a.tracks {
+		t.release()
+	}
+	a.tracks = nil
+	for snd, v := range a.voices {
+		if v.stream != nil {
+			a.ReleaseSound(snd)
+		}
+	}
 	al.MakeContextCurrent(0)
 	if a.ctx != 0 {
 		al.DestroyContext(a.ctx)
@@ -75,11 +110,39 @@ func (a *openal) Dispose() {
 	}
 }
 
-// SetGain sets the listener gain to a value between 0 and 1.
+// SetGain sets the master bus gain to a value between 0 and 1.
 // Values outside the 0 to 1 range are ignored.
-func (a *openal) SetGain(zeroToOne float64) {
-	if zeroToOne >= 0 && zeroToOne <= 1 {
-		al.Listenerf(al.GAIN, float32(zeroToOne))
+func (a *openal) SetGain(zeroToOne float64) { a.BusGain(Master, zeroToOne) }
+
+// Implement Audio.
+func (a *openal) SetBus(snd uint64, bus int) {
+	if v, ok := a.voices[snd]; ok && bus > Master && bus < Buses {
+		v.bus = bus
+		a.mix()
+	}
+}
+
+// Implement Audio. Values outside the 0 to 1 range are ignored.
+func (a *openal) BusGain(bus int, zeroToOne float64) {
+	if bus >= Master && bus < Buses && zeroToOne >= 0 && zeroToOne <= 1 {
+		a.buses[bus].gain = zeroToOne
+		a.mix()
+	}
+}
+
+// Implement Audio.
+func (a *openal) MuteBus(bus int, mute bool) {
+	if bus >= Master && bus < Buses {
+		a.buses[bus].mute = mute
+		a.mix()
+	}
+}
+
+// Implement Audio.
+func (a *openal) PauseBus(bus int, pause bool) {
+	if bus >= Master && bus < Buses {
+		a.buses[bus].pause = pause
+		a.mix()
 	}
 }
 
@@ -104,6 +167,12 @@ func (a *openal) BindSound(snd, buff *uint64, d *Data) (err error) {
 			al.GenSources(1, &snd32)
 			al.Sourcei(snd32, al.BUFFER, int32(*buff))
 			*snd = uint64(snd32)
+			v := &voice{bus: Sfx, gain: 1, level: 1, buff: buff32, freq: d.Frequency}
+			if frame := uint32(d.Channels) * uint32(d.SampleBits) / 8; frame > 0 {
+				v.loop = [2]int32{0, int32(d.DataSize / frame)}
+				v.frames = v.loop[1]
+			}
+			a.voices[*snd] = v
 		}
 	}
 	return err
@@ -115,15 +184,412 @@ func (a *openal) PlaceListener(x, y, z float64) {
 }
 
 // Implement Audio.
+func (a *openal) OrientListener(fx, fy, fz, ux, uy, uz float64) {
+	orientation := []float32{float32(fx), float32(fy), float32(fz), float32(ux), float32(uy), float32(uz)}
+	al.Listenerfv(al.ORIENTATION, &orientation[0])
+}
+
+// Implement Audio.
+func (a *openal) ListenerVelocity(vx, vy, vz float64) {
+	al.Listener3f(al.VELOCITY, float32(vx), float32(vy), float32(vz))
+}
+
+// Implement Audio.
+func (a *openal) SoundVelocity(snd uint64, vx, vy, vz float64) {
+	al.Source3f(uint32(snd), al.VELOCITY, float32(vx), float32(vy), float32(vz))
+}
+
+// Implement Audio. Invalid values are ignored.
+func (a *openal) SetDoppler(factor, speedOfSound float64) {
+	if factor >= 0 && speedOfSound > 0 {
+		al.DopplerFactor(float32(factor))
+		al.SpeedOfSound(float32(speedOfSound))
+	}
+}
+
+// Implement Audio.
+func (a *openal) PlaceSound(snd uint64, x, y, z float64) {
+	al.Source3f(uint32(snd), al.POSITION, float32(x), float32(y), float32(z))
+}
+
+// Implement Audio.
+func (a *openal) SetRolloff(snd uint64, ref, max, rolloff float64) {
+	al.Sourcef(uint32(snd), al.REFERENCE_DISTANCE, float32(ref))
+	al.Sourcef(uint32(snd), al.MAX_DISTANCE, float32(max))
+	al.Sourcef(uint32(snd), al.ROLLOFF_FACTOR, float32(rolloff))
+}
+
+// Implement Audio. Uses the ALC_SOFT_HRTF extension to reset
+// the device with the requested HRTF setting.
+func (a *openal) EnableHRTF(enable bool) error {
+	if !al.IsDeviceExtensionPresent(a.dev, "ALC_SOFT_HRTF") {
+		return fmt.Errorf("openal: HRTF is not supported")
+	}
+	attrs := []int32{al.C_HRTF_SOFT, al.C_FALSE, 0}
+	if enable {
+		attrs[1] = al.C_TRUE
+	}
+	if !al.ResetDeviceSOFT(a.dev, &attrs[0]) {
+		return fmt.Errorf("openal: could not reset device for HRTF")
+	}
+	return nil
+}
+
+// Implement Audio.
+// Sounds played on a paused bus start when the bus is resumed.
 func (a *openal) PlaySound(snd uint64, x, y, z float64) {
+	if v, ok := a.voices[snd]; ok && !a.steal(snd, v) {
+		return // no voice for the sound.
+	}
 	al.Source3f(uint32(snd), al.POSITION, float32(x), float32(y), float32(z))
+	if v, ok := a.voices[snd]; ok {
+		a.plays++
+		v.held, v.level, v.fade, v.played = false, 1, 0, a.plays
+		al.Sourcef(uint32(snd), al.GAIN, float32(a.voiceGain(v)))
+		if v.stream != nil {
+			v.playing = true
+			v.stream.requeue() // buffers played before the last stop.
+		}
+		if a.paused(v.bus) {
+			al.SourceRewind(uint32(snd))
+			v.paused = true
+			return
+		}
+	}
 	al.SourcePlay(uint32(snd))
 }
 
+// Implement Audio.
+func (a *openal) SetVoices(limit int) {
+	if limit >= 0 {
+		a.limit = limit
+	}
+}
+
+// Implement Audio.
+func (a *openal) SoundPriority(snd uint64, priority int) {
+	if v, ok := a.voices[snd]; ok {
+		v.priority = priority
+	}
+}
+
+// steal stops playing sounds until there is a voice for the given sound.
+// Returns false if the playing sounds have a higher priority. Sounds
+// that are already playing keep their voice.
+func (a *openal) steal(snd uint64, v *voice) bool {
+	if a.limit <= 0 || a.active(snd, v) {
+		return true
+	}
+	for {
+		count, victim, low := 0, uint64(0), (*voice)(nil)
+		for s, other := range a.voices {
+			if !a.active(s, other) {
+				continue
+			}
+			count++
+			if low == nil || other.priority < low.priority ||
+				(other.priority == low.priority && other.played < low.played) {
+				victim, low = s, other
+			}
+		}
+		if count < a.limit {
+			return true
+		}
+		if low.priority > v.priority {
+			return false
+		}
+		a.StopSound(victim)
+	}
+}
+
+// active returns true if the sound is using a voice. Sounds held by a
+// paused bus, and streams waiting for data, are still using a voice.
+func (a *openal) active(snd uint64, v *voice) bool {
+	var state int32
+	al.GetSourcei(uint32(snd), al.SOURCE_STATE, &state)
+	return state == al.PLAYING || state == al.PAUSED || v.paused || v.playing
+}
+
+// Implement Audio.
+func (a *openal) StopSound(snd uint64) {
+	al.SourceStop(uint32(snd))
+	if v, ok := a.voices[snd]; ok {
+		v.paused, v.held, v.level, v.fade = false, false, 1, 0
+		v.playing = false
+	}
+}
+
+// Implement Audio.
+func (a *openal) PauseSound(snd uint64) {
+	if v, ok := a.voices[snd]; ok && !v.held {
+		var state int32
+		if al.GetSourcei(uint32(snd), al.SOURCE_STATE, &state); state == al.PLAYING || v.paused {
+			al.SourcePause(uint32(snd))
+			v.held = true
+		}
+	}
+}
+
+// Implement Audio. Sounds on a paused bus resume with the bus.
+func (a *openal) ResumeSound(snd uint64) {
+	if v, ok := a.voices[snd]; ok && v.held {
+		v.held = false
+		if v.paused = a.paused(v.bus); !v.paused {
+			al.SourcePlay(uint32(snd))
+		}
+	}
+}
+
+// Implement Audio. Fading a sound that is already fading changes
+// the fade rate.
+func (a *openal) FadeSound(snd uint64, seconds float64) {
+	if v, ok := a.voices[snd]; ok {
+		if seconds <= 0 {
+			a.StopSound(snd)
+			return
+		}
+		v.fade = v.level / seconds
+	}
+}
+
+// Implement Audio.
+func (a *openal) SoundGain(snd uint64, gain float64) {
+	if v, ok := a.voices[snd]; ok && gain >= 0 && gain <= 1 {
+		v.gain = gain
+		al.Sourcef(uint32(snd), al.GAIN, float32(a.voiceGain(v)))
+	}
+}
+
+// Implement Audio.
+func (a *openal) SoundPitch(snd uint64, pitch float64) {
+	if pitch > 0 {
+		al.Sourcef(uint32(snd), al.PITCH, float32(pitch))
+	}
+}
+
+// Implement Audio.
+func (a *openal) SetSound(snd uint64, gain, pitch float64, loop bool) {
+	looping := int32(al.FALSE)
+	if loop {
+		looping = al.TRUE
+	}
+	if v, ok := a.voices[snd]; ok {
+		v.gain = gain
+		gain = a.voiceGain(v)
+		if v.stream != nil {
+			looping = al.FALSE // queued buffers can't loop.
+		}
+	}
+	al.Sourcef(uint32(snd), al.GAIN, float32(gain))
+	al.Sourcef(uint32(snd), al.PITCH, float32(pitch))
+	al.Sourcei(uint32(snd), al.LOOPING, looping)
+}
+
+// Implement Audio. Uses the AL_SOFT_loop_points extension which needs
+// the buffer to be detached from its source while the points change.
+func (a *openal) LoopPoints(snd uint64, start, end float64) {
+	v, ok := a.voices[snd]
+	if !ok || !a.loopPoints || v.stream != nil {
+		return
+	}
+	loop := [2]int32{int32(frames(start, v.freq)), v.frames}
+	if end > 0 && frames(end, v.freq) < int64(v.frames) {
+		loop[1] = int32(frames(end, v.freq))
+	}
+	if loop == v.loop || loop[0] < 0 || loop[0] >= loop[1] {
+		return // unchanged or invalid.
+	}
+	src := uint32(snd)
+	al.SourceStop(src)
+	al.Sourcei(src, al.BUFFER, 0)
+	al.Bufferiv(v.buff, al.LOOP_POINTS_SOFT, &loop[0])
+	al.Sourcei(src, al.BUFFER, int32(v.buff))
+	v.loop = loop
+}
+
 // Implement Audio.
 func (a *openal) ReleaseSound(snd uint64) {
+	if v, ok := a.voices[snd]; ok && v.stream != nil {
+		v.stream.release()
+		delete(a.voices, snd)
+		return
+	}
 	snd32 := uint32(snd)
 	al.DeleteSources(1, &snd32)
+	delete(a.voices, snd)
+}
+
+// Implement Audio.
+func (a *openal) PlayMusic(d *Data, s io.ReadCloser, fadeIn float64) error {
+	t, err := a.newTrack(d, s)
+	if err != nil {
+		return err
+	}
+	a.StopMusic(fadeIn)
+	if fadeIn > 0 {
+		t.gain, t.fade = 0, 1/fadeIn
+	}
+	al.Sourcei(t.src, al.SOURCE_RELATIVE, al.TRUE)
+	al.Sourcef(t.src, al.GAIN, float32(t.gain*a.busGain(Music)))
+	if t.paused = a.paused(Music); !t.paused {
+		al.SourcePlay(t.src)
+	}
+	a.tracks = append(a.tracks, t)
+	a.last = time.Now()
+	return nil
+}
+
+// Implement Audio. Streamed sounds use a track that is not faded.
+func (a *openal) BindStream(snd *uint64, d *Data, s io.ReadCloser) error {
+	if alerr := al.GetError(); alerr != al.NO_ERROR {
+		log.Printf("openal.BindStream need to find and fix prior error %X", alerr)
+	}
+	d.Loop = false
+	t, err := a.newTrack(d, s)
+	if err != nil {
+		return err
+	}
+	*snd = uint64(t.src)
+	a.voices[*snd] = &voice{bus: Sfx, gain: 1, level: 1, freq: d.Frequency, stream: t}
+	return nil
+}
+
+// newTrack creates a source and queues the first buffers from the
+// given stream. The stream is closed if the track cannot be created.
+func (a *openal) newTrack(d *Data, s io.ReadCloser) (*track, error) {
+	format, err := a.format(d)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	t := &track{stream: s, format: format, freq: int32(d.Frequency), gain: 1}
+	frame := int(d.Channels) * int(d.SampleBits) / 8
+	t.block = make([]byte, int(d.Frequency)/musicFills*frame)
+	if seeker, ok := s.(io.Seeker); ok && d.Loop {
+		t.seeker = seeker
+		t.loopStart = frames(d.LoopStart, d.Frequency) * int64(frame)
+		if d.LoopEnd > d.LoopStart {
+			t.loopEnd = frames(d.LoopEnd, d.Frequency) * int64(frame)
+		}
+	}
+	al.GenSources(1, &t.src)
+	t.buffs = make([]uint32, musicBuffers)
+	al.GenBuffers(musicBuffers, &t.buffs[0])
+	for _, buff := range t.buffs {
+		t.fill(buff)
+	}
+	if alerr := al.GetError(); alerr != al.NO_ERROR {
+		t.release()
+		return nil, fmt.Errorf("Failed streaming %s", d.Name)
+	}
+	return t, nil
+}
+
+// Implement Audio.
+func (a *openal) Capture(d *Data, seconds float64) (io.ReadCloser, error) {
+	format, err := a.format(d)
+	if err != nil {
+		return nil, err
+	}
+	c := &capture{frame: int(d.Channels) * int(d.SampleBits) / 8}
+	size := frames(seconds, d.Frequency)
+	if size < 1 {
+		return nil, fmt.Errorf("openal: invalid capture buffer %f seconds", seconds)
+	}
+	if c.dev = al.CaptureOpenDevice("", d.Frequency, format, int32(size)); c.dev == 0 {
+		return nil, fmt.Errorf("openal: could not open capture device")
+	}
+	al.CaptureStart(c.dev)
+	return c, nil
+}
+
+// Implement Audio.
+func (a *openal) StopMusic(fadeOut float64) {
+	for _, t := range a.tracks {
+		switch {
+		case fadeOut <= 0:
+			t.gain, t.fade = 0, 0
+		case t.fade >= 0:
+			t.fade = -1 / fadeOut // keep the rate of fading tracks.
+		}
+	}
+	a.Update()
+}
+
+// Implement Audio. Tracks are released once they have finished
+// playing or have faded out. Faded sounds are stopped.
+func (a *openal) Update() {
+	now := time.Now()
+	dt := now.Sub(a.last).Seconds()
+	a.last = now
+	for snd, v := range a.voices {
+		if v.paused || v.held {
+			continue
+		}
+		if v.fade > 0 {
+			if v.level -= v.fade * dt; v.level <= 0 {
+				a.StopSound(snd)
+				continue
+			}
+			al.Sourcef(uint32(snd), al.GAIN, float32(a.voiceGain(v)))
+		}
+		if v.stream != nil && v.playing && !v.stream.refill() {
+			v.playing = false // stream ended.
+		}
+	}
+	playing := a.tracks[:0]
+	for _, t := range a.tracks {
+		if !t.update(dt, a.busGain(Music)) {
+			t.release()
+			continue
+		}
+		playing = append(playing, t)
+	}
+	a.tracks = playing
+}
+
+// busGain returns the gain for the sounds on the given bus.
+// The master bus gain is applied by the listener.
+func (a *openal) busGain(bus int) float64 {
+	if b := a.buses[bus]; !b.mute {
+		return b.gain
+	}
+	return 0
+}
+
+// voiceGain returns the gain for a bound sound.
+func (a *openal) voiceGain(v *voice) float64 { return v.gain * v.level * a.busGain(v.bus) }
+
+// paused returns true if sounds on the given bus are paused.
+func (a *openal) paused(bus int) bool { return a.buses[bus].pause || a.buses[Master].pause }
+
+// mix applies the bus settings to the listener and each sound.
+// Sounds are paused or resumed to match their bus.
+func (a *openal) mix() {
+	al.Listenerf(al.GAIN, float32(a.busGain(Master)))
+	for snd, v := range a.voices {
+		al.Sourcef(uint32(snd), al.GAIN, float32(a.voiceGain(v)))
+		var state int32
+		switch al.GetSourcei(uint32(snd), al.SOURCE_STATE, &state); {
+		case a.paused(v.bus) && state == al.PLAYING:
+			al.SourcePause(uint32(snd))
+			v.paused = true
+		case !a.paused(v.bus) && v.paused && !v.held:
+			al.SourcePlay(uint32(snd))
+			v.paused = false
+		}
+	}
+	for _, t := range a.tracks {
+		al.Sourcef(t.src, al.GAIN, float32(t.gain*a.busGain(Music)))
+		switch {
+		case a.paused(Music) && !t.paused:
+			al.SourcePause(t.src)
+			t.paused = true
+		case !a.paused(Music) && t.paused:
+			al.SourcePlay(t.src)
+			t.paused = false
+		}
+	}
 }
 
 // format figures out which of the OpenAL formats to use based on the
@@ -145,3 +611,179 @@ func (a *openal) format(d *Data) (format int32, err error) {
 	}
 	return format, err
 }
+
+// =============================================================================
+
+// voice holds the mixer settings for a bound sound.
+type voice struct {
+	buff   uint32   // Sound data buffer.
+	freq   uint32   // Samples per second.
+	frames int32    // Sound length in samples.
+	loop   [2]int32 // Current loop start and end samples.
+	bus    int      // Mixer bus.
+	gain   float64  // Sound volume before applying the bus gain.
+	level  float64  // Fade volume from 0 to 1.
+	fade   float64  // Fade volume change per second.
+	paused bool     // True if paused, or played, while the bus is paused.
+	held   bool     // True if paused using PauseSound.
+
+	// Voices are shared by priority and then by age.
+	priority int    // Higher priority sounds are kept.
+	played   uint64 // Play order. Lower is older.
+
+	// Streamed sounds refill their track while playing.
+	stream  *track // Nil for bound sounds.
+	playing bool   // True from PlaySound until stopped or the stream ends.
+}
+
+// capture records from a capture device. Implements io.ReadCloser.
+type capture struct {
+	dev   al.Device // Capture device. 0 once closed.
+	frame int       // Bytes per sample frame.
+}
+
+// Read copies the recorded sample frames that fit into p.
+func (c *capture) Read(p []byte) (n int, err error) {
+	if c.dev == 0 {
+		return 0, io.EOF
+	}
+	var recorded int32
+	al.GetDeviceIntegerv(c.dev, al.C_CAPTURE_SAMPLES, 1, &recorded)
+	frames := len(p) / c.frame
+	if int(recorded) < frames {
+		frames = int(recorded)
+	}
+	if frames > 0 {
+		al.CaptureSamples(c.dev, al.Pointer(&p[0]), frames)
+	}
+	return frames * c.frame, nil
+}
+
+// Close stops recording and releases the capture device.
+func (c *capture) Close() error {
+	if c.dev != 0 {
+		al.CaptureStop(c.dev)
+		al.CaptureCloseDevice(c.dev)
+		c.dev = 0
+	}
+	return nil
+}
+
+// Music is streamed using a small number of queued buffers that are
+// refilled as they finish playing.
+const (
+	musicBuffers = 4 // Number of buffers queued for each track.
+	musicFills   = 4 // Buffers per second of music.
+)
+
+// track is a music stream that is being played.
+type track struct {
+	src    uint32        // Source playing the queued buffers.
+	buffs  []uint32      // Buffers bound to the source.
+	stream io.ReadCloser // Music data. Nil once the stream has ended.
+	block  []byte        // Reused buffer for reading the stream.
+	format int32         // OpenAL data format.
+	freq   int32         // Samples per second.
+	gain   float64       // Current fade volume from 0 to 1.
+	fade   float64       // Volume change per second.
+	paused bool          // True while the Music bus is paused.
+
+	// Looping tracks seek back to the loop start at the loop end.
+	seeker    io.Seeker // Nil for tracks that do not loop.
+	pos       int64     // Stream bytes read.
+	loopStart int64     // Loop start in bytes.
+	loopEnd   int64     // Loop end in bytes. 0 for the end of the stream.
+}
+
+// update refills played buffers and adjusts the track fade over the
+// given elapsed seconds. Paused tracks are left as is. Returns false
+// if the track is done.
+func (t *track) update(dt, busGain float64) bool {
+	if t.paused {
+		return t.gain > 0 || t.fade > 0 // unless stopped.
+	}
+	if t.gain = t.gain + t.fade*dt; t.gain >= 1 {
+		t.gain, t.fade = 1, 0
+	}
+	if t.gain <= 0 && t.fade <= 0 {
+		return false // faded out.
+	}
+	al.Sourcef(t.src, al.GAIN, float32(t.gain*busGain))
+	return t.refill()
+}
+
+// refill replaces played buffers with the next part of the stream and
+// restarts the source if it ran out of data. Returns false once all of
+// the stream has been played.
+func (t *track) refill() bool {
+	if t.requeue() == 0 {
+		return false // finished playing.
+	}
+	var state int32
+	if al.GetSourcei(t.src, al.SOURCE_STATE, &state); state != al.PLAYING {
+		al.SourcePlay(t.src) // restart after running out of data.
+	}
+	return true
+}
+
+// requeue replaces played buffers with the next part of the stream.
+// Returns the number of buffers queued.
+func (t *track) requeue() (queued int32) {
+	var played int32
+	al.GetSourcei(t.src, al.BUFFERS_PROCESSED, &played)
+	for ; played > 0; played-- {
+		var buff uint32
+		al.SourceUnqueueBuffers(t.src, 1, &buff)
+		t.fill(buff)
+	}
+	al.GetSourcei(t.src, al.BUFFERS_QUEUED, &queued)
+	return queued
+}
+
+// fill reads the next piece of the stream into the given buffer and
+// queues it for playing. Looping tracks stop reading at the loop end so
+// the loop start is queued right after it. The stream is closed once it
+// has been read.
+func (t *track) fill(buff uint32) {
+	for t.stream != nil {
+		block := t.block
+		if t.loopEnd > t.pos && t.loopEnd-t.pos < int64(len(block)) {
+			block = block[:t.loopEnd-t.pos]
+		}
+		n, err := io.ReadFull(t.stream, block)
+		if t.pos += int64(n); n > 0 {
+			al.BufferData(buff, t.format, al.Pointer(&block[0]), int32(n), t.freq)
+			al.SourceQueueBuffers(t.src, 1, &buff)
+		}
+		ended := err == io.EOF || err == io.ErrUnexpectedEOF || t.pos == t.loopEnd
+		switch {
+		case ended && t.seeker != nil && t.pos > t.loopStart:
+			if _, err = t.seeker.Seek(t.loopStart, io.SeekStart); err == nil {
+				if t.pos = t.loopStart; n > 0 {
+					return
+				}
+				continue // fill the buffer from the loop start.
+			}
+			log.Printf("openal: music loop %s", err)
+		case err == nil:
+			return
+		}
+		if !ended {
+			log.Printf("openal: music stream %s", err)
+		}
+		t.stream.Close()
+		t.stream = nil
+	}
+}
+
+// release stops the track and frees its stream, source, and buffers.
+func (t *track) release() {
+	al.SourceStop(t.src)
+	al.Sourcei(t.src, al.BUFFER, 0)
+	al.DeleteSources(1, &t.src)
+	al.DeleteBuffers(int32(len(t.buffs)), &t.buffs[0])
+	if t.stream != nil {
+		t.stream.Close()
+		t.stream = nil
+	}
+}
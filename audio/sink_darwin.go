@@ -0,0 +1,146 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build nativeaudio
+
+package audio
+
+// The osx native audio layer uses a CoreAudio output queue. The queue
+// calls back on its own thread as each buffer finishes playing. Writes
+// wait for a played buffer to refill.
+
+// #cgo darwin LDFLAGS: -framework AudioToolbox -framework CoreFoundation
+//
+// #include <stdlib.h>
+// #include <string.h>
+// #include <pthread.h>
+// #include <AudioToolbox/AudioToolbox.h>
+//
+// #define AQ_BUFFERS 3
+//
+// typedef struct {
+//     AudioQueueRef       queue;
+//     AudioQueueBufferRef buffs[AQ_BUFFERS];
+//     int                 free[AQ_BUFFERS]; // Indexes of played buffers.
+//     int                 nfree;            // Number of played buffers.
+//     int                 started;          // Queue starts once all buffers are filled.
+//     pthread_mutex_t     lock;
+//     pthread_cond_t      played;
+// } aqOutput;
+//
+// static void aqPlayed(void *data, AudioQueueRef queue, AudioQueueBufferRef buff) {
+//     aqOutput *o = (aqOutput *)data;
+//     pthread_mutex_lock(&o->lock);
+//     for (int cnt = 0; cnt < AQ_BUFFERS; cnt++) {
+//         if (o->buffs[cnt] == buff) {
+//             o->free[o->nfree++] = cnt;
+//         }
+//     }
+//     pthread_cond_signal(&o->played);
+//     pthread_mutex_unlock(&o->lock);
+// }
+//
+// static aqOutput *aqOpen(int freq, int channels, int bytes) {
+//     AudioStreamBasicDescription f = {0};
+//     f.mSampleRate = freq;
+//     f.mFormatID = kAudioFormatLinearPCM;
+//     f.mFormatFlags = kLinearPCMFormatFlagIsSignedInteger | kLinearPCMFormatFlagIsPacked;
+//     f.mBytesPerPacket = f.mBytesPerFrame = 2 * channels;
+//     f.mFramesPerPacket = 1;
+//     f.mChannelsPerFrame = channels;
+//     f.mBitsPerChannel = 16;
+//     aqOutput *o = calloc(1, sizeof(aqOutput));
+//     pthread_mutex_init(&o->lock, NULL);
+//     pthread_cond_init(&o->played, NULL);
+//     if (AudioQueueNewOutput(&f, aqPlayed, o, NULL, NULL, 0, &o->queue) != noErr) {
+//         free(o);
+//         return NULL;
+//     }
+//     for (int cnt = 0; cnt < AQ_BUFFERS; cnt++) {
+//         AudioQueueAllocateBuffer(o->queue, bytes, &o->buffs[cnt]);
+//         o->free[o->nfree++] = cnt;
+//     }
+//     return o;
+// }
+//
+// static OSStatus aqWrite(aqOutput *o, void *data, int bytes) {
+//     pthread_mutex_lock(&o->lock);
+//     while (o->nfree == 0) {
+//         pthread_cond_wait(&o->played, &o->lock);
+//     }
+//     AudioQueueBufferRef buff = o->buffs[o->free[--o->nfree]];
+//     int start = !o->started && o->nfree == 0;
+//     pthread_mutex_unlock(&o->lock);
+//     if (bytes > buff->mAudioDataBytesCapacity) {
+//         bytes = buff->mAudioDataBytesCapacity;
+//     }
+//     memcpy(buff->mAudioData, data, bytes);
+//     buff->mAudioDataByteSize = bytes;
+//     OSStatus err = AudioQueueEnqueueBuffer(o->queue, buff, 0, NULL);
+//     if (err == noErr && start) {
+//         o->started = 1;
+//         err = AudioQueueStart(o->queue, NULL);
+//     }
+//     return err;
+// }
+//
+// static void aqClose(aqOutput *o) {
+//     AudioQueueStop(o->queue, true);
+//     AudioQueueDispose(o->queue, true);
+//     pthread_cond_destroy(&o->played);
+//     pthread_mutex_destroy(&o->lock);
+//     free(o);
+// }
+import "C" // must be located here.
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// coreAudio plays sounds using a CoreAudio output queue. Implements sink.
+type coreAudio struct {
+	out   *C.aqOutput // Output queue. Nil when closed.
+	bytes int         // Queue buffer size.
+}
+
+// newSink returns the osx native sink.
+func newSink() sink { return &coreAudio{} }
+
+// Implement sink. Queue buffers hold one mixed block.
+func (c *coreAudio) open(frequency, channels int) error {
+	c.bytes = mixFrames * channels * 2
+	if c.out = C.aqOpen(C.int(frequency), C.int(channels), C.int(c.bytes)); c.out == nil {
+		return fmt.Errorf("coreaudio: could not open output queue")
+	}
+	return nil
+}
+
+// Implement sink.
+func (c *coreAudio) write(samples []int16) error {
+	for len(samples) > 0 {
+		size := len(samples) * 2
+		if size > c.bytes {
+			size = c.bytes
+		}
+		if err := C.aqWrite(c.out, unsafe.Pointer(&samples[0]), C.int(size)); err != 0 {
+			return fmt.Errorf("coreaudio: write error %d", int(err))
+		}
+		samples = samples[size/2:]
+	}
+	return nil
+}
+
+// Implement sink.
+func (c *coreAudio) close() {
+	if c.out != nil {
+		C.aqClose(c.out)
+		c.out = nil
+	}
+}
+
+// Implement sink. Recording is not yet supported by the osx native layer.
+func (c *coreAudio) record(d *Data, seconds float64) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("coreaudio: capture is not supported")
+}
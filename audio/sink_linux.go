@@ -0,0 +1,142 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build nativeaudio
+
+package audio
+
+// The linux native audio layer uses ALSA. Sound servers like PulseAudio
+// and PipeWire are used through the ALSA default device.
+
+// #cgo linux LDFLAGS: -lasound
+//
+// #include <stdlib.h>
+// #include <alsa/asoundlib.h>
+import "C" // must be located here.
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// alsa plays sounds using the default ALSA device. Implements sink.
+type alsa struct {
+	pcm      *C.snd_pcm_t // Playback device.
+	channels int          // Interleaved channels.
+}
+
+// newSink returns the linux native sink.
+func newSink() sink { return &alsa{} }
+
+// alsaLatency is the device buffer size in microseconds.
+const alsaLatency = 50000
+
+// alsaOpen opens the default device for playback or capture.
+func alsaOpen(stream C.snd_pcm_stream_t, mode C.int, d *Data, latency int) (pcm *C.snd_pcm_t, err error) {
+	name := C.CString("default")
+	defer C.free(unsafe.Pointer(name))
+	if rc := C.snd_pcm_open(&pcm, name, stream, mode); rc < 0 {
+		return nil, fmt.Errorf("alsa: open %s", C.GoString(C.snd_strerror(rc)))
+	}
+	format := C.snd_pcm_format_t(C.SND_PCM_FORMAT_S16_LE)
+	if d.SampleBits == 8 {
+		format = C.SND_PCM_FORMAT_U8
+	}
+	// allow resampling since devices may not support the mixer frequency.
+	rc := C.snd_pcm_set_params(pcm, format, C.SND_PCM_ACCESS_RW_INTERLEAVED,
+		C.uint(d.Channels), C.uint(d.Frequency), 1, C.uint(latency))
+	if rc < 0 {
+		C.snd_pcm_close(pcm)
+		return nil, fmt.Errorf("alsa: format %s", C.GoString(C.snd_strerror(rc)))
+	}
+	return pcm, nil
+}
+
+// Implement sink.
+func (a *alsa) open(frequency, channels int) (err error) {
+	d := &Data{Channels: uint16(channels), SampleBits: 16, Frequency: uint32(frequency)}
+	if a.pcm, err = alsaOpen(C.SND_PCM_STREAM_PLAYBACK, 0, d, alsaLatency); err == nil {
+		a.channels = channels
+	}
+	return err
+}
+
+// Implement sink. Recovers from underruns.
+func (a *alsa) write(samples []int16) error {
+	for len(samples) >= a.channels {
+		frames := C.snd_pcm_uframes_t(len(samples) / a.channels)
+		n := C.snd_pcm_writei(a.pcm, unsafe.Pointer(&samples[0]), frames)
+		if n < 0 {
+			if rc := C.snd_pcm_recover(a.pcm, C.int(n), 1); rc < 0 {
+				return fmt.Errorf("alsa: write %s", C.GoString(C.snd_strerror(rc)))
+			}
+			continue
+		}
+		samples = samples[int(n)*a.channels:]
+	}
+	return nil
+}
+
+// Implement sink.
+func (a *alsa) close() {
+	if a.pcm != nil {
+		C.snd_pcm_drop(a.pcm)
+		C.snd_pcm_close(a.pcm)
+		a.pcm = nil
+	}
+}
+
+// Implement sink.
+func (a *alsa) record(d *Data, seconds float64) (io.ReadCloser, error) {
+	latency := int(seconds * 1000000)
+	pcm, err := alsaOpen(C.SND_PCM_STREAM_CAPTURE, C.SND_PCM_NONBLOCK, d, latency)
+	if err != nil {
+		return nil, err
+	}
+	if rc := C.snd_pcm_start(pcm); rc < 0 {
+		C.snd_pcm_close(pcm)
+		return nil, fmt.Errorf("alsa: record %s", C.GoString(C.snd_strerror(rc)))
+	}
+	return &alsaCapture{pcm: pcm, frame: int(d.Channels) * int(d.SampleBits) / 8}, nil
+}
+
+// alsaCapture records from an ALSA capture device. Implements io.ReadCloser.
+type alsaCapture struct {
+	pcm   *C.snd_pcm_t // Capture device. Nil once closed.
+	frame int          // Bytes per sample frame.
+}
+
+// Read copies the recorded sample frames that fit into p without blocking.
+// Recording restarts if the recorded samples were not read in time.
+func (c *alsaCapture) Read(p []byte) (n int, err error) {
+	if c.pcm == nil {
+		return 0, io.EOF
+	}
+	frames := len(p) / c.frame
+	if frames == 0 {
+		return 0, nil
+	}
+	got := C.snd_pcm_readi(c.pcm, unsafe.Pointer(&p[0]), C.snd_pcm_uframes_t(frames))
+	switch {
+	case got == -C.EAGAIN:
+		return 0, nil // nothing recorded yet.
+	case got < 0:
+		if rc := C.snd_pcm_recover(c.pcm, C.int(got), 1); rc < 0 {
+			return 0, fmt.Errorf("alsa: read %s", C.GoString(C.snd_strerror(rc)))
+		}
+		C.snd_pcm_start(c.pcm)
+		return 0, nil
+	}
+	return int(got) * c.frame, nil
+}
+
+// Close stops recording and releases the capture device.
+func (c *alsaCapture) Close() error {
+	if c.pcm != nil {
+		C.snd_pcm_drop(c.pcm)
+		C.snd_pcm_close(c.pcm)
+		c.pcm = nil
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build nativeaudio
+
+package audio
+
+// The microsoft (windows) native audio layer uses a shared mode WASAPI
+// render client. The device converts the mixer format to its own format.
+// Writes wait for the device to signal that there is buffer space.
+
+// #cgo windows LDFLAGS: -lole32
+//
+// #define COBJMACROS
+// #include <stdlib.h>
+// #include <string.h>
+// #include <windows.h>
+// #include <mmdeviceapi.h>
+// #include <audioclient.h>
+//
+// // COM identifiers are defined here so that linking does not need uuid.lib.
+// static const CLSID vuCLSID_MMDeviceEnumerator = {0xBCDE0395, 0xE52F, 0x467C, {0x8E, 0x3D, 0xC4, 0x57, 0x92, 0x91, 0x69, 0x2E}};
+// static const IID vuIID_IMMDeviceEnumerator = {0xA95664D2, 0x9614, 0x4F35, {0xA7, 0x46, 0xDE, 0x8D, 0xB6, 0x36, 0x17, 0xE6}};
+// static const IID vuIID_IAudioClient = {0x1CB9AD4C, 0xDBFA, 0x4C32, {0xB1, 0x78, 0xC2, 0xF5, 0x68, 0xA7, 0x03, 0xB2}};
+// static const IID vuIID_IAudioRenderClient = {0xF294ACFC, 0x3146, 0x4483, {0xA7, 0xBF, 0xAD, 0xDC, 0xA7, 0xC2, 0x60, 0xE2}};
+//
+// typedef struct {
+//     IAudioClient       *client;
+//     IAudioRenderClient *render;
+//     HANDLE             ready;    // Signalled when there is buffer space.
+//     UINT32             frames;   // Device buffer size in frames.
+//     int                channels; // Interleaved channels.
+// } wasOutput;
+//
+// static void wasClose(wasOutput *o) {
+//     if (o->client) {
+//         IAudioClient_Stop(o->client);
+//     }
+//     if (o->render) {
+//         IAudioRenderClient_Release(o->render);
+//     }
+//     if (o->client) {
+//         IAudioClient_Release(o->client);
+//     }
+//     if (o->ready) {
+//         CloseHandle(o->ready);
+//     }
+//     free(o);
+// }
+//
+// static wasOutput *wasOpen(int freq, int channels, REFERENCE_TIME latency) {
+//     IMMDeviceEnumerator *enumerator = NULL;
+//     IMMDevice *device = NULL;
+//     wasOutput *o = calloc(1, sizeof(wasOutput));
+//     o->channels = channels;
+//     WAVEFORMATEX f = {0};
+//     f.wFormatTag = WAVE_FORMAT_PCM;
+//     f.nChannels = channels;
+//     f.nSamplesPerSec = freq;
+//     f.wBitsPerSample = 16;
+//     f.nBlockAlign = 2 * channels;
+//     f.nAvgBytesPerSec = freq * f.nBlockAlign;
+//     DWORD flags = AUDCLNT_STREAMFLAGS_EVENTCALLBACK | AUDCLNT_STREAMFLAGS_AUTOCONVERTPCM | AUDCLNT_STREAMFLAGS_SRC_DEFAULT_QUALITY;
+//     CoInitializeEx(NULL, COINIT_MULTITHREADED);
+//     HRESULT hr = CoCreateInstance(&vuCLSID_MMDeviceEnumerator, NULL, CLSCTX_ALL, &vuIID_IMMDeviceEnumerator, (void **)&enumerator);
+//     if (SUCCEEDED(hr)) {
+//         hr = IMMDeviceEnumerator_GetDefaultAudioEndpoint(enumerator, eRender, eConsole, &device);
+//     }
+//     if (SUCCEEDED(hr)) {
+//         hr = IMMDevice_Activate(device, &vuIID_IAudioClient, CLSCTX_ALL, NULL, (void **)&o->client);
+//     }
+//     if (SUCCEEDED(hr)) {
+//         hr = IAudioClient_Initialize(o->client, AUDCLNT_SHAREMODE_SHARED, flags, latency, 0, &f, NULL);
+//     }
+//     if (SUCCEEDED(hr)) {
+//         o->ready = CreateEvent(NULL, FALSE, FALSE, NULL);
+//         hr = IAudioClient_SetEventHandle(o->client, o->ready);
+//     }
+//     if (SUCCEEDED(hr)) {
+//         hr = IAudioClient_GetBufferSize(o->client, &o->frames);
+//     }
+//     if (SUCCEEDED(hr)) {
+//         hr = IAudioClient_GetService(o->client, &vuIID_IAudioRenderClient, (void **)&o->render);
+//     }
+//     if (SUCCEEDED(hr)) {
+//         hr = IAudioClient_Start(o->client);
+//     }
+//     if (device) {
+//         IMMDevice_Release(device);
+//     }
+//     if (enumerator) {
+//         IMMDeviceEnumerator_Release(enumerator);
+//     }
+//     if (FAILED(hr)) {
+//         wasClose(o);
+//         return NULL;
+//     }
+//     return o;
+// }
+//
+// static HRESULT wasWrite(wasOutput *o, short *data, UINT32 frames) {
+//     while (frames > 0) {
+//         UINT32 padding;
+//         BYTE *buff;
+//         HRESULT hr = IAudioClient_GetCurrentPadding(o->client, &padding);
+//         if (FAILED(hr)) {
+//             return hr;
+//         }
+//         UINT32 space = o->frames - padding;
+//         if (space == 0) {
+//             WaitForSingleObject(o->ready, 100);
+//             continue;
+//         }
+//         if (space > frames) {
+//             space = frames;
+//         }
+//         if (FAILED(hr = IAudioRenderClient_GetBuffer(o->render, space, &buff))) {
+//             return hr;
+//         }
+//         memcpy(buff, data, space * 2 * o->channels);
+//         if (FAILED(hr = IAudioRenderClient_ReleaseBuffer(o->render, space, 0))) {
+//             return hr;
+//         }
+//         data += space * o->channels;
+//         frames -= space;
+//     }
+//     return S_OK;
+// }
+import "C" // must be located here.
+
+import (
+	"fmt"
+	"io"
+)
+
+// wasapi plays sounds using the default WASAPI render device.
+// Implements sink.
+type wasapi struct {
+	out      *C.wasOutput // Render client. Nil when closed.
+	channels int          // Interleaved channels.
+}
+
+// newSink returns the windows native sink.
+func newSink() sink { return &wasapi{} }
+
+// wasapiLatency is the device buffer size in 100 nanosecond units.
+const wasapiLatency = 500000
+
+// Implement sink.
+func (w *wasapi) open(frequency, channels int) error {
+	if w.out = C.wasOpen(C.int(frequency), C.int(channels), wasapiLatency); w.out == nil {
+		return fmt.Errorf("wasapi: could not open render device")
+	}
+	w.channels = channels
+	return nil
+}
+
+// Implement sink.
+func (w *wasapi) write(samples []int16) error {
+	if len(samples) < w.channels {
+		return nil
+	}
+	frames := C.UINT32(len(samples) / w.channels)
+	if hr := C.wasWrite(w.out, (*C.short)(&samples[0]), frames); hr < 0 {
+		return fmt.Errorf("wasapi: write error %X", uint32(hr))
+	}
+	return nil
+}
+
+// Implement sink.
+func (w *wasapi) close() {
+	if w.out != nil {
+		C.wasClose(w.out)
+		w.out = nil
+	}
+}
+
+// Implement sink. Recording is not yet supported by the windows native layer.
+func (w *wasapi) record(d *Data, seconds float64) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("wasapi: capture is not supported")
+}
@@ -0,0 +1,358 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// bindings.go maps named actions and axes to user inputs.
+// DESIGN: Bindings are application helpers, like the camera controllers.
+//         Applications check actions instead of keys so that the inputs
+//         can be changed by players without changing the application.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bindings map named actions, like "jump", and named axes, like "move_x",
+// to keys, mouse buttons, and gamepad inputs. Actions are pressed or not
+// pressed, while axes range from -1 to 1. For example:
+//     b := vu.NewBindings()
+//     b.Bind("jump", vu.KSpace, vu.PadA)
+//     b.BindAxis("move_x", vu.KA, vu.KD)
+//     b.BindPadAxis("move_x", vu.PadLX)
+//     ...
+//     if b.Pressed(in, "jump") { ... } // in App.Update.
+//     move := b.Axis(in, "move_x")
+// Bindings can be changed while the application runs, and can be saved
+// and loaded to keep player changes.
+type Bindings struct {
+	Pad      int     // Gamepad slot for gamepad inputs. Default 0.
+	DeadZone float64 // Gamepad axis values ignored near 0. Default 0.2.
+
+	actions map[string][]int      // Action inputs by action name.
+	axes    map[string][]axisBind // Axis inputs by axis name.
+}
+
+// axisBind is one axis input: either a pair of keys or buttons
+// for the negative and positive directions, or a gamepad axis.
+type axisBind struct {
+	neg, pos int  // Key or button codes.
+	axis     int  // Gamepad axis index.
+	pad      bool // True for a gamepad axis.
+}
+
+// NewBindings creates an empty set of bindings.
+func NewBindings() *Bindings {
+	return &Bindings{DeadZone: 0.2, actions: map[string][]int{}, axes: map[string][]axisBind{}}
+}
+
+// Bind adds keys, mouse buttons, or gamepad buttons to the named action.
+// The action is pressed when any of its inputs are pressed.
+func (b *Bindings) Bind(action string, codes ...int) {
+	b.actions[action] = append(b.actions[action], codes...)
+}
+
+// BindAxis adds a pair of keys or buttons to the named axis.
+// Pressing neg moves the axis to -1 and pressing pos moves it to 1.
+func (b *Bindings) BindAxis(axis string, neg, pos int) {
+	b.axes[axis] = append(b.axes[axis], axisBind{neg: neg, pos: pos})
+}
+
+// BindPadAxis adds a gamepad axis, like PadLX, to the named axis.
+func (b *Bindings) BindPadAxis(axis string, padAxis int) {
+	b.axes[axis] = append(b.axes[axis], axisBind{axis: padAxis, pad: true})
+}
+
+// Unbind removes all inputs from the named action or axis.
+// Use Unbind followed by Bind to rebind an action.
+func (b *Bindings) Unbind(name string) {
+	delete(b.actions, name)
+	delete(b.axes, name)
+}
+
+// Codes returns the keys and buttons bound to the named action.
+// The returned slice is owned by the bindings and should not be changed.
+func (b *Bindings) Codes(action string) []int { return b.actions[action] }
+
+// Down returns true while any of the action inputs are held down.
+func (b *Bindings) Down(in *Input, action string) bool {
+	for _, code := range b.actions[action] {
+		if d, ok := b.duration(in, code); ok && d >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Pressed returns true for the update where the action was pressed.
+// This includes inputs pressed and released within the same update.
+func (b *Bindings) Pressed(in *Input, action string) bool {
+	pressed := false
+	for _, code := range b.actions[action] {
+		if d, ok := b.duration(in, code); ok && d > 1 {
+			return false // already held by another input.
+		} else if ok && (d == 1 || d == KeyReleased) {
+			pressed = true
+		}
+	}
+	return pressed
+}
+
+// Released returns true for the update where the action was released.
+// Actions with other inputs still held are not released.
+func (b *Bindings) Released(in *Input, action string) bool {
+	released := false
+	for _, code := range b.actions[action] {
+		if d, ok := b.duration(in, code); ok && d >= 0 {
+			return false
+		} else if ok {
+			released = true
+		}
+	}
+	return released
+}
+
+// Axis returns the named axis value from -1 to 1. The axis inputs are
+// combined so that opposing inputs cancel each other out.
+func (b *Bindings) Axis(in *Input, axis string) float64 {
+	v := 0.0
+	for _, ab := range b.axes[axis] {
+		switch {
+		case ab.pad:
+			v += b.padAxis(in, ab.axis)
+		default:
+			if d, ok := b.duration(in, ab.neg); ok && d >= 0 {
+				v--
+			}
+			if d, ok := b.duration(in, ab.pos); ok && d >= 0 {
+				v++
+			}
+		}
+	}
+	return math.Max(-1, math.Min(1, v))
+}
+
+// Capture returns a newly pressed key or button, if any. Used when
+// rebinding to get the next input pressed by the player. Keys are
+// checked before gamepad buttons, and the lowest code is returned when
+// several are pressed in the same update.
+func (b *Bindings) Capture(in *Input) (code int, ok bool) {
+	capture := func(down map[int]int) {
+		for c, d := range down {
+			if (d == 1 || d == KeyReleased) && (!ok || c < code) {
+				code, ok = c, true
+			}
+		}
+	}
+	if capture(in.Down); !ok && b.Pad >= 0 && b.Pad < len(in.Pads) {
+		capture(in.Pads[b.Pad].Down)
+	}
+	return code, ok
+}
+
+// duration returns the pressed duration of a key or button.
+// Gamepad buttons are read from the bindings gamepad.
+func (b *Bindings) duration(in *Input, code int) (d int, ok bool) {
+	if code >= PadA && code <= PadRight {
+		if b.Pad < 0 || b.Pad >= len(in.Pads) {
+			return 0, false
+		}
+		d, ok = in.Pads[b.Pad].Down[code]
+		return d, ok
+	}
+	d, ok = in.Down[code]
+	return d, ok
+}
+
+// padAxis returns the gamepad axis value with the dead zone removed.
+// Values outside the dead zone are rescaled to start from 0.
+func (b *Bindings) padAxis(in *Input, axis int) float64 {
+	if b.Pad < 0 || b.Pad >= len(in.Pads) || axis < 0 || axis >= len(in.Pads[b.Pad].Axes) {
+		return 0
+	}
+	v := in.Pads[b.Pad].Axes[axis]
+	if math.Abs(v) <= b.DeadZone || b.DeadZone >= 1 {
+		return 0
+	}
+	return math.Copysign((math.Abs(v)-b.DeadZone)/(1-b.DeadZone), v)
+}
+
+// Saving and loading.
+// ===========================================================================
+
+// Save writes the bindings as text, one action or axis input per line.
+// Inputs are written using their constant names, ie: KSpace, PadA.
+//    action name code...   : action inputs.
+//    axis   name neg pos   : axis keys or buttons.
+//    axis   name padaxis   : gamepad axis.
+func (b *Bindings) Save(w io.Writer) error {
+	names := []string{}
+	for name := range b.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		codes := []string{}
+		for _, code := range b.actions[name] {
+			codes = append(codes, codeName(code))
+		}
+		if _, err := fmt.Fprintf(w, "action %s %s\n", name, strings.Join(codes, " ")); err != nil {
+			return err
+		}
+	}
+	names = names[:0]
+	for name := range b.axes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, ab := range b.axes[name] {
+			line := fmt.Sprintf("axis %s %s %s\n", name, codeName(ab.neg), codeName(ab.pos))
+			if ab.pad {
+				line = fmt.Sprintf("axis %s %s\n", name, padAxisName(ab.axis))
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Load replaces the bindings with bindings read from text written
+// by Save. Lines starting with # are comments. The bindings are
+// unchanged if there is an error.
+func (b *Bindings) Load(r io.Reader) error {
+	actions, axes := map[string][]int{}, map[string][]axisBind{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if err := bindLine(scanner.Text(), actions, axes); err != nil {
+			return fmt.Errorf("Invalid bindings line %d: %s", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	b.actions, b.axes = actions, axes
+	return nil
+}
+
+// bindLine parses one line of saved bindings.
+func bindLine(line string, actions map[string][]int, axes map[string][]axisBind) error {
+	tokens := strings.Fields(line)
+	if len(tokens) == 0 || strings.HasPrefix(tokens[0], "#") {
+		return nil
+	}
+	switch {
+	case tokens[0] == "action" && len(tokens) >= 2:
+		for _, token := range tokens[2:] {
+			code, ok := bindCode(token)
+			if !ok {
+				return fmt.Errorf("unknown input %s", token)
+			}
+			actions[tokens[1]] = append(actions[tokens[1]], code)
+		}
+		if _, ok := actions[tokens[1]]; !ok {
+			actions[tokens[1]] = []int{}
+		}
+	case tokens[0] == "axis" && len(tokens) == 3:
+		axis, ok := padAxisNames[tokens[2]]
+		if !ok {
+			return fmt.Errorf("unknown gamepad axis %s", tokens[2])
+		}
+		axes[tokens[1]] = append(axes[tokens[1]], axisBind{axis: axis, pad: true})
+	case tokens[0] == "axis" && len(tokens) == 4:
+		neg, nok := bindCode(tokens[2])
+		pos, pok := bindCode(tokens[3])
+		if !nok || !pok {
+			return fmt.Errorf("unknown axis inputs %s %s", tokens[2], tokens[3])
+		}
+		axes[tokens[1]] = append(axes[tokens[1]], axisBind{neg: neg, pos: pos})
+	default:
+		return fmt.Errorf("expected action or axis binding")
+	}
+	return nil
+}
+
+// codeName returns the constant name for a key or button code.
+// The first name alphabetically is used in case platforms reuse a code.
+func codeName(code int) string {
+	match := ""
+	for name, c := range bindNames {
+		if c == code && (match == "" || name < match) {
+			match = name
+		}
+	}
+	if match == "" {
+		return strconv.Itoa(code)
+	}
+	return match
+}
+
+// bindCode returns the key or button code for a saved name.
+// Codes without a name are saved as numbers.
+func bindCode(name string) (code int, ok bool) {
+	if code, ok = bindNames[name]; !ok {
+		var err error
+		code, err = strconv.Atoi(name)
+		ok = err == nil
+	}
+	return code, ok
+}
+
+// padAxisName returns the constant name for a gamepad axis.
+func padAxisName(axis int) string {
+	for name, a := range padAxisNames {
+		if a == axis {
+			return name
+		}
+	}
+	return strconv.Itoa(axis)
+}
+
+// bindNames are the saved names for keys and buttons. Names are used
+// since key codes are different on each platform.
+var bindNames = map[string]int{
+	"K0": K0, "K1": K1, "K2": K2, "K3": K3,
+	"K4": K4, "K5": K5, "K6": K6, "K7": K7,
+	"K8": K8, "K9": K9, "KA": KA, "KB": KB,
+	"KC": KC, "KD": KD, "KE": KE, "KF": KF,
+	"KG": KG, "KH": KH, "KI": KI, "KJ": KJ,
+	"KK": KK, "KL": KL, "KM": KM, "KN": KN,
+	"KO": KO, "KP": KP, "KQ": KQ, "KR": KR,
+	"KS": KS, "KT": KT, "KU": KU, "KV": KV,
+	"KW": KW, "KX": KX, "KY": KY, "KZ": KZ,
+	"KEqual": KEqual, "KMinus": KMinus, "KRBkt": KRBkt, "KLBkt": KLBkt,
+	"KQt": KQt, "KSemi": KSemi, "KBSl": KBSl, "KComma": KComma,
+	"KSlash": KSlash, "KDot": KDot, "KGrave": KGrave, "KRet": KRet,
+	"KTab": KTab, "KSpace": KSpace, "KDel": KDel, "KEsc": KEsc,
+	"KF1": KF1, "KF2": KF2, "KF3": KF3, "KF4": KF4,
+	"KF5": KF5, "KF6": KF6, "KF7": KF7, "KF8": KF8,
+	"KF9": KF9, "KF10": KF10, "KF11": KF11, "KF12": KF12,
+	"KF13": KF13, "KF14": KF14, "KF15": KF15, "KF16": KF16,
+	"KF17": KF17, "KF18": KF18, "KF19": KF19, "KHome": KHome,
+	"KPgUp": KPgUp, "KFDel": KFDel, "KEnd": KEnd, "KPgDn": KPgDn,
+	"KLa": KLa, "KRa": KRa, "KDa": KDa, "KUa": KUa,
+	"KKpDot": KKpDot, "KKpMlt": KKpMlt, "KKpAdd": KKpAdd, "KKpClr": KKpClr,
+	"KKpDiv": KKpDiv, "KKpEnt": KKpEnt, "KKpSub": KKpSub, "KKpEql": KKpEql,
+	"KKp0": KKp0, "KKp1": KKp1, "KKp2": KKp2, "KKp3": KKp3,
+	"KKp4": KKp4, "KKp5": KKp5, "KKp6": KKp6, "KKp7": KKp7,
+	"KKp8": KKp8, "KKp9": KKp9, "KLm": KLm, "KMm": KMm,
+	"KRm": KRm, "KCtl": KCtl, "KFn": KFn, "KShift": KShift,
+	"KCmd": KCmd, "KAlt": KAlt, "PadA": PadA, "PadB": PadB,
+	"PadX": PadX, "PadY": PadY, "PadBack": PadBack, "PadGuide": PadGuide,
+	"PadStart": PadStart, "PadLStick": PadLStick, "PadRStick": PadRStick, "PadLShoulder": PadLShoulder,
+	"PadRShoulder": PadRShoulder, "PadUp": PadUp, "PadDown": PadDown, "PadLeft": PadLeft,
+	"PadRight": PadRight,
+}
+
+// padAxisNames are the saved names for gamepad axes.
+var padAxisNames = map[string]int{
+	"PadLX": PadLX, "PadLY": PadLY, "PadRX": PadRX,
+	"PadRY": PadRY, "PadLT": PadLT, "PadRT": PadRT,
+}
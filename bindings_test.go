@@ -0,0 +1,108 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Actions are pressed by any of their keys or buttons.
+func TestBindActions(t *testing.T) {
+	b := NewBindings()
+	b.Bind("jump", KSpace, PadA)
+	in := &Input{Down: map[int]int{KSpace: 1}}
+	in.Pads[0].Down = map[int]int{}
+	if !b.Pressed(in, "jump") || !b.Down(in, "jump") || b.Released(in, "jump") {
+		t.Errorf("Expected jump pressed")
+	}
+	in.Down[KSpace], in.Pads[0].Down[PadA] = 5, 1
+	if b.Pressed(in, "jump") || !b.Down(in, "jump") {
+		t.Errorf("Expected jump held")
+	}
+	delete(in.Pads[0].Down, PadA)
+	in.Down[KSpace] = KeyReleased + 5
+	if !b.Released(in, "jump") || b.Down(in, "jump") {
+		t.Errorf("Expected jump released")
+	}
+	in.Down[KSpace] = KeyReleased // tapped within one update.
+	if !b.Pressed(in, "jump") || !b.Released(in, "jump") {
+		t.Errorf("Expected tapped jump pressed and released")
+	}
+
+	// captures pick the lowest newly pressed key before any buttons.
+	in.Down = map[int]int{KW: 1, KA: KeyReleased, KSpace: 3}
+	in.Pads[0].Down = map[int]int{PadA: 1}
+	want := KW // key codes differ by platform.
+	if KA < KW {
+		want = KA
+	}
+	for cnt := 0; cnt < 10; cnt++ {
+		if code, ok := b.Capture(in); !ok || code != want {
+			t.Fatalf("Expected capture of the lowest pressed code, got %d", code)
+		}
+	}
+	in.Down = map[int]int{}
+	if code, ok := b.Capture(in); !ok || code != PadA {
+		t.Errorf("Expected pad button capture, got %d", code)
+	}
+	b.Unbind("jump")
+	b.Bind("jump", KW)
+	if b.Released(in, "jump") || len(b.Codes("jump")) != 1 {
+		t.Errorf("Expected jump rebound")
+	}
+}
+
+// Axes combine keys and gamepad sticks.
+func TestBindAxes(t *testing.T) {
+	b := NewBindings()
+	b.BindAxis("move_x", KA, KD)
+	b.BindPadAxis("move_x", PadLX)
+	in := &Input{Down: map[int]int{KA: 3}}
+	in.Pads[0].Axes[PadLX] = 0.6
+	if v := b.Axis(in, "move_x"); !lin.Aeq(v, -0.5) {
+		t.Errorf("Expected combined axis got %f", v)
+	}
+	if in.Pads[0].Axes[PadLX] = 0.1; b.Axis(in, "move_x") != -1 {
+		t.Errorf("Expected dead zone to be ignored")
+	}
+	if b.Axis(in, "unknown") != 0 {
+		t.Errorf("Expected unbound axis to be 0")
+	}
+}
+
+// Saved bindings load back into the same bindings.
+// Key and mouse codes are never treated as gamepad buttons.
+func TestBindKeysNotPads(t *testing.T) {
+	for name, code := range bindNames {
+		if strings.HasPrefix(name, "K") && code >= PadA && code <= PadRight {
+			t.Errorf("Expected %s %#x outside the gamepad buttons", name, code)
+		}
+	}
+}
+
+func TestBindSave(t *testing.T) {
+	b := NewBindings()
+	b.Bind("jump", KSpace, PadA)
+	b.BindAxis("move_x", KA, KD)
+	b.BindPadAxis("move_x", PadLX)
+	buff := &bytes.Buffer{}
+	if err := b.Save(buff); err != nil {
+		t.Fatal(err)
+	}
+	saved := buff.String()
+	loaded := NewBindings()
+	if err := loaded.Load(strings.NewReader("# player one\n" + saved)); err != nil {
+		t.Fatal(err)
+	}
+	if buff.Reset(); loaded.Save(buff) != nil || buff.String() != saved {
+		t.Errorf("Expected same bindings got\n%s\nfrom\n%s", buff.String(), saved)
+	}
+	if err := loaded.Load(strings.NewReader("action jump KNope")); err == nil || len(loaded.Codes("jump")) != 2 {
+		t.Errorf("Expected error and unchanged bindings")
+	}
+}
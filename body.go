@@ -104,6 +104,12 @@ func (bs *bodies) dispose(id eid) {
 func (bs *bodies) stepVelocities(eng *engine, dts float64) {
 	bs.physics.Step(bs.bods, dts)
 
+	// Bodies that started colliding play their impact sounds.
+	for _, im := range bs.physics.Impacts() {
+		bs.impact(eng, im.A, im.Impulse)
+		bs.impact(eng, im.B, im.Impulse)
+	}
+
 	// The associated pov needs to be marked as dirty in order to
 	// update the transform.
 	for _, eid := range bs.eids {
@@ -115,3 +121,13 @@ func (bs *bodies) stepVelocities(eng *engine, dts float64) {
 	//         associated povs for updating the stable flag. How much Quicker
 	//         than the current hash lookup?
 }
+
+// impact triggers the impact event for the pov of the given colliding body.
+func (bs *bodies) impact(eng *engine, b physics.Body, impulse float64) {
+	for cnt, bod := range bs.bods {
+		if bod.Eq(b) {
+			eng.sounds.trigger(bs.eids[cnt], impactEvent, impulse)
+			return
+		}
+	}
+}
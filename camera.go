@@ -23,6 +23,8 @@ package vu
 //   the pov hierarchy traversal.
 
 import (
+	"math"
+
 	"github.com/gazed/vu/math/lin"
 	"github.com/gazed/vu/render"
 )
@@ -57,23 +59,65 @@ type Camera struct {
 	xrot   *lin.Q // X-axis rotation: from Pitch.
 	target uint32 // render layer target. Default 0.
 
+	// Render pass order and clear controls.
+	order   int        // Render order. Lower orders are drawn first.
+	enabled bool       // Disabled cameras don't render. Default true.
+	pass    uint32     // Unique camera render pass.
+	clear   int        // ClearDefault, ClearColor, ClearDepth, ClearNone.
+	cc      [4]float32 // Clear color for ClearColor.
+
+	// Physical camera exposure and focus.
+	exposure float64 // Scene light multiplier for the "exposure" uniform.
+	focus    float64 // Distance to objects in focus. Default 10.
+	fstop    float64 // Aperture f-stop. Smaller is blurrier. Default 2.8.
+
+	// Saved poses and any active transition between poses.
+	poses map[string]CameraPose // Named poses.
+	trans *transition           // Active transition. Nil when none.
+
+	// Perspective depth range controls.
+	fov, ratio float64 // Perspective projection. Zero fov for others.
+	near, far  float64 // Perspective depth range limits.
+	fit        bool    // Fit near and far to the visible models.
+	fitn, fitf float64 // Nearest and farthest visible model depths.
+	logz       bool    // Use a logarithmic depth buffer.
+
+	// Orthographic projection values.
+	ortho bool       // True if using an orthographic projection.
+	box   [6]float64 // Unzoomed left, right, bottom, top, near, far.
+	zoom  float64    // Orthographic magnification. Default 1.
+	snap  bool       // Snap the view to whole pixels.
+
+	// Camera shake layered on top of the view transform.
+	shake    shake   // Current shake.
+	shakeRot *lin.Q  // Shake rotation.
+	shakeM   *lin.M4 // Shake rotation matrix.
+
 	// Track the view, projection matricies and their inverses.
 	vm  *lin.M4 // View part of MVP matrix.
 	ivm *lin.M4 // Inverse view matrix.
 	pm  *lin.M4 // Projection part of MVP matrix.
 	ipm *lin.M4 // Inverse projection matrix.
 
+	// Projection before the oblique near plane is applied.
+	oblique bool    // True if pm has an oblique near plane.
+	opm     *lin.M4 // Projection without the oblique near plane.
+	oipm    *lin.M4 // Inverse projection without the oblique near plane.
+	plane   *lin.V4 // World space oblique near plane.
+
 	// Scratch variables needed each update.
 	q0  *lin.Q  // Scratch for camera transform calculations.
 	qx  *lin.Q  // Scratch for camera transform calculations.
 	v0  *lin.V4 // Scratch for pick ray calculations.
+	v1  *lin.V4 // Scratch for pick ray calculations.
+	vpm *lin.M4 // Scratch for frustum calculations.
 	ray *lin.V3 // Scratch for pick ray calculations.
 }
 
 // newCamera creates a default rendering field that is looking down
 // the positive Z axis with positive Y up.
 func newCamera() *Camera {
-	c := &Camera{Depth: true}
+	c := &Camera{Depth: true, enabled: true, zoom: 1, exposure: 1, focus: 10, fstop: 2.8}
 	c.Vt = VP
 	c.Look = lin.NewQ().SetAa(0, 1, 0, 0)
 	c.at = lin.NewT()
@@ -82,8 +126,15 @@ func newCamera() *Camera {
 	c.ivm = (&lin.M4{}).Set(lin.M4I)
 	c.pm = &lin.M4{}
 	c.ipm = &lin.M4{}
+	c.opm = &lin.M4{}
+	c.oipm = &lin.M4{}
+	c.plane = &lin.V4{}
 	c.q0 = &lin.Q{}
 	c.v0 = &lin.V4{}
+	c.v1 = &lin.V4{}
+	c.vpm = &lin.M4{}
+	c.shakeRot = &lin.Q{}
+	c.shakeM = &lin.M4{}
 	c.ray = &lin.V3{}
 	return c
 }
@@ -106,6 +157,23 @@ func (c *Camera) isCulled(px, py, pz float64) bool {
 func (c *Camera) updateTransform() {
 	c.transform(c.vm)            // view transform.
 	ivp(c.at, c.qx, c.q0, c.ivm) // inverse view transform.
+	if c.snap && c.ortho {
+		wx, wy := c.vm.Wx, c.vm.Wy
+		c.vm.Wx = math.Floor(c.vm.Wx*c.zoom+0.5) / c.zoom // whole pixels.
+		c.vm.Wy = math.Floor(c.vm.Wy*c.zoom+0.5) / c.zoom
+		c.ivm.TranslateTM(wx-c.vm.Wx, wy-c.vm.Wy, 0) // undo snap for picking.
+	}
+	if s := &c.shake; s.left > 0 {
+		p, y, r := s.offsets()
+		c.shakeRot.SetAa(1, 0, 0, lin.Rad(p))
+		c.q0.SetAa(0, 1, 0, lin.Rad(y))
+		c.shakeRot.Mult(c.shakeRot, c.q0)
+		c.q0.SetAa(0, 0, 1, lin.Rad(r))
+		c.shakeRot.Mult(c.shakeRot, c.q0)
+		c.vm.Mult(c.vm, c.shakeM.SetQ(c.shakeRot)) // shake in view space.
+		c.q0.Inv(c.shakeRot)
+		c.ivm.Mult(c.shakeM.SetQ(c.q0), c.ivm) // undo shake for picking.
+	}
 }
 
 // At returns the cameras current location in world space.
@@ -165,28 +233,268 @@ func (c *Camera) SetUI() *Camera {
 	return c
 }
 
+// SetClear controls how the camera clears the screen, or its render
+// layer, before it draws. Cameras default to ClearDefault where the
+// screen is cleared with the State background color and render layers
+// only clear depth. ClearColor clears to the given color. ClearDepth and
+// ClearNone keep previous camera colors for multi-pass compositing.
+func (c *Camera) SetClear(mode int, r, g, b, a float64) *Camera {
+	c.clear = mode
+	c.cc = [4]float32{float32(r), float32(g), float32(b), float32(a)}
+	return c
+}
+
+// SetOrder sets when the camera renders relative to other cameras.
+// Everything seen by a lower order camera is drawn before anything seen
+// by a higher order camera, independent of Pov creation order. Cameras
+// default to order 0. For example a world camera at 0, a weapon view
+// model camera at 1, and a UI camera at 2.
+func (c *Camera) SetOrder(order int) *Camera {
+	c.order = order
+	return c
+}
+
+// Order returns the camera render order.
+func (c *Camera) Order() int { return c.order }
+
+// SetEnabled turns camera rendering on or off. A disabled camera, and
+// the Pov hierarchy it views, are not rendered. Cameras are enabled
+// by default.
+func (c *Camera) SetEnabled(enabled bool) *Camera {
+	c.enabled = enabled
+	return c
+}
+
+// Enabled returns true if the camera is rendering.
+func (c *Camera) Enabled() bool { return c.enabled }
+
+// Camera clear modes used in Camera.SetClear.
+const (
+	ClearDefault = render.ClearDefault // Background color or layer depth.
+	ClearColor   = render.ClearColor   // Clear to the camera color.
+	ClearDepth   = render.ClearDepth   // Keep colors, clear depth.
+	ClearNone    = render.ClearNone    // Keep colors and depth.
+)
+
 // SetPerspective makes the camera use a 3D projection.
 // This is the projection part of model-view-projection.
 func (c *Camera) SetPerspective(fov, ratio, near, far float64) {
+	c.ortho = false
+	c.fov, c.ratio, c.near, c.far = fov, ratio, near, far
 	c.pm.Persp(fov, ratio, near, far)
 	c.ipm.PerspInv(fov, ratio, near, far)
+	c.oblique = false
 	c.updateTransform()
 }
 
+// SetFocalLength sets a perspective projection using physical camera
+// values, matching cameras from photographs or modelling tools. The
+// focal length and sensor height are in millimeters, ie: 50mm lens on
+// a 24mm high full frame sensor.
+func (c *Camera) SetFocalLength(focal, sensor, ratio, near, far float64) {
+	c.SetPerspective(FocalFov(focal, sensor), ratio, near, far)
+}
+
+// FocalFov returns the vertical field of view in degrees for a physical
+// camera lens focal length and sensor height in millimeters.
+func FocalFov(focal, sensor float64) float64 {
+	return lin.Deg(2 * math.Atan(sensor/(2*focal)))
+}
+
+// SetExposure sets the camera exposure from the physical camera aperture
+// f-stop, shutter time in seconds, and ISO sensitivity. For example a
+// sunny day is around f/16, 1/100s, and ISO 100. The exposure is given
+// to shaders in the "exposure" uniform, ie: "tonemap", as a multiplier
+// for scene light values.
+func (c *Camera) SetExposure(aperture, shutter, iso float64) {
+	ev100 := math.Log2(aperture * aperture / shutter * 100 / iso)
+	c.exposure = 1 / (1.2 * math.Pow(2, ev100)) // 1.2: lens and sensor losses.
+}
+
+// Exposure returns the scene light multiplier. The default is 1.
+func (c *Camera) Exposure() float64 { return c.exposure }
+
+// SetFocus sets the distance to objects that are in focus and the lens
+// aperture f-stop. Smaller f-stops have a shallower depth of field which
+// blurs more of the scene. Used by the depth of field effect. See DOF.
+func (c *Camera) SetFocus(distance, fstop float64) {
+	if distance > 0 && fstop > 0 {
+		c.focus, c.fstop = distance, fstop
+	}
+}
+
+// Focus returns the focus distance and aperture f-stop.
+func (c *Camera) Focus() (distance, fstop float64) { return c.focus, c.fstop }
+
+// SetDepthFit enables fitting the perspective near and far planes to the
+// models visible each frame, keeping them within the near and far limits
+// given to SetPerspective. Depth precision is spread over the visible
+// models instead of the full depth range. Ignored for orthographic and
+// custom projections.
+func (c *Camera) SetDepthFit(fit bool) {
+	c.fit, c.fitn, c.fitf = fit, math.MaxFloat64, 0
+	if !fit && c.fov > 0 {
+		c.pm.Persp(c.fov, c.ratio, c.near, c.far) // restore the limits.
+		c.ipm.PerspInv(c.fov, c.ratio, c.near, c.far)
+		c.oblique = false
+	}
+}
+
+// fitModel widens the visible depth range to include a model at the
+// given world location with the given bounding radius. An infinite
+// radius widens the range to the perspective limits.
+func (c *Camera) fitModel(wx, wy, wz, radius float64) {
+	if c.fit {
+		vec := c.v0.SetS(wx, wy, wz, 1)
+		vec.MultvM(vec, c.vm)
+		c.fitn = math.Min(c.fitn, -vec.Z-radius) // view looks down -Z.
+		c.fitf = math.Max(c.fitf, -vec.Z+radius)
+	}
+}
+
+// fitDepth updates the perspective projection to the visible depth
+// range gathered since the last call. Expected to be called once
+// per frame after all the visible models have been fitted.
+func (c *Camera) fitDepth() {
+	if !c.fit || c.ortho || c.fov <= 0 {
+		return
+	}
+	near, far := math.Max(c.near, c.fitn), math.Min(c.far, c.fitf)
+	if near < far { // something is visible.
+		c.pm.Persp(c.fov, c.ratio, near, far)
+		c.ipm.PerspInv(c.fov, c.ratio, near, far)
+		if c.oblique {
+			c.obliqueClip() // keep the oblique plane with the fitted depth.
+		}
+	}
+	c.fitn, c.fitf = math.MaxFloat64, 0
+}
+
+// SetLogDepth enables a logarithmic depth buffer. Depth precision
+// is spread evenly across distances which removes z-fighting between
+// distant surfaces in large scenes, ie: planets and terrain. Only shaders
+// that support the "logz" uniform, ie: "solid", "phong", "nmap", are
+// affected. Effects that linearize the scene depth, ie: GI and DOF, expect
+// a standard depth buffer.
+func (c *Camera) SetLogDepth(on bool) { c.logz = on }
+
+// LogDepth returns true if the camera uses a logarithmic depth buffer.
+func (c *Camera) LogDepth() bool { return c.logz }
+
+// logDepth returns the "logz" shader uniform value.
+// Zero disables logarithmic depth.
+func (c *Camera) logDepth() float64 {
+	if !c.logz || c.ortho || c.far <= 0 {
+		return 0
+	}
+	return 2 / math.Log2(c.far+1)
+}
+
 // SetOrthographic makes the camera use a 2D projection.
 // This is the projection part of model-view-projection.
+// Same as SetOrtho.
 func (c *Camera) SetOrthographic(left, right, bottom, top, near, far float64) {
-	c.pm.Ortho(left, right, bottom, top, near, far)
-	c.transform(c.vm)
+	c.SetOrtho(left, right, bottom, top, near, far)
+}
+
+// SetOrtho makes the camera use an orthographic projection where
+// objects keep their size regardless of distance. Used for 2D games,
+// UI scenes, and isometric views. Pixel perfect 2D rendering expects
+// the extents to match the window size, ie: 0, ww, 0, wh. The current
+// zoom is applied around the center of the extents.
+func (c *Camera) SetOrtho(left, right, bottom, top, near, far float64) {
+	c.ortho = true
+	c.box = [6]float64{left, right, bottom, top, near, far}
+	c.setOrtho()
+}
+
+// setOrtho applies the zoom to the orthographic extents.
+func (c *Camera) setOrtho() {
+	l, r, b, t, n, f := c.box[0], c.box[1], c.box[2], c.box[3], c.box[4], c.box[5]
+	cx, cy := (l+r)*0.5, (b+t)*0.5
+	hw, hh := (r-l)*0.5/c.zoom, (t-b)*0.5/c.zoom
+	l, r, b, t = cx-hw, cx+hw, cy-hh, cy+hh
+	c.pm.Ortho(l, r, b, t, n, f)
+	c.ipm.OrthoInv(l, r, b, t, n, f)
+	c.oblique = false
+	c.updateTransform()
+}
+
+// SetProjection sets a custom projection matrix, ie: for special effects
+// or head mounted displays. The inverse is calculated for picking.
+func (c *Camera) SetProjection(pm *lin.M4) {
+	c.ortho, c.fov = false, 0
+	c.pm.Set(pm)
+	c.ipm.Inv(pm)
+	c.oblique = false
+	c.updateTransform()
+}
+
+// SetObliqueClip replaces the current projection near plane with the
+// given world space plane. Only points where nx*x + ny*y + nz*z + d > 0
+// are rendered. Used by portal and water reflection cameras rendering
+// to layers to remove objects behind the portal or below the water.
+// The far plane is adjusted, and depth precision reduced, as a side
+// effect. Expected to be called after the camera moves and after
+// the projection is set. Each call replaces the previous oblique plane.
+// The plane is reapplied each frame when fitting depth, see SetDepthFit.
+// See:
+//    http://www.terathon.com/lengyel/Lengyel-Oblique.pdf
+func (c *Camera) SetObliqueClip(nx, ny, nz, d float64) {
+	if c.oblique {
+		c.pm.Set(c.opm) // start from the projection without a plane.
+		c.ipm.Set(c.oipm)
+	}
+	c.plane.SetS(nx, ny, nz, d)
+	c.obliqueClip()
+}
 
-	// Inverse matrix currently ignored for Orthographic.
-	// Ortho views are expected to match the screen pixel sizes.
-	c.ipm.Set(lin.M4I)
+// obliqueClip replaces the near plane of the current projection
+// with the oblique plane.
+func (c *Camera) obliqueClip() {
+	c.opm.Set(c.pm)
+	c.oipm.Set(c.ipm)
+	c.oblique = true
+	ivm := c.vpm.Inv(c.vm)                    // view to world.
+	cp := c.v0.MultMv(ivm, c.v1.Set(c.plane)) // view space plane.
+	corner := c.v1.SetS(math.Copysign(1, cp.X), math.Copysign(1, cp.Y), 1, 1)
+	corner.MultvM(corner, c.ipm) // far corner opposite the plane.
+	if dot := cp.Dot(corner); dot != 0 {
+		cp.Scale(cp, 2/dot)
+		pm := c.pm
+		pm.Xz, pm.Yz, pm.Zz, pm.Wz = cp.X-pm.Xw, cp.Y-pm.Yw, cp.Z-pm.Zw, cp.W-pm.Ww
+		c.ipm.Inv(pm)
+	}
+}
+
+// Zoom returns the orthographic magnification.
+func (c *Camera) Zoom() float64 { return c.zoom }
+
+// SetZoom sets the orthographic magnification where values larger
+// than 1 zoom in and values between 0 and 1 zoom out. Ignored for
+// perspective cameras where the field of view controls zoom.
+func (c *Camera) SetZoom(zoom float64) {
+	if zoom > 0 {
+		c.zoom = zoom
+		if c.ortho {
+			c.setOrtho()
+		}
+	}
+}
+
+// SetPixelSnap rounds the orthographic view location to whole pixels
+// so that 2D sprites don't shimmer as the camera moves. Expects the
+// orthographic extents to be in pixels. See SetOrtho.
+func (c *Camera) SetPixelSnap(snap bool) {
+	c.snap = snap
+	c.updateTransform()
 }
 
 // Ray applies inverse transforms to derive world space coordinates
 // for a ray projected from the camera through the mouse's mx,my
 // screen position given window width and height ww,wh.
+// Orthographic cameras ignore the projection, as they always have,
+// so use PickRay to pick models with an orthographic camera.
 func (c *Camera) Ray(mx, my, ww, wh int) (x, y, z float64) {
 	c.ray.SetS(0, 0, 0)
 	if mx >= 0 && mx <= ww && my >= 0 && my <= wh {
@@ -195,7 +503,11 @@ func (c *Camera) Ray(mx, my, ww, wh int) (x, y, z float64) {
 		clip := c.v0.SetS(clipx, clipy, -1, 1)
 
 		// Use the inverse perspective to go from clip to eye (view) coordinates.
-		eye := clip.MultvM(clip, c.ipm)
+		ipm := c.ipm
+		if c.ortho {
+			ipm = lin.M4I // ortho extents are not applied.
+		}
+		eye := clip.MultvM(clip, ipm)
 		eye.Z = -1 // into the screen
 		eye.W = 0  // want a vector, not a point
 
@@ -227,6 +539,222 @@ func (c *Camera) Screen(wx, wy, wz float64, ww, wh int) (sx, sy int) {
 	return sx, sy
 }
 
+// PickRay returns the world space origin and unit direction of a ray
+// projected from the camera through the mouse's mx,my screen position
+// given window width and height ww,wh. The ray starts on the near plane
+// which works for both perspective and orthographic cameras. Use with
+// the physics Ray and Cast to pick models.
+func (c *Camera) PickRay(mx, my, ww, wh int) (ox, oy, oz, dx, dy, dz float64) {
+	clipx := float64(2*mx)/float64(ww) - 1 // mx to range -1:1
+	clipy := float64(2*my)/float64(wh) - 1 // my to range -1:1
+	near := c.unproject(c.v0, clipx, clipy, -1)
+	far := c.unproject(c.v1, clipx, clipy, 1)
+	c.ray.SetS(far.X-near.X, far.Y-near.Y, far.Z-near.Z).Unit()
+	return near.X, near.Y, near.Z, c.ray.X, c.ray.Y, c.ray.Z
+}
+
+// unproject converts normalized device coordinates, range -1:1,
+// into a world space point using the inverse projection and view.
+func (c *Camera) unproject(v *lin.V4, nx, ny, nz float64) *lin.V4 {
+	v.SetS(nx, ny, nz, 1)
+	v.MultvM(v, c.ipm) // clip to eye (view) coordinates.
+	v.SetS(v.X/v.W, v.Y/v.W, v.Z/v.W, 1)
+	return v.MultvM(v, c.ivm) // eye (view) to world coordinates.
+}
+
+// Project applies the camera transform on a 3D point in world space
+// wx,wy,wz and returns the screen pixel location sx,sy for the window
+// width and height ww,wh. Unlike Screen, locations outside the screen
+// are returned so they can be clamped, ie: for off screen HUD markers.
+// The depth ranges from 0 at the near plane to 1 at the far plane.
+// Depth is -1 for points behind the camera and sx,sy are mirrored
+// through the screen center, so flip them to point a marker at the
+// point behind the camera.
+func (c *Camera) Project(wx, wy, wz float64, ww, wh int) (sx, sy, depth float64) {
+	vec := c.v0.SetS(wx, wy, wz, 1)
+	vec.MultvM(vec, c.vm) // apply view matrix.
+	vec.MultvM(vec, c.pm) // apply projection matrix.
+	depth = vec.Z*0.5/vec.W + 0.5
+	if vec.W <= 0 {
+		vec.W = math.Min(vec.W, -lin.Epsilon) // behind the camera.
+		depth = -1
+	}
+	sx = (vec.X*0.5/vec.W + 0.5) * float64(ww)
+	sy = (vec.Y*0.5/vec.W + 0.5) * float64(wh)
+	return sx, sy, depth
+}
+
+// Frustum returns the world space planes of the current view and
+// projection. Useful for application culling, level of detail, and
+// spawning decisions that match what the camera renders.
+func (c *Camera) Frustum() (f lin.Frustum) {
+	f.SetM4(c.vpm.Mult(c.vm, c.pm))
+	return f
+}
+
+// Shake rattles the camera view for impacts and explosions. The shake
+// rotates the view up to amplitude degrees, changing direction about
+// frequency times a second, and fades out over duration seconds.
+// Overlapping shakes use the strongest. Shake does not affect the
+// camera location or orientation values.
+func (c *Camera) Shake(amplitude, frequency, duration float64) {
+	if duration > 0 && amplitude >= c.shake.intensity() {
+		c.shake.amp, c.shake.freq = amplitude, frequency
+		c.shake.dur, c.shake.left = duration, duration
+	}
+}
+
+// Shaking returns true while the camera is shaking.
+func (c *Camera) Shaking() bool { return c.shake.left > 0 }
+
+// CameraPose is a camera location and orientation.
+type CameraPose struct {
+	X, Y, Z    float64 // Location.
+	Pitch, Yaw float64 // Orientation in degrees.
+}
+
+// Pose returns the current camera location and orientation.
+func (c *Camera) Pose() CameraPose {
+	x, y, z := c.At()
+	return CameraPose{X: x, Y: y, Z: z, Pitch: c.Pitch, Yaw: c.Yaw}
+}
+
+// SetPose moves the camera directly to the given pose, stopping
+// any active transition.
+func (c *Camera) SetPose(p CameraPose) {
+	c.trans = nil
+	c.setPose(p)
+}
+
+// setPose updates the camera location and orientation.
+func (c *Camera) setPose(p CameraPose) {
+	c.at.Loc.SetS(p.X, p.Y, p.Z)
+	c.SetPitch(p.Pitch) // Pitch and Yaw update the transform.
+	c.SetYaw(p.Yaw)
+}
+
+// SavePose remembers the current camera pose using the given name.
+func (c *Camera) SavePose(name string) {
+	if c.poses == nil {
+		c.poses = map[string]CameraPose{}
+	}
+	c.poses[name] = c.Pose()
+}
+
+// SavedPose returns the named pose and true if it exists.
+func (c *Camera) SavedPose(name string) (p CameraPose, ok bool) {
+	p, ok = c.poses[name]
+	return p, ok
+}
+
+// TransitionTo smoothly moves the camera from its current pose to the
+// given pose over the given seconds. The camera is moved by the engine
+// each update. The ease function, ie: lin.EaseInOut, changes the speed
+// of the transition. A nil ease moves at a constant speed. For example:
+//     if pose, ok := cam.SavedPose("overview"); ok {
+//         cam.TransitionTo(pose, 2, lin.EaseInOut)
+//     }
+func (c *Camera) TransitionTo(p CameraPose, seconds float64, ease func(float64) float64) {
+	if seconds <= 0 {
+		c.SetPose(p)
+		return
+	}
+	if ease == nil {
+		ease = lin.EaseLinear
+	}
+	c.trans = &transition{from: c.Pose(), to: p, dur: seconds, ease: ease}
+}
+
+// Transitioning returns true while the camera is moving to a new pose.
+func (c *Camera) Transitioning() bool { return c.trans != nil }
+
+// transition tracks a camera move between two poses.
+type transition struct {
+	from, to CameraPose            // Start and end pose.
+	dur, at  float64               // Total and elapsed seconds.
+	ease     func(float64) float64 // Easing function.
+}
+
+// pose returns the transition pose at the current time. Yaw is
+// interpolated in the shortest direction.
+func (t *transition) pose() CameraPose {
+	r := t.ease(lin.Clamp(t.at/t.dur, 0, 1))
+	yaw := math.Mod(t.to.Yaw-t.from.Yaw, 360)
+	switch {
+	case yaw > 180:
+		yaw -= 360
+	case yaw < -180:
+		yaw += 360
+	}
+	return CameraPose{
+		X:     lin.Lerp(t.from.X, t.to.X, r),
+		Y:     lin.Lerp(t.from.Y, t.to.Y, r),
+		Z:     lin.Lerp(t.from.Z, t.to.Z, r),
+		Pitch: lin.Lerp(t.from.Pitch, t.to.Pitch, r),
+		Yaw:   t.from.Yaw + yaw*r,
+	}
+}
+
+// animate advances any camera transition and shake by the elapsed seconds.
+func (c *Camera) animate(dt float64) {
+	if t := c.trans; t != nil {
+		t.at += dt
+		if t.at >= t.dur {
+			c.trans = nil
+			c.setPose(t.to) // exactly at the end pose.
+		} else {
+			c.setPose(t.pose())
+		}
+	}
+	if c.shake.left > 0 {
+		c.shake.elapsed += dt
+		c.shake.left = math.Max(c.shake.left-dt, 0)
+		c.updateTransform()
+	}
+}
+
+// shake uses smooth noise to rotate a camera. The shake intensity drops
+// off with the square of the remaining time, which feels more natural
+// than a linear fade.
+type shake struct {
+	amp, freq float64 // Maximum degrees and noise speed.
+	dur, left float64 // Total and remaining seconds.
+	elapsed   float64 // Seconds the shake has been running.
+}
+
+// intensity returns the current maximum shake in degrees.
+func (s *shake) intensity() float64 {
+	if s.left <= 0 || s.dur <= 0 {
+		return 0
+	}
+	fade := s.left / s.dur
+	return s.amp * fade * fade
+}
+
+// offsets returns the current pitch, yaw, and roll shake in degrees.
+// Roll is reduced since it is more noticeable.
+func (s *shake) offsets() (pitch, yaw, roll float64) {
+	at, amp := s.elapsed*s.freq, s.intensity()
+	return amp * shakeNoise(at, 0), amp * shakeNoise(at, 1), amp * 0.5 * shakeNoise(at, 2)
+}
+
+// shakeNoise is smooth 1D value noise returning -1 to 1 for a
+// given time and noise channel.
+func shakeNoise(t float64, channel int) float64 {
+	i := math.Floor(t)
+	f := t - i
+	f = f * f * (3 - 2*f) // smoothstep.
+	return lin.Lerp(shakeHash(int(i), channel), shakeHash(int(i)+1, channel), f)
+}
+
+// shakeHash returns a repeatable random value from -1 to 1.
+func shakeHash(i, channel int) float64 {
+	h := uint32(i)*374761393 + uint32(channel)*668265263
+	h = (h ^ h>>13) * 1274126177
+	h ^= h >> 16
+	return float64(h)/float64(math.MaxUint32)*2 - 1
+}
+
 // camera
 // ===========================================================================
 // view transforms
@@ -270,7 +798,8 @@ func ivp(at *lin.T, xrot, scr *lin.Q, vm *lin.M4) *lin.M4 {
 // cams manages all the active Camera instances.
 // There's not many cameras so not much to optimize.
 type cams struct {
-	data map[eid]*Camera // Camera instance data.
+	data   map[eid]*Camera // Camera instance data.
+	passes uint32          // Last assigned camera render pass.
 }
 
 // newCams creates the camera component manager and is expected to
@@ -294,10 +823,27 @@ func (cs *cams) create(id eid) *Camera {
 		return c // Don't allow creating over existing camera.
 	}
 	c := newCamera()
+	cs.passes++
+	c.pass = cs.passes
 	cs.data[id] = c
 	return c
 }
 
+// animate advances the camera transitions and shakes by the elapsed seconds.
+func (cs *cams) animate(dt float64) {
+	for _, c := range cs.data {
+		c.animate(dt)
+	}
+}
+
+// fitDepth updates the projections of cameras that fit their
+// depth range to the visible models.
+func (cs *cams) fitDepth() {
+	for _, c := range cs.data {
+		c.fitDepth()
+	}
+}
+
 // dispose removes the camera associated with the given entity.
 // Nothing happens if there is no camera.
 func (cs *cams) dispose(id eid) { delete(cs.data, id) }
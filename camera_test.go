@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/gazed/vu/math/lin"
+	"github.com/gazed/vu/render"
 )
 
 // Test a ray cast with simple perspective and view inverses.
@@ -161,6 +162,251 @@ func TestScreen(t *testing.T) {
 	}
 }
 
+// The pick ray from the center of the screen starts on the near
+// plane in front of the camera and points directly at the origin.
+func TestPickRay(t *testing.T) {
+	cam, ww, wh := initScene()
+	cam.SetAt(0, 0, 14)
+	ox, oy, oz, dx, dy, dz := cam.PickRay(ww/2, wh/2, ww, wh)
+	if !lin.AeqZ(ox) || !lin.AeqZ(oy) || !lin.Aeq(oz, 13.9) {
+		t.Errorf("Expected near plane origin, got %f %f %f", ox, oy, oz)
+	}
+	if !lin.AeqZ(dx) || !lin.AeqZ(dy) || !lin.Aeq(dz, -1) {
+		t.Errorf("Expected -Z direction, got %f %f %f", dx, dy, dz)
+	}
+}
+
+// Projected points keep fractional and off screen locations.
+func TestProject(t *testing.T) {
+	cam, ww, wh := initScene()
+	cam.SetAt(0, 0, 14)
+	if sx, sy, depth := cam.Project(0, 0, 0, ww, wh); !lin.Aeq(sx, 640) || !lin.Aeq(sy, 400) || depth <= 0 || depth >= 1 {
+		t.Errorf("Expected center screen, got %f %f %f", sx, sy, depth)
+	}
+	if sx, _, _ := cam.Project(100, 0, 0, ww, wh); sx <= float64(ww) {
+		t.Errorf("Expected off screen location, got %f", sx)
+	}
+	if sx, _, depth := cam.Project(5, 0, 20, ww, wh); depth >= 0 || sx >= 640 {
+		t.Errorf("Expected mirrored location behind the camera, got %f %f", sx, depth)
+	}
+}
+
+// Zooming an orthographic camera shrinks the visible area around
+// its center and pixel snapping keeps the view on whole pixels.
+func TestOrthoZoom(t *testing.T) {
+	cam := newCamera()
+	cam.SetOrtho(0, 800, 0, 600, 0, 10)
+	cam.SetZoom(2)
+	if sx, sy, _ := cam.Project(200, 150, 0, 800, 600); !lin.AeqZ(sx) || !lin.AeqZ(sy) {
+		t.Errorf("Expected zoomed corner at 0,0, got %f %f", sx, sy)
+	}
+	if x, y, z := cam.Ray(0, 0, 800, 600); !lin.Aeq(x, y) || !lin.Aeq(y, z) || !lin.Aeq(x, -1/math.Sqrt(3)) {
+		t.Errorf("Expected ortho ray to ignore the projection, got %f %f %f", x, y, z)
+	}
+	cam.SetPixelSnap(true)
+	cam.SetAt(10.3, 0, 0)
+	if !lin.Aeq(cam.vm.Wx, -10.5) {
+		t.Errorf("Expected view snapped to half units, got %f", cam.vm.Wx)
+	}
+}
+
+// Picking a pixel of a snapped orthographic view finds the world
+// location drawn at that pixel.
+func TestOrthoSnapPick(t *testing.T) {
+	cam := newCamera()
+	cam.SetOrtho(0, 800, 0, 600, 0, 10)
+	cam.SetPixelSnap(true)
+	cam.SetAt(10.3, 5.8, 0)
+	ox, oy, oz, _, _, _ := cam.PickRay(400, 300, 800, 600)
+	if sx, sy, _ := cam.Project(ox, oy, oz, 800, 600); !lin.Aeq(sx, 400) || !lin.Aeq(sy, 300) {
+		t.Errorf("Expected picked point drawn at the center pixel, got %f %f", sx, sy)
+	}
+}
+
+// The frustum contains what the camera sees.
+func TestFrustum(t *testing.T) {
+	cam, _, _ := initScene()
+	cam.SetAt(0, 0, 14)
+	f := cam.Frustum()
+	if !f.ContainsPoint(&lin.V3{}) || f.ContainsPoint(&lin.V3{Z: 20}) || f.ContainsPoint(&lin.V3{Z: -1000}) {
+		t.Errorf("Expected only the origin to be visible")
+	}
+	if f.ContainsSphere(&lin.V3{X: 50}, 1) || !f.ContainsSphere(&lin.V3{X: 50}, 50) {
+		t.Errorf("Expected only the large sphere to reach into view")
+	}
+	box := &lin.Aabb{Min: lin.V3{X: -1, Y: -1, Z: -1}, Max: lin.V3{X: 1, Y: 1, Z: 1}}
+	if !f.ContainsAabb(box) || f.ContainsAabb(&lin.Aabb{Min: lin.V3{X: 40, Y: -1, Z: -1}, Max: lin.V3{X: 42, Y: 1, Z: 1}}) {
+		t.Errorf("Expected only the centered box to be visible")
+	}
+	fc := NewFrustumCull(1)
+	if fc.Culled(cam, 0, 0, 0) || !fc.Culled(cam, 50, 0, 0) || !fc.Culled(cam, 0, 0, 20) {
+		t.Errorf("Expected the culler to match the frustum")
+	}
+}
+
+// Camera shake moves the view without changing the camera
+// and fades out after its duration.
+func TestShake(t *testing.T) {
+	cam, ww, wh := initScene()
+	cam.SetAt(0, 0, 14)
+	cam.Shake(5, 10, 1)
+	cam.animate(0.33)
+	if sx, sy, _ := cam.Project(0, 0, 0, ww, wh); !cam.Shaking() || (lin.Aeq(sx, 640) && lin.Aeq(sy, 400)) {
+		t.Errorf("Expected shaken view, got %f %f", sx, sy)
+	}
+	if x, y, z := cam.At(); x != 0 || y != 0 || z != 14 || cam.Pitch != 0 || cam.Yaw != 0 {
+		t.Errorf("Expected unchanged camera")
+	}
+	ox, oy, oz, _, _, _ := cam.PickRay(640, 400, ww, wh)
+	if sx, sy, _ := cam.Project(ox, oy, oz, ww, wh); !lin.Aeq(sx, 640) || !lin.Aeq(sy, 400) {
+		t.Errorf("Expected picking to match the shaken view, got %f %f", sx, sy)
+	}
+	cam.animate(1)
+	if sx, sy, _ := cam.Project(0, 0, 0, ww, wh); cam.Shaking() || !lin.Aeq(sx, 640) || !lin.Aeq(sy, 400) {
+		t.Errorf("Expected shake to end, got %f %f", sx, sy)
+	}
+}
+
+// Custom projections are inverted for picking.
+func TestSetProjection(t *testing.T) {
+	cam, _, _ := initScene()
+	cam.SetProjection(lin.NewM4().Persp(45, 1.5, 0.5, 100))
+	if !lin.NewM4().Mult(cam.pm, cam.ipm).Aeq(lin.M4I) {
+		t.Errorf("Expected inverse projection")
+	}
+}
+
+// An oblique near plane clips everything on the wrong side of the plane.
+func TestObliqueClip(t *testing.T) {
+	cam, ww, wh := initScene()
+	cam.SetObliqueClip(0, 0, -1, -5) // keep z < -5.
+	if _, _, depth := cam.Project(0, 0, -6, ww, wh); depth < 0 || depth > 1 {
+		t.Errorf("Expected visible point beyond the plane, got %f", depth)
+	}
+	if _, _, depth := cam.Project(0, 0, -4, ww, wh); depth >= 0 {
+		t.Errorf("Expected clipped point in front of the plane, got %f", depth)
+	}
+}
+
+// Oblique planes replace, rather than add to, the previous plane.
+func TestObliqueClipRepeat(t *testing.T) {
+	cam, _, _ := initScene()
+	once, _, _ := initScene()
+	once.SetObliqueClip(0, 0, -1, -5)
+	cam.SetObliqueClip(1, -1, -1, -2)
+	cam.SetObliqueClip(0, 0, -1, -5)
+	if !cam.pm.Aeq(once.pm) || !cam.ipm.Aeq(once.ipm) {
+		t.Errorf("Expected the same projection as a single oblique clip")
+	}
+}
+
+// Fitting the depth range keeps the oblique near plane.
+func TestObliqueClipDepthFit(t *testing.T) {
+	cam, ww, wh := initScene()
+	cam.SetDepthFit(true)
+	cam.SetObliqueClip(0, 0, -1, -5) // keep z < -5.
+	cam.fitModel(0, 0, -20, 18)
+	cam.fitDepth()
+	if _, _, depth := cam.Project(0, 0, -4, ww, wh); depth >= 0 {
+		t.Errorf("Expected clipped point in front of the plane, got %f", depth)
+	}
+	if _, _, depth := cam.Project(0, 0, -6, ww, wh); depth < 0 || depth > 1 {
+		t.Errorf("Expected visible point beyond the plane, got %f", depth)
+	}
+}
+
+// Transitions move smoothly between saved poses.
+func TestTransitionTo(t *testing.T) {
+	cam := newCamera()
+	cam.SetPose(CameraPose{X: 10, Yaw: 350})
+	cam.SavePose("start")
+	cam.TransitionTo(CameraPose{X: 20, Pitch: 30, Yaw: 10}, 2, nil)
+	cam.animate(1)
+	if p := cam.Pose(); !lin.Aeq(p.X, 15) || !lin.Aeq(p.Pitch, 15) || !lin.AeqZ(p.Yaw-360) || !cam.Transitioning() {
+		t.Errorf("Expected half way pose, got %+v", p)
+	}
+	cam.animate(1.5)
+	if p := cam.Pose(); p.X != 20 || p.Yaw != 10 || cam.Transitioning() {
+		t.Errorf("Expected end pose, got %+v", p)
+	}
+	if p, ok := cam.SavedPose("start"); !ok || p.X != 10 {
+		t.Errorf("Expected saved start pose, got %+v", p)
+	}
+}
+
+// Physical camera values match known references.
+func TestPhysicalCamera(t *testing.T) {
+	if fov := FocalFov(50, 24); math.Abs(fov-26.99) > 0.01 {
+		t.Errorf("Expected 27 degree fov for a 50mm lens, got %f", fov)
+	}
+	cam := newCamera()
+	cam.SetExposure(1, 1, 100) // EV100 0
+	if !lin.Aeq(cam.Exposure(), 1/1.2) {
+		t.Errorf("Expected EV 0 exposure, got %f", cam.Exposure())
+	}
+	cam.SetExposure(16, 0.01, 100) // sunny 16.
+	if cam.Exposure() > 0.0001 {
+		t.Errorf("Expected low exposure for bright scenes, got %f", cam.Exposure())
+	}
+}
+
+// Fitting the visible depth range keeps within the perspective limits.
+func TestDepthFit(t *testing.T) {
+	cam, _, _ := initScene() // near 0.1, far 500.
+	cam.SetDepthFit(true)
+	cam.fitModel(0, 0, -20, 2) // 18 to 22 in front of the camera.
+	cam.fitModel(0, 0, -600, 1)
+	cam.fitDepth()
+	if n, f := cam.pm.Wz/(-1+cam.pm.Zz), cam.pm.Wz/(1+cam.pm.Zz); !lin.Aeq(n, 18) || !lin.Aeq(f, 500) {
+		t.Errorf("Expected fitted depth 18:500, got %f:%f", n, f)
+	}
+
+	// fitted models use their mesh bounds and scale.
+	eng := newEngine(nil)
+	p := eng.root().NewPov().SetAt(0, 0, -100).SetScale(2, 2, 2)
+	m := &model{msh: newMesh("level")}
+	m.msh.InitData(0, 3, render.StaticDraw, false).SetData(0, []float32{30, 0, 0, -10, 5, 0, 0, 0, 10})
+	eng.povs.updateWorldTransforms()
+	if r := eng.frames.radius(p, m); !lin.Aeq(r, 60) {
+		t.Errorf("Expected scaled mesh radius 60, got %f", r)
+	}
+	cam.fitModel(0, 0, -100, eng.frames.radius(p, m))
+	cam.fitDepth()
+	if n, f := cam.pm.Wz/(-1+cam.pm.Zz), cam.pm.Wz/(1+cam.pm.Zz); !lin.Aeq(n, 40) || !lin.Aeq(f, 160) {
+		t.Errorf("Expected fitted depth 40:160, got %f:%f", n, f)
+	}
+	if r := eng.frames.radius(p, &model{}); !math.IsInf(r, 1) {
+		t.Errorf("Expected unknown bounds to use the full depth range, got %f", r)
+	}
+	if cam.logDepth() != 0 {
+		t.Errorf("Expected standard depth by default")
+	}
+	cam.SetLogDepth(true)
+	if z := cam.logDepth(); !lin.Aeq(z, 2/math.Log2(501)) {
+		t.Errorf("Expected log depth scale, got %f", z)
+	}
+}
+
+// Draws from a higher order camera sort after all the draws
+// from a lower order camera regardless of bucket or creation order.
+func TestCameraOrder(t *testing.T) {
+	world, ui := newCamera(), newCamera().SetUI()
+	world.SetOrder(1)
+	if !world.Enabled() || world.Order() != 1 {
+		t.Errorf("Expected enabled order 1 camera")
+	}
+	m, p := newModel("solid"), newPov(nil, 1, nil)
+	mv, mvp := &lin.M4{}, &lin.M4{}
+	d0, d1 := render.NewDraw(), render.NewDraw()
+	drawPov(d0, p, mv, mvp, ui, m, 0)    // overlay bucket, order 0.
+	drawPov(d1, p, mv, mvp, world, m, 0) // opaque bucket, order 1.
+	frame := []*render.Draw{d1, d0}
+	render.SortDraws(frame)
+	if frame[0] != d0 || frame[1] != d1 {
+		t.Errorf("Expected the order 0 camera to draw first")
+	}
+}
+
 // =============================================================================
 // test utility methods.
 
@@ -0,0 +1,224 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// controller.go provides reusable camera movement helpers.
+// DESIGN: Controllers are application helpers, not engine components.
+//         Each wraps a Camera and is updated by the application once per
+//         App.Update with the user input or elapsed time.
+
+import (
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+	"github.com/gazed/vu/physics"
+)
+
+// Orbit circles a camera around a target location. Dragging the mouse
+// with the orbit Button down spins the camera around the target and
+// scrolling zooms towards or away from the target. Dragging up tilts
+// the view up, the same as Fly. For example:
+//     orbit := vu.NewOrbit(cam, 10)
+//     ...
+//     orbit.Update(in) // once per App.Update.
+type Orbit struct {
+	Distance    float64 // Distance from camera to target.
+	MinDistance float64 // Closest zoom. Default 1.
+	MaxDistance float64 // Furthest zoom. Default 1000.
+	Sensitivity float64 // Degrees of spin per pixel of mouse drag.
+	ZoomSpeed   float64 // Distance change per scroll amount.
+	Button      int     // Mouse button that spins. Default KLm.
+
+	cam        *Camera // Controlled camera.
+	tx, ty, tz float64 // Target location.
+	mx, my     int     // Previous mouse location.
+	drag       bool    // True while the Button is held.
+}
+
+// NewOrbit creates an orbit controller for the given camera that
+// is the given distance away from a target at the origin.
+func NewOrbit(cam *Camera, distance float64) *Orbit {
+	o := &Orbit{cam: cam, Distance: distance, Button: KLm}
+	o.MinDistance, o.MaxDistance = 1, 1000
+	o.Sensitivity, o.ZoomSpeed = 0.3, 1
+	o.place()
+	return o
+}
+
+// SetTarget changes the location the camera orbits.
+func (o *Orbit) SetTarget(x, y, z float64) {
+	o.tx, o.ty, o.tz = x, y, z
+	o.place()
+}
+
+// Update spins and zooms the camera based on the user input.
+func (o *Orbit) Update(in *Input) {
+	if _, ok := in.Down[o.Button]; ok {
+		if o.drag {
+			dx, dy := float64(in.Mx-o.mx), float64(in.My-o.my)
+			o.cam.SetYaw(o.cam.Yaw - dx*o.Sensitivity)
+			o.cam.SetPitch(lin.Clamp(o.cam.Pitch+dy*o.Sensitivity, -89, 89))
+		}
+		o.drag = true
+	} else {
+		o.drag = false
+	}
+	o.mx, o.my = in.Mx, in.My
+	if in.Scroll != 0 {
+		o.Distance -= float64(in.Scroll) * o.ZoomSpeed
+	}
+	o.place()
+}
+
+// place the camera Distance back from the target along
+// the direction the camera is looking.
+func (o *Orbit) place() {
+	o.Distance = lin.Clamp(o.Distance, o.MinDistance, o.MaxDistance)
+	fx, fy, fz := lin.MultSQ(0, 0, -1, o.cam.Lookat())
+	o.cam.SetAt(o.tx-fx*o.Distance, o.ty-fy*o.Distance, o.tz-fz*o.Distance)
+}
+
+// =============================================================================
+
+// Fly is a first person camera controller. The mouse looks around while
+// the Button is held, or always if Button is 0. Moving the mouse up
+// looks up. WASD moves forward, left, back, and right in the look
+// direction. QE moves down and up. Shift moves faster. For example:
+//     fly := vu.NewFly(cam)
+//     ...
+//     fly.Update(in) // once per App.Update.
+type Fly struct {
+	Speed       float64 // Movement in units per second.
+	Sensitivity float64 // Degrees of look per pixel of mouse movement.
+	Button      int     // Mouse button to look. Default KRm.
+
+	cam    *Camera // Controlled camera.
+	mx, my int     // Previous mouse location.
+	look   bool    // True while looking.
+}
+
+// NewFly creates a first person controller for the given camera.
+func NewFly(cam *Camera) *Fly {
+	return &Fly{cam: cam, Speed: 10, Sensitivity: 0.2, Button: KRm}
+}
+
+// Update turns and moves the camera based on the user input.
+func (f *Fly) Update(in *Input) {
+	_, down := in.Down[f.Button]
+	if down || f.Button == 0 {
+		if f.look {
+			dx, dy := float64(in.Mx-f.mx), float64(in.My-f.my)
+			f.cam.SetYaw(f.cam.Yaw - dx*f.Sensitivity)
+			f.cam.SetPitch(lin.Clamp(f.cam.Pitch+dy*f.Sensitivity, -89, 89))
+		}
+		f.look = true
+	} else {
+		f.look = false
+	}
+	f.mx, f.my = in.Mx, in.My
+
+	// move relative to the look direction.
+	speed := f.Speed * in.Dt
+	if _, ok := in.Down[KShift]; ok {
+		speed *= 3
+	}
+	for press := range in.Down {
+		switch press {
+		case KW:
+			f.cam.Move(0, 0, -speed, f.cam.Lookat())
+		case KS:
+			f.cam.Move(0, 0, speed, f.cam.Lookat())
+		case KA:
+			f.cam.Move(-speed, 0, 0, f.cam.Lookat())
+		case KD:
+			f.cam.Move(speed, 0, 0, f.cam.Lookat())
+		case KE:
+			f.cam.Move(0, speed, 0, lin.QI)
+		case KQ:
+			f.cam.Move(0, -speed, 0, lin.QI)
+		}
+	}
+}
+
+// =============================================================================
+
+// Follow is a third person camera controller that smoothly trails
+// behind and above a target Pov while looking at it. The camera is
+// pulled closer to the target when one of the Obstacles is between
+// the target and the camera. For example:
+//     follow := vu.NewFollow(cam, player)
+//     follow.Obstacles = append(follow.Obstacles, wall.Body())
+//     ...
+//     follow.Update(in.Dt) // once per App.Update.
+type Follow struct {
+	Distance  float64        // Distance behind the target.
+	Height    float64        // Distance above the target.
+	Smooth    float64        // Catch up rate. 0 snaps to the target.
+	Obstacles []physics.Body // Bodies that block the camera view.
+
+	cam    *Camera      // Controlled camera.
+	target *Pov         // Followed Pov.
+	ray    physics.Body // Reused for obstacle checks.
+	placed bool         // False until the camera first moves.
+}
+
+// NewFollow creates a follow controller that trails the camera
+// behind the given target.
+func NewFollow(cam *Camera, target *Pov) *Follow {
+	f := &Follow{cam: cam, target: target, Distance: 8, Height: 3, Smooth: 5}
+	f.ray = NewRay(0, 0, -1)
+	return f
+}
+
+// Update moves the camera towards its spot behind the target
+// where dt is the elapsed time in seconds, ie: Input.Dt.
+func (f *Follow) Update(dt float64) {
+	tx, ty, tz := f.target.World()
+	f.follow(dt, tx, ty, tz, f.target.View())
+}
+
+// follow moves the camera towards the spot behind and above a target
+// at the given location and orientation. The target is expected to be
+// facing along its -Z axis.
+func (f *Follow) follow(dt, tx, ty, tz float64, q *lin.Q) {
+	bx, _, bz := lin.MultSQ(0, 0, 1, q) // behind the target on the XZ plane.
+	if l := math.Sqrt(bx*bx + bz*bz); l > 0 {
+		bx, bz = bx/l, bz/l
+	}
+	cx, cy, cz := tx+bx*f.Distance, ty+f.Height, tz+bz*f.Distance
+
+	// move in front of any obstacles between the target and the camera.
+	dx, dy, dz := cx-tx, cy-ty, cz-tz
+	dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if reach := dist; dist > 0 && len(f.Obstacles) > 0 {
+		SetRay(f.ray, dx/dist, dy/dist, dz/dist)
+		f.ray.World().SetLoc(tx, ty, tz)
+		for _, b := range f.Obstacles {
+			if hit, hx, hy, hz := Cast(f.ray, b); hit {
+				hx, hy, hz = hx-tx, hy-ty, hz-tz
+				if d := math.Sqrt(hx*hx+hy*hy+hz*hz) - followMargin; d < reach {
+					reach = math.Max(d, 0)
+				}
+			}
+		}
+		cx, cy, cz = tx+dx/dist*reach, ty+dy/dist*reach, tz+dz/dist*reach
+	}
+
+	// smoothly move towards the new spot.
+	if f.placed && f.Smooth > 0 {
+		ratio := 1 - math.Exp(-f.Smooth*dt)
+		x, y, z := f.cam.At()
+		cx, cy, cz = lin.Lerp(x, cx, ratio), lin.Lerp(y, cy, ratio), lin.Lerp(z, cz, ratio)
+	}
+	f.placed = true
+	f.cam.SetAt(cx, cy, cz)
+
+	// look at the target.
+	dx, dy, dz = tx-cx, ty-cy, tz-cz
+	f.cam.SetYaw(lin.Deg(math.Atan2(-dx, -dz)))
+	f.cam.SetPitch(lin.Deg(math.Atan2(dy, math.Sqrt(dx*dx+dz*dz))))
+}
+
+// followMargin keeps the follow camera slightly in front of obstacles.
+const followMargin = 0.2
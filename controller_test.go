@@ -0,0 +1,81 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// The orbit camera keeps the target in the center of the screen.
+func TestOrbit(t *testing.T) {
+	cam, ww, wh := initScene()
+	orbit := NewOrbit(cam, 10)
+	orbit.SetTarget(1, 2, 3)
+	in := &Input{Down: map[int]int{KLm: 1}, Mx: 100, My: 100}
+	orbit.Update(in)
+	in.Mx, in.My, in.Scroll = 200, 150, 2
+	orbit.Update(in)
+	if cam.Yaw == 0 || cam.Pitch == 0 || orbit.Distance != 8 {
+		t.Errorf("Expected spin and zoom, got %f %f %f", cam.Yaw, cam.Pitch, orbit.Distance)
+	}
+	if sx, sy, _ := cam.Project(1, 2, 3, ww, wh); !lin.Aeq(sx, 640) || !lin.Aeq(sy, 400) {
+		t.Errorf("Expected centered target, got %f %f", sx, sy)
+	}
+}
+
+// The fly camera moves in the direction it is looking.
+func TestFly(t *testing.T) {
+	cam, _, _ := initScene()
+	fly := NewFly(cam)
+	cam.SetYaw(90) // face -X
+	fly.Update(&Input{Down: map[int]int{KW: 1}, Dt: 0.5})
+	if x, y, z := cam.At(); !lin.Aeq(x, -5) || !lin.AeqZ(y) || !lin.AeqZ(z) {
+		t.Errorf("Expected to move along -X, got %f %f %f", x, y, z)
+	}
+}
+
+// Moving the mouse up tilts the view up for both orbit and fly.
+func TestControllerPitch(t *testing.T) {
+	cam, _, _ := initScene()
+	orbit := NewOrbit(cam, 10)
+	in := &Input{Down: map[int]int{KLm: 1, KRm: 1}, Mx: 100, My: 100}
+	orbit.Update(in)
+	in.My = 110 // mouse origin is bottom left.
+	orbit.Update(in)
+	if _, fy, _ := lin.MultSQ(0, 0, -1, cam.Lookat()); cam.Pitch <= 0 || fy <= 0 {
+		t.Errorf("Expected orbit to look up, got %f %f", cam.Pitch, fy)
+	}
+	cam.SetPitch(0)
+	fly := NewFly(cam)
+	in.My = 100
+	fly.Update(in)
+	in.My = 110
+	fly.Update(in)
+	if _, fy, _ := lin.MultSQ(0, 0, -1, cam.Lookat()); cam.Pitch <= 0 || fy <= 0 {
+		t.Errorf("Expected fly to look up, got %f %f", cam.Pitch, fy)
+	}
+}
+
+// The follow camera trails behind the target and stays in front of obstacles.
+func TestFollow(t *testing.T) {
+	cam, ww, wh := initScene()
+	follow := NewFollow(cam, nil)
+	follow.follow(0.1, 0, 0, 0, lin.QI)
+	if x, y, z := cam.At(); !lin.AeqZ(x) || !lin.Aeq(y, 3) || !lin.Aeq(z, 8) {
+		t.Errorf("Expected camera behind target, got %f %f %f", x, y, z)
+	}
+	if sx, sy, _ := cam.Project(0, 0, 0, ww, wh); !lin.Aeq(sx, 640) || !lin.Aeq(sy, 400) {
+		t.Errorf("Expected centered target, got %f %f", sx, sy)
+	}
+	wall := NewPlane(0, 0, 1)
+	wall.World().SetLoc(0, 0, 4)
+	follow.Obstacles = append(follow.Obstacles, wall)
+	follow.Smooth = 0
+	follow.follow(0.1, 0, 0, 0, lin.QI)
+	if _, _, z := cam.At(); z >= 4 {
+		t.Errorf("Expected camera in front of the wall, got %f", z)
+	}
+}
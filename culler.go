@@ -72,3 +72,35 @@ func (rc *radiusCull) Culled(cam *Camera, px, py, pz float64) bool {
 	toc := cam.Distance(px, py, pz)
 	return toc > rc.rr
 }
+
+// =============================================================================
+
+// NewFrustumCull returns a culler that removes objects outside the
+// camera view. Objects are treated as spheres of radius r so that large
+// objects are kept while any part of them is visible. The planes are the
+// same as Camera.Frustum so application checks agree with what is drawn.
+func NewFrustumCull(r float64) Culler {
+	if r < 0 {
+		r = 0
+	}
+	return &frustumCull{radius: r}
+}
+
+// frustumCull removes everything outside the camera view volume.
+type frustumCull struct {
+	radius float64     // object size.
+	vpm    lin.M4      // view projection used for the planes.
+	f      lin.Frustum // planes updated when the camera changes.
+	at     lin.V3      // scratch.
+}
+
+// Culler implementation. True if the given location is
+// outside the camera view.
+func (fc *frustumCull) Culled(cam *Camera, px, py, pz float64) bool {
+	if vpm := cam.vpm.Mult(cam.vm, cam.pm); !vpm.Eq(&fc.vpm) {
+		fc.vpm.Set(vpm)
+		fc.f.SetM4(vpm)
+	}
+	fc.at.SetS(px, py, pz)
+	return !fc.f.ContainsSphere(&fc.at, fc.radius)
+}
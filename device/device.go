@@ -2,8 +2,8 @@
 // Use is governed by a BSD-style license found in the LICENSE file.
 
 // Package device provides minimal platform/os access to a 3D rendering context
-// and user input. Access to user keyboard and mouse input is provided through
-// the Update method and Pressed structure. The application is responsible
+// and user input. Access to user keyboard, mouse, touch, and game controller input is
+// provided through the Update method and Pressed structure. The application is responsible
 // for providing any windowing constructs like buttons, controls, dialogs,
 // sub-panels, text-boxes, etc.
 //
@@ -13,12 +13,23 @@ package device
 // Big thanks to GLFW (http://www.glfw.org) from which the minimalist API
 // philosophy was borrowed along with which OS specific API's mattered.
 //
-// FUTURE: Linux support  : ignore X support and wait for Wayland vs Mir.
-//                          Latest - Intel to support Wayland, not Mir.
-//                          Need to pick one distro for main testing.
-// FUTURE: Android support: Doable, maybe even maintainable.
-// FUTURE: iOS support    : Doable, not maintainable. Like to do this without
-//                          needing Xcode download and fake Xcode projects.
+// Linux support uses X11, which also runs on Wayland desktops using XWayland.
+// A native Wayland layer can be added once the Wayland desktops settle.
+//
+// Android and iOS support builds the application as a library that the
+// operating system starts. Android uses -buildmode=c-shared and loads
+// the library as a NativeActivity. The activity needs android:configChanges
+// for orientation and screenSize so that rotating is reported as a resize.
+// iOS uses -buildmode=c-archive linked into an Xcode project whose main
+// calls gs_ios_main, see os_mobile.h. Touches are reported as Touches
+// with the first touch also acting as the left mouse button. Moving the
+// application to the background is reported as Minimized.
+
+import (
+	"image"
+	"math"
+	"time"
+)
 
 // Device wraps OS specific functionality. The expected usage is:
 //     dev := device.New("title", x, y, width, height)
@@ -34,8 +45,33 @@ type Device interface {
 	Open()                // Open the window and process events.
 	ShowCursor(show bool) // Displays or hides the cursor.
 	SetCursorAt(x, y int) // Places the cursor at the given window location.
+	LockCursor(lock bool) // Hides the cursor and reports unbounded movement.
 	Dispose()             // Release OS specific resources.
 
+	// SetCursorShape uses one of the system cursor shapes, ie: HandCursor,
+	// while the cursor is over the window. SetCursorImage uses the image
+	// as the cursor where the hot spot is the image pixel, from the top
+	// left, that is the cursor location.
+	SetCursorShape(shape int)
+	SetCursorImage(img *image.NRGBA, hotX, hotY int)
+
+	// SetTitle changes the window title. SetIcon uses the image as the
+	// window icon, ie: in the title bar, taskbar, or dock.
+	SetTitle(title string)
+	SetIcon(img *image.NRGBA)
+
+	// Attention asks for the user's attention while the window is not
+	// focused, ie: by flashing the taskbar button or bouncing the dock icon.
+	// Progress shows a progress bar, from 0 to 1, on the taskbar button or
+	// dock icon where supported. Negative progress removes the progress bar.
+	Attention()
+	Progress(p float64)
+
+	// InterceptClose reports the user asking to close the window as
+	// Pressed.Closing instead of closing the window. The application
+	// is expected to Dispose of the device when it is ready to close.
+	InterceptClose(intercept bool)
+
 	// IsAlive returns true if the window is alive processing user input.
 	// Quitting the application window will cause IsAlive to return false.
 	IsAlive() bool
@@ -44,13 +80,36 @@ type Device interface {
 	// any OS specific window trim. The window x,y (0,0) coordinates are
 	// at the bottom left of the window.
 	Size() (x, y, width, height int)
+
+	// PixelSize returns the graphics context size in drawing pixels.
+	// This is larger than Size on high resolution displays where
+	// Scale is the number of drawing pixels per window unit.
+	PixelSize() (width, height int)
+	Scale() float64
+
 	IsFullScreen() bool // Returns true if window is full screen.
 	ToggleFullScreen()  // Flips between full screen and windowed mode.
 
+	// Monitors lists the attached displays and their display modes.
+	// The primary monitor is listed first.
+	Monitors() []Monitor
+
+	// SetWindowMode switches between Windowed, Borderless, and Exclusive
+	// full screen on the given monitor index, -1 for the current monitor.
+	// The display mode is only used for Exclusive, which falls back to
+	// Borderless if the display mode can't be set.
+	SetWindowMode(mode, monitor int, m Mode)
+
 	// SwapBuffers exchanges the graphic drawing buffers. Expected to be
 	// called after a render. All rendering contexts are double buffered.
 	SwapBuffers()
 
+	// Rumble runs the low and high frequency motors of the game controller
+	// in the given Pressed.Pads slot for the given number of seconds.
+	// Magnitudes range from 0 to 1. Zero magnitudes or seconds stop the
+	// motors. Controllers and platforms without motors ignore rumbles.
+	Rumble(pad int, low, high, secs float64)
+
 	// Copy/Paste interacts with the system clipboard using strings.
 	Copy() string   // Returns nil if no string on clipboard.
 	Paste(s string) // Paste the given string onto the clipboard.
@@ -72,12 +131,84 @@ type Device interface {
 // determined using the difference with KEY_RELEASED.
 type Pressed struct {
 	Mx, My  int         // Current mouse location.
+	Dx, Dy  int         // Mouse movement since the last update.
 	Scroll  int         // The amount of scrolling, if any.
 	Down    map[int]int // Pressed keys and pressed duration.
 	Focus   bool        // True if window has focus.
 	Resized bool        // True if window was resized or moved.
+
+	// Window events: Minimized is true while the window is minimized
+	// or the mobile application is in the background, Moved is true if
+	// the window was moved, and Closing is true if the user asked to close
+	// the window while close requests are intercepted.
+	Minimized bool
+	Moved     bool
+	Closing   bool
+
+	// Scans are the pressed keys by key location, using the key codes of
+	// the keys at the same location on a US keyboard, ie: KW is the key
+	// above KS whatever the keyboard layout. Durations are as for Down.
+	// Taps counts the quick successive presses of each pressed key or
+	// mouse button, ie: 2 for a double tap.
+	Scans map[int]int
+	Taps  map[int]int
+
+	// Locked is true while the cursor is locked. The mouse location
+	// does not change while locked, but the mouse movement is unbounded.
+	Locked bool
+
+	// Text is the text typed since the last update. Dead keys and
+	// input methods, ie: IME, compose text from multiple key presses.
+	// Composing is the unfinished text while it is being composed.
+	Text      string
+	Composing string
+
+	// Touches are the current touch screen touches, including the
+	// touches that ended since the last update. Gesture holds the
+	// gestures recognized from the touches.
+	Touches []Touch
+	Gesture Gesture
+
+	// Dropped are the paths of the files dropped on the window
+	// since the last update.
+	Dropped []string
+
+	// Pads are the game controllers by controller slot.
+	// Unused slots are not connected.
+	Pads [MaxPads]Gamepad
 }
 
+// Monitor describes an attached display.
+type Monitor struct {
+	Name       string // Display name.
+	X, Y, W, H int    // Desktop location and size in pixels.
+	Primary    bool   // True for the main display.
+	Modes      []Mode // Supported display modes.
+}
+
+// Mode is a monitor display mode.
+type Mode struct {
+	W, H    int // Size in pixels.
+	Refresh int // Refresh rate in hertz, 0 if unknown.
+}
+
+// Cursor shapes used with SetCursorShape.
+const (
+	ArrowCursor     = iota // Default pointer.
+	HandCursor             // Pointing hand, ie: for links and buttons.
+	IBeamCursor            // Text selection.
+	CrosshairCursor        // Precise selection.
+	ResizeXCursor          // Horizontal resize.
+	ResizeYCursor          // Vertical resize.
+)
+
+// Window modes used with SetWindowMode.
+const (
+	Windowed   = iota // Window with a title bar and border.
+	Borderless        // Window without trim covering a monitor.
+	Exclusive         // Borderless window with a changed display mode.
+)
+
 // KeyReleased is used to indicate a key up event has occurred.
 // The total duration of a key press can be calculated by the difference
 // of Pressed.Down duration with KEY_RELEASED. A user would have to hold
@@ -107,6 +238,14 @@ func New(title string, x, y, width, height int) Device { return newDevice(title,
 //        os_windows.c     : c code wrapping windows API.
 //        os_windows.h
 //        os_windows_test.c
+//     os_linux  : Linux native layer. Wraps the following.
+//        os_linux.c       : c code wrapping Xlib and GLX.
+//        os_linux.h
+//     os_mobile : Android and iOS native layer. Wraps the following.
+//        os_android.c     : c code wrapping NativeActivity and EGL.
+//        os_ios.m         : objective-c code wraps UIKit.
+//        os_mobile.h
+//     os_android, os_ios: Android and iOS key codes.
 //
 // Design note 2: user events need to be processed on the main thread for OSX.
 //                See: native::readAndDispatch
@@ -115,6 +254,10 @@ func New(title string, x, y, width, height int) Device { return newDevice(title,
 type device struct {
 	os    *nativeOs // Native layer wrapper.
 	input *input    // User input handler.
+
+	// Controller rumbles are stopped by Update once they expire.
+	rumble rumbles
+	stops  []int
 }
 
 // newDevice initializes a OS specific window with a valid render context.
@@ -134,11 +277,55 @@ func (d *device) Open()                           { d.os.openShell() }
 func (d *device) Dispose()                        { d.os.dispose() }
 func (d *device) IsAlive() bool                   { return d.os.isAlive() }
 func (d *device) Size() (x, y, width, height int) { return d.os.size() }
+func (d *device) PixelSize() (width, height int)  { return d.os.pixelSize() }
+func (d *device) Scale() float64                  { return d.os.scale() }
 func (d *device) ShowCursor(show bool)            { d.os.showCursor(show) }
 func (d *device) SwapBuffers()                    { d.os.swapBuffers() }
 func (d *device) IsFullScreen() bool              { return d.os.isFullscreen() }
 func (d *device) ToggleFullScreen()               { d.os.toggleFullscreen() }
+func (d *device) Monitors() []Monitor             { return d.os.monitors() }
 func (d *device) SetCursorAt(x, y int)            { d.os.setCursorAt(x, y) }
+func (d *device) LockCursor(lock bool)            { d.input.locked = lock; d.os.lockCursor(lock) }
 func (d *device) Copy() string                    { return d.os.copyClip() }
 func (d *device) Paste(s string)                  { d.os.pasteClip(s) }
-func (d *device) Update() *Pressed                { return d.input.pollEvents(d.os) }
+
+// Update stops any expired controller rumbles and returns the
+// latest user input.
+func (d *device) Update() *Pressed {
+	d.stops = d.rumble.expired(time.Now(), d.stops[:0])
+	for _, pad := range d.stops {
+		d.os.rumble(pad, 0, 0)
+	}
+	return d.input.pollEvents(d.os)
+}
+
+// Rumble starts controller motors that are stopped by a later Update.
+func (d *device) Rumble(pad int, low, high, secs float64) {
+	low, high = rumbleMagnitude(low), rumbleMagnitude(high)
+	if secs <= 0 || low == 0 && high == 0 {
+		low, high, secs = 0, 0, 0
+	}
+	if d.rumble.start(pad, secs, time.Now()) {
+		d.os.rumble(pad, low, high)
+	}
+}
+
+// SetWindowMode switches between windowed and full screen modes.
+func (d *device) SetWindowMode(mode, monitor int, m Mode) {
+	d.os.setWindowMode(mode, monitor, m)
+}
+
+// InterceptClose reports close requests instead of closing the window.
+func (d *device) InterceptClose(intercept bool) { d.os.interceptClose(intercept) }
+
+// Titles, icons, attention, and progress change the window decorations.
+func (d *device) SetTitle(title string)    { d.os.retitle(title) }
+func (d *device) SetIcon(img *image.NRGBA) { d.os.setIcon(img) }
+func (d *device) Attention()               { d.os.attention() }
+func (d *device) Progress(p float64)       { d.os.progress(math.Min(p, 1)) }
+
+// Cursor shapes and images replace the cursor over the window.
+func (d *device) SetCursorShape(shape int) { d.os.setCursorShape(shape) }
+func (d *device) SetCursorImage(img *image.NRGBA, hotX, hotY int) {
+	d.os.setCursorImage(img, hotX, hotY)
+}
@@ -0,0 +1,379 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+// gamepad.go turns the raw controller state from the native layer into
+// the standard gamepad layout. Native controllers that do not already
+// report the standard layout are translated using mappings in the SDL
+// gamecontrollerdb format. See:
+//     https://github.com/gabomdq/SDL_GameControllerDB
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gamepad is the current state of a game controller using the standard
+// controller layout: a Xbox style controller having two sticks, two
+// triggers, a direction pad, and face and shoulder buttons.
+// Pressed buttons are tracked like keys in Pressed.Down with positive
+// down durations and negative release durations.
+type Gamepad struct {
+	Name      string           // Controller name, if known.
+	Connected bool             // True while the controller is attached.
+	Changed   bool             // True the update the controller attached or detached.
+	Axes      [PadAxes]float64 // Sticks from -1 to 1, triggers from 0 to 1.
+	Down      map[int]int      // Pressed buttons and pressed duration.
+}
+
+// Standard gamepad buttons. Button values don't conflict with key
+// values so that buttons and keys can be bound to the same actions.
+const (
+	PadA         = 0x100 + iota // Bottom face button.
+	PadB                        // Right face button.
+	PadX                        // Left face button.
+	PadY                        // Top face button.
+	PadBack                     // Left center button.
+	PadGuide                    // Center logo button.
+	PadStart                    // Right center button.
+	PadLStick                   // Left stick press.
+	PadRStick                   // Right stick press.
+	PadLShoulder                // Left bumper.
+	PadRShoulder                // Right bumper.
+	PadUp                       // Direction pad.
+	PadDown                     //   "
+	PadLeft                     //   "
+	PadRight                    //   "
+	padButtons                  // Number of standard buttons.
+)
+
+// Standard gamepad axes are indexes into Gamepad.Axes.
+// Up and left are negative stick values.
+const (
+	PadLX   = iota // Left stick horizontal.
+	PadLY          // Left stick vertical.
+	PadRX          // Right stick horizontal.
+	PadRY          // Right stick vertical.
+	PadLT          // Left trigger.
+	PadRT          // Right trigger.
+	PadAxes        // Number of standard axes.
+)
+
+// MaxPads is the number of controllers tracked at the same time.
+const MaxPads = 4
+
+// AddPadMappings reads controller mappings in the SDL gamecontrollerdb
+// format, ie: the gamecontrollerdb.txt file. Mappings for other platforms
+// are ignored. Controllers without a mapping use their native button and
+// axis order. Mappings are only needed by native layers that do not
+// already report the standard layout.
+func AddPadMappings(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		m, err := parsePadMapping(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("Invalid pad mapping line %d: %s", line, err)
+		}
+		if m != nil && (m.platform == "" || m.platform == padPlatforms[runtime.GOOS]) {
+			padLock.Lock()
+			padMappings[m.guid] = m
+			padLock.Unlock()
+		}
+	}
+	return scanner.Err()
+}
+
+// padPlatforms map runtime operating systems to gamecontrollerdb platforms.
+var padPlatforms = map[string]string{"darwin": "Mac OS X", "windows": "Windows", "linux": "Linux"}
+
+// padMappings are the loaded controller mappings by controller guid.
+// Mappings can be added while controllers are being polled.
+var padMappings = map[string]*padMapping{}
+var padLock sync.Mutex
+
+// Gamepad
+// ===========================================================================
+// rawPad is the native controller state.
+
+// rawPad is the controller state reported by the native layer.
+// Mapped controllers already use the standard layout where the
+// buttons and axes are in the standard constant order.
+type rawPad struct {
+	connected bool      // True while the controller is attached.
+	mapped    bool      // True if already in the standard layout.
+	guid      string    // SDL style controller identifier.
+	name      string    // Native controller name.
+	buttons   []bool    // Pressed buttons.
+	axes      []float64 // Axes from -1 to 1.
+	hats      []int     // Hat bitmasks: up 1, right 2, down 4, left 8.
+}
+
+// padSource is one raw controller input. Axis sources can be limited to
+// their positive or negative half and can be inverted. Sources for half
+// of a target axis, like a direction pad button, set the target half.
+type padSource struct {
+	kind  byte // b: button, a: axis, h: hat.
+	index int  // raw button, axis, or hat index.
+	mask  int  // hat direction bit.
+	half  int  // source half: -1 negative, 1 positive, 0 full axis.
+	flip  bool // invert the axis.
+	dst   int  // target half: -1 negative, 1 positive, 0 full axis.
+}
+
+// padMapping translates one controller type into the standard layout.
+type padMapping struct {
+	guid     string              // Controller identifier.
+	name     string              // Controller name.
+	platform string              // Platform, empty for any platform.
+	buttons  map[int]padSource   // Standard buttons by button code.
+	axes     map[int][]padSource // Standard axes. Half axes have two sources.
+}
+
+// padTargets are the gamecontrollerdb names for the standard inputs.
+// Buttons use button codes and axes use axis indexes offset by padAxis.
+var padTargets = map[string]int{
+	"a": PadA, "b": PadB, "x": PadX, "y": PadY,
+	"back": PadBack, "guide": PadGuide, "start": PadStart,
+	"leftstick": PadLStick, "rightstick": PadRStick,
+	"leftshoulder": PadLShoulder, "rightshoulder": PadRShoulder,
+	"dpup": PadUp, "dpdown": PadDown, "dpleft": PadLeft, "dpright": PadRight,
+	"leftx": padAxis + PadLX, "lefty": padAxis + PadLY,
+	"rightx": padAxis + PadRX, "righty": padAxis + PadRY,
+	"lefttrigger": padAxis + PadLT, "righttrigger": padAxis + PadRT,
+}
+
+// padAxis offsets axis targets from the button targets.
+const padAxis = 0x1000
+
+// parsePadMapping parses one gamecontrollerdb line. Blank lines and
+// comments return nil. Unknown targets are ignored since newer
+// gamecontrollerdb files add extra buttons.
+//
+//	guid,name,target:source,...,platform:name,
+func parsePadMapping(line string) (*padMapping, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+	fields := strings.Split(line, ",")
+	if len(fields) < 2 || len(fields[0]) != 32 {
+		return nil, fmt.Errorf("expected guid,name")
+	}
+	m := &padMapping{guid: fields[0], name: fields[1]}
+	m.buttons, m.axes = map[int]padSource{}, map[int][]padSource{}
+	for _, field := range fields[2:] {
+		if field == "" {
+			continue
+		}
+		pair := strings.SplitN(field, ":", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("expected target:source %s", field)
+		}
+		target, source := pair[0], pair[1]
+		if target == "platform" {
+			m.platform = source
+			continue
+		}
+		half := 0
+		if strings.HasPrefix(target, "+") || strings.HasPrefix(target, "-") {
+			half, target = 1, target[1:]
+			if pair[0][0] == '-' {
+				half = -1
+			}
+		}
+		code, ok := padTargets[target]
+		if !ok {
+			continue // ignore unsupported targets.
+		}
+		src, err := parsePadSource(source)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s", target, err)
+		}
+		if code < padAxis {
+			m.buttons[code] = src
+			continue
+		}
+		src.dst = half
+		m.axes[code-padAxis] = append(m.axes[code-padAxis], src)
+	}
+	return m, nil
+}
+
+// parsePadSource parses a raw input: b0 for buttons, a0 for axes
+// with optional +/- half and ~ invert, and h0.1 for hat directions.
+func parsePadSource(s string) (src padSource, err error) {
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		src.half = 1
+		if s[0] == '-' {
+			src.half = -1
+		}
+		s = s[1:]
+	}
+	if strings.HasSuffix(s, "~") {
+		src.flip, s = true, strings.TrimSuffix(s, "~")
+	}
+	if len(s) < 2 {
+		return src, fmt.Errorf("bad source %s", s)
+	}
+	src.kind = s[0]
+	switch src.kind {
+	case 'b', 'a':
+		src.index, err = strconv.Atoi(s[1:])
+	case 'h':
+		hat := strings.SplitN(s[1:], ".", 2)
+		if len(hat) != 2 {
+			return src, fmt.Errorf("bad hat %s", s)
+		}
+		if src.index, err = strconv.Atoi(hat[0]); err == nil {
+			src.mask, err = strconv.Atoi(hat[1])
+		}
+	default:
+		return src, fmt.Errorf("bad source %s", s)
+	}
+	if err != nil || src.index < 0 {
+		return src, fmt.Errorf("bad source %s", s)
+	}
+	return src, nil
+}
+
+// mapping returns the mapping for the given controller guid.
+// Guids that differ only by controller version or name checksum
+// share mappings. Nil is returned for unknown controllers.
+func mapping(guid string) *padMapping {
+	padLock.Lock()
+	defer padLock.Unlock()
+	if m, ok := padMappings[guid]; ok {
+		return m
+	}
+	if len(guid) == 32 {
+		for _, m := range padMappings {
+			if m.guid[:4] == guid[:4] && m.guid[8:24] == guid[8:24] {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// usbBus is the SDL guid bus type for USB controllers.
+const usbBus = 0x03
+
+// padGUID returns the SDL style guid for a controller. The 16 byte guid
+// holds the little endian bus, vendor, product, and version numbers.
+func padGUID(bus, vendor, product, version int) string {
+	le := func(v int) string { return fmt.Sprintf("%02x%02x0000", v&0xFF, (v>>8)&0xFF) }
+	return le(bus) + le(vendor) + le(product) + le(version)
+}
+
+// button returns the pressed state of a raw input.
+func (src padSource) button(raw *rawPad) bool {
+	switch src.kind {
+	case 'b':
+		return src.index < len(raw.buttons) && raw.buttons[src.index]
+	case 'h':
+		return src.index < len(raw.hats) && raw.hats[src.index]&src.mask != 0
+	case 'a':
+		return src.axis(raw) > 0.5
+	}
+	return false
+}
+
+// axis returns the value of a raw input from -1 to 1. Half axes
+// return values from 0 to 1 and pressed buttons return 1.
+func (src padSource) axis(raw *rawPad) float64 {
+	if src.kind != 'a' {
+		if src.button(raw) {
+			return 1
+		}
+		return 0
+	}
+	v := 0.0
+	if src.index < len(raw.axes) {
+		v = raw.axes[src.index]
+	}
+	if src.flip {
+		v = -v
+	}
+	switch {
+	case src.half > 0:
+		return math.Max(0, v)
+	case src.half < 0:
+		return math.Max(0, -v)
+	}
+	return v
+}
+
+// standard fills the standard buttons and axes from a raw controller.
+// Triggers reported as full axes are scaled to range from 0 to 1.
+func (m *padMapping) standard(raw *rawPad, buttons []bool, axes []float64) {
+	for code, src := range m.buttons {
+		buttons[code-PadA] = src.button(raw)
+	}
+	for index, srcs := range m.axes {
+		v := 0.0
+		for _, src := range srcs {
+			if src.dst != 0 {
+				v += math.Max(0, src.axis(raw)) * float64(src.dst)
+				continue
+			}
+			v += src.axis(raw)
+		}
+		full := len(srcs) == 1 && srcs[0].kind == 'a' && srcs[0].half == 0 && srcs[0].dst == 0
+		if (index == PadLT || index == PadRT) && full {
+			v = (v + 1) * 0.5
+		}
+		axes[index] = v
+	}
+}
+
+// unmapped fills the standard buttons and axes using the raw button and
+// axis order. Used for controllers already in the standard layout and
+// for controllers without a mapping.
+func unmapped(raw *rawPad, buttons []bool, axes []float64) {
+	copy(buttons, raw.buttons)
+	copy(axes, raw.axes)
+}
+
+// Rumble
+// ===========================================================================
+// rumbles times the controller motors.
+
+// rumbles are the times when each controller slot's motors are stopped.
+// Native layers only start and stop the motors. A zero time means the
+// motors are not running.
+type rumbles [MaxPads]time.Time
+
+// start records when the rumble for the given controller slot ends.
+// Returns false for unknown slots.
+func (r *rumbles) start(pad int, secs float64, now time.Time) bool {
+	if pad < 0 || pad >= len(r) {
+		return false
+	}
+	r[pad] = time.Time{}
+	if secs > 0 {
+		r[pad] = now.Add(time.Duration(secs * float64(time.Second)))
+	}
+	return true
+}
+
+// expired returns the controller slots whose rumbles ended before now.
+func (r *rumbles) expired(now time.Time, pads []int) []int {
+	for cnt, end := range r {
+		if !end.IsZero() && !now.Before(end) {
+			r[cnt] = time.Time{}
+			pads = append(pads, cnt)
+		}
+	}
+	return pads
+}
+
+// rumbleMagnitude limits motor magnitudes to range from 0 to 1.
+func rumbleMagnitude(v float64) float64 { return math.Max(0, math.Min(1, v)) }
@@ -0,0 +1,109 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A gamecontrollerdb style line for a controller with a hat direction pad,
+// full axis triggers, and an inverted split axis.
+var testPadDB = "030000004c050000cc09000000010000,PS4 Controller," +
+	"a:b1,b:b2,back:b8,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1," +
+	"leftshoulder:b4,leftx:a0,lefty:a1,lefttrigger:a3,-rightx:a2~,+rightx:b10," +
+	"touchpad:b13,platform:Mac OS X,\n# comment\n"
+
+func TestPadMapping(t *testing.T) {
+	m, err := parsePadMapping(strings.Split(testPadDB, "\n")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.name != "PS4 Controller" || m.platform != "Mac OS X" || len(m.buttons) != 8 {
+		t.Errorf("Bad mapping %+v", m)
+	}
+	raw := &rawPad{connected: true, buttons: make([]bool, 14), axes: []float64{0.5, -1, -0.5, -1}, hats: []int{3}}
+	raw.buttons[1] = true
+	buttons, axes := make([]bool, padButtons-PadA), make([]float64, PadAxes)
+	m.standard(raw, buttons, axes)
+	if !buttons[PadA-PadA] || buttons[PadB-PadA] || !buttons[PadUp-PadA] || !buttons[PadRight-PadA] {
+		t.Errorf("Bad buttons %v", buttons)
+	}
+	if axes[PadLX] != 0.5 || axes[PadLY] != -1 || axes[PadLT] != 0 || axes[PadRX] != -0.5 {
+		t.Errorf("Bad axes %v", axes)
+	}
+	for _, bad := range []string{"030000004c05,short", "030000004c050000cc09000000010000,Pad,a:c1", "030000004c050000cc09000000010000,Pad,a"} {
+		if _, err := parsePadMapping(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+// Mappings are found by guid, ignoring the controller version.
+func TestPadGUID(t *testing.T) {
+	if guid := padGUID(usbBus, 0x054c, 0x09cc, 0x0100); guid != "030000004c050000cc09000000010000" {
+		t.Errorf("Bad guid %s", guid)
+	}
+	defer func() { padMappings = map[string]*padMapping{} }()
+	db := "030000005e0400008e02000000000000,Any Platform,a:b0,\n" + testPadDB
+	if err := AddPadMappings(strings.NewReader(db)); err != nil {
+		t.Fatal(err)
+	}
+	if m := mapping(padGUID(usbBus, 0x045e, 0x028e, 0x0114)); m == nil || m.name != "Any Platform" {
+		t.Errorf("Expected mapping for a newer controller version")
+	}
+	if m := mapping(padGUID(usbBus, 0x054c, 0x09cc, 0x0100)); (m != nil) != (runtime.GOOS == "darwin") {
+		t.Errorf("Expected only mappings for this platform")
+	}
+	if err := AddPadMappings(strings.NewReader("bad")); err == nil {
+		t.Errorf("Expected bad mapping error")
+	}
+}
+
+// Controller buttons are pressed, held, and released like keys.
+func TestProcessPads(t *testing.T) {
+	i := newInput()
+	raws := make([]rawPad, MaxPads)
+	raws[1] = rawPad{connected: true, mapped: true, name: "pad", buttons: []bool{true}, axes: []float64{0, 0, 0, 0, 1, 0}}
+	i.processPads(raws)
+	i.updateDurations()
+	i.clone(i.curr, i.down)
+	pad := i.down.Pads[1]
+	if !pad.Connected || !pad.Changed || pad.Down[PadA] != 1 || pad.Axes[PadLT] != 1 || i.down.Pads[0].Connected {
+		t.Errorf("Expected connected pad with A pressed got %+v", pad)
+	}
+	raws[1].connected = false
+	i.processPads(raws)
+	i.updateDurations()
+	i.clone(i.curr, i.down)
+	if pad = i.down.Pads[1]; pad.Connected || !pad.Changed || pad.Down[PadA] != 1+KeyReleased {
+		t.Errorf("Expected disconnect to release A got %+v", pad)
+	}
+	if i.clone(i.curr, i.down); len(i.down.Pads[1].Down) != 0 {
+		t.Errorf("Expected released buttons to be removed")
+	}
+}
+
+// Rumbles expire after their duration and zero durations stop rumbles.
+func TestRumbles(t *testing.T) {
+	r := &rumbles{}
+	now := time.Now()
+	if r.start(MaxPads, 1, now) || !r.start(1, 0.5, now) || !r.start(2, 1, now) {
+		t.Errorf("Expected only valid controller slots")
+	}
+	if stops := r.expired(now.Add(time.Second/4), nil); len(stops) != 0 {
+		t.Errorf("Expected running rumbles got %v", stops)
+	}
+	if stops := r.expired(now.Add(time.Second/2), nil); len(stops) != 1 || stops[0] != 1 {
+		t.Errorf("Expected pad 1 to stop got %v", stops)
+	}
+	if r.start(2, 0, now); !r[2].IsZero() || len(r.expired(now.Add(time.Hour), nil)) != 0 {
+		t.Errorf("Expected a stopped rumble to not expire")
+	}
+	if rumbleMagnitude(-1) != 0 || rumbleMagnitude(2) != 1 || rumbleMagnitude(0.5) != 0.5 {
+		t.Errorf("Expected magnitudes from 0 to 1")
+	}
+}
@@ -3,6 +3,8 @@
 
 package device
 
+import "unicode"
+
 // Design note: The original intent was to collect and process the OS
 // event queues concurrently. However, OSX only allows event processng
 // from the main thread.
@@ -13,14 +15,49 @@ type input struct {
 	in   *userInput // Input is processed in a map of pressed keys.
 	curr *Pressed   // Consolidates current user events into state.
 	down *Pressed   // Clone of curr that is shared with the application.
+
+	// Controller state is polled from the native layer and
+	// translated to the standard layout each update.
+	raw     []rawPad                // Native controller state by slot.
+	buttons [padButtons - PadA]bool // Scratch standard buttons.
+
+	// Mouse movement is the change in mouse location unless the
+	// cursor is locked, in which case the native layer reports it.
+	locked  bool // True while the cursor is locked.
+	located bool // True once the mouse location is known.
+
+	// Touches are polled from the native layer each update.
+	// Gestures are recognized by tracking the touches that are down.
+	touches []Touch            // Native touches.
+	starts  map[int]touchStart // Touches that are down by id.
+	prev    map[int]Touch      // Touches from the previous update.
+	peak    int                // Most touches down at the same time.
+
+	// Quick successive presses of the same key are counted as taps.
+	ticks uint64         // Updates processed.
+	taps  map[int]keyTap // Taps by key or mouse button.
+}
+
+// keyTap tracks the taps of a key. Presses within tapTicks of the
+// previous release continue counting taps.
+type keyTap struct {
+	count    int    // Successive quick presses.
+	released uint64 // Update tick of the last release.
 }
 
 // newInput creates the memory needed to process user input events.
 func newInput() *input {
 	i := &input{}
 	i.in = &userInput{}
-	i.curr = &Pressed{Focus: true, Down: map[int]int{}}
-	i.down = &Pressed{Focus: true, Down: map[int]int{}}
+	i.curr = &Pressed{Focus: true, Down: map[int]int{}, Scans: map[int]int{}, Taps: map[int]int{}}
+	i.down = &Pressed{Focus: true, Down: map[int]int{}, Scans: map[int]int{}, Taps: map[int]int{}}
+	i.taps = map[int]keyTap{}
+	i.raw = make([]rawPad, MaxPads)
+	i.starts, i.prev = map[int]touchStart{}, map[int]Touch{}
+	for cnt := range i.curr.Pads {
+		i.curr.Pads[cnt].Down = map[int]int{}
+		i.down.Pads[cnt].Down = map[int]int{}
+	}
 	return i
 }
 
@@ -30,6 +67,12 @@ func newInput() *input {
 func (i *input) pollEvents(os *nativeOs) *Pressed {
 	i.processEvent(os.readDispatch(i.in)) // sample events at twice the update rate
 	i.processEvent(os.readDispatch(i.in)) // ...by reading 2 events each update.
+	os.readPads(i.raw)
+	i.processPads(i.raw)
+	i.processText(os.readText())
+	i.curr.Dropped = append(i.curr.Dropped, os.readDropped()...)
+	i.touches = os.readTouches(i.touches[:0])
+	i.processTouches(i.touches)
 	i.updateDurations()
 	i.clone(i.curr, i.down)
 	return i.down
@@ -40,32 +83,49 @@ func (i *input) pollEvents(os *nativeOs) *Pressed {
 // of how long each key has been pressed is recorded in update ticks.
 // This method is only expected to be called by i.pollEvents().
 func (i *input) processEvent(event *userInput) {
+	if i.locked {
+		i.curr.Dx, i.curr.Dy = i.curr.Dx+event.dx, i.curr.Dy+event.dy
+	} else if i.located {
+		i.curr.Dx += event.mouseX - i.curr.Mx
+		i.curr.Dy += event.mouseY - i.curr.My
+	}
 	i.curr.Mx, i.curr.My = event.mouseX, event.mouseY
+	i.curr.Locked, i.located = i.locked, true
 	i.curr.Scroll += event.scroll
 
 	// turn key and mouse events into state
 	switch event.id {
 	case resizedShell, movedShell:
 		i.curr.Resized = true
+		i.curr.Moved = i.curr.Moved || event.id == movedShell
 
 		// release all down keys on resize to avoid missing
 		// release events.
 		for key := range i.curr.Down {
 			i.recordRelease(key)
 		}
+		for scan := range i.curr.Scans {
+			i.recordScan(scan, false)
+		}
 	case activatedShell, uniconifiedShell:
 		i.curr.Focus = true
+		i.curr.Minimized = i.curr.Minimized && event.id != uniconifiedShell
 	case deactivatedShell, iconifiedShell:
 		i.curr.Focus = false
+		i.curr.Minimized = i.curr.Minimized || event.id == iconifiedShell
 		i.releaseAll()
+	case closingShell:
+		i.curr.Closing = true
 	case clickedMouse:
 		i.recordPress(event.button)
 	case releasedMouse:
 		i.recordRelease(event.button)
 	case pressedKey:
 		i.recordPress(event.key)
+		i.recordScan(event.scan, true)
 	case releasedKey:
 		i.recordRelease(event.key)
+		i.recordScan(event.scan, false)
 	default:
 		// capture modifier key state.
 		if event.mods&shiftKeyMask != 0 {
@@ -93,6 +153,59 @@ func (i *input) processEvent(event *userInput) {
 		} else {
 			i.recordRelease(altKey)
 		}
+		for _, key := range modifierKeys { // modifiers have fixed locations.
+			down, ok := i.curr.Down[key]
+			i.recordScan(key, ok && down >= 0)
+		}
+	}
+}
+
+// processPads updates the gamepads from the native controller state.
+// Controllers are translated to the standard layout using the loaded
+// mappings. Buttons are released when a controller is detached.
+func (i *input) processPads(raws []rawPad) {
+	for cnt := range i.curr.Pads {
+		pad, raw := &i.curr.Pads[cnt], &raws[cnt]
+		pad.Changed = pad.Connected != raw.connected
+		pad.Connected = raw.connected
+		buttons := i.buttons[:]
+		for b := range buttons {
+			buttons[b] = false
+		}
+		pad.Axes = [PadAxes]float64{}
+		if raw.connected {
+			pad.Name = raw.name
+			if m := mapping(raw.guid); m != nil && !raw.mapped {
+				pad.Name = m.name
+				m.standard(raw, buttons, pad.Axes[:])
+			} else {
+				unmapped(raw, buttons, pad.Axes[:])
+			}
+		}
+		for b, pressed := range buttons {
+			switch _, down := pad.Down[PadA+b]; {
+			case pressed && !down && i.curr.Focus:
+				pad.Down[PadA+b] = 0
+			case !pressed && down && pad.Down[PadA+b] >= 0:
+				pad.Down[PadA+b] += KeyReleased
+			}
+		}
+	}
+}
+
+// processText adds the typed text. Control characters are ignored since
+// keys like enter and delete are reported as key presses. Some platforms
+// also include private use characters for function keys.
+func (i *input) processText(typed, composing string) {
+	i.curr.Composing = ""
+	if !i.curr.Focus {
+		return
+	}
+	i.curr.Composing = composing
+	for _, r := range typed {
+		if !unicode.IsControl(r) && (r < 0xF700 || r > 0xF8FF) {
+			i.curr.Text += string(r)
+		}
 	}
 }
 
@@ -102,14 +215,38 @@ func (i *input) recordPress(code int) {
 	if code >= 0 && i.curr.Focus {
 		if _, ok := i.curr.Down[code]; !ok {
 			i.curr.Down[code] = 0
+			tap := i.taps[code]
+			if i.ticks-tap.released > tapTicks {
+				tap.count = 0 // too slow to continue tapping.
+			}
+			tap.count++
+			i.taps[code] = tap
+			i.curr.Taps[code] = tap.count
 		}
 	}
 }
 
 // recordRelease tracks key or mouse up user input events.
 func (i *input) recordRelease(code int) {
-	if _, ok := i.curr.Down[code]; ok {
+	if down, ok := i.curr.Down[code]; ok {
 		i.curr.Down[code] = i.curr.Down[code] + KeyReleased
+		if down >= 0 {
+			tap := i.taps[code]
+			tap.released = i.ticks
+			i.taps[code] = tap
+		}
+	}
+}
+
+// recordScan tracks key down and up events by key location.
+// Ignore any key presses unless the window has focus.
+func (i *input) recordScan(scan int, pressed bool) {
+	down, ok := i.curr.Scans[scan]
+	switch {
+	case pressed && !ok && scan >= 0 && i.curr.Focus:
+		i.curr.Scans[scan] = 0
+	case !pressed && ok && down >= 0:
+		i.curr.Scans[scan] = down + KeyReleased
 	}
 }
 
@@ -119,16 +256,39 @@ func (i *input) releaseAll() {
 	for code, down := range i.curr.Down {
 		i.curr.Down[code] = down + KeyReleased
 	}
+	for scan := range i.curr.Scans {
+		i.recordScan(scan, false)
+	}
+	for cnt := range i.curr.Pads {
+		for code, down := range i.curr.Pads[cnt].Down {
+			if down >= 0 {
+				i.curr.Pads[cnt].Down[code] = down + KeyReleased
+			}
+		}
+	}
 }
 
 // updateDurations tracks how long keys have been pressed for.
 // Expected to be called each update. Ignore released keys.
 func (i *input) updateDurations() {
+	i.ticks++
 	for key, val := range i.curr.Down {
 		if val >= 0 {
 			i.curr.Down[key] = val + 1
 		}
 	}
+	for scan, val := range i.curr.Scans {
+		if val >= 0 {
+			i.curr.Scans[scan] = val + 1
+		}
+	}
+	for cnt := range i.curr.Pads {
+		for button, val := range i.curr.Pads[cnt].Down {
+			if val >= 0 {
+				i.curr.Pads[cnt].Down[button] = val + 1
+			}
+		}
+	}
 }
 
 // clone the current user input information into the structure that is
@@ -138,16 +298,55 @@ func (i *input) clone(in, out *Pressed) {
 	for key := range out.Down {
 		delete(out.Down, key)
 	}
+	for key := range out.Taps {
+		delete(out.Taps, key)
+	}
 	for key, val := range in.Down {
 		out.Down[key] = val
+		out.Taps[key] = in.Taps[key]
 		if val < 0 {
 			delete(in.Down, key) // remove released keys.
+			delete(in.Taps, key)
+		}
+	}
+	for scan := range out.Scans {
+		delete(out.Scans, scan)
+	}
+	for scan, val := range in.Scans {
+		out.Scans[scan] = val
+		if val < 0 {
+			delete(in.Scans, scan) // remove released keys.
 		}
 	}
 	out.Mx, out.My = in.Mx, in.My
+	out.Dx, out.Dy = in.Dx, in.Dy
+	in.Dx, in.Dy = 0, 0 // remove previous mouse movement.
+	out.Locked = in.Locked
+	out.Text, out.Composing = in.Text, in.Composing
+	out.Touches = append(out.Touches[:0], in.Touches...)
+	out.Gesture = in.Gesture
+	in.Text = "" // remove previous typed text.
+	out.Dropped = append(out.Dropped[:0], in.Dropped...)
+	in.Dropped = in.Dropped[:0] // remove previous dropped files.
 	out.Focus = in.Focus
 	out.Resized = in.Resized
+	out.Minimized, out.Moved, out.Closing = in.Minimized, in.Moved, in.Closing
+	in.Moved, in.Closing = false, false // remove previous window events.
 	out.Scroll = in.Scroll
+	for cnt := range in.Pads {
+		pin, pout := &in.Pads[cnt], &out.Pads[cnt]
+		pout.Name, pout.Connected, pout.Changed = pin.Name, pin.Connected, pin.Changed
+		pout.Axes = pin.Axes
+		for button := range pout.Down {
+			delete(pout.Down, button)
+		}
+		for button, val := range pin.Down {
+			pout.Down[button] = val
+			if val < 0 {
+				delete(pin.Down, button) // remove released buttons.
+			}
+		}
+	}
 	in.Scroll = 0      // remove previous scroll info.
 	in.Resized = false // remove previous resized trigger.
 }
@@ -164,8 +363,10 @@ type userInput struct {
 	mouseY int // Current mouse Y position.
 	button int // Currently pressed mouse button (if any).
 	key    int // Current key pressed (if any).
+	scan   int // Key location of the current key as a US keyboard key.
 	mods   int // Mask of the current modifier keys (if any).
 	scroll int // Scroll amount (if any).
+	dx, dy int // Mouse movement while the cursor is locked.
 }
 
 // userInput
@@ -189,6 +390,7 @@ const (
 	pressedKey
 	releasedKey
 	scrolled
+	closingShell
 )
 
 // Modifier key values don't conflict with regular keys.
@@ -200,6 +402,9 @@ const (
 	altKey      = altKeyMask
 )
 
+// modifierKeys are the keys tracked from the modifier key state.
+var modifierKeys = [...]int{controlKey, shiftKey, functionKey, commandKey, altKey}
+
 // Based on the keys on a Mac OSX extended keyboard excluding
 // OS specific keys like eject. Most keyboards will support
 // some subset of the following keys. Currently pressed keys are
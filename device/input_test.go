@@ -0,0 +1,101 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+import "testing"
+
+// Mouse movement is the change in location unless the cursor is locked.
+func TestMouseMovement(t *testing.T) {
+	i := newInput()
+	i.processEvent(&userInput{mouseX: 100, mouseY: 100})
+	i.processEvent(&userInput{mouseX: 110, mouseY: 95})
+	i.clone(i.curr, i.down)
+	if i.down.Dx != 10 || i.down.Dy != -5 {
+		t.Errorf("Expected movement from the first location got %d %d", i.down.Dx, i.down.Dy)
+	}
+	i.locked = true
+	i.processEvent(&userInput{mouseX: 110, mouseY: 95, dx: 400, dy: 3})
+	i.processEvent(&userInput{mouseX: 110, mouseY: 95, dx: 300, dy: 2})
+	i.clone(i.curr, i.down)
+	if i.down.Dx != 700 || i.down.Dy != 5 || !i.down.Locked {
+		t.Errorf("Expected unbounded locked movement got %d %d", i.down.Dx, i.down.Dy)
+	}
+	if i.clone(i.curr, i.down); i.down.Dx != 0 || i.down.Dy != 0 {
+		t.Errorf("Expected movement to reset each update")
+	}
+}
+
+// Typed text is accumulated each update without control characters.
+func TestTypedText(t *testing.T) {
+	i := newInput()
+	i.processText("a\b\r", "")
+	i.processText("é日", "に")
+	i.clone(i.curr, i.down)
+	if i.down.Text != "aé日" || i.down.Composing != "に" {
+		t.Errorf("Expected typed text got %q %q", i.down.Text, i.down.Composing)
+	}
+	i.curr.Focus = false
+	i.processText("b", "に")
+	if i.clone(i.curr, i.down); i.down.Text != "" || i.down.Composing != "" {
+		t.Errorf("Expected no text without focus got %q %q", i.down.Text, i.down.Composing)
+	}
+}
+
+// Dropped files are reported once.
+func TestDroppedFiles(t *testing.T) {
+	i := newInput()
+	i.curr.Dropped = append(i.curr.Dropped, "a.obj", "b.png")
+	if i.clone(i.curr, i.down); len(i.down.Dropped) != 2 || i.down.Dropped[1] != "b.png" {
+		t.Errorf("Expected dropped files got %v", i.down.Dropped)
+	}
+	if i.clone(i.curr, i.down); len(i.down.Dropped) != 0 {
+		t.Errorf("Expected dropped files to reset each update")
+	}
+}
+
+// Keys are tracked by location and quick presses are counted as taps.
+func TestKeyTaps(t *testing.T) {
+	i := newInput()
+	press := func(id int) {
+		i.processEvent(&userInput{id: id, key: KQ, scan: KA})
+		i.updateDurations()
+		i.clone(i.curr, i.down)
+	}
+	press(pressedKey)
+	if i.down.Down[KQ] != 1 || i.down.Scans[KA] != 1 || i.down.Taps[KQ] != 1 {
+		t.Errorf("Expected pressed key got %v %v %v", i.down.Down, i.down.Scans, i.down.Taps)
+	}
+	press(releasedKey)
+	press(pressedKey)
+	if i.down.Taps[KQ] != 2 || i.down.Scans[KA] != 1 {
+		t.Errorf("Expected double tap got %v", i.down.Taps)
+	}
+	press(releasedKey)
+	for cnt := 0; cnt < tapTicks+1; cnt++ {
+		i.updateDurations()
+	}
+	press(pressedKey)
+	if i.down.Taps[KQ] != 1 {
+		t.Errorf("Expected slow press to restart taps got %v", i.down.Taps)
+	}
+}
+
+// Window events are reported for the update where they happen
+// while minimized is reported until the window is restored.
+func TestWindowEvents(t *testing.T) {
+	i := newInput()
+	i.processEvent(&userInput{id: iconifiedShell})
+	i.processEvent(&userInput{id: movedShell})
+	i.processEvent(&userInput{id: closingShell})
+	if i.clone(i.curr, i.down); !i.down.Minimized || !i.down.Moved || !i.down.Closing || i.down.Focus {
+		t.Errorf("Expected window events got %+v", i.down)
+	}
+	if i.clone(i.curr, i.down); !i.down.Minimized || i.down.Moved || i.down.Closing {
+		t.Errorf("Expected events to reset each update got %+v", i.down)
+	}
+	i.processEvent(&userInput{id: uniconifiedShell})
+	if i.clone(i.curr, i.down); i.down.Minimized || !i.down.Focus {
+		t.Errorf("Expected restored window got %+v", i.down)
+	}
+}
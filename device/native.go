@@ -4,6 +4,7 @@
 package device
 
 import (
+	"image"
 	"log"
 )
 
@@ -38,6 +39,19 @@ type native interface {
 	// to process.
 	readDispatch(r *nrefs, in *userInput) *userInput
 
+	// gamepads fills the state of the attached game controllers.
+	// There is one rawPad for each controller slot.
+	//    osx: IOHIDManager controllers in their native layout.
+	//    win: XInputGetState controllers in the standard layout.
+	gamepads(r *nrefs, pads []rawPad)
+
+	// rumble starts, or for zero magnitudes stops, the low and high
+	// frequency motors of the controller in the given slot. Magnitudes
+	// range from 0 to 1. The motors run until they are stopped.
+	//    osx: not supported.
+	//    win: XInputSetState vibration.
+	rumble(r *nrefs, pad int, low, high float64)
+
 	// shell creates the "window" on the given display. In some cases this is
 	// a window and in others it holds device independent attributes. The supplied
 	// Shell structure's id is set to a reference of the underlying OS structure.
@@ -61,6 +75,17 @@ type native interface {
 	//    win: GetClientRect(hwnd, &rect);
 	size(r *nrefs) (x, y, w, h int)
 
+	// pixelSize gets the current window size in drawing pixels. This is
+	// larger than the window size on high resolution displays.
+	//    osx: [view convertRectToBacking:[view bounds]];
+	//    win: GetClientRect(hwnd, &rect);
+	pixelSize(r *nrefs) (w, h int)
+
+	// scale gets the number of drawing pixels per window unit.
+	//    osx: [window backingScaleFactor];
+	//    win: GetDeviceCaps(hdc, LOGPIXELSX) / 96;
+	scale(r *nrefs) float64
+
 	// showCursor hides or shows the cursor. The cursor is locked to the
 	// application window when it is hidden.
 	//    osx: [NSCursor unhide]; [NSCursor hide];
@@ -72,6 +97,48 @@ type native interface {
 	//    win: SetCursorPos(loc.x, loc.y);
 	setCursorAt(r *nrefs, x, y int)
 
+	// lockCursor hides the cursor and stops it from moving. Mouse movement
+	// is reported in the userInput dx, dy fields while locked.
+	//    osx: CGAssociateMouseAndMouseCursorPosition(false);
+	//    win: ClipCursor(&rect); SetCursorPos(center.x, center.y);
+	lockCursor(r *nrefs, lock bool)
+
+	// cursorShape uses one of the system cursor shapes over the window.
+	//    osx: [view addCursorRect:[view bounds] cursor:cursor];
+	//    win: LoadCursor(NULL, IDC_HAND); WM_SETCURSOR
+	cursorShape(r *nrefs, shape int)
+
+	// cursorImage uses the w by h RGBA pixels as the cursor over the window.
+	// The hot spot is the pixel, from the top left, at the cursor location.
+	//    osx: [[NSCursor alloc] initWithImage:image hotSpot:hotSpot];
+	//    win: CreateIconIndirect(&info); WM_SETCURSOR
+	cursorImage(r *nrefs, pixels []byte, w, h, hotX, hotY int)
+
+	// text returns the text typed since the last call and any unfinished
+	// text that is being composed using dead keys or an input method.
+	//    osx: [view interpretKeyEvents:events]; NSTextInputClient
+	//    win: TranslateMessage(&msg); WM_CHAR, WM_IME_COMPOSITION
+	text(r *nrefs) (typed, composing string)
+
+	// touches appends the current touch screen touches. Touches that ended
+	// are reported once and touches that began are reported as moved after
+	// they have been read once.
+	//    osx: not supported, trackpads do not report window touches.
+	//    win: RegisterTouchWindow(hwnd, 0); WM_TOUCH
+	touches(r *nrefs, touches []Touch) []Touch
+
+	// interceptClose reports close requests as closing events instead
+	// of closing the window.
+	//    osx: -(BOOL)windowShouldClose:(id)sender { return NO; }
+	//    win: WM_CLOSE return 0;
+	interceptClose(r *nrefs, intercept bool)
+
+	// dropped returns the paths of the files dropped on the window
+	// since the last call.
+	//    osx: [view registerForDraggedTypes:types]; NSDraggingDestination
+	//    win: DragAcceptFiles(hwnd, TRUE); WM_DROPFILES
+	dropped(r *nrefs) []string
+
 	// context creates an OpenGL context and fills in the context field of the
 	// nrefs structure. For example the context field is:
 	//    osx: pointer to NSOpenGLContext
@@ -105,6 +172,26 @@ type native interface {
 	// the window is created.
 	setTitle(title string)
 
+	// retitle changes the window title after the window is created.
+	retitle(r *nrefs, title string)
+
+	// icon uses the w by h RGBA pixels as the application icon.
+	//    osx: the dock icon.
+	//    win: the title bar and taskbar icon.
+	icon(r *nrefs, pixels []byte, w, h int)
+
+	// attention asks for the user's attention when the window is not
+	// focused, ie: by highlighting the taskbar button.
+	//    osx: NSApplication requestUserAttention bounces the dock icon.
+	//    win: FlashWindowEx flashes the taskbar button.
+	attention(r *nrefs)
+
+	// progress shows the progress, from 0 to 1, on the taskbar button or
+	// dock icon. Negative progress removes the progress.
+	//    osx: progress bar over the dock icon.
+	//    win: ITaskbarList3 taskbar button progress.
+	progress(r *nrefs, p float64)
+
 	// isFullscreen returns true when application is is full screen mode.
 	isFullscreen(r *nrefs) bool
 
@@ -112,6 +199,17 @@ type native interface {
 	// Must be called after starting processing with readDispatch().
 	toggleFullscreen(r *nrefs)
 
+	// monitors returns the attached displays and their display modes.
+	//    osx: [NSScreen screens]; CGDisplayCopyAllDisplayModes(id, NULL);
+	//    win: EnumDisplayMonitors(...); EnumDisplaySettings(...);
+	monitors(r *nrefs) []Monitor
+
+	// setWindowMode switches the window between windowed, borderless,
+	// and exclusive full screen on the given monitor.
+	//    osx: [window setStyleMask:NSWindowStyleMaskBorderless]; CGDisplaySetDisplayMode(...)
+	//    win: SetWindowLong(hwnd, GWL_STYLE, style); ChangeDisplaySettingsEx(...)
+	setWindowMode(r *nrefs, mode, monitor int, m Mode)
+
 	// copyClip puts the given string on the system clipboard.
 	copyClip(r *nrefs) string
 
@@ -190,12 +288,60 @@ func (os *nativeOs) size() (x, y, w, h int) {
 	return int(winx), int(winy), int(width), int(height)
 }
 
+// pixelSize returns the drawing area size in pixels.
+func (os *nativeOs) pixelSize() (w, h int) { return os.nl.pixelSize(os.nr) }
+
+// scale returns the drawing area content scale.
+func (os *nativeOs) scale() float64 { return os.nl.scale(os.nr) }
+
 // showCursor shows or hides the window cursor.
 func (os *nativeOs) showCursor(show bool) { os.nl.showCursor(os.nr, show) }
 
 // setCursor places the cursor at the given screen coordinates.
 func (os *nativeOs) setCursorAt(x, y int) { os.nl.setCursorAt(os.nr, x, y) }
 
+// lockCursor locks or unlocks the window cursor.
+func (os *nativeOs) lockCursor(lock bool) { os.nl.lockCursor(os.nr, lock) }
+
+// setCursorShape uses one of the system cursor shapes.
+func (os *nativeOs) setCursorShape(shape int) { os.nl.cursorShape(os.nr, shape) }
+
+// setCursorImage uses the given image as the cursor.
+func (os *nativeOs) setCursorImage(img *image.NRGBA, hotX, hotY int) {
+	if pixels, w, h := cursorPixels(img); len(pixels) > 0 {
+		os.nl.cursorImage(os.nr, pixels, w, h, hotX, hotY)
+	}
+}
+
+// retitle changes the window title.
+func (os *nativeOs) retitle(title string) { os.nl.retitle(os.nr, title) }
+
+// setIcon uses the given image as the application icon.
+func (os *nativeOs) setIcon(img *image.NRGBA) {
+	if pixels, w, h := cursorPixels(img); len(pixels) > 0 {
+		os.nl.icon(os.nr, pixels, w, h)
+	}
+}
+
+// attention asks for the user's attention.
+func (os *nativeOs) attention() { os.nl.attention(os.nr) }
+
+// progress shows the progress on the taskbar button or dock icon.
+func (os *nativeOs) progress(p float64) { os.nl.progress(os.nr, p) }
+
+// cursorPixels packs the image rows together since the native layers
+// expect the pixels without any row padding.
+func cursorPixels(img *image.NRGBA) (pixels []byte, w, h int) {
+	b := img.Bounds()
+	w, h = b.Dx(), b.Dy()
+	pixels = make([]byte, 0, w*h*4)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		start := img.PixOffset(b.Min.X, y)
+		pixels = append(pixels, img.Pix[start:start+w*4]...)
+	}
+	return pixels, w, h
+}
+
 // createContext makes and initializes the OpenGL context.
 func (os *nativeOs) createContext(depth, alpha int) {
 	os.nl.setDepthBufferSize(depth)
@@ -212,6 +358,14 @@ func (os *nativeOs) isFullscreen() bool { return os.nl.isFullscreen(os.nr) }
 // toggleFullscreen flips between application windowed and full screen mode.
 func (os *nativeOs) toggleFullscreen() { os.nl.toggleFullscreen(os.nr) }
 
+// monitors lists the attached displays.
+func (os *nativeOs) monitors() []Monitor { return os.nl.monitors(os.nr) }
+
+// setWindowMode switches between windowed and full screen modes.
+func (os *nativeOs) setWindowMode(mode, monitor int, m Mode) {
+	os.nl.setWindowMode(os.nr, mode, monitor, m)
+}
+
 // swapBuffers flips the drawing buffers.
 // Expected to be called at the end of each drawwing loop.
 func (os *nativeOs) swapBuffers() { os.nl.swapBuffers(os.nr) }
@@ -223,6 +377,24 @@ func (os *nativeOs) readDispatch(in *userInput) *userInput {
 	return os.nl.readDispatch(os.nr, in)
 }
 
+// readPads polls the native controller state.
+func (os *nativeOs) readPads(pads []rawPad) { os.nl.gamepads(os.nr, pads) }
+
+// rumble starts or stops the controller motors.
+func (os *nativeOs) rumble(pad int, low, high float64) { os.nl.rumble(os.nr, pad, low, high) }
+
+// readTouches polls the native touch screen touches.
+func (os *nativeOs) readTouches(touches []Touch) []Touch { return os.nl.touches(os.nr, touches) }
+
+// interceptClose reports close requests instead of closing the window.
+func (os *nativeOs) interceptClose(intercept bool) { os.nl.interceptClose(os.nr, intercept) }
+
+// readDropped polls the native dropped files.
+func (os *nativeOs) readDropped() []string { return os.nl.dropped(os.nr) }
+
+// readText polls the native typed text.
+func (os *nativeOs) readText() (typed, composing string) { return os.nl.text(os.nr) }
+
 // copyClip puts the given string on the system clipboard.
 func (os *nativeOs) copyClip() string { return os.nl.copyClip(os.nr) }
 
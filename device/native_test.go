@@ -0,0 +1,22 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+import (
+	"image"
+	"testing"
+)
+
+// Cursor images are sent to the native layers without row padding.
+func TestCursorPixels(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for cnt := range img.Pix {
+		img.Pix[cnt] = byte(cnt)
+	}
+	sub := img.SubImage(image.Rect(1, 1, 3, 3)).(*image.NRGBA)
+	pixels, w, h := cursorPixels(sub)
+	if w != 2 || h != 2 || len(pixels) != 16 || pixels[0] != 20 || pixels[8] != 36 {
+		t.Errorf("Expected packed 2x2 image got %dx%d %v", w, h, pixels)
+	}
+}
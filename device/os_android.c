@@ -0,0 +1,550 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// The android native layer implementation. The application is built with
+// -buildmode=c-shared and started as a NativeActivity. The activity
+// callbacks happen on the activity thread and are handed to the
+// application thread which polls them using gs_read_dispatch.
+
+#include <jni.h>
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+#include <android/configuration.h>
+#include <android/input.h>
+#include <android/keycodes.h>
+#include <android/log.h>
+#include <android/looper.h>
+#include <android/native_activity.h>
+#include <android/native_window.h>
+#include <EGL/egl.h>
+#include "os_mobile.h"
+
+#ifndef EGL_OPENGL_ES3_BIT
+#define EGL_OPENGL_ES3_BIT 0x00000040
+#endif
+
+#define gs_log(...) __android_log_print(ANDROID_LOG_INFO, "vu", __VA_ARGS__)
+
+// Application defaults. Can be overridden by gs_set_attr_l.
+struct AppDefaults {
+    long gs_AlphaSize;
+    long gs_DepthSize;
+};
+struct AppDefaults defaults = { 8, 24 };
+
+// State shared between the activity thread and the application thread.
+// Guarded by gs_lock. The activity thread waits for the application
+// thread to let go of windows and input queues before they are destroyed.
+static pthread_mutex_t gs_lock = PTHREAD_MUTEX_INITIALIZER;
+static pthread_cond_t gs_cond = PTHREAD_COND_INITIALIZER;
+static ANativeActivity *gs_activity = NULL;
+static ANativeWindow *gs_window = NULL;  // window from the activity.
+static AInputQueue *gs_queue = NULL;     // input from the activity.
+static long gs_alive = 0;                // -2 once the activity is destroyed.
+static unsigned char gs_started = 0;     // application thread started.
+static long gs_pending[32];              // lifecycle events for the application.
+static int gs_npending = 0;
+
+// Application thread state.
+static ANativeWindow *gs_bound = NULL;   // window used by the surface.
+static AInputQueue *gs_attached = NULL;  // queue attached to the looper.
+static ALooper *gs_looper = NULL;
+static EGLDisplay gs_egl = EGL_NO_DISPLAY;
+static EGLConfig gs_config = NULL;
+static EGLContext gs_ctx = EGL_NO_CONTEXT;
+static EGLSurface gs_surface = EGL_NO_SURFACE;
+static long gs_width = 0, gs_height = 0;
+static long gs_mousex = 0, gs_mousey = 0;
+static long gs_mods = 0;
+static unsigned char gs_close_intercepted = 0;
+
+// Touches that are down or have ended since the last gs_touches call.
+static GSTouch gs_touch[GS_MaxTouches];
+static int gs_ntouch = 0;
+
+// Text typed since the last gs_text call.
+static char gs_typed[GS_TextSize];
+static int gs_ntyped = 0;
+
+// Queue a lifecycle event for the application thread. Must hold gs_lock.
+static void gs_post(long event) {
+    if (gs_npending < (int)(sizeof(gs_pending) / sizeof(gs_pending[0]))) {
+        gs_pending[gs_npending++] = event;
+    }
+}
+
+// Post a lifecycle event from the activity thread.
+static void gs_post_locked(long event) {
+    pthread_mutex_lock(&gs_lock);
+    gs_post(event);
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// The application main runs until the application returns from main.
+static void* gs_run(void *arg) {
+    gs_go_main();
+    return NULL;
+}
+
+// Activity callbacks run on the activity thread.
+static void gs_on_resume(ANativeActivity *activity) { gs_post_locked(GS_WindowUniconified); }
+static void gs_on_pause(ANativeActivity *activity) { gs_post_locked(GS_WindowIconified); }
+static void gs_on_focus(ANativeActivity *activity, int focused) {
+    gs_post_locked(focused ? GS_WindowActive : GS_WindowInactive);
+}
+static void gs_on_resized(ANativeActivity *activity, ANativeWindow *window) {
+    gs_post_locked(GS_WindowResized);
+}
+static void gs_on_configuration(ANativeActivity *activity) { gs_post_locked(GS_WindowResized); }
+static void gs_on_window_created(ANativeActivity *activity, ANativeWindow *window) {
+    pthread_mutex_lock(&gs_lock);
+    gs_window = window;
+    pthread_cond_broadcast(&gs_cond);
+    pthread_mutex_unlock(&gs_lock);
+}
+static void gs_on_window_destroyed(ANativeActivity *activity, ANativeWindow *window) {
+    pthread_mutex_lock(&gs_lock);
+    gs_window = NULL;
+    while (gs_bound == window && gs_alive == 1) {
+        pthread_cond_wait(&gs_cond, &gs_lock);
+    }
+    pthread_mutex_unlock(&gs_lock);
+}
+static void gs_on_queue_created(ANativeActivity *activity, AInputQueue *queue) {
+    pthread_mutex_lock(&gs_lock);
+    gs_queue = queue;
+    pthread_mutex_unlock(&gs_lock);
+}
+static void gs_on_queue_destroyed(ANativeActivity *activity, AInputQueue *queue) {
+    pthread_mutex_lock(&gs_lock);
+    gs_queue = NULL;
+    while (gs_attached == queue && gs_alive == 1) {
+        pthread_cond_wait(&gs_cond, &gs_lock);
+    }
+    pthread_mutex_unlock(&gs_lock);
+}
+static void gs_on_destroy(ANativeActivity *activity) {
+    pthread_mutex_lock(&gs_lock);
+    gs_activity = NULL;
+    gs_alive = -2;
+    pthread_cond_broadcast(&gs_cond);
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// Entry point for the NativeActivity. The application main is started
+// once. Activities are recreated on configuration changes unless the
+// manifest lists the changes in android:configChanges.
+void ANativeActivity_onCreate(ANativeActivity *activity, void *savedState, size_t savedStateSize) {
+    activity->callbacks->onResume = gs_on_resume;
+    activity->callbacks->onPause = gs_on_pause;
+    activity->callbacks->onWindowFocusChanged = gs_on_focus;
+    activity->callbacks->onNativeWindowCreated = gs_on_window_created;
+    activity->callbacks->onNativeWindowResized = gs_on_resized;
+    activity->callbacks->onNativeWindowDestroyed = gs_on_window_destroyed;
+    activity->callbacks->onInputQueueCreated = gs_on_queue_created;
+    activity->callbacks->onInputQueueDestroyed = gs_on_queue_destroyed;
+    activity->callbacks->onConfigurationChanged = gs_on_configuration;
+    activity->callbacks->onDestroy = gs_on_destroy;
+    pthread_mutex_lock(&gs_lock);
+    gs_activity = activity;
+    if (!gs_started) {
+        gs_started = 1;
+        pthread_t thread;
+        pthread_attr_t attr;
+        pthread_attr_init(&attr);
+        pthread_attr_setdetachstate(&attr, PTHREAD_CREATE_DETACHED);
+        pthread_create(&thread, &attr, gs_run, NULL);
+        pthread_attr_destroy(&attr);
+    }
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// Prepare the EGL display and the looper for input events.
+long gs_display_init() {
+    gs_looper = ALooper_prepare(ALOOPER_PREPARE_ALLOW_NON_CALLBACKS);
+    gs_egl = eglGetDisplay(EGL_DEFAULT_DISPLAY);
+    if (gs_egl == EGL_NO_DISPLAY || !eglInitialize(gs_egl, NULL, NULL)) {
+        gs_log("Failed to initialize EGL.");
+        return 0;
+    }
+    return 1;
+}
+
+// Release the surface, context, and input queue.
+void gs_display_dispose(long display) {
+    pthread_mutex_lock(&gs_lock);
+    if (gs_attached != NULL) {
+        AInputQueue_detachLooper(gs_attached);
+        gs_attached = NULL;
+    }
+    if (gs_egl != EGL_NO_DISPLAY) {
+        eglMakeCurrent(gs_egl, EGL_NO_SURFACE, EGL_NO_SURFACE, EGL_NO_CONTEXT);
+        if (gs_surface != EGL_NO_SURFACE) {
+            eglDestroySurface(gs_egl, gs_surface);
+            gs_surface = EGL_NO_SURFACE;
+        }
+        if (gs_ctx != EGL_NO_CONTEXT) {
+            eglDestroyContext(gs_egl, gs_ctx);
+            gs_ctx = EGL_NO_CONTEXT;
+        }
+        eglTerminate(gs_egl);
+        gs_egl = EGL_NO_DISPLAY;
+    }
+    gs_bound = NULL;
+    pthread_cond_broadcast(&gs_cond);
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// Wait for the activity window and pick a matching frame buffer configuration.
+long gs_shell(long display) {
+    pthread_mutex_lock(&gs_lock);
+    while (gs_window == NULL && gs_alive != -2) {
+        pthread_cond_wait(&gs_cond, &gs_lock);
+    }
+    ANativeWindow *window = gs_window;
+    if (window != NULL) {
+        gs_alive = 1;
+    }
+    pthread_mutex_unlock(&gs_lock);
+    if (window == NULL) {
+        return 0;
+    }
+    EGLint attribs[] = {
+        EGL_RENDERABLE_TYPE, EGL_OPENGL_ES3_BIT,
+        EGL_SURFACE_TYPE,    EGL_WINDOW_BIT,
+        EGL_RED_SIZE,        8,
+        EGL_GREEN_SIZE,      8,
+        EGL_BLUE_SIZE,       8,
+        EGL_ALPHA_SIZE,      (EGLint)defaults.gs_AlphaSize,
+        EGL_DEPTH_SIZE,      (EGLint)defaults.gs_DepthSize,
+        EGL_NONE
+    };
+    EGLint count = 0;
+    if (!eglChooseConfig(gs_egl, attribs, &gs_config, 1, &count) || count == 0) {
+        gs_log("No matching frame buffer configuration.");
+        return 0;
+    }
+    return (long)window;
+}
+
+// Return 1 until the activity is destroyed.
+unsigned char gs_shell_alive(long shell) { return gs_alive == 1; }
+
+// Create a drawing surface for the given window. Must hold gs_lock.
+static void gs_bind_window(ANativeWindow *window) {
+    EGLint format;
+    eglGetConfigAttrib(gs_egl, gs_config, EGL_NATIVE_VISUAL_ID, &format);
+    ANativeWindow_setBuffersGeometry(window, 0, 0, format);
+    gs_surface = eglCreateWindowSurface(gs_egl, gs_config, window, NULL);
+    if (gs_surface == EGL_NO_SURFACE) {
+        gs_log("Failed to create the window surface.");
+        return;
+    }
+    eglMakeCurrent(gs_egl, gs_surface, gs_surface, gs_ctx);
+    gs_bound = window;
+    gs_width = ANativeWindow_getWidth(window);
+    gs_height = ANativeWindow_getHeight(window);
+}
+
+// Release the drawing surface while keeping the context and its resources.
+// Must hold gs_lock.
+static void gs_unbind_window() {
+    if (gs_surface != EGL_NO_SURFACE) {
+        eglMakeCurrent(gs_egl, EGL_NO_SURFACE, EGL_NO_SURFACE, gs_ctx);
+        eglDestroySurface(gs_egl, gs_surface);
+        gs_surface = EGL_NO_SURFACE;
+    }
+    gs_bound = NULL;
+    pthread_cond_broadcast(&gs_cond);
+}
+
+// Create an OpenGL ES 3 context and a surface for the window.
+long gs_context(long display, long shell) {
+    EGLint attribs[] = { EGL_CONTEXT_CLIENT_VERSION, 3, EGL_NONE };
+    gs_ctx = eglCreateContext(gs_egl, gs_config, EGL_NO_CONTEXT, attribs);
+    if (gs_ctx == EGL_NO_CONTEXT) {
+        gs_log("Failed to create an OpenGL ES 3 context.");
+        return 0;
+    }
+    pthread_mutex_lock(&gs_lock);
+    if (gs_window == (ANativeWindow *)shell) {
+        gs_bind_window(gs_window);
+    }
+    pthread_mutex_unlock(&gs_lock);
+    return (long)gs_ctx;
+}
+
+// Show the latest drawing if there is a window.
+void gs_swap_buffers(long display) {
+    if (gs_surface != EGL_NO_SURFACE) {
+        eglSwapBuffers(gs_egl, gs_surface);
+    }
+}
+
+// Turn a key press into text using the java KeyEvent since the
+// native key events don't provide characters.
+static void gs_key_text(AInputEvent *event) {
+    ANativeActivity *activity = gs_activity;
+    if (activity == NULL) {
+        return;
+    }
+    JNIEnv *env = NULL;
+    JavaVM *vm = activity->vm;
+    if ((*vm)->AttachCurrentThread(vm, &env, NULL) != JNI_OK) {
+        return;
+    }
+    jclass cls = (*env)->FindClass(env, "android/view/KeyEvent");
+    if (cls != NULL) {
+        jmethodID init = (*env)->GetMethodID(env, cls, "<init>", "(II)V");
+        jmethodID unicode = (*env)->GetMethodID(env, cls, "getUnicodeChar", "(I)I");
+        jobject key = (*env)->NewObject(env, cls, init, AKEY_EVENT_ACTION_DOWN, AKeyEvent_getKeyCode(event));
+        if (key != NULL) {
+            int c = (*env)->CallIntMethod(env, key, unicode, AKeyEvent_getMetaState(event));
+            if (c >= 0x20 && c != 0x7F && gs_ntyped + 4 < GS_TextSize) {
+                char *s = &gs_typed[gs_ntyped]; // encode the code point as UTF-8.
+                if (c < 0x80) {
+                    s[0] = c;
+                    gs_ntyped += 1;
+                } else if (c < 0x800) {
+                    s[0] = 0xC0 | (c >> 6);
+                    s[1] = 0x80 | (c & 0x3F);
+                    gs_ntyped += 2;
+                } else {
+                    s[0] = 0xE0 | (c >> 12);
+                    s[1] = 0x80 | ((c >> 6) & 0x3F);
+                    s[2] = 0x80 | (c & 0x3F);
+                    gs_ntyped += 3;
+                }
+                gs_typed[gs_ntyped] = 0;
+            }
+            (*env)->DeleteLocalRef(env, key);
+        }
+        (*env)->DeleteLocalRef(env, cls);
+    }
+    if ((*env)->ExceptionCheck(env)) {
+        (*env)->ExceptionClear(env);
+    }
+}
+
+// Translate the android meta state into the modifier masks.
+static long gs_meta(int32_t meta) {
+    long mods = 0;
+    if (meta & AMETA_SHIFT_ON) {
+        mods |= GS_ShiftKeyMask;
+    }
+    if (meta & AMETA_CTRL_ON) {
+        mods |= GS_ControlKeyMask;
+    }
+    if (meta & AMETA_ALT_ON) {
+        mods |= GS_AlternateKeyMask;
+    }
+    if (meta & AMETA_META_ON) {
+        mods |= GS_CommandKeyMask;
+    }
+    if (meta & AMETA_FUNCTION_ON) {
+        mods |= GS_FunctionKeyMask;
+    }
+    return mods;
+}
+
+// Update the tracked touch with the given id. Touches that begin
+// when all the touch slots are used are ignored.
+static void gs_touch_update(long id, long phase, float x, float y) {
+    GSTouch *touch = NULL;
+    int cnt;
+    for (cnt = 0; cnt < gs_ntouch; cnt++) {
+        if (gs_touch[cnt].id == id && gs_touch[cnt].phase != GS_TouchEnded) {
+            touch = &gs_touch[cnt];
+        }
+    }
+    if (touch == NULL) {
+        if (phase != GS_TouchBegan || gs_ntouch >= GS_MaxTouches) {
+            return;
+        }
+        touch = &gs_touch[gs_ntouch++];
+        touch->id = id;
+        touch->phase = GS_TouchBegan;
+    } else if (phase == GS_TouchEnded) {
+        touch->phase = GS_TouchEnded;
+    }
+    touch->x = x;
+    touch->y = y;
+}
+
+// Translate a key or touch event. Returns 1 if the event was handled.
+static int gs_input(AInputEvent *event, GSEvent *gs_urge) {
+    if (AInputEvent_getType(event) == AINPUT_EVENT_TYPE_KEY) {
+        int32_t action = AKeyEvent_getAction(event);
+        int32_t code = AKeyEvent_getKeyCode(event);
+        gs_mods = gs_meta(AKeyEvent_getMetaState(event));
+        if (code == AKEYCODE_BACK) {
+            if (!gs_close_intercepted) {
+                return 0; // let the system stop the activity.
+            }
+            if (action == AKEY_EVENT_ACTION_UP) {
+                gs_urge->event = GS_WindowClosing;
+            }
+            return 1;
+        }
+        if (action == AKEY_EVENT_ACTION_DOWN) {
+            if (AKeyEvent_getRepeatCount(event) == 0) {
+                gs_urge->event = GS_KeyDown;
+                gs_urge->key = code;
+            }
+            gs_key_text(event);
+        } else if (action == AKEY_EVENT_ACTION_UP) {
+            gs_urge->event = GS_KeyUp;
+            gs_urge->key = code;
+        }
+        return 1;
+    }
+    if (AInputEvent_getType(event) != AINPUT_EVENT_TYPE_MOTION) {
+        return 0;
+    }
+
+    // touch locations are in pixels from the top left.
+    int32_t action = AMotionEvent_getAction(event);
+    int32_t masked = action & AMOTION_EVENT_ACTION_MASK;
+    size_t index = (action & AMOTION_EVENT_ACTION_POINTER_INDEX_MASK) >> AMOTION_EVENT_ACTION_POINTER_INDEX_SHIFT;
+    size_t cnt, count = AMotionEvent_getPointerCount(event);
+    for (cnt = 0; cnt < count; cnt++) {
+        long phase = GS_TouchMoved;
+        if ((masked == AMOTION_EVENT_ACTION_DOWN || masked == AMOTION_EVENT_ACTION_POINTER_DOWN) && cnt == index) {
+            phase = GS_TouchBegan;
+        } else if (((masked == AMOTION_EVENT_ACTION_UP || masked == AMOTION_EVENT_ACTION_POINTER_UP) && cnt == index) ||
+            masked == AMOTION_EVENT_ACTION_CANCEL) {
+            phase = GS_TouchEnded;
+        }
+        float x = AMotionEvent_getX(event, cnt);
+        float y = gs_height - AMotionEvent_getY(event, cnt);
+        gs_touch_update(AMotionEvent_getPointerId(event, cnt), phase, x, y);
+    }
+
+    // the first touch also acts as the left mouse button.
+    gs_mousex = AMotionEvent_getX(event, 0);
+    gs_mousey = gs_height - AMotionEvent_getY(event, 0);
+    switch (masked) {
+    case AMOTION_EVENT_ACTION_DOWN:   gs_urge->event = GS_LeftMouseDown; break;
+    case AMOTION_EVENT_ACTION_UP:
+    case AMOTION_EVENT_ACTION_CANCEL: gs_urge->event = GS_LeftMouseUp; break;
+    case AMOTION_EVENT_ACTION_MOVE:   gs_urge->event = GS_MouseMoved; break;
+    }
+    return 1;
+}
+
+// Follow the activity window and input queue, then process one
+// lifecycle or input event.
+void gs_read_dispatch(long display, GSEvent *gs_urge) {
+    pthread_mutex_lock(&gs_lock);
+    if (gs_bound != gs_window && gs_ctx != EGL_NO_CONTEXT) {
+        gs_unbind_window();
+        if (gs_window != NULL) {
+            gs_bind_window(gs_window);
+            gs_post(GS_WindowResized);
+        }
+    } else if (gs_bound != NULL) {
+        long w = ANativeWindow_getWidth(gs_bound), h = ANativeWindow_getHeight(gs_bound);
+        if (w != gs_width || h != gs_height) {
+            gs_width = w;
+            gs_height = h;
+            gs_post(GS_WindowResized);
+        }
+    }
+    if (gs_attached != gs_queue) {
+        if (gs_attached != NULL) {
+            AInputQueue_detachLooper(gs_attached);
+        }
+        gs_attached = gs_queue;
+        if (gs_attached != NULL) {
+            AInputQueue_attachLooper(gs_attached, gs_looper, 1, NULL, NULL);
+        }
+        pthread_cond_broadcast(&gs_cond);
+    }
+    if (gs_npending > 0) {
+        gs_urge->event = gs_pending[0];
+        memmove(&gs_pending[0], &gs_pending[1], --gs_npending * sizeof(gs_pending[0]));
+    }
+    pthread_mutex_unlock(&gs_lock);
+
+    // the input queue stays attached while the activity thread waits for it.
+    ALooper_pollOnce(0, NULL, NULL, NULL);
+    AInputEvent *event = NULL;
+    while (gs_urge->event == 0 && gs_attached != NULL && AInputQueue_getEvent(gs_attached, &event) >= 0) {
+        if (AInputQueue_preDispatchEvent(gs_attached, event)) {
+            continue; // consumed by the input method.
+        }
+        AInputQueue_finishEvent(gs_attached, event, gs_input(event, gs_urge));
+    }
+    gs_urge->mousex = gs_mousex;
+    gs_urge->mousey = gs_mousey;
+    gs_urge->mods = gs_mods;
+}
+
+// Get the touches that are down or have ended since the last call.
+// Ended touches are then dropped and the others are marked as moved.
+long gs_touches(GSTouch *touches) {
+    long count = gs_ntouch;
+    int cnt, kept = 0;
+    for (cnt = 0; cnt < gs_ntouch; cnt++) {
+        touches[cnt] = gs_touch[cnt];
+        if (gs_touch[cnt].phase != GS_TouchEnded) {
+            gs_touch[kept] = gs_touch[cnt];
+            gs_touch[kept].phase = GS_TouchMoved;
+            kept++;
+        }
+    }
+    gs_ntouch = kept;
+    return count;
+}
+
+// Get the text typed since the last call.
+void gs_text(GSText *text) {
+    memcpy(text->typed, gs_typed, gs_ntyped + 1);
+    text->composing[0] = 0;
+    gs_ntyped = 0;
+    gs_typed[0] = 0;
+}
+
+// Report GS_WindowClosing for the back button instead of stopping the activity.
+void gs_intercept_close(unsigned char intercept) { gs_close_intercepted = intercept; }
+
+// Android windows are sized in pixels.
+void gs_size(long display, long *x, long *y, long *w, long *h) {
+    *x = 0;
+    *y = 0;
+    *w = gs_width;
+    *h = gs_height;
+}
+void gs_pixel_size(long display, long *w, long *h) {
+    *w = gs_width;
+    *h = gs_height;
+}
+
+// The screen density relative to the 160 dots per inch baseline.
+float gs_scale(long display) {
+    float scale = 1.0f;
+    pthread_mutex_lock(&gs_lock);
+    if (gs_activity != NULL) {
+        AConfiguration *config = AConfiguration_new();
+        AConfiguration_fromAssetManager(config, gs_activity->assetManager);
+        int32_t density = AConfiguration_getDensity(config);
+        if (density > 0 && density != ACONFIGURATION_DENSITY_NONE && density != ACONFIGURATION_DENSITY_ANY) {
+            scale = density / (float)ACONFIGURATION_DENSITY_MEDIUM;
+        }
+        AConfiguration_delete(config);
+    }
+    pthread_mutex_unlock(&gs_lock);
+    return scale;
+}
+
+// Set long attributes. Only positive values are used.
+void gs_set_attr_l(long attr, long value) {
+    if (value <= 0) {
+        return;
+    }
+    switch (attr) {
+    case GS_AlphaSize: defaults.gs_AlphaSize = value; break;
+    case GS_DepthSize: defaults.gs_DepthSize = value; break;
+    }
+}
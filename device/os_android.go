@@ -0,0 +1,115 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+// Expose the underlying android key codes as generic code.
+// Each native layer is expected to support the generic codes.
+//
+// Android key codes identify the key meaning rather than the key
+// location. The keys without an android key code are given unique
+// unused values.
+const (
+	key0              = 0x07  // AKEYCODE_0
+	key1              = 0x08  // AKEYCODE_1
+	key2              = 0x09  // AKEYCODE_2
+	key3              = 0x0A  // AKEYCODE_3
+	key4              = 0x0B  // AKEYCODE_4
+	key5              = 0x0C  // AKEYCODE_5
+	key6              = 0x0D  // AKEYCODE_6
+	key7              = 0x0E  // AKEYCODE_7
+	key8              = 0x0F  // AKEYCODE_8
+	key9              = 0x10  // AKEYCODE_9
+	keyA              = 0x1D  // AKEYCODE_A
+	keyB              = 0x1E  // AKEYCODE_B
+	keyC              = 0x1F  // AKEYCODE_C
+	keyD              = 0x20  // AKEYCODE_D
+	keyE              = 0x21  // AKEYCODE_E
+	keyF              = 0x22  // AKEYCODE_F
+	keyG              = 0x23  // AKEYCODE_G
+	keyH              = 0x24  // AKEYCODE_H
+	keyI              = 0x25  // AKEYCODE_I
+	keyJ              = 0x26  // AKEYCODE_J
+	keyK              = 0x27  // AKEYCODE_K
+	keyL              = 0x28  // AKEYCODE_L
+	keyM              = 0x29  // AKEYCODE_M
+	keyN              = 0x2A  // AKEYCODE_N
+	keyO              = 0x2B  // AKEYCODE_O
+	keyP              = 0x2C  // AKEYCODE_P
+	keyQ              = 0x2D  // AKEYCODE_Q
+	keyR              = 0x2E  // AKEYCODE_R
+	keyS              = 0x2F  // AKEYCODE_S
+	keyT              = 0x30  // AKEYCODE_T
+	keyU              = 0x31  // AKEYCODE_U
+	keyV              = 0x32  // AKEYCODE_V
+	keyW              = 0x33  // AKEYCODE_W
+	keyX              = 0x34  // AKEYCODE_X
+	keyY              = 0x35  // AKEYCODE_Y
+	keyZ              = 0x36  // AKEYCODE_Z
+	keyF1             = 0x83  // AKEYCODE_F1
+	keyF2             = 0x84  // AKEYCODE_F2
+	keyF3             = 0x85  // AKEYCODE_F3
+	keyF4             = 0x86  // AKEYCODE_F4
+	keyF5             = 0x87  // AKEYCODE_F5
+	keyF6             = 0x88  // AKEYCODE_F6
+	keyF7             = 0x89  // AKEYCODE_F7
+	keyF8             = 0x8A  // AKEYCODE_F8
+	keyF9             = 0x8B  // AKEYCODE_F9
+	keyF10            = 0x8C  // AKEYCODE_F10
+	keyF11            = 0x8D  // AKEYCODE_F11
+	keyF12            = 0x8E  // AKEYCODE_F12
+	keyF13            = 0x1F0 // unused: android has no F13
+	keyF14            = 0x1F1 // unused: android has no F14
+	keyF15            = 0x1F2 // unused: android has no F15
+	keyF16            = 0x1F3 // unused: android has no F16
+	keyF17            = 0x1F4 // unused: android has no F17
+	keyF18            = 0x1F5 // unused: android has no F18
+	keyF19            = 0x1F6 // unused: android has no F19
+	keyF20            = 0x1F7 // unused: android has no F20
+	keyKeypad0        = 0x90  // AKEYCODE_NUMPAD_0
+	keyKeypad1        = 0x91  // AKEYCODE_NUMPAD_1
+	keyKeypad2        = 0x92  // AKEYCODE_NUMPAD_2
+	keyKeypad3        = 0x93  // AKEYCODE_NUMPAD_3
+	keyKeypad4        = 0x94  // AKEYCODE_NUMPAD_4
+	keyKeypad5        = 0x95  // AKEYCODE_NUMPAD_5
+	keyKeypad6        = 0x96  // AKEYCODE_NUMPAD_6
+	keyKeypad7        = 0x97  // AKEYCODE_NUMPAD_7
+	keyKeypad8        = 0x98  // AKEYCODE_NUMPAD_8
+	keyKeypad9        = 0x99  // AKEYCODE_NUMPAD_9
+	keyKeypadDecimal  = 0x9E  // AKEYCODE_NUMPAD_DOT
+	keyKeypadMultiply = 0x9B  // AKEYCODE_NUMPAD_MULTIPLY
+	keyKeypadPlus     = 0x9D  // AKEYCODE_NUMPAD_ADD
+	keyKeypadClear    = 0x8F  // AKEYCODE_NUM_LOCK
+	keyKeypadDivide   = 0x9A  // AKEYCODE_NUMPAD_DIVIDE
+	keyKeypadEnter    = 0xA0  // AKEYCODE_NUMPAD_ENTER
+	keyKeypadMinus    = 0x9C  // AKEYCODE_NUMPAD_SUBTRACT
+	keyKeypadEquals   = 0xA1  // AKEYCODE_NUMPAD_EQUALS
+	keyEqual          = 0x46  // AKEYCODE_EQUALS
+	keyMinus          = 0x45  // AKEYCODE_MINUS
+	keyLeftBracket    = 0x47  // AKEYCODE_LEFT_BRACKET
+	keyRightBracket   = 0x48  // AKEYCODE_RIGHT_BRACKET
+	keyQuote          = 0x4B  // AKEYCODE_APOSTROPHE
+	keySemicolon      = 0x4A  // AKEYCODE_SEMICOLON
+	keyBackslash      = 0x49  // AKEYCODE_BACKSLASH
+	keyGrave          = 0x44  // AKEYCODE_GRAVE
+	keySlash          = 0x4C  // AKEYCODE_SLASH
+	keyComma          = 0x37  // AKEYCODE_COMMA
+	keyPeriod         = 0x38  // AKEYCODE_PERIOD
+	keyReturn         = 0x42  // AKEYCODE_ENTER
+	keyTab            = 0x3D  // AKEYCODE_TAB
+	keySpace          = 0x3E  // AKEYCODE_SPACE
+	keyDelete         = 0x43  // AKEYCODE_DEL
+	keyForwardDelete  = 0x70  // AKEYCODE_FORWARD_DEL
+	keyEscape         = 0x6F  // AKEYCODE_ESCAPE
+	keyHome           = 0x7A  // AKEYCODE_MOVE_HOME
+	keyPageUp         = 0x5C  // AKEYCODE_PAGE_UP
+	keyPageDown       = 0x5D  // AKEYCODE_PAGE_DOWN
+	keyLeftArrow      = 0x15  // AKEYCODE_DPAD_LEFT
+	keyRightArrow     = 0x16  // AKEYCODE_DPAD_RIGHT
+	keyDownArrow      = 0x14  // AKEYCODE_DPAD_DOWN
+	keyUpArrow        = 0x13  // AKEYCODE_DPAD_UP
+	keyEnd            = 0x7B  // AKEYCODE_MOVE_END
+	mouseLeft         = 0x1F1 // primary touch (tack on values past keys and gamepad buttons)
+	mouseMiddle       = 0x1F2 // unused
+	mouseRight        = 0x1F3 // unused
+)
@@ -1,6 +1,8 @@
 // Copyright © 2013-2016 Galvanized Logic Inc.
 // Use is governed by a BSD-style license found in the LICENSE file.
 
+// +build !ios
+
 package device
 
 // The OSX (darwin) native layer. This wraps the c functions that wrap the
@@ -10,7 +12,7 @@ package device
 // // The following block is C code and cgo directvies.
 //
 // #cgo darwin CFLAGS: -x objective-c -fno-common
-// #cgo darwin LDFLAGS: -framework Cocoa -framework OpenGL
+// #cgo darwin LDFLAGS: -framework Cocoa -framework OpenGL -framework IOKit
 //
 // #include <stdlib.h>
 // #include "os_darwin.h"
@@ -18,12 +20,16 @@ import "C" // must be located here.
 
 import (
 	"runtime"
+	"strings"
 	"unsafe"
 )
 
 // OS specific structure to differentiate it from the other native layers.
 type osx struct {
-	gsu *C.GSEvent
+	gsu  *C.GSEvent
+	pads [MaxPads]C.GSPad // Matches GS_MaxPads.
+
+	typing C.GSText // Typed text from the last read.
 }
 
 // OSX specific. Otherwise the shell will freeze within seconds of creation.
@@ -56,6 +62,20 @@ func (o *osx) showCursor(r *nrefs, show bool) {
 	}
 	C.gs_show_cursor(C.uchar(trueFalse))
 }
+func (o *osx) interceptClose(r *nrefs, intercept bool) {
+	tf1 := 0
+	if intercept {
+		tf1 = 1
+	}
+	C.gs_intercept_close(C.uchar(tf1))
+}
+func (o *osx) lockCursor(r *nrefs, lock bool) {
+	trueFalse := 0 // trueFalse needs to be 0 or 1.
+	if lock {
+		trueFalse = 1
+	}
+	C.gs_lock_cursor(C.long(r.display), C.uchar(trueFalse))
+}
 
 // Implement native interface.
 func (o *osx) readDispatch(r *nrefs, in *userInput) *userInput {
@@ -64,6 +84,8 @@ func (o *osx) readDispatch(r *nrefs, in *userInput) *userInput {
 	o.gsu.mousey = -1
 	o.gsu.key = 0
 	o.gsu.scroll = 0
+	o.gsu.dx = 0
+	o.gsu.dy = 0
 
 	// o.gsu.mods retain the modifier key state between calls.
 	C.gs_read_dispatch(C.long(r.display), o.gsu)
@@ -73,16 +95,80 @@ func (o *osx) readDispatch(r *nrefs, in *userInput) *userInput {
 	if in.id != 0 {
 		in.button = mouseButtons[int(o.gsu.event)]
 		in.key = int(o.gsu.key)
+		in.scan = in.key // OSX key codes are already key locations.
 		in.scroll = int(o.gsu.scroll)
 	} else {
-		in.button, in.key, in.scroll = 0, 0, 0
+		in.button, in.key, in.scan, in.scroll = 0, 0, 0, 0
 	}
 	in.mods = int(o.gsu.mods) & (controlKeyMask | shiftKeyMask | functionKeyMask | commandKeyMask | altKeyMask)
 	in.mouseX = int(o.gsu.mousex)
 	in.mouseY = int(o.gsu.mousey)
+	in.dx, in.dy = int(o.gsu.dx), int(o.gsu.dy)
 	return in
 }
 
+// Implement native interface. Controllers are reported in their native
+// layout and identified using SDL style USB guids.
+func (o *osx) gamepads(r *nrefs, pads []rawPad) {
+	C.gs_pads(&o.pads[0])
+	for cnt := range pads {
+		gp, pad := &o.pads[cnt], &pads[cnt]
+		if pad.connected = gp.connected == 1; !pad.connected {
+			continue
+		}
+		pad.name = C.GoString(&gp.name[0])
+		pad.guid = padGUID(usbBus, int(gp.vendor), int(gp.product), int(gp.version))
+		pad.buttons, pad.axes, pad.hats = pad.buttons[:0], pad.axes[:0], pad.hats[:0]
+		for b := 0; b < int(gp.nbuttons); b++ {
+			pad.buttons = append(pad.buttons, gp.buttons[b] != 0)
+		}
+		for a := 0; a < int(gp.naxes); a++ {
+			pad.axes = append(pad.axes, float64(gp.axes[a]))
+		}
+		for h := 0; h < int(gp.nhats); h++ {
+			pad.hats = append(pad.hats, int(gp.hats[h]))
+		}
+	}
+}
+
+// Implement native interface. Rumble is not supported: the HID
+// controllers don't provide a common force feedback interface.
+func (o *osx) rumble(r *nrefs, pad int, low, high float64) {}
+
+// Implement native interface. Touch screens are not supported.
+func (o *osx) touches(r *nrefs, touches []Touch) []Touch { return touches }
+
+// Implement native interface.
+func (o *osx) monitors(r *nrefs) []Monitor {
+	gms := make([]C.GSMonitor, maxMonitors)
+	count := int(C.gs_monitors(&gms[0]))
+	monitors := make([]Monitor, count)
+	for cnt := range monitors {
+		gm, m := &gms[cnt], &monitors[cnt]
+		m.Name, m.Primary = C.GoString(&gm.name[0]), gm.primary == 1
+		m.X, m.Y, m.W, m.H = int(gm.x), int(gm.y), int(gm.w), int(gm.h)
+		for mode := 0; mode < int(gm.nmodes); mode++ {
+			dm := &gm.modes[mode]
+			m.Modes = append(m.Modes, Mode{W: int(dm.w), H: int(dm.h), Refresh: int(dm.refresh)})
+		}
+	}
+	return monitors
+}
+
+// maxMonitors matches GS_MaxMonitors.
+const maxMonitors = 8
+
+// Implement native interface.
+func (o *osx) setWindowMode(r *nrefs, mode, monitor int, m Mode) {
+	C.gs_set_window_mode(C.long(r.display), C.long(mode), C.long(monitor), C.long(m.W), C.long(m.H), C.long(m.Refresh))
+}
+
+// Implement native interface.
+func (o *osx) text(r *nrefs) (typed, composing string) {
+	C.gs_text(&o.typing)
+	return C.GoString(&o.typing.typed[0]), C.GoString(&o.typing.composing[0])
+}
+
 // Implement native interface.
 func (o *osx) size(r *nrefs) (x, y, w, h int) {
 	var winx, winy, width, height float32
@@ -90,6 +176,16 @@ func (o *osx) size(r *nrefs) (x, y, w, h int) {
 	return int(winx), int(winy), int(width), int(height)
 }
 
+// Implement native interface.
+func (o *osx) pixelSize(r *nrefs) (w, h int) {
+	var width, height float32
+	C.gs_pixel_size(C.long(r.shell), (*C.float)(&width), (*C.float)(&height))
+	return int(width), int(height)
+}
+
+// Implement native interface.
+func (o *osx) scale(r *nrefs) float64 { return float64(C.gs_scale(C.long(r.shell))) }
+
 // Implement native interface.
 func (o *osx) setSize(x, y, width, height int) {
 	C.gs_set_attr_l(C.GS_ShellX, C.long(x))
@@ -105,6 +201,38 @@ func (o *osx) setTitle(title string) {
 	C.gs_set_attr_s(C.GS_AppName, cstr)
 }
 
+// Implement native interface.
+func (o *osx) retitle(r *nrefs, title string) {
+	cstr := C.CString(title)
+	defer C.free(unsafe.Pointer(cstr))
+	C.gs_set_title(C.long(r.shell), cstr)
+}
+
+// Implement native interface. The icon is the dock icon.
+func (o *osx) icon(r *nrefs, pixels []byte, w, h int) {
+	C.gs_set_icon((*C.uchar)(&pixels[0]), C.long(w), C.long(h))
+}
+func (o *osx) attention(r *nrefs)           { C.gs_attention() }
+func (o *osx) progress(r *nrefs, p float64) { C.gs_progress(C.double(p)) }
+
+// Implement native interface.
+func (o *osx) cursorShape(r *nrefs, shape int) { C.gs_cursor_shape(C.long(r.shell), C.long(shape)) }
+
+// Implement native interface.
+func (o *osx) cursorImage(r *nrefs, pixels []byte, w, h, hotX, hotY int) {
+	C.gs_cursor_image(C.long(r.shell), (*C.uchar)(&pixels[0]), C.long(w), C.long(h), C.long(hotX), C.long(hotY))
+}
+
+// Implement native interface: nrefs unused, needed by other platforms.
+func (o *osx) dropped(r *nrefs) []string {
+	if cstr := C.gs_dropped(); cstr != nil {
+		paths := C.GoString(cstr)    // make a Go copy.
+		C.free(unsafe.Pointer(cstr)) // free the C copy.
+		return strings.Split(strings.TrimSuffix(paths, "\n"), "\n")
+	}
+	return nil
+}
+
 // Implement native interface: nrefs unused, needed by other platforms.
 func (o *osx) copyClip(r *nrefs) string {
 	if cstr := C.gs_clip_copy(); cstr != nil {
@@ -140,6 +268,7 @@ var events = map[int]int{
 	C.GS_WindowUniconified: uniconifiedShell,
 	C.GS_WindowActive:      activatedShell,
 	C.GS_WindowInactive:    deactivatedShell,
+	C.GS_WindowClosing:     closingShell,
 }
 
 // Map the mice buttons into left and right.
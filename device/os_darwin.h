@@ -22,8 +22,67 @@ typedef struct {
     long key;     // which key, or mouse button was affected, if any.
     long mods;    // which modifier keys are currently pressed, if any.
     long scroll;  // the scroll amount if any.
+    long dx;      // mouse movement while the cursor is locked.
+    long dy;      // mouse movement while the cursor is locked.
 } GSEvent;
 
+// Used to pass back the attached game controllers each polling call.
+// Buttons, axes, and hats are in the native controller order.
+#define GS_MaxPads    4
+#define GS_PadButtons 32
+#define GS_PadAxes    8
+#define GS_PadHats    4
+typedef struct {
+    long  connected;               // 1 while the controller is attached.
+    long  vendor;                  // USB vendor id.
+    long  product;                 // USB product id.
+    long  version;                 // Controller version number.
+    char  name[64];                // Controller product name.
+    long  nbuttons;                // Number of buttons.
+    long  naxes;                   // Number of axes.
+    long  nhats;                   // Number of hats.
+    long  buttons[GS_PadButtons];  // 1 if the button is pressed.
+    float axes[GS_PadAxes];        // Axis values from -1 to 1.
+    long  hats[GS_PadHats];        // Hat bitmask: up 1, right 2, down 4, left 8.
+} GSPad;
+
+// Used to pass back the typed text each polling call. Strings are UTF-8
+// and are dropped if they do not fit.
+#define GS_TextSize 256
+typedef struct {
+    char typed[GS_TextSize];     // text typed since the last call.
+    char composing[GS_TextSize]; // unfinished text from dead keys or an IME.
+} GSText;
+
+// Cursor shapes used with gs_cursor_shape.
+// The shapes match the device package cursor shapes.
+#define GS_ArrowCursor     0
+#define GS_HandCursor      1
+#define GS_IBeamCursor     2
+#define GS_CrosshairCursor 3
+#define GS_ResizeXCursor   4
+#define GS_ResizeYCursor   5
+
+// Used to pass back the attached monitors and their display modes.
+// The window modes match the device package window modes.
+#define GS_MaxMonitors 8
+#define GS_MaxModes    128
+#define GS_Windowed    0
+#define GS_Borderless  1
+#define GS_Exclusive   2
+typedef struct {
+    long w;       // width in pixels.
+    long h;       // height in pixels.
+    long refresh; // refresh rate in hertz, 0 if unknown.
+} GSMode;
+typedef struct {
+    char   name[64];           // display name.
+    long   x, y, w, h;         // desktop location relative to the bottom left.
+    long   primary;            // 1 for the main display.
+    long   nmodes;             // number of display modes.
+    GSMode modes[GS_MaxModes]; // supported display modes.
+} GSMonitor;
+
 // Initialize the underlying Cocoa layer and create the default application.
 // Returns a reference to the shared NSApplication instance (display).
 long gs_display_init();
@@ -51,20 +110,77 @@ unsigned char gs_fullscreen(long display);
 // of events with gs_read_dispatch().
 void gs_toggle_fullscreen(long display);
 
+// Get the attached monitors. Returns the number of monitors.
+long gs_monitors(GSMonitor *monitors);
+
+// Switch to one of the GS_Windowed, GS_Borderless, or GS_Exclusive window
+// modes on the given monitor, -1 for the current monitor. The display mode
+// w, h, refresh is only used for GS_Exclusive.
+void gs_set_window_mode(long display, long mode, long monitor, long w, long h, long refresh);
+
 // Process a user event. This must be called inside an event loop in order
 // for the application to work. The event is also processed to determine
 // window events.
 void gs_read_dispatch(long display, GSEvent *gs_urge);
 
+// Get the state of the attached game controllers. Fills GS_MaxPads
+// controllers. Controllers keep their slot until they are detached.
+void gs_pads(GSPad *pads);
+
+// Get the text typed since the last call. Text is collected from the
+// key down events using the cocoa text input system.
+void gs_text(GSText *text);
+
+// Report GS_WindowClosing events instead of closing the window
+// when the user asks to close the window.
+void gs_intercept_close(unsigned char intercept);
+
+// Get the paths of the files dropped on the window since the last call.
+// Paths are separated by newlines. Returns NULL if no files were dropped,
+// otherwise the returned string must be freed by the caller.
+char* gs_dropped();
+
 // Get the current main window drawing area size.
 void gs_size(long shell, float *x, float*y, float *w, float *h);
 
+// Get the main window drawing area size in backing store pixels.
+// This is larger than the window size on Retina displays.
+void gs_pixel_size(long shell, float *w, float *h);
+
+// Get the main window content scale, the number of backing store
+// pixels per window unit.
+float gs_scale(long shell);
+
 // Show or hide cursor. Lock it if it is hidden.
 void gs_show_cursor(unsigned char show);
 
+// Use one of the system cursor shapes while the cursor is over the window.
+void gs_cursor_shape(long shell, long shape);
+
+// Use the w by h pixel RGBA image as the cursor while it is over the window.
+// The hot spot is the image pixel, from the top left, that is the cursor location.
+void gs_cursor_image(long shell, unsigned char *rgba, long w, long h, long hotx, long hoty);
+
+// Change the window title after the window has been created.
+void gs_set_title(long shell, char *title);
+
+// Use the w by h pixel RGBA image as the dock icon.
+void gs_set_icon(unsigned char *rgba, long w, long h);
+
+// Bounce the dock icon to get the user's attention.
+void gs_attention();
+
+// Show the progress, from 0 to 1, on the dock icon.
+// Negative progress restores the plain dock icon.
+void gs_progress(double progress);
+
 // Set the cursor location to the given screen coordinates.
 void gs_set_cursor_location(long display, long x, long y);
 
+// Lock or unlock the cursor. A locked cursor is hidden and does not move.
+// Mouse movement is reported in the GSEvent dx, dy fields instead.
+void gs_lock_cursor(long display, unsigned char lock);
+
 // Create an OpenGL context using the given shell. Subsequent calls will
 // return the current context (ignoring the input parameter).
 //
@@ -123,7 +239,8 @@ enum {
     GS_WindowIconified       = 52,
     GS_WindowUniconified     = 53,
     GS_WindowActive          = 54,
-    GS_WindowInactive        = 55
+    GS_WindowInactive        = 55,
+    GS_WindowClosing         = 56
 };
 
 // Wrap the underlying key modifier definitions.
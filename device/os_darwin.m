@@ -1,6 +1,8 @@
 // Copyright © 2013-2016 Galvanized Logic Inc.
 // Use is governed by a BSD-style license found in the LICENSE file.
 
+// +build !ios
+
 // The OSX (darwin) native layer implementation.
 // This wraps the OSX API's (where the real work is done).
 // Also see:
@@ -10,6 +12,7 @@
 //    https://lists.apple.com/archives/Mac-opengl/2010/Mar/msg00078.html
 
 #import <Cocoa/Cocoa.h>
+#import <IOKit/hid/IOHIDLib.h>
 #import "os_darwin.h"
 
 // Application defaults. Internal use only.
@@ -26,6 +29,10 @@ typedef struct {
 } GSDefaults;
 GSDefaults defaults = { 100, 100, 640, 480, 8, 24, @"App" };
 
+// Set to 1 while the cursor is locked. The cursor stays disassociated
+// from the mouse while the window has focus.
+static unsigned char gs_cursor_locked = 0;
+
 // Get current mouse position independent of the event.
 void gs_pos(long display, float *x, float *y) {
     NSWindow *window = [(id)display mainWindow];
@@ -41,7 +48,23 @@ void gs_set_cursor_location(long display, long x, long y) {
     CGRect screenRect = CGDisplayBounds(CGMainDisplayID()); // origin at top left.
     CGPoint point = CGPointMake(windowRect.origin.x+x, screenRect.size.height-windowRect.origin.y-y);
     CGWarpMouseCursorPosition(point);
-    CGAssociateMouseAndMouseCursorPosition(true);
+    CGAssociateMouseAndMouseCursorPosition(!gs_cursor_locked);
+}
+
+// Lock or unlock the cursor. Locking hides the cursor and disassociates
+// it from the mouse so that the mouse movement is unbounded. Movement
+// is then reported using the mouse event deltas.
+void gs_lock_cursor(long display, unsigned char lock) {
+    if (lock == gs_cursor_locked) {
+        return;
+    }
+    gs_cursor_locked = lock;
+    if (lock) {
+        [NSCursor hide];
+    } else {
+        [NSCursor unhide];
+    }
+    CGAssociateMouseAndMouseCursorPosition(!lock);
 }
 
 // Get the current scroll wheel value. This will be 0 if the last event was
@@ -67,6 +90,117 @@ void gs_show_cursor(unsigned char show) {
     }
 }
 
+// Replace the window cursor. The cursor rectangles are reset so that
+// the new cursor is used as soon as the cursor is over the window.
+static void gs_set_cursor(long shell, NSCursor *cursor) {
+    [cursor retain];
+    [gs_cursor release];
+    gs_cursor = cursor;
+    [(id)shell invalidateCursorRectsForView:[(id)shell contentView]];
+    [gs_cursor set];
+}
+
+// Use one of the system cursors.
+void gs_cursor_shape(long shell, long shape) {
+    NSCursor *cursor = [NSCursor arrowCursor];
+    switch (shape) {
+    case GS_HandCursor:      cursor = [NSCursor pointingHandCursor]; break;
+    case GS_IBeamCursor:     cursor = [NSCursor IBeamCursor]; break;
+    case GS_CrosshairCursor: cursor = [NSCursor crosshairCursor]; break;
+    case GS_ResizeXCursor:   cursor = [NSCursor resizeLeftRightCursor]; break;
+    case GS_ResizeYCursor:   cursor = [NSCursor resizeUpDownCursor]; break;
+    }
+    gs_set_cursor(shell, cursor);
+}
+
+// Create an autoreleased image from the RGBA pixels. The pixels are copied.
+static NSImage *gs_image(unsigned char *rgba, long w, long h) {
+    NSBitmapImageRep *rep = [[[NSBitmapImageRep alloc]
+        initWithBitmapDataPlanes:NULL
+                      pixelsWide:w
+                      pixelsHigh:h
+                   bitsPerSample:8
+                 samplesPerPixel:4
+                        hasAlpha:YES
+                        isPlanar:NO
+                  colorSpaceName:NSDeviceRGBColorSpace
+                    bitmapFormat:NSAlphaNonpremultipliedBitmapFormat
+                     bytesPerRow:w * 4
+                    bitsPerPixel:32] autorelease];
+    memcpy([rep bitmapData], rgba, w * h * 4);
+    NSImage *image = [[[NSImage alloc] initWithSize:NSMakeSize(w, h)] autorelease];
+    [image addRepresentation:rep];
+    return image;
+}
+
+// Create a cursor from the RGBA image.
+void gs_cursor_image(long shell, unsigned char *rgba, long w, long h, long hotx, long hoty) {
+    NSAutoreleasePool *pool = [[NSAutoreleasePool alloc] init];
+    NSImage *image = gs_image(rgba, w, h);
+    NSCursor *cursor = [[[NSCursor alloc] initWithImage:image hotSpot:NSMakePoint(hotx, hoty)] autorelease];
+    gs_set_cursor(shell, cursor);
+    [pool drain];
+}
+
+// Change the window title.
+void gs_set_title(long shell, char *title) {
+    NSWindow *window = (NSWindow *)shell;
+    [window setTitle:[NSString stringWithUTF8String:title]];
+}
+
+// The dock tile shows the progress over the application icon
+// using a custom dock tile view. Nil while there is no progress.
+static NSImageView *gs_dock_view = nil;
+static NSProgressIndicator *gs_dock_bar = nil;
+
+// Use the RGBA image as the dock icon.
+void gs_set_icon(unsigned char *rgba, long w, long h) {
+    NSAutoreleasePool *pool = [[NSAutoreleasePool alloc] init];
+    NSImage *image = gs_image(rgba, w, h);
+    [NSApp setApplicationIconImage:image];
+    if (gs_dock_view != nil) {
+        [gs_dock_view setImage:image];
+        [[NSApp dockTile] display];
+    }
+    [pool drain];
+}
+
+// Bounce the dock icon once if the application is not active.
+void gs_attention() {
+    [NSApp requestUserAttention:NSInformationalRequest];
+}
+
+// Show the progress as a bar across the bottom of the dock icon.
+// Negative progress restores the plain dock icon.
+void gs_progress(double progress) {
+    NSDockTile *tile = [NSApp dockTile];
+    if (progress < 0) {
+        if (gs_dock_view != nil) {
+            [tile setContentView:nil];
+            [gs_dock_view release];
+            [gs_dock_bar release];
+            gs_dock_view = nil;
+            gs_dock_bar = nil;
+            [tile display];
+        }
+        return;
+    }
+    if (gs_dock_view == nil) {
+        NSSize size = [tile size];
+        gs_dock_view = [[NSImageView alloc] initWithFrame:NSMakeRect(0, 0, size.width, size.height)];
+        [gs_dock_view setImage:[NSApp applicationIconImage]];
+        gs_dock_bar = [[NSProgressIndicator alloc] initWithFrame:NSMakeRect(0, 0, size.width, size.height / 8)];
+        [gs_dock_bar setStyle:NSProgressIndicatorBarStyle];
+        [gs_dock_bar setIndeterminate:NO];
+        [gs_dock_bar setMinValue:0];
+        [gs_dock_bar setMaxValue:1];
+        [gs_dock_view addSubview:gs_dock_bar];
+        [tile setContentView:gs_dock_view];
+    }
+    [gs_dock_bar setDoubleValue:progress > 1 ? 1 : progress];
+    [tile display];
+}
+
 // Called before running the application to create a few menu items.
 //
 // This uses a hidden API (setAppleMenu) so that the application menu can
@@ -90,7 +224,7 @@ static void createMenus(NSApplication *display)
     [display performSelector:@selector(setAppleMenu:) withObject: m];
     [menuBar setSubmenu:m forItem:mi];
     [m addItemWithTitle:[NSString stringWithFormat:@"Quit %@", appName]
-                 action:@selector(orderOut:)
+                 action:@selector(quit:)
           keyEquivalent:@"q"];
 
     // the view menu
@@ -107,31 +241,115 @@ static void createMenus(NSApplication *display)
 // it will be processed during the next read and dispatch.
 long winEvent = 0;
 
+// Set when close requests are reported as GS_WindowClosing events.
+static unsigned char gs_close_intercepted = 0;
+
 // Global state used to track window closure. This is needed to avoid accessing the
 // external shell pointer after a window has closes. There is no sure way to
 // check if an object pointer is valid once that object has been released.
 long gs_win_alive = -1;
 
+// Text typed since the last gs_text call and the text currently being
+// composed. Filled by the EventDelegate as the text input system
+// interprets key down events.
+static NSMutableString *gs_typed = nil;
+static NSMutableString *gs_composing = nil;
+
+// Paths of the files dropped on the window since the last gs_dropped call.
+static NSMutableString *gs_drops = nil;
+
+// The cursor used while the cursor is over the window. Nil for the arrow.
+static NSCursor *gs_cursor = nil;
+
 // Used to get window notifications since it is far easier to let the window code
 // figure out what particular mouse clicks and drags mean.
 // These will be triggered as the underlying window processes the mouse moves and
 // clicks sent during the gs_read_dispatch calls.
-@interface EventDelegate : NSView <NSWindowDelegate> { }
+// Key down events are passed to the text input system which handles
+// dead keys and input methods (IME) using the NSTextInputClient protocol.
+@interface EventDelegate : NSView <NSWindowDelegate, NSTextInputClient> { }
 @end
 @implementation EventDelegate
 -(void)windowWillClose:(NSNotification *)notification { gs_win_alive = -2; }
+-(BOOL)windowShouldClose:(id)sender {
+    if (gs_close_intercepted) {
+        winEvent = GS_WindowClosing;
+        return NO;
+    }
+    return YES;
+}
+
+// The quit menu item closes the window unless close requests are intercepted.
+-(void)quit:(id)sender {
+    if ([self windowShouldClose:sender]) {
+        [[self window] orderOut:sender];
+    }
+}
 -(void)windowDidResize:(NSNotification *)notification { winEvent = GS_WindowResized; }
+-(void)windowDidChangeBackingProperties:(NSNotification *)notification { winEvent = GS_WindowResized; }
 -(void)windowDidMove:(NSNotification *)notification { winEvent = GS_WindowMoved; }
 -(void)windowDidMiniaturize:(NSNotification *)notification { winEvent = GS_WindowIconified; }
 -(void)windowDidDeminiaturize:(NSNotification *)notification { winEvent = GS_WindowUniconified; }
--(void)windowDidBecomeKey:(NSNotification *)notification { winEvent = GS_WindowActive; }
--(void)windowDidResignKey:(NSNotification *)notification { winEvent = GS_WindowInactive; }
+-(void)windowDidBecomeKey:(NSNotification *)notification {
+    winEvent = GS_WindowActive;
+    CGAssociateMouseAndMouseCursorPosition(!gs_cursor_locked);
+}
+-(void)windowDidResignKey:(NSNotification *)notification {
+    winEvent = GS_WindowInactive;
+    CGAssociateMouseAndMouseCursorPosition(true); // free the mouse for other apps.
+}
 
 // let OS know that this app handles keys in order to prevent beeping.
 -(BOOL)canBecomeKeyView { return YES; }
 -(BOOL)acceptsFirstResponder { return YES; }
--(void)keyUp:(NSNotification *)notification {  }
--(void)keyDown:(NSNotification *)notification { }
+-(void)keyUp:(NSEvent *)event {  }
+-(void)keyDown:(NSEvent *)event { [self interpretKeyEvents:[NSArray arrayWithObject:event]]; }
+
+// Implement NSTextInputClient. Only the typed and composing text is tracked
+// since the application owns any text being edited.
+-(void)insertText:(id)text replacementRange:(NSRange)range {
+    NSString *str = [text isKindOfClass:[NSAttributedString class]] ? [text string] : text;
+    [gs_typed appendString:str];
+    [gs_composing setString:@""];
+}
+-(void)setMarkedText:(id)text selectedRange:(NSRange)selected replacementRange:(NSRange)range {
+    NSString *str = [text isKindOfClass:[NSAttributedString class]] ? [text string] : text;
+    [gs_composing setString:str];
+}
+-(void)unmarkText { [gs_composing setString:@""]; }
+-(BOOL)hasMarkedText { return [gs_composing length] > 0; }
+-(NSRange)markedRange {
+    if ([gs_composing length] > 0) {
+        return NSMakeRange(0, [gs_composing length]);
+    }
+    return NSMakeRange(NSNotFound, 0);
+}
+-(NSRange)selectedRange { return NSMakeRange(NSNotFound, 0); }
+-(NSArray *)validAttributesForMarkedText { return [NSArray array]; }
+-(NSAttributedString *)attributedSubstringForProposedRange:(NSRange)range actualRange:(NSRangePointer)actual { return nil; }
+-(NSUInteger)characterIndexForPoint:(NSPoint)point { return NSNotFound; }
+-(void)doCommandBySelector:(SEL)selector { } // commands like delete are reported as keys.
+
+// Place the input method candidate window at the bottom left of the window.
+-(NSRect)firstRectForCharacterRange:(NSRange)range actualRange:(NSRangePointer)actual {
+    NSRect frame = [[self window] convertRectToScreen:[self frame]];
+    return NSMakeRect(frame.origin.x, frame.origin.y, 0, 0);
+}
+
+// Use the current cursor over the whole window.
+-(void)resetCursorRects {
+    [self addCursorRect:[self bounds] cursor:(gs_cursor ? gs_cursor : [NSCursor arrowCursor])];
+}
+
+// Accept files dragged onto the window. Only the file paths are kept.
+-(NSDragOperation)draggingEntered:(id<NSDraggingInfo>)sender { return NSDragOperationCopy; }
+-(BOOL)performDragOperation:(id<NSDraggingInfo>)sender {
+    NSArray *files = [[sender draggingPasteboard] propertyListForType:NSFilenamesPboardType];
+    for (NSString *path in files) {
+        [gs_drops appendFormat:@"%@\n", path];
+    }
+    return [files count] > 0;
+}
 @end
 
 // Create the top level application (display).
@@ -152,11 +370,19 @@ void gs_display_dispose(long display) {
     [(id)display stop: nil];
 }
 
+// GSWindow allows a borderless window to have focus and be the main window.
+@interface GSWindow : NSWindow { }
+@end
+@implementation GSWindow
+-(BOOL)canBecomeKeyWindow { return YES; }
+-(BOOL)canBecomeMainWindow { return YES; }
+@end
+
 // Create the window.
 long createShell(long display) {
     NSRect frame = NSMakeRect( defaults.gs_ShellX, defaults.gs_ShellY, defaults.gs_ShellWidth, defaults.gs_ShellHeight );
     unsigned int styleMask = NSWindowStyleMaskTitled| NSWindowStyleMaskClosable |NSWindowStyleMaskMiniaturizable | NSWindowStyleMaskResizable;
-    NSWindow *window = [[NSWindow alloc]
+    NSWindow *window = [[GSWindow alloc]
         initWithContentRect:frame
                   styleMask:styleMask
                     backing:NSBackingStoreBuffered
@@ -166,7 +392,13 @@ long createShell(long display) {
     // Hook in the delegate.
     EventDelegate *delegate = [[[EventDelegate alloc] initWithFrame:frame] autorelease];
     [window setContentView:delegate];
+    [delegate setWantsBestResolutionOpenGLSurface:YES]; // Retina resolution.
     [window setDelegate:delegate];
+    [window makeFirstResponder:delegate];
+    gs_typed = [[NSMutableString alloc] init];
+    gs_composing = [[NSMutableString alloc] init];
+    gs_drops = [[NSMutableString alloc] init];
+    [delegate registerForDraggedTypes:[NSArray arrayWithObject:NSFilenamesPboardType]];
     [window makeKeyWindow];
     [window orderBack:nil];
     [window setCollectionBehavior:[window collectionBehavior] | NSWindowCollectionBehaviorFullScreenPrimary];
@@ -209,6 +441,8 @@ void gs_shell_open(long display) {
 // like a 3D app update loop, as each call only processes a single event.
 //
 // MouseMoved events are ignored since the mouse position is returned each time.
+// Mouse movement is accumulated from the move and drag events when the cursor
+// is locked.
 void gs_read_dispatch(long display, GSEvent *urge) {
     NSAutoreleasePool *pool = [[NSAutoreleasePool alloc] init];
 
@@ -234,6 +468,19 @@ void gs_read_dispatch(long display, GSEvent *urge) {
                         untilDate:nil
                            inMode:NSDefaultRunLoopMode
                           dequeue:YES];
+        if (nil != event && gs_cursor_locked) {
+            switch ([event type]) {
+            case NSEventTypeMouseMoved:
+            case NSEventTypeLeftMouseDragged:
+            case NSEventTypeRightMouseDragged:
+            case NSEventTypeOtherMouseDragged:
+                urge->dx += (long)[event deltaX];
+                urge->dy -= (long)[event deltaY]; // deltaY is positive down.
+                break;
+            default:
+                break;
+            }
+        }
         if (nil != event && GS_MouseMoved != [event type]) {
             urge->event = (long) [event type];
             [(id)display sendEvent:event]; // could create a new winEvent.
@@ -260,6 +507,35 @@ void gs_read_dispatch(long display, GSEvent *urge) {
     [pool release];
 }
 
+// Get the text typed since the last call. Text that does not fit is dropped
+// since getCString fails if the buffer is too small.
+void gs_text(GSText *text) {
+    text->typed[0] = 0;
+    text->composing[0] = 0;
+    if (![gs_typed getCString:text->typed maxLength:GS_TextSize encoding:NSUTF8StringEncoding]) {
+        text->typed[0] = 0;
+    }
+    if (![gs_composing getCString:text->composing maxLength:GS_TextSize encoding:NSUTF8StringEncoding]) {
+        text->composing[0] = 0;
+    }
+    [gs_typed setString:@""];
+}
+
+// Report close requests as events instead of closing the window.
+void gs_intercept_close(unsigned char intercept) {
+    gs_close_intercepted = intercept;
+}
+
+// Get the paths of the files dropped since the last call.
+char* gs_dropped() {
+    if ([gs_drops length] == 0) {
+        return NULL;
+    }
+    char *paths = strdup([gs_drops UTF8String]); // must be freed by caller.
+    [gs_drops setString:@""];
+    return paths;
+}
+
 // The window is hidden in the windowWillClose event and the main loop is expected
 // to call this method to check if the shell should be terminated. This allows the
 // application a chance to do any final cleanup before everything stops.
@@ -272,17 +548,162 @@ unsigned char gs_shell_alive(long shell) {
     return ((gs_win_alive == 1) && ([win isMiniaturized] == YES || [win isVisible] == YES));
 }
 
+// Window mode state used to restore windowed mode.
+static long gs_window_mode = GS_Windowed;
+static NSUInteger gs_window_style = 0;
+static NSRect gs_window_frame;
+static CGDirectDisplayID gs_display_id = 0;
+static CGDisplayModeRef gs_display_mode = NULL; // original exclusive display mode.
+
 // Return 1 if the application is full screen, 0 otherwise.
 // This needs to return the correct result right after a call
 // to gs_toggle_fullscreen.
 unsigned char gs_fullscreen(long display) {
     NSWindow *window = [(id)display mainWindow];
-    return (([window styleMask] & NSWindowStyleMaskFullScreen) == NSWindowStyleMaskFullScreen);
+    return gs_window_mode != GS_Windowed ||
+        (([window styleMask] & NSWindowStyleMaskFullScreen) == NSWindowStyleMaskFullScreen);
+}
+
+// Get the display identifier for a screen.
+static CGDirectDisplayID gs_screen_display(NSScreen *screen) {
+    return [[[screen deviceDescription] objectForKey:@"NSScreenNumber"] unsignedIntValue];
+}
+
+// Get the attached screens and their display modes. The first screen
+// is the main screen. Duplicate display modes are skipped.
+long gs_monitors(GSMonitor *monitors) {
+    NSAutoreleasePool *pool = [[NSAutoreleasePool alloc] init];
+    NSArray *screens = [NSScreen screens];
+    long count = 0;
+    for (NSScreen *screen in screens) {
+        if (count >= GS_MaxMonitors) {
+            break;
+        }
+        GSMonitor *m = &monitors[count];
+        NSRect frame = [screen frame]; // origin at bottom left of the main screen.
+        m->x = frame.origin.x;
+        m->y = frame.origin.y;
+        m->w = frame.size.width;
+        m->h = frame.size.height;
+        m->primary = count == 0;
+        snprintf(m->name, sizeof(m->name), "Display %ld", count+1);
+        if ([screen respondsToSelector:@selector(localizedName)]) {
+            strncpy(m->name, [[screen localizedName] UTF8String], sizeof(m->name)-1);
+            m->name[sizeof(m->name)-1] = 0;
+        }
+        m->nmodes = 0;
+        CFArrayRef modes = CGDisplayCopyAllDisplayModes(gs_screen_display(screen), NULL);
+        for (CFIndex cnt = 0; modes != NULL && cnt < CFArrayGetCount(modes) && m->nmodes < GS_MaxModes; cnt++) {
+            CGDisplayModeRef mode = (CGDisplayModeRef)CFArrayGetValueAtIndex(modes, cnt);
+            GSMode gm = {CGDisplayModeGetWidth(mode), CGDisplayModeGetHeight(mode), (long)CGDisplayModeGetRefreshRate(mode)};
+            int dup = 0;
+            for (long d = 0; d < m->nmodes; d++) {
+                GSMode *dm = &m->modes[d];
+                dup = dup || (dm->w == gm.w && dm->h == gm.h && dm->refresh == gm.refresh);
+            }
+            if (!dup) {
+                m->modes[m->nmodes++] = gm;
+            }
+        }
+        if (modes != NULL) {
+            CFRelease(modes);
+        }
+        count++;
+    }
+    [pool drain];
+    return count;
+}
+
+// Set the display mode of a screen that best matches the requested size
+// and refresh rate. Return 1 if the display mode was changed.
+static int gs_set_display_mode(NSScreen *screen, long w, long h, long refresh) {
+    CGDirectDisplayID did = gs_screen_display(screen);
+    CFArrayRef modes = CGDisplayCopyAllDisplayModes(did, NULL);
+    CGDisplayModeRef match = NULL;
+    for (CFIndex cnt = 0; modes != NULL && cnt < CFArrayGetCount(modes); cnt++) {
+        CGDisplayModeRef mode = (CGDisplayModeRef)CFArrayGetValueAtIndex(modes, cnt);
+        if (CGDisplayModeGetWidth(mode) == w && CGDisplayModeGetHeight(mode) == h &&
+            (refresh <= 0 || (long)CGDisplayModeGetRefreshRate(mode) == refresh)) {
+            match = mode;
+            break;
+        }
+    }
+    int changed = 0;
+    if (match != NULL) {
+        CGDisplayModeRef original = CGDisplayCopyDisplayMode(did);
+        if (CGDisplaySetDisplayMode(did, match, NULL) == kCGErrorSuccess) {
+            gs_display_id = did;
+            gs_display_mode = original;
+            changed = 1;
+        } else {
+            CGDisplayModeRelease(original);
+        }
+    }
+    if (modes != NULL) {
+        CFRelease(modes);
+    }
+    return changed;
+}
+
+// Switch between windowed, borderless, and exclusive window modes.
+// Borderless and exclusive windows cover the screen above the menu bar
+// and dock. Exclusive mode falls back to borderless if the display mode
+// can't be set. Windowed mode restores the previous window frame, centered
+// on the requested screen if there is one.
+void gs_set_window_mode(long display, long mode, long monitor, long w, long h, long refresh) {
+    NSWindow *window = [(id)display mainWindow];
+    if (([window styleMask] & NSWindowStyleMaskFullScreen) == NSWindowStyleMaskFullScreen) {
+        [window toggleFullScreen:nil]; // leave the full screen space.
+    }
+    if (gs_window_mode == GS_Windowed && mode != GS_Windowed) {
+        gs_window_style = [window styleMask];
+        gs_window_frame = [window frame];
+    }
+    if (gs_display_mode != NULL) {
+        CGDisplaySetDisplayMode(gs_display_id, gs_display_mode, NULL);
+        CGDisplayModeRelease(gs_display_mode);
+        gs_display_mode = NULL;
+    }
+    NSArray *screens = [NSScreen screens];
+    NSScreen *screen = [window screen];
+    if (monitor >= 0 && monitor < (long)[screens count]) {
+        screen = [screens objectAtIndex:monitor];
+    }
+    if (mode == GS_Exclusive && !gs_set_display_mode(screen, w, h, refresh)) {
+        mode = GS_Borderless;
+    }
+    if (mode == GS_Windowed) {
+        [(id)display setPresentationOptions:NSApplicationPresentationDefault];
+        [window setLevel:NSNormalWindowLevel];
+        if (gs_window_mode != GS_Windowed) {
+            [window setStyleMask:gs_window_style];
+        }
+        NSRect frame = gs_window_mode != GS_Windowed ? gs_window_frame : [window frame];
+        if (monitor >= 0) {
+            NSRect visible = [screen visibleFrame];
+            frame.origin.x = visible.origin.x + (visible.size.width - frame.size.width)*0.5;
+            frame.origin.y = visible.origin.y + (visible.size.height - frame.size.height)*0.5;
+        }
+        [window setFrame:frame display:YES];
+    } else {
+        [window setStyleMask:NSWindowStyleMaskBorderless];
+        [window setLevel:NSMainMenuWindowLevel+1];
+        [(id)display setPresentationOptions:NSApplicationPresentationHideDock | NSApplicationPresentationHideMenuBar];
+        [window setFrame:[screen frame] display:YES];
+        [window makeKeyAndOrderFront:nil];
+    }
+    gs_window_mode = mode;
+    winEvent = GS_WindowResized; // update the context and application.
 }
 
 // Flip full screen mode. Must be called after starting processing
-// of events with gs_read_dispatch().
+// of events with gs_read_dispatch(). Borderless and exclusive windows
+// go back to windowed mode.
 void gs_toggle_fullscreen(long display) {
+    if (gs_window_mode != GS_Windowed) {
+        gs_set_window_mode(display, GS_Windowed, -1, 0, 0, 0);
+        return;
+    }
     NSWindow *window = [(id)display mainWindow];
     [window toggleFullScreen:nil];
 }
@@ -342,6 +763,19 @@ void gs_size(long shell, float *x, float *y, float *w, float *h) {
     *h = content.size.height;
 }
 
+// Get current shell size in backing store pixels.
+void gs_pixel_size(long shell, float *w, float *h) {
+    NSView *view = [(id)shell contentView];
+    NSRect pixels = [view convertRectToBacking:[view bounds]];
+    *w = pixels.size.width;
+    *h = pixels.size.height;
+}
+
+// Get current shell content scale.
+float gs_scale(long shell) {
+    return [(id)shell backingScaleFactor];
+}
+
 // Update startup numeric defaults. Minimal effort is made to ensure a valid value.
 void gs_set_attr_l(long attr, long value) {
     switch (attr) {
@@ -398,3 +832,162 @@ void gs_clip_paste(const char* string) {
     [pb declareTypes:types owner:nil];
     [pb setString:[NSString stringWithUTF8String:string] forType:NSStringPboardType];
 }
+
+// Game controllers are found by the HID manager. Attached controllers keep
+// their slot until they are detached. The HID manager callbacks happen while
+// gs_read_dispatch processes the main run loop.
+static IOHIDManagerRef gs_hid = NULL;
+static IOHIDDeviceRef gs_pad_devices[GS_MaxPads];
+
+// Put a newly attached controller in the first free slot.
+static void gs_pad_added(void *context, IOReturn result, void *sender, IOHIDDeviceRef device) {
+    for (int cnt = 0; cnt < GS_MaxPads; cnt++) {
+        if (gs_pad_devices[cnt] == device) {
+            return;
+        }
+    }
+    for (int cnt = 0; cnt < GS_MaxPads; cnt++) {
+        if (gs_pad_devices[cnt] == NULL) {
+            gs_pad_devices[cnt] = (IOHIDDeviceRef)CFRetain(device);
+            return;
+        }
+    }
+}
+
+// Free the slot of a detached controller.
+static void gs_pad_removed(void *context, IOReturn result, void *sender, IOHIDDeviceRef device) {
+    for (int cnt = 0; cnt < GS_MaxPads; cnt++) {
+        if (gs_pad_devices[cnt] == device) {
+            CFRelease(device);
+            gs_pad_devices[cnt] = NULL;
+        }
+    }
+}
+
+// Start watching for joysticks, gamepads, and multi-axis controllers.
+static void gs_pads_init() {
+    gs_hid = IOHIDManagerCreate(kCFAllocatorDefault, kIOHIDOptionsTypeNone);
+    int page = kHIDPage_GenericDesktop;
+    int usages[] = {kHIDUsage_GD_Joystick, kHIDUsage_GD_GamePad, kHIDUsage_GD_MultiAxisController};
+    CFMutableArrayRef matches = CFArrayCreateMutable(kCFAllocatorDefault, 0, &kCFTypeArrayCallBacks);
+    for (int cnt = 0; cnt < 3; cnt++) {
+        CFNumberRef pageRef = CFNumberCreate(kCFAllocatorDefault, kCFNumberIntType, &page);
+        CFNumberRef usageRef = CFNumberCreate(kCFAllocatorDefault, kCFNumberIntType, &usages[cnt]);
+        const void *keys[] = {CFSTR(kIOHIDDeviceUsagePageKey), CFSTR(kIOHIDDeviceUsageKey)};
+        const void *vals[] = {pageRef, usageRef};
+        CFDictionaryRef match = CFDictionaryCreate(kCFAllocatorDefault, keys, vals, 2,
+            &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+        CFArrayAppendValue(matches, match);
+        CFRelease(match);
+        CFRelease(usageRef);
+        CFRelease(pageRef);
+    }
+    IOHIDManagerSetDeviceMatchingMultiple(gs_hid, matches);
+    CFRelease(matches);
+    IOHIDManagerRegisterDeviceMatchingCallback(gs_hid, gs_pad_added, NULL);
+    IOHIDManagerRegisterDeviceRemovalCallback(gs_hid, gs_pad_removed, NULL);
+    IOHIDManagerScheduleWithRunLoop(gs_hid, CFRunLoopGetMain(), kCFRunLoopDefaultMode);
+    IOHIDManagerOpen(gs_hid, kIOHIDOptionsTypeNone);
+}
+
+// Get a numeric controller property.
+static long gs_pad_property(IOHIDDeviceRef device, CFStringRef key) {
+    long value = 0;
+    CFTypeRef ref = IOHIDDeviceGetProperty(device, key);
+    if (ref != NULL && CFGetTypeID(ref) == CFNumberGetTypeID()) {
+        CFNumberGetValue((CFNumberRef)ref, kCFNumberLongType, &value);
+    }
+    return value;
+}
+
+// Read the current state of one controller. Buttons are ordered by their
+// button number and axes are ordered by usage to match the order used by
+// SDL gamecontrollerdb mappings.
+static void gs_pad_read(IOHIDDeviceRef device, GSPad *pad) {
+    static const long hatmasks[] = {1, 3, 2, 6, 4, 12, 8, 9}; // clockwise from up.
+    long usages[GS_PadAxes];
+    pad->connected = 1;
+    pad->vendor = gs_pad_property(device, CFSTR(kIOHIDVendorIDKey));
+    pad->product = gs_pad_property(device, CFSTR(kIOHIDProductIDKey));
+    pad->version = gs_pad_property(device, CFSTR(kIOHIDVersionNumberKey));
+    CFTypeRef name = IOHIDDeviceGetProperty(device, CFSTR(kIOHIDProductKey));
+    if (name != NULL && CFGetTypeID(name) == CFStringGetTypeID()) {
+        CFStringGetCString((CFStringRef)name, pad->name, sizeof(pad->name), kCFStringEncodingUTF8);
+    }
+    CFArrayRef elements = IOHIDDeviceCopyMatchingElements(device, NULL, kIOHIDOptionsTypeNone);
+    if (elements == NULL) {
+        return;
+    }
+    for (CFIndex cnt = 0; cnt < CFArrayGetCount(elements); cnt++) {
+        IOHIDElementRef element = (IOHIDElementRef)CFArrayGetValueAtIndex(elements, cnt);
+        IOHIDElementType type = IOHIDElementGetType(element);
+        if (type != kIOHIDElementTypeInput_Misc && type != kIOHIDElementTypeInput_Button &&
+            type != kIOHIDElementTypeInput_Axis) {
+            continue;
+        }
+        IOHIDValueRef valueRef;
+        if (IOHIDDeviceGetValue(device, element, &valueRef) != kIOReturnSuccess) {
+            continue;
+        }
+        long value = IOHIDValueGetIntegerValue(valueRef);
+        long min = IOHIDElementGetLogicalMin(element);
+        long max = IOHIDElementGetLogicalMax(element);
+        uint32_t page = IOHIDElementGetUsagePage(element);
+        uint32_t usage = IOHIDElementGetUsage(element);
+        if (page == kHIDPage_Button && usage >= 1 && usage <= GS_PadButtons) {
+            pad->buttons[usage-1] = value != 0;
+            if (usage > pad->nbuttons) {
+                pad->nbuttons = usage;
+            }
+            continue;
+        }
+        if (page != kHIDPage_GenericDesktop) {
+            continue;
+        }
+        switch (usage) {
+        case kHIDUsage_GD_X:
+        case kHIDUsage_GD_Y:
+        case kHIDUsage_GD_Z:
+        case kHIDUsage_GD_Rx:
+        case kHIDUsage_GD_Ry:
+        case kHIDUsage_GD_Rz:
+        case kHIDUsage_GD_Slider:
+        case kHIDUsage_GD_Dial:
+        case kHIDUsage_GD_Wheel:
+            if (pad->naxes < GS_PadAxes && max > min) {
+                float axis = 2.0f * (value - min) / (max - min) - 1.0f;
+                long at = pad->naxes++;
+                for (; at > 0 && usages[at-1] > usage; at--) { // insert by usage.
+                    usages[at] = usages[at-1];
+                    pad->axes[at] = pad->axes[at-1];
+                }
+                usages[at] = usage;
+                pad->axes[at] = axis;
+            }
+            break;
+        case kHIDUsage_GD_Hatswitch:
+            if (pad->nhats < GS_PadHats) {
+                long position = value - min;
+                if (max - min == 3) {
+                    position *= 2; // 4 position hat.
+                }
+                pad->hats[pad->nhats++] = (position >= 0 && position < 8) ? hatmasks[position] : 0;
+            }
+            break;
+        }
+    }
+    CFRelease(elements);
+}
+
+// Get the state of the attached game controllers.
+void gs_pads(GSPad *pads) {
+    if (gs_hid == NULL) {
+        gs_pads_init();
+    }
+    for (int cnt = 0; cnt < GS_MaxPads; cnt++) {
+        memset(&pads[cnt], 0, sizeof(GSPad));
+        if (gs_pad_devices[cnt] != NULL) {
+            gs_pad_read(gs_pad_devices[cnt], &pads[cnt]);
+        }
+    }
+}
@@ -0,0 +1,115 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+// Expose the underlying iOS key codes as generic code.
+// Each native layer is expected to support the generic codes.
+//
+// Hardware keyboard keys are reported using their USB HID usage codes.
+// They are the location of the key on a US keyboard regardless of the
+// keyboard layout.
+const (
+	key0              = 0x27  // UIKeyboardHIDUsageKeyboard0
+	key1              = 0x1E  // UIKeyboardHIDUsageKeyboard1
+	key2              = 0x1F  // UIKeyboardHIDUsageKeyboard2
+	key3              = 0x20  // UIKeyboardHIDUsageKeyboard3
+	key4              = 0x21  // UIKeyboardHIDUsageKeyboard4
+	key5              = 0x22  // UIKeyboardHIDUsageKeyboard5
+	key6              = 0x23  // UIKeyboardHIDUsageKeyboard6
+	key7              = 0x24  // UIKeyboardHIDUsageKeyboard7
+	key8              = 0x25  // UIKeyboardHIDUsageKeyboard8
+	key9              = 0x26  // UIKeyboardHIDUsageKeyboard9
+	keyA              = 0x04  // UIKeyboardHIDUsageKeyboardA
+	keyB              = 0x05  // UIKeyboardHIDUsageKeyboardB
+	keyC              = 0x06  // UIKeyboardHIDUsageKeyboardC
+	keyD              = 0x07  // UIKeyboardHIDUsageKeyboardD
+	keyE              = 0x08  // UIKeyboardHIDUsageKeyboardE
+	keyF              = 0x09  // UIKeyboardHIDUsageKeyboardF
+	keyG              = 0x0A  // UIKeyboardHIDUsageKeyboardG
+	keyH              = 0x0B  // UIKeyboardHIDUsageKeyboardH
+	keyI              = 0x0C  // UIKeyboardHIDUsageKeyboardI
+	keyJ              = 0x0D  // UIKeyboardHIDUsageKeyboardJ
+	keyK              = 0x0E  // UIKeyboardHIDUsageKeyboardK
+	keyL              = 0x0F  // UIKeyboardHIDUsageKeyboardL
+	keyM              = 0x10  // UIKeyboardHIDUsageKeyboardM
+	keyN              = 0x11  // UIKeyboardHIDUsageKeyboardN
+	keyO              = 0x12  // UIKeyboardHIDUsageKeyboardO
+	keyP              = 0x13  // UIKeyboardHIDUsageKeyboardP
+	keyQ              = 0x14  // UIKeyboardHIDUsageKeyboardQ
+	keyR              = 0x15  // UIKeyboardHIDUsageKeyboardR
+	keyS              = 0x16  // UIKeyboardHIDUsageKeyboardS
+	keyT              = 0x17  // UIKeyboardHIDUsageKeyboardT
+	keyU              = 0x18  // UIKeyboardHIDUsageKeyboardU
+	keyV              = 0x19  // UIKeyboardHIDUsageKeyboardV
+	keyW              = 0x1A  // UIKeyboardHIDUsageKeyboardW
+	keyX              = 0x1B  // UIKeyboardHIDUsageKeyboardX
+	keyY              = 0x1C  // UIKeyboardHIDUsageKeyboardY
+	keyZ              = 0x1D  // UIKeyboardHIDUsageKeyboardZ
+	keyF1             = 0x3A  // UIKeyboardHIDUsageKeyboardF1
+	keyF2             = 0x3B  // UIKeyboardHIDUsageKeyboardF2
+	keyF3             = 0x3C  // UIKeyboardHIDUsageKeyboardF3
+	keyF4             = 0x3D  // UIKeyboardHIDUsageKeyboardF4
+	keyF5             = 0x3E  // UIKeyboardHIDUsageKeyboardF5
+	keyF6             = 0x3F  // UIKeyboardHIDUsageKeyboardF6
+	keyF7             = 0x40  // UIKeyboardHIDUsageKeyboardF7
+	keyF8             = 0x41  // UIKeyboardHIDUsageKeyboardF8
+	keyF9             = 0x42  // UIKeyboardHIDUsageKeyboardF9
+	keyF10            = 0x43  // UIKeyboardHIDUsageKeyboardF10
+	keyF11            = 0x44  // UIKeyboardHIDUsageKeyboardF11
+	keyF12            = 0x45  // UIKeyboardHIDUsageKeyboardF12
+	keyF13            = 0x68  // UIKeyboardHIDUsageKeyboardF13
+	keyF14            = 0x69  // UIKeyboardHIDUsageKeyboardF14
+	keyF15            = 0x6A  // UIKeyboardHIDUsageKeyboardF15
+	keyF16            = 0x6B  // UIKeyboardHIDUsageKeyboardF16
+	keyF17            = 0x6C  // UIKeyboardHIDUsageKeyboardF17
+	keyF18            = 0x6D  // UIKeyboardHIDUsageKeyboardF18
+	keyF19            = 0x6E  // UIKeyboardHIDUsageKeyboardF19
+	keyF20            = 0x6F  // UIKeyboardHIDUsageKeyboardF20
+	keyKeypad0        = 0x62  // UIKeyboardHIDUsageKeypad0
+	keyKeypad1        = 0x59  // UIKeyboardHIDUsageKeypad1
+	keyKeypad2        = 0x5A  // UIKeyboardHIDUsageKeypad2
+	keyKeypad3        = 0x5B  // UIKeyboardHIDUsageKeypad3
+	keyKeypad4        = 0x5C  // UIKeyboardHIDUsageKeypad4
+	keyKeypad5        = 0x5D  // UIKeyboardHIDUsageKeypad5
+	keyKeypad6        = 0x5E  // UIKeyboardHIDUsageKeypad6
+	keyKeypad7        = 0x5F  // UIKeyboardHIDUsageKeypad7
+	keyKeypad8        = 0x60  // UIKeyboardHIDUsageKeypad8
+	keyKeypad9        = 0x61  // UIKeyboardHIDUsageKeypad9
+	keyKeypadDecimal  = 0x63  // UIKeyboardHIDUsageKeypadPeriod
+	keyKeypadMultiply = 0x55  // UIKeyboardHIDUsageKeypadAsterisk
+	keyKeypadPlus     = 0x57  // UIKeyboardHIDUsageKeypadPlus
+	keyKeypadClear    = 0x53  // UIKeyboardHIDUsageKeypadNumLock
+	keyKeypadDivide   = 0x54  // UIKeyboardHIDUsageKeypadSlash
+	keyKeypadEnter    = 0x58  // UIKeyboardHIDUsageKeypadEnter
+	keyKeypadMinus    = 0x56  // UIKeyboardHIDUsageKeypadHyphen
+	keyKeypadEquals   = 0x67  // UIKeyboardHIDUsageKeypadEqualSign
+	keyEqual          = 0x2E  // UIKeyboardHIDUsageKeyboardEqualSign
+	keyMinus          = 0x2D  // UIKeyboardHIDUsageKeyboardHyphen
+	keyLeftBracket    = 0x2F  // UIKeyboardHIDUsageKeyboardOpenBracket
+	keyRightBracket   = 0x30  // UIKeyboardHIDUsageKeyboardCloseBracket
+	keyQuote          = 0x34  // UIKeyboardHIDUsageKeyboardQuote
+	keySemicolon      = 0x33  // UIKeyboardHIDUsageKeyboardSemicolon
+	keyBackslash      = 0x31  // UIKeyboardHIDUsageKeyboardBackslash
+	keyGrave          = 0x35  // UIKeyboardHIDUsageKeyboardGraveAccentAndTilde
+	keySlash          = 0x38  // UIKeyboardHIDUsageKeyboardSlash
+	keyComma          = 0x36  // UIKeyboardHIDUsageKeyboardComma
+	keyPeriod         = 0x37  // UIKeyboardHIDUsageKeyboardPeriod
+	keyReturn         = 0x28  // UIKeyboardHIDUsageKeyboardReturnOrEnter
+	keyTab            = 0x2B  // UIKeyboardHIDUsageKeyboardTab
+	keySpace          = 0x2C  // UIKeyboardHIDUsageKeyboardSpacebar
+	keyDelete         = 0x2A  // UIKeyboardHIDUsageKeyboardDeleteOrBackspace
+	keyForwardDelete  = 0x4C  // UIKeyboardHIDUsageKeyboardDeleteForward
+	keyEscape         = 0x29  // UIKeyboardHIDUsageKeyboardEscape
+	keyHome           = 0x4A  // UIKeyboardHIDUsageKeyboardHome
+	keyPageUp         = 0x4B  // UIKeyboardHIDUsageKeyboardPageUp
+	keyPageDown       = 0x4E  // UIKeyboardHIDUsageKeyboardPageDown
+	keyLeftArrow      = 0x50  // UIKeyboardHIDUsageKeyboardLeftArrow
+	keyRightArrow     = 0x4F  // UIKeyboardHIDUsageKeyboardRightArrow
+	keyDownArrow      = 0x51  // UIKeyboardHIDUsageKeyboardDownArrow
+	keyUpArrow        = 0x52  // UIKeyboardHIDUsageKeyboardUpArrow
+	keyEnd            = 0x4D  // UIKeyboardHIDUsageKeyboardEnd
+	mouseLeft         = 0x1F1 // primary touch (tack on values past keys and gamepad buttons)
+	mouseMiddle       = 0x1F2 // unused
+	mouseRight        = 0x1F3 // unused
+)
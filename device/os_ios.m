@@ -0,0 +1,455 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// The iOS native layer implementation. The application is built with
+// -buildmode=c-archive and linked into an iOS application whose main
+// calls gs_ios_main. UIKit owns the main thread, so the UIKit callbacks
+// are collected on the main thread and handed to the application thread
+// which polls them using gs_read_dispatch. The application thread draws
+// into the view layer using its own OpenGL ES context.
+//
+// Uses manual reference counting, ie: no -fobjc-arc.
+
+#import <UIKit/UIKit.h>
+#import <QuartzCore/QuartzCore.h>
+#import <OpenGLES/EAGL.h>
+#import <OpenGLES/ES3/gl.h>
+#include <pthread.h>
+#include "os_mobile.h"
+
+// Application defaults. Can be overridden by gs_set_attr_l.
+struct AppDefaults {
+    long gs_AlphaSize;
+    long gs_DepthSize;
+};
+struct AppDefaults defaults = { 8, 24 };
+
+// State shared between the main thread and the application thread.
+// Guarded by gs_lock.
+static pthread_mutex_t gs_lock = PTHREAD_MUTEX_INITIALIZER;
+static pthread_cond_t gs_cond = PTHREAD_COND_INITIALIZER;
+static UIView *gs_view = nil;            // the one full screen view.
+static long gs_alive = 0;                // -2 once the application terminates.
+static GSEvent gs_pending[64];           // events for the application thread.
+static int gs_npending = 0;
+static GSTouch gs_touch[GS_MaxTouches];  // touches since the last gs_touches.
+static int gs_ntouch = 0;
+static UITouch *gs_primary = nil;        // touch that acts as the mouse.
+static long gs_mousex = 0, gs_mousey = 0;
+static long gs_mods = 0;
+static char gs_typed[GS_TextSize];       // text since the last gs_text.
+static int gs_ntyped = 0;
+static float gs_width = 0, gs_height = 0; // view size in points.
+static float gs_factor = 1;               // pixels per point.
+static unsigned char gs_resized = 0;      // renderbuffers need resizing.
+static unsigned char gs_background = 0;   // no drawing in the background.
+
+// Application thread state.
+static EAGLContext *gs_ctx = nil;
+static GLuint gs_fbo = 0, gs_color = 0, gs_depth = 0;
+static GLint gs_pw = 0, gs_ph = 0;
+
+// Queue an event for the application thread. Must hold gs_lock.
+static void gs_post(long event, long key) {
+    if (gs_npending < (int)(sizeof(gs_pending) / sizeof(gs_pending[0]))) {
+        GSEvent *e = &gs_pending[gs_npending++];
+        memset(e, 0, sizeof(GSEvent));
+        e->event = event;
+        e->key = key;
+    }
+}
+
+// Post an event from the main thread.
+static void gs_post_locked(long event) {
+    pthread_mutex_lock(&gs_lock);
+    gs_post(event, 0);
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// Update the tracked touch with the given id. Touches that begin
+// when all the touch slots are used are ignored. Must hold gs_lock.
+static void gs_touch_update(long id, long phase, float x, float y) {
+    GSTouch *touch = NULL;
+    int cnt;
+    for (cnt = 0; cnt < gs_ntouch; cnt++) {
+        if (gs_touch[cnt].id == id && gs_touch[cnt].phase != GS_TouchEnded) {
+            touch = &gs_touch[cnt];
+        }
+    }
+    if (touch == NULL) {
+        if (phase != GS_TouchBegan || gs_ntouch >= GS_MaxTouches) {
+            return;
+        }
+        touch = &gs_touch[gs_ntouch++];
+        touch->id = id;
+        touch->phase = GS_TouchBegan;
+    } else if (phase == GS_TouchEnded) {
+        touch->phase = GS_TouchEnded;
+    }
+    touch->x = x;
+    touch->y = y;
+}
+
+// Translate the UIKit modifier flags into the modifier masks.
+static long gs_flags(UIKeyModifierFlags flags) {
+    long mods = 0;
+    if (flags & UIKeyModifierShift) {
+        mods |= GS_ShiftKeyMask;
+    }
+    if (flags & UIKeyModifierControl) {
+        mods |= GS_ControlKeyMask;
+    }
+    if (flags & UIKeyModifierAlternate) {
+        mods |= GS_AlternateKeyMask;
+    }
+    if (flags & UIKeyModifierCommand) {
+        mods |= GS_CommandKeyMask;
+    }
+    return mods;
+}
+
+// GSView is a full screen OpenGL ES view that tracks touches and
+// hardware keyboard presses.
+@interface GSView : UIView
+@end
+
+@implementation GSView
++ (Class) layerClass { return [CAEAGLLayer class]; }
+
+- (id) initWithFrame:(CGRect)frame {
+    if ((self = [super initWithFrame:frame])) {
+        CAEAGLLayer *layer = (CAEAGLLayer *)self.layer;
+        layer.opaque = YES;
+        layer.drawableProperties = @{ kEAGLDrawablePropertyRetainedBacking: @NO,
+                                      kEAGLDrawablePropertyColorFormat: kEAGLColorFormatRGBA8 };
+        self.contentScaleFactor = [UIScreen mainScreen].nativeScale;
+        self.multipleTouchEnabled = YES;
+    }
+    return self;
+}
+
+// Remember the view size for the application thread.
+- (void) layoutSubviews {
+    [super layoutSubviews];
+    pthread_mutex_lock(&gs_lock);
+    gs_width = self.bounds.size.width;
+    gs_height = self.bounds.size.height;
+    gs_factor = self.contentScaleFactor;
+    gs_resized = 1;
+    gs_post(GS_WindowResized, 0);
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// Touch locations are in points from the top left.
+- (void) track:(NSSet *)touches phase:(long)phase {
+    pthread_mutex_lock(&gs_lock);
+    for (UITouch *touch in touches) {
+        CGPoint loc = [touch locationInView:self];
+        float x = loc.x, y = self.bounds.size.height - loc.y;
+        gs_touch_update((long)touch, phase, x, y);
+
+        // the first touch also acts as the left mouse button.
+        if (phase == GS_TouchBegan && gs_primary == nil) {
+            gs_primary = touch;
+            gs_post(GS_LeftMouseDown, 0);
+        }
+        if (touch == gs_primary) {
+            gs_mousex = x;
+            gs_mousey = y;
+            if (phase == GS_TouchMoved) {
+                gs_post(GS_MouseMoved, 0);
+            } else if (phase == GS_TouchEnded) {
+                gs_primary = nil;
+                gs_post(GS_LeftMouseUp, 0);
+            }
+        }
+    }
+    pthread_mutex_unlock(&gs_lock);
+}
+- (void) touchesBegan:(NSSet *)touches withEvent:(UIEvent *)event { [self track:touches phase:GS_TouchBegan]; }
+- (void) touchesMoved:(NSSet *)touches withEvent:(UIEvent *)event { [self track:touches phase:GS_TouchMoved]; }
+- (void) touchesEnded:(NSSet *)touches withEvent:(UIEvent *)event { [self track:touches phase:GS_TouchEnded]; }
+- (void) touchesCancelled:(NSSet *)touches withEvent:(UIEvent *)event { [self track:touches phase:GS_TouchEnded]; }
+
+// Hardware keyboard keys are reported using their HID usage codes.
+- (BOOL) canBecomeFirstResponder { return YES; }
+- (void) press:(NSSet *)presses down:(BOOL)down {
+    if (@available(iOS 13.4, *)) {
+        pthread_mutex_lock(&gs_lock);
+        for (UIPress *press in presses) {
+            UIKey *key = press.key;
+            if (key == nil) {
+                continue;
+            }
+            gs_mods = gs_flags(key.modifierFlags);
+            gs_post(down ? GS_KeyDown : GS_KeyUp, key.keyCode);
+            const char *text = [key.characters UTF8String];
+            int count = text != NULL ? (int)strlen(text) : 0;
+            if (down && count > 0 && (unsigned char)text[0] >= 0x20 && text[0] != 0x7F &&
+                gs_ntyped + count < GS_TextSize) {
+                memcpy(&gs_typed[gs_ntyped], text, count);
+                gs_ntyped += count;
+                gs_typed[gs_ntyped] = 0;
+            }
+        }
+        pthread_mutex_unlock(&gs_lock);
+    }
+}
+- (void) pressesBegan:(NSSet *)presses withEvent:(UIPressesEvent *)event { [self press:presses down:YES]; }
+- (void) pressesEnded:(NSSet *)presses withEvent:(UIPressesEvent *)event { [self press:presses down:NO]; }
+- (void) pressesCancelled:(NSSet *)presses withEvent:(UIPressesEvent *)event { [self press:presses down:NO]; }
+@end
+
+// GSViewController hides the status bar and creates the view.
+@interface GSViewController : UIViewController
+@end
+
+@implementation GSViewController
+- (void) loadView {
+    GSView *view = [[GSView alloc] initWithFrame:[UIScreen mainScreen].bounds];
+    self.view = view;
+    [view release];
+}
+- (void) viewDidAppear:(BOOL)animated {
+    [super viewDidAppear:animated];
+    [self.view becomeFirstResponder];
+}
+- (BOOL) prefersStatusBarHidden { return YES; }
+- (BOOL) prefersHomeIndicatorAutoHidden { return YES; }
+@end
+
+// The application thread runs the application main.
+static void* gs_run(void *arg) {
+    gs_go_main();
+    return NULL;
+}
+
+// GSAppDelegate creates the window and follows the application lifecycle.
+@interface GSAppDelegate : UIResponder <UIApplicationDelegate>
+@property (retain, nonatomic) UIWindow *window;
+@end
+
+@implementation GSAppDelegate
+- (BOOL) application:(UIApplication *)app didFinishLaunchingWithOptions:(NSDictionary *)options {
+    UIWindow *window = [[UIWindow alloc] initWithFrame:[UIScreen mainScreen].bounds];
+    GSViewController *controller = [[GSViewController alloc] init];
+    window.rootViewController = controller;
+    [window makeKeyAndVisible];
+    self.window = window;
+    pthread_mutex_lock(&gs_lock);
+    gs_view = controller.view;
+    pthread_cond_broadcast(&gs_cond);
+    pthread_mutex_unlock(&gs_lock);
+    [controller release];
+    [window release];
+
+    pthread_t thread;
+    pthread_attr_t attr;
+    pthread_attr_init(&attr);
+    pthread_attr_setdetachstate(&attr, PTHREAD_CREATE_DETACHED);
+    pthread_create(&thread, &attr, gs_run, NULL);
+    pthread_attr_destroy(&attr);
+    return YES;
+}
+- (void) applicationDidBecomeActive:(UIApplication *)app { gs_post_locked(GS_WindowActive); }
+- (void) applicationWillResignActive:(UIApplication *)app { gs_post_locked(GS_WindowInactive); }
+- (void) applicationDidEnterBackground:(UIApplication *)app {
+    pthread_mutex_lock(&gs_lock);
+    gs_background = 1;
+    gs_post(GS_WindowIconified, 0);
+    pthread_mutex_unlock(&gs_lock);
+}
+- (void) applicationWillEnterForeground:(UIApplication *)app {
+    pthread_mutex_lock(&gs_lock);
+    gs_background = 0;
+    gs_post(GS_WindowUniconified, 0);
+    pthread_mutex_unlock(&gs_lock);
+}
+- (void) applicationWillTerminate:(UIApplication *)app {
+    pthread_mutex_lock(&gs_lock);
+    gs_alive = -2;
+    pthread_cond_broadcast(&gs_cond);
+    pthread_mutex_unlock(&gs_lock);
+}
+- (void) dealloc {
+    [_window release];
+    [super dealloc];
+}
+@end
+
+// Start UIKit. Called from the iOS application main. Does not return.
+int gs_ios_main(int argc, char *argv[]) {
+    NSAutoreleasePool *pool = [[NSAutoreleasePool alloc] init];
+    int result = UIApplicationMain(argc, argv, nil, NSStringFromClass([GSAppDelegate class]));
+    [pool release];
+    return result;
+}
+
+// UIKit is already running.
+long gs_display_init() { return 1; }
+
+// Release the OpenGL ES buffers and context.
+void gs_display_dispose(long display) {
+    if (gs_ctx != nil) {
+        [EAGLContext setCurrentContext:gs_ctx];
+        glDeleteFramebuffers(1, &gs_fbo);
+        glDeleteRenderbuffers(1, &gs_color);
+        glDeleteRenderbuffers(1, &gs_depth);
+        gs_fbo = gs_color = gs_depth = 0;
+        [EAGLContext setCurrentContext:nil];
+        [gs_ctx release];
+        gs_ctx = nil;
+    }
+}
+
+// Wait for the application delegate to create the view.
+long gs_shell(long display) {
+    pthread_mutex_lock(&gs_lock);
+    while (gs_view == nil && gs_alive != -2) {
+        pthread_cond_wait(&gs_cond, &gs_lock);
+    }
+    UIView *view = gs_view;
+    if (view != nil) {
+        gs_alive = 1;
+    }
+    pthread_mutex_unlock(&gs_lock);
+    return (long)view;
+}
+
+// Return 1 until the application terminates.
+unsigned char gs_shell_alive(long shell) { return gs_alive == 1; }
+
+// Size the color and depth buffers to match the view layer.
+static void gs_storage() {
+    glBindRenderbuffer(GL_RENDERBUFFER, gs_color);
+    [gs_ctx renderbufferStorage:GL_RENDERBUFFER fromDrawable:(CAEAGLLayer *)gs_view.layer];
+    glGetRenderbufferParameteriv(GL_RENDERBUFFER, GL_RENDERBUFFER_WIDTH, &gs_pw);
+    glGetRenderbufferParameteriv(GL_RENDERBUFFER, GL_RENDERBUFFER_HEIGHT, &gs_ph);
+    if (defaults.gs_DepthSize > 0) {
+        GLenum format = defaults.gs_DepthSize > 16 ? GL_DEPTH_COMPONENT24 : GL_DEPTH_COMPONENT16;
+        glBindRenderbuffer(GL_RENDERBUFFER, gs_depth);
+        glRenderbufferStorage(GL_RENDERBUFFER, format, gs_pw, gs_ph);
+    }
+    glBindRenderbuffer(GL_RENDERBUFFER, gs_color);
+}
+
+// Create an OpenGL ES 3 context that draws into a framebuffer backed by
+// the view layer. There is no default framebuffer, so the framebuffer
+// is left bound for the renderer to use as the screen.
+long gs_context(long display, long shell) {
+    gs_ctx = [[EAGLContext alloc] initWithAPI:kEAGLRenderingAPIOpenGLES3];
+    if (gs_ctx == nil || ![EAGLContext setCurrentContext:gs_ctx]) {
+        NSLog(@"Failed to create an OpenGL ES 3 context.");
+        return 0;
+    }
+    glGenFramebuffers(1, &gs_fbo);
+    glGenRenderbuffers(1, &gs_color);
+    glGenRenderbuffers(1, &gs_depth);
+    glBindFramebuffer(GL_FRAMEBUFFER, gs_fbo);
+    pthread_mutex_lock(&gs_lock);
+    gs_storage();
+    gs_resized = 0;
+    pthread_mutex_unlock(&gs_lock);
+    glFramebufferRenderbuffer(GL_FRAMEBUFFER, GL_COLOR_ATTACHMENT0, GL_RENDERBUFFER, gs_color);
+    if (defaults.gs_DepthSize > 0) {
+        glFramebufferRenderbuffer(GL_FRAMEBUFFER, GL_DEPTH_ATTACHMENT, GL_RENDERBUFFER, gs_depth);
+    }
+    if (glCheckFramebufferStatus(GL_FRAMEBUFFER) != GL_FRAMEBUFFER_COMPLETE) {
+        NSLog(@"Incomplete view framebuffer.");
+    }
+    return (long)gs_ctx;
+}
+
+// Show the latest drawing unless the application is in the background.
+void gs_swap_buffers(long display) {
+    pthread_mutex_lock(&gs_lock);
+    unsigned char background = gs_background;
+    pthread_mutex_unlock(&gs_lock);
+    if (gs_ctx != nil && !background) {
+        glBindRenderbuffer(GL_RENDERBUFFER, gs_color);
+        [gs_ctx presentRenderbuffer:GL_RENDERBUFFER];
+    }
+}
+
+// Process one queued event. The renderbuffers are resized here so
+// that the new size is ready when the resize is reported.
+void gs_read_dispatch(long display, GSEvent *gs_urge) {
+    pthread_mutex_lock(&gs_lock);
+    if (gs_resized && gs_ctx != nil) {
+        gs_resized = 0;
+        gs_storage();
+    }
+    if (gs_npending > 0) {
+        *gs_urge = gs_pending[0];
+        memmove(&gs_pending[0], &gs_pending[1], --gs_npending * sizeof(GSEvent));
+    }
+    gs_urge->mousex = gs_mousex;
+    gs_urge->mousey = gs_mousey;
+    gs_urge->mods = gs_mods;
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// Get the touches that are down or have ended since the last call.
+// Ended touches are then dropped and the others are marked as moved.
+long gs_touches(GSTouch *touches) {
+    pthread_mutex_lock(&gs_lock);
+    long count = gs_ntouch;
+    int cnt, kept = 0;
+    for (cnt = 0; cnt < gs_ntouch; cnt++) {
+        touches[cnt] = gs_touch[cnt];
+        if (gs_touch[cnt].phase != GS_TouchEnded) {
+            gs_touch[kept] = gs_touch[cnt];
+            gs_touch[kept].phase = GS_TouchMoved;
+            kept++;
+        }
+    }
+    gs_ntouch = kept;
+    pthread_mutex_unlock(&gs_lock);
+    return count;
+}
+
+// Get the text typed since the last call.
+void gs_text(GSText *text) {
+    pthread_mutex_lock(&gs_lock);
+    memcpy(text->typed, gs_typed, gs_ntyped + 1);
+    text->composing[0] = 0;
+    gs_ntyped = 0;
+    gs_typed[0] = 0;
+    pthread_mutex_unlock(&gs_lock);
+}
+
+// iOS applications don't have a back button.
+void gs_intercept_close(unsigned char intercept) {}
+
+// iOS views are sized in points.
+void gs_size(long display, long *x, long *y, long *w, long *h) {
+    pthread_mutex_lock(&gs_lock);
+    *x = 0;
+    *y = 0;
+    *w = (long)gs_width;
+    *h = (long)gs_height;
+    pthread_mutex_unlock(&gs_lock);
+}
+void gs_pixel_size(long display, long *w, long *h) {
+    *w = gs_pw;
+    *h = gs_ph;
+}
+
+// The number of pixels for each point.
+float gs_scale(long display) {
+    pthread_mutex_lock(&gs_lock);
+    float scale = gs_factor;
+    pthread_mutex_unlock(&gs_lock);
+    return scale;
+}
+
+// Set long attributes. Only positive values are used.
+void gs_set_attr_l(long attr, long value) {
+    if (value <= 0) {
+        return;
+    }
+    switch (attr) {
+    case GS_AlphaSize: defaults.gs_AlphaSize = value; break;
+    case GS_DepthSize: defaults.gs_DepthSize = value; break;
+    }
+}
@@ -0,0 +1,1103 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build !android
+
+// The linux (X11) native layer implementation.
+// This wraps Xlib and GLX so that there is no need to include the X
+// headers for the golang bindings.
+
+#include <dirent.h>
+#include <errno.h>
+#include <fcntl.h>
+#include <locale.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+#include <sys/ioctl.h>
+#include <linux/input.h>
+#include <linux/joystick.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+#include <X11/Xatom.h>
+#include <X11/cursorfont.h>
+#include <GL/glx.h>
+#include "os_linux.h"
+
+// Application defaults. Can be overridden by gs_set_attr_* methods.
+struct AppDefaults {
+    long gs_ShellX;
+    long gs_ShellY;
+    long gs_ShellWidth;
+    long gs_ShellHeight;
+    long gs_AlphaSize;
+    long gs_DepthSize;
+    char gs_AppName[40];
+};
+struct AppDefaults defaults = { 100, 100, 240, 280, 8, 24, "App" };
+
+// Used to check for the user quitting the application.
+// Set to 1 when the window is created and -2 when it is closed.
+long gs_win_alive = -1;
+
+// There is only one window and one OpenGL context.
+static Window gs_win = 0;
+static GLXFBConfig gs_fbconfig;
+static XVisualInfo *gs_visual = NULL;
+static GLXContext gs_ctx = NULL;
+static XIM gs_im = NULL;
+static XIC gs_ic = NULL;
+static long gs_width = 0, gs_height = 0; // last known drawing area size.
+
+// Window state.
+static unsigned char gs_unmapped = 0;         // 1 while the window is iconified.
+static unsigned char gs_close_intercepted = 0;
+static unsigned char gs_cursor_hidden = 0;
+static unsigned char gs_cursor_locked = 0;
+static long gs_win_mode = GS_Windowed;
+static Cursor gs_cursor = None; // current cursor, None for the default.
+static Cursor gs_blank = None;  // invisible cursor for hiding.
+
+// Atoms used to talk to the window manager and other applications.
+static Atom gs_wm_protocols, gs_wm_delete, gs_wm_state, gs_wm_fullscreen, gs_wm_name;
+static Atom gs_wm_icon, gs_wm_attention;
+static Atom gs_clipboard, gs_utf8, gs_targets, gs_property;
+static Atom gs_dnd_aware, gs_dnd_enter, gs_dnd_position, gs_dnd_status, gs_dnd_drop;
+static Atom gs_dnd_finished, gs_dnd_selection, gs_dnd_copy, gs_uri_list;
+
+// Drag and drop state for the current drag.
+static Window gs_dnd_source = 0;
+static long gs_dnd_version = 0;
+
+// Text typed since the last gs_text call.
+static char gs_typed[GS_TextSize];
+static int gs_ntyped = 0;
+
+// Files dropped since the last gs_dropped call.
+static char *gs_drops = NULL;
+static size_t gs_ndrops = 0;
+
+// Text owned by this application while it owns the clipboard.
+static char *gs_clip = NULL;
+
+// Game controllers are read from the joystick devices /dev/input/js0-3.
+static int gs_pad_fds[GS_MaxPads] = { -1, -1, -1, -1 };
+static GSPad gs_pad_state[GS_MaxPads];
+static int gs_pad_poll = 0;
+
+// Controller rumble uses the force feedback event device of each joystick.
+// The devices are opened when first needed.
+static int gs_ff_fds[GS_MaxPads] = { -1, -1, -1, -1 };
+static int gs_ff_ids[GS_MaxPads] = { -1, -1, -1, -1 };
+
+// Close the force feedback device for the given controller slot.
+static void gs_ff_close(int pad) {
+    if (gs_ff_fds[pad] >= 0) {
+        close(gs_ff_fds[pad]);
+        gs_ff_fds[pad] = -1;
+    }
+    gs_ff_ids[pad] = -1;
+}
+
+// Connect to the X server. The display is always the default display.
+long gs_display_init() {
+    if (strcmp(setlocale(LC_CTYPE, NULL), "C") == 0) {
+        setlocale(LC_CTYPE, ""); // needed for the input method.
+    }
+    XSetLocaleModifiers("");
+    Display *dpy = XOpenDisplay(NULL);
+    if (dpy == NULL) {
+        printf("Failed to open the X display.\n");
+        return 0;
+    }
+    gs_wm_protocols = XInternAtom(dpy, "WM_PROTOCOLS", False);
+    gs_wm_delete = XInternAtom(dpy, "WM_DELETE_WINDOW", False);
+    gs_wm_state = XInternAtom(dpy, "_NET_WM_STATE", False);
+    gs_wm_fullscreen = XInternAtom(dpy, "_NET_WM_STATE_FULLSCREEN", False);
+    gs_wm_name = XInternAtom(dpy, "_NET_WM_NAME", False);
+    gs_wm_icon = XInternAtom(dpy, "_NET_WM_ICON", False);
+    gs_wm_attention = XInternAtom(dpy, "_NET_WM_STATE_DEMANDS_ATTENTION", False);
+    gs_clipboard = XInternAtom(dpy, "CLIPBOARD", False);
+    gs_utf8 = XInternAtom(dpy, "UTF8_STRING", False);
+    gs_targets = XInternAtom(dpy, "TARGETS", False);
+    gs_property = XInternAtom(dpy, "GS_SELECTION", False);
+    gs_dnd_aware = XInternAtom(dpy, "XdndAware", False);
+    gs_dnd_enter = XInternAtom(dpy, "XdndEnter", False);
+    gs_dnd_position = XInternAtom(dpy, "XdndPosition", False);
+    gs_dnd_status = XInternAtom(dpy, "XdndStatus", False);
+    gs_dnd_drop = XInternAtom(dpy, "XdndDrop", False);
+    gs_dnd_finished = XInternAtom(dpy, "XdndFinished", False);
+    gs_dnd_selection = XInternAtom(dpy, "XdndSelection", False);
+    gs_dnd_copy = XInternAtom(dpy, "XdndActionCopy", False);
+    gs_uri_list = XInternAtom(dpy, "text/uri-list", False);
+    return (long)dpy;
+}
+
+// Release the window, context, cursors, and controllers.
+void gs_display_dispose(long display) {
+    Display *dpy = (Display *)display;
+    int cnt;
+    for (cnt = 0; cnt < GS_MaxPads; cnt++) {
+        if (gs_pad_fds[cnt] >= 0) {
+            close(gs_pad_fds[cnt]);
+            gs_pad_fds[cnt] = -1;
+        }
+        gs_ff_close(cnt);
+    }
+    if (dpy == NULL) {
+        return;
+    }
+    if (gs_ic != NULL) {
+        XDestroyIC(gs_ic);
+        gs_ic = NULL;
+    }
+    if (gs_im != NULL) {
+        XCloseIM(gs_im);
+        gs_im = NULL;
+    }
+    if (gs_ctx != NULL) {
+        glXMakeCurrent(dpy, None, NULL);
+        glXDestroyContext(dpy, gs_ctx);
+        gs_ctx = NULL;
+    }
+    if (gs_cursor != None) {
+        XFreeCursor(dpy, gs_cursor);
+        gs_cursor = None;
+    }
+    if (gs_blank != None) {
+        XFreeCursor(dpy, gs_blank);
+        gs_blank = None;
+    }
+    if (gs_win != 0) {
+        XDestroyWindow(dpy, gs_win);
+        gs_win = 0;
+    }
+    if (gs_visual != NULL) {
+        XFree(gs_visual);
+        gs_visual = NULL;
+    }
+    free(gs_clip);
+    gs_clip = NULL;
+    free(gs_drops);
+    gs_drops = NULL;
+    gs_ndrops = 0;
+    XCloseDisplay(dpy);
+}
+
+// Create the window. X windows have their origin at the top left, so
+// the shell location is flipped to match the other platforms.
+long gs_shell(long display) {
+    Display *dpy = (Display *)display;
+    int screen = DefaultScreen(dpy);
+    int attribs[] = {
+        GLX_X_RENDERABLE,  True,
+        GLX_DRAWABLE_TYPE, GLX_WINDOW_BIT,
+        GLX_RENDER_TYPE,   GLX_RGBA_BIT,
+        GLX_RED_SIZE,      8,
+        GLX_GREEN_SIZE,    8,
+        GLX_BLUE_SIZE,     8,
+        GLX_ALPHA_SIZE,    (int)defaults.gs_AlphaSize,
+        GLX_DEPTH_SIZE,    (int)defaults.gs_DepthSize,
+        GLX_DOUBLEBUFFER,  True,
+        None
+    };
+    int count = 0;
+    GLXFBConfig *configs = glXChooseFBConfig(dpy, screen, attribs, &count);
+    if (configs == NULL || count == 0) {
+        printf("No matching frame buffer configuration.\n");
+        return 0;
+    }
+    gs_fbconfig = configs[0];
+    XFree(configs);
+    gs_visual = glXGetVisualFromFBConfig(dpy, gs_fbconfig);
+    if (gs_visual == NULL) {
+        printf("No matching visual.\n");
+        return 0;
+    }
+
+    // create the window with the events that the layer processes.
+    Window root = RootWindow(dpy, screen);
+    XSetWindowAttributes swa;
+    memset(&swa, 0, sizeof(swa));
+    swa.colormap = XCreateColormap(dpy, root, gs_visual->visual, AllocNone);
+    swa.border_pixel = 0;
+    swa.event_mask = KeyPressMask | KeyReleaseMask | ButtonPressMask | ButtonReleaseMask |
+        PointerMotionMask | StructureNotifyMask | FocusChangeMask;
+    long x = defaults.gs_ShellX;
+    long y = DisplayHeight(dpy, screen) - defaults.gs_ShellY - defaults.gs_ShellHeight;
+    gs_win = XCreateWindow(dpy, root, x, y, defaults.gs_ShellWidth, defaults.gs_ShellHeight,
+        0, gs_visual->depth, InputOutput, gs_visual->visual,
+        CWColormap | CWBorderPixel | CWEventMask, &swa);
+    if (gs_win == 0) {
+        printf("Failed to create the window.\n");
+        return 0;
+    }
+    gs_width = defaults.gs_ShellWidth;
+    gs_height = defaults.gs_ShellHeight;
+
+    // ask the window manager to respect the requested location.
+    XSizeHints *hints = XAllocSizeHints();
+    hints->flags = PPosition | PSize;
+    hints->x = x;
+    hints->y = y;
+    hints->width = defaults.gs_ShellWidth;
+    hints->height = defaults.gs_ShellHeight;
+    XSetWMNormalHints(dpy, gs_win, hints);
+    XFree(hints);
+
+    // title as both latin-1 and UTF-8.
+    XStoreName(dpy, gs_win, defaults.gs_AppName);
+    XChangeProperty(dpy, gs_win, gs_wm_name, gs_utf8, 8, PropModeReplace,
+        (unsigned char *)defaults.gs_AppName, strlen(defaults.gs_AppName));
+
+    // get told when the user closes the window and when files are dragged.
+    XSetWMProtocols(dpy, gs_win, &gs_wm_delete, 1);
+    Atom version = 5;
+    XChangeProperty(dpy, gs_win, gs_dnd_aware, XA_ATOM, 32, PropModeReplace, (unsigned char *)&version, 1);
+
+    // the input method turns key presses into text.
+    gs_im = XOpenIM(dpy, NULL, NULL, NULL);
+    if (gs_im != NULL) {
+        gs_ic = XCreateIC(gs_im, XNInputStyle, XIMPreeditNothing | XIMStatusNothing,
+            XNClientWindow, gs_win, XNFocusWindow, gs_win, NULL);
+    }
+    gs_win_alive = 1;
+    return (long)gs_win;
+}
+
+// Show the window.
+void gs_shell_open(long display, long shell) {
+    Display *dpy = (Display *)display;
+    XMapRaised(dpy, (Window)shell);
+    XFlush(dpy);
+}
+
+// Return 1 as long as the window hasn't been closed.
+unsigned char gs_shell_alive(long shell) { return gs_win_alive == 1; }
+
+// Full screen is any mode other than windowed.
+unsigned char gs_fullscreen(long display) { return gs_win_mode != GS_Windowed; }
+
+// Flip between windowed and borderless full screen.
+void gs_toggle_fullscreen(long display) {
+    long mode = gs_win_mode == GS_Windowed ? GS_Borderless : GS_Windowed;
+    gs_set_window_mode(display, mode, -1, 0, 0, 0);
+}
+
+// Without the XRandR extension the default screen is the only monitor
+// and only the current display mode is known.
+long gs_monitors(long display, GSMonitor *monitors) {
+    Display *dpy = (Display *)display;
+    int screen = DefaultScreen(dpy);
+    GSMonitor *m = &monitors[0];
+    memset(m, 0, sizeof(GSMonitor));
+    snprintf(m->name, sizeof(m->name), "%s", DisplayString(dpy));
+    m->w = DisplayWidth(dpy, screen);
+    m->h = DisplayHeight(dpy, screen);
+    m->primary = 1;
+    m->nmodes = 1;
+    m->modes[0].w = m->w;
+    m->modes[0].h = m->h;
+    return 1;
+}
+
+// Ask the window manager to add or remove the full screen state.
+// Exclusive mode can't change the display mode without XRandR, so it
+// is the same as borderless.
+void gs_set_window_mode(long display, long mode, long monitor, long w, long h, long refresh) {
+    Display *dpy = (Display *)display;
+    if (mode == GS_Exclusive) {
+        mode = GS_Borderless;
+    }
+    if (gs_win == 0 || mode == gs_win_mode) {
+        return;
+    }
+    XEvent ev;
+    memset(&ev, 0, sizeof(ev));
+    ev.type = ClientMessage;
+    ev.xclient.window = gs_win;
+    ev.xclient.message_type = gs_wm_state;
+    ev.xclient.format = 32;
+    ev.xclient.data.l[0] = mode == GS_Windowed ? 0 : 1; // _NET_WM_STATE_REMOVE or _ADD
+    ev.xclient.data.l[1] = gs_wm_fullscreen;
+    ev.xclient.data.l[3] = 1; // normal application.
+    XSendEvent(dpy, DefaultRootWindow(dpy), False, SubstructureNotifyMask | SubstructureRedirectMask, &ev);
+    XFlush(dpy);
+    gs_win_mode = mode;
+}
+
+// Translate the X modifier state into the modifier masks.
+static long gs_mods(unsigned int state) {
+    long mods = 0;
+    if (state & ShiftMask) {
+        mods |= GS_ShiftKeyMask;
+    }
+    if (state & ControlMask) {
+        mods |= GS_ControlKeyMask;
+    }
+    if (state & Mod1Mask) {
+        mods |= GS_AlternateKeyMask;
+    }
+    if (state & Mod4Mask) {
+        mods |= GS_CommandKeyMask;
+    }
+    return mods;
+}
+
+// Append UTF-8 text to the typed text, dropping text that doesn't fit.
+static void gs_type(const char *text, int count) {
+    if (count > 0 && gs_ntyped + count < GS_TextSize) {
+        memcpy(&gs_typed[gs_ntyped], text, count);
+        gs_ntyped += count;
+        gs_typed[gs_ntyped] = 0;
+    }
+}
+
+// Append a newline terminated path to the dropped files.
+static void gs_drop(const char *path, size_t count) {
+    char *drops = realloc(gs_drops, gs_ndrops + count + 2);
+    if (drops == NULL) {
+        return;
+    }
+    gs_drops = drops;
+    memcpy(&gs_drops[gs_ndrops], path, count);
+    gs_ndrops += count;
+    gs_drops[gs_ndrops++] = '\n';
+    gs_drops[gs_ndrops] = 0;
+}
+
+// Turn a hex digit into its value.
+static int gs_hex(char c) {
+    if (c >= '0' && c <= '9') {
+        return c - '0';
+    }
+    if (c >= 'a' && c <= 'f') {
+        return c - 'a' + 10;
+    }
+    if (c >= 'A' && c <= 'F') {
+        return c - 'A' + 10;
+    }
+    return -1;
+}
+
+// Collect the file paths from a dropped text/uri-list. Each line is
+// a file://host/path URI with special characters percent encoded.
+static void gs_drop_uris(char *uris) {
+    char *line = strtok(uris, "\r\n");
+    for (; line != NULL; line = strtok(NULL, "\r\n")) {
+        if (strncmp(line, "file://", 7) != 0) {
+            continue; // ignore comments and other schemes.
+        }
+        char *path = strchr(line + 7, '/');
+        if (path == NULL) {
+            continue;
+        }
+        char *src = path, *dst = path;
+        for (; *src; src++, dst++) {
+            if (src[0] == '%' && gs_hex(src[1]) >= 0 && gs_hex(src[2]) >= 0) {
+                *dst = (char)(gs_hex(src[1]) << 4 | gs_hex(src[2]));
+                src += 2;
+            } else {
+                *dst = *src;
+            }
+        }
+        gs_drop(path, dst - path);
+    }
+}
+
+// Send a XDND client message to the drag source.
+static void gs_dnd_reply(Display *dpy, Atom type, long accept) {
+    XEvent ev;
+    memset(&ev, 0, sizeof(ev));
+    ev.type = ClientMessage;
+    ev.xclient.window = gs_dnd_source;
+    ev.xclient.message_type = type;
+    ev.xclient.format = 32;
+    ev.xclient.data.l[0] = gs_win;
+    ev.xclient.data.l[1] = accept;
+    if (type == gs_dnd_finished) {
+        ev.xclient.data.l[2] = accept ? gs_dnd_copy : None;
+    } else {
+        ev.xclient.data.l[4] = gs_dnd_copy;
+    }
+    XSendEvent(dpy, gs_dnd_source, False, NoEventMask, &ev);
+    XFlush(dpy);
+}
+
+// Handle window manager and drag and drop messages.
+static void gs_client_message(Display *dpy, XClientMessageEvent *msg, GSEvent *gs_urge) {
+    if (msg->message_type == gs_wm_protocols && (Atom)msg->data.l[0] == gs_wm_delete) {
+        if (gs_close_intercepted) {
+            gs_urge->event = GS_WindowClosing;
+        } else {
+            gs_win_alive = -2;
+        }
+    } else if (msg->message_type == gs_dnd_enter) {
+        gs_dnd_source = msg->data.l[0];
+        gs_dnd_version = msg->data.l[1] >> 24;
+    } else if (msg->message_type == gs_dnd_position) {
+        gs_dnd_reply(dpy, gs_dnd_status, 1);
+    } else if (msg->message_type == gs_dnd_drop) {
+        Time time = gs_dnd_version >= 1 ? (Time)msg->data.l[2] : CurrentTime;
+        XConvertSelection(dpy, gs_dnd_selection, gs_uri_list, gs_dnd_selection, gs_win, time);
+    }
+}
+
+// Read the dropped files once the drag source has provided them.
+static void gs_selection_notify(Display *dpy, XSelectionEvent *sel) {
+    if (sel->selection != gs_dnd_selection) {
+        return;
+    }
+    if (sel->property != None) {
+        Atom type;
+        int format;
+        unsigned long count, remaining;
+        unsigned char *data = NULL;
+        XGetWindowProperty(dpy, gs_win, sel->property, 0, 0x7FFFFFFF, True, AnyPropertyType,
+            &type, &format, &count, &remaining, &data);
+        if (data != NULL) {
+            gs_drop_uris((char *)data);
+            XFree(data);
+        }
+    }
+    if (gs_dnd_version >= 2) {
+        gs_dnd_reply(dpy, gs_dnd_finished, sel->property != None);
+    }
+}
+
+// Give the clipboard text to another application.
+static void gs_selection_request(Display *dpy, XSelectionRequestEvent *req) {
+    XSelectionEvent reply;
+    memset(&reply, 0, sizeof(reply));
+    reply.type = SelectionNotify;
+    reply.requestor = req->requestor;
+    reply.selection = req->selection;
+    reply.target = req->target;
+    reply.time = req->time;
+    reply.property = None;
+    Atom property = req->property != None ? req->property : req->target;
+    if (gs_clip != NULL) {
+        if (req->target == gs_targets) {
+            Atom targets[] = { gs_targets, gs_utf8, XA_STRING };
+            XChangeProperty(dpy, req->requestor, property, XA_ATOM, 32, PropModeReplace,
+                (unsigned char *)targets, 3);
+            reply.property = property;
+        } else if (req->target == gs_utf8 || req->target == XA_STRING) {
+            XChangeProperty(dpy, req->requestor, property, req->target, 8, PropModeReplace,
+                (unsigned char *)gs_clip, strlen(gs_clip));
+            reply.property = property;
+        }
+    }
+    XSendEvent(dpy, req->requestor, False, NoEventMask, (XEvent *)&reply);
+    XFlush(dpy);
+}
+
+// Keep the pointer in the window while the cursor is locked.
+static void gs_grab(Display *dpy, unsigned char grab) {
+    if (grab) {
+        XGrabPointer(dpy, gs_win, True, ButtonPressMask | ButtonReleaseMask | PointerMotionMask,
+            GrabModeAsync, GrabModeAsync, gs_win, None, CurrentTime);
+        XWarpPointer(dpy, None, gs_win, 0, 0, 0, 0, gs_width / 2, gs_height / 2);
+    } else {
+        XUngrabPointer(dpy, CurrentTime);
+    }
+    XFlush(dpy);
+}
+
+// Translate one X event into a GSEvent. Unused events are ignored.
+static void gs_handle_event(Display *dpy, XEvent *ev, GSEvent *gs_urge) {
+    XEvent next;
+    char text[64];
+    KeySym sym;
+    Status status;
+    int count;
+    switch (ev->type) {
+    case KeyPress:
+        gs_urge->event = GS_KeyDown;
+        gs_urge->key = ev->xkey.keycode;
+        if (gs_ic != NULL) {
+            count = Xutf8LookupString(gs_ic, &ev->xkey, text, sizeof(text), &sym, &status);
+            if (status == XLookupChars || status == XLookupBoth) {
+                if ((unsigned char)text[0] >= 0x20 && text[0] != 0x7F) {
+                    gs_type(text, count); // ignore control characters.
+                }
+            }
+        }
+        break;
+    case KeyRelease:
+        // X repeats held keys with release and press pairs. Ignore the
+        // release so that the key stays down.
+        if (XEventsQueued(dpy, QueuedAfterReading)) {
+            XPeekEvent(dpy, &next);
+            if (next.type == KeyPress && next.xkey.time == ev->xkey.time &&
+                next.xkey.keycode == ev->xkey.keycode) {
+                break;
+            }
+        }
+        gs_urge->event = GS_KeyUp;
+        gs_urge->key = ev->xkey.keycode;
+        break;
+    case ButtonPress:
+        switch (ev->xbutton.button) {
+        case Button1: gs_urge->event = GS_LeftMouseDown; break;
+        case Button2: gs_urge->event = GS_OtherMouseDown; break;
+        case Button3: gs_urge->event = GS_RightMouseDown; break;
+        case Button4: // scroll direction matches OSX.
+            gs_urge->event = GS_ScrollWheel;
+            gs_urge->scroll = -1;
+            break;
+        case Button5:
+            gs_urge->event = GS_ScrollWheel;
+            gs_urge->scroll = 1;
+            break;
+        }
+        break;
+    case ButtonRelease:
+        switch (ev->xbutton.button) {
+        case Button1: gs_urge->event = GS_LeftMouseUp; break;
+        case Button2: gs_urge->event = GS_OtherMouseUp; break;
+        case Button3: gs_urge->event = GS_RightMouseUp; break;
+        }
+        break;
+    case MotionNotify:
+        gs_urge->event = GS_MouseMoved;
+        if (gs_cursor_locked) {
+            long dx = ev->xmotion.x - gs_width / 2;
+            long dy = gs_height / 2 - ev->xmotion.y;
+            if (dx != 0 || dy != 0) {
+                gs_urge->dx = dx;
+                gs_urge->dy = dy;
+                XWarpPointer(dpy, None, gs_win, 0, 0, 0, 0, gs_width / 2, gs_height / 2);
+            }
+        }
+        break;
+    case ConfigureNotify:
+        if (ev->xconfigure.width != gs_width || ev->xconfigure.height != gs_height) {
+            gs_width = ev->xconfigure.width;
+            gs_height = ev->xconfigure.height;
+            gs_urge->event = GS_WindowResized;
+        } else {
+            gs_urge->event = GS_WindowMoved;
+        }
+        break;
+    case FocusIn:
+        if (ev->xfocus.mode != NotifyGrab && ev->xfocus.mode != NotifyUngrab) {
+            if (gs_ic != NULL) {
+                XSetICFocus(gs_ic);
+            }
+            if (gs_cursor_locked) {
+                gs_grab(dpy, 1);
+            }
+            gs_urge->event = GS_WindowActive;
+        }
+        break;
+    case FocusOut:
+        if (ev->xfocus.mode != NotifyGrab && ev->xfocus.mode != NotifyUngrab) {
+            if (gs_ic != NULL) {
+                XUnsetICFocus(gs_ic);
+            }
+            if (gs_cursor_locked) {
+                gs_grab(dpy, 0);
+            }
+            gs_urge->event = GS_WindowInactive;
+        }
+        break;
+    case MapNotify:
+        if (gs_unmapped) {
+            gs_unmapped = 0;
+            gs_urge->event = GS_WindowUniconified;
+        }
+        break;
+    case UnmapNotify:
+        gs_unmapped = 1;
+        gs_urge->event = GS_WindowIconified;
+        break;
+    case ClientMessage:
+        gs_client_message(dpy, &ev->xclient, gs_urge);
+        break;
+    case SelectionNotify:
+        gs_selection_notify(dpy, &ev->xselection);
+        break;
+    case SelectionRequest:
+        gs_selection_request(dpy, &ev->xselectionrequest);
+        break;
+    case SelectionClear:
+        free(gs_clip);
+        gs_clip = NULL;
+        break;
+    }
+}
+
+// Process at most one X event each call. The mouse location and
+// modifiers are always filled in.
+void gs_read_dispatch(long display, GSEvent *gs_urge) {
+    Display *dpy = (Display *)display;
+    if (XPending(dpy) > 0) {
+        XEvent ev;
+        XNextEvent(dpy, &ev);
+        if (!XFilterEvent(&ev, None)) {
+            gs_handle_event(dpy, &ev, gs_urge);
+        }
+    }
+    Window root, child;
+    int rootx, rooty, winx, winy;
+    unsigned int mask;
+    if (XQueryPointer(dpy, gs_win, &root, &child, &rootx, &rooty, &winx, &winy, &mask)) {
+        gs_urge->mousex = winx;
+        gs_urge->mousey = gs_height - winy;
+        gs_urge->mods = gs_mods(mask);
+    }
+}
+
+// Read a hex value from the joystick sysfs device id files.
+static long gs_pad_id(int pad, const char *id) {
+    char path[128];
+    long value = 0;
+    snprintf(path, sizeof(path), "/sys/class/input/js%d/device/id/%s", pad, id);
+    FILE *f = fopen(path, "r");
+    if (f != NULL) {
+        if (fscanf(f, "%lx", &value) != 1) {
+            value = 0;
+        }
+        fclose(f);
+    }
+    return value;
+}
+
+// Open the joystick device for the given controller slot.
+static void gs_pad_open(int pad) {
+    char path[32];
+    snprintf(path, sizeof(path), "/dev/input/js%d", pad);
+    int fd = open(path, O_RDONLY | O_NONBLOCK);
+    if (fd < 0) {
+        return;
+    }
+    GSPad *gp = &gs_pad_state[pad];
+    memset(gp, 0, sizeof(GSPad));
+    unsigned char axes = 0, buttons = 0;
+    ioctl(fd, JSIOCGAXES, &axes);
+    ioctl(fd, JSIOCGBUTTONS, &buttons);
+    if (ioctl(fd, JSIOCGNAME(sizeof(gp->name)), gp->name) < 0) {
+        snprintf(gp->name, sizeof(gp->name), "Joystick");
+    }
+    gp->naxes = axes < GS_PadAxes ? axes : GS_PadAxes;
+    gp->nbuttons = buttons < GS_PadButtons ? buttons : GS_PadButtons;
+    gp->vendor = gs_pad_id(pad, "vendor");
+    gp->product = gs_pad_id(pad, "product");
+    gp->version = gs_pad_id(pad, "version");
+    gp->connected = 1;
+    gs_pad_fds[pad] = fd;
+}
+
+// Read the joystick events since the last call. Newly attached
+// controllers are only looked for every so often since opening
+// devices is slow.
+void gs_pads(GSPad *pads) {
+    int cnt;
+    if (gs_pad_poll-- <= 0) {
+        gs_pad_poll = 120;
+        for (cnt = 0; cnt < GS_MaxPads; cnt++) {
+            if (gs_pad_fds[cnt] < 0) {
+                gs_pad_open(cnt);
+            }
+        }
+    }
+    for (cnt = 0; cnt < GS_MaxPads; cnt++) {
+        GSPad *gp = &gs_pad_state[cnt];
+        int fd = gs_pad_fds[cnt];
+        if (fd < 0) {
+            continue;
+        }
+        struct js_event e;
+        ssize_t bytes;
+        while ((bytes = read(fd, &e, sizeof(e))) == sizeof(e)) {
+            unsigned char type = e.type & ~JS_EVENT_INIT;
+            if (type == JS_EVENT_BUTTON && e.number < gp->nbuttons) {
+                gp->buttons[e.number] = e.value ? 1 : 0;
+            } else if (type == JS_EVENT_AXIS && e.number < gp->naxes) {
+                gp->axes[e.number] = e.value / 32767.0f;
+            }
+        }
+        if (bytes < 0 && errno != EAGAIN) {
+            close(fd); // controller detached.
+            gs_pad_fds[cnt] = -1;
+            gs_ff_close(cnt);
+            memset(gp, 0, sizeof(GSPad));
+        }
+    }
+    memcpy(pads, gs_pad_state, sizeof(gs_pad_state));
+}
+
+// Open the event device that shares the joystick device for the given
+// controller slot. Event devices are often only writable by the user
+// logged into the desktop.
+static int gs_ff_open(int pad) {
+    char path[64];
+    int fd = -1;
+    snprintf(path, sizeof(path), "/sys/class/input/js%d/device", pad);
+    DIR *dir = opendir(path);
+    if (dir == NULL) {
+        return -1;
+    }
+    struct dirent *entry;
+    while (fd < 0 && (entry = readdir(dir)) != NULL) {
+        if (strncmp(entry->d_name, "event", 5) == 0) {
+            snprintf(path, sizeof(path), "/dev/input/%s", entry->d_name);
+            fd = open(path, O_RDWR | O_NONBLOCK);
+        }
+    }
+    closedir(dir);
+    return fd;
+}
+
+// Upload and play a rumble effect. The effect has a zero length so that
+// it plays until it is stopped or replaced.
+void gs_rumble(long pad, float low, float high) {
+    if (pad < 0 || pad >= GS_MaxPads || gs_pad_fds[pad] < 0) {
+        return;
+    }
+    if (gs_ff_fds[pad] < 0 && (gs_ff_fds[pad] = gs_ff_open(pad)) < 0) {
+        return;
+    }
+    struct input_event play;
+    memset(&play, 0, sizeof(play));
+    play.type = EV_FF;
+    if (low <= 0 && high <= 0) {
+        if (gs_ff_ids[pad] >= 0) {
+            play.code = gs_ff_ids[pad];
+            play.value = 0;
+            if (write(gs_ff_fds[pad], &play, sizeof(play)) < 0) {
+                gs_ff_close(pad);
+            }
+        }
+        return;
+    }
+    struct ff_effect effect;
+    memset(&effect, 0, sizeof(effect));
+    effect.type = FF_RUMBLE;
+    effect.id = gs_ff_ids[pad];
+    effect.u.rumble.strong_magnitude = (unsigned short)(low * 0xFFFF);
+    effect.u.rumble.weak_magnitude = (unsigned short)(high * 0xFFFF);
+    if (ioctl(gs_ff_fds[pad], EVIOCSFF, &effect) < 0) {
+        return; // no rumble motors.
+    }
+    gs_ff_ids[pad] = effect.id;
+    play.code = effect.id;
+    play.value = 1;
+    if (write(gs_ff_fds[pad], &play, sizeof(play)) < 0) {
+        gs_ff_close(pad);
+    }
+}
+
+// The input method only produces finished text, so composing is always empty.
+void gs_text(GSText *text) {
+    memcpy(text->typed, gs_typed, gs_ntyped + 1);
+    text->composing[0] = 0;
+    gs_ntyped = 0;
+    gs_typed[0] = 0;
+}
+
+// Report GS_WindowClosing instead of closing the window.
+void gs_intercept_close(unsigned char intercept) { gs_close_intercepted = intercept; }
+
+// Hand the dropped files to the caller who frees them.
+char* gs_dropped() {
+    char *drops = gs_drops;
+    gs_drops = NULL;
+    gs_ndrops = 0;
+    return drops;
+}
+
+// Get the window location relative to the bottom left of the screen.
+void gs_size(long display, long *x, long *y, long *w, long *h) {
+    Display *dpy = (Display *)display;
+    XWindowAttributes attrs;
+    XGetWindowAttributes(dpy, gs_win, &attrs);
+    Window child;
+    int rootx = 0, rooty = 0;
+    XTranslateCoordinates(dpy, gs_win, attrs.root, 0, 0, &rootx, &rooty, &child);
+    *x = rootx;
+    *y = DisplayHeight(dpy, DefaultScreen(dpy)) - (rooty + attrs.height);
+    *w = attrs.width;
+    *h = attrs.height;
+}
+
+// X has no per window scale. Use the desktop Xft.dpi setting when there is one.
+float gs_scale(long display) {
+    char *dpi = XGetDefault((Display *)display, "Xft", "dpi");
+    if (dpi != NULL) {
+        float value = atof(dpi);
+        if (value > 0) {
+            return value / 96.0f;
+        }
+    }
+    return 1.0f;
+}
+
+// Use the invisible cursor while hidden or locked, otherwise the current cursor.
+static void gs_use_cursor(Display *dpy) {
+    if (gs_win == 0) {
+        return;
+    }
+    if (gs_cursor_hidden || gs_cursor_locked) {
+        if (gs_blank == None) {
+            char none[1] = { 0 };
+            XColor black;
+            memset(&black, 0, sizeof(black));
+            Pixmap pix = XCreateBitmapFromData(dpy, gs_win, none, 1, 1);
+            gs_blank = XCreatePixmapCursor(dpy, pix, pix, &black, &black, 0, 0);
+            XFreePixmap(dpy, pix);
+        }
+        XDefineCursor(dpy, gs_win, gs_blank);
+    } else if (gs_cursor != None) {
+        XDefineCursor(dpy, gs_win, gs_cursor);
+    } else {
+        XUndefineCursor(dpy, gs_win);
+    }
+    XFlush(dpy);
+}
+
+// Replace the current cursor.
+static void gs_set_cursor(Display *dpy, Cursor cursor) {
+    if (gs_cursor != None) {
+        XFreeCursor(dpy, gs_cursor);
+    }
+    gs_cursor = cursor;
+    gs_use_cursor(dpy);
+}
+
+// Show or hide the cursor.
+void gs_show_cursor(long display, unsigned char show) {
+    gs_cursor_hidden = !show;
+    gs_use_cursor((Display *)display);
+}
+
+// Use the X cursor font for the system cursor shapes.
+void gs_cursor_shape(long display, long shape) {
+    Display *dpy = (Display *)display;
+    unsigned int glyph = XC_left_ptr;
+    switch (shape) {
+    case GS_HandCursor:      glyph = XC_hand2; break;
+    case GS_IBeamCursor:     glyph = XC_xterm; break;
+    case GS_CrosshairCursor: glyph = XC_crosshair; break;
+    case GS_ResizeXCursor:   glyph = XC_sb_h_double_arrow; break;
+    case GS_ResizeYCursor:   glyph = XC_sb_v_double_arrow; break;
+    }
+    gs_set_cursor(dpy, XCreateFontCursor(dpy, glyph));
+}
+
+// Without the Xcursor extension cursor images are limited to two colors.
+// Mostly transparent pixels are hidden, dark pixels are black, and the
+// remaining pixels are white.
+void gs_cursor_image(long display, unsigned char *rgba, long w, long h, long hotx, long hoty) {
+    Display *dpy = (Display *)display;
+    long stride = (w + 7) / 8;
+    char *source = calloc(stride * h * 2, 1);
+    if (source == NULL) {
+        return;
+    }
+    char *mask = source + stride * h;
+    long x, y;
+    for (y = 0; y < h; y++) {
+        for (x = 0; x < w; x++) {
+            unsigned char *p = &rgba[(y * w + x) * 4];
+            if (p[3] < 128) {
+                continue;
+            }
+            char bit = 1 << (x % 8); // bitmaps are least significant bit first.
+            mask[y * stride + x / 8] |= bit;
+            if ((p[0] + p[1] + p[2]) / 3 < 128) {
+                source[y * stride + x / 8] |= bit;
+            }
+        }
+    }
+    Pixmap src = XCreateBitmapFromData(dpy, gs_win, source, w, h);
+    Pixmap msk = XCreateBitmapFromData(dpy, gs_win, mask, w, h);
+    XColor black, white;
+    memset(&black, 0, sizeof(black));
+    memset(&white, 0, sizeof(white));
+    white.red = white.green = white.blue = 0xFFFF;
+    gs_set_cursor(dpy, XCreatePixmapCursor(dpy, src, msk, &black, &white, hotx, hoty));
+    XFreePixmap(dpy, src);
+    XFreePixmap(dpy, msk);
+    free(source);
+}
+
+// Change the window title as both latin-1 and UTF-8.
+void gs_set_title(long display, char *title) {
+    Display *dpy = (Display *)display;
+    if (gs_win == 0) {
+        return;
+    }
+    XStoreName(dpy, gs_win, title);
+    XChangeProperty(dpy, gs_win, gs_wm_name, gs_utf8, 8, PropModeReplace,
+        (unsigned char *)title, strlen(title));
+    XFlush(dpy);
+}
+
+// The window manager icon property holds the width, height, and then
+// the ARGB pixels, each as a long even when longs are 64 bits.
+void gs_set_icon(long display, unsigned char *rgba, long w, long h) {
+    Display *dpy = (Display *)display;
+    if (gs_win == 0) {
+        return;
+    }
+    unsigned long *icon = calloc(2 + w * h, sizeof(unsigned long));
+    if (icon == NULL) {
+        return;
+    }
+    icon[0] = w;
+    icon[1] = h;
+    long cnt;
+    for (cnt = 0; cnt < w * h; cnt++) {
+        unsigned char *p = &rgba[cnt * 4];
+        icon[2 + cnt] = (unsigned long)p[3] << 24 | p[0] << 16 | p[1] << 8 | p[2];
+    }
+    XChangeProperty(dpy, gs_win, gs_wm_icon, XA_CARDINAL, 32, PropModeReplace,
+        (unsigned char *)icon, 2 + w * h);
+    XFlush(dpy);
+    free(icon);
+}
+
+// Ask the window manager to mark the window as needing attention.
+// The window manager clears the state once the window is focused.
+void gs_attention(long display) {
+    Display *dpy = (Display *)display;
+    if (gs_win == 0) {
+        return;
+    }
+    XEvent ev;
+    memset(&ev, 0, sizeof(ev));
+    ev.type = ClientMessage;
+    ev.xclient.window = gs_win;
+    ev.xclient.message_type = gs_wm_state;
+    ev.xclient.format = 32;
+    ev.xclient.data.l[0] = 1; // _NET_WM_STATE_ADD
+    ev.xclient.data.l[1] = gs_wm_attention;
+    ev.xclient.data.l[3] = 1; // normal application.
+    XSendEvent(dpy, DefaultRootWindow(dpy), False, SubstructureNotifyMask | SubstructureRedirectMask, &ev);
+    XFlush(dpy);
+}
+
+// Move the cursor to the given window location.
+void gs_set_cursor_location(long display, long x, long y) {
+    Display *dpy = (Display *)display;
+    XWarpPointer(dpy, None, gs_win, 0, 0, 0, 0, x, gs_height - y);
+    XFlush(dpy);
+}
+
+// A locked cursor is hidden, grabbed, and kept at the window center.
+void gs_lock_cursor(long display, unsigned char lock) {
+    Display *dpy = (Display *)display;
+    if (lock == gs_cursor_locked) {
+        return;
+    }
+    gs_cursor_locked = lock;
+    gs_grab(dpy, lock);
+    gs_use_cursor(dpy);
+}
+
+// Create an OpenGL 3.3 core context, falling back to a legacy
+// context for drivers without GLX_ARB_create_context.
+typedef GLXContext (*gs_create_context)(Display *, GLXFBConfig, GLXContext, Bool, const int *);
+long gs_context(long display, long shell) {
+    Display *dpy = (Display *)display;
+    gs_create_context create = (gs_create_context)glXGetProcAddressARB(
+        (const GLubyte *)"glXCreateContextAttribsARB");
+    if (create != NULL) {
+        int attribs[] = {
+            GLX_CONTEXT_MAJOR_VERSION_ARB, 3,
+            GLX_CONTEXT_MINOR_VERSION_ARB, 3,
+            GLX_CONTEXT_PROFILE_MASK_ARB,  GLX_CONTEXT_CORE_PROFILE_BIT_ARB,
+            None
+        };
+        gs_ctx = create(dpy, gs_fbconfig, NULL, True, attribs);
+    }
+    if (gs_ctx == NULL) {
+        gs_ctx = glXCreateNewContext(dpy, gs_fbconfig, GLX_RGBA_TYPE, NULL, True);
+    }
+    if (gs_ctx == NULL) {
+        printf("Failed to create an OpenGL context.\n");
+        return 0;
+    }
+    glXMakeCurrent(dpy, (Window)shell, gs_ctx);
+    return (long)gs_ctx;
+}
+
+// Show the latest drawing.
+void gs_swap_buffers(long display) { glXSwapBuffers((Display *)display, gs_win); }
+
+// Only match the clipboard replies.
+static Bool gs_is_clip_reply(Display *dpy, XEvent *ev, XPointer arg) {
+    return ev->type == SelectionNotify && ev->xselection.selection == gs_clipboard;
+}
+
+// Ask the clipboard owner for its text and wait a short time for the reply.
+char* gs_clip_copy(long display) {
+    Display *dpy = (Display *)display;
+    Window owner = XGetSelectionOwner(dpy, gs_clipboard);
+    if (owner == None) {
+        return NULL;
+    }
+    if (owner == gs_win) {
+        return gs_clip != NULL ? strdup(gs_clip) : NULL;
+    }
+    XConvertSelection(dpy, gs_clipboard, gs_utf8, gs_property, gs_win, CurrentTime);
+    XFlush(dpy);
+    XEvent ev;
+    int waits;
+    for (waits = 0; waits < 100; waits++) {
+        if (XCheckIfEvent(dpy, &ev, gs_is_clip_reply, NULL)) {
+            break;
+        }
+        usleep(1000);
+    }
+    if (waits == 100 || ev.xselection.property == None) {
+        return NULL;
+    }
+    Atom type;
+    int format;
+    unsigned long count, remaining;
+    unsigned char *data = NULL;
+    char *text = NULL;
+    XGetWindowProperty(dpy, gs_win, gs_property, 0, 0x7FFFFFFF, True, AnyPropertyType,
+        &type, &format, &count, &remaining, &data);
+    if (data != NULL) {
+        text = strdup((char *)data);
+        XFree(data);
+    }
+    return text;
+}
+
+// Take ownership of the clipboard. The text is given to other
+// applications when they ask for it.
+void gs_clip_paste(long display, const char* string) {
+    Display *dpy = (Display *)display;
+    free(gs_clip);
+    gs_clip = strdup(string);
+    XSetSelectionOwner(dpy, gs_clipboard, gs_win, CurrentTime);
+    XFlush(dpy);
+}
+
+// Set long attributes. Only positive values are used.
+void gs_set_attr_l(long attr, long value) {
+    if (value <= 0) {
+        return;
+    }
+    switch (attr) {
+    case GS_ShellX:      defaults.gs_ShellX = value; break;
+    case GS_ShellY:      defaults.gs_ShellY = value; break;
+    case GS_ShellWidth:  defaults.gs_ShellWidth = value; break;
+    case GS_ShellHeight: defaults.gs_ShellHeight = value; break;
+    case GS_AlphaSize:   defaults.gs_AlphaSize = value; break;
+    case GS_DepthSize:   defaults.gs_DepthSize = value; break;
+    }
+}
+
+// Set string attributes.
+void gs_set_attr_s(long attr, char * value) {
+    if (value != NULL && attr == GS_AppName) {
+        snprintf(defaults.gs_AppName, sizeof(defaults.gs_AppName), "%s", value);
+    }
+}
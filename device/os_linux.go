@@ -0,0 +1,400 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build !android
+
+package device
+
+// The linux (X11) native layer. This wraps the c functions that wrap
+// the Xlib and GLX API's (where the real work is done).
+
+// // The following block is C code and cgo directvies.
+//
+// #cgo linux LDFLAGS: -lX11 -lGL
+//
+// #include <stdlib.h>
+// #include "os_linux.h"
+import "C" // must be located here.
+
+import (
+	"runtime"
+	"strings"
+	"unsafe"
+)
+
+// OS specific structure to differentiate it from the other native layers.
+type lnx struct {
+	gsu  *C.GSEvent
+	pads [MaxPads]C.GSPad // Matches GS_MaxPads.
+
+	typing C.GSText // Typed text from the last read.
+}
+
+// Keep all X and OpenGL calls on the same thread.
+func init() { runtime.LockOSThread() }
+
+// nativeLayer gets a reference to the native operating system. Each native
+// layer implements this factory method. Compiling will leave only the one that
+// matches the current platform.
+func nativeLayer() native { return &lnx{gsu: &C.GSEvent{}} }
+
+// Implement native interface.
+func (l *lnx) context(r *nrefs) int64 {
+	return int64(C.gs_context(C.long(r.display), C.long(r.shell)))
+}
+func (l *lnx) display() int64              { return int64(C.gs_display_init()) }
+func (l *lnx) displayDispose(r *nrefs)     { C.gs_display_dispose(C.long(r.display)) }
+func (l *lnx) shell(r *nrefs) int64        { return int64(C.gs_shell(C.long(r.display))) }
+func (l *lnx) shellOpen(r *nrefs)          { C.gs_shell_open(C.long(r.display), C.long(r.shell)) }
+func (l *lnx) shellAlive(r *nrefs) bool    { return uint(C.gs_shell_alive(C.long(r.shell))) == 1 }
+func (l *lnx) isFullscreen(r *nrefs) bool  { return uint(C.gs_fullscreen(C.long(r.display))) == 1 }
+func (l *lnx) toggleFullscreen(r *nrefs)   { C.gs_toggle_fullscreen(C.long(r.display)) }
+func (l *lnx) swapBuffers(r *nrefs)        { C.gs_swap_buffers(C.long(r.display)) }
+func (l *lnx) setAlphaBufferSize(size int) { C.gs_set_attr_l(C.GS_AlphaSize, C.long(size)) }
+func (l *lnx) setDepthBufferSize(size int) { C.gs_set_attr_l(C.GS_DepthSize, C.long(size)) }
+func (l *lnx) setCursorAt(r *nrefs, x, y int) {
+	C.gs_set_cursor_location(C.long(r.display), C.long(x), C.long(y))
+}
+func (l *lnx) showCursor(r *nrefs, show bool) {
+	tf1 := 0
+	if show {
+		tf1 = 1
+	}
+	C.gs_show_cursor(C.long(r.display), C.uchar(tf1))
+}
+func (l *lnx) interceptClose(r *nrefs, intercept bool) {
+	tf1 := 0
+	if intercept {
+		tf1 = 1
+	}
+	C.gs_intercept_close(C.uchar(tf1))
+}
+func (l *lnx) lockCursor(r *nrefs, lock bool) {
+	tf1 := 0
+	if lock {
+		tf1 = 1
+	}
+	C.gs_lock_cursor(C.long(r.display), C.uchar(tf1))
+}
+
+// Implement native interface.
+func (l *lnx) readDispatch(r *nrefs, in *userInput) *userInput {
+	l.gsu.event = 0
+	l.gsu.mousex = -1
+	l.gsu.mousey = -1
+	l.gsu.key = 0
+	l.gsu.mods = 0
+	l.gsu.scroll = 0
+	l.gsu.dx = 0
+	l.gsu.dy = 0
+	C.gs_read_dispatch(C.long(r.display), l.gsu)
+
+	// transfer/translate the native event into the input buffer.
+	in.id = events[int(l.gsu.event)]
+	if in.id != 0 {
+		in.button = mouseButtons[int(l.gsu.event)]
+		in.key = int(l.gsu.key)
+		in.scan = in.key // X key codes are already key locations.
+		in.scroll = int(l.gsu.scroll)
+	} else {
+		in.button, in.key, in.scan, in.scroll = 0, 0, 0, 0
+	}
+	in.mods = int(l.gsu.mods)
+	in.mouseX = int(l.gsu.mousex)
+	in.mouseY = int(l.gsu.mousey)
+	in.dx, in.dy = int(l.gsu.dx), int(l.gsu.dy)
+	return in
+}
+
+// Implement native interface. Joysticks are reported in their native
+// layout and identified using SDL style USB guids.
+func (l *lnx) gamepads(r *nrefs, pads []rawPad) {
+	C.gs_pads(&l.pads[0])
+	for cnt := range pads {
+		gp, pad := &l.pads[cnt], &pads[cnt]
+		if pad.connected = gp.connected == 1; !pad.connected {
+			continue
+		}
+		pad.name = C.GoString(&gp.name[0])
+		pad.guid = padGUID(usbBus, int(gp.vendor), int(gp.product), int(gp.version))
+		pad.buttons, pad.axes, pad.hats = pad.buttons[:0], pad.axes[:0], pad.hats[:0]
+		for b := 0; b < int(gp.nbuttons); b++ {
+			pad.buttons = append(pad.buttons, gp.buttons[b] != 0)
+		}
+		for a := 0; a < int(gp.naxes); a++ {
+			pad.axes = append(pad.axes, float64(gp.axes[a]))
+		}
+	}
+}
+
+// Implement native interface. Rumble uses the force feedback event
+// device of the joystick, when it has one.
+func (l *lnx) rumble(r *nrefs, pad int, low, high float64) {
+	C.gs_rumble(C.long(pad), C.float(low), C.float(high))
+}
+
+// Implement native interface. Touch screens are not supported.
+func (l *lnx) touches(r *nrefs, touches []Touch) []Touch { return touches }
+
+// Implement native interface.
+func (l *lnx) monitors(r *nrefs) []Monitor {
+	gms := make([]C.GSMonitor, maxMonitors)
+	count := int(C.gs_monitors(C.long(r.display), &gms[0]))
+	monitors := make([]Monitor, count)
+	for cnt := range monitors {
+		gm, m := &gms[cnt], &monitors[cnt]
+		m.Name, m.Primary = C.GoString(&gm.name[0]), gm.primary == 1
+		m.X, m.Y, m.W, m.H = int(gm.x), int(gm.y), int(gm.w), int(gm.h)
+		for mode := 0; mode < int(gm.nmodes); mode++ {
+			dm := &gm.modes[mode]
+			m.Modes = append(m.Modes, Mode{W: int(dm.w), H: int(dm.h), Refresh: int(dm.refresh)})
+		}
+	}
+	return monitors
+}
+
+// maxMonitors matches GS_MaxMonitors.
+const maxMonitors = 8
+
+// Implement native interface.
+func (l *lnx) setWindowMode(r *nrefs, mode, monitor int, m Mode) {
+	C.gs_set_window_mode(C.long(r.display), C.long(mode), C.long(monitor), C.long(m.W), C.long(m.H), C.long(m.Refresh))
+}
+
+// Implement native interface.
+func (l *lnx) text(r *nrefs) (typed, composing string) {
+	C.gs_text(&l.typing)
+	return C.GoString(&l.typing.typed[0]), C.GoString(&l.typing.composing[0])
+}
+
+// Implement native interface.
+func (l *lnx) size(r *nrefs) (x, y, w, h int) {
+	var winx, winy, width, height C.long
+	C.gs_size(C.long(r.display), &winx, &winy, &width, &height)
+	return int(winx), int(winy), int(width), int(height)
+}
+
+// Implement native interface. X windows are pixel sized.
+func (l *lnx) pixelSize(r *nrefs) (w, h int) {
+	_, _, w, h = l.size(r)
+	return w, h
+}
+
+// Implement native interface.
+func (l *lnx) scale(r *nrefs) float64 { return float64(C.gs_scale(C.long(r.display))) }
+
+// Implement native interface.
+func (l *lnx) setSize(x, y, width, height int) {
+	C.gs_set_attr_l(C.GS_ShellX, C.long(x))
+	C.gs_set_attr_l(C.GS_ShellY, C.long(y))
+	C.gs_set_attr_l(C.GS_ShellWidth, C.long(width))
+	C.gs_set_attr_l(C.GS_ShellHeight, C.long(height))
+}
+
+// Implement native interface.
+func (l *lnx) setTitle(title string) {
+	cstr := C.CString(title)
+	defer C.free(unsafe.Pointer(cstr))
+	C.gs_set_attr_s(C.GS_AppName, cstr)
+}
+
+// Implement native interface.
+func (l *lnx) retitle(r *nrefs, title string) {
+	cstr := C.CString(title)
+	defer C.free(unsafe.Pointer(cstr))
+	C.gs_set_title(C.long(r.display), cstr)
+}
+
+// Implement native interface.
+func (l *lnx) icon(r *nrefs, pixels []byte, w, h int) {
+	C.gs_set_icon(C.long(r.display), (*C.uchar)(&pixels[0]), C.long(w), C.long(h))
+}
+func (l *lnx) attention(r *nrefs) { C.gs_attention(C.long(r.display)) }
+
+// Implement native interface. There is no standard X taskbar progress.
+func (l *lnx) progress(r *nrefs, p float64) {}
+
+// Implement native interface.
+func (l *lnx) cursorShape(r *nrefs, shape int) { C.gs_cursor_shape(C.long(r.display), C.long(shape)) }
+
+// Implement native interface.
+func (l *lnx) cursorImage(r *nrefs, pixels []byte, w, h, hotX, hotY int) {
+	C.gs_cursor_image(C.long(r.display), (*C.uchar)(&pixels[0]), C.long(w), C.long(h), C.long(hotX), C.long(hotY))
+}
+
+// Implement native interface: nrefs unused, needed by other platforms.
+func (l *lnx) dropped(r *nrefs) []string {
+	if cstr := C.gs_dropped(); cstr != nil {
+		paths := C.GoString(cstr)    // make a Go copy.
+		C.free(unsafe.Pointer(cstr)) // free the C copy.
+		return strings.Split(strings.TrimSuffix(paths, "\n"), "\n")
+	}
+	return nil
+}
+
+// Implement native interface.
+func (l *lnx) copyClip(r *nrefs) string {
+	if cstr := C.gs_clip_copy(C.long(r.display)); cstr != nil {
+		str := C.GoString(cstr)      // make a Go copy.
+		C.free(unsafe.Pointer(cstr)) // free the C copy.
+		return str
+	}
+	return ""
+}
+
+// Implement native interface.
+func (l *lnx) pasteClip(r *nrefs, s string) {
+	cstr := C.CString(s)
+	defer C.free(unsafe.Pointer(cstr))
+	C.gs_clip_paste(C.long(r.display), cstr)
+}
+
+// Transform os specific events to user events.
+var events = map[int]int{
+	C.GS_LeftMouseDown:     clickedMouse,
+	C.GS_RightMouseDown:    clickedMouse,
+	C.GS_OtherMouseDown:    clickedMouse,
+	C.GS_LeftMouseUp:       releasedMouse,
+	C.GS_RightMouseUp:      releasedMouse,
+	C.GS_OtherMouseUp:      releasedMouse,
+	C.GS_MouseMoved:        movedMouse,
+	C.GS_KeyDown:           pressedKey,
+	C.GS_KeyUp:             releasedKey,
+	C.GS_ScrollWheel:       scrolled,
+	C.GS_WindowResized:     resizedShell,
+	C.GS_WindowMoved:       movedShell,
+	C.GS_WindowIconified:   iconifiedShell,
+	C.GS_WindowUniconified: uniconifiedShell,
+	C.GS_WindowActive:      activatedShell,
+	C.GS_WindowInactive:    deactivatedShell,
+	C.GS_WindowClosing:     closingShell,
+}
+
+// Map the mice buttons into left and right.
+var mouseButtons = map[int]int{
+	C.GS_LeftMouseDown:  mouseLeft,
+	C.GS_RightMouseDown: mouseRight,
+	C.GS_OtherMouseDown: mouseMiddle,
+	C.GS_LeftMouseUp:    mouseLeft,
+	C.GS_RightMouseUp:   mouseRight,
+	C.GS_OtherMouseUp:   mouseMiddle,
+}
+
+// Expose the underlying X key modifier masks.
+const (
+	shiftKeyMask    = C.GS_ShiftKeyMask
+	controlKeyMask  = C.GS_ControlKeyMask
+	functionKeyMask = C.GS_FunctionKeyMask
+	commandKeyMask  = C.GS_CommandKeyMask
+	altKeyMask      = C.GS_AlternateKeyMask
+)
+
+// Expose the underlying X key codes as generic code.
+// Each native layer is expected to support the generic codes.
+//
+// X key codes are the linux evdev key codes plus 8. They are the
+// location of the key on a US keyboard regardless of the keyboard layout.
+const (
+	key0              = 0x13  // KEY_0
+	key1              = 0x0A  // KEY_1
+	key2              = 0x0B  // KEY_2
+	key3              = 0x0C  // KEY_3
+	key4              = 0x0D  // KEY_4
+	key5              = 0x0E  // KEY_5
+	key6              = 0x0F  // KEY_6
+	key7              = 0x10  // KEY_7
+	key8              = 0x11  // KEY_8
+	key9              = 0x12  // KEY_9
+	keyA              = 0x26  // KEY_A
+	keyB              = 0x38  // KEY_B
+	keyC              = 0x36  // KEY_C
+	keyD              = 0x28  // KEY_D
+	keyE              = 0x1A  // KEY_E
+	keyF              = 0x29  // KEY_F
+	keyG              = 0x2A  // KEY_G
+	keyH              = 0x2B  // KEY_H
+	keyI              = 0x1F  // KEY_I
+	keyJ              = 0x2C  // KEY_J
+	keyK              = 0x2D  // KEY_K
+	keyL              = 0x2E  // KEY_L
+	keyM              = 0x3A  // KEY_M
+	keyN              = 0x39  // KEY_N
+	keyO              = 0x20  // KEY_O
+	keyP              = 0x21  // KEY_P
+	keyQ              = 0x18  // KEY_Q
+	keyR              = 0x1B  // KEY_R
+	keyS              = 0x27  // KEY_S
+	keyT              = 0x1C  // KEY_T
+	keyU              = 0x1E  // KEY_U
+	keyV              = 0x37  // KEY_V
+	keyW              = 0x19  // KEY_W
+	keyX              = 0x35  // KEY_X
+	keyY              = 0x1D  // KEY_Y
+	keyZ              = 0x34  // KEY_Z
+	keyF1             = 0x43  // KEY_F1
+	keyF2             = 0x44  // KEY_F2
+	keyF3             = 0x45  // KEY_F3
+	keyF4             = 0x46  // KEY_F4
+	keyF5             = 0x47  // KEY_F5
+	keyF6             = 0x48  // KEY_F6
+	keyF7             = 0x49  // KEY_F7
+	keyF8             = 0x4A  // KEY_F8
+	keyF9             = 0x4B  // KEY_F9
+	keyF10            = 0x4C  // KEY_F10
+	keyF11            = 0x5F  // KEY_F11
+	keyF12            = 0x60  // KEY_F12
+	keyF13            = 0xBF  // KEY_F13
+	keyF14            = 0xC0  // KEY_F14
+	keyF15            = 0xC1  // KEY_F15
+	keyF16            = 0xC2  // KEY_F16
+	keyF17            = 0xC3  // KEY_F17
+	keyF18            = 0xC4  // KEY_F18
+	keyF19            = 0xC5  // KEY_F19
+	keyF20            = 0xC6  // KEY_F20
+	keyKeypad0        = 0x5A  // KEY_KP0
+	keyKeypad1        = 0x57  // KEY_KP1
+	keyKeypad2        = 0x58  // KEY_KP2
+	keyKeypad3        = 0x59  // KEY_KP3
+	keyKeypad4        = 0x53  // KEY_KP4
+	keyKeypad5        = 0x54  // KEY_KP5
+	keyKeypad6        = 0x55  // KEY_KP6
+	keyKeypad7        = 0x4F  // KEY_KP7
+	keyKeypad8        = 0x50  // KEY_KP8
+	keyKeypad9        = 0x51  // KEY_KP9
+	keyKeypadDecimal  = 0x5B  // KEY_KPDOT
+	keyKeypadMultiply = 0x3F  // KEY_KPASTERISK
+	keyKeypadPlus     = 0x56  // KEY_KPPLUS
+	keyKeypadClear    = 0x4D  // KEY_NUMLOCK
+	keyKeypadDivide   = 0x6A  // KEY_KPSLASH
+	keyKeypadEnter    = 0x68  // KEY_KPENTER
+	keyKeypadMinus    = 0x52  // KEY_KPMINUS
+	keyKeypadEquals   = 0x7D  // KEY_KPEQUAL
+	keyEqual          = 0x15  // KEY_EQUAL
+	keyMinus          = 0x14  // KEY_MINUS
+	keyLeftBracket    = 0x22  // KEY_LEFTBRACE
+	keyRightBracket   = 0x23  // KEY_RIGHTBRACE
+	keyQuote          = 0x30  // KEY_APOSTROPHE
+	keySemicolon      = 0x2F  // KEY_SEMICOLON
+	keyBackslash      = 0x33  // KEY_BACKSLASH
+	keyGrave          = 0x31  // KEY_GRAVE
+	keySlash          = 0x3D  // KEY_SLASH
+	keyComma          = 0x3B  // KEY_COMMA
+	keyPeriod         = 0x3C  // KEY_DOT
+	keyReturn         = 0x24  // KEY_ENTER
+	keyTab            = 0x17  // KEY_TAB
+	keySpace          = 0x41  // KEY_SPACE
+	keyDelete         = 0x16  // KEY_BACKSPACE
+	keyForwardDelete  = 0x77  // KEY_DELETE
+	keyEscape         = 0x09  // KEY_ESC
+	keyHome           = 0x6E  // KEY_HOME
+	keyPageUp         = 0x70  // KEY_PAGEUP
+	keyPageDown       = 0x75  // KEY_PAGEDOWN
+	keyLeftArrow      = 0x71  // KEY_LEFT
+	keyRightArrow     = 0x72  // KEY_RIGHT
+	keyDownArrow      = 0x74  // KEY_DOWN
+	keyUpArrow        = 0x6F  // KEY_UP
+	keyEnd            = 0x73  // KEY_END
+	mouseLeft         = 0x1F1 // Button1 (tack on values past keys and gamepad buttons)
+	mouseMiddle       = 0x1F2 // Button2
+	mouseRight        = 0x1F3 // Button3
+)
@@ -0,0 +1,245 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// Linux support to get a window with an OpenGL graphic context.
+// This uses X11 (Xlib) and GLX which also run on Wayland desktops
+// through XWayland.
+//
+// Maintenance Notes:
+// The design is to wrap Xlib functionality in C syntax methods so a simple
+// binding layer can be created. Design goals include:
+//    - minimize state, passing in needed information where possible.
+//    - keep in/out parameter types to the basic C types.
+//    - minimize the number of calls.
+//    - use reasonable defaults where possible.
+//    - duplicate enums where necessary so that no extra includes are needed.
+
+#ifndef os_linux_h
+#define os_linux_h
+
+// Used to pass back user input each polling call.
+typedef struct {
+    long event;   // the user event. Zero if nothing is happening.
+    long mousex;  // current mouse position is always filled in.
+    long mousey;  // current mouse position is always filled in.
+    long key;     // which key, or mouse button was affected, if any.
+    long mods;    // which modifier keys are currently pressed, if any.
+    long scroll;  // the scroll amount if any.
+    long dx;      // mouse movement while the cursor is locked.
+    long dy;      // mouse movement while the cursor is locked.
+} GSEvent;
+
+// Used to pass back the attached game controllers each polling call.
+// Buttons and axes are in the native controller order. The linux
+// joystick interface reports hats as axes.
+#define GS_MaxPads    4
+#define GS_PadButtons 32
+#define GS_PadAxes    16
+typedef struct {
+    long  connected;               // 1 while the controller is attached.
+    long  vendor;                  // USB vendor id.
+    long  product;                 // USB product id.
+    long  version;                 // Controller version number.
+    char  name[64];                // Controller product name.
+    long  nbuttons;                // Number of buttons.
+    long  naxes;                   // Number of axes.
+    long  buttons[GS_PadButtons];  // 1 if the button is pressed.
+    float axes[GS_PadAxes];        // Axis values from -1 to 1.
+} GSPad;
+
+// Used to pass back the typed text each polling call. Strings are UTF-8
+// and are dropped if they do not fit.
+#define GS_TextSize 256
+typedef struct {
+    char typed[GS_TextSize];     // text typed since the last call.
+    char composing[GS_TextSize]; // unfinished text from dead keys or an IME.
+} GSText;
+
+// Cursor shapes used with gs_cursor_shape.
+// The shapes match the device package cursor shapes.
+#define GS_ArrowCursor     0
+#define GS_HandCursor      1
+#define GS_IBeamCursor     2
+#define GS_CrosshairCursor 3
+#define GS_ResizeXCursor   4
+#define GS_ResizeYCursor   5
+
+// Used to pass back the attached monitors and their display modes.
+// The window modes match the device package window modes.
+#define GS_MaxMonitors 8
+#define GS_MaxModes    128
+#define GS_Windowed    0
+#define GS_Borderless  1
+#define GS_Exclusive   2
+typedef struct {
+    long w;       // width in pixels.
+    long h;       // height in pixels.
+    long refresh; // refresh rate in hertz, 0 if unknown.
+} GSMode;
+typedef struct {
+    char   name[64];           // display name.
+    long   x, y, w, h;         // desktop location relative to the bottom left.
+    long   primary;            // 1 for the main display.
+    long   nmodes;             // number of display modes.
+    GSMode modes[GS_MaxModes]; // supported display modes.
+} GSMonitor;
+
+// Connect to the X server. Returns a reference to the X display.
+long gs_display_init();
+
+// Cleans and releases all resources including the OpenGL context.
+void gs_display_dispose(long display);
+
+// Creates the window (shell) on the given display.
+// Returns a reference to the shell.
+long gs_shell(long display);
+
+// Opens the window (shell) on the given display.
+void gs_shell_open(long display, long shell);
+
+// Used to check for the user quitting the application.
+// Return 1 as long as the user hasn't closed the window.
+unsigned char gs_shell_alive(long shell);
+
+// Used to check if the application is full screen mode.
+// Return 1 if the application is full screen, 0 otherwise.
+unsigned char gs_fullscreen(long display);
+
+// Flip full screen mode. Must be called after starting processing
+// of events with gs_read_dispatch().
+void gs_toggle_fullscreen(long display);
+
+// Get the attached monitors. Returns the number of monitors.
+long gs_monitors(long display, GSMonitor *monitors);
+
+// Switch to one of the GS_Windowed, GS_Borderless, or GS_Exclusive window
+// modes on the given monitor, -1 for the current monitor. The display mode
+// w, h, refresh is only used for GS_Exclusive.
+void gs_set_window_mode(long display, long mode, long monitor, long w, long h, long refresh);
+
+// Process a user event. This must be called inside an event loop in order
+// for the application to work. The event is also processed to determine
+// window events.
+void gs_read_dispatch(long display, GSEvent *gs_urge);
+
+// Get the state of the attached game controllers. Fills GS_MaxPads
+// controllers. Controllers keep their slot until they are detached.
+void gs_pads(GSPad *pads);
+
+// Run the low and high frequency motors of the controller in the given
+// slot using its force feedback event device. Magnitudes range from 0
+// to 1 where 0 stops the motor. Ignored if the device can't be opened.
+void gs_rumble(long pad, float low, float high);
+
+// Get the text typed since the last call. Text is collected from the
+// key press events using the X input method.
+void gs_text(GSText *text);
+
+// Report GS_WindowClosing events instead of closing the window
+// when the user asks to close the window.
+void gs_intercept_close(unsigned char intercept);
+
+// Get the paths of the files dropped on the window since the last call.
+// Paths are separated by newlines. Returns NULL if no files were dropped,
+// otherwise the returned string must be freed by the caller.
+char* gs_dropped();
+
+// Get the current main window drawing area size.
+void gs_size(long display, long *x, long *y, long *w, long *h);
+
+// Get the main window content scale, the desktop dots per inch
+// relative to the standard 96 dots per inch.
+float gs_scale(long display);
+
+// Show or hide cursor. Lock it to the window if it is hidden.
+void gs_show_cursor(long display, unsigned char show);
+
+// Use one of the system cursor shapes while the cursor is over the window.
+void gs_cursor_shape(long display, long shape);
+
+// Use the w by h pixel RGBA image as the cursor while it is over the window.
+// The hot spot is the image pixel, from the top left, that is the cursor location.
+void gs_cursor_image(long display, unsigned char *rgba, long w, long h, long hotx, long hoty);
+
+// Change the window title after the window has been created.
+void gs_set_title(long display, char *title);
+
+// Use the w by h pixel RGBA image as the window icon.
+void gs_set_icon(long display, unsigned char *rgba, long w, long h);
+
+// Mark the window as needing attention, ie: for the taskbar to
+// highlight the window until the window is focused.
+void gs_attention(long display);
+
+// Set the cursor location to the given window coordinates.
+void gs_set_cursor_location(long display, long x, long y);
+
+// Lock or unlock the cursor. A locked cursor is hidden and does not move.
+// Mouse movement is reported in the GSEvent dx, dy fields instead.
+void gs_lock_cursor(long display, unsigned char lock);
+
+// Create an OpenGL context using the given shell. Returns 0 if a
+// rendering context could not be created.
+long gs_context(long display, long shell);
+
+// Flip the front and back rendering buffers. This is expected to be called
+// each pass through the event loop to display the most recent drawing.
+void gs_swap_buffers(long display);
+
+// Copy and paste strings to and from the clipboard.
+// Strings returned by copy must be freed by the caller.
+char* gs_clip_copy(long display);
+void gs_clip_paste(long display, const char* string);
+
+// Customize the window and context by setting attributes before the
+// display or context is initialized.
+void gs_set_attr_l(long attr, long value);
+void gs_set_attr_s(long attr, char * value);
+
+// Used in the provided setter functions to set one or more of the
+// following attributes.
+enum AppAttributes
+{
+    GS_AppName,     // Text("App")
+    GS_ShellX,      // 100
+    GS_ShellY,      // 100
+    GS_ShellWidth,  // 640
+    GS_ShellHeight, // 480
+    GS_AlphaSize,   //  8
+    GS_DepthSize    // 24
+};
+
+// Possible return values from gs_read_dispatch. The values
+// are unique to this layer and do not match the X event types.
+enum {
+    GS_LeftMouseDown     = 1,
+    GS_LeftMouseUp       = 2,
+    GS_RightMouseDown    = 3,
+    GS_RightMouseUp      = 4,
+    GS_MouseMoved        = 5,
+    GS_KeyDown           = 10,
+    GS_KeyUp             = 11,
+    GS_ScrollWheel       = 22,
+    GS_OtherMouseDown    = 25,
+    GS_OtherMouseUp      = 26,
+    GS_WindowResized     = 50,
+    GS_WindowMoved       = 51,
+    GS_WindowIconified   = 52,
+    GS_WindowUniconified = 53,
+    GS_WindowActive      = 54,
+    GS_WindowInactive    = 55,
+    GS_WindowClosing     = 56
+};
+
+// Provide key modifier bit masks. All currently pressed modifier
+// keys come back combined into one bitmask value. The masks are
+// larger than the X key codes so they don't conflict with keys.
+enum {
+    GS_ShiftKeyMask     = 1 << 17, // ShiftMask
+    GS_ControlKeyMask   = 1 << 18, // ControlMask
+    GS_AlternateKeyMask = 1 << 19, // Mod1Mask
+    GS_CommandKeyMask   = 1 << 20, // Mod4Mask
+    GS_FunctionKeyMask  = 1 << 23, // Unused
+};
+
+#endif
@@ -0,0 +1,219 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build android ios
+
+package device
+
+// The mobile native layer. This wraps the c functions that wrap the
+// android NativeActivity and EGL, or the iOS UIKit and OpenGL ES API's
+// (where the real work is done).
+//
+// Mobile applications are started by the operating system, so the Go
+// application is built as a library: -buildmode=c-shared for android
+// and -buildmode=c-archive for iOS. The native layer runs the Go
+// application main on its own thread once the application has started.
+
+// // The following block is C code and cgo directvies.
+//
+// #cgo android LDFLAGS: -landroid -lEGL -lGLESv3 -llog
+// #cgo ios     LDFLAGS: -framework UIKit -framework OpenGLES -framework QuartzCore -framework Foundation
+//
+// #include <stdlib.h>
+// #include "os_mobile.h"
+import "C" // must be located here.
+
+import (
+	"runtime"
+	_ "unsafe" // needed for go:linkname.
+)
+
+// OS specific structure to differentiate it from the other native layers.
+type mob struct {
+	gsu *C.GSEvent
+
+	typing  C.GSText              // Typed text from the last read.
+	touched [maxTouches]C.GSTouch // Touches from the last read.
+}
+
+// maxTouches matches GS_MaxTouches.
+const maxTouches = 10
+
+// Keep all EGL and OpenGL ES calls on the same thread.
+func init() { runtime.LockOSThread() }
+
+// mainMain is the application main. Libraries don't run main.main,
+// so the native layer starts it using gs_go_main.
+//
+//go:linkname mainMain main.main
+func mainMain()
+
+//export gs_go_main
+func gs_go_main() { mainMain() }
+
+// nativeLayer gets a reference to the native operating system. Each native
+// layer implements this factory method. Compiling will leave only the one that
+// matches the current platform.
+func nativeLayer() native { return &mob{gsu: &C.GSEvent{}} }
+
+// Implement native interface.
+func (m *mob) context(r *nrefs) int64 {
+	return int64(C.gs_context(C.long(r.display), C.long(r.shell)))
+}
+func (m *mob) display() int64              { return int64(C.gs_display_init()) }
+func (m *mob) displayDispose(r *nrefs)     { C.gs_display_dispose(C.long(r.display)) }
+func (m *mob) shell(r *nrefs) int64        { return int64(C.gs_shell(C.long(r.display))) }
+func (m *mob) shellOpen(r *nrefs)          {} // Mobile windows are always open.
+func (m *mob) shellAlive(r *nrefs) bool    { return uint(C.gs_shell_alive(C.long(r.shell))) == 1 }
+func (m *mob) isFullscreen(r *nrefs) bool  { return true } // Mobile windows are always full screen.
+func (m *mob) toggleFullscreen(r *nrefs)   {}
+func (m *mob) swapBuffers(r *nrefs)        { C.gs_swap_buffers(C.long(r.display)) }
+func (m *mob) setAlphaBufferSize(size int) { C.gs_set_attr_l(C.GS_AlphaSize, C.long(size)) }
+func (m *mob) setDepthBufferSize(size int) { C.gs_set_attr_l(C.GS_DepthSize, C.long(size)) }
+
+// Implement native interface. There is no cursor on a touch screen.
+func (m *mob) setCursorAt(r *nrefs, x, y int)                            {}
+func (m *mob) showCursor(r *nrefs, show bool)                            {}
+func (m *mob) lockCursor(r *nrefs, lock bool)                            {}
+func (m *mob) cursorShape(r *nrefs, shape int)                           {}
+func (m *mob) cursorImage(r *nrefs, pixels []byte, w, h, hotX, hotY int) {}
+
+// Implement native interface. Closing is the android back button.
+func (m *mob) interceptClose(r *nrefs, intercept bool) {
+	tf1 := 0
+	if intercept {
+		tf1 = 1
+	}
+	C.gs_intercept_close(C.uchar(tf1))
+}
+
+// Implement native interface.
+func (m *mob) readDispatch(r *nrefs, in *userInput) *userInput {
+	m.gsu.event = 0
+	m.gsu.mousex = -1
+	m.gsu.mousey = -1
+	m.gsu.key = 0
+	m.gsu.mods = 0
+	m.gsu.scroll = 0
+	m.gsu.dx = 0
+	m.gsu.dy = 0
+	C.gs_read_dispatch(C.long(r.display), m.gsu)
+
+	// transfer/translate the native event into the input buffer.
+	in.id = events[int(m.gsu.event)]
+	if in.id != 0 {
+		in.button = mouseButtons[int(m.gsu.event)]
+		in.key = int(m.gsu.key)
+		in.scan = in.key // Mobile key codes don't depend on the keyboard layout.
+		in.scroll = int(m.gsu.scroll)
+	} else {
+		in.button, in.key, in.scan, in.scroll = 0, 0, 0, 0
+	}
+	in.mods = int(m.gsu.mods)
+	in.mouseX = int(m.gsu.mousex)
+	in.mouseY = int(m.gsu.mousey)
+	in.dx, in.dy = int(m.gsu.dx), int(m.gsu.dy)
+	return in
+}
+
+// Implement native interface. Gamepads are not yet supported.
+func (m *mob) gamepads(r *nrefs, pads []rawPad) {
+	for cnt := range pads {
+		pads[cnt].connected = false
+	}
+}
+
+// Implement native interface. Rumble needs gamepads.
+func (m *mob) rumble(r *nrefs, pad int, low, high float64) {}
+
+// Implement native interface. The native touch phases match the
+// Touch phase constants.
+func (m *mob) touches(r *nrefs, touches []Touch) []Touch {
+	count := int(C.gs_touches(&m.touched[0]))
+	for cnt := 0; cnt < count; cnt++ {
+		t := &m.touched[cnt]
+		touches = append(touches, Touch{ID: int(t.id), Phase: int(t.phase), X: float64(t.x), Y: float64(t.y)})
+	}
+	return touches
+}
+
+// Implement native interface. The application window fills
+// the one screen.
+func (m *mob) monitors(r *nrefs) []Monitor {
+	w, h := m.pixelSize(r)
+	return []Monitor{{Name: "screen", Primary: true, W: w, H: h}}
+}
+
+// Implement native interface. Mobile windows are always full screen.
+func (m *mob) setWindowMode(r *nrefs, mode, monitor int, md Mode) {}
+
+// Implement native interface.
+func (m *mob) text(r *nrefs) (typed, composing string) {
+	C.gs_text(&m.typing)
+	return C.GoString(&m.typing.typed[0]), C.GoString(&m.typing.composing[0])
+}
+
+// Implement native interface.
+func (m *mob) size(r *nrefs) (x, y, w, h int) {
+	var winx, winy, width, height C.long
+	C.gs_size(C.long(r.display), &winx, &winy, &width, &height)
+	return int(winx), int(winy), int(width), int(height)
+}
+
+// Implement native interface.
+func (m *mob) pixelSize(r *nrefs) (w, h int) {
+	var width, height C.long
+	C.gs_pixel_size(C.long(r.display), &width, &height)
+	return int(width), int(height)
+}
+
+// Implement native interface.
+func (m *mob) scale(r *nrefs) float64 { return float64(C.gs_scale(C.long(r.display))) }
+
+// Implement native interface. The operating system decides
+// the window size and there is no window title.
+func (m *mob) setSize(x, y, width, height int) {}
+func (m *mob) setTitle(title string)           {}
+
+// Implement native interface. The application icon and name are
+// part of the application package, and there is no taskbar.
+func (m *mob) retitle(r *nrefs, title string)         {}
+func (m *mob) icon(r *nrefs, pixels []byte, w, h int) {}
+func (m *mob) attention(r *nrefs)                     {}
+func (m *mob) progress(r *nrefs, p float64)           {}
+
+// Implement native interface. There is no file dropping
+// or clipboard access.
+func (m *mob) dropped(r *nrefs) []string    { return nil }
+func (m *mob) copyClip(r *nrefs) string     { return "" }
+func (m *mob) pasteClip(r *nrefs, s string) {}
+
+// Transform os specific events to user events.
+var events = map[int]int{
+	C.GS_LeftMouseDown:     clickedMouse,
+	C.GS_LeftMouseUp:       releasedMouse,
+	C.GS_MouseMoved:        movedMouse,
+	C.GS_KeyDown:           pressedKey,
+	C.GS_KeyUp:             releasedKey,
+	C.GS_WindowResized:     resizedShell,
+	C.GS_WindowIconified:   iconifiedShell,
+	C.GS_WindowUniconified: uniconifiedShell,
+	C.GS_WindowActive:      activatedShell,
+	C.GS_WindowInactive:    deactivatedShell,
+	C.GS_WindowClosing:     closingShell,
+}
+
+// The primary touch is reported as the left mouse button.
+var mouseButtons = map[int]int{
+	C.GS_LeftMouseDown: mouseLeft,
+	C.GS_LeftMouseUp:   mouseLeft,
+}
+
+// Expose the underlying key modifier masks.
+const (
+	shiftKeyMask    = C.GS_ShiftKeyMask
+	controlKeyMask  = C.GS_ControlKeyMask
+	functionKeyMask = C.GS_FunctionKeyMask
+	commandKeyMask  = C.GS_CommandKeyMask
+	altKeyMask      = C.GS_AlternateKeyMask
+)
@@ -0,0 +1,146 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+#ifndef os_mobile_h
+#define os_mobile_h
+
+// os_mobile.h defines the method calls needed by the os_mobile.go native
+// layer. It is implemented by os_android.c using EGL and OpenGL ES and
+// by os_ios.m using UIKit and OpenGL ES.
+//
+// Mobile applications do not own the main thread. The native layer starts
+// and calls the application main, see gs_go_main, on its own thread once
+// the operating system has started the application. The application
+// then uses the native layer from that thread like the desktop layers.
+
+// Used to pass back user input each polling call.
+typedef struct {
+    long event;   // the user event. Zero if nothing is happening.
+    long mousex;  // current primary touch location is always filled in.
+    long mousey;  // current primary touch location is always filled in.
+    long key;     // which key, or mouse button was affected, if any.
+    long mods;    // which modifier keys are currently pressed, if any.
+    long scroll;  // the scroll amount if any.
+    long dx;      // unused: the cursor can't be locked.
+    long dy;      // unused: the cursor can't be locked.
+} GSEvent;
+
+// Used to pass back the touch screen touches each polling call.
+// The phases match the device package Touch phases.
+#define GS_MaxTouches 10
+#define GS_TouchBegan 0
+#define GS_TouchMoved 1
+#define GS_TouchEnded 2
+typedef struct {
+    long  id;    // identifies the touch while it is down.
+    long  phase; // one of the GS_Touch phases.
+    float x;     // window location relative to the bottom left corner.
+    float y;     // window location relative to the bottom left corner.
+} GSTouch;
+
+// Used to pass back the typed text each polling call. Strings are UTF-8
+// and are dropped if they do not fit.
+#define GS_TextSize 256
+typedef struct {
+    char typed[GS_TextSize];     // text typed since the last call.
+    char composing[GS_TextSize]; // unused: always empty.
+} GSText;
+
+// Prepare the graphics display. Returns 0 if there is no graphics display.
+long gs_display_init();
+
+// Cleans and releases all resources including the OpenGL ES context.
+void gs_display_dispose(long display);
+
+// Wait for the operating system to provide the application window.
+// Returns a reference to the window, 0 if the application was stopped first.
+long gs_shell(long display);
+
+// Used to check for the operating system stopping the application.
+// Return 1 as long as the application is running.
+unsigned char gs_shell_alive(long shell);
+
+// Process a user or application lifecycle event. This must be called
+// inside an event loop in order for the application to work. Moving the
+// application to the background is reported as iconified and returning
+// to the foreground as uniconified.
+void gs_read_dispatch(long display, GSEvent *gs_urge);
+
+// Get the touches that are down or ended since the last call.
+// Returns the number of touches.
+long gs_touches(GSTouch *touches);
+
+// Get the text typed on a hardware keyboard since the last call.
+void gs_text(GSText *text);
+
+// Report GS_WindowClosing events instead of stopping the application when
+// the user presses the back button. Has no effect on iOS.
+void gs_intercept_close(unsigned char intercept);
+
+// Get the current window size. Mobile windows always fill the
+// screen, so the location is always 0, 0.
+void gs_size(long display, long *x, long *y, long *w, long *h);
+
+// Get the current window drawing area size in pixels. This is larger
+// than the window size on iOS where windows are sized in points.
+void gs_pixel_size(long display, long *w, long *h);
+
+// Get the number of drawing pixels for each window unit, or for
+// android the screen density relative to 160 dots per inch.
+float gs_scale(long display);
+
+// Create an OpenGL ES 3 context for the given window. Returns 0 if a
+// rendering context could not be created.
+long gs_context(long display, long shell);
+
+// Flip the front and back rendering buffers. Nothing is drawn while
+// the application is in the background.
+void gs_swap_buffers(long display);
+
+// Customize the context by setting attributes before the context is created.
+void gs_set_attr_l(long attr, long value);
+
+// Called by the native layer to start the application main, see os_mobile.go.
+extern void gs_go_main();
+
+#ifdef __APPLE__
+// Start the iOS application. Called from the iOS application main instead
+// of UIApplicationMain. Does not return.
+int gs_ios_main(int argc, char *argv[]);
+#endif
+
+// Used in the provided setter functions to set one or more of the
+// following attributes.
+enum AppAttributes
+{
+    GS_AlphaSize,   //  8
+    GS_DepthSize    // 24
+};
+
+// Possible return values from gs_read_dispatch.
+enum {
+    GS_LeftMouseDown     = 1,  // primary touch began.
+    GS_LeftMouseUp       = 2,  // primary touch ended.
+    GS_MouseMoved        = 5,  // primary touch moved.
+    GS_KeyDown           = 10,
+    GS_KeyUp             = 11,
+    GS_WindowResized     = 50,
+    GS_WindowIconified   = 52, // application moved to the background.
+    GS_WindowUniconified = 53, // application returned to the foreground.
+    GS_WindowActive      = 54,
+    GS_WindowInactive    = 55,
+    GS_WindowClosing     = 56  // back button while close is intercepted.
+};
+
+// Provide key modifier bit masks. All currently pressed modifier
+// keys come back combined into one bitmask value. The masks are
+// larger than the key codes so they don't conflict with keys.
+enum {
+    GS_ShiftKeyMask     = 1 << 17,
+    GS_ControlKeyMask   = 1 << 18,
+    GS_AlternateKeyMask = 1 << 19,
+    GS_CommandKeyMask   = 1 << 20,
+    GS_FunctionKeyMask  = 1 << 23,
+};
+
+#endif
@@ -5,6 +5,11 @@
 // This wraps the microsoft windowing API's (where the real work is done).
 
 #include "os_windows.h"
+#include <xinput.h>
+#include <imm.h>
+#include <shellapi.h>
+#define COBJMACROS // C style COM calls, ie: ITaskbarList3_HrInit.
+#include <shobjidl.h>
 
 // Application defaults. Internal use only. Not really state per-se these are
 // consulted at startup for initial values. These are updated using the
@@ -26,6 +31,10 @@ struct AppDefaults defaults = { 100, 100, 240, 280, 8, 24, TEXT("App") };
 // included in a golang build using the .syso file type.
 #define IDI_APPICON 101
 
+// Private methods needed by copy/paste and titles to handle UTF8 strings.
+WCHAR* utf8_wchar(const char* utf8);
+char* wchar_utf8(const WCHAR* wide);
+
 // State used to track window closure. Needed to avoid accessing the
 // external shell pointer after a window has closed. There is no sure way
 // to check if an object pointer is valid once that object has been released.
@@ -46,7 +55,113 @@ static int gs_event_rear = 0;
 static int gs_event_size = sizeof(gs_events) / sizeof(gs_events[0]);
 
 // Full screen toggle structure.
-static GSScreen gs_screen = {0, 0, 0, 0, {0, 0, 0, 0}};
+static GSScreen gs_screen = {0, 0, 0, 0, {0, 0, 0, 0}, GS_Windowed, ""};
+
+// Set to 1 while the cursor is locked.
+static unsigned char gs_cursor_locked = 0;
+
+// Text typed since the last gs_text call and the text currently being
+// composed, both as UTF-16. Dead keys are combined by TranslateMessage.
+static WCHAR gs_typed[GS_TextSize];
+static int gs_ntyped = 0;
+static WCHAR gs_composing[GS_TextSize];
+
+// Touches that are down or have ended since the last gs_touches call.
+static GSTouch gs_touch[GS_MaxTouches];
+static int gs_ntouch = 0;
+
+// Set when close requests are reported as GS_WindowClosing events.
+static unsigned char gs_close_intercepted = 0;
+
+// Set while the window is minimized, to report when it is restored.
+static unsigned char gs_minimized = 0;
+
+// The cursor used while the cursor is over the window client area.
+// Custom image cursors are destroyed when they are replaced.
+static HCURSOR gs_cursor = NULL;
+static unsigned char gs_cursor_custom = 0;
+
+// Paths of the files dropped since the last gs_dropped call as UTF-8,
+// each followed by a newline.
+static char *gs_drops = NULL;
+static int gs_ndrops = 0;
+
+// Append the paths of the files from a WM_DROPFILES message.
+static void gs_drop_files(HDROP drop)
+{
+    UINT count = DragQueryFileW(drop, 0xFFFFFFFF, NULL, 0);
+    for (UINT cnt = 0; cnt < count; cnt++)
+    {
+        WCHAR path[MAX_PATH];
+        if (DragQueryFileW(drop, cnt, path, MAX_PATH) == 0)
+        {
+            continue;
+        }
+        int size = WideCharToMultiByte(CP_UTF8, 0, path, -1, NULL, 0, NULL, NULL);
+        char *drops = realloc(gs_drops, gs_ndrops + size + 1);
+        if (size == 0 || drops == NULL)
+        {
+            continue;
+        }
+        gs_drops = drops;
+        WideCharToMultiByte(CP_UTF8, 0, path, -1, gs_drops + gs_ndrops, size, NULL, NULL);
+        gs_ndrops += size; // size includes the terminating null...
+        gs_drops[gs_ndrops - 1] = '\n'; // ...which becomes the separator.
+        gs_drops[gs_ndrops] = 0;
+    }
+    DragFinish(drop);
+}
+
+// Update the tracked touches from a WM_TOUCH message. Touches that begin
+// when all the touch slots are used are ignored.
+static void gs_touch_input(HWND hwnd, WPARAM wParam, LPARAM lParam)
+{
+    TOUCHINPUT inputs[GS_MaxTouches];
+    UINT count = LOWORD(wParam);
+    if (count > GS_MaxTouches)
+    {
+        count = GS_MaxTouches;
+    }
+    if (GetTouchInputInfo((HTOUCHINPUT)lParam, count, inputs, sizeof(TOUCHINPUT)) == 0)
+    {
+        return;
+    }
+    RECT rect;
+    GetClientRect(hwnd, &rect);
+    for (UINT cnt = 0; cnt < count; cnt++)
+    {
+        TOUCHINPUT *in = &inputs[cnt];
+        GSTouch *touch = NULL;
+        for (int t = 0; t < gs_ntouch; t++)
+        {
+            if (gs_touch[t].id == (long)in->dwID && gs_touch[t].phase != GS_TouchEnded)
+            {
+                touch = &gs_touch[t];
+            }
+        }
+        if (touch == NULL)
+        {
+            if (!(in->dwFlags & TOUCHEVENTF_DOWN) || gs_ntouch >= GS_MaxTouches)
+            {
+                continue;
+            }
+            touch = &gs_touch[gs_ntouch++];
+            touch->id = (long)in->dwID;
+            touch->phase = GS_TouchBegan;
+        }
+        if (in->dwFlags & TOUCHEVENTF_UP)
+        {
+            touch->phase = GS_TouchEnded;
+        }
+
+        // touch locations are in hundredths of a pixel in screen coordinates.
+        POINT loc = { TOUCH_COORD_TO_PIXEL(in->x), TOUCH_COORD_TO_PIXEL(in->y) };
+        ScreenToClient(hwnd, &loc);
+        touch->x = loc.x;
+        touch->y = rect.bottom - loc.y;
+    }
+    CloseTouchInputHandle((HTOUCHINPUT)lParam);
+}
 
 void gs_write_urge(long eid, long key, long scroll)
 {
@@ -57,9 +172,23 @@ void gs_write_urge(long eid, long key, long scroll)
     eve->mousex = -1;
     eve->mousey = -1;
     eve->mods = 0;
+    eve->scan = 0;
     gs_event_rear = (gs_event_rear + 1) % gs_event_size;
 }
 
+// Write a key event along with the keyboard scan code of the key.
+// Extended keys, like the arrow keys, have 0xE000 added to the scan code.
+static void gs_write_key(long eid, long key, LPARAM lParam)
+{
+    GSEvent *eve = &(gs_events[gs_event_rear]);
+    gs_write_urge(eid, key, 0);
+    eve->scan = (lParam >> 16) & 0xFF;
+    if (lParam & 0x01000000)
+    {
+        eve->scan |= 0xE000;
+    }
+}
+
 // Windows callback procedure. Handle a few events often returning 0 to mark
 // them as handled. This method is mostly microsoft magic as each event may
 // have its own behaviour and different return codes.
@@ -84,6 +213,11 @@ LRESULT CALLBACK gs_wnd_proc(HWND hwnd, UINT msg, WPARAM wParam, LPARAM lParam)
         }
         case WM_CLOSE:
         {
+            if (gs_close_intercepted)
+            {
+                gs_write_urge(GS_WindowClosing, 0, 0);
+                return 0;
+            }
             gs_win_alive = -2;
             PostQuitMessage( 0 );
             return 0;
@@ -105,9 +239,57 @@ LRESULT CALLBACK gs_wnd_proc(HWND hwnd, UINT msg, WPARAM wParam, LPARAM lParam)
 			if (msg == WM_SYSKEYDOWN) {
 				msg = WM_KEYDOWN;
 			}
-            gs_write_urge(msg, key, 0);
+            gs_write_key(msg, key, lParam);
+            return 0;
+        }
+        case WM_CHAR:
+        {
+            if (gs_ntyped < GS_TextSize-1)
+            {
+                gs_typed[gs_ntyped++] = (WCHAR)wParam;
+            }
+            return 0;
+        }
+        case WM_TOUCH:
+        {
+            gs_touch_input(hwnd, wParam, lParam);
+            return 0;
+        }
+        case WM_SETCURSOR:
+        {
+            // only replace the cursor over the client area, not the trim.
+            if (LOWORD(lParam) == HTCLIENT && gs_cursor != NULL)
+            {
+                SetCursor(gs_cursor);
+                return TRUE;
+            }
+            break;
+        }
+        case WM_DROPFILES:
+        {
+            gs_drop_files((HDROP)wParam);
             return 0;
         }
+        case WM_SYSCHAR:
+        {
+            return 0; // avoid the menu beep for ALT key combinations.
+        }
+        case WM_IME_COMPOSITION:
+        {
+            if (lParam & GCS_COMPSTR)
+            {
+                HIMC imc = ImmGetContext(hwnd);
+                LONG size = ImmGetCompositionStringW(imc, GCS_COMPSTR, gs_composing, sizeof(gs_composing)-sizeof(WCHAR));
+                gs_composing[size > 0 ? size/sizeof(WCHAR) : 0] = 0;
+                ImmReleaseContext(hwnd, imc);
+            }
+            break; // DefWindowProc sends the composed result as WM_CHAR.
+        }
+        case WM_IME_ENDCOMPOSITION:
+        {
+            gs_composing[0] = 0;
+            break;
+        }
         case WM_MBUTTONDOWN:
         case WM_RBUTTONDOWN:
         case WM_LBUTTONDOWN:
@@ -138,6 +320,21 @@ LRESULT CALLBACK gs_wnd_proc(HWND hwnd, UINT msg, WPARAM wParam, LPARAM lParam)
             {
                 gs_write_urge(GS_WindowResized, 0, 0);
             }
+            if (wParam == SIZE_MINIMIZED)
+            {
+                gs_minimized = 1;
+                gs_write_urge(GS_WindowIconified, 0, 0);
+            }
+            else if (gs_minimized)
+            {
+                gs_minimized = 0;
+                gs_write_urge(GS_WindowUniconified, 0, 0);
+            }
+            return 0;
+        }
+        case WM_MOVE:
+        {
+            gs_write_urge(msg, 0, 0); // sends GS_WindowMoved
             return 0;
         }
         case WM_EXITSIZEMOVE:
@@ -145,6 +342,15 @@ LRESULT CALLBACK gs_wnd_proc(HWND hwnd, UINT msg, WPARAM wParam, LPARAM lParam)
             gs_write_urge(msg, 0, 0); // sends GS_WindowResized
             return 0;
         }
+        case WM_DPICHANGED:
+        {
+            // use the suggested size to keep the window the same physical size.
+            RECT *rect = (RECT *)lParam;
+            SetWindowPos(hwnd, NULL, rect->left, rect->top, rect->right - rect->left,
+                rect->bottom - rect->top, SWP_NOZORDER | SWP_NOACTIVATE);
+            gs_write_urge(GS_WindowResized, 0, 0);
+            return 0;
+        }
     }
 
     // Pass all unhandled messages to DefWindowProc
@@ -165,6 +371,29 @@ void gs_pos(long display, long *x, long *y)
     *y = rect.bottom - point.y;
 }
 
+// Report how far a locked cursor moved away from the window center and
+// put it back. The cursor is clipped to the window each time since windows
+// can release the clip, ie: on focus changes. Nothing is done while the
+// window does not have focus so that other applications can use the mouse.
+static void gs_center_cursor(long display, GSEvent *gs_urge)
+{
+    HWND hwnd = LongToHandle(display);
+    RECT rect;
+    POINT loc, center;
+    if (GetForegroundWindow() != hwnd || GetClientRect(hwnd, &rect) == 0 || GetCursorPos(&loc) == 0)
+    {
+        return;
+    }
+    center.x = rect.right / 2;
+    center.y = rect.bottom / 2;
+    ClientToScreen(hwnd, &center);
+    gs_urge->dx += loc.x - center.x;
+    gs_urge->dy += center.y - loc.y; // screen y is positive down.
+    SetCursorPos(center.x, center.y);
+    MapWindowPoints(hwnd, NULL, (LPPOINT)&rect, 2);
+    ClipCursor(&rect);
+}
+
 // Process all queued up user events and send one of the processed events
 // back to the application. Prefer PeekMessage (non-blocking) over
 // GetMessage (blocking).
@@ -179,7 +408,8 @@ void gs_read_dispatch(long display, GSEvent *gs_urge)
             gs_win_alive = -2;
             return;
         }
-        DispatchMessage( &msg ); // goes to wnd_proc
+        TranslateMessage( &msg ); // turns key messages into WM_CHAR.
+        DispatchMessage( &msg );  // goes to wnd_proc
 
         // message queue has been processed, return interesting stuff.
         if ( gs_event_front != gs_event_rear )
@@ -188,6 +418,7 @@ void gs_read_dispatch(long display, GSEvent *gs_urge)
             gs_urge->event = eve->event;
             gs_urge->key = eve->key;
             gs_urge->scroll = eve->scroll;
+            gs_urge->scan = eve->scan;
 	        gs_event_front = (gs_event_front + 1) % gs_event_size;
         }
     }
@@ -218,6 +449,10 @@ void gs_read_dispatch(long display, GSEvent *gs_urge)
 
     // update the mouse each time rather than dealing with mouse move events.
     gs_pos(display, &(gs_urge->mousex), &(gs_urge->mousey));
+    if (gs_cursor_locked)
+    {
+        gs_center_cursor(display, gs_urge);
+    }
 }
 
 // Create the window, but don't open it.
@@ -250,6 +485,8 @@ long gs_create_window(HMODULE hInstance, LPSTR className)
         hInstance,              // Module instance handle.
         NULL                    // Additional app data.
     );
+    RegisterTouchWindow(display, 0);
+    DragAcceptFiles(display, TRUE);
     return HandleToLong(display);
 }
 
@@ -259,6 +496,7 @@ long gs_display_init()
 {
     // Get the application instance.
     HMODULE hInstance = GetModuleHandle(NULL);
+    SetProcessDPIAware(); // Render at full resolution. Don't let windows scale.
     LPSTR gs_className = TEXT("GS_WIN");
 
     // Register the window class - once.
@@ -319,13 +557,96 @@ unsigned char gs_fullscreen(long display)
 }
 
 // Flip full screen mode. Expected to be called after starting processing
-// of events with gs_read_dispatch(). Based on:
+// of events with gs_read_dispatch(). Full screen is a borderless window
+// covering the current monitor.
+void gs_toggle_fullscreen(long display)
+{
+    gs_set_window_mode(display, gs_screen.full ? GS_Windowed : GS_Borderless, -1, 0, 0, 0);
+}
+
+// Collects the monitor handles during EnumDisplayMonitors.
+typedef struct {
+    HMONITOR handles[GS_MaxMonitors];
+    int      count;
+} GSMonitorList;
+static BOOL CALLBACK gs_monitor_proc(HMONITOR monitor, HDC hdc, LPRECT rect, LPARAM data)
+{
+    GSMonitorList *list = (GSMonitorList *)data;
+    if (list->count < GS_MaxMonitors)
+    {
+        list->handles[list->count++] = monitor;
+    }
+    return TRUE;
+}
+
+// Get the attached monitors and their 32 bit display modes. Duplicate
+// display modes are skipped. Locations are relative to the bottom left
+// of the primary monitor.
+long gs_monitors(GSMonitor *monitors)
+{
+    GSMonitorList list = {{0}, 0};
+    EnumDisplayMonitors(NULL, NULL, gs_monitor_proc, (LPARAM)&list);
+    RECT desktop;
+    GetWindowRect(GetDesktopWindow(), &desktop);
+    for (int cnt = 0; cnt < list.count; cnt++)
+    {
+        GSMonitor *m = &monitors[cnt];
+        MONITORINFOEX info;
+        info.cbSize = sizeof(info);
+        GetMonitorInfo(list.handles[cnt], (MONITORINFO *)&info);
+        m->x = info.rcMonitor.left;
+        m->y = desktop.bottom - info.rcMonitor.bottom;
+        m->w = info.rcMonitor.right - info.rcMonitor.left;
+        m->h = info.rcMonitor.bottom - info.rcMonitor.top;
+        m->primary = (info.dwFlags & MONITORINFOF_PRIMARY) != 0;
+        DISPLAY_DEVICE dev;
+        dev.cb = sizeof(dev);
+        LPSTR name = info.szDevice;
+        if (EnumDisplayDevices(info.szDevice, 0, &dev, 0))
+        {
+            name = dev.DeviceString;
+        }
+        strncpy(m->name, name, sizeof(m->name)-1);
+        m->name[sizeof(m->name)-1] = 0;
+        m->nmodes = 0;
+        DEVMODE dm;
+        dm.dmSize = sizeof(dm);
+        dm.dmDriverExtra = 0;
+        for (DWORD mode = 0; EnumDisplaySettings(info.szDevice, mode, &dm) && m->nmodes < GS_MaxModes; mode++)
+        {
+            if (dm.dmBitsPerPel != 32)
+            {
+                continue;
+            }
+            int dup = 0;
+            for (long d = 0; d < m->nmodes; d++)
+            {
+                GSMode *gm = &m->modes[d];
+                dup = dup || (gm->w == (long)dm.dmPelsWidth && gm->h == (long)dm.dmPelsHeight && gm->refresh == (long)dm.dmDisplayFrequency);
+            }
+            if (!dup)
+            {
+                GSMode *gm = &m->modes[m->nmodes++];
+                gm->w = dm.dmPelsWidth;
+                gm->h = dm.dmPelsHeight;
+                gm->refresh = dm.dmDisplayFrequency > 1 ? dm.dmDisplayFrequency : 0;
+            }
+        }
+    }
+    return list.count;
+}
+
+// Switch between windowed, borderless, and exclusive window modes. Borderless
+// and exclusive windows cover a monitor. Exclusive mode falls back to borderless
+// if the display mode can't be changed. Windowed mode restores the previous
+// window, centered on the requested monitor if there is one. Based on:
 // http://src.chromium.org/viewvc/chrome/trunk/src/ui/views/win/
 //        fullscreen_handler.cc?revision=HEAD&view=markup
-void gs_toggle_fullscreen(long display)
+void gs_set_window_mode(long display, long mode, long monitor, long w, long h, long refresh)
 {
     HWND hwnd = LongToHandle(display);
-    if (!gs_screen.full)
+    unsigned char was_full = gs_screen.full;
+    if (!was_full && mode != GS_Windowed)
     {
         gs_screen.maxed = IsZoomed(hwnd);
         if (gs_screen.maxed)
@@ -336,7 +657,48 @@ void gs_toggle_fullscreen(long display)
         gs_screen.ex_style = GetWindowLong(hwnd, GWL_EXSTYLE);
         GetWindowRect(hwnd, &gs_screen.rect);
     }
-    gs_screen.full = !gs_screen.full;
+    if (gs_screen.device[0] != 0)
+    {
+        ChangeDisplaySettingsEx(gs_screen.device, NULL, NULL, 0, NULL); // restore display mode.
+        gs_screen.device[0] = 0;
+    }
+
+    // find the target monitor.
+    HMONITOR target = MonitorFromWindow(hwnd, MONITOR_DEFAULTTONEAREST);
+    GSMonitorList list = {{0}, 0};
+    EnumDisplayMonitors(NULL, NULL, gs_monitor_proc, (LPARAM)&list);
+    if (monitor >= 0 && monitor < list.count)
+    {
+        target = list.handles[monitor];
+    }
+    MONITORINFOEX m_info;
+    m_info.cbSize = sizeof(m_info);
+    GetMonitorInfo(target, (MONITORINFO *)&m_info);
+    if (mode == GS_Exclusive)
+    {
+        DEVMODE dm;
+        ZeroMemory(&dm, sizeof(dm));
+        dm.dmSize = sizeof(dm);
+        dm.dmPelsWidth = w;
+        dm.dmPelsHeight = h;
+        dm.dmFields = DM_PELSWIDTH | DM_PELSHEIGHT;
+        if (refresh > 0)
+        {
+            dm.dmDisplayFrequency = refresh;
+            dm.dmFields |= DM_DISPLAYFREQUENCY;
+        }
+        if (ChangeDisplaySettingsEx(m_info.szDevice, &dm, NULL, CDS_FULLSCREEN, NULL) == DISP_CHANGE_SUCCESSFUL)
+        {
+            strncpy(gs_screen.device, m_info.szDevice, sizeof(gs_screen.device));
+            GetMonitorInfo(target, (MONITORINFO *)&m_info); // monitor size changed.
+        }
+        else
+        {
+            mode = GS_Borderless;
+        }
+    }
+    gs_screen.full = mode != GS_Windowed;
+    gs_screen.mode = mode;
     if (gs_screen.full)
     {
         SetWindowLong(hwnd, GWL_STYLE,
@@ -344,23 +706,36 @@ void gs_toggle_fullscreen(long display)
         SetWindowLong(hwnd, GWL_EXSTYLE,
                    gs_screen.ex_style & ~(WS_EX_DLGMODALFRAME |
                    WS_EX_WINDOWEDGE | WS_EX_CLIENTEDGE | WS_EX_STATICEDGE));
-        MONITORINFO m_info;
-        m_info.cbSize = sizeof(m_info);
-        GetMonitorInfo(MonitorFromWindow(hwnd, MONITOR_DEFAULTTONEAREST), &m_info);
         RECT m_rect = m_info.rcMonitor;
-        SetWindowPos(hwnd, NULL, m_rect.left, m_rect.top,
+        SetWindowPos(hwnd, HWND_TOP, m_rect.left, m_rect.top,
                      m_rect.right-m_rect.left, m_rect.bottom-m_rect.top,
-                     SWP_NOZORDER | SWP_NOACTIVATE | SWP_FRAMECHANGED);
+                     SWP_NOACTIVATE | SWP_FRAMECHANGED);
     }
     else
     {
-        SetWindowLong(hwnd, GWL_STYLE, gs_screen.style);
-        SetWindowLong(hwnd, GWL_EXSTYLE, gs_screen.ex_style);
         RECT m_rect = gs_screen.rect;
+        if (was_full)
+        {
+            SetWindowLong(hwnd, GWL_STYLE, gs_screen.style);
+            SetWindowLong(hwnd, GWL_EXSTYLE, gs_screen.ex_style);
+        }
+        else
+        {
+            GetWindowRect(hwnd, &m_rect);
+        }
+        if (monitor >= 0)
+        {
+            RECT work = m_info.rcWork;
+            long ww = m_rect.right-m_rect.left, wh = m_rect.bottom-m_rect.top;
+            m_rect.left = work.left + ((work.right-work.left) - ww)/2;
+            m_rect.top = work.top + ((work.bottom-work.top) - wh)/2;
+            m_rect.right = m_rect.left + ww;
+            m_rect.bottom = m_rect.top + wh;
+        }
         SetWindowPos(hwnd, NULL, m_rect.left, m_rect.top,
                      m_rect.right-m_rect.left, m_rect.bottom-m_rect.top,
                      SWP_NOZORDER | SWP_NOACTIVATE | SWP_FRAMECHANGED);
-        if (gs_screen.maxed)
+        if (was_full && gs_screen.maxed)
         {
             SendMessage(hwnd, WM_SYSCOMMAND, SC_MAXIMIZE, 0);
         }
@@ -387,6 +762,78 @@ void gs_set_cursor_location(long display, long x, long y)
     }
 }
 
+// Get the touches that are down or have ended since the last call.
+// Ended touches are then dropped and the others are marked as moved.
+long gs_touches(GSTouch *touches)
+{
+    long count = gs_ntouch;
+    int kept = 0;
+    for (int cnt = 0; cnt < gs_ntouch; cnt++)
+    {
+        touches[cnt] = gs_touch[cnt];
+        if (gs_touch[cnt].phase != GS_TouchEnded)
+        {
+            gs_touch[kept] = gs_touch[cnt];
+            gs_touch[kept].phase = GS_TouchMoved;
+            kept++;
+        }
+    }
+    gs_ntouch = kept;
+    return count;
+}
+
+// Get the text typed since the last call as UTF-8. Text that does not fit
+// is dropped since WideCharToMultiByte fails if the buffer is too small.
+void gs_text(GSText *text)
+{
+    gs_typed[gs_ntyped] = 0;
+    if (WideCharToMultiByte(CP_UTF8, 0, gs_typed, -1, text->typed, GS_TextSize, NULL, NULL) == 0)
+    {
+        text->typed[0] = 0;
+    }
+    if (WideCharToMultiByte(CP_UTF8, 0, gs_composing, -1, text->composing, GS_TextSize, NULL, NULL) == 0)
+    {
+        text->composing[0] = 0;
+    }
+    gs_ntyped = 0;
+}
+
+// Report close requests as events instead of closing the window.
+void gs_intercept_close(unsigned char intercept)
+{
+    gs_close_intercepted = intercept;
+}
+
+// Get the paths of the files dropped since the last call.
+char* gs_dropped()
+{
+    char *paths = gs_drops; // must be freed by caller.
+    gs_drops = NULL;
+    gs_ndrops = 0;
+    return paths;
+}
+
+// Lock or unlock the cursor. A locked cursor is hidden, clipped to the
+// window, and kept centered so that mouse movement is unbounded.
+void gs_lock_cursor(long display, unsigned char lock)
+{
+    if (lock == gs_cursor_locked)
+    {
+        return;
+    }
+    gs_cursor_locked = lock;
+    if (lock)
+    {
+        GSEvent ignore = {0};
+        gs_center_cursor(display, &ignore); // don't report the first jump.
+    }
+    else
+    {
+        ClipCursor(NULL);
+    }
+    ShowCursor( !lock );
+}
+
 // Get the current application windows client area location and size.
 void gs_size(long display, long *x, long *y, long *w, long *h)
 {
@@ -402,6 +849,172 @@ void gs_size(long display, long *x, long *y, long *w, long *h)
     *y = desktop.bottom - rect.bottom;
 }
 
+// Get the content scale from the dots per inch of the window device context.
+float gs_scale(long display)
+{
+    HWND hwnd = LongToHandle(display);
+    HDC hdc = GetDC(hwnd);
+    float dpi = (float) GetDeviceCaps(hdc, LOGPIXELSX);
+    ReleaseDC(hwnd, hdc);
+    return dpi / 96.0f;
+}
+
+// Replace the window cursor and use it immediately if the cursor
+// is over the window client area.
+static void gs_set_cursor(HWND hwnd, HCURSOR cursor, unsigned char custom)
+{
+    if (gs_cursor_custom)
+    {
+        DestroyIcon(gs_cursor);
+    }
+    gs_cursor = cursor;
+    gs_cursor_custom = custom;
+    POINT loc;
+    RECT rect;
+    GetCursorPos(&loc);
+    ScreenToClient(hwnd, &loc);
+    GetClientRect(hwnd, &rect);
+    if (PtInRect(&rect, loc))
+    {
+        SetCursor(gs_cursor);
+    }
+}
+
+// Use one of the system cursors.
+void gs_cursor_shape(long display, long shape)
+{
+    LPCTSTR name = IDC_ARROW;
+    switch (shape)
+    {
+    case GS_HandCursor:      name = IDC_HAND; break;
+    case GS_IBeamCursor:     name = IDC_IBEAM; break;
+    case GS_CrosshairCursor: name = IDC_CROSS; break;
+    case GS_ResizeXCursor:   name = IDC_SIZEWE; break;
+    case GS_ResizeYCursor:   name = IDC_SIZENS; break;
+    }
+    gs_set_cursor(LongToHandle(display), LoadCursor(NULL, name), 0);
+}
+
+// Create a cursor or icon from the RGBA image. The image is copied into a top
+// down BGRA bitmap where the alpha channel is used for transparency.
+static HICON gs_create_icon(unsigned char *rgba, long w, long h, BOOL icon, long hotx, long hoty)
+{
+    BITMAPV5HEADER bi = {0};
+    bi.bV5Size        = sizeof(BITMAPV5HEADER);
+    bi.bV5Width       = w;
+    bi.bV5Height      = -h; // top down.
+    bi.bV5Planes      = 1;
+    bi.bV5BitCount    = 32;
+    bi.bV5Compression = BI_BITFIELDS;
+    bi.bV5RedMask     = 0x00FF0000;
+    bi.bV5GreenMask   = 0x0000FF00;
+    bi.bV5BlueMask    = 0x000000FF;
+    bi.bV5AlphaMask   = 0xFF000000;
+    unsigned char *bits = NULL;
+    HDC hdc = GetDC(NULL);
+    HBITMAP color = CreateDIBSection(hdc, (BITMAPINFO *)&bi, DIB_RGB_COLORS, (void **)&bits, NULL, 0);
+    ReleaseDC(NULL, hdc);
+    if (color == NULL)
+    {
+        return NULL;
+    }
+    for (long cnt = 0; cnt < w * h; cnt++)
+    {
+        bits[cnt*4+0] = rgba[cnt*4+2];
+        bits[cnt*4+1] = rgba[cnt*4+1];
+        bits[cnt*4+2] = rgba[cnt*4+0];
+        bits[cnt*4+3] = rgba[cnt*4+3];
+    }
+    HBITMAP mask = CreateBitmap(w, h, 1, 1, NULL);
+    ICONINFO info = {0};
+    info.fIcon    = icon;
+    info.xHotspot = hotx;
+    info.yHotspot = hoty;
+    info.hbmMask  = mask;
+    info.hbmColor = color;
+    HICON created = CreateIconIndirect(&info);
+    DeleteObject(color);
+    DeleteObject(mask);
+    return created;
+}
+
+// Create a cursor from the RGBA image.
+void gs_cursor_image(long display, unsigned char *rgba, long w, long h, long hotx, long hoty)
+{
+    HCURSOR cursor = gs_create_icon(rgba, w, h, FALSE, hotx, hoty);
+    if (cursor != NULL)
+    {
+        gs_set_cursor(LongToHandle(display), cursor, 1);
+    }
+}
+
+// Change the window title. The UTF-8 title is converted to a wide string.
+void gs_set_title(long display, char *title)
+{
+    WCHAR *wide = utf8_wchar(title);
+    if (wide != NULL)
+    {
+        SetWindowTextW(LongToHandle(display), wide);
+        free(wide);
+    }
+}
+
+// Use the RGBA image as the title bar and taskbar icon. The previous
+// icon is released once it has been replaced.
+void gs_set_icon(long display, unsigned char *rgba, long w, long h)
+{
+    static HICON gs_icon = NULL;
+    HICON icon = gs_create_icon(rgba, w, h, TRUE, 0, 0);
+    if (icon == NULL)
+    {
+        return;
+    }
+    HWND hwnd = LongToHandle(display);
+    SendMessage(hwnd, WM_SETICON, ICON_BIG, (LPARAM)icon);
+    SendMessage(hwnd, WM_SETICON, ICON_SMALL, (LPARAM)icon);
+    if (gs_icon != NULL)
+    {
+        DestroyIcon(gs_icon);
+    }
+    gs_icon = icon;
+}
+
+// Flash the taskbar button until the window is focused.
+void gs_attention(long display)
+{
+    FLASHWINFO info = {0};
+    info.cbSize  = sizeof(FLASHWINFO);
+    info.hwnd    = LongToHandle(display);
+    info.dwFlags = FLASHW_TRAY | FLASHW_TIMERNOFG;
+    FlashWindowEx(&info);
+}
+
+// Show the progress on the taskbar button. The taskbar is created
+// when first needed. Negative progress removes the progress bar.
+void gs_progress(long display, double progress)
+{
+    static ITaskbarList3 *gs_taskbar = NULL;
+    if (gs_taskbar == NULL)
+    {
+        CoInitialize(NULL);
+        if (FAILED(CoCreateInstance(&CLSID_TaskbarList, NULL, CLSCTX_INPROC_SERVER,
+            &IID_ITaskbarList3, (void **)&gs_taskbar)))
+        {
+            gs_taskbar = NULL;
+            return;
+        }
+        ITaskbarList3_HrInit(gs_taskbar);
+    }
+    HWND hwnd = LongToHandle(display);
+    if (progress < 0)
+    {
+        ITaskbarList3_SetProgressState(gs_taskbar, hwnd, TBPF_NOPROGRESS);
+        return;
+    }
+    ITaskbarList3_SetProgressState(gs_taskbar, hwnd, TBPF_NORMAL);
+    ITaskbarList3_SetProgressValue(gs_taskbar, hwnd, (ULONGLONG)(progress * 1000), 1000);
+}
+
 // Show or hide cursor. Lock it to the window if it is hidden.
 void gs_show_cursor(long display, unsigned char show)
 {
@@ -457,10 +1070,6 @@ void gs_set_attr_s(long attr, char * value)
    }
 }
 
-// Private methods needed by copy/paste to handle UTF8 strings.
-WCHAR* utf8_wchar(const char* utf8);
-char* wchar_utf8(const WCHAR* wide);
-
 // Paste the given string into the general clipboard.
 void gs_clip_paste(long display, const char* string) {
      WCHAR* widestr = utf8_wchar(string);
@@ -540,3 +1149,87 @@ char* wchar_utf8(const WCHAR* wide) {
     }
     return utf8; // needs to be freed by the caller.
 }
+
+// XInput is loaded when first used so that there is no link dependency
+// on a particular XInput version.
+typedef DWORD (WINAPI *gs_xinput_state)(DWORD, XINPUT_STATE*);
+typedef DWORD (WINAPI *gs_xinput_vibration)(DWORD, XINPUT_VIBRATION*);
+static gs_xinput_state gs_xinput_get_state = NULL;
+static gs_xinput_vibration gs_xinput_set_state = NULL;
+static int gs_xinput_loaded = 0;
+
+// Load the newest available XInput the first time it is needed.
+static void gs_xinput_load()
+{
+    if (!gs_xinput_loaded)
+    {
+        const char *dlls[] = {"xinput1_4.dll", "xinput1_3.dll", "xinput9_1_0.dll"};
+        gs_xinput_loaded = 1;
+        for (int cnt = 0; cnt < 3 && gs_xinput_get_state == NULL; cnt++)
+        {
+            HMODULE lib = LoadLibraryA(dlls[cnt]);
+            if (lib != NULL)
+            {
+                gs_xinput_get_state = (gs_xinput_state) GetProcAddress(lib, "XInputGetState");
+                gs_xinput_set_state = (gs_xinput_vibration) GetProcAddress(lib, "XInputSetState");
+            }
+        }
+    }
+}
+
+// Scale an XInput stick value to range from -1 to 1.
+static float gs_stick(SHORT value) {
+    return value < 0 ? value / 32768.0f : value / 32767.0f;
+}
+
+// Get the state of the attached XInput game controllers. Buttons are in the
+// standard gamepad order. The guide button is not reported by XInput.
+// Stick up is negative to match the standard gamepad layout.
+void gs_pads(GSPad *pads)
+{
+    static const WORD masks[GS_PadButtons] = {
+        XINPUT_GAMEPAD_A, XINPUT_GAMEPAD_B, XINPUT_GAMEPAD_X, XINPUT_GAMEPAD_Y,
+        XINPUT_GAMEPAD_BACK, 0, XINPUT_GAMEPAD_START,
+        XINPUT_GAMEPAD_LEFT_THUMB, XINPUT_GAMEPAD_RIGHT_THUMB,
+        XINPUT_GAMEPAD_LEFT_SHOULDER, XINPUT_GAMEPAD_RIGHT_SHOULDER,
+        XINPUT_GAMEPAD_DPAD_UP, XINPUT_GAMEPAD_DPAD_DOWN,
+        XINPUT_GAMEPAD_DPAD_LEFT, XINPUT_GAMEPAD_DPAD_RIGHT,
+    };
+    gs_xinput_load();
+    for (DWORD cnt = 0; cnt < GS_MaxPads; cnt++)
+    {
+        GSPad *pad = &pads[cnt];
+        memset(pad, 0, sizeof(GSPad));
+        XINPUT_STATE state;
+        if (gs_xinput_get_state == NULL || gs_xinput_get_state(cnt, &state) != ERROR_SUCCESS)
+        {
+            continue;
+        }
+        pad->connected = 1;
+        XINPUT_GAMEPAD *gp = &state.Gamepad;
+        for (int b = 0; b < GS_PadButtons; b++)
+        {
+            pad->buttons[b] = masks[b] != 0 && (gp->wButtons & masks[b]) != 0;
+        }
+        pad->axes[0] = gs_stick(gp->sThumbLX);
+        pad->axes[1] = -gs_stick(gp->sThumbLY);
+        pad->axes[2] = gs_stick(gp->sThumbRX);
+        pad->axes[3] = -gs_stick(gp->sThumbRY);
+        pad->axes[4] = gp->bLeftTrigger / 255.0f;
+        pad->axes[5] = gp->bRightTrigger / 255.0f;
+    }
+}
+
+// Set the XInput motor speeds. The left motor is the low frequency motor.
+void gs_rumble(long pad, float low, float high)
+{
+    gs_xinput_load();
+    if (gs_xinput_set_state == NULL || pad < 0 || pad >= GS_MaxPads)
+    {
+        return;
+    }
+    XINPUT_VIBRATION vibration;
+    vibration.wLeftMotorSpeed = (WORD)(low * 65535.0f);
+    vibration.wRightMotorSpeed = (WORD)(high * 65535.0f);
+    gs_xinput_set_state((DWORD)pad, &vibration);
+}
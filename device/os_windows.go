@@ -9,6 +9,7 @@ package device
 // // This is C code and cgo directvies.
 //
 // #cgo windows CFLAGS: -m64
+// #cgo windows LDFLAGS: -limm32 -lshell32 -lole32 -luuid
 // #cgo windows,!dx LDFLAGS: -lopengl32 -lgdi32
 // #cgo windows,dx LDFLAGS: -ld3d11
 // #cgo windows,dx CXXFLAGS: -std=c++11
@@ -18,6 +19,7 @@ import "C" // must be located here.
 
 import (
 	"runtime"
+	"strings"
 	"unsafe"
 )
 
@@ -25,9 +27,16 @@ import (
 // Two input structures are continually reused each time rather than allocating
 // a new input structure on each readAndDispatch.
 type win struct {
-	gsu *C.GSEvent
+	gsu  *C.GSEvent
+	pads [MaxPads]C.GSPad // Matches GS_MaxPads.
+
+	typing  C.GSText              // Typed text from the last read.
+	touched [maxTouches]C.GSTouch // Touches from the last read.
 }
 
+// maxTouches matches GS_MaxTouches.
+const maxTouches = 10
+
 // OpenGL related, see: https://code.google.com/p/go-wiki/wiki/LockOSThread
 func init() { runtime.LockOSThread() }
 
@@ -60,6 +69,20 @@ func (w *win) showCursor(r *nrefs, show bool) {
 	}
 	C.gs_show_cursor(C.long(r.display), C.uchar(tf1))
 }
+func (w *win) interceptClose(r *nrefs, intercept bool) {
+	tf1 := 0
+	if intercept {
+		tf1 = 1
+	}
+	C.gs_intercept_close(C.uchar(tf1))
+}
+func (w *win) lockCursor(r *nrefs, lock bool) {
+	tf1 := 0
+	if lock {
+		tf1 = 1
+	}
+	C.gs_lock_cursor(C.long(r.display), C.uchar(tf1))
+}
 
 // Implement native interface.
 func (w *win) readDispatch(r *nrefs, in *userInput) *userInput {
@@ -69,6 +92,9 @@ func (w *win) readDispatch(r *nrefs, in *userInput) *userInput {
 	w.gsu.key = 0
 	w.gsu.mods = 0
 	w.gsu.scroll = 0
+	w.gsu.dx = 0
+	w.gsu.dy = 0
+	w.gsu.scan = 0
 	C.gs_read_dispatch(C.long(r.display), w.gsu)
 
 	// transfer/translate the native event into the input buffer.
@@ -76,16 +102,89 @@ func (w *win) readDispatch(r *nrefs, in *userInput) *userInput {
 	if in.id != 0 {
 		in.button = mouseButtons[int(w.gsu.event)]
 		in.key = int(w.gsu.key)
+		in.scan = -1 // ignore unknown scan codes.
+		if key, ok := scanKeys[int(w.gsu.scan)]; ok {
+			in.scan = key
+		}
 		in.scroll = int(w.gsu.scroll)
 	} else {
-		in.button, in.key, in.scroll = 0, 0, 0
+		in.button, in.key, in.scan, in.scroll = 0, 0, 0, 0
 	}
 	in.mods = int(w.gsu.mods)
 	in.mouseX = int(w.gsu.mousex)
 	in.mouseY = int(w.gsu.mousey)
+	in.dx, in.dy = int(w.gsu.dx), int(w.gsu.dy)
 	return in
 }
 
+// Implement native interface. XInput controllers already use
+// the standard gamepad layout.
+func (w *win) gamepads(r *nrefs, pads []rawPad) {
+	C.gs_pads(&w.pads[0])
+	for cnt := range pads {
+		gp, pad := &w.pads[cnt], &pads[cnt]
+		if pad.connected = gp.connected == 1; !pad.connected {
+			continue
+		}
+		pad.name, pad.mapped = "XInput Controller", true
+		pad.buttons, pad.axes = pad.buttons[:0], pad.axes[:0]
+		for _, b := range gp.buttons {
+			pad.buttons = append(pad.buttons, b != 0)
+		}
+		for _, a := range gp.axes {
+			pad.axes = append(pad.axes, float64(a))
+		}
+	}
+}
+
+// Implement native interface. XInput controllers have a low
+// frequency motor on the left and a high frequency motor on the right.
+func (w *win) rumble(r *nrefs, pad int, low, high float64) {
+	C.gs_rumble(C.long(pad), C.float(low), C.float(high))
+}
+
+// Implement native interface. The native touch phases match the
+// Touch phase constants.
+func (w *win) touches(r *nrefs, touches []Touch) []Touch {
+	count := int(C.gs_touches(&w.touched[0]))
+	for cnt := 0; cnt < count; cnt++ {
+		t := &w.touched[cnt]
+		touches = append(touches, Touch{ID: int(t.id), Phase: int(t.phase), X: float64(t.x), Y: float64(t.y)})
+	}
+	return touches
+}
+
+// Implement native interface.
+func (w *win) monitors(r *nrefs) []Monitor {
+	gms := make([]C.GSMonitor, maxMonitors)
+	count := int(C.gs_monitors(&gms[0]))
+	monitors := make([]Monitor, count)
+	for cnt := range monitors {
+		gm, m := &gms[cnt], &monitors[cnt]
+		m.Name, m.Primary = C.GoString(&gm.name[0]), gm.primary == 1
+		m.X, m.Y, m.W, m.H = int(gm.x), int(gm.y), int(gm.w), int(gm.h)
+		for mode := 0; mode < int(gm.nmodes); mode++ {
+			dm := &gm.modes[mode]
+			m.Modes = append(m.Modes, Mode{W: int(dm.w), H: int(dm.h), Refresh: int(dm.refresh)})
+		}
+	}
+	return monitors
+}
+
+// maxMonitors matches GS_MaxMonitors.
+const maxMonitors = 8
+
+// Implement native interface.
+func (w *win) setWindowMode(r *nrefs, mode, monitor int, m Mode) {
+	C.gs_set_window_mode(C.long(r.display), C.long(mode), C.long(monitor), C.long(m.W), C.long(m.H), C.long(m.Refresh))
+}
+
+// Implement native interface.
+func (w *win) text(r *nrefs) (typed, composing string) {
+	C.gs_text(&w.typing)
+	return C.GoString(&w.typing.typed[0]), C.GoString(&w.typing.composing[0])
+}
+
 // Implement native interface.
 func (w *win) size(r *nrefs) (x int, y int, wx int, hy int) {
 	var winx, winy, width, height int32
@@ -93,6 +192,16 @@ func (w *win) size(r *nrefs) (x int, y int, wx int, hy int) {
 	return int(winx), int(winy), int(width), int(height)
 }
 
+// Implement native interface. Windows are pixel sized since the
+// process is DPI aware.
+func (w *win) pixelSize(r *nrefs) (wx, hy int) {
+	_, _, wx, hy = w.size(r)
+	return wx, hy
+}
+
+// Implement native interface.
+func (w *win) scale(r *nrefs) float64 { return float64(C.gs_scale(C.long(r.display))) }
+
 // Implement native interface.
 func (w *win) setSize(x, y, width, height int) {
 	C.gs_set_attr_l(C.GS_ShellX, C.long(x))
@@ -108,6 +217,38 @@ func (w *win) setTitle(title string) {
 	C.gs_set_attr_s(C.GS_AppName, cstr)
 }
 
+// Implement native interface.
+func (w *win) retitle(r *nrefs, title string) {
+	cstr := C.CString(title)
+	defer C.free(unsafe.Pointer(cstr))
+	C.gs_set_title(C.long(r.display), cstr)
+}
+
+// Implement native interface.
+func (w *win) icon(r *nrefs, pixels []byte, width, height int) {
+	C.gs_set_icon(C.long(r.display), (*C.uchar)(&pixels[0]), C.long(width), C.long(height))
+}
+func (w *win) attention(r *nrefs)           { C.gs_attention(C.long(r.display)) }
+func (w *win) progress(r *nrefs, p float64) { C.gs_progress(C.long(r.display), C.double(p)) }
+
+// Implement native interface.
+func (w *win) cursorShape(r *nrefs, shape int) { C.gs_cursor_shape(C.long(r.display), C.long(shape)) }
+
+// Implement native interface.
+func (w *win) cursorImage(r *nrefs, pixels []byte, w, h, hotX, hotY int) {
+	C.gs_cursor_image(C.long(r.display), (*C.uchar)(&pixels[0]), C.long(w), C.long(h), C.long(hotX), C.long(hotY))
+}
+
+// Implement native interface: nrefs unused, needed by other platforms.
+func (w *win) dropped(r *nrefs) []string {
+	if cstr := C.gs_dropped(); cstr != nil {
+		paths := C.GoString(cstr)    // make a Go copy.
+		C.free(unsafe.Pointer(cstr)) // free the C copy.
+		return strings.Split(strings.TrimSuffix(paths, "\n"), "\n")
+	}
+	return nil
+}
+
 // Implement native interface.
 func (w *win) copyClip(r *nrefs) string {
 	if cstr := C.gs_clip_copy(C.long(r.display)); cstr != nil {
@@ -144,6 +285,7 @@ var events = map[int]int{
 	C.GS_WindowUniconified: uniconifiedShell,
 	C.GS_WindowActive:      activatedShell,
 	C.GS_WindowInactive:    deactivatedShell,
+	C.GS_WindowClosing:     closingShell,
 }
 
 // Also map the mice buttons into left and right.
@@ -275,3 +417,30 @@ const (
 	mouseMiddle       = 0x04 // VK_MBUTTON Middle mouse button (three-button mouse)
 	mouseRight        = 0x02 // VK_RBUTTON Right mouse button
 )
+
+// scanKeys maps keyboard scan codes to the key codes of the keys at the
+// same location on a US keyboard. Extended scan codes have 0xE000 added.
+var scanKeys = map[int]int{
+	0x01: keyEscape, 0x02: key1, 0x03: key2, 0x04: key3, 0x05: key4,
+	0x06: key5, 0x07: key6, 0x08: key7, 0x09: key8, 0x0A: key9,
+	0x0B: key0, 0x0C: keyMinus, 0x0D: keyEqual, 0x0E: keyDelete, 0x0F: keyTab,
+	0x10: keyQ, 0x11: keyW, 0x12: keyE, 0x13: keyR, 0x14: keyT,
+	0x15: keyY, 0x16: keyU, 0x17: keyI, 0x18: keyO, 0x19: keyP,
+	0x1A: keyLeftBracket, 0x1B: keyRightBracket, 0x1C: keyReturn,
+	0x1E: keyA, 0x1F: keyS, 0x20: keyD, 0x21: keyF, 0x22: keyG,
+	0x23: keyH, 0x24: keyJ, 0x25: keyK, 0x26: keyL, 0x27: keySemicolon,
+	0x28: keyQuote, 0x29: keyGrave, 0x2B: keyBackslash,
+	0x2C: keyZ, 0x2D: keyX, 0x2E: keyC, 0x2F: keyV, 0x30: keyB,
+	0x31: keyN, 0x32: keyM, 0x33: keyComma, 0x34: keyPeriod, 0x35: keySlash,
+	0x37: keyKeypadMultiply, 0x39: keySpace,
+	0x3B: keyF1, 0x3C: keyF2, 0x3D: keyF3, 0x3E: keyF4, 0x3F: keyF5,
+	0x40: keyF6, 0x41: keyF7, 0x42: keyF8, 0x43: keyF9, 0x44: keyF10,
+	0x47: keyKeypad7, 0x48: keyKeypad8, 0x49: keyKeypad9, 0x4A: keyKeypadMinus,
+	0x4B: keyKeypad4, 0x4C: keyKeypad5, 0x4D: keyKeypad6, 0x4E: keyKeypadPlus,
+	0x4F: keyKeypad1, 0x50: keyKeypad2, 0x51: keyKeypad3, 0x52: keyKeypad0,
+	0x53: keyKeypadDecimal, 0x57: keyF11, 0x58: keyF12,
+	0xE01C: keyKeypadEnter, 0xE035: keyKeypadDivide, 0xE047: keyHome,
+	0xE048: keyUpArrow, 0xE049: keyPageUp, 0xE04B: keyLeftArrow,
+	0xE04D: keyRightArrow, 0xE04F: keyEnd, 0xE050: keyDownArrow,
+	0xE051: keyPageDown, 0xE053: keyForwardDelete,
+}
@@ -6,6 +6,11 @@
 
 // os_windows.h defines the method calls needed by os_windows.go native layer.
 
+// Touch input needs windows 7 or later.
+#ifndef _WIN32_WINNT
+#define _WIN32_WINNT 0x0601
+#endif
+
 #include <stdio.h>
 #include <windows.h>
 
@@ -17,8 +22,72 @@ typedef struct {
     long key;     // which key is currently pressed, if any.
     long mods;    // which modifier keys are currently pressed, if any.
     long scroll;  // the scroll amount if any.
+    long dx;      // mouse movement while the cursor is locked.
+    long dy;      // mouse movement while the cursor is locked.
+    long scan;    // keyboard scan code of the key, if any.
 } GSEvent;
 
+// Used to pass back the attached game controllers each polling call.
+// XInput controllers are reported in the standard gamepad order.
+#define GS_MaxPads    4
+#define GS_PadButtons 15
+#define GS_PadAxes    6
+typedef struct {
+    long  connected;              // 1 while the controller is attached.
+    long  buttons[GS_PadButtons]; // 1 if the button is pressed.
+    float axes[GS_PadAxes];       // Sticks from -1 to 1, triggers from 0 to 1.
+} GSPad;
+
+// Used to pass back the typed text each polling call. Strings are UTF-8
+// and are dropped if they do not fit.
+#define GS_TextSize 256
+typedef struct {
+    char typed[GS_TextSize];     // text typed since the last call.
+    char composing[GS_TextSize]; // unfinished text from dead keys or an IME.
+} GSText;
+
+// Cursor shapes used with gs_cursor_shape.
+// The shapes match the device package cursor shapes.
+#define GS_ArrowCursor     0
+#define GS_HandCursor      1
+#define GS_IBeamCursor     2
+#define GS_CrosshairCursor 3
+#define GS_ResizeXCursor   4
+#define GS_ResizeYCursor   5
+
+// Used to pass back the attached monitors and their display modes.
+// The window modes match the device package window modes.
+#define GS_MaxMonitors 8
+#define GS_MaxModes    128
+#define GS_Windowed    0
+#define GS_Borderless  1
+#define GS_Exclusive   2
+typedef struct {
+    long w;       // width in pixels.
+    long h;       // height in pixels.
+    long refresh; // refresh rate in hertz, 0 if unknown.
+} GSMode;
+typedef struct {
+    char   name[64];           // display name.
+    long   x, y, w, h;         // desktop location relative to the bottom left.
+    long   primary;            // 1 for the main display.
+    long   nmodes;             // number of display modes.
+    GSMode modes[GS_MaxModes]; // supported display modes.
+} GSMonitor;
+
+// Used to pass back the touch screen touches each polling call.
+// The phases match the device package Touch phases.
+#define GS_MaxTouches 10
+#define GS_TouchBegan 0
+#define GS_TouchMoved 1
+#define GS_TouchEnded 2
+typedef struct {
+    long  id;    // identifies the touch while it is down.
+    long  phase; // one of the GS_Touch phases.
+    float x;     // window location relative to the bottom left corner.
+    float y;     // window location relative to the bottom left corner.
+} GSTouch;
+
 // Used to toggle between full screen and windowed mode.
 typedef struct {
     unsigned char full;     // true when in full screen mode.
@@ -26,6 +95,8 @@ typedef struct {
     long          style;    // used to restore windowed mode style.
     long          ex_style; // used to restore windowed mode style.
     RECT          rect;     // used to restore windowed dimensions.
+    long          mode;     // one of the GS_Windowed window modes.
+    char          device[CCHDEVICENAME]; // monitor with a changed display mode.
 } GSScreen;
 
 // Initialize the underlying application window.
@@ -51,20 +122,81 @@ unsigned char gs_fullscreen(long display);
 // of events with gs_read_dispatch().
 void gs_toggle_fullscreen(long display);
 
+// Get the attached monitors. Returns the number of monitors.
+long gs_monitors(GSMonitor *monitors);
+
+// Switch to one of the GS_Windowed, GS_Borderless, or GS_Exclusive window
+// modes on the given monitor, -1 for the current monitor. The display mode
+// w, h, refresh is only used for GS_Exclusive.
+void gs_set_window_mode(long display, long mode, long monitor, long w, long h, long refresh);
+
 // Process a user event. This must be called inside an event loop in order
 // for the application to work. The event is also processed to determine
 // window events.
 void gs_read_dispatch(long display, GSEvent *gs_urge);
 
+// Get the state of the attached XInput game controllers.
+// Fills GS_MaxPads controllers.
+void gs_pads(GSPad *pads);
+
+// Run the low and high frequency motors of the XInput controller in the
+// given slot. Magnitudes range from 0 to 1 where 0 stops the motor.
+void gs_rumble(long pad, float low, float high);
+
+// Get the text typed since the last call. Text is collected from the
+// WM_CHAR and WM_IME_COMPOSITION messages.
+void gs_text(GSText *text);
+
+// Report GS_WindowClosing events instead of closing the window
+// when the user asks to close the window.
+void gs_intercept_close(unsigned char intercept);
+
+// Get the paths of the files dropped on the window since the last call.
+// Paths are separated by newlines. Returns NULL if no files were dropped,
+// otherwise the returned string must be freed by the caller.
+char* gs_dropped();
+
+// Get the touches that are down or ended since the last call.
+// Returns the number of touches.
+long gs_touches(GSTouch *touches);
+
 // Get the current main window drawing area size.
 void gs_size(long display, long *x, long *y, long *w, long *h);
 
+// Get the main window content scale, the monitor dots per inch
+// relative to the standard 96 dots per inch.
+float gs_scale(long display);
+
 // Show or hide cursor. Lock it if it is hidden.
 void gs_show_cursor(long display, unsigned char show);
 
+// Use one of the system cursor shapes while the cursor is over the window.
+void gs_cursor_shape(long display, long shape);
+
+// Use the w by h pixel RGBA image as the cursor while it is over the window.
+// The hot spot is the image pixel, from the top left, that is the cursor location.
+void gs_cursor_image(long display, unsigned char *rgba, long w, long h, long hotx, long hoty);
+
+// Change the window title after the window has been created.
+void gs_set_title(long display, char *title);
+
+// Use the w by h pixel RGBA image as the window and taskbar icon.
+void gs_set_icon(long display, unsigned char *rgba, long w, long h);
+
+// Flash the taskbar button until the window is focused.
+void gs_attention(long display);
+
+// Show the progress, from 0 to 1, on the taskbar button.
+// Negative progress removes the progress bar.
+void gs_progress(long display, double progress);
+
 // Set the cursor location to the given screen coordinates.
 void gs_set_cursor_location(long display, long x, long y);
 
+// Lock or unlock the cursor. A locked cursor is hidden and kept centered
+// in the window. Mouse movement is reported in the GSEvent dx, dy fields.
+void gs_lock_cursor(long display, unsigned char lock);
+
 // Create an OpenGL context using the given shell. Subsequent calls
 // return the current context and ignoring the input parameters.
 //
@@ -143,7 +275,8 @@ enum {
     GS_WindowIconified   = 0x0019, // WM_SHOWWINDOW + true  (1)
     GS_WindowUniconified = 0x0018, // WM_SHOWWINDOW + false (0)
     GS_WindowActive      = 0x0007, // WM_ACTIVATE + WA_ACTIVE (1)
-    GS_WindowInactive    = 0x0006  // WM_ACTIVATE + WM_INACTIVE (0)
+    GS_WindowInactive    = 0x0006, // WM_ACTIVATE + WM_INACTIVE (0)
+    GS_WindowClosing     = 0x0010  // WM_CLOSE
 };
 
 // Provide key modifier bit masks. All currently pressed modifier
@@ -0,0 +1,98 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+// touch.go recognizes simple gestures from the touch screen touches
+// reported by the native layer.
+
+import "math"
+
+// Touch is one finger or pen on a touch screen. Touches are reported each
+// update from when they begin until the update where they end.
+type Touch struct {
+	ID    int     // Identifies the touch while it is down.
+	Phase int     // One of TouchBegan, TouchMoved, TouchEnded.
+	X, Y  float64 // Window location with the origin at the bottom left.
+}
+
+// Touch phases.
+const (
+	TouchBegan = iota // Touch started since the last update.
+	TouchMoved        // Touch continues, it may or may not have moved.
+	TouchEnded        // Touch lifted since the last update.
+)
+
+// Gesture holds the gestures recognized from the touches each update.
+type Gesture struct {
+	Tapped bool    // True the update a single quick touch ended.
+	Tx, Ty float64 // Tap location.
+	Pinch  float64 // Change in distance between two touches, 1 for none.
+	Px, Py float64 // Pan: average touch movement since the last update.
+}
+
+// Taps are recognized for touches that end quickly without moving much.
+const (
+	tapTicks = 15 // Updates a touch can be down and still be a tap.
+	tapSlop  = 10 // Pixels a touch can move and still be a tap.
+)
+
+// touchStart tracks a touch that is down.
+type touchStart struct {
+	x, y  float64 // Location where the touch began.
+	ticks int     // Updates the touch has been down.
+}
+
+// processTouches recognizes gestures from the current touches. Gestures
+// compare touches with their locations from the previous update.
+func (i *input) processTouches(touches []Touch) {
+	i.curr.Touches = append(i.curr.Touches[:0], touches...)
+	g := Gesture{Pinch: 1}
+	var pair []Touch // touches seen last update, for pinch.
+	moved := 0
+	for _, t := range touches {
+		if prev, ok := i.prev[t.ID]; ok {
+			g.Px, g.Py = g.Px+t.X-prev.X, g.Py+t.Y-prev.Y
+			moved++
+			if len(pair) < 4 {
+				pair = append(pair, prev, t)
+			}
+		}
+	}
+	if moved > 0 {
+		g.Px, g.Py = g.Px/float64(moved), g.Py/float64(moved)
+	}
+	if len(pair) == 4 {
+		before := math.Hypot(pair[0].X-pair[2].X, pair[0].Y-pair[2].Y)
+		after := math.Hypot(pair[1].X-pair[3].X, pair[1].Y-pair[3].Y)
+		if before > 0 {
+			g.Pinch = after / before
+		}
+	}
+
+	// track the touches that are down.
+	if len(i.starts) == 0 {
+		i.peak = 0
+	}
+	for _, t := range touches {
+		start, ok := i.starts[t.ID]
+		if !ok {
+			start = touchStart{x: t.X, y: t.Y}
+		}
+		start.ticks++
+		if t.Phase == TouchEnded {
+			quick := start.ticks <= tapTicks && math.Hypot(t.X-start.x, t.Y-start.y) <= tapSlop
+			if quick && i.peak <= 1 {
+				g.Tapped, g.Tx, g.Ty = true, t.X, t.Y
+			}
+			delete(i.starts, t.ID)
+			delete(i.prev, t.ID)
+			continue
+		}
+		i.starts[t.ID], i.prev[t.ID] = start, t
+	}
+	if len(i.starts) > i.peak {
+		i.peak = len(i.starts)
+	}
+	i.curr.Gesture = g
+}
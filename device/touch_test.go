@@ -0,0 +1,29 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package device
+
+import "testing"
+
+// A single quick touch is a tap while two touches can pinch and pan.
+func TestGestures(t *testing.T) {
+	i := newInput()
+	i.processTouches([]Touch{{ID: 1, Phase: TouchBegan, X: 50, Y: 50}})
+	i.processTouches([]Touch{{ID: 1, Phase: TouchEnded, X: 52, Y: 51}})
+	if g := i.curr.Gesture; !g.Tapped || g.Tx != 52 || g.Px != 2 || g.Py != 1 {
+		t.Errorf("Expected tap got %+v", g)
+	}
+	i.processTouches([]Touch{{ID: 2, Phase: TouchBegan, X: 0, Y: 0}, {ID: 3, Phase: TouchBegan, X: 10, Y: 0}})
+	i.processTouches([]Touch{{ID: 2, Phase: TouchMoved, X: 0, Y: 10}, {ID: 3, Phase: TouchMoved, X: 20, Y: 10}})
+	if g := i.curr.Gesture; g.Tapped || g.Pinch != 2 || g.Px != 5 || g.Py != 10 {
+		t.Errorf("Expected pinch and pan got %+v", g)
+	}
+	i.processTouches([]Touch{{ID: 2, Phase: TouchEnded, X: 0, Y: 10}, {ID: 3, Phase: TouchEnded, X: 20, Y: 10}})
+	if g := i.curr.Gesture; g.Tapped || len(i.starts) != 0 || len(i.curr.Touches) != 2 {
+		t.Errorf("Expected no tap after a two touch gesture got %+v", g)
+	}
+	i.clone(i.curr, i.down)
+	if len(i.down.Touches) != 2 || i.down.Touches[1].Phase != TouchEnded {
+		t.Errorf("Expected ended touches to be shared")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// diagnostic.go reports problems with application assets.
+// DESIGN: the loader collects diagnostics while loading a group of
+//         assets and returns them with the loaded assets. The engine
+//         reports them to the application during the next update so
+//         broken assets are noticed instead of rendering as nothing.
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/gazed/vu/load"
+)
+
+// Diagnostic describes a problem found while loading, checking, or
+// binding an asset. Fatal problems mean the asset could not be used.
+// Other problems are warnings where the asset is used, but may not look,
+// or sound, as expected. Diagnostics are reported to the application
+// using the function registered with Eng.Diagnose.
+type Diagnostic struct {
+	Asset string // Asset as named in Model.Load, ie: "tex:wood".
+	Kind  int    // Problem category, ie: LoadFailed.
+	Fatal bool   // True if the asset could not be used.
+	Msg   string // Problem details, ie: the shader compile log.
+}
+
+// Error implements the error interface.
+func (d *Diagnostic) Error() string {
+	level := "warning"
+	if d.Fatal {
+		level = "error"
+	}
+	return fmt.Sprintf("%s %s %s: %s", d.Asset, diagnosticKinds[d.Kind], level, d.Msg)
+}
+
+// Diagnostic kinds used for Diagnostic.Kind.
+const (
+	LoadFailed   = iota // Asset file is missing or could not be parsed.
+	BindFailed          // Asset data was rejected by the GPU or audio card.
+	ShaderFailed        // Shader did not compile or link. Msg has the log.
+	BadIndices          // Mesh faces reference missing vertices.
+	BadUVs              // Mesh texture coordinates are missing or invalid.
+	NonManifold         // Mesh edges are shared by more than two faces.
+)
+
+// diagnosticKinds describe the diagnostic kinds.
+var diagnosticKinds = []string{"load", "bind", "shader", "indices", "uv", "manifold"}
+
+// Diagnostic
+// =============================================================================
+// internal implementation for diagnostics.
+
+// bindError marks errors from binding asset data to the GPU or audio card.
+type bindError struct{ error }
+
+// diagnosis is returned by the loader along with a group of loaded
+// assets. It holds the problems found while loading the group.
+type diagnosis struct {
+	diags []*Diagnostic
+}
+
+// diagnosisID is the single identifier for loader diagnostics.
+var diagnosisID = assetID(dgn, "")
+
+// aid is used to uniquely identify assets.
+func (d *diagnosis) aid() aid      { return diagnosisID } // hashed type and name.
+func (d *diagnosis) label() string { return "diagnosis" } // asset name
+
+// diagnose creates a fatal diagnostic from an asset load error.
+func diagnose(id aid, name string, err error) *Diagnostic {
+	switch e := err.(type) {
+	case *Diagnostic:
+		return e
+	case bindError:
+		kind := BindFailed
+		if id.dataType() == shd {
+			kind = ShaderFailed
+		}
+		return &Diagnostic{Asset: assetName(id, name), Kind: kind, Fatal: true, Msg: e.Error()}
+	}
+	return &Diagnostic{Asset: assetName(id, name), Kind: LoadFailed, Fatal: true, Msg: err.Error()}
+}
+
+// report sends diagnostics to the application, or logs them if the
+// application has not asked for diagnostics.
+func (eng *engine) report(diags []*Diagnostic) {
+	for _, d := range diags {
+		if eng.diagnose != nil {
+			eng.diagnose(d)
+		} else {
+			log.Printf("%s", d) // dev error.
+		}
+	}
+}
+
+// checkMesh validates imported mesh data for the named asset. Problems
+// that would have the GPU read past the mesh data are fatal.
+func checkMesh(name string, d *load.MshData) (diags []*Diagnostic) {
+	problem := func(kind int, fatal bool, format string, args ...interface{}) {
+		diags = append(diags, &Diagnostic{Asset: name, Kind: kind, Fatal: fatal, Msg: fmt.Sprintf(format, args...)})
+	}
+	verts := len(d.V) / 3
+	if len(d.F)%3 != 0 {
+		problem(BadIndices, true, "%d indices is not a multiple of 3", len(d.F))
+	}
+	for _, f := range d.F {
+		if int(f) >= verts {
+			problem(BadIndices, true, "index %d with %d vertices", f, verts)
+			break
+		}
+	}
+	if len(d.T) > 0 && len(d.T) != verts*2 {
+		problem(BadUVs, true, "%d texture coordinates for %d vertices", len(d.T)/2, verts)
+	}
+	for _, uv := range d.T {
+		if math.IsNaN(float64(uv)) || math.IsInf(float64(uv), 0) {
+			problem(BadUVs, false, "texture coordinate %f", uv)
+			break
+		}
+	}
+	if len(diags) > 0 {
+		return diags // edges are only checked for valid faces.
+	}
+
+	// count the faces that share each edge.
+	type edge struct{ a, b uint16 }
+	edges := map[edge]int{}
+	for cnt := 0; cnt+2 < len(d.F); cnt += 3 {
+		for i := 0; i < 3; i++ {
+			a, b := d.F[cnt+i], d.F[cnt+(i+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			edges[edge{a, b}]++
+		}
+	}
+	shared := 0
+	for _, faces := range edges {
+		if faces > 2 {
+			shared++
+		}
+	}
+	if shared > 0 {
+		problem(NonManifold, false, "%d edges shared by more than two faces", shared)
+	}
+	return diags
+}
@@ -0,0 +1,88 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gazed/vu/load"
+)
+
+func TestCheckMesh(t *testing.T) {
+	quad := func() *load.MshData {
+		return &load.MshData{V: make([]float32, 12), T: make([]float32, 8), F: []uint16{0, 1, 2, 0, 2, 3}}
+	}
+	if diags := checkMesh("msh:quad", quad()); len(diags) != 0 {
+		t.Errorf("Expected valid mesh got %v", diags)
+	}
+	bad := quad()
+	bad.F[5] = 4
+	if diags := checkMesh("msh:quad", bad); len(diags) != 1 || diags[0].Kind != BadIndices || !diags[0].Fatal {
+		t.Errorf("Expected fatal index problem got %v", diags)
+	}
+	bad = quad()
+	bad.T[3] = float32(math.NaN())
+	if diags := checkMesh("msh:quad", bad); len(diags) != 1 || diags[0].Kind != BadUVs || diags[0].Fatal {
+		t.Errorf("Expected uv warning got %v", diags)
+	}
+	bad.T = bad.T[:6]
+	if diags := checkMesh("msh:quad", bad); len(diags) == 0 || !diags[0].Fatal {
+		t.Errorf("Expected fatal uv count problem got %v", diags)
+	}
+	fin := quad()
+	fin.V = make([]float32, 15)
+	fin.T = nil
+	fin.F = append(fin.F, 0, 2, 4) // three faces share edge 0-2.
+	if diags := checkMesh("msh:fin", fin); len(diags) != 1 || diags[0].Kind != NonManifold {
+		t.Errorf("Expected non-manifold warning got %v", diags)
+	}
+}
+
+// The example models should not have problems.
+// Uses vu/eg resource directories.
+func TestCheckModels(t *testing.T) {
+	files, _ := filepath.Glob("eg/models/*.obj")
+	loc := load.NewLocator().Dir("OBJ", "eg/models")
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".obj")
+		msh := &load.MshData{}
+		if msh.Load(name, loc) != nil {
+			continue // bad files are tested by package load.
+		}
+		for _, d := range checkMesh("msh:"+name, msh) {
+			if d.Fatal {
+				t.Errorf("Unexpected problem %s", d)
+			}
+		}
+	}
+}
+
+// Load failures are returned and reported as diagnostics.
+func TestDiagnose(t *testing.T) {
+	machine, reqs, loaded, stop := make(chan msg), make(chan map[aid]string), make(chan map[aid]asset), make(chan bool)
+	go runLoader(machine, reqs, loaded, stop)
+	defer close(stop)
+	reqs <- map[aid]string{assetID(tex, "missing"): "missing", assetID(mat, "gone"): "gone"}
+	assets := <-loaded
+	if _, ok := assets[assetID(tex, "missing")].(*failure); !ok {
+		t.Errorf("Expected texture failure")
+	}
+
+	eng := newEngine(nil)
+	reports := map[string]int{}
+	eng.Diagnose(func(d *Diagnostic) { reports[d.Asset] = d.Kind })
+	eng.finishLoads(assets)
+	if len(reports) != 2 || reports["tex:missing"] != LoadFailed || reports["mat:gone"] != LoadFailed {
+		t.Errorf("Expected load failures got %v", reports)
+	}
+	d := diagnose(assetID(shd, "toon"), "toon", bindError{fmt.Errorf("0:1 syntax error")})
+	if d.Kind != ShaderFailed || d.Error() != "shd:toon shader error: 0:1 syntax error" {
+		t.Errorf("Expected shader failure got %s", d)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// dof.go provides a depth of field post effect.
+// DESIGN: The scene is rendered to an ImageBuffer layer. A screen model
+//         using the "dof" shader blurs each pixel by its circle of
+//         confusion, calculated from the scene depth and the scene camera
+//         focus. The blur uses a disc of samples to approximate bokeh.
+//         See: http://http.developer.nvidia.com/GPUGems/gpugems_ch23.html
+
+// DOF blurs the parts of a rendered scene that are out of the scene
+// camera focus. The scene is rendered to a layer which is then displayed
+// by a screen model using the "dof" shader. For example:
+//     scene := eng.Root().NewPov()
+//     cam := scene.NewCam()
+//     cam.SetFocus(5, 1.4)
+//     scene.NewLayer()
+//     ...
+//     screen := eng.Root().NewPov() // with its own camera.
+//     model := screen.NewPov().NewModel("dof", "msh:flipboard")
+//     model.UseLayer(scene.Layer())
+//     dof := vu.NewDOF(cam, model)
+//     ...
+//     dof.Update() // once per App.Update.
+type DOF struct {
+	MaxBlur float64 // Largest blur radius as a fraction of the screen.
+
+	on     bool    // Effect is enabled by default.
+	cam    *Camera // Scene camera with focus and projection.
+	screen Model   // Model using the "dof" shader.
+}
+
+// NewDOF creates a depth of field effect for the scene seen by the
+// given camera. The screen Model is expected to use the "dof" shader
+// with the scene render layer.
+func NewDOF(cam *Camera, screen Model) *DOF {
+	return &DOF{MaxBlur: 0.01, on: true, cam: cam, screen: screen}
+}
+
+// On returns true if the effect is enabled.
+func (d *DOF) On() bool { return d.on }
+
+// SetOn enables or disables the effect. A disabled effect
+// displays the scene layer without any blur.
+func (d *DOF) SetOn(on bool) { d.on = on }
+
+// Update sets the shader uniforms from the camera focus. Expected to be
+// called each update after any changes to the camera focus or projection.
+func (d *DOF) Update() {
+	blur := d.MaxBlur
+	if !d.on {
+		blur = 0 // everything in focus.
+	}
+	focus, fstop := d.cam.Focus()
+	d.screen.SetUniform("dof", focus, 1/fstop, blur)
+	d.screen.SetUniform("dofp", d.cam.pm.Zz, d.cam.pm.Wz) // depth linearization.
+}
@@ -0,0 +1,25 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+)
+
+// The effect uses the camera focus and removes the blur when disabled.
+func TestDOFUpdate(t *testing.T) {
+	cam, _, _ := initScene()
+	cam.SetFocus(5, 2)
+	screen := newModel("dof")
+	dof := NewDOF(cam, screen)
+	dof.Update()
+	if u := screen.Uniform("dof"); len(u) != 3 || u[0] != 5 || u[1] != 0.5 || u[2] == 0 {
+		t.Errorf("Expected camera focus, got %v", u)
+	}
+	dof.SetOn(false)
+	dof.Update()
+	if u := screen.Uniform("dof"); u[2] != 0 {
+		t.Errorf("Expected no blur, got %v", u)
+	}
+}
@@ -8,9 +8,16 @@ package vu
 // DESIGN: keep small by delegating application requests to the components.
 
 import (
-	"log"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"strings"
 	"time"
 
+	"github.com/gazed/vu/audio"
+	"github.com/gazed/vu/device"
+	"github.com/gazed/vu/load"
 	"github.com/gazed/vu/physics"
 )
 
@@ -32,6 +39,60 @@ type Eng interface {
 	// functions that take an EngAttr parameter, ie: vu.Color(0,0,0).
 	Set(...EngAttr) // Update one or more engine attributes.
 
+	// Preload loads and binds assets before they are needed, ie: at the
+	// start of a level. Assets are named as in Model.Load, ie: "msh:box",
+	// with "shd:" for shaders and "snd:" for sounds. Done is called during
+	// a later update with the names of any assets that failed to load.
+	// Models created afterwards use the already loaded assets.
+	Preload(assets []string, done func(failed []string))
+
+	// Manifest lists the assets used by the models and sounds of the
+	// given Pov and its children. The manifest can be saved and used
+	// with Preload the next time the scene is created.
+	Manifest(p *Pov) []string
+
+	// LoadBank reads an audio bank file describing sounds by name along
+	// with their gain, pitch variation, looping, round robin sound
+	// files, and the events that trigger them. Sounds added afterwards
+	// using a bank sound name, ie: Pov.AddSound("hit"), use the bank
+	// settings. See Pov.Trigger.
+	LoadBank(name string) error
+
+	// PlayMusic streams the named music track, ie: "theme" for
+	// "theme.ogg", instead of loading it all at once. The current track,
+	// if any, crossfades to the new track over fadeIn seconds. StopMusic
+	// fades out the current track. Music is played without a location.
+	// Audio bank sound names play the bank sound file and loop using the
+	// bank loop points, ie: repeat the end of a song without its intro.
+	PlayMusic(name string, fadeIn float64) error
+	StopMusic(fadeOut float64)
+
+	// Record starts recording from the default microphone. Recorded sound
+	// is 16 bit mono samples at the given samples per second, ie: 16000
+	// for voice. Reading the recording returns the samples recorded since
+	// the last read without blocking, so reads may return no samples.
+	// Up to a second of samples is kept between reads. Close the
+	// recording to stop recording and release the microphone.
+	Record(frequency uint32) (io.ReadCloser, error)
+
+	// Clipboard returns the text on the system clipboard, or the empty
+	// string if there is no text. Use the SetClipboard attribute to
+	// put text on the clipboard.
+	Clipboard() string
+
+	// OnClose registers a function that is called during an update when
+	// the user asks to close the window. Return true to close the window
+	// and shut down the engine, or false to keep running, ie: to ask to
+	// save before quitting. Windows close without asking when there is
+	// no registered function.
+	OnClose(allow func() bool)
+
+	// Diagnose registers a function that is called during an update
+	// with each problem found while loading, checking, or binding assets,
+	// ie: a missing texture or a shader compile log. Problems are logged
+	// when there is no registered function.
+	Diagnose(report func(d *Diagnostic))
+
 	// Timing gives application feedback. It is updated each processing loop.
 	// The returned update times should be averaged over multiple calls.
 	Usage() *Timing // Per update loop performance metrics.
@@ -76,19 +137,25 @@ type engine struct {
 	load     chan map[aid]string // Request asset load.
 	loaded   chan map[aid]asset  // Receive newly loaded assets.
 	stopLoad chan bool           // Send or close to stop loader.
+	loc      load.Locator        // Scene imports. Created when needed.
+	diagnose func(*Diagnostic)   // Application asset problem reports.
+	closing  func() bool         // Application close request check.
+	buses    [audio.Buses]setBus // Audio mixer settings.
 
 	// Application entities are grouped into components.
 	// All entities are Pov (location:orientation) based.
-	ids    *eids   // Entity id manager.
-	povs   *povs   // Entity transform component.
-	cams   *cams   // Camera component.
-	models *models // Visible component.
-	bodies *bodies // Physic component.
-	frames *frames // Render component.
-	sounds *sounds // Audio component.
-	lights *lights // Light component.
-	layers *layers // Pre-render-pass component
-	times  *Timing // Update loop timing statistics.
+	ids    *eids       // Entity id manager.
+	povs   *povs       // Entity transform component.
+	cams   *cams       // Camera component.
+	models *models     // Visible component.
+	bodies *bodies     // Physic component.
+	frames *frames     // Render component.
+	sounds *sounds     // Audio component.
+	lights *lights     // Light component.
+	layers *layers     // Pre-render-pass component
+	projs  *projectors // Texture projector component.
+	loads  *preloads   // Asset preload component.
+	times  *Timing     // Update loop timing statistics.
 }
 
 // newEngine is expected to be called once on startup
@@ -99,6 +166,9 @@ func newEngine(machine chan msg) *engine {
 	eng.data = newAppData()
 	eng.povs = newPovs()
 	eng.times = &Timing{}
+	for cnt := range eng.buses {
+		eng.buses[cnt] = setBus{bus: cnt, gain: 1}
+	}
 	eng.Reset() // allocate data components.
 
 	// init comunications with the load goroutine.
@@ -125,10 +195,12 @@ func (eng *engine) Reset() {
 	eng.models = newModels(eng)
 	eng.lights = newLights()
 	eng.layers = newLayers(eng)
+	eng.projs = newProjectors()
 	eng.sounds = newSounds(eng)
 	eng.sounds.setListener(eng.povs.get(0))
 	eng.bodies = newBodies()
 	eng.frames = newFrames()
+	eng.loads = newPreloads(eng)
 }
 
 // Shutdown is a user request to close down the engine.
@@ -136,7 +208,11 @@ func (eng *engine) Reset() {
 func (eng *engine) Shutdown() {
 	eng.alive = false
 	eng.disposePov(eng.root().id)
+	if eng.loc != nil {
+		eng.loc.Dispose()
+	}
 	if eng.machine != nil {
+		eng.sounds.shutdown()
 		eng.stopLoad <- true
 		eng.machine <- &shutdown{}
 	}
@@ -233,13 +309,23 @@ func (eng *engine) communicate() {
 		eng.stopLoad <- true // Tell the loader to stop.
 		return               // Device/window has closed.
 	case assets := <-eng.loaded:
-		eng.models.finishLoads(assets)
-		eng.sounds.finishLoads(assets)
+		eng.finishLoads(assets)
 	default:
 		// don't block when there are no channels to process.
 	}
 }
 
+// finishLoads reports any asset problems and passes the
+// loaded assets to the components that requested them.
+func (eng *engine) finishLoads(assets map[aid]asset) {
+	if d, ok := assets[diagnosisID].(*diagnosis); ok {
+		eng.report(d.diags)
+	}
+	eng.models.finishLoads(assets)
+	eng.sounds.finishLoads(assets)
+	eng.loads.finishLoads(assets)
+}
+
 // update polls user input, runs physics, calls application update,
 // and finally refreshes all models resulting in updated transforms.
 // The transform hierarchy is now ready to generate a render frame.
@@ -252,6 +338,12 @@ func (eng *engine) update(app App, dt time.Duration, ut uint64) {
 	state := eng.data.state // Engine state has been refreshed.
 	dts := dt.Seconds()     // delta time as float.
 
+	// let the application decide whether the window closes.
+	if input.closing && eng.closing != nil && eng.closing() {
+		eng.Shutdown()
+		return
+	}
+
 	// update the location and orientation of any physics bodies.
 	eng.bodies.stepVelocities(eng, dts) // Marks povs as dirty.
 
@@ -266,8 +358,10 @@ func (eng *engine) update(app App, dt time.Duration, ut uint64) {
 	if eng.alive {
 		eng.models.refresh(dts) // check for new load requests.
 		eng.sounds.refresh()    // check for new load requests.
+		eng.lights.animate(dts) // advance light effects.
+		eng.cams.animate(dts)   // advance camera moves and shakes.
 		eng.povs.updateWorldTransforms()
-		eng.sounds.repositionSoundListener()
+		eng.sounds.reposition(dts)
 	}
 }
 
@@ -287,7 +381,12 @@ func (eng *engine) release(rd *releaseData) { eng.machine <- rd }
 func (eng *engine) rebind(assets []asset) {
 	eng.machine <- &bindData{data: assets, reply: eng.bindReply} // request binds.
 	if err := <-eng.bindReply; err != nil {                      // wait for binds.
-		log.Printf("%s", err)
+		names := []string{}
+		for _, a := range assets {
+			names = append(names, assetName(a.aid(), a.label()))
+		}
+		d := &Diagnostic{Asset: strings.Join(names, ","), Kind: BindFailed, Msg: err.Error()}
+		eng.report([]*Diagnostic{d})
 	}
 }
 
@@ -350,8 +449,14 @@ func (eng *engine) setSolid(id eid, mass, bounce float64) {
 }
 
 // sound: audio entities.
-func (eng *engine) addSound(id eid, name string) { eng.sounds.create(id, name) }
-func (eng *engine) playSound(id eid, index int)  { eng.sounds.play(id, index) }
+func (eng *engine) addSound(id eid, name string)       { eng.sounds.create(id, name) }
+func (eng *engine) playSound(id eid, index int) *Sound { return eng.sounds.play(id, index, 1) }
+func (eng *engine) addGenerator(id eid, name string, g *audio.Generator) {
+	eng.sounds.generate(id, name, g)
+}
+func (eng *engine) trigger(id eid, event string, strength float64) {
+	eng.sounds.trigger(id, event, strength)
+}
 
 // FUTURE: cleaning up resources is not complete. Dispose currently means
 // removing entities from the Pov hierarchy and from the eng entity manager,
@@ -380,6 +485,8 @@ func (eng *engine) dispose(id eid, component int) {
 		eng.lights.dispose(id)
 	case PovLayer:
 		eng.layers.dispose(id)
+	case PovProjector:
+		eng.projs.dispose(id)
 	case PovNode:
 		eng.disposePov(id)
 	}
@@ -395,6 +502,54 @@ func (eng *engine) disposePov(id eid) {
 	eng.sounds.dispose(id)
 	eng.lights.dispose(id)
 	eng.layers.dispose(id)
+	eng.projs.dispose(id)
+}
+
+// Preload submits assets for loading ahead of their use.
+func (eng *engine) Preload(assets []string, done func(failed []string)) {
+	eng.loads.create(assets, done)
+}
+
+// Manifest returns the assets used by the given pov hierarchy.
+func (eng *engine) Manifest(p *Pov) []string { return eng.loads.manifest(p) }
+
+// Diagnose registers the application asset problem reporter.
+func (eng *engine) Diagnose(report func(d *Diagnostic)) { eng.diagnose = report }
+
+// OnClose registers the application close request check. The window
+// only reports close requests while there is a check.
+func (eng *engine) OnClose(allow func() bool) {
+	eng.closing = allow
+	eng.machine <- &interceptClose{intercept: allow != nil}
+}
+
+// LoadBank registers the sounds from the named audio bank.
+func (eng *engine) LoadBank(name string) error { return eng.sounds.loadBank(name) }
+
+// PlayMusic streams the named music track.
+func (eng *engine) PlayMusic(name string, fadeIn float64) error {
+	return eng.sounds.playMusic(name, fadeIn)
+}
+
+// StopMusic fades out the current music track.
+func (eng *engine) StopMusic(fadeOut float64) { eng.machine <- &stopMusic{fadeOut: fadeOut} }
+
+// Record opens the microphone using the machine audio layer.
+func (eng *engine) Record(frequency uint32) (io.ReadCloser, error) {
+	d := &audio.Data{Name: "record", Channels: 1, SampleBits: 16, Frequency: frequency}
+	oc := &openCapture{data: d, reply: make(chan *openCapture)}
+	eng.machine <- oc
+	if oc = <-oc.reply; oc.err != nil {
+		return nil, fmt.Errorf("Record: %s", oc.err)
+	}
+	return oc.capture, nil
+}
+
+// Clipboard gets the system clipboard text from the machine.
+func (eng *engine) Clipboard() string {
+	gc := &getClipboard{reply: make(chan string)}
+	eng.machine <- gc
+	return <-gc.reply
 }
 
 // Usage returns numbers collected each time through the
@@ -490,6 +645,68 @@ func CursorAt(x, y int) EngAttr {
 	}
 }
 
+// CursorLocked hides the cursor and holds it in place so that mouse
+// movement is unbounded, ie: for mouse look camera controls.
+// The movement is reported in Input.Dx, Input.Dy each update.
+// Engine attribute expected to be used in Eng.Set().
+func CursorLocked(lock bool) EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &lockCursor{lock: lock}
+	}
+}
+
+// CursorShape replaces the cursor with one of the system cursor
+// shapes, ie: HandCursor, while the cursor is over the window.
+// Engine attribute expected to be used in Eng.Set().
+func CursorShape(shape int) EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &cursorShape{shape: shape}
+	}
+}
+
+// CursorImage replaces the cursor with the given image while the cursor
+// is over the window. The hot spot hotX, hotY is the image pixel, from
+// the top left, that is the cursor location. Use CursorShape(ArrowCursor)
+// to restore the default cursor.
+// Engine attribute expected to be used in Eng.Set().
+func CursorImage(img image.Image, hotX, hotY int) EngAttr {
+	b := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	return func(e Eng) {
+		e.(*engine).machine <- &cursorImage{img: nrgba, hotX: hotX, hotY: hotY}
+	}
+}
+
+// Cursor shapes used with CursorShape.
+const (
+	ArrowCursor     = device.ArrowCursor     // Default pointer.
+	HandCursor      = device.HandCursor      // Pointing hand for links and buttons.
+	IBeamCursor     = device.IBeamCursor     // Text selection.
+	CrosshairCursor = device.CrosshairCursor // Precise selection.
+	ResizeXCursor   = device.ResizeXCursor   // Horizontal resize.
+	ResizeYCursor   = device.ResizeYCursor   // Vertical resize.
+)
+
+// SetClipboard puts the given text on the system clipboard.
+// Engine attribute expected to be used in Eng.Set().
+func SetClipboard(text string) EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &setClipboard{text: text}
+	}
+}
+
+// Rumble runs the low and high frequency motors of the game controller
+// in the given Input.Pads slot for secs seconds, ie: for impacts and
+// explosions. Magnitudes range from 0 to 1. A zero duration stops the
+// motors. Controllers without motors ignore rumbles.
+// Engine attribute expected to be used in Eng.Set().
+func Rumble(pad int, low, high, secs float64) EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &rumblePad{pad: pad, low: low, high: high, secs: secs}
+	}
+}
+
 // On enables/disables render attributes like Blend, CullFace, etc...
 // Engine attribute expected to be used in Eng.Set().
 func On(attr uint32, enabled bool) EngAttr {
@@ -498,32 +715,156 @@ func On(attr uint32, enabled bool) EngAttr {
 	}
 }
 
+// Title changes the window title.
+// Engine attribute expected to be used in Eng.Set().
+func Title(title string) EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &setTitle{title: title}
+	}
+}
+
+// WindowIcon uses the named image as the window icon, ie: in the title
+// bar, taskbar, or dock. The image is loaded the same way as textures.
+// Problems loading the image are reported as a Diagnostic.
+// Engine attribute expected to be used in Eng.Set().
+func WindowIcon(name string) EngAttr {
+	return func(e Eng) {
+		eng := e.(*engine)
+		if eng.loc == nil {
+			eng.loc = load.NewLocator()
+		}
+		data := &load.ImgData{}
+		if err := data.Load(name, eng.loc); err != nil {
+			eng.report([]*Diagnostic{{Asset: "tex:" + name, Kind: LoadFailed, Fatal: true, Msg: err.Error()}})
+			return
+		}
+		b := data.Img.Bounds()
+		nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(nrgba, nrgba.Bounds(), data.Img, b.Min, draw.Src)
+		eng.machine <- &windowIcon{img: nrgba}
+	}
+}
+
+// Attention asks for the user's attention while the window is not
+// focused, ie: by flashing the taskbar button or bouncing the dock icon.
+// Engine attribute expected to be used in Eng.Set().
+func Attention() EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &requestAttention{}
+	}
+}
+
+// Progress shows the progress p, from 0 to 1, on the taskbar button or
+// dock icon, ie: while loading levels. Negative progress removes the
+// progress. Ignored where the platform has no taskbar progress.
+// Engine attribute expected to be used in Eng.Set().
+func Progress(p float64) EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &showProgress{p: p}
+	}
+}
+
+// WindowMode switches between Windowed, Borderless, and Exclusive full
+// screen on the given State.Monitors index, -1 for the current monitor.
+// The display mode is one of the monitor modes and is only used for
+// Exclusive full screen.
+// Engine attribute expected to be used in Eng.Set().
+func WindowMode(mode, monitor int, dm device.Mode) EngAttr {
+	return func(e Eng) {
+		e.(*engine).machine <- &setWindowMode{mode: mode, monitor: monitor, dm: dm}
+	}
+}
+
+// Window modes used with WindowMode.
+const (
+	Windowed   = device.Windowed   // Window with a title bar and border.
+	Borderless = device.Borderless // Window without trim covering a monitor.
+	Exclusive  = device.Exclusive  // Borderless with a changed display mode.
+)
+
 // ToggleFullScreen flips full screen and windowed mode.
 // Engine attribute expected to be used in Eng.Set().
 func ToggleFullScreen() EngAttr {
 	return func(e Eng) { e.(*engine).machine <- &toggleScreen{} }
 }
 
-// Mute toggles the sound volume.
+// Mute toggles the sound volume. Same as MuteBus(MasterBus, mute).
+// Engine attribute expected to be used in Eng.Set().
+func Mute(mute bool) EngAttr { return MuteBus(MasterBus, mute) }
+
+// Volume sets the sound volume. Same as BusVolume(MasterBus, zeroToOne).
+// Engine attribute expected to be used in Eng.Set().
+func Volume(zeroToOne float64) EngAttr { return BusVolume(MasterBus, zeroToOne) }
+
+// BusVolume sets the volume for all sounds on a mixer bus, ie: MusicBus.
+// Valid values are 0 to 1. The volume is kept while the bus is muted.
+// Engine attribute expected to be used in Eng.Set().
+func BusVolume(bus int, zeroToOne float64) EngAttr {
+	return mixBus(bus, func(b *setBus) {
+		if zeroToOne >= 0 && zeroToOne <= 1 {
+			b.gain = zeroToOne
+		}
+	})
+}
+
+// MuteBus silences, or restores, all sounds on a mixer bus.
 // Engine attribute expected to be used in Eng.Set().
-func Mute(mute bool) EngAttr {
-	gain := 1.0
-	if mute {
-		gain = 0.0
+func MuteBus(bus int, mute bool) EngAttr {
+	return mixBus(bus, func(b *setBus) { b.mute = mute })
+}
+
+// PauseBus pauses, or resumes, all sounds on a mixer bus. Sounds played
+// on a paused bus start once the bus is resumed. Pausing the MasterBus
+// pauses all sounds, ie: while showing a game menu.
+// Engine attribute expected to be used in Eng.Set().
+func PauseBus(bus int, pause bool) EngAttr {
+	return mixBus(bus, func(b *setBus) { b.pause = pause })
+}
+
+// mixBus updates the engine mixer settings for a bus and sends
+// them to the machine. Unknown buses are ignored.
+func mixBus(bus int, change func(b *setBus)) EngAttr {
+	return func(e Eng) {
+		if eng := e.(*engine); bus >= MasterBus && bus < len(eng.buses) {
+			change(&eng.buses[bus])
+			b := eng.buses[bus]
+			eng.machine <- &b
+		}
 	}
+}
+
+// HRTF turns on head related transfer functions for 3D sound. HRTF
+// makes sounds appear above, below, or behind the listener when using
+// headphones. Not all audio devices support HRTF.
+// Engine attribute expected to be used in Eng.Set().
+func HRTF(enable bool) EngAttr {
 	return func(e Eng) {
-		e.(*engine).machine <- &setVolume{gain: gain}
+		e.(*engine).machine <- &enableHRTF{enable: enable}
 	}
 }
 
-// Volume sets the sound volume.
+// Doppler sets how much the pitch of sounds changes as they move towards
+// or away from the listener. A factor of 0 turns off the doppler effect
+// and the default is 1. The speed of sound, default 343.3, is in world
+// units per second. Sound and listener velocities come from how fast
+// their pov moves.
 // Engine attribute expected to be used in Eng.Set().
-func Volume(zeroToOne float64) EngAttr {
+func Doppler(factor, speedOfSound float64) EngAttr {
 	return func(e Eng) {
-		e.(*engine).machine <- &setVolume{gain: zeroToOne}
+		e.(*engine).machine <- &setDoppler{factor: factor, speed: speedOfSound}
 	}
 }
 
+// Voices sets the number of sounds that can play at once, default 32,
+// where 0 is no limit. Playing a sound over the limit stops the lowest
+// priority sound, the oldest for equal priorities, unless all playing
+// sounds have a higher priority, in which case the new sound is not
+// played. Sound priorities come from audio banks. See Eng.LoadBank.
+// Engine attribute expected to be used in Eng.Set().
+func Voices(limit int) EngAttr {
+	return func(e Eng) { e.(*engine).machine <- &setVoices{limit: limit} }
+}
+
 // Gravity changes the physics gravity constant.
 // Engine attribute expected to be used in Eng.Set().
 func Gravity(g float64) EngAttr {
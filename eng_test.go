@@ -6,6 +6,7 @@ package vu
 import (
 	"testing"
 
+	"github.com/gazed/vu/load"
 	"github.com/gazed/vu/math/lin"
 )
 
@@ -53,3 +54,20 @@ func BenchmarkSetDirect(b *testing.B) {
 		p.SetScale(4, 4, 4) // options using method calls.
 	}
 }
+
+// Window icons are loaded like textures and sent to the device.
+// Uses vu/eg resource directories.
+func TestWindowIcon(t *testing.T) {
+	eng := newEngine(make(chan msg, 1))
+	eng.loc = load.NewLocator().Dir("PNG", "eg/images")
+	WindowIcon("cell")(eng)
+	if icon, ok := (<-eng.machine).(*windowIcon); !ok || icon.img.Bounds().Dx() == 0 {
+		t.Errorf("Expected window icon")
+	}
+	reports := map[string]int{}
+	eng.Diagnose(func(d *Diagnostic) { reports[d.Asset] = d.Kind })
+	WindowIcon("missing")(eng)
+	if len(reports) != 1 || reports["tex:missing"] != LoadFailed || len(eng.machine) != 0 {
+		t.Errorf("Expected load failure got %v", reports)
+	}
+}
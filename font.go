@@ -24,6 +24,11 @@ type Labeler interface {
 	SetWrap(w int) Labeler      // Set the string wrap length in pixels.
 	StrSize() (w, h int)        // Width, Height in pixels, 0 if not loaded.
 	StrColor(r, g, b float64)   // Label color where each value is from 0-1
+
+	// Distance field font effects used by the "sdf" shader. The outline
+	// width and glow size are from 0 to 0.5 where 0 turns the effect off.
+	StrOutline(r, g, b, width float64) // Outline color and width.
+	StrGlow(r, g, b, size float64)     // Glow color and size.
 }
 
 // Labeler
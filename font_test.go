@@ -0,0 +1,31 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Distance field labels request a generated font and texture
+// and start with the outline and glow turned off.
+func TestSdfLabel(t *testing.T) {
+	eng := newEngine(nil)
+	p := eng.root().NewPov()
+	label := p.NewLabel("sdf", "DejaVuSans.sdf")
+	m := eng.models.data[p.id]
+	if _, ok := m.assets[assetID(fnt, "DejaVuSans.sdf")]; !ok {
+		t.Errorf("Expected font request %v", m.assets)
+	}
+	if _, ok := m.assets[assetID(tex, "DejaVuSans.sdf")]; !ok {
+		t.Errorf("Expected texture request %v", m.assets)
+	}
+	if !reflect.DeepEqual(m.Uniform("outline"), []float32{0, 0, 0, 0}) {
+		t.Errorf("Expected no outline got %v", m.Uniform("outline"))
+	}
+	label.StrGlow(1, 0.5, 0, 0.25)
+	if !reflect.DeepEqual(m.Uniform("glow"), []float32{1, 0.5, 0, 0.25}) {
+		t.Errorf("Expected glow got %v", m.Uniform("glow"))
+	}
+}
@@ -8,6 +8,7 @@ package vu
 
 import (
 	"log"
+	"math"
 	"time"
 
 	"github.com/gazed/vu/math/lin"
@@ -34,8 +35,12 @@ type frames struct {
 	drawCalls int // Number of models rendered last update.
 	verticies int // Number of verticies rendered last update.
 
+	// Lights found so far while updating a frame.
+	lit []litPov // Latest light last.
+
 	// Scratch variables: reused to reduce garbage collection.
 	white *Light  // default light.
+	dark  *Light  // no light for models without an applicable light.
 	mv    *lin.M4 // Scratch model-view matrix.
 	mvp   *lin.M4 // Scratch model-view-proj matrix.
 	v0    *lin.V4 // Scratch for location calculations.
@@ -46,6 +51,9 @@ func newFrames() *frames {
 	fs := &frames{}
 	fs.scene = []*Pov{}   // updated each frame
 	fs.white = newLight() // default light.
+	fs.dark = newLight()  // no light.
+	fs.dark.SetColor(0, 0, 0)
+	fs.lit = []litPov{}
 	fs.mv = &lin.M4{}
 	fs.mvp = &lin.M4{}
 	fs.v0 = &lin.V4{}
@@ -78,10 +86,14 @@ func (fs *frames) drawFrame(eng *engine) {
 	fs.snap = fs.snap[:0]   // resize keeping underlying memory.
 	fs.scene = fs.scene[:0] // ditto.
 	if root := eng.root(); root != nil {
+		eng.projs.update()
 		cam := eng.cams.get(root.id)
 		fs.drawCalls, fs.verticies = 0, 0
-		fs.scene = fs.updateScene(eng, 0, cam, root, fs.scene)
-		fs.snap = fs.updateFrame(eng, fs.scene, fs.snap)
+		if cam == nil || cam.enabled {
+			fs.scene = fs.updateScene(eng, 0, cam, root, fs.scene)
+			eng.cams.fitDepth() // before the projections are used.
+			fs.snap = fs.updateFrame(eng, fs.scene, fs.snap)
+		}
 	}
 	render.SortDraws(fs.snap)
 }
@@ -103,6 +115,9 @@ func (fs *frames) updateScene(eng *engine, rt uint32, cam *Camera, p *Pov, scene
 			p.toc = cam.Distance(px, py, pz) // may not make sense for 2D screen objects.
 			if culled = cam.isCulled(px, py, pz); !culled {
 				scene = append(scene, p)
+				if cam.fit && cam.Depth {
+					cam.fitModel(px, py, pz, fs.radius(p, m))
+				}
 			}
 		} else {
 			scene = append(scene, p) // Keep non-model nodes.
@@ -116,6 +131,9 @@ func (fs *frames) updateScene(eng *engine, rt uint32, cam *Camera, p *Pov, scene
 					renderTarget = layer.bid // update render target layer.
 				}
 				if camera := eng.cams.get(child.id); camera != nil {
+					if !camera.enabled {
+						continue // disabled cameras don't render.
+					}
 					cam = camera // update camera for culling.
 					cam.target = renderTarget
 				}
@@ -138,12 +156,27 @@ func (fs *frames) sceneLocation(p *Pov, is3D bool) (px, py, pz float64) {
 	return p.At() // 2D screen pixel space for UI culling.
 }
 
+// radius returns a model bounding radius from its mesh bounds and
+// world scale. Models whose mesh bounds are not known, ie: not loaded
+// or not float vertex data, return an infinite radius so that depth
+// fitting keeps the full depth range.
+func (fs *frames) radius(p *Pov, m *model) float64 {
+	if m.msh == nil || m.msh.radius <= 0 {
+		return math.Inf(1)
+	}
+	mm := p.mm
+	sx := mm.Xx*mm.Xx + mm.Xy*mm.Xy + mm.Xz*mm.Xz
+	sy := mm.Yx*mm.Yx + mm.Yy*mm.Yy + mm.Yz*mm.Yz
+	sz := mm.Zx*mm.Zx + mm.Zy*mm.Zy + mm.Zz*mm.Zz
+	return m.msh.radius * math.Sqrt(math.Max(sx, math.Max(sy, sz)))
+}
+
 // updateFrame prepares for rendering by converting a sequenced list
 // of Pov's into render system draw call requests.
 func (fs *frames) updateFrame(eng *engine, viewed []*Pov, f frame) frame {
-	var cam *Camera                // default nil camera.
-	light := fs.white              // Default light.
-	lwx, lwy, lwz := 0.0, 0.0, 0.0 // Light world position.
+	var cam *Camera     // default nil camera.
+	fs.lit = fs.lit[:0] // lights found in this frame.
+	eng.layers.fitShadows(eng.lights.data)
 
 	// turn pov's, models, and cameras into render draw requests.
 	for _, p := range viewed {
@@ -151,19 +184,21 @@ func (fs *frames) updateFrame(eng *engine, viewed []*Pov, f frame) frame {
 			cam = camera // keep the latest camera.
 		}
 
-		// keep the latest light.
+		// collect the lights.
 		if l := eng.lights.get(p.id); l != nil {
-			light = l
+			lp := litPov{light: l, wx: p.mm.Wx, wy: p.mm.Wy, wz: p.mm.Wz}
 			if cam != nil {
 				lx, ly, lz := p.At()
 				vec := fs.v0.SetS(lx, ly, lz, 1)
 				vec.MultvM(vec, cam.vm)
-				lwx, lwy, lwz = vec.X, vec.Y, vec.Z
+				lp.x, lp.y, lp.z = vec.X, vec.Y, vec.Z
 			}
+			fs.lit = append(fs.lit, lp)
 		}
 
 		// render all models with loaded assets.
 		if model := eng.models.getActive(p.id); model != nil {
+			light, lwx, lwy, lwz := fs.light(model.lightMask, p.mm.Wx, p.mm.Wy, p.mm.Wz)
 			if model.msh != nil && len(model.msh.vdata) > 0 {
 				var draw **render.Draw
 				if f, draw = fs.getDraw(f); draw != nil {
@@ -171,13 +206,19 @@ func (fs *frames) updateFrame(eng *engine, viewed []*Pov, f frame) frame {
 					// optionally render model shadowmap from light position.
 					// Its a sun light so no need to account for orientation.
 					if model.castShadow {
-						eng.layers.renderShadow(*draw, p, cam, model, lwx, lwy, lwz)
+						eng.layers.renderShadow(*draw, p, cam, model, light, lwx, lwy, lwz)
 						fs.drawCalls++                           // models rendered stat.
 						fs.verticies += model.msh.vdata[0].Len() // verticies rendered stat.
-						f, draw = fs.getDraw(f)                  // need new draw call.
+						if model.shadowOnly {
+							continue // invisible shadow casters are done.
+						}
+						f, draw = fs.getDraw(f) // need new draw call.
 					}
 
-					// always render model normally from camera position.
+					// render model normally from camera position.
+					if model.hasShadows && eng.layers.shadows != nil {
+						eng.layers.shadowLight(light)
+					}
 					drawPov(*draw, p, fs.mv, fs.mvp, cam, model, cam.target)
 					drawModel(*draw, model, p.mm, eng.layers.shadows)
 					drawLight(*draw, light, lwx, lwy, lwz)
@@ -195,6 +236,53 @@ func (fs *frames) updateFrame(eng *engine, viewed []*Pov, f frame) frame {
 	return f
 }
 
+// light returns the light, and its position, that affects a model
+// at the given world location with the given light mask. Lights with a
+// Range are culled when the model is out of reach. The in reach ranged
+// light closest to the model, relative to its range, is preferred over
+// unlimited lights. Otherwise the latest enabled unlimited light is used.
+// The default white light is used if there are no lights. No light is
+// used if there are lights, but none apply.
+func (fs *frames) light(mask uint32, wx, wy, wz float64) (l *Light, lx, ly, lz float64) {
+	if len(fs.lit) == 0 {
+		return fs.white, 0, 0, 0
+	}
+	near, nearest := -1, 1.0 // closest ranged light.
+	far := -1                // latest unlimited light.
+	for cnt := len(fs.lit) - 1; cnt >= 0; cnt-- {
+		lp := fs.lit[cnt]
+		if !lp.light.lights(mask) {
+			continue
+		}
+		if lp.light.Range <= 0 {
+			if far < 0 {
+				far = cnt
+			}
+			continue
+		}
+		dx, dy, dz := wx-lp.wx, wy-lp.wy, wz-lp.wz
+		if reach := math.Sqrt(dx*dx+dy*dy+dz*dz) / lp.light.Range; reach <= nearest {
+			near, nearest = cnt, reach
+		}
+	}
+	switch {
+	case near >= 0:
+		lp := fs.lit[near]
+		return lp.light, lp.x, lp.y, lp.z
+	case far >= 0:
+		lp := fs.lit[far]
+		return lp.light, lp.x, lp.y, lp.z
+	}
+	return fs.dark, 0, 0, 0
+}
+
+// litPov is a light and its position in view and world space.
+type litPov struct {
+	light      *Light  // Light color and settings.
+	x, y, z    float64 // Light position.
+	wx, wy, wz float64 // Light world position for culling.
+}
+
 // getDraw returns a render.Draw. The frame is grown as needed and draw
 // instances are reused if available. Every frame value up to cap(frame)
 // is expected to have already been allocated.
@@ -235,7 +323,7 @@ func drawPov(d *render.Draw, p *Pov, mv, mvp *lin.M4, cam *Camera, m *model, rt
 		bucket = render.DepthPass // pre-passes first.
 	case cam.Overlay > 0:
 		bucket = cam.Overlay // OVERLAY draw last.
-	case m.alpha < 1:
+	case m.alpha < 1 || m.blended:
 		bucket = render.Transparent // sort and draw after opaque.
 	}
 	depth := cam.Depth && m.depth // both must be true for depth rendering.
@@ -244,6 +332,10 @@ func drawPov(d *render.Draw, p *Pov, mv, mvp *lin.M4, cam *Camera, m *model, rt
 		tocam = p.toc
 	}
 	d.SetHints(bucket, tocam, depth, rt)
+	d.SetOrder(cam.order)
+	d.SetClear(cam.pass, cam.clear, cam.cc[0], cam.cc[1], cam.cc[2], cam.cc[3])
+	d.SetFloats("exposure", float32(cam.exposure))
+	d.SetFloats("logz", float32(cam.logDepth()))
 }
 
 // drawModel sets the model specific bound data references and
@@ -264,9 +356,40 @@ func drawModel(d *render.Draw, m *model, mm *lin.M4, shadows *layer) {
 			m.sm.Mult(mm, m.layer.vp)   // model (light) view.
 			m.sm.Mult(m.sm, m.layer.bm) // incorporate shadow bias.
 			d.SetDbm(m.sm)
+
+			// Shadow map filtering and acne controls.
+			d.SetFloats("sbias", float32(m.layer.bias), float32(m.layer.nbias))
+			d.SetFloats("sfilt", float32(m.layer.filter), float32(m.layer.size))
 		}
 	}
 
+	// Texture projection matrix maps model verticies to projector texture space.
+	if m.proj != nil {
+		m.sm.Mult(mm, m.proj.vpm)
+		d.SetPtm(m.sm)
+		additive := 0.0
+		if m.proj.Additive {
+			additive = 1
+		}
+		d.SetFloats("proj", float32(m.proj.Strength), float32(additive))
+	}
+
+	// Baked ambient occlusion. Shaders ignore the map when "aon" is 0.
+	if m.ao != nil {
+		d.SetAomap(m.ao.tid)
+		d.SetFloats("aon", 1)
+	} else {
+		d.SetAomap(0)
+		d.SetFloats("aon", 0)
+	}
+
+	// Environment cube map for skyboxes and reflections.
+	if m.cube != nil {
+		d.SetCubemap(m.cube.tid)
+	} else {
+		d.SetCubemap(0)
+	}
+
 	// use the shadow map texture for models that show shadows.
 	if m.hasShadows {
 		m.UseLayer(shadows)
@@ -274,6 +397,7 @@ func drawModel(d *render.Draw, m *model, mm *lin.M4, shadows *layer) {
 
 	// Set the bound data references.
 	d.SetRefs(m.shd.program, m.msh.vao, m.drawMode)
+	d.SetSurface(m.blend, m.twoSided)
 	if total := len(m.texs); total > 0 {
 		for cnt, t := range m.texs {
 			d.SetTex(total, cnt, t.tid, t.f0, t.fn)
@@ -319,6 +443,7 @@ func drawMaterial(d *render.Draw, m *material) {
 // drawLight sets the data needed by the render system.
 // In this case the light color.
 func drawLight(d *render.Draw, l *Light, px, py, pz float64) {
-	d.SetFloats("lp", float32(px), float32(py), float32(pz))    // position
-	d.SetFloats("lc", float32(l.R), float32(l.G), float32(l.B)) // color
+	r, g, b := l.color()
+	d.SetFloats("lp", float32(px), float32(py), float32(pz)) // position
+	d.SetFloats("lc", float32(r), float32(g), float32(b))    // color
 }
@@ -0,0 +1,61 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// gi.go provides a coarse screen space global illumination post effect.
+// DESIGN: The scene is rendered to an ImageBuffer layer. A screen model
+//         using the "gi" shader gathers the color of nearby pixels that
+//         are at a similar depth and adds it back as bounced light.
+//         Only light bouncing off visible surfaces is captured. See:
+//         http://john-chapman-graphics.blogspot.ca/2013/01/ssao-tutorial.html
+// FUTURE: Light propagation volumes for off screen bounce light.
+
+// GI adds approximate one bounce indirect lighting from a rendered scene.
+// Moving lights change the scene colors which in turn change the bounce
+// color on nearby surfaces. The scene is rendered to a layer which is
+// then displayed by a screen model using the "gi" shader. For example:
+//     scene := eng.Root().NewPov()
+//     cam := scene.NewCam()
+//     scene.NewLayer()
+//     ...
+//     screen := eng.Root().NewPov() // with its own camera.
+//     model := screen.NewPov().NewModel("gi", "msh:flipboard")
+//     model.UseLayer(scene.Layer())
+//     gi := vu.NewGI(cam, model)
+//     ...
+//     gi.Update() // once per App.Update.
+type GI struct {
+	Radius    float64 // Sample radius as a fraction of the screen.
+	Intensity float64 // Bounce light brightness.
+	Falloff   float64 // View space depth difference where bounce fades.
+
+	on     bool    // Effect is enabled by default.
+	cam    *Camera // Scene camera used to linearize depth.
+	screen Model   // Model using the "gi" shader.
+}
+
+// NewGI creates a screen space global illumination effect for the
+// scene seen by the given camera. The screen Model is expected to
+// use the "gi" shader with the scene render layer.
+func NewGI(cam *Camera, screen Model) *GI {
+	return &GI{Radius: 0.05, Intensity: 0.6, Falloff: 2, on: true, cam: cam, screen: screen}
+}
+
+// On returns true if the effect is enabled.
+func (g *GI) On() bool { return g.on }
+
+// SetOn enables or disables the effect. A disabled effect
+// displays the scene layer without any bounce light.
+func (g *GI) SetOn(on bool) { g.on = on }
+
+// Update sets the shader uniforms. Expected to be called each update
+// after any changes to the effect values or camera projection.
+func (g *GI) Update() {
+	intensity := g.Intensity
+	if !g.on {
+		intensity = 0 // no bounce light.
+	}
+	g.screen.SetUniform("gi", g.Radius, intensity, g.Falloff)
+	g.screen.SetUniform("gip", g.cam.pm.Zz, g.cam.pm.Wz) // depth linearization.
+}
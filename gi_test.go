@@ -0,0 +1,28 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+)
+
+// Disabling the effect removes the bounce light while keeping
+// the other effect values.
+func TestGIUpdate(t *testing.T) {
+	cam, _, _ := initScene()
+	screen := newModel("gi")
+	gi := NewGI(cam, screen)
+	gi.Update()
+	if u := screen.Uniform("gi"); len(u) != 3 || u[1] != float32(gi.Intensity) {
+		t.Errorf("Expected bounce intensity, got %v", u)
+	}
+	if u := screen.Uniform("gip"); len(u) != 2 || u[0] != float32(cam.pm.Zz) || u[1] != float32(cam.pm.Wz) {
+		t.Errorf("Expected camera projection values, got %v", u)
+	}
+	gi.SetOn(false)
+	gi.Update()
+	if u := screen.Uniform("gi"); u[1] != 0 || u[0] != float32(gi.Radius) {
+		t.Errorf("Expected no bounce light, got %v", u)
+	}
+}
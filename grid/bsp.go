@@ -0,0 +1,101 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import (
+	"math/rand"
+)
+
+// bsp is a level of rooms connected by corridors where the rooms are
+// placed using binary space partitioning.
+type bsp struct {
+	grid // superclass grid
+}
+
+// Generate a dungeon by recursively splitting the given space in two,
+// placing a random room in each of the final spaces, and then joining
+// the rooms on either side of every split with a corridor. See:
+//     http://www.roguebasin.com/index.php?title=Basic_BSP_Dungeon_generation
+// Joining the two halves of each split ensures all rooms are connected.
+func (b *bsp) Generate(width, depth int) Grid {
+	b.create(width, depth, allWalls)
+	width, depth = b.Size()
+	b.split(&room{1, 1, width - 2, depth - 2}) // keep the outside walls.
+	return b
+}
+
+// split divides the given space in two until the spaces are too small
+// to split, and then adds a room. Returns the rooms added to the space.
+func (b *bsp) split(space *room) (rooms []*room) {
+	min := 6 // smallest space that holds a 3x3 room and walls.
+	splitX, splitY := space.w >= min*2, space.h >= min*2
+	switch {
+	case splitX && splitY:
+		splitX = rand.Intn(space.w+space.h) < space.w // favour splitting the long side.
+	case !splitX && !splitY:
+		return []*room{b.room(space)}
+	}
+	var rooms1, rooms2 []*room
+	if splitX {
+		cut := min + rand.Intn(space.w-min*2+1)
+		rooms1 = b.split(&room{space.x, space.y, cut, space.h})
+		rooms2 = b.split(&room{space.x + cut, space.y, space.w - cut, space.h})
+	} else {
+		cut := min + rand.Intn(space.h-min*2+1)
+		rooms1 = b.split(&room{space.x, space.y, space.w, cut})
+		rooms2 = b.split(&room{space.x, space.y + cut, space.w, space.h - cut})
+	}
+	b.corridor(rooms1[rand.Intn(len(rooms1))], rooms2[rand.Intn(len(rooms2))])
+	return append(rooms1, rooms2...)
+}
+
+// room clears a randomly sized room inside the given space, leaving at
+// least one wall between the room and the space edges.
+func (b *bsp) room(space *room) *room {
+	w := 3 + rand.Intn(space.w-4)
+	h := 3 + rand.Intn(space.h-4)
+	rm := &room{space.x + 1 + rand.Intn(space.w-w-1), space.y + 1 + rand.Intn(space.h-h-1), w, h}
+	for x := rm.x; x < rm.x+rm.w; x++ {
+		for y := rm.y; y < rm.y+rm.h; y++ {
+			b.cells[x][y].isWall = allFloors
+		}
+	}
+	return rm
+}
+
+// corridor joins the centers of two rooms with a corridor that goes
+// along x and then y, or along y and then x.
+func (b *bsp) corridor(r0, r1 *room) {
+	x0, y0 := r0.x+r0.w/2, r0.y+r0.h/2
+	x1, y1 := r1.x+r1.w/2, r1.y+r1.h/2
+	if rand.Intn(2) == 0 {
+		b.dig(x0, y0, x1, y0)
+		b.dig(x1, y0, x1, y1)
+	} else {
+		b.dig(x0, y0, x0, y1)
+		b.dig(x0, y1, x1, y1)
+	}
+}
+
+// dig clears the cells on the straight line from x0, y0 to x1, y1.
+func (b *bsp) dig(x0, y0, x1, y1 int) {
+	dx, dy := sign(x1-x0), sign(y1-y0)
+	for x, y := x0, y0; ; x, y = x+dx, y+dy {
+		b.cells[x][y].isWall = allFloors
+		if x == x1 && y == y1 {
+			return
+		}
+	}
+}
+
+// sign returns -1, 0, or 1 for negative, zero, or positive values.
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,22 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import "testing"
+
+// Used to view level while tweaking algorithm.
+func TestBSPGenerate(t *testing.T) {
+	for seed := int64(1); seed < 20; seed++ {
+		g := New(BSPDungeon)
+		g.Seed(seed)
+		g.Generate(60, 30)
+		if w, h := g.Size(); w != 61 || h != 31 {
+			t.Fatalf("Could not create grid")
+		}
+		if open, reached := reachable(g); open < 100 || reached != open {
+			t.Errorf("Expected connected rooms %d %d", open, reached)
+		}
+	}
+	// g.(*bsp).dump() // view level.
+}
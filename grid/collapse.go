@@ -0,0 +1,221 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import (
+	"math/rand"
+)
+
+// Tile is a square piece used to solve a tile map with Collapse.
+// Tiles fit next to each other when the touching edges have the same
+// edge type, ie: two tiles with open passages on the touching edges.
+type Tile struct {
+	Edges  [4]int  // Edge types for the north, east, south, west edges.
+	Weight float64 // How often the tile is used relative to others.
+}
+
+// Collapse fills a width by depth map with tiles so that all touching
+// tile edges match, using the wave function collapse algorithm. See:
+//     https://github.com/mxgmn/WaveFunctionCollapse
+// Each map spot starts with all tiles possible. The spot with the fewest
+// possible tiles is set to a random tile, and the tiles that no longer
+// fit are removed from the surrounding spots, until every spot has one
+// tile. Edges on the outside of the map must match border unless border
+// is negative. The returned map holds the tile index for each spot where
+// 0,0 is the bottom left. False is returned if the tiles couldn't be
+// solved, ie: there are no tiles that fit the border.
+//
+// The seed can be set to solve the same map each time.
+func Collapse(width, depth int, tiles []Tile, border int, seed int64) (tileMap [][]int, ok bool) {
+	if width <= 0 || depth <= 0 || len(tiles) == 0 {
+		return nil, false
+	}
+	rgen := rand.New(rand.NewSource(seed))
+	for tries := 0; tries < 10; tries++ { // restart on contradictions.
+		if tileMap, ok = collapse(width, depth, tiles, border, rgen); ok {
+			return tileMap, ok
+		}
+	}
+	return nil, false
+}
+
+// Offsets to the north, east, south, west neighbours. The opposite
+// of each direction is two directions on.
+var tileSteps = [4][2]int{{0, 1}, {1, 0}, {0, -1}, {-1, 0}}
+
+// collapse tries once to solve a tile map.
+func collapse(width, depth int, tiles []Tile, border int, rgen *rand.Rand) (tileMap [][]int, ok bool) {
+	possible := make([][][]bool, width) // possible tiles for each spot.
+	count := make([][]int, width)       // number of possible tiles.
+	todo := [][2]int{}                  // spots to update.
+	for x := range possible {
+		possible[x] = make([][]bool, depth)
+		count[x] = make([]int, depth)
+		for y := range possible[x] {
+			possible[x][y] = make([]bool, len(tiles))
+			for t, tile := range tiles {
+				fits := tile.Weight > 0
+				for dir, step := range tileSteps {
+					nx, ny := x+step[0], y+step[1]
+					outside := nx < 0 || ny < 0 || nx >= width || ny >= depth
+					fits = fits && (!outside || border < 0 || tile.Edges[dir] == border)
+				}
+				if possible[x][y][t] = fits; fits {
+					count[x][y]++
+				}
+			}
+			if count[x][y] == 0 {
+				return nil, false
+			}
+			todo = append(todo, [2]int{x, y})
+		}
+	}
+	for {
+		// remove tiles that don't fit the neighbouring possible tiles.
+		for len(todo) > 0 {
+			x, y := todo[len(todo)-1][0], todo[len(todo)-1][1]
+			todo = todo[:len(todo)-1]
+			for dir, step := range tileSteps {
+				nx, ny := x+step[0], y+step[1]
+				if nx < 0 || ny < 0 || nx >= width || ny >= depth {
+					continue
+				}
+				edges := map[int]bool{}
+				for t, can := range possible[x][y] {
+					if can {
+						edges[tiles[t].Edges[dir]] = true
+					}
+				}
+				removed := false
+				for t, can := range possible[nx][ny] {
+					if can && !edges[tiles[t].Edges[(dir+2)%4]] {
+						possible[nx][ny][t] = false
+						count[nx][ny]--
+						removed = true
+					}
+				}
+				if count[nx][ny] == 0 {
+					return nil, false // contradiction.
+				}
+				if removed {
+					todo = append(todo, [2]int{nx, ny})
+				}
+			}
+		}
+
+		// pick the unsolved spot with the fewest possible tiles.
+		// Ties are broken randomly.
+		best, bx, by, ties := len(tiles)+1, -1, -1, 0
+		for x := range count {
+			for y, c := range count[x] {
+				switch {
+				case c <= 1 || c > best:
+				case c < best:
+					best, bx, by, ties = c, x, y, 1
+				default:
+					if ties++; rgen.Intn(ties) == 0 {
+						bx, by = x, y
+					}
+				}
+			}
+		}
+		if bx < 0 {
+			break // all spots are solved.
+		}
+
+		// choose one of the possible tiles by weight.
+		total := 0.0
+		for t, can := range possible[bx][by] {
+			if can {
+				total += tiles[t].Weight
+			}
+		}
+		pick, chosen := rgen.Float64()*total, -1
+		for t, can := range possible[bx][by] {
+			if can {
+				chosen = t
+				if pick -= tiles[t].Weight; pick < 0 {
+					break
+				}
+			}
+		}
+		for t := range possible[bx][by] {
+			possible[bx][by][t] = t == chosen
+		}
+		count[bx][by] = 1
+		todo = append(todo, [2]int{bx, by})
+	}
+
+	// every spot has one possible tile.
+	tileMap = make([][]int, width)
+	for x := range tileMap {
+		tileMap[x] = make([]int, depth)
+		for y := range tileMap[x] {
+			for t, can := range possible[x][y] {
+				if can {
+					tileMap[x][y] = t
+				}
+			}
+		}
+	}
+	return tileMap, true
+}
+
+// =============================================================================
+
+// waveCollapse is a level of passages made from tiles that are fitted
+// together using Collapse.
+type waveCollapse struct {
+	grid // superclass grid
+}
+
+// passageTiles are 2x2 cell tiles with an open cell in the middle and
+// open or closed passages on each side. There is also a solid tile.
+// Edge type 1 is a passage and 0 is a wall. The weights favour
+// corridors over dead ends.
+var passageTiles []Tile
+
+// init creates the passage tiles.
+func init() {
+	for sides := 0; sides < 16; sides++ {
+		tile, open := Tile{}, 0
+		for dir := range tile.Edges {
+			tile.Edges[dir] = (sides >> uint(dir)) & 1
+			open += tile.Edges[dir]
+		}
+		tile.Weight = []float64{0, 0.2, 1, 0.6, 0.3}[open]
+		passageTiles = append(passageTiles, tile)
+	}
+	passageTiles[0].Weight = 0.5 // solid tile.
+}
+
+// Generate a level by solving a tile map where each tile is a grid cell
+// with possible passages to the neighbouring tiles. Every other grid
+// cell is a tile center. The parts of the level that can't be reached
+// from the largest open area are filled in.
+func (wc *waveCollapse) Generate(width, depth int) Grid {
+	wc.create(width, depth, allWalls)
+	width, depth = wc.Size()
+	tileMap, ok := Collapse(width/2, depth/2, passageTiles, 0, rand.Int63())
+	if !ok {
+		return wc // all walls. Shouldn't happen since every tile has matches.
+	}
+	for tx := range tileMap {
+		for ty, t := range tileMap[tx] {
+			if t == 0 {
+				continue // solid.
+			}
+			x, y := tx*2+1, ty*2+1
+			wc.cells[x][y].isWall = allFloors
+			if passageTiles[t].Edges[0] == 1 {
+				wc.cells[x][y+1].isWall = allFloors
+			}
+			if passageTiles[t].Edges[1] == 1 {
+				wc.cells[x+1][y].isWall = allFloors
+			}
+		}
+	}
+	wc.largest()
+	return wc
+}
@@ -0,0 +1,53 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import "testing"
+
+// All touching tile edges match, including the border.
+func TestCollapse(t *testing.T) {
+	tileMap, ok := Collapse(12, 8, passageTiles, 0, 123)
+	if !ok || len(tileMap) != 12 || len(tileMap[0]) != 8 {
+		t.Fatalf("Expected solved tile map")
+	}
+	for x := range tileMap {
+		for y, tile := range tileMap[x] {
+			for dir, step := range tileSteps {
+				edge := passageTiles[tile].Edges[dir]
+				nx, ny := x+step[0], y+step[1]
+				if nx < 0 || ny < 0 || nx >= 12 || ny >= 8 {
+					if edge != 0 {
+						t.Errorf("Expected border edge at %d %d", x, y)
+					}
+				} else if other := passageTiles[tileMap[nx][ny]]; other.Edges[(dir+2)%4] != edge {
+					t.Errorf("Expected matching edges at %d %d", x, y)
+				}
+			}
+		}
+	}
+}
+
+// Tiles that can't fit the border can't be solved.
+func TestCollapseFail(t *testing.T) {
+	tiles := []Tile{{Edges: [4]int{1, 1, 1, 1}, Weight: 1}}
+	if _, ok := Collapse(4, 4, tiles, 0, 1); ok {
+		t.Errorf("Expected no solution")
+	}
+	if tileMap, ok := Collapse(4, 4, tiles, -1, 1); !ok || tileMap[3][3] != 0 {
+		t.Errorf("Expected solution without a border")
+	}
+}
+
+// Used to view level while tweaking algorithm.
+func TestWaveCollapseGenerate(t *testing.T) {
+	g := New(WaveCollapse)
+	g.Generate(40, 20)
+	if w, h := g.Size(); w != 41 || h != 21 {
+		t.Error("Could not create grid")
+	}
+	if open, reached := reachable(g); open < 40 || reached != open {
+		t.Errorf("Expected connected passages %d %d", open, reached)
+	}
+	// g.(*waveCollapse).dump() // view level.
+}
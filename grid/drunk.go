@@ -0,0 +1,50 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import (
+	"math/rand"
+)
+
+// drunk is a cave dug out by randomly wandering walkers.
+type drunk struct {
+	grid // superclass grid
+}
+
+// Generate a cave using the drunkard's walk algorithm. See:
+//     http://www.roguebasin.com/index.php?title=Random_Walk_Cave_Generation
+// Walkers start at open spots and dig out the cells they stagger through
+// until enough of the grid is open. Every walker starts in the area
+// already dug out, so the cave is always connected.
+func (d *drunk) Generate(width, depth int) Grid {
+	d.create(width, depth, allWalls)
+	width, depth = d.Size()
+	inside := (width - 2) * (depth - 2) // keep the outside walls.
+	open := []*cell{d.cells[width/2][depth/2]}
+	open[0].isWall = allFloors
+	for len(open) < inside*45/100 { // dig out 45% of the cells.
+		u := open[rand.Intn(len(open))]
+		for steps := 0; steps < inside/4; steps++ {
+			x, y := u.x, u.y
+			switch rand.Intn(4) {
+			case 0:
+				y++
+			case 1:
+				y--
+			case 2:
+				x++
+			default:
+				x--
+			}
+			if x < 1 || y < 1 || x >= width-1 || y >= depth-1 {
+				continue // stay inside.
+			}
+			if u = d.cells[x][y]; u.isWall {
+				u.isWall = allFloors
+				open = append(open, u)
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,21 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import "testing"
+
+// Used to view level while tweaking algorithm.
+func TestDrunkGenerate(t *testing.T) {
+	g := New(DrunkCave)
+	g.Generate(80, 40)
+	w, h := g.Size()
+	if w != 81 || h != 41 {
+		t.Error("Could not create grid")
+	}
+	open, reached := reachable(g)
+	if open < 79*39*45/100 || reached != open || g.IsOpen(0, 20) || g.IsOpen(40, 40) {
+		t.Errorf("Expected connected cave %d %d", open, reached)
+	}
+	// g.(*drunk).dump() // view level.
+}
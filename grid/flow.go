@@ -17,18 +17,29 @@ import (
 
 // Flow creates a map to help units move towards their goal. The flow is
 // initialized with a map and a goal. Afterwards each unit can query the flow
-// for the best diretion to move towards their goal.
+// for the best diretion to move towards their goal. The flow is created
+// once for all units sharing a goal, so that the cost of each unit query
+// does not depend on the map size or the number of units.
 type Flow interface {
 
 	// Create a new flow field based on the given goal location.
 	// This can be called to update maps as the goal changes.
 	Create(goalx, goaly int) // Call once before calling Next.
 
+	// CreateGoals is like Create except that units move towards
+	// the closest of many goals. Goals are x, y grid location pairs.
+	CreateGoals(goals []int)
+
 	// Next, based on the current grid location gx, gy, returns the
 	// next grid location nx, ny. 0, 0 is returned if the current
 	// location is the goal. 9, 9 is returned if the given location
-	// is invalid.
+	// is invalid or can't reach a goal.
 	Next(gx, gy int) (nx, ny int)
+
+	// Distance returns the number of direct grid steps from the
+	// given grid location to the closest goal. -1 is returned if the
+	// given location is invalid or can't reach a goal.
+	Distance(gx, gy int) int
 }
 
 // NewFlow creates a flow based on a plan.
@@ -47,6 +58,7 @@ type flow struct {
 	flowmap    [][]int // direction to goal for each cell.
 	neighbours []int   // scratch for calculating valid neighbours.
 	candidates []int   // map crawl candidates. For creating goalmap.
+	queued     []bool  // true for cells that are already candidates.
 	max        int     // impassable value for flow and goal maps.
 }
 
@@ -64,31 +76,45 @@ const (
 )
 
 // newFlow creates a flow map towards the given goal using the plan
-// as the cost map. Note that using MaxUint8 to mean impassable implies
-// flow map sizes less than 512x512. Flow maps really should be limited
-// to 100x100 or smaller.
+// as the cost map. Creating a flow visits each grid cell a fixed number
+// of times, so flows can cover large maps, ie: 1000x1000 cells.
 func newFlow(p Plan) *flow {
-	f := &flow{max: math.MaxUint8}
+	f := &flow{max: math.MaxInt32}
 	f.xsz, f.ysz = p.Size()
 	f.costmap = p
-	f.flowmap = make([][]int, f.ysz)
-	f.goalmap = make([][]int, f.ysz)
-	for y := range f.flowmap {
-		f.flowmap[y] = make([]int, f.xsz)
-		f.goalmap[y] = make([]int, f.xsz)
+	f.flowmap = make([][]int, f.xsz)
+	f.goalmap = make([][]int, f.xsz)
+	for x := range f.flowmap {
+		f.flowmap[x] = make([]int, f.ysz)
+		f.goalmap[x] = make([]int, f.ysz)
 	}
+	f.queued = make([]bool, f.xsz*f.ysz)
 	f.neighbours = make([]int, 8) // max neighbours is 8.
 	return f
 }
 
 // Create implements Flow.
-func (f *flow) Create(goalx, goaly int) {
-	f.createGoalmap(goalx, goaly) // create goal map from cost map.
-	f.createFlowmap(goalx, goaly) // create flow map from goal map.
+func (f *flow) Create(goalx, goaly int) { f.CreateGoals([]int{goalx, goaly}) }
+
+// CreateGoals implements Flow.
+func (f *flow) CreateGoals(goals []int) {
+	f.createGoalmap(goals) // create goal map from cost map.
+	f.createFlowmap(goals) // create flow map from goal map.
+}
+
+// Distance implements Flow.
+func (f *flow) Distance(gx, gy int) int {
+	if !f.valid(gx, gy) || f.goalmap[gx][gy] == f.max {
+		return -1
+	}
+	return f.goalmap[gx][gy]
 }
 
 // Next implements Flow.
 func (f *flow) Next(gx, gy int) (nx, ny int) {
+	if !f.valid(gx, gy) {
+		return 9, 9 // Invalid.
+	}
 	switch f.flowmap[gx][gy] {
 	case north:
 		return 0, 1
@@ -113,29 +139,41 @@ func (f *flow) Next(gx, gy int) (nx, ny int) {
 }
 
 // createGoalmap creates the goal map from the cost map.
-// This spreads out from the goalnode until each reachable node
-// has been processed.
-func (f *flow) createGoalmap(goalx, goaly int) {
+// This spreads out from the goal nodes until each reachable node
+// has been processed. Goals that are off the map or impassable
+// are ignored.
+func (f *flow) createGoalmap(goals []int) {
 
 	// reset all node costs to a large values.
-	for y, row := range f.goalmap {
-		for x := range row {
+	for x, column := range f.goalmap {
+		for y := range column {
 			f.goalmap[x][y] = f.max
 			f.flowmap[x][y] = f.max
 		}
 	}
+	for cnt := range f.queued {
+		f.queued[cnt] = false
+	}
 
-	// set the goal node to value 0 and push it on the open list.
-	f.goalmap[goalx][goaly] = 0
+	// set the goal nodes to value 0 and push them on the open list.
 	f.candidates = f.candidates[:0] // reset keeping memory.
-	f.candidates = append(f.candidates, f.id(goalx, goaly))
+	for cnt := 0; cnt+1 < len(goals); cnt += 2 {
+		gx, gy := goals[cnt], goals[cnt+1]
+		if f.valid(gx, gy) && f.costmap.IsOpen(gx, gy) && !f.queued[f.id(gx, gy)] {
+			f.goalmap[gx][gy] = 0
+			f.queued[f.id(gx, gy)] = true
+			f.candidates = append(f.candidates, f.id(gx, gy))
+		}
+	}
 
-	// while there are nodes on the open list.
-	for len(f.candidates) > 0 {
+	// while there are nodes on the open list. Candidates are processed
+	// first in first out, keeping the processed candidates in the list
+	// rather than copying the list each time one is removed.
+	for next := 0; next < len(f.candidates); next++ {
 
-		// get the first candidate, removing it from the candidate list.
-		candidate := f.candidates[0]
-		f.candidates = append(f.candidates[:0], f.candidates[1:]...)
+		// get the next candidate, allowing it to be a candidate again.
+		candidate := f.candidates[next]
+		f.queued[candidate] = false
 		x, y := f.at(candidate)
 
 		// process the candidates immediate neighbours ignoring diagonals.
@@ -153,14 +191,18 @@ func (f *flow) createGoalmap(goalx, goaly int) {
 				nx = x - 1
 			}
 
-			// check and update the cost for each neighbour.
+			// check and update the cost for each open neighbour.
+			if f.cost(nx, ny) == f.max {
+				continue
+			}
 			endNodeCost += f.cost(nx, ny)
 			if endNodeCost < f.goalmap[nx][ny] {
 				neighbourID := f.id(nx, ny)
 
 				// Set neighbour node cost and add it as a candidate.
 				f.goalmap[nx][ny] = endNodeCost
-				if !f.alreadyCandidate(neighbourID) {
+				if !f.queued[neighbourID] {
+					f.queued[neighbourID] = true
 					f.candidates = append(f.candidates, neighbourID)
 				}
 			}
@@ -169,9 +211,9 @@ func (f *flow) createGoalmap(goalx, goaly int) {
 }
 
 // createFlowmap creates the flow map from the goal map.
-func (f *flow) createFlowmap(goalx, goaly int) {
-	for y, row := range f.goalmap {
-		for x := range row {
+func (f *flow) createFlowmap(goals []int) {
+	for x, column := range f.goalmap {
+		for y := range column {
 			costToGoal := f.max
 			leastCost := f.max
 
@@ -181,25 +223,35 @@ func (f *flow) createFlowmap(goalx, goaly int) {
 			} else {
 
 				// the direction is the lowest cost of the eight neighbours.
+				// Diagonals are skipped when they would cut a wall corner.
 				neighbours := f.findNeighbours(x, y)
 				for _, dir := range neighbours {
+					costToGoal = f.max
 					switch dir {
 					case north:
 						costToGoal = f.goalmap[x][y+1]
 					case ne:
-						costToGoal = f.goalmap[x+1][y+1]
+						if f.open(x, y+1) && f.open(x+1, y) {
+							costToGoal = f.goalmap[x+1][y+1]
+						}
 					case east:
 						costToGoal = f.goalmap[x+1][y]
 					case se:
-						costToGoal = f.goalmap[x+1][y-1]
+						if f.open(x, y-1) && f.open(x+1, y) {
+							costToGoal = f.goalmap[x+1][y-1]
+						}
 					case south:
 						costToGoal = f.goalmap[x][y-1]
 					case sw:
-						costToGoal = f.goalmap[x-1][y-1]
+						if f.open(x, y-1) && f.open(x-1, y) {
+							costToGoal = f.goalmap[x-1][y-1]
+						}
 					case west:
 						costToGoal = f.goalmap[x-1][y]
 					case nw:
-						costToGoal = f.goalmap[x-1][y+1]
+						if f.open(x, y+1) && f.open(x-1, y) {
+							costToGoal = f.goalmap[x-1][y+1]
+						}
 					}
 					if costToGoal < leastCost {
 						leastCost = costToGoal
@@ -209,7 +261,11 @@ func (f *flow) createFlowmap(goalx, goaly int) {
 			}
 		}
 	}
-	f.flowmap[goalx][goaly] = goal
+	for cnt := 0; cnt+1 < len(goals); cnt += 2 {
+		if gx, gy := goals[cnt], goals[cnt+1]; f.valid(gx, gy) && f.goalmap[gx][gy] == 0 {
+			f.flowmap[gx][gy] = goal
+		}
+	}
 }
 
 // Find the all neighbours including diagonals. Relies on f.neighbours
@@ -262,15 +318,11 @@ func (f *flow) directNeighbours(x, y int) []int {
 	return f.neighbours
 }
 
-// scan the list of candidates for the given identifier.
-func (f *flow) alreadyCandidate(id int) bool {
-	for _, candidateID := range f.candidates {
-		if id == candidateID {
-			return true
-		}
-	}
-	return false
-}
+// valid returns true if x,y is on the map.
+func (f *flow) valid(x, y int) bool { return x >= 0 && x < f.xsz && y >= 0 && y < f.ysz }
+
+// open returns true if x,y can reach a goal.
+func (f *flow) open(x, y int) bool { return f.goalmap[x][y] != f.max }
 
 // The cost for a plan is very high for walls and 1 for open areas.
 func (f *flow) cost(x, y int) int {
@@ -80,6 +80,65 @@ func TestFlowNext(t *testing.T) {
 	}
 }
 
+// Units move towards the closest goal.
+func TestFlowGoals(t *testing.T) {
+	f := newFlow(&emptyPlan{})
+	f.CreateGoals([]int{0, 0, gridSize - 1, gridSize - 1, -1, 5})
+	if d := f.Distance(2, 1); d != 3 {
+		t.Errorf("Expected 3 steps to the bottom goal, got %d", d)
+	}
+	if d := f.Distance(gridSize-2, gridSize-3); d != 3 {
+		t.Errorf("Expected 3 steps to the top goal, got %d", d)
+	}
+	if dx, dy := f.Next(gridSize-2, gridSize-3); dx != 1 || dy != 1 {
+		t.Errorf("Expected move to the top goal, got %d %d", dx, dy)
+	}
+	if dx, dy := f.Next(0, 0); dx != 0 || dy != 0 {
+		t.Errorf("Expected no move at a goal, got %d %d", dx, dy)
+	}
+	if dx, dy := f.Next(-1, 5); dx != 9 || dy != 9 || f.Distance(gridSize, 0) != -1 {
+		t.Errorf("Expected invalid location, got %d %d", dx, dy)
+	}
+}
+
+// Maps need not be square and units don't cut across wall corners.
+func TestFlowWall(t *testing.T) {
+	f := newFlow(&wallPlan{})
+	f.Create(0, 0)
+	if dx, dy := f.Next(wallX+1, 1); dx != 0 || dy != -1 {
+		t.Errorf("Expected move around the wall corner, got %d %d", dx, dy)
+	}
+	if d := f.Distance(wallX, 2); d != -1 {
+		t.Errorf("Expected wall to be unreachable, got %d", d)
+	}
+	if d := f.Distance(wallX+1, 2); d != wallX+1+2 {
+		t.Errorf("Expected path under the wall, got %d", d)
+	}
+	if d := f.Distance(wallX+1, wallY-1); d != -1 {
+		t.Errorf("Expected closed room to be unreachable, got %d", d)
+	}
+}
+
+// wallPlan is a wide map with a wall that has a gap at the bottom,
+// and an enclosed space in the top right corner.
+type wallPlan struct{}
+
+const wallX, wallY = 2, 8
+
+func (wp *wallPlan) Size() (width, depth int) { return 3 * wallY, wallY }
+func (wp *wallPlan) IsOpen(x, y int) bool {
+	w, d := wp.Size()
+	switch {
+	case x < 0 || x >= w || y < 0 || y >= d:
+		return false
+	case x == wallX && y > 0:
+		return false // wall with a gap at the bottom.
+	case x == wallX+2 && y >= wallY-2, y == wallY-2 && x >= wallX:
+		return false // walled in space at the top.
+	}
+	return true
+}
+
 // unit tests.
 // ============================================================================
 // utility methods
@@ -136,6 +195,7 @@ func printFlowmap(f *flow) {
 // Run "go test -bench ." to get something like:
 // BenchmarkFlowEmpty	   50000	     64254 ns/op
 // BenchmarkFlowBlock	   50000	     45827 ns/op
+// BenchmarkFlowLarge	      10	 117177889 ns/op
 
 // No walls or barriers is slightly slower than when there are some walls.
 func BenchmarkFlowEmpty(b *testing.B) {
@@ -151,3 +211,18 @@ func BenchmarkFlowBlock(b *testing.B) {
 		f.Create(0, 0)
 	}
 }
+
+// Flow maps for large maps show the cost of creating the map is
+// proportional to the map size.
+func BenchmarkFlowLarge(b *testing.B) {
+	f := newFlow(&largePlan{})
+	for cnt := 0; cnt < b.N; cnt++ {
+		f.Create(0, 0)
+	}
+}
+
+// largePlan is a large map with no barriers.
+type largePlan struct{}
+
+func (lp *largePlan) Size() (width, depth int) { return 1000, 1000 }
+func (lp *largePlan) IsOpen(x, y int) bool     { return x >= 0 && x < 1000 && y >= 0 && y < 1000 }
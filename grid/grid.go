@@ -19,7 +19,8 @@
 //          }
 //       }
 //
-// Package grid also provides A-star and flow field path finding algorihms.
+// Package grid also provides A-star, hierarchical, and flow field path finding
+// algorihms, influence maps, and a wave function collapse tile solver.
 //
 // Package grid is provided as part of the vu (virtual universe) 3D engine.
 package grid
@@ -83,6 +84,22 @@ const (
 	// Dungeon produces interconnected square areas resembling a series
 	// of rooms connected by corridors.
 	Dungeon
+
+	// BSPDungeon produces rooms connected by corridors by recursively
+	// splitting the area in two using binary space partitioning.
+	BSPDungeon
+
+	// DrunkCave produces a single connected cave dug out by random walks.
+	DrunkCave
+
+	// VoronoiRegions produces irregular regions, each around one of a
+	// set of random points, connected by doors. VoronoiRegions grids
+	// implement Regions.
+	VoronoiRegions
+
+	// WaveCollapse produces passages from tiles that are fitted together
+	// using wave function collapse. See Collapse.
+	WaveCollapse
 )
 
 // Grid interface and grid types.
@@ -101,6 +118,16 @@ type Plan interface {
 	IsOpen(x, y int) bool // Return true if the given location is traversable.
 }
 
+// Regions is a Grid where the open cells are divided into numbered
+// regions, ie: a VoronoiRegions grid.
+type Regions interface {
+	Grid
+
+	// Region returns the region number, starting at 0, for the given open
+	// cell. Walls and locations outside the grid return -1.
+	Region(x, y int) int
+}
+
 // ===========================================================================
 // grid implements Grid
 
@@ -120,6 +147,14 @@ func New(gridType int) Grid {
 		return &cave{}
 	case Dungeon:
 		return &dungeon{}
+	case BSPDungeon:
+		return &bsp{}
+	case DrunkCave:
+		return &drunk{}
+	case VoronoiRegions:
+		return &voronoi{}
+	case WaveCollapse:
+		return &waveCollapse{}
 	}
 	return nil
 }
@@ -264,6 +299,52 @@ func (g *grid) neighbours(u *cell, isWall bool) []*cell {
 	return wp // walls or floors depending on isWall.
 }
 
+// areas labels each open cell with the number of the connected open
+// area that it belongs to. Area numbers start at 0.
+func (g *grid) areas() (areas map[*cell]int, count int) {
+	areas = map[*cell]int{}
+	for _, u := range g.cellSlice() {
+		if _, ok := areas[u]; ok || u.isWall {
+			continue
+		}
+		areas[u] = count
+		todo := []*cell{u}
+		for len(todo) > 0 {
+			c := todo[len(todo)-1]
+			todo = todo[:len(todo)-1]
+			for _, n := range g.neighbours(c, allFloors) {
+				if _, ok := areas[n]; !ok {
+					areas[n] = count
+					todo = append(todo, n)
+				}
+			}
+		}
+		count++
+	}
+	return areas, count
+}
+
+// largest fills in the open cells that can't be reached from
+// the largest connected open area.
+func (g *grid) largest() {
+	areas, count := g.areas()
+	sizes := make([]int, count)
+	for _, area := range areas {
+		sizes[area]++
+	}
+	keep := 0
+	for area, size := range sizes {
+		if size > sizes[keep] {
+			keep = area
+		}
+	}
+	for u, area := range areas {
+		if area != keep {
+			u.isWall = allWalls
+		}
+	}
+}
+
 // dump prints a grid for debugging purposes.  This expects a fixed width
 // font and looks better with some fixed fonts than others.
 // The grid is dumped such that the 0,0 is at the bottom left and on
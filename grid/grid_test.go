@@ -114,3 +114,34 @@ func TestNeighbours(t *testing.T) {
 		t.Error("0,0 should have 0 walls.")
 	}
 }
+
+// reachable returns the number of open cells and the number of them that
+// can be reached from the first open cell.
+func reachable(p Plan) (open, reached int) {
+	w, h := p.Size()
+	seen := map[[2]int]bool{}
+	todo := [][2]int{}
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if p.IsOpen(x, y) {
+				if open++; len(seen) == 0 {
+					seen[[2]int{x, y}] = true
+					todo = append(todo, [2]int{x, y})
+				}
+			}
+		}
+	}
+	for len(todo) > 0 {
+		at := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		reached++
+		for _, step := range tileSteps {
+			n := [2]int{at[0] + step[0], at[1] + step[1]}
+			if p.IsOpen(n[0], n[1]) && !seen[n] {
+				seen[n] = true
+				todo = append(todo, n)
+			}
+		}
+	}
+	return open, reached
+}
@@ -0,0 +1,426 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+// hpa is a hierarchical path finder (HPA*) that works with large 2D level
+// layouts that conform to Plan. See:
+//     http://webdocs.cs.ualberta.ca/~mmueller/ps/hpastar.pdf
+//
+// Design Notes:
+//   • The plan is divided into square clusters. Open spots on either side
+//     of a cluster edge are entrances. Short entrances get one pair of
+//     nodes in the middle, long ones get a pair at each end.
+//   • Nodes in the same cluster are joined with the cost of the shortest
+//     path between them that stays in the cluster. This is done once when
+//     the path finder is created.
+//   • Paths are found over the nodes, and then refined by finding the
+//     grid steps between the nodes one cluster at a time. This keeps the
+//     cost of finding a path low even though the plan is huge.
+//   • Moves follow the same rules as Path: diagonal moves are allowed
+//     when both the adjacent horizontal and vertical spots are open.
+
+// NewHierarchicalPath creates a path finder for large Plans, ie: 1024x1024,
+// where finding paths over all the grid spots would be too slow. The plan
+// is divided into clusters that are the given number of grid spots wide,
+// ie: 32. Returned paths are close to, but not always, the shortest path.
+// The plan is copied, so create a new path finder if the plan changes.
+func NewHierarchicalPath(p Plan, cluster int) Path { return newHpa(p, cluster) }
+
+// =============================================================================
+
+// hpa is the hierarchical implementation of Path.
+type hpa struct {
+	open     []bool      // floor plan open spots, for speed.
+	xsz, ysz int         // floor plan x,y dimensions.
+	cs       int         // cluster size in grid spots.
+	cx, cy   int         // number of clusters along x and y.
+	nodes    []hpaNode   // entrance nodes.
+	at       map[int]int // node indexes by grid spot id.
+	clusters [][]int     // node indexes for each cluster.
+
+	// scratch for searching.
+	dist    []int    // cost to each cluster spot, or node.
+	from    []int    // previous cluster spot, or node.
+	want    []bool   // cluster spots to find.
+	targets []int    // scratch for flood targets.
+	queue   hpaQueue // candidates ordered by estimated cost.
+	route   []int    // scratch for returning path points.
+}
+
+// hpaNode is a cluster entrance.
+type hpaNode struct {
+	x, y  int       // grid location.
+	edges []hpaEdge // connected nodes.
+}
+
+// hpaEdge is the cost to get to another node.
+type hpaEdge struct {
+	to, cost int
+}
+
+// Move costs. Diagonals cost about √2 times as much.
+const (
+	hpaOrth = 10
+	hpaDiag = 14
+	hpaMax  = int(^uint(0) >> 1) // unreachable.
+)
+
+// newHpa divides the plan into clusters and joins the cluster entrances.
+func newHpa(p Plan, cluster int) *hpa {
+	h := &hpa{cs: cluster, at: map[int]int{}}
+	if h.cs < 4 {
+		h.cs = 4
+	}
+	h.xsz, h.ysz = p.Size()
+	h.open = make([]bool, h.xsz*h.ysz)
+	for x := 0; x < h.xsz; x++ {
+		for y := 0; y < h.ysz; y++ {
+			h.open[x*h.ysz+y] = p.IsOpen(x, y)
+		}
+	}
+	h.cx, h.cy = (h.xsz+h.cs-1)/h.cs, (h.ysz+h.cs-1)/h.cs
+	h.clusters = make([][]int, h.cx*h.cy)
+	h.dist = make([]int, h.cs*h.cs)
+	h.from = make([]int, h.cs*h.cs)
+	h.want = make([]bool, h.cs*h.cs)
+	for bx := 0; bx < h.cx; bx++ {
+		for by := 0; by < h.cy; by++ {
+			x0, y0, x1, y1 := h.bounds(bx, by)
+			if x1 < h.xsz {
+				h.entrances(x1-1, y0, 1, 0, y1-y0) // east edge.
+			}
+			if y1 < h.ysz {
+				h.entrances(x0, y1-1, 0, 1, x1-x0) // north edge.
+			}
+		}
+	}
+
+	// join the nodes in each cluster.
+	for c, nodes := range h.clusters {
+		for _, n := range nodes {
+			h.flood(c, h.nodes[n].x, h.nodes[n].y, h.spots(c))
+			for _, m := range nodes {
+				if cost := h.dist[h.local(c, h.nodes[m].x, h.nodes[m].y)]; m != n && cost != hpaMax {
+					h.nodes[n].edges = append(h.nodes[n].edges, hpaEdge{m, cost})
+				}
+			}
+		}
+	}
+	return h
+}
+
+// entrances adds the entrance nodes along one cluster edge. The edge
+// starts at x, y and goes size spots along the edge. The spots on the
+// other side of the edge are dx, dy away.
+func (h *hpa) entrances(x, y, dx, dy, size int) {
+	ax, ay := dy, dx // along the edge.
+	start := -1
+	for cnt := 0; cnt <= size; cnt++ {
+		sx, sy := x+ax*cnt, y+ay*cnt
+		open := cnt < size && h.isOpen(sx, sy) && h.isOpen(sx+dx, sy+dy)
+		switch {
+		case open && start < 0:
+			start = cnt
+		case !open && start >= 0:
+			end := cnt - 1
+			if end-start < 6 {
+				mid := (start + end) / 2
+				h.join(x+ax*mid, y+ay*mid, dx, dy)
+			} else {
+				h.join(x+ax*start, y+ay*start, dx, dy)
+				h.join(x+ax*end, y+ay*end, dx, dy)
+			}
+			start = -1
+		}
+	}
+}
+
+// join adds nodes on either side of a cluster edge.
+func (h *hpa) join(x, y, dx, dy int) {
+	a, b := h.node(x, y), h.node(x+dx, y+dy)
+	h.nodes[a].edges = append(h.nodes[a].edges, hpaEdge{b, hpaOrth})
+	h.nodes[b].edges = append(h.nodes[b].edges, hpaEdge{a, hpaOrth})
+}
+
+// node returns the node for the given grid spot, creating it if necessary.
+func (h *hpa) node(x, y int) int {
+	if n, ok := h.at[x*h.ysz+y]; ok {
+		return n
+	}
+	n := len(h.nodes)
+	h.nodes = append(h.nodes, hpaNode{x: x, y: y})
+	h.at[x*h.ysz+y] = n
+	c := h.cluster(x, y)
+	h.clusters[c] = append(h.clusters[c], n)
+	return n
+}
+
+// cluster returns the cluster index for the given grid spot.
+func (h *hpa) cluster(x, y int) int { return (x/h.cs)*h.cy + y/h.cs }
+
+// bounds returns the grid spots covered by the given cluster.
+// The upper bounds are not part of the cluster.
+func (h *hpa) bounds(bx, by int) (x0, y0, x1, y1 int) {
+	x0, y0 = bx*h.cs, by*h.cs
+	return x0, y0, imin(x0+h.cs, h.xsz), imin(y0+h.cs, h.ysz)
+}
+
+// spots returns the scratch indexes of the nodes in the given cluster.
+func (h *hpa) spots(c int) []int {
+	h.targets = h.targets[:0]
+	for _, n := range h.clusters[c] {
+		h.targets = append(h.targets, h.local(c, h.nodes[n].x, h.nodes[n].y))
+	}
+	return h.targets
+}
+
+// local returns the scratch index for a grid spot in the given cluster.
+func (h *hpa) local(c, x, y int) int {
+	x0, y0, _, _ := h.bounds(c/h.cy, c%h.cy)
+	return (x-x0)*h.cs + (y - y0)
+}
+
+// flood finds the cost of the shortest path from x, y to the target
+// spots in the given cluster, staying inside the cluster. The costs are
+// left in h.dist and the previous spot along each path in h.from.
+// Targets are scratch indexes, see local.
+func (h *hpa) flood(c, x, y int, targets []int) {
+	bx, by := c/h.cy, c%h.cy
+	x0, y0, x1, y1 := h.bounds(bx, by)
+	for cnt := range h.dist {
+		h.dist[cnt], h.from[cnt], h.want[cnt] = hpaMax, -1, false
+	}
+	left := 0 // targets left to reach.
+	for _, id := range targets {
+		if !h.want[id] {
+			h.want[id] = true
+			left++
+		}
+	}
+	start := h.local(c, x, y)
+	h.dist[start] = 0
+	h.queue = append(h.queue[:0], hpaEstimate{start, 0})
+	for len(h.queue) > 0 && left > 0 {
+		at := h.queue.pop()
+		if at.estimate > h.dist[at.id] {
+			continue // already found a shorter way.
+		}
+		if h.want[at.id] {
+			h.want[at.id] = false
+			left--
+		}
+		ax, ay := x0+at.id/h.cs, y0+at.id%h.cs
+		for _, step := range hpaSteps {
+			nx, ny := ax+step[0], ay+step[1]
+			if nx < x0 || ny < y0 || nx >= x1 || ny >= y1 || !h.canStep(ax, ay, step[0], step[1]) {
+				continue
+			}
+			cost := h.dist[at.id] + hpaOrth
+			if step[0] != 0 && step[1] != 0 {
+				cost = h.dist[at.id] + hpaDiag
+			}
+			if id := (nx-x0)*h.cs + (ny - y0); cost < h.dist[id] {
+				h.dist[id], h.from[id] = cost, at.id
+				h.queue.push(hpaEstimate{id, cost})
+			}
+		}
+	}
+}
+
+// Offsets to the eight neighbouring grid spots.
+var hpaSteps = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+// canStep returns true if the move from x, y by dx, dy is allowed.
+// Diagonal moves need both adjacent spots to be open.
+func (h *hpa) canStep(x, y, dx, dy int) bool {
+	if !h.isOpen(x+dx, y+dy) {
+		return false
+	}
+	return dx == 0 || dy == 0 || (h.isOpen(x+dx, y) && h.isOpen(x, y+dy))
+}
+
+// isOpen returns true if the grid spot is inside the plan and open.
+func (h *hpa) isOpen(x, y int) bool {
+	return x >= 0 && y >= 0 && x < h.xsz && y < h.ysz && h.open[x*h.ysz+y]
+}
+
+// Find implements Path. Paths within one cluster stay inside the
+// cluster when possible.
+func (h *hpa) Find(fx, fy, tx, ty int) (path []int) {
+	h.route = h.route[:0]
+	if !h.isOpen(fx, fy) || !h.isOpen(tx, ty) {
+		return h.route // no path found, return empty list.
+	}
+	h.route = append(h.route, fx, fy)
+	sc, gc := h.cluster(fx, fy), h.cluster(tx, ty)
+	if sc == gc && h.refine(sc, fx, fy, tx, ty) {
+		return h.route
+	}
+	route := h.search(sc, gc, fx, fy, tx, ty)
+	if len(route) == 0 {
+		return h.route[:0] // no path found, return empty list.
+	}
+	px, py := fx, fy
+	for _, n := range route {
+		x, y := h.nodes[n].x, h.nodes[n].y
+		if c := h.cluster(x, y); c != h.cluster(px, py) {
+			h.route = append(h.route, x, y) // step across the cluster edge.
+		} else {
+			h.refine(c, px, py, x, y)
+		}
+		px, py = x, y
+	}
+	h.refine(gc, px, py, tx, ty)
+	return h.route
+}
+
+// search finds the nodes along the shortest path from the start to the
+// goal. The start and goal are joined to the nodes in their clusters.
+func (h *hpa) search(sc, gc, fx, fy, tx, ty int) (route []int) {
+	h.flood(gc, tx, ty, h.spots(gc))
+	toGoal := map[int]int{} // node cost to the goal.
+	for _, n := range h.clusters[gc] {
+		if cost := h.dist[h.local(gc, h.nodes[n].x, h.nodes[n].y)]; cost != hpaMax {
+			toGoal[n] = cost
+		}
+	}
+	estimate := func(n int) int { return octile(h.nodes[n].x-tx, h.nodes[n].y-ty) }
+	goal := len(h.nodes) // the goal node index.
+	cost := make([]int, len(h.nodes)+1)
+	from := make([]int, len(h.nodes)+1)
+	for cnt := range cost {
+		cost[cnt], from[cnt] = hpaMax, -1
+	}
+	h.flood(sc, fx, fy, h.spots(sc))
+	h.queue = h.queue[:0]
+	for _, n := range h.clusters[sc] {
+		if c := h.dist[h.local(sc, h.nodes[n].x, h.nodes[n].y)]; c != hpaMax {
+			cost[n] = c
+			h.queue.push(hpaEstimate{n, c + estimate(n)})
+		}
+	}
+	for len(h.queue) > 0 {
+		at := h.queue.pop()
+		if at.id == goal {
+			for n := from[goal]; n >= 0; n = from[n] {
+				route = append(route, n)
+			}
+			for i, j := 0, len(route)-1; i < j; i, j = i+1, j-1 {
+				route[i], route[j] = route[j], route[i]
+			}
+			return route
+		}
+		if at.estimate > cost[at.id]+estimate(at.id) {
+			continue // already found a shorter way.
+		}
+		if c, ok := toGoal[at.id]; ok && cost[at.id]+c < cost[goal] {
+			cost[goal], from[goal] = cost[at.id]+c, at.id
+			h.queue.push(hpaEstimate{goal, cost[goal]})
+		}
+		for _, e := range h.nodes[at.id].edges {
+			if c := cost[at.id] + e.cost; c < cost[e.to] {
+				cost[e.to], from[e.to] = c, at.id
+				h.queue.push(hpaEstimate{e.to, c + estimate(e.to)})
+			}
+		}
+	}
+	return nil
+}
+
+// refine adds the grid spots after x0, y0 up to and including x1, y1
+// to the route, where both spots are in the given cluster. Returns
+// false if the spots can't be joined inside the cluster.
+func (h *hpa) refine(c, x0, y0, x1, y1 int) bool {
+	end := h.local(c, x1, y1)
+	h.flood(c, x0, y0, []int{end})
+	if h.dist[end] == hpaMax {
+		return false
+	}
+	bx, by := c/h.cy, c%h.cy
+	cx0, cy0, _, _ := h.bounds(bx, by)
+	start := len(h.route)
+	for id := end; h.from[id] >= 0; id = h.from[id] {
+		h.route = append(h.route, cx0+id/h.cs, cy0+id%h.cs)
+	}
+	for i, j := start, len(h.route)-2; i < j; i, j = i+2, j-2 {
+		h.route[i], h.route[j] = h.route[j], h.route[i]
+		h.route[i+1], h.route[j+1] = h.route[j+1], h.route[i+1]
+	}
+	return true
+}
+
+// octile returns the cost of the shortest path over an open grid.
+func octile(dx, dy int) int {
+	dx, dy = iabs(dx), iabs(dy)
+	if dx < dy {
+		dx, dy = dy, dx
+	}
+	return hpaOrth*(dx-dy) + hpaDiag*dy
+}
+
+// iabs returns the absolute value of a.
+func iabs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// imin returns the smaller of a and b.
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// =============================================================================
+
+// hpaEstimate is a search candidate.
+type hpaEstimate struct {
+	id       int // cluster spot or node.
+	estimate int // estimated total path cost.
+}
+
+// hpaQueue orders candidates by lowest estimate. It is a binary heap
+// like container/heap, without boxing each candidate in an interface.
+type hpaQueue []hpaEstimate
+
+// push adds a candidate.
+func (q *hpaQueue) push(e hpaEstimate) {
+	*q = append(*q, e)
+	h := *q
+	for i := len(h) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if h[parent].estimate <= h[i].estimate {
+			break
+		}
+		h[parent], h[i] = h[i], h[parent]
+		i = parent
+	}
+}
+
+// pop removes and returns the candidate with the lowest estimate.
+func (q *hpaQueue) pop() hpaEstimate {
+	h := *q
+	top, last := h[0], len(h)-1
+	h[0] = h[last]
+	h = h[:last]
+	for i := 0; ; {
+		low, left, right := i, 2*i+1, 2*i+2
+		if left < len(h) && h[left].estimate < h[low].estimate {
+			low = left
+		}
+		if right < len(h) && h[right].estimate < h[low].estimate {
+			low = right
+		}
+		if low == i {
+			break
+		}
+		h[i], h[low] = h[low], h[i]
+		i = low
+	}
+	*q = h
+	return top
+}
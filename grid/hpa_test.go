@@ -0,0 +1,93 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import (
+	"testing"
+)
+
+// Find a path across an empty room, crossing many clusters.
+func TestHpaDiagonal(t *testing.T) {
+	p, dest := newHpa(&emptyPlan{}, 5), gridSize-1
+	pts := p.Find(0, 0, dest, dest)
+	if cost := pathCost(t, &emptyPlan{}, pts, 0, 0, dest, dest); cost > dest*hpaDiag*6/5 {
+		t.Errorf("Expected close to diagonal path, got %d %v", cost, pts)
+	}
+	if pts = p.Find(2, 2, 3, 4); len(pts) != 6 {
+		t.Errorf("Expected short path in one cluster, got %v", pts)
+	}
+}
+
+// Paths through rooms and corridors are close to the best path.
+func TestHpaRooms(t *testing.T) {
+	plan := &roomPlan{}
+	p := newHpa(plan, 8)
+	pts := p.Find(12, 22, 24, 10)
+	cost := pathCost(t, plan, pts, 12, 22, 24, 10)
+	best := pathCost(t, plan, newPath(plan).Find(12, 22, 24, 10), 12, 22, 24, 10)
+	if cost > best*11/10 {
+		t.Errorf("Expected close to best path %d %d %v", cost, best, pts)
+	}
+	if pts := p.Find(12, 22, 0, 0); len(pts) != 0 {
+		t.Errorf("Expected no path to a wall, got %v", pts)
+	}
+}
+
+// pathCost checks that a path goes from one spot to another using
+// valid moves, and returns the path cost.
+func pathCost(t *testing.T, plan Plan, pts []int, fx, fy, tx, ty int) (cost int) {
+	if len(pts) < 2 || pts[0] != fx || pts[1] != fy || pts[len(pts)-2] != tx || pts[len(pts)-1] != ty {
+		t.Fatalf("Expected path from %d,%d to %d,%d, got %v", fx, fy, tx, ty, pts)
+	}
+	for cnt := 2; cnt < len(pts); cnt += 2 {
+		x, y, dx, dy := pts[cnt-2], pts[cnt-1], pts[cnt]-pts[cnt-2], pts[cnt+1]-pts[cnt-1]
+		switch {
+		case iabs(dx) > 1 || iabs(dy) > 1 || (dx == 0 && dy == 0) || !plan.IsOpen(x+dx, y+dy):
+			t.Fatalf("Invalid step at %d,%d in %v", x, y, pts)
+		case dx != 0 && dy != 0:
+			if !plan.IsOpen(x+dx, y) || !plan.IsOpen(x, y+dy) {
+				t.Fatalf("Invalid diagonal at %d,%d in %v", x, y, pts)
+			}
+			cost += hpaDiag
+		default:
+			cost += hpaOrth
+		}
+	}
+	return cost
+}
+
+// unit tests
+// ============================================================================
+// benchmarking.
+
+// Check hierarchical path finding efficiency on a large map.
+// Run "go test -bench Hpa" to get something like:
+//     BenchmarkHpa	      20	  14320675 ns/op
+
+func BenchmarkHpa(b *testing.B) {
+	plan := New(RoomSkirmish)
+	plan.Seed(42)
+	plan.Generate(1023, 1023)
+	plan.(*rooms).largest() // ensure the corners are connected.
+	p := NewHierarchicalPath(plan, 32)
+	fx, fy, tx, ty := corner(plan, 0), corner(plan, 0), corner(plan, 1022), corner(plan, 1022)
+	if len(p.Find(fx, fy, tx, ty)) == 0 {
+		b.Fatalf("Expected path")
+	}
+	b.ResetTimer()
+	for cnt := 0; cnt < b.N; cnt++ {
+		p.Find(fx, fy, tx, ty)
+	}
+}
+
+// corner returns the open spot on the diagonal closest to at.
+func corner(p Plan, at int) int {
+	for d := 0; ; d++ {
+		for _, v := range []int{at + d, at - d} {
+			if p.IsOpen(v, v) {
+				return v
+			}
+		}
+	}
+}
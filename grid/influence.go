@@ -0,0 +1,262 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+// Influence maps spread values, like threat, coverage, or resources,
+// over the open spots of a level so that AI can ask questions like
+// "where is the safest spot nearby". See:
+//    http://www.gameaipro.com/GameAIPro2/GameAIPro2_Chapter30_Modular_Tactical_Influence_Maps.pdf
+//    http://aigamedev.com/open/tutorial/influence-map-mechanics/
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Influence is a layer of values over the open grid spots of a Plan.
+// Values are added with Deposit, spread with Blur, and faded with Decay.
+// Separate layers, ie: one for each side, can be combined with Add.
+// Walls and spots outside the plan always have a value of 0.
+type Influence interface {
+	Size() (width, depth int) // Matches the plan size.
+	Value(x, y int) float64   // Value at a grid spot.
+	Set(x, y int, v float64)  // Set the value at an open grid spot.
+	Clear()                   // Set all values to 0.
+
+	// Deposit adds amount at x, y and to the open spots within radius.
+	// The amount added falls off linearly with the distance from x, y.
+	Deposit(x, y int, amount float64, radius int)
+
+	// Decay multiplies all values by rate, ie: 0.9 each update means
+	// old influence fades away.
+	Decay(rate float64)
+
+	// Blur spreads values to the neighbouring open spots. Each spot
+	// moves towards the average of its open neighbours by spread where
+	// 0 is no change and 1 replaces the value with the average.
+	Blur(spread float64)
+
+	// Add adds the values from another layer of the same size scaled
+	// by scale, ie: use -1 to subtract enemy influence.
+	Add(layer Influence, scale float64)
+
+	// Best returns the open spot with the highest value within radius
+	// of x, y. A negative radius searches the whole map. Returns -1, -1
+	// if there are no open spots within radius.
+	Best(x, y, radius int) (bx, by int, v float64)
+
+	// Sample returns the value at a location between grid spots by
+	// blending the four closest spots, ie: 1.5, 1.5 is the average of
+	// spots 1,1 2,1 1,2 and 2,2.
+	Sample(x, y float64) float64
+
+	// Pick returns a random open spot within radius of x, y where spots
+	// with higher positive values are more likely to be picked. Returns
+	// -1, -1 if there are no spots with a positive value.
+	Pick(x, y, radius int) (px, py int)
+}
+
+// NewInfluence creates an influence layer for a plan. The open spots
+// are copied, so create a new layer if the plan changes.
+func NewInfluence(p Plan) Influence { return newInfluence(p) }
+
+// public interface
+// =============================================================================
+// private implementaiton
+
+// influence is the default implementation of Influence.
+type influence struct {
+	xsz, ysz int         // floor plan x,y dimensions.
+	open     [][]bool    // floor plan open spots, for speed.
+	values   [][]float64 // influence at each spot.
+	scratch  [][]float64 // values while blurring.
+}
+
+// newInfluence creates an empty influence layer the same size as
+// the plan.
+func newInfluence(p Plan) *influence {
+	in := &influence{}
+	in.xsz, in.ysz = p.Size()
+	in.open = make([][]bool, in.xsz)
+	in.values = make([][]float64, in.xsz)
+	in.scratch = make([][]float64, in.xsz)
+	for x := range in.open {
+		in.open[x] = make([]bool, in.ysz)
+		in.values[x] = make([]float64, in.ysz)
+		in.scratch[x] = make([]float64, in.ysz)
+		for y := range in.open[x] {
+			in.open[x][y] = p.IsOpen(x, y)
+		}
+	}
+	return in
+}
+
+// Size implements Influence.
+func (in *influence) Size() (width, depth int) { return in.xsz, in.ysz }
+
+// isOpen returns true if x, y is an open spot on the plan.
+func (in *influence) isOpen(x, y int) bool {
+	return x >= 0 && x < in.xsz && y >= 0 && y < in.ysz && in.open[x][y]
+}
+
+// Value implements Influence.
+func (in *influence) Value(x, y int) float64 {
+	if !in.isOpen(x, y) {
+		return 0
+	}
+	return in.values[x][y]
+}
+
+// Set implements Influence.
+func (in *influence) Set(x, y int, v float64) {
+	if in.isOpen(x, y) {
+		in.values[x][y] = v
+	}
+}
+
+// Clear implements Influence.
+func (in *influence) Clear() {
+	for x := range in.values {
+		for y := range in.values[x] {
+			in.values[x][y] = 0
+		}
+	}
+}
+
+// Deposit implements Influence.
+func (in *influence) Deposit(x, y int, amount float64, radius int) {
+	if radius < 0 {
+		radius = 0
+	}
+	reach := float64(radius + 1) // no influence at this distance.
+	x0, y0, x1, y1 := in.bounds(x, y, radius)
+	for sx := x0; sx <= x1; sx++ {
+		for sy := y0; sy <= y1; sy++ {
+			if in.open[sx][sy] && in.within(sx-x, sy-y, radius) {
+				dist := math.Hypot(float64(sx-x), float64(sy-y))
+				in.values[sx][sy] += amount * (1 - dist/reach)
+			}
+		}
+	}
+}
+
+// Decay implements Influence.
+func (in *influence) Decay(rate float64) {
+	for x := range in.values {
+		for y := range in.values[x] {
+			if in.values[x][y] *= rate; math.Abs(in.values[x][y]) < 1e-9 {
+				in.values[x][y] = 0 // avoid lingering tiny values.
+			}
+		}
+	}
+}
+
+// Blur implements Influence.
+func (in *influence) Blur(spread float64) {
+	for x := range in.values {
+		for y, v := range in.values[x] {
+			in.scratch[x][y] = v
+			if !in.open[x][y] {
+				continue
+			}
+			sum, count := 0.0, 0
+			for nx := x - 1; nx <= x+1; nx++ {
+				for ny := y - 1; ny <= y+1; ny++ {
+					if (nx != x || ny != y) && in.isOpen(nx, ny) {
+						sum += in.values[nx][ny]
+						count++
+					}
+				}
+			}
+			if count > 0 {
+				in.scratch[x][y] = v + (sum/float64(count)-v)*spread
+			}
+		}
+	}
+	in.values, in.scratch = in.scratch, in.values
+}
+
+// Add implements Influence.
+func (in *influence) Add(layer Influence, scale float64) {
+	if w, d := layer.Size(); w != in.xsz || d != in.ysz {
+		return // layers must match.
+	}
+	for x := range in.values {
+		for y := range in.values[x] {
+			if in.open[x][y] {
+				in.values[x][y] += layer.Value(x, y) * scale
+			}
+		}
+	}
+}
+
+// Best implements Influence.
+func (in *influence) Best(x, y, radius int) (bx, by int, v float64) {
+	bx, by, v = -1, -1, math.Inf(-1)
+	x0, y0, x1, y1 := in.bounds(x, y, radius)
+	for sx := x0; sx <= x1; sx++ {
+		for sy := y0; sy <= y1; sy++ {
+			if in.open[sx][sy] && in.values[sx][sy] > v && in.within(sx-x, sy-y, radius) {
+				bx, by, v = sx, sy, in.values[sx][sy]
+			}
+		}
+	}
+	if bx < 0 {
+		return -1, -1, 0
+	}
+	return bx, by, v
+}
+
+// Sample implements Influence.
+func (in *influence) Sample(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	ix, iy := int(fx), int(fy)
+	tx, ty := x-fx, y-fy
+	bottom := in.Value(ix, iy)*(1-tx) + in.Value(ix+1, iy)*tx
+	top := in.Value(ix, iy+1)*(1-tx) + in.Value(ix+1, iy+1)*tx
+	return bottom*(1-ty) + top*ty
+}
+
+// Pick implements Influence.
+func (in *influence) Pick(x, y, radius int) (px, py int) {
+	px, py = -1, -1
+	total := 0.0
+	x0, y0, x1, y1 := in.bounds(x, y, radius)
+	for sx := x0; sx <= x1; sx++ {
+		for sy := y0; sy <= y1; sy++ {
+			v := in.values[sx][sy]
+			if in.open[sx][sy] && v > 0 && in.within(sx-x, sy-y, radius) {
+				if total += v; rand.Float64()*total < v {
+					px, py = sx, sy // keeps each spot with chance v/total.
+				}
+			}
+		}
+	}
+	return px, py
+}
+
+// bounds returns the grid spots, clamped to the plan, around x, y that
+// could be within radius. A negative radius is the whole plan.
+func (in *influence) bounds(x, y, radius int) (x0, y0, x1, y1 int) {
+	if radius < 0 {
+		return 0, 0, in.xsz - 1, in.ysz - 1
+	}
+	x0, y0 = imax(x-radius, 0), imax(y-radius, 0)
+	x1, y1 = imin(x+radius, in.xsz-1), imin(y+radius, in.ysz-1)
+	return x0, y0, x1, y1
+}
+
+// within returns true if the offset dx, dy is inside a circle of the
+// given radius. A negative radius includes everything.
+func (in *influence) within(dx, dy, radius int) bool {
+	return radius < 0 || dx*dx+dy*dy <= radius*radius
+}
+
+// imax returns the larger of two ints.
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
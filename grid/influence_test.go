@@ -0,0 +1,77 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import (
+	"math"
+	"testing"
+)
+
+// Deposits fall off with distance and don't land on walls.
+func TestInfluenceDeposit(t *testing.T) {
+	in := newInfluence(&roomPlan{})
+	in.Deposit(1, 1, 4, 3)
+	if v := in.Value(1, 1); v != 4 {
+		t.Errorf("Expected full amount at center, got %f", v)
+	}
+	if near, far := in.Value(2, 1), in.Value(3, 1); near <= far || far <= 0 {
+		t.Errorf("Expected falloff, got %f %f", near, far)
+	}
+	if v := in.Value(0, 0); v != 0 {
+		t.Errorf("Expected no influence on walls, got %f", v)
+	}
+	if v := in.Value(5, 1); v != 0 {
+		t.Errorf("Expected no influence outside radius, got %f", v)
+	}
+}
+
+// Blurring spreads influence and decay fades it.
+func TestInfluenceBlurDecay(t *testing.T) {
+	in := newInfluence(&emptyPlan{})
+	in.Set(10, 10, 8)
+	in.Blur(0.5)
+	if v := in.Value(10, 10); v != 4 {
+		t.Errorf("Expected center to halve, got %f", v)
+	}
+	if v := in.Value(11, 11); v != 0.5 {
+		t.Errorf("Expected spread to neighbour, got %f", v)
+	}
+	in.Decay(0.5)
+	if v := in.Value(10, 10); v != 2 {
+		t.Errorf("Expected decay, got %f", v)
+	}
+	in.Clear()
+	if v := in.Value(10, 10); v != 0 {
+		t.Errorf("Expected clear, got %f", v)
+	}
+}
+
+// Combined layers can be queried for the best spots.
+func TestInfluenceQueries(t *testing.T) {
+	threat, cover := newInfluence(&emptyPlan{}), newInfluence(&emptyPlan{})
+	cover.Deposit(5, 5, 10, 4)
+	cover.Deposit(15, 15, 10, 4)
+	threat.Deposit(15, 15, 20, 4)
+	cover.Add(threat, -1)
+	if x, y, v := cover.Best(10, 10, -1); x != 5 || y != 5 || v != 10 {
+		t.Errorf("Expected safe cover at 5,5 got %d,%d %f", x, y, v)
+	}
+	if x, y, v := cover.Best(15, 15, 1); iabs(x-15)+iabs(y-15) != 1 || math.Abs(v+8) > 1e-9 {
+		t.Errorf("Expected best spot next to threat, got %d,%d %f", x, y, v)
+	}
+	if x, y, _ := cover.Best(-10, -10, 2); x != -1 || y != -1 {
+		t.Errorf("Expected no spots off the map, got %d,%d", x, y)
+	}
+	if v := cover.Sample(5.5, 5); v != (cover.Value(5, 5)+cover.Value(6, 5))/2 {
+		t.Errorf("Expected blended sample, got %f", v)
+	}
+	for cnt := 0; cnt < 10; cnt++ {
+		if x, y := cover.Pick(10, 10, -1); !cover.within(x-5, y-5, 4) {
+			t.Errorf("Expected pick near cover, got %d,%d", x, y)
+		}
+	}
+	if x, y := threat.Pick(0, 0, 3); x != -1 || y != -1 {
+		t.Errorf("Expected no pick, got %d,%d", x, y)
+	}
+}
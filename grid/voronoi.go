@@ -0,0 +1,143 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import (
+	"math/rand"
+)
+
+// voronoi is a level divided into irregular regions where each open cell
+// belongs to the region around the closest of a set of random points.
+type voronoi struct {
+	grid           // superclass grid
+	region [][]int // region number for each cell, -1 for walls.
+}
+
+// Generate a region map by scattering random points and giving each
+// cell to its closest point. See:
+//     https://en.wikipedia.org/wiki/Voronoi_diagram
+// Walls are placed between regions, and then doors are opened between
+// neighbouring regions until every region can be reached, plus a few
+// more doors so that there is more than one way around.
+func (v *voronoi) Generate(width, depth int) Grid {
+	v.create(width, depth, allFloors)
+	width, depth = v.Size()
+
+	// give each cell to the closest point.
+	points := make([]*cell, width*depth/64+2) // one point for each 8x8 cells.
+	for cnt := range points {
+		points[cnt] = &cell{1 + rand.Intn(width-2), 1 + rand.Intn(depth-2), allFloors}
+	}
+	closest := make([][]int, width)
+	for x := range closest {
+		closest[x] = make([]int, depth)
+		for y := range closest[x] {
+			best := width*width + depth*depth
+			for id, p := range points {
+				if dist := (p.x-x)*(p.x-x) + (p.y-y)*(p.y-y); dist < best {
+					closest[x][y], best = id, dist
+				}
+			}
+		}
+	}
+
+	// wall off the outside and each region from its east and north
+	// neighbours.
+	v.region = make([][]int, width)
+	for x := range v.region {
+		v.region[x] = make([]int, depth)
+		for y := range v.region[x] {
+			id := closest[x][y]
+			u := v.cells[x][y]
+			u.isWall = x == 0 || y == 0 || x == width-1 || y == depth-1 ||
+				closest[x+1][y] != id || closest[x][y+1] != id
+			if v.region[x][y] = id; u.isWall {
+				v.region[x][y] = -1
+			}
+		}
+	}
+	v.doors()
+	return v
+}
+
+// doors opens walls that separate open areas until the areas can all
+// be reached from each other. Some regions get an extra door to each
+// neighbour. Any small areas that still can't be reached are filled in.
+func (v *voronoi) doors() {
+	width, depth := v.Size()
+	candidates := []*cell{}
+	for x := 1; x < width-1; x++ {
+		for y := 1; y < depth-1; y++ {
+			if a, _ := v.between(x, y); a >= 0 {
+				candidates = append(candidates, v.cells[x][y])
+			}
+		}
+	}
+
+	// joined tracks the open areas that can reach each other.
+	areas, count := v.areas()
+	joined := make([]int, count)
+	for id := range joined {
+		joined[id] = id
+	}
+	root := func(id int) int {
+		for joined[id] != id {
+			id = joined[id]
+		}
+		return id
+	}
+	doors := map[[2]int]bool{}
+	for _, index := range rand.Perm(len(candidates)) {
+		u := candidates[index]
+		a, b := v.between(u.x, u.y)
+		aa, ab := v.beside(areas, u)
+		switch ra, rb := root(aa), root(ab); {
+		case ra != rb:
+			joined[ra] = rb // join the areas.
+		case a == b || doors[[2]int{a, b}] || rand.Intn(4) != 0:
+			continue // no extra door.
+		}
+		doors[[2]int{a, b}], doors[[2]int{b, a}] = true, true
+		u.isWall = allFloors
+		v.region[u.x][u.y] = a
+	}
+	v.largest()
+	for x := range v.cells {
+		for y, u := range v.cells[x] {
+			if u.isWall {
+				v.region[x][y] = -1
+			}
+		}
+	}
+}
+
+// beside returns the open areas on either side of a door.
+func (v *voronoi) beside(areas map[*cell]int, u *cell) (a, b int) {
+	if v.IsOpen(u.x-1, u.y) && v.IsOpen(u.x+1, u.y) {
+		return areas[v.cells[u.x-1][u.y]], areas[v.cells[u.x+1][u.y]]
+	}
+	return areas[v.cells[u.x][u.y-1]], areas[v.cells[u.x][u.y+1]]
+}
+
+// between returns the regions on either side of the wall at x, y.
+// Returns -1, -1 if the cell is not a wall between two open cells.
+func (v *voronoi) between(x, y int) (a, b int) {
+	switch {
+	case v.IsOpen(x, y):
+	case v.IsOpen(x-1, y) && v.IsOpen(x+1, y):
+		return v.region[x-1][y], v.region[x+1][y]
+	case v.IsOpen(x, y-1) && v.IsOpen(x, y+1):
+		return v.region[x][y-1], v.region[x][y+1]
+	}
+	return -1, -1
+}
+
+// Region returns the region number for the given cell.
+// Walls and cells outside the grid return -1.
+func (v *voronoi) Region(x, y int) int {
+	if !v.IsOpen(x, y) {
+		return -1
+	}
+	return v.region[x][y]
+}
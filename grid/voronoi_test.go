@@ -0,0 +1,33 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package grid
+
+import "testing"
+
+// Used to view level while tweaking algorithm.
+func TestVoronoiGenerate(t *testing.T) {
+	for seed := int64(1); seed < 20; seed++ {
+		g := New(VoronoiRegions).(Regions)
+		g.Seed(seed)
+		g.Generate(40, 40)
+		regions := map[int]bool{}
+		w, h := g.Size()
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				if id := g.Region(x, y); id >= 0 {
+					regions[id] = true
+				} else if g.IsOpen(x, y) {
+					t.Fatalf("Expected open cells to have a region %d %d", x, y)
+				}
+			}
+		}
+		if len(regions) < 10 || g.Region(-1, 0) != -1 {
+			t.Errorf("Expected regions, got %d", len(regions))
+		}
+		if open, reached := reachable(g); reached != open {
+			t.Errorf("Expected connected regions %d %d", open, reached)
+		}
+	}
+	// g.(*voronoi).dump() // view level.
+}
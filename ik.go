@@ -0,0 +1,213 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// ik.go bends animated joints so that the end of a joint chain reaches
+// a target, ie: feet planted on uneven terrain, or a hand reaching for
+// a door handle. Chains are solved each update after the animation pose
+// has been calculated, so the solved chain blends with the playing
+// animation. Some IK references:
+//    http://theorangeduck.com/page/simple-two-joint
+//    http://www.andreasaristidou.com/FABRIK.html
+
+import (
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// IK is an inverse kinematics chain of animation joints. The joints
+// are rotated, keeping their bone lengths, so that the last joint
+// reaches for the target. Chains of 3 joints, ie: hip, knee, ankle, are
+// solved exactly. Longer chains, ie: a tail or a spine, use FABRIK.
+// IK needs models whose animation data includes joint bind locations.
+//
+// Locations are in model space, ie: the space of the Pov holding the
+// animated model. The application updates Target each frame as needed.
+type IK struct {
+	Joints     []int   // Chain from root to end joint, ie: Model.Joint indicies.
+	Target     lin.V3  // Where the end joint reaches for.
+	Pole       *lin.V3 // Optional bend direction hint, ie: in front of the knee.
+	Weight     float64 // Blend from 0 for the animation, to 1 for the solved chain.
+	Iterations int     // Maximum FABRIK iterations. Default 10.
+
+	// scratch values reused each update.
+	at   []lin.V3  // current joint locations.
+	goal []lin.V3  // solved joint locations.
+	lens []float64 // distances between joints.
+}
+
+// NewIK creates an IK chain for the given joints ordered from the root
+// joint to the end joint. Each joint is expected to be a descendant of
+// the previous joint. The chain is fully weighted.
+func NewIK(joints ...int) *IK {
+	return &IK{Joints: joints, Weight: 1, Iterations: 10}
+}
+
+// ikReach is how close, in model units, FABRIK needs to get to the target.
+const ikReach = 0.001
+
+// solve updates the pose matricies so the chain reaches for its target.
+// Chains with invalid joints, or animations without bind locations,
+// are ignored.
+func (a *animation) solve(ik *IK, pose []lin.M4) {
+	n := len(ik.Joints)
+	if n < 2 || ik.Weight <= 0 || len(a.binds) != a.jointCnt || len(pose) < a.jointCnt {
+		return
+	}
+	for _, j := range ik.Joints {
+		if j < 0 || j >= a.jointCnt {
+			return
+		}
+	}
+	if len(ik.at) != n {
+		ik.at, ik.goal, ik.lens = make([]lin.V3, n), make([]lin.V3, n), make([]float64, n-1)
+	}
+	for cnt, j := range ik.Joints {
+		a.jointAt(j, pose, &ik.at[cnt])
+		ik.goal[cnt] = ik.at[cnt]
+	}
+	if n == 3 {
+		twoBone(ik.goal, &ik.Target, ik.Pole)
+	} else {
+		fabrik(ik.goal, ik.lens, &ik.Target, ik.Iterations)
+	}
+
+	// turn each joint so its bone points at its solved child location.
+	// Earlier turns move the later joints so get their new locations.
+	from, to, q := &lin.V3{}, &lin.V3{}, &lin.Q{}
+	for cnt := 0; cnt < n-1; cnt++ {
+		root := &ik.at[cnt]
+		a.jointAt(ik.Joints[cnt], pose, root)
+		a.jointAt(ik.Joints[cnt+1], pose, &ik.at[cnt+1])
+		from.Sub(&ik.at[cnt+1], root)
+		to.Sub(&ik.goal[cnt+1], &ik.goal[cnt])
+		q.Set(lin.QI).SetRotation(from, to)
+		if ik.Weight < 1 {
+			q.Slerp(lin.QI, q, ik.Weight)
+		}
+		a.turn(ik.Joints[cnt], pose, root, q)
+	}
+}
+
+// jointAt updates v to be the posed location of joint j in model space.
+// Joints without a bind location use the model origin.
+// The updated vector v is returned.
+func (a *animation) jointAt(j int, pose []lin.M4, v *lin.V3) *lin.V3 {
+	return a.posedAt(j, &pose[j], v)
+}
+
+// posedAt updates v to be the location of joint j moved by its pose
+// matrix m. The updated vector v is returned.
+func (a *animation) posedAt(j int, m *lin.M4, v *lin.V3) *lin.V3 {
+	b := &lin.V3{}
+	if j < len(a.binds) {
+		b = &a.binds[j]
+	}
+	v.SetS(b.X*m.Xx+b.Y*m.Yx+b.Z*m.Zx+m.Wx,
+		b.X*m.Xy+b.Y*m.Yy+b.Z*m.Zy+m.Wy,
+		b.X*m.Xz+b.Y*m.Yz+b.Z*m.Zz+m.Wz)
+	return v
+}
+
+// turn rotates joint j, and all the joints below it, by rotation q
+// around the model space location at.
+func (a *animation) turn(j int, pose []lin.M4, at *lin.V3, q *lin.Q) {
+	inv := &lin.Q{}
+	a.jnt0.SetQ(inv.Inv(q)) // row vectors are turned by the inverse.
+	a.jnt0.TranslateTM(-at.X, -at.Y, -at.Z).TranslateMT(at.X, at.Y, at.Z)
+	pose[j].Mult(&pose[j], a.jnt0)
+
+	// parents come before their children, so one pass finds
+	// all the joints below j.
+	below := a.below[:0]
+	for cnt := 0; cnt < a.jointCnt; cnt++ {
+		p := int(a.joints[cnt])
+		below = append(below, cnt > j && p >= 0 && (p == j || below[p]))
+		if below[cnt] {
+			pose[cnt].Mult(&pose[cnt], a.jnt0)
+		}
+	}
+	a.below = below
+}
+
+// twoBone updates the middle and end locations of the 3 joint chain at
+// so the end reaches target, bending towards pole if given, or towards
+// the current middle joint. Targets out of reach are pointed at.
+func twoBone(at []lin.V3, target, pole *lin.V3) {
+	a, b, c := &at[0], &at[1], &at[2]
+	l1, l2 := a.Dist(b), b.Dist(c)
+	dir := &lin.V3{}
+	d := dir.Sub(target, a).Len()
+	if d < lin.Epsilon || l1 < lin.Epsilon || l2 < lin.Epsilon {
+		return
+	}
+	dir.Scale(dir, 1/d)
+	d = lin.Clamp(d, math.Abs(l1-l2), l1+l2)
+
+	// bend in the plane of the target and the hint.
+	bend := &lin.V3{}
+	if pole != nil {
+		bend.Sub(pole, a)
+	} else {
+		bend.Sub(b, a)
+	}
+	bend.Sub(bend, (&lin.V3{}).Scale(dir, bend.Dot(dir)))
+	if bend.Len() < lin.Epsilon {
+		other := &lin.V3{}
+		dir.Plane(bend, other) // any perpendicular will do.
+	}
+	bend.Unit()
+
+	// law of cosines gives the middle joint distance along
+	// the target direction and its distance from that line.
+	x := (d*d + l1*l1 - l2*l2) / (2 * d)
+	h := math.Sqrt(math.Max(l1*l1-x*x, 0))
+	b.SetS(a.X+dir.X*x+bend.X*h, a.Y+dir.Y*x+bend.Y*h, a.Z+dir.Z*x+bend.Z*h)
+	c.SetS(a.X+dir.X*d, a.Y+dir.Y*d, a.Z+dir.Z*d)
+}
+
+// fabrik updates the locations of the joint chain at so the end reaches
+// target, keeping the root in place and the distances between joints.
+// Targets out of reach are pointed at. Lens is scratch space for the
+// distances between joints.
+func fabrik(at []lin.V3, lens []float64, target *lin.V3, iterations int) {
+	n, total := len(at), 0.0
+	for cnt := range lens {
+		lens[cnt] = at[cnt].Dist(&at[cnt+1])
+		total += lens[cnt]
+	}
+	root, dir := at[0], &lin.V3{}
+	if root.Dist(target) >= total {
+		dir.Sub(target, &root).Unit()
+		for cnt, l := range lens {
+			j := &at[cnt]
+			at[cnt+1].SetS(j.X+dir.X*l, j.Y+dir.Y*l, j.Z+dir.Z*l)
+		}
+		return
+	}
+	if iterations <= 0 {
+		iterations = 10
+	}
+	for it := 0; it < iterations && at[n-1].Dist(target) > ikReach; it++ {
+		at[n-1].Set(target) // backwards from the target.
+		for cnt := n - 2; cnt >= 0; cnt-- {
+			ikPull(&at[cnt], &at[cnt+1], lens[cnt])
+		}
+		at[0] = root // forwards from the root.
+		for cnt := 0; cnt < n-1; cnt++ {
+			ikPull(&at[cnt+1], &at[cnt], lens[cnt])
+		}
+	}
+}
+
+// ikPull moves joint v towards joint to until it is length away.
+func ikPull(v, to *lin.V3, length float64) {
+	dir := &lin.V3{}
+	if dir.Sub(v, to).Len() < lin.Epsilon {
+		return // no direction to pull in.
+	}
+	dir.Unit()
+	v.SetS(to.X+dir.X*length, to.Y+dir.Y*length, to.Z+dir.Z*length)
+}
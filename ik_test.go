@@ -0,0 +1,101 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// ikArm creates a straight up chain of joints 1 unit apart in the
+// bind pose, ie: shoulder, elbow, wrist, hand.
+func ikArm(joints int) (*animation, []lin.M4) {
+	a := newAnimation("arm")
+	frames, parents, binds := []*lin.M4{}, []int32{}, []lin.V3{}
+	for cnt := 0; cnt < joints; cnt++ {
+		frames = append(frames, lin.NewM4I())
+		parents = append(parents, int32(cnt-1))
+		binds = append(binds, lin.V3{Y: float64(cnt)})
+	}
+	a.setData(frames, parents, nil, binds, []movement{{name: "rest", f0: 0, fn: 1, rate: 30}})
+	pose := make([]lin.M4, joints)
+	a.animate(0, 0, 0, pose)
+	return a, pose
+}
+
+// Two bone chains reach targets exactly, keep their bone lengths,
+// and bend towards the pole.
+func TestTwoBoneIK(t *testing.T) {
+	a, pose := ikArm(3)
+	ik := NewIK(0, 1, 2)
+	ik.Target.SetS(1, 1, 0)
+	ik.Pole = &lin.V3{X: -1, Y: 1}
+	a.solve(ik, pose)
+	elbow, hand := a.jointAt(1, pose, &lin.V3{}), a.jointAt(2, pose, &lin.V3{})
+	if !hand.Aeq(&ik.Target) || !lin.Aeq(elbow.Len(), 1) || !lin.Aeq(elbow.Dist(hand), 1) {
+		t.Fatalf("Expected hand at target, got elbow %v hand %v", *elbow, *hand)
+	}
+	if !elbow.Aeq(&lin.V3{Y: 1}) {
+		t.Errorf("Expected elbow bent towards the pole, got %v", *elbow)
+	}
+
+	// out of reach targets are pointed at.
+	ik.Target.SetS(0, 0, 5)
+	a.animate(0, 0, 0, pose)
+	a.solve(ik, pose)
+	if hand = a.jointAt(2, pose, hand); !hand.Aeq(&lin.V3{Z: 2}) {
+		t.Errorf("Expected straight arm, got %v", *hand)
+	}
+}
+
+func TestFabrikIK(t *testing.T) {
+	a, pose := ikArm(5)
+	ik := NewIK(0, 1, 2, 3, 4)
+	ik.Target.SetS(2, 2, 0)
+	a.solve(ik, pose)
+	prev, v := &lin.V3{}, &lin.V3{}
+	for cnt := 1; cnt < 5; cnt++ {
+		if a.jointAt(cnt, pose, v); !lin.Aeq(v.Dist(prev), 1) {
+			t.Fatalf("Expected bone lengths kept, got %f", v.Dist(prev))
+		}
+		prev.Set(v)
+	}
+	if v.Dist(&ik.Target) > 0.01 {
+		t.Errorf("Expected end near target, got %v", *v)
+	}
+}
+
+// Partly weighted chains blend with the animation.
+func TestIKWeight(t *testing.T) {
+	a, pose := ikArm(3)
+	ik := NewIK(0, 1, 2)
+	ik.Target.SetS(0, -2, 0)
+	ik.Weight = 0
+	if a.solve(ik, pose); !a.jointAt(2, pose, &lin.V3{}).Aeq(&lin.V3{Y: 2}) {
+		t.Errorf("Expected no weight to keep the animation")
+	}
+	ik.Weight, ik.Target = 0.5, lin.V3{X: 2}
+	a.solve(ik, pose)
+	if hand := a.jointAt(2, pose, &lin.V3{}); hand.Aeq(&ik.Target) || hand.Aeq(&lin.V3{Y: 2}) {
+		t.Errorf("Expected hand part way to the target, got %v", *hand)
+	}
+}
+
+func TestModelIK(t *testing.T) {
+	m := &model{}
+	m.anm, m.pose = ikArm(3)
+	ik := NewIK(0, 1, 2)
+	m.AddIK(ik)
+	m.AddIK(ik)
+	if len(m.iks) != 1 {
+		t.Fatalf("Expected one chain, got %d", len(m.iks))
+	}
+	if x, y, z := m.JointAt(2); x != 0 || y != 2 || z != 0 {
+		t.Errorf("Expected hand at 0,2,0, got %f %f %f", x, y, z)
+	}
+	if m.RemoveIK(ik); len(m.iks) != 0 {
+		t.Errorf("Expected chain removed")
+	}
+}
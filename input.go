@@ -4,7 +4,6 @@
 package vu
 
 // input.go wraps the device layer for the engine applications.
-// FUTURE: Have the device layer also support text entry.
 
 import (
 	"github.com/gazed/vu/device"
@@ -20,14 +19,62 @@ import (
 // include how long they have been pressed in update ticks. A negative
 // value indicates a key release, upon which the total down duration can
 // be calculated using the down duration less the KeyReleased timestamp.
+//
+// Game controllers are reported in the standard gamepad layout. Pressed
+// controller buttons are tracked like keys in each Gamepad.Down map.
+// Controllers that are not already reported in the standard layout
+// need mappings, see device.AddPadMappings.
 type Input struct {
-	Mx, My  int         // Current mouse location.
-	Down    map[int]int // Keys, buttons with down duration ticks.
-	Focus   bool        // True if window is in focus.
-	Resized bool        // True if window was resized or moved.
-	Scroll  int         // Scroll amount: plus, minus or zero.
-	Dt      float64     // Delta time for this update tick.
-	Ut      uint64      // Total number of update ticks.
+	Mx, My  int     // Current mouse location.
+	Dx, Dy  int     // Mouse movement since the last update.
+	Down    Keys    // Keys, buttons with down duration ticks.
+	Focus   bool    // True if window is in focus.
+	Resized bool    // True if window was resized or moved.
+	Scroll  int     // Scroll amount: plus, minus or zero.
+	Dt      float64 // Delta time for this update tick.
+	Ut      uint64  // Total number of update ticks.
+
+	// Window events. FocusChanged and MinimizeChanged are true for the
+	// update where Focus or Minimized changed, ie: to pause the game or
+	// mute the audio when the window is not in use. Moved is true for
+	// the update where the window moved. See Eng.OnClose.
+	Minimized       bool
+	FocusChanged    bool
+	MinimizeChanged bool
+	Moved           bool
+	closing         bool // the user asked to close the window.
+
+	// Scans are the pressed keys by key location, using the key codes of
+	// the keys at the same location on a US keyboard, ie: KW, KA, KS, KD
+	// are the same keys for any keyboard layout. Taps counts the quick
+	// successive presses of each pressed key or button, ie: 2 for a
+	// double tap.
+	Scans Keys
+	Taps  map[int]int
+
+	// Pads are the game controllers by controller slot.
+	// Unused slots are not connected.
+	Pads [device.MaxPads]device.Gamepad
+
+	// Locked is true while the cursor is locked, see CursorLocked.
+	// Use Dx, Dy for mouse movement since Mx, My do not change.
+	Locked bool
+
+	// Text is the text typed since the last update, use it for text
+	// entry rather than the key codes. Composing is any unfinished text
+	// from dead keys or an input method. Display it while it is shown.
+	Text      string
+	Composing string
+
+	// Touches are the current touch screen touches, including the
+	// touches that ended since the last update. Gesture holds the
+	// taps, pinches, and pans recognized from the touches.
+	Touches []device.Touch
+	Gesture device.Gesture
+
+	// Dropped are the paths of the files dragged and dropped onto
+	// the window since the last update, ie: assets for an editor.
+	Dropped []string
 }
 
 // convertInput copies the given device.Pressed input into vu.Input.
@@ -35,7 +82,16 @@ type Input struct {
 // in update ticks. It is expected to be called each update.
 func (in *Input) convertInput(pressed *device.Pressed, ut uint64, dt float64) {
 	in.Mx, in.My = pressed.Mx, pressed.My
-	in.Focus = pressed.Focus
+	in.Dx, in.Dy = pressed.Dx, pressed.Dy
+	in.Locked = pressed.Locked
+	in.Text, in.Composing = pressed.Text, pressed.Composing
+	in.Touches = append(in.Touches[:0], pressed.Touches...)
+	in.Gesture = pressed.Gesture
+	in.Dropped = append(in.Dropped[:0], pressed.Dropped...)
+	in.FocusChanged = in.Focus != pressed.Focus
+	in.MinimizeChanged = in.Minimized != pressed.Minimized
+	in.Focus, in.Minimized = pressed.Focus, pressed.Minimized
+	in.Moved, in.closing = pressed.Moved, pressed.Closing
 	in.Resized = pressed.Resized
 	in.Scroll = pressed.Scroll
 	in.Dt = dt
@@ -49,6 +105,62 @@ func (in *Input) convertInput(pressed *device.Pressed, ut uint64, dt float64) {
 	for key, val := range pressed.Down {
 		in.Down[key] = val
 	}
+	if in.Scans == nil {
+		in.Scans, in.Taps = Keys{}, map[int]int{}
+	}
+	for scan := range in.Scans {
+		delete(in.Scans, scan)
+	}
+	for scan, val := range pressed.Scans {
+		in.Scans[scan] = val
+	}
+	for key := range in.Taps {
+		delete(in.Taps, key)
+	}
+	for key, val := range pressed.Taps {
+		in.Taps[key] = val
+	}
+	for cnt := range in.Pads {
+		pad, dev := &in.Pads[cnt], &pressed.Pads[cnt]
+		if pad.Down == nil {
+			pad.Down = map[int]int{}
+		}
+		for button := range pad.Down {
+			delete(pad.Down, button)
+		}
+		for button, val := range dev.Down {
+			pad.Down[button] = val
+		}
+		pad.Name, pad.Connected, pad.Changed, pad.Axes = dev.Name, dev.Connected, dev.Changed, dev.Axes
+	}
+}
+
+// Keys maps pressed keys or buttons to their down durations in update
+// ticks. Released keys have negative durations for the update where
+// they are released, see KeyReleased.
+type Keys map[int]int
+
+// Pressed returns true for the update where the key was pressed.
+// This includes keys that were pressed and released within one update.
+func (k Keys) Pressed(key int) bool {
+	d, ok := k[key]
+	return ok && (d == 1 || d == KeyReleased)
+}
+
+// Released returns true for the update where the key was released.
+func (k Keys) Released(key int) bool {
+	d, ok := k[key]
+	return ok && d < 0
+}
+
+// Held returns how long, in update ticks, the key has been held down.
+// Released keys return how long they were held before being released.
+// Keys that are not pressed return 0.
+func (k Keys) Held(key int) int {
+	if d := k[key]; d < 0 {
+		return d - KeyReleased
+	}
+	return k[key]
 }
 
 // Expose the device package keys as a convenience so the
@@ -163,6 +275,39 @@ const (
 	KAlt   = device.KAlt   // ◇ 9671     "
 )
 
+// Expose the device package gamepad buttons and axes. Buttons are
+// keys in Gamepad.Down. Axes are indexes into Gamepad.Axes.
+const (
+	PadA         = device.PadA         // Bottom face button.
+	PadB         = device.PadB         // Right face button.
+	PadX         = device.PadX         // Left face button.
+	PadY         = device.PadY         // Top face button.
+	PadBack      = device.PadBack      // Left center button.
+	PadGuide     = device.PadGuide     // Center logo button.
+	PadStart     = device.PadStart     // Right center button.
+	PadLStick    = device.PadLStick    // Left stick press.
+	PadRStick    = device.PadRStick    // Right stick press.
+	PadLShoulder = device.PadLShoulder // Left bumper.
+	PadRShoulder = device.PadRShoulder // Right bumper.
+	PadUp        = device.PadUp        // Direction pad.
+	PadDown      = device.PadDown      //   "
+	PadLeft      = device.PadLeft      //   "
+	PadRight     = device.PadRight     //   "
+	PadLX        = device.PadLX        // Left stick horizontal, -1 is left.
+	PadLY        = device.PadLY        // Left stick vertical, -1 is up.
+	PadRX        = device.PadRX        // Right stick horizontal.
+	PadRY        = device.PadRY        // Right stick vertical.
+	PadLT        = device.PadLT        // Left trigger from 0 to 1.
+	PadRT        = device.PadRT        // Right trigger from 0 to 1.
+)
+
+// Expose the device package touch phases.
+const (
+	TouchBegan = device.TouchBegan // Touch started since the last update.
+	TouchMoved = device.TouchMoved // Touch continues.
+	TouchEnded = device.TouchEnded // Touch lifted since the last update.
+)
+
 // Keysym returns a single rune representing the given key.
 // Zero is returned if there is no rune for the key. This is intended
 // to provide a default means of representing each keyboard key with
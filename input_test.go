@@ -0,0 +1,20 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import "testing"
+
+// Keys report presses, releases, and how long keys were held.
+func TestKeys(t *testing.T) {
+	k := Keys{KA: 1, KB: 7, KC: KeyReleased + 4, KD: KeyReleased}
+	if !k.Pressed(KA) || k.Pressed(KB) || k.Pressed(KC) || !k.Pressed(KD) || k.Pressed(KE) {
+		t.Errorf("Expected KA, KD pressed")
+	}
+	if k.Released(KA) || !k.Released(KC) || !k.Released(KD) || k.Released(KE) {
+		t.Errorf("Expected KC, KD released")
+	}
+	if k.Held(KB) != 7 || k.Held(KC) != 4 || k.Held(KE) != 0 {
+		t.Errorf("Expected held durations got %d %d", k.Held(KB), k.Held(KC))
+	}
+}
@@ -22,7 +22,7 @@ import (
 	"github.com/gazed/vu/render"
 )
 
-// Layer is used to render to a 1024x1024 sized frame buffer based texture.
+// Layer is used to render to a frame buffer based texture, 1024x1024 by default.
 // A layer represents the output of rendering to a texture instead of to
 // the default framebuffer for the screen. The layer texture is then used
 // as part of the final screen render. There are currently 2 uses for layers:
@@ -46,13 +46,15 @@ type Layer interface{}
 type layer struct {
 	bid  uint32 // Framebuffer id. Default 0 for default framebuffer.
 	attr int    // What type of layer. DepthBuffer or ImageBuffer.
+	size int    // Texture width and height in pixels.
 
 	// Texture to render to. Used for both DepthBuffer and ImageBuffer.
 	tex *texture // Created when layer is created.
 
-	// ImageBuffer depth reference. Needed for rendering texture image
+	// ImageBuffer depth texture. Needed for rendering texture image
 	// that uses depth to simulate rendering to a normal framebuffer.
-	db uint32 // Valid for ImageBuffer.
+	// Also available to shaders that need scene depth, ie: post effects.
+	db *texture // Valid for ImageBuffer.
 
 	// Bias matrix is used by shadow shader reading from the depthBuffer.
 	bm *lin.M4 // bias matrix needed for a shadow shader.
@@ -61,11 +63,17 @@ type layer struct {
 	// the depth shader to render objects from the point of view
 	// of the light instead of the eye.
 	vp *lin.M4 // light view-projection layer transform.
+
+	// Shadow map settings copied from the light that rendered
+	// the DepthBuffer. Needed by the shadow shader.
+	bias   float64 // depth bias.
+	nbias  float64 // normal bias.
+	filter int     // ShadowHard, ShadowPCF, ShadowPCSS.
 }
 
 // newLayer creates the framebuffer needed to render to a texture.
 func newLayer(attr int) *layer {
-	l := &layer{attr: attr}
+	l := &layer{attr: attr, size: render.LayerSize}
 	l.vp = &lin.M4{}
 	l.bm = &lin.M4{
 		Xx: 0.5, Xy: 0.0, Xz: 0.0, Xw: 0.0,
@@ -74,6 +82,7 @@ func newLayer(attr int) *layer {
 		Wx: 0.5, Wy: 0.5, Wz: 0.5, Ww: 1.0,
 	}
 	l.tex = newTexture("layer")
+	l.db = newTexture("layerdepth")
 	return l
 }
 
@@ -130,6 +139,17 @@ func (ls *layers) create(id eid, attr int) *layer {
 	return l
 }
 
+// resize rebinds the layer framebuffer and textures using the new size.
+// The layer instance is kept since models may already reference it.
+func (ls *layers) resize(l *layer, size int) {
+	if size <= 0 || size == l.size {
+		return
+	}
+	ls.eng.release(&releaseData{data: l}) // dispose of the old framebuffer.
+	l.size = size
+	ls.bindLayer(l)
+}
+
 // bindLayer requests a new framebuffer based texture for a view.
 func (ls *layers) bindLayer(layer *layer) error {
 	bindReply := make(chan error)
@@ -166,10 +186,36 @@ func (ls *layers) enableShadows() {
 	ls.shadows = l
 }
 
+// fitShadows resizes the shared shadow map to the largest shadow size
+// of the lights that are on. Expected to be called once each frame
+// before any shadow draws are queued, so that queued draws never
+// reference a released shadow map.
+func (ls *layers) fitShadows(lights map[eid]*Light) {
+	if ls.shadows == nil {
+		return // shadows not enabled.
+	}
+	size := 0
+	for _, l := range lights {
+		if l.on && l.ShadowSize > size {
+			size = l.ShadowSize
+		}
+	}
+	ls.resize(ls.shadows, size)
+}
+
+// shadowLight applies the lights shadow settings to the shadow map.
+// Draws copy the settings when they are queued.
+func (ls *layers) shadowLight(light *Light) {
+	ls.shadows.bias, ls.shadows.nbias = light.ShadowBias, light.NormalBias
+	ls.shadows.filter = light.ShadowFilter
+}
+
 // render the models shadow from light position.
 // Its a sun light so no need to account for orientation.
+// The lights shadow settings are applied to the shadow map.
 func (ls *layers) renderShadow(draw *render.Draw, p *Pov, cam *Camera,
-	model *model, lx, ly, lz float64) {
+	model *model, light *Light, lx, ly, lz float64) {
+	ls.shadowLight(light)
 	ls.shadows.vp.Set(lin.M4I)
 	ls.shadows.vp.TranslateTM(lx, ly, lz)     // (light) view
 	ls.mv.Mult(p.mm, ls.shadows.vp)           // model-(light) view
@@ -178,6 +224,7 @@ func (ls *layers) renderShadow(draw *render.Draw, p *Pov, cam *Camera,
 
 	// render the model using the shadow map "depth" shader.
 	drawPov(draw, p, ls.mv, ls.mvp, cam, model, ls.shadows.bid)
+	draw.SetClear(cam.pass, render.ClearDefault, 0, 0, 0, 0) // shadow maps clear depth.
 	shd := model.shd
 	model.shd = ls.shadowShader
 	drawModel(draw, model, p.mm, ls.shadows)
@@ -3,25 +3,223 @@
 
 package vu
 
-// light.go defines light color.
+// light.go defines light color and shadow controls.
 // FUTURE: handle multiple lights for one scene. Need a shader that
 //         incorporates multiple lights into the final color.
 
+import (
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+	"github.com/gazed/vu/render"
+)
+
 // Light is used by shaders to interact with a models material values.
 // Light is attached to a Pov to give it a position in world space.
 // Light is defaulted to white 1,1,1. Valid R,G,B color values
 // are from 0 to 1.
+//
+// A light only affects models whose light mask shares a bit with the
+// light Mask. Both default to AllLayers. See LightMask.
+//
+// A light with a Range, like a lamp or torch, only affects models
+// within range. Scenes with many ranged lights have each model
+// evaluate the most relevant light instead of the latest light.
+//
+// A LightEffect, like Flicker or Pulse, changes the rendered light color
+// over time while leaving the R,G,B values unchanged.
+//
+// The shadow values are used when the light produces a shadow map
+// for models that CastShadow. Increase the bias values to remove
+// shadow acne. Decrease them if shadows detach from their objects.
 type Light struct {
 	R, G, B float64 // Red, Green, Blue values range from 0 to 1.
+	Mask    uint32  // Models affected by this light. Default AllLayers.
+	Range   float64 // Light reach. Default 0 for unlimited, ie: sun.
+	on      bool    // Lights are on by default.
+
+	// Optional effect that changes the rendered light color.
+	effect  LightEffect // Set by application.
+	elapsed float64     // Seconds since the effect was set.
+	cr, cg  float64     // Rendered light color: red, green,
+	cb      float64     // ... and blue.
+
+	// Shadow map controls.
+	ShadowSize   int     // Shadow map width and height. Default 1024.
+	ShadowBias   float64 // Depth bias. Default 0.005.
+	NormalBias   float64 // Offset along surface normals. Default 0.
+	ShadowFilter int     // ShadowHard, ShadowPCF, or ShadowPCSS.
 }
 
 // newLight creates a white light.
-func newLight() *Light { return &Light{R: 1, G: 1, B: 1} }
+func newLight() *Light {
+	l := &Light{R: 1, G: 1, B: 1, Mask: AllLayers, on: true}
+	l.cr, l.cg, l.cb = 1, 1, 1
+	l.ShadowSize = render.LayerSize
+	l.ShadowBias = 0.005
+	l.ShadowFilter = ShadowPCF
+	return l
+}
 
 // SetColor is a convenience method for changing the light color.
-func (l *Light) SetColor(r, g, b float64) { l.R, l.G, l.B = r, g, b }
+func (l *Light) SetColor(r, g, b float64) {
+	l.R, l.G, l.B = r, g, b
+	l.recolor()
+}
+
+// SetEffect attaches an effect that is advanced each update by the
+// engine. A nil effect removes any existing effect.
+func (l *Light) SetEffect(effect LightEffect) {
+	l.effect, l.elapsed = effect, 0
+	l.recolor()
+}
+
+// color returns the rendered light color.
+func (l *Light) color() (r, g, b float64) {
+	if l.effect == nil {
+		return l.R, l.G, l.B
+	}
+	return l.cr, l.cg, l.cb
+}
+
+// animate advances the light effect by the given elapsed seconds.
+func (l *Light) animate(dt float64) {
+	if l.effect != nil {
+		l.elapsed += dt
+		l.recolor()
+	}
+}
+
+// recolor updates the rendered light color from the light color
+// and the effect at the current elapsed time.
+func (l *Light) recolor() {
+	l.cr, l.cg, l.cb = l.R, l.G, l.B
+	if l.effect != nil {
+		sr, sg, sb := l.effect(l.elapsed)
+		l.cr, l.cg, l.cb = l.R*sr, l.G*sg, l.B*sb
+	}
+}
+
+// On returns true if the light is affecting models.
+func (l *Light) On() bool { return l.on }
+
+// SetOn enables or disables the light. Disabled lights
+// are ignored when rendering.
+func (l *Light) SetOn(on bool) { l.on = on }
+
+// lights returns true if the light is on and affects
+// models in the given mask.
+func (l *Light) lights(mask uint32) bool { return l.on && l.Mask&mask != 0 }
+
+// AllLayers is the default light and model light mask.
+const AllLayers uint32 = 0xFFFFFFFF
+
+// SetShadow is a convenience method for changing the shadow map
+// resolution, biases, and filter.
+func (l *Light) SetShadow(size int, bias, normalBias float64, filter int) {
+	l.ShadowSize, l.ShadowBias, l.NormalBias, l.ShadowFilter = size, bias, normalBias, filter
+}
+
+// Shadow filter modes for Light.ShadowFilter trade off quality for speed.
+const (
+	ShadowHard = iota // Single sample. Fast with jagged edges.
+	ShadowPCF         // Percentage closer filtering. Smoothed edges.
+	ShadowPCSS        // Percentage closer soft shadows. Softer with distance.
+)
 
 // =============================================================================
+// light effects
+
+// LightEffect returns the per color scale applied to a light color
+// given the elapsed seconds since the effect started. LightEffects are
+// attached using Light.SetEffect and updated by the engine.
+type LightEffect func(elapsed float64) (r, g, b float64)
+
+// Flicker profiles used with Flicker.
+const (
+	CandleFlicker = iota // Gentle, quick, waver.
+	TorchFlicker         // Strong, irregular, waver.
+	FaultyFlicker        // Mostly on, with sudden drop outs.
+)
+
+// Flicker returns an effect that irregularly dims a light
+// based on one of the flicker profiles.
+func Flicker(profile int) LightEffect {
+	switch profile {
+	case FaultyFlicker:
+		return func(elapsed float64) (r, g, b float64) {
+			s := 1.0
+			if flickerWave(elapsed*3) > 0.85 {
+				s = 0.1 // drop out.
+			}
+			return s, s, s
+		}
+	case TorchFlicker:
+		return flickerEffect(0.4, 2.5)
+	default:
+		return flickerEffect(0.15, 4)
+	}
+}
+
+// flickerEffect dims a light by up to the given amount at
+// the given speed.
+func flickerEffect(amount, speed float64) LightEffect {
+	return func(elapsed float64) (r, g, b float64) {
+		s := 1 - amount*(0.5+0.5*flickerWave(elapsed*speed))
+		return s, s, s
+	}
+}
+
+// flickerWave returns a smooth, non-repeating looking value between
+// -1 and 1 by summing sine waves with unrelated frequencies.
+func flickerWave(t float64) float64 {
+	return (math.Sin(t*7.3) + math.Sin(t*13.1+1.7) + math.Sin(t*2.9+4.1)) / 3
+}
+
+// Pulse returns an effect that smoothly dims a light down to
+// the low intensity and back once per period seconds.
+func Pulse(period, low float64) LightEffect {
+	return func(elapsed float64) (r, g, b float64) {
+		if period <= 0 {
+			return 1, 1, 1
+		}
+		wave := 0.5 + 0.5*math.Cos(elapsed/period*lin.PIx2) // 1 to 0 to 1.
+		s := lin.Lerp(low, 1, wave)
+		return s, s, s
+	}
+}
+
+// ColorRamp returns an effect that blends through the given colors,
+// specified as r,g,b triples, once every period seconds. The ramp
+// either repeats or holds the last color. For example an alarm:
+//     light.SetEffect(vu.ColorRamp(1, true, 1, 0, 0, 1, 1, 1, 1, 0, 0))
+func ColorRamp(period float64, loop bool, colors ...float64) LightEffect {
+	steps := len(colors)/3 - 1
+	return func(elapsed float64) (r, g, b float64) {
+		if steps < 0 {
+			return 1, 1, 1
+		}
+		ratio := 1.0
+		if period > 0 {
+			ratio = elapsed / period
+		}
+		if loop {
+			ratio -= math.Floor(ratio)
+		}
+		at := lin.Clamp(ratio, 0, 1) * float64(steps)
+		i := int(at)
+		if i >= steps {
+			c := colors[steps*3:]
+			return c[0], c[1], c[2] // last color.
+		}
+		c0, c1, f := colors[i*3:], colors[i*3+3:], at-float64(i)
+		return lin.Lerp(c0[0], c1[0], f), lin.Lerp(c0[1], c1[1], f), lin.Lerp(c0[2], c1[2], f)
+	}
+}
+
+// light effects
+// =============================================================================
+// lights
 
 // lights manages all the active Light instances.
 // There's not many lights so not much to optimize.
@@ -54,6 +252,13 @@ func (ls *lights) create(id eid) *Light {
 	return l
 }
 
+// animate advances the light effects by the elapsed seconds.
+func (ls *lights) animate(dt float64) {
+	for _, l := range ls.data {
+		l.animate(dt)
+	}
+}
+
 // dispose the light associated for the given entity. Do nothing
 // if no such light exists.
 func (ls *lights) dispose(id eid) { delete(ls.data, id) }
@@ -0,0 +1,122 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+	"github.com/gazed/vu/render"
+)
+
+// Models use the latest enabled light that matches their light mask.
+func TestLightMask(t *testing.T) {
+	fs := newFrames()
+	if l, _, _, _ := fs.light(AllLayers, 0, 0, 0); l != fs.white {
+		t.Errorf("Expected default light when there are no lights")
+	}
+	world, ui := newLight(), newLight()
+	world.Mask, ui.Mask = 1, 2
+	fs.lit = append(fs.lit, litPov{light: world}, litPov{light: ui, x: 1})
+	if l, lx, _, _ := fs.light(1, 0, 0, 0); l != world || lx != 0 {
+		t.Errorf("Expected world light for world models")
+	}
+	if l, lx, _, _ := fs.light(AllLayers, 0, 0, 0); l != ui || lx != 1 {
+		t.Errorf("Expected latest light for unmasked models")
+	}
+	ui.SetOn(false)
+	if l, _, _, _ := fs.light(2, 0, 0, 0); l != fs.dark {
+		t.Errorf("Expected no light when the ui light is off")
+	}
+}
+
+// Models use the closest ranged light in reach before unlimited lights.
+func TestLightCulling(t *testing.T) {
+	fs := newFrames()
+	sun, lamp, torch := newLight(), newLight(), newLight()
+	lamp.Range, torch.Range = 10, 4
+	fs.lit = append(fs.lit, litPov{light: sun}, litPov{light: lamp, wx: 10}, litPov{light: torch, wx: -5})
+	if l, _, _, _ := fs.light(AllLayers, 8, 0, 0); l != lamp {
+		t.Errorf("Expected lamp for models near the lamp")
+	}
+	if l, _, _, _ := fs.light(AllLayers, -4, 0, 0); l != torch {
+		t.Errorf("Expected torch for models near the torch")
+	}
+	if l, _, _, _ := fs.light(AllLayers, 0, 0, 50); l != sun {
+		t.Errorf("Expected sun for models out of range of ranged lights")
+	}
+	sun.SetOn(false)
+	if l, _, _, _ := fs.light(AllLayers, 0, 0, 50); l != fs.dark {
+		t.Errorf("Expected no light for models out of range")
+	}
+}
+
+// Light effects scale the light color without changing it.
+func TestLightEffects(t *testing.T) {
+	l := newLight()
+	l.SetColor(1, 0.5, 0.5)
+	l.SetEffect(Pulse(2, 0))
+	l.animate(1) // half way through the pulse.
+	if r, g, b := l.color(); r != 0 || g != 0 || b != 0 || l.R != 1 {
+		t.Errorf("Expected dimmed light, got %f %f %f", r, g, b)
+	}
+	l.SetEffect(ColorRamp(1, false, 1, 0, 0, 0, 0, 1))
+	if r, g, b := l.color(); r != 1 || g != 0 || b != 0 {
+		t.Errorf("Expected first ramp color before animating, got %f %f %f", r, g, b)
+	}
+	l.animate(0.5)
+	if r, g, b := l.color(); !lin.Aeq(r, 0.5) || g != 0 || !lin.Aeq(b, 0.25) {
+		t.Errorf("Expected half way ramp color, got %f %f %f", r, g, b)
+	}
+	l.animate(1) // hold last color.
+	if r, g, b := l.color(); r != 0 || g != 0 || b != 0.5 {
+		t.Errorf("Expected last ramp color, got %f %f %f", r, g, b)
+	}
+	if l.SetColor(0, 1, 1); l.cr != 0 || l.cg != 0 || l.cb != 1 {
+		t.Errorf("Expected new color with effect, got %f %f %f", l.cr, l.cg, l.cb)
+	}
+	l.SetColor(1, 0.5, 0.5)
+	l.SetEffect(Flicker(TorchFlicker))
+	for cnt := 0; cnt < 100; cnt++ {
+		l.animate(0.02)
+		if r, _, _ := l.color(); r < 0.6 || r > 1 {
+			t.Errorf("Expected flicker within range, got %f", r)
+		}
+	}
+}
+
+// Lights with different shadow sizes share one shadow map that is
+// resized once, before the frame draws, to the largest size.
+func TestShadowSizes(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	eng.layers.shadows = newLayer(render.DepthBuffer)
+	binds := 0
+	go func() {
+		for m := range machine {
+			if bd, ok := m.(*bindData); ok {
+				binds++
+				bd.reply <- nil
+			}
+		}
+	}()
+	sun, lamp := eng.root().NewPov().NewLight(), eng.root().NewPov().NewLight()
+	sun.SetShadow(512, 0.01, 0, ShadowHard)
+	lamp.SetShadow(2048, 0.002, 0.1, ShadowPCSS)
+	eng.layers.fitShadows(eng.lights.data)
+	eng.layers.fitShadows(eng.lights.data)
+	if size := eng.layers.shadows.size; size != 2048 {
+		t.Errorf("Expected largest shadow size, got %d", size)
+	}
+	eng.layers.shadowLight(sun)
+	if s := eng.layers.shadows; s.bias != 0.01 || s.filter != ShadowHard || s.size != 2048 {
+		t.Errorf("Expected sun shadow settings, got %f %d %d", s.bias, s.filter, s.size)
+	}
+	lamp.SetOn(false)
+	eng.layers.fitShadows(eng.lights.data)
+	close(machine)
+	if size := eng.layers.shadows.size; size != 512 || binds != 2 {
+		t.Errorf("Expected one resize for each change, got %d %d", size, binds)
+	}
+}
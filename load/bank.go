@@ -0,0 +1,158 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Bank loads a vu audio bank file. Audio bank files are text files that
+// describe a group of sounds along with how each sound is played so that
+// sound design can be changed without changing code. Each line holds one
+// statement. Lines starting with # are comments. A sound statement starts
+// a new sound and the following statements apply to that sound.
+//    sound  name         : starts a new sound.
+//    file   name         : sound file. Repeat for round robin variations.
+//    gain   f            : volume from 0 to 1. Defaults to 1.
+//    pitch  min [max]    : random pitch range. Defaults to 1 1.
+//    loop   [start end]  : repeat the sound, with optional loop points in seconds.
+//    distance ref max [r]: full volume distance, fade distance limit, rolloff.
+//    bus    name         : mixer bus, one of sfx, music, voice. Defaults to sfx.
+//    priority n          : voice priority, higher sounds are kept. Defaults to 0.
+//    trigger e [min max] : play the sound on engine event e.
+// Distances default to 1, no limit, and a rolloff of 1. Trigger events
+// with a strength, like collision impacts, are silent up to strength min
+// and reach full volume at strength max. Repeat for more events.
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import overwrites the data in BankData.
+func Bank(r io.Reader, d *BankData) error {
+	d.Sounds = []BankSound{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if err := bankLine(scanner.Text(), d); err != nil {
+			return fmt.Errorf("Invalid .bank line %d: %s", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for _, s := range d.Sounds {
+		if len(s.Files) == 0 {
+			return fmt.Errorf("Invalid .bank sound %s: expected file", s.Name)
+		}
+	}
+	return nil
+}
+
+// bankLine parses one line of an audio bank file into the given bank.
+func bankLine(line string, d *BankData) error {
+	tokens := strings.Fields(line)
+	if len(tokens) == 0 || strings.HasPrefix(tokens[0], "#") {
+		return nil
+	}
+	if tokens[0] == "sound" {
+		if len(tokens) != 2 {
+			return fmt.Errorf("expected sound name")
+		}
+		d.Sounds = append(d.Sounds, BankSound{Name: tokens[1], Bus: "sfx", Gain: 1, Pitch: [2]float64{1, 1}, RefDistance: 1, Rolloff: 1})
+		return nil
+	}
+	if len(d.Sounds) == 0 {
+		return fmt.Errorf("expected sound before %s", tokens[0])
+	}
+	s := &d.Sounds[len(d.Sounds)-1]
+	vals, err := bankFloats(tokens[1:])
+	switch tokens[0] {
+	case "file":
+		if len(tokens) != 2 {
+			return fmt.Errorf("expected file name")
+		}
+		s.Files = append(s.Files, tokens[1])
+	case "gain":
+		if err != nil || len(vals) != 1 || vals[0] < 0 {
+			return fmt.Errorf("expected gain value")
+		}
+		s.Gain = vals[0]
+	case "pitch":
+		if err != nil || len(vals) < 1 || len(vals) > 2 || vals[0] <= 0 {
+			return fmt.Errorf("expected pitch min [max]")
+		}
+		s.Pitch = [2]float64{vals[0], vals[0]}
+		if len(vals) == 2 {
+			if vals[1] < vals[0] {
+				return fmt.Errorf("expected pitch max above min")
+			}
+			s.Pitch[1] = vals[1]
+		}
+	case "loop":
+		if err != nil || (len(vals) != 0 && len(vals) != 2) {
+			return fmt.Errorf("expected loop [start end]")
+		}
+		s.Loop = true
+		if len(vals) == 2 {
+			if vals[0] < 0 || vals[1] <= vals[0] {
+				return fmt.Errorf("expected loop end after start")
+			}
+			s.LoopStart, s.LoopEnd = vals[0], vals[1]
+		}
+	case "distance":
+		if err != nil || len(vals) < 2 || len(vals) > 3 || vals[0] <= 0 || vals[1] < vals[0] {
+			return fmt.Errorf("expected distance ref max [rolloff]")
+		}
+		s.RefDistance, s.MaxDistance = vals[0], vals[1]
+		if len(vals) == 3 {
+			s.Rolloff = vals[2]
+		}
+	case "bus":
+		if len(tokens) != 2 || !bankBuses[tokens[1]] {
+			return fmt.Errorf("expected bus sfx, music, or voice")
+		}
+		s.Bus = tokens[1]
+	case "priority":
+		if len(tokens) != 2 {
+			return fmt.Errorf("expected priority value")
+		}
+		priority, err := strconv.Atoi(tokens[1])
+		if err != nil {
+			return fmt.Errorf("expected priority value")
+		}
+		s.Priority = priority
+	case "trigger":
+		if len(tokens) != 2 && len(tokens) != 4 {
+			return fmt.Errorf("expected trigger event [min max]")
+		}
+		trigger := BankTrigger{Event: tokens[1]}
+		if len(tokens) == 4 {
+			vals, err = bankFloats(tokens[2:])
+			if err != nil || vals[0] < 0 || vals[1] <= vals[0] {
+				return fmt.Errorf("expected trigger max above min")
+			}
+			trigger.Min, trigger.Max = vals[0], vals[1]
+		}
+		s.Triggers = append(s.Triggers, trigger)
+	default:
+		return fmt.Errorf("unknown statement %s", tokens[0])
+	}
+	return nil
+}
+
+// bankBuses are the valid mixer bus names.
+var bankBuses = map[string]bool{"sfx": true, "music": true, "voice": true}
+
+// bankFloats parses the tokens as float values.
+func bankFloats(tokens []string) ([]float64, error) {
+	vals := make([]float64, len(tokens))
+	for cnt, t := range tokens {
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, err
+		}
+		vals[cnt] = f
+	}
+	return vals, nil
+}
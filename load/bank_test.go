@@ -0,0 +1,48 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Uses vu/eg resource directories.
+func TestLoadBank(t *testing.T) {
+	b := &BankData{}
+	if err := b.Load("effects", NewLocator().Dir("BANK", "../eg/audio")); err != nil {
+		t.Fatalf("Should be able to load a valid audio bank %s", err)
+	}
+	want := []BankSound{
+		{Name: "hit", Files: []string{"ricochet", "bloop"}, Bus: "sfx", Gain: 0.8, Pitch: [2]float64{0.9, 1.1},
+			RefDistance: 2, MaxDistance: 50, Rolloff: 1, Triggers: []BankTrigger{{Event: "impact", Min: 0.5, Max: 5}}},
+		{Name: "hum", Files: []string{"bloop"}, Bus: "music", Gain: 0.5, Pitch: [2]float64{1, 1}, Loop: true,
+			LoopStart: 0.05, LoopEnd: 0.15, RefDistance: 1, Rolloff: 1, Priority: 1},
+	}
+	if !reflect.DeepEqual(b.Sounds, want) {
+		t.Errorf("Expected %+v got %+v", want, b.Sounds)
+	}
+}
+
+func TestBankLines(t *testing.T) {
+	b := &BankData{}
+	if err := Bank(strings.NewReader("sound a\nfile a1\npitch 2\nloop 0.5 1.5\ntrigger click\ntrigger step 1 2\n"), b); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.Sounds[0]; s.Pitch != [2]float64{2, 2} || !s.Loop || s.LoopStart != 0.5 || s.LoopEnd != 1.5 {
+		t.Errorf("Bad sound %+v", s)
+	}
+	if s := b.Sounds[0]; len(s.Triggers) != 2 || s.Triggers[0].Event != "click" || s.Triggers[1].Max != 2 {
+		t.Errorf("Bad triggers %+v", s.Triggers)
+	}
+	for _, bad := range []string{"file a", "sound a", "sound a\nfile a\ngain loud",
+		"sound a\nfile a\npitch 1.2 0.8", "sound a\nfile a\nloop 2 1", "sound a\nfile a\nreverb 1",
+		"sound a\nfile a\ndistance 5 2", "sound a\nfile a\nbus radio", "sound a\nfile a\npriority 0.5",
+		"sound a\nfile a\ntrigger", "sound a\nfile a\ntrigger impact 2 1"} {
+		if err := Bank(strings.NewReader(bad), b); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// cube.go converts equirectangular panoramas to cube map faces.
+// FUTURE: GPU conversion by rendering the panorama into each cube
+//         face framebuffer. Useful for large environment maps.
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Equirect converts an equirectangular, latitude longitude, panorama
+// into six square cube map faces in the order +X, -X, +Y, -Y, +Z, -Z.
+// The center of the panorama faces -Z and the top row is straight up.
+// The face size is in pixels and defaults to a quarter of the panorama
+// width when size is 0. High dynamic range FloatImage panoramas produce
+// FloatImage faces. All other images produce NRGBA faces.
+func Equirect(img image.Image, size int) (faces [6]image.Image) {
+	b := img.Bounds()
+	if size <= 0 {
+		if size = b.Dx() / 4; size < 1 {
+			size = 1
+		}
+	}
+	src, isFloat := img.(*FloatImage)
+	if !isFloat {
+		src = toFloat(img)
+	}
+	rect := image.Rect(0, 0, size, size)
+	for face := range faces {
+		dst := NewFloatImage(rect)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				u := 2*(float64(x)+0.5)/float64(size) - 1
+				v := 2*(float64(y)+0.5)/float64(size) - 1
+				dx, dy, dz := cubeDirection(face, u, v)
+				lon := math.Atan2(dx, -dz)                          // -Pi to Pi, 0 facing -Z.
+				lat := math.Asin(dy / math.Sqrt(dx*dx+dy*dy+dz*dz)) // -Pi/2 to Pi/2.
+				px := (lon/(2*math.Pi) + 0.5) * float64(b.Dx())
+				py := (0.5 - lat/math.Pi) * float64(b.Dy())
+				i := y*dst.Stride + x*4
+				src.bilinear(px, py, dst.Pix[i:i+4])
+			}
+		}
+		if isFloat {
+			faces[face] = dst
+		} else {
+			faces[face] = toNRGBA(dst)
+		}
+	}
+	return faces
+}
+
+// =============================================================================
+// internal implementation for cube map conversion.
+
+// cubeDirection returns the direction for the face pixel at u, v where
+// u and v range from -1 to 1 starting at the top left of the face.
+// Follows the OpenGL cube map face orientations.
+func cubeDirection(face int, u, v float64) (x, y, z float64) {
+	switch face {
+	case 0:
+		return 1, -v, -u // +X
+	case 1:
+		return -1, -v, u // -X
+	case 2:
+		return u, 1, v // +Y
+	case 3:
+		return u, -1, -v // -Y
+	case 4:
+		return u, -v, 1 // +Z
+	}
+	return -u, -v, -1 // -Z
+}
+
+// bilinear samples the image at the pixel location px, py where pixel
+// centers are at 0.5. Samples wrap horizontally and clamp vertically.
+func (f *FloatImage) bilinear(px, py float64, rgba []float32) {
+	w, h := f.Rect.Dx(), f.Rect.Dy()
+	px, py = px-0.5, py-0.5
+	x0, y0 := int(math.Floor(px)), int(math.Floor(py))
+	fx, fy := float32(px-float64(x0)), float32(py-float64(y0))
+	wrap := func(x int) int { return ((x % w) + w) % w }
+	clamp := func(y int) int { return int(lin.Clamp(float64(y), 0, float64(h-1))) }
+	xa, xb, ya, yb := wrap(x0), wrap(x0+1), clamp(y0), clamp(y0+1)
+	for c := 0; c < 4; c++ {
+		top := f.Pix[ya*f.Stride+xa*4+c]*(1-fx) + f.Pix[ya*f.Stride+xb*4+c]*fx
+		bot := f.Pix[yb*f.Stride+xa*4+c]*(1-fx) + f.Pix[yb*f.Stride+xb*4+c]*fx
+		rgba[c] = top*(1-fy) + bot*fy
+	}
+}
+
+// toFloat copies any image into a FloatImage with 0 to 1 color values.
+func toFloat(img image.Image) *FloatImage {
+	b := img.Bounds()
+	f := NewFloatImage(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := color.NRGBA64Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA64)
+			i := y*f.Stride + x*4
+			f.Pix[i+0] = float32(c.R) / 0xffff
+			f.Pix[i+1] = float32(c.G) / 0xffff
+			f.Pix[i+2] = float32(c.B) / 0xffff
+			f.Pix[i+3] = float32(c.A) / 0xffff
+		}
+	}
+	return f
+}
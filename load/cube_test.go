@@ -0,0 +1,114 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// panorama creates an equirectangular image with the red value
+// marking each quarter of longitude and green marking the top half.
+func panorama(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			quarter := int((float64(x)+0.5)/float64(w)*4+0.5) % 4
+			c := color.NRGBA{R: uint8(quarter * 60), A: 255}
+			if y < h/2 {
+				c.G = 255
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// Face centers sample the panorama in the matching direction.
+func TestEquirect(t *testing.T) {
+	faces := Equirect(panorama(64, 32), 8)
+	want := []color.NRGBA{
+		{180, 128, 0, 255}, // +X
+		{60, 128, 0, 255},  // -X
+		{0, 255, 0, 255},   // +Y
+		{0, 0, 0, 255},     // -Y
+		{0, 128, 0, 255},   // +Z
+		{120, 128, 0, 255}, // -Z
+	}
+	for cnt, face := range faces {
+		img, ok := face.(*image.NRGBA)
+		if !ok || img.Rect.Dx() != 8 || img.Rect.Dy() != 8 {
+			t.Fatalf("Expected 8x8 NRGBA face got %T", face)
+		}
+		c := img.NRGBAAt(4, 3)
+		if cnt < 2 || cnt > 3 {
+			c.G = want[cnt].G // horizon is a blend of top and bottom.
+		} else {
+			c.R = want[cnt].R // poles mix all longitudes.
+		}
+		if c != want[cnt] {
+			t.Errorf("Face %d expected %v got %v", cnt, want[cnt], c)
+		}
+	}
+}
+
+// High dynamic range panoramas keep values above 1.
+func TestEquirectFloat(t *testing.T) {
+	hdr := NewFloatImage(image.Rect(0, 0, 8, 4))
+	for cnt := range hdr.Pix {
+		hdr.Pix[cnt] = 4
+	}
+	faces := Equirect(hdr, 0)
+	f, ok := faces[5].(*FloatImage)
+	if !ok || f.Rect.Dx() != 2 {
+		t.Fatalf("Expected 2x2 FloatImage face got %T", faces[5])
+	}
+	if f.Pix[0] != 4 {
+		t.Errorf("Expected unclamped value 4 got %f", f.Pix[0])
+	}
+}
+
+// Cube maps load from six face images or from a single panorama.
+func TestLoadCube(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string, img image.Image) {
+		f, _ := os.Create(filepath.Join(dir, name+".png"))
+		png.Encode(f, img)
+		f.Close()
+	}
+	for cnt, suffix := range cubeSuffixes {
+		img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+		img.Pix[0], img.Pix[3] = uint8(cnt*40), 255
+		write("sky"+suffix, img)
+	}
+	write("pano", panorama(16, 8))
+	write("bad_px", image.NewNRGBA(image.Rect(0, 0, 4, 4)))
+	loc := NewLocator().Dir("PNG", dir)
+
+	c := &CubeData{}
+	if err := c.Load("sky", loc); err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := c.Faces[5].At(0, 0).RGBA(); r>>8 != 200 {
+		t.Errorf("Expected -Z face last got %d", r>>8)
+	}
+	if err := c.Load("pano", loc); err != nil {
+		t.Fatal(err)
+	}
+	if c.Faces[0].Bounds().Dx() != 4 {
+		t.Errorf("Expected panorama faces of size 4 got %d", c.Faces[0].Bounds().Dx())
+	}
+	if err := c.Load("bad", loc); err == nil {
+		t.Error("Expected missing face error")
+	}
+}
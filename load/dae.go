@@ -0,0 +1,768 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// dae.go imports COLLADA 1.4 and 1.5 scenes. The specification is at:
+//    https://www.khronos.org/collada/
+// FUTURE: Skin weights and animations. Skinned geometry is currently
+//         imported in its bind pose as static meshes.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Dae reads a COLLADA scene. The node hierarchy, triangle, polylist, and
+// polygon geometry, and the common profile materials are imported. The
+// scene is converted to be Y up and measured in meters. Images are
+// expected to be loaded separately by name as textures,
+// ie: "images/wood.png" is "wood".
+//
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import appends to the data in ScnData.
+func Dae(r io.Reader, d *ScnData) error {
+	c := &dae{}
+	if err := xml.NewDecoder(r).Decode(c); err != nil {
+		return fmt.Errorf("Invalid .dae file: %s", err)
+	}
+	scene := c.scene()
+	if scene == nil {
+		return fmt.Errorf("No visual scene in .dae file")
+	}
+	c.index()
+	if d.Images == nil {
+		d.Images = map[string]*ImgData{}
+	}
+	root := c.root()
+	for cnt := range scene.Nodes {
+		if err := c.addNode(d, &scene.Nodes[cnt], -1, root, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// internal implementation for loading COLLADA files.
+
+// dae is the subset of a COLLADA file that is imported.
+type dae struct {
+	Asset struct {
+		Unit struct {
+			Meter float64 `xml:"meter,attr"`
+		} `xml:"unit"`
+		UpAxis string `xml:"up_axis"`
+	} `xml:"asset"`
+	Images      []daeImage       `xml:"library_images>image"`
+	Materials   []daeMaterial    `xml:"library_materials>material"`
+	Effects     []daeEffect      `xml:"library_effects>effect"`
+	Geometries  []daeGeometry    `xml:"library_geometries>geometry"`
+	Controllers []daeController  `xml:"library_controllers>controller"`
+	Nodes       []daeNode        `xml:"library_nodes>node"`
+	Scenes      []daeVisualScene `xml:"library_visual_scenes>visual_scene"`
+	Scene       struct {
+		Instance daeInstance `xml:"instance_visual_scene"`
+	} `xml:"scene"`
+
+	// lookups by id created after parsing.
+	images      map[string]*daeImage
+	materials   map[string]*daeMaterial
+	effects     map[string]*daeEffect
+	geometries  map[string]*daeGeometry
+	controllers map[string]*daeController
+	nodes       map[string]*daeNode
+	sources     map[string]*daeSource
+	meshes      map[string][]int // imported meshes by geometry and materials.
+}
+
+// daeImage is a texture file.
+type daeImage struct {
+	ID       string `xml:"id,attr"`
+	InitFrom struct {
+		File string `xml:",chardata"` // COLLADA 1.4
+		Ref  string `xml:"ref"`       // COLLADA 1.5
+	} `xml:"init_from"`
+}
+
+// daeMaterial refers to an effect.
+type daeMaterial struct {
+	ID     string      `xml:"id,attr"`
+	Name   string      `xml:"name,attr"`
+	Effect daeInstance `xml:"instance_effect"`
+}
+
+// daeEffect holds the common profile lighting values.
+type daeEffect struct {
+	ID      string `xml:"id,attr"`
+	Profile struct {
+		Params    []daeParam `xml:"newparam"`
+		Technique struct {
+			Shading []daeShading `xml:",any"` // phong, blinn, lambert, or constant.
+		} `xml:"technique"`
+	} `xml:"profile_COMMON"`
+}
+
+// daeParam is an effect surface or sampler used to find texture images.
+type daeParam struct {
+	Sid     string `xml:"sid,attr"`
+	Surface struct {
+		InitFrom string `xml:"init_from"`
+	} `xml:"surface"`
+	Sampler struct {
+		Source   string      `xml:"source"`
+		Instance daeInstance `xml:"instance_image"`
+	} `xml:"sampler2D"`
+}
+
+// daeShading is one of the common profile lighting models.
+type daeShading struct {
+	XMLName      xml.Name
+	Ambient      *daeColor `xml:"ambient"`
+	Diffuse      *daeColor `xml:"diffuse"`
+	Specular     *daeColor `xml:"specular"`
+	Shininess    *daeFloat `xml:"shininess"`
+	Transparent  *daeColor `xml:"transparent"`
+	Transparency *daeFloat `xml:"transparency"`
+}
+
+// daeColor is either a color or a texture.
+type daeColor struct {
+	Opaque  string `xml:"opaque,attr"` // Transparency mode.
+	Color   string `xml:"color"`
+	Texture *struct {
+		Texture string `xml:"texture,attr"`
+	} `xml:"texture"`
+}
+
+// daeFloat is a single effect value.
+type daeFloat struct {
+	Float string `xml:"float"`
+}
+
+// daeGeometry is a mesh made of one or more primitive lists.
+type daeGeometry struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+	Mesh struct {
+		Sources  []daeSource `xml:"source"`
+		Vertices struct {
+			ID     string     `xml:"id,attr"`
+			Inputs []daeInput `xml:"input"`
+		} `xml:"vertices"`
+		Triangles []daePrimitive `xml:"triangles"`
+		Polylists []daePrimitive `xml:"polylist"`
+		Polygons  []daePrimitive `xml:"polygons"`
+	} `xml:"mesh"`
+}
+
+// daeSource is an array of vertex attribute values.
+type daeSource struct {
+	ID       string `xml:"id,attr"`
+	Floats   string `xml:"float_array"`
+	Accessor struct {
+		Stride int `xml:"stride,attr"`
+	} `xml:"technique_common>accessor"`
+	values []float32 // parsed floats.
+}
+
+// daeInput links a primitive to a source.
+type daeInput struct {
+	Semantic string `xml:"semantic,attr"`
+	Source   string `xml:"source,attr"`
+	Offset   int    `xml:"offset,attr"`
+	Set      int    `xml:"set,attr"`
+}
+
+// daePrimitive is a list of triangles or polygons.
+type daePrimitive struct {
+	Material string     `xml:"material,attr"`
+	Inputs   []daeInput `xml:"input"`
+	VCount   string     `xml:"vcount"` // Polylist polygon sizes.
+	P        []string   `xml:"p"`      // Indicies. One per polygon for polygons.
+}
+
+// daeController is a skin that refers to its geometry.
+type daeController struct {
+	ID   string `xml:"id,attr"`
+	Skin struct {
+		Source string `xml:"source,attr"`
+	} `xml:"skin"`
+}
+
+// daeNode is one element of the scene hierarchy.
+type daeNode struct {
+	ID          string        `xml:"id,attr"`
+	Name        string        `xml:"name,attr"`
+	Nodes       []daeNode     `xml:"node"`
+	Geometries  []daeInstance `xml:"instance_geometry"`
+	Controllers []daeInstance `xml:"instance_controller"`
+	Instances   []daeInstance `xml:"instance_node"`
+	Transforms  []daeValue    `xml:",any"` // Applied in order.
+}
+
+// daeValue is an element holding a list of numbers.
+type daeValue struct {
+	XMLName xml.Name
+	Values  string `xml:",chardata"`
+}
+
+// daeInstance refers to another element by url and binds materials.
+type daeInstance struct {
+	URL       string `xml:"url,attr"`
+	Materials []struct {
+		Symbol string `xml:"symbol,attr"`
+		Target string `xml:"target,attr"`
+	} `xml:"bind_material>technique_common>instance_material"`
+}
+
+// daeVisualScene holds the root nodes.
+type daeVisualScene struct {
+	ID    string    `xml:"id,attr"`
+	Nodes []daeNode `xml:"node"`
+}
+
+// scene returns the instanced visual scene, or the first visual scene.
+func (c *dae) scene() *daeVisualScene {
+	for cnt, vs := range c.Scenes {
+		if "#"+vs.ID == c.Scene.Instance.URL {
+			return &c.Scenes[cnt]
+		}
+	}
+	if len(c.Scenes) > 0 {
+		return &c.Scenes[0]
+	}
+	return nil
+}
+
+// index creates the lookups from element ids.
+func (c *dae) index() {
+	c.images = map[string]*daeImage{}
+	for cnt := range c.Images {
+		c.images[c.Images[cnt].ID] = &c.Images[cnt]
+	}
+	c.materials = map[string]*daeMaterial{}
+	for cnt := range c.Materials {
+		c.materials[c.Materials[cnt].ID] = &c.Materials[cnt]
+	}
+	c.effects = map[string]*daeEffect{}
+	for cnt := range c.Effects {
+		c.effects[c.Effects[cnt].ID] = &c.Effects[cnt]
+	}
+	c.geometries = map[string]*daeGeometry{}
+	c.sources = map[string]*daeSource{}
+	for cnt := range c.Geometries {
+		g := &c.Geometries[cnt]
+		c.geometries[g.ID] = g
+		for si := range g.Mesh.Sources {
+			s := &g.Mesh.Sources[si]
+			s.values = daeFloats(s.Floats)
+			if s.Accessor.Stride <= 0 {
+				s.Accessor.Stride = 1
+			}
+			c.sources[s.ID] = s
+		}
+	}
+	c.controllers = map[string]*daeController{}
+	for cnt := range c.Controllers {
+		c.controllers[c.Controllers[cnt].ID] = &c.Controllers[cnt]
+	}
+	c.nodes = map[string]*daeNode{}
+	for cnt := range c.Nodes {
+		c.nodes[c.Nodes[cnt].ID] = &c.Nodes[cnt]
+	}
+	c.meshes = map[string][]int{}
+}
+
+// root returns the transform that converts the scene to Y up meters.
+func (c *dae) root() *daeMatrix {
+	m := daeIdentity()
+	switch strings.TrimSpace(c.Asset.UpAxis) {
+	case "Z_UP":
+		m = m.mul(daeRotate(1, 0, 0, -90))
+	case "X_UP":
+		m = m.mul(daeRotate(0, 0, 1, 90))
+	}
+	if s := c.Asset.Unit.Meter; s > 0 && s != 1 {
+		m = m.mul(daeScale(s, s, s))
+	}
+	return m
+}
+
+// addNode adds the node and its children to the scene hierarchy.
+// The parent transform is only used for root nodes.
+func (c *dae) addNode(d *ScnData, n *daeNode, parent int, pre *daeMatrix, depth int) error {
+	if depth > 32 {
+		return fmt.Errorf("Invalid .dae node nesting %s", n.ID)
+	}
+	m, err := n.matrix()
+	if err != nil {
+		return err
+	}
+	name := n.Name
+	if name == "" {
+		name = n.ID
+	}
+	sn := ScnNode{Name: name, Parent: parent}
+	pre.mul(m).m4().Decompose(&sn.Loc, &sn.Rot, &sn.Scale)
+	instances := n.Geometries
+	for _, ic := range n.Controllers {
+		if ctrl, ok := c.controllers[strings.TrimPrefix(ic.URL, "#")]; ok {
+			ic.URL = ctrl.Skin.Source // bind pose geometry.
+			instances = append(instances, ic)
+		}
+	}
+	for _, ig := range instances {
+		meshes, err := c.loadMeshes(d, ig)
+		if err != nil {
+			return err
+		}
+		sn.Meshes = append(sn.Meshes, meshes...)
+	}
+	at := len(d.Nodes)
+	d.Nodes = append(d.Nodes, sn)
+	for _, in := range n.Instances {
+		if ln, ok := c.nodes[strings.TrimPrefix(in.URL, "#")]; ok {
+			if err := c.addNode(d, ln, at, daeIdentity(), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	for cnt := range n.Nodes {
+		if err := c.addNode(d, &n.Nodes[cnt], at, daeIdentity(), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matrix combines the node transforms.
+func (n *daeNode) matrix() (*daeMatrix, error) {
+	m := daeIdentity()
+	for _, t := range n.Transforms {
+		v := daeFloats(t.Values)
+		switch t.XMLName.Local {
+		case "matrix":
+			if len(v) != 16 {
+				return nil, fmt.Errorf("Invalid .dae matrix in node %s", n.ID)
+			}
+			tm := &daeMatrix{}
+			for cnt := range tm {
+				tm[cnt] = float64(v[cnt])
+			}
+			m = m.mul(tm)
+		case "translate":
+			if len(v) != 3 {
+				return nil, fmt.Errorf("Invalid .dae translate in node %s", n.ID)
+			}
+			m = m.mul(daeTranslate(float64(v[0]), float64(v[1]), float64(v[2])))
+		case "rotate":
+			if len(v) != 4 {
+				return nil, fmt.Errorf("Invalid .dae rotate in node %s", n.ID)
+			}
+			m = m.mul(daeRotate(float64(v[0]), float64(v[1]), float64(v[2]), float64(v[3])))
+		case "scale":
+			if len(v) != 3 {
+				return nil, fmt.Errorf("Invalid .dae scale in node %s", n.ID)
+			}
+			m = m.mul(daeScale(float64(v[0]), float64(v[1]), float64(v[2])))
+		}
+	}
+	return m, nil
+}
+
+// loadMeshes imports the geometry primitives for a geometry instance.
+// Geometry instances with the same materials share meshes.
+func (c *dae) loadMeshes(d *ScnData, ig daeInstance) ([]int, error) {
+	g, ok := c.geometries[strings.TrimPrefix(ig.URL, "#")]
+	if !ok {
+		return nil, nil // not mesh geometry.
+	}
+	bound := map[string]string{} // material symbol to material id.
+	key := g.ID
+	for _, im := range ig.Materials {
+		bound[im.Symbol] = strings.TrimPrefix(im.Target, "#")
+		key += " " + im.Symbol + "=" + im.Target
+	}
+	if meshes, ok := c.meshes[key]; ok {
+		return meshes, nil
+	}
+	meshes := []int{}
+	prims := [][]daePrimitive{g.Mesh.Triangles, g.Mesh.Polylists, g.Mesh.Polygons}
+	for kind, list := range prims {
+		for cnt := range list {
+			p := &list[cnt]
+			name := g.Name
+			if name == "" {
+				name = g.ID
+			}
+			sm := ScnMesh{Skin: -1}
+			sm.Name = name
+			if err := c.primitive(g, p, kind, &sm.MshData); err != nil {
+				return nil, err
+			}
+			if len(sm.F) == 0 {
+				continue
+			}
+			sm.GenNormals()
+			sm.GenTangents()
+			c.material(bound[p.Material], &sm)
+			sm.Optimize()
+			meshes = append(meshes, len(d.Meshes))
+			d.Meshes = append(d.Meshes, sm)
+		}
+	}
+	c.meshes[key] = meshes
+	return meshes, nil
+}
+
+// Primitive kinds in the order they are listed by loadMeshes.
+const (
+	daeTriangles = iota
+	daePolylist
+	daePolygons
+)
+
+// primitive creates mesh data from one list of triangles or polygons.
+// Polygons are triangulated as fans.
+func (c *dae) primitive(g *daeGeometry, p *daePrimitive, kind int, msh *MshData) error {
+	var pos, norm, uv *daeSource
+	vOff, nOff, tOff, stride := -1, -1, -1, 0
+	uvSet := math.MaxInt32
+	for _, in := range p.Inputs {
+		src := strings.TrimPrefix(in.Source, "#")
+		if in.Offset < 0 {
+			return fmt.Errorf("Invalid .dae input offset %d in %s", in.Offset, g.ID)
+		}
+		if in.Offset+1 > stride {
+			stride = in.Offset + 1
+		}
+		switch in.Semantic {
+		case "VERTEX":
+			if src != g.Mesh.Vertices.ID {
+				return fmt.Errorf("Invalid .dae vertices %s", src)
+			}
+			vOff = in.Offset
+			for _, vin := range g.Mesh.Vertices.Inputs {
+				vsrc := c.sources[strings.TrimPrefix(vin.Source, "#")]
+				switch vin.Semantic {
+				case "POSITION":
+					pos = vsrc
+				case "NORMAL":
+					norm, nOff = vsrc, in.Offset
+				case "TEXCOORD":
+					uv, tOff = vsrc, in.Offset
+				}
+			}
+		case "NORMAL":
+			norm, nOff = c.sources[src], in.Offset
+		case "TEXCOORD":
+			if in.Set < uvSet {
+				uv, tOff, uvSet = c.sources[src], in.Offset, in.Set
+			}
+		}
+	}
+	if pos == nil || vOff < 0 {
+		return fmt.Errorf("Invalid .dae geometry %s has no positions", g.ID)
+	}
+
+	// collect the polygon sizes and indicies.
+	polys, indicies := []int{}, []int{}
+	for _, list := range p.P {
+		ints, err := daeInts(list)
+		if err != nil {
+			return fmt.Errorf("Invalid .dae indicies in %s: %s", g.ID, err)
+		}
+		indicies = append(indicies, ints...)
+		if kind == daePolygons {
+			polys = append(polys, len(ints)/stride)
+		}
+	}
+	switch kind {
+	case daeTriangles:
+		for cnt := 0; cnt < len(indicies)/stride/3; cnt++ {
+			polys = append(polys, 3)
+		}
+	case daePolylist:
+		var err error
+		if polys, err = daeInts(p.VCount); err != nil {
+			return fmt.Errorf("Invalid .dae vcount in %s: %s", g.ID, err)
+		}
+	}
+
+	// create one vertex for each unique combination of indicies.
+	verts := map[[3]int]uint16{}
+	corners := []uint16{}
+	corner := 0
+	for _, size := range polys {
+		corners = corners[:0]
+		for cnt := 0; cnt < size; cnt, corner = cnt+1, corner+1 {
+			at := corner * stride
+			if at+stride > len(indicies) {
+				return fmt.Errorf("Invalid .dae primitive in %s", g.ID)
+			}
+			key := [3]int{indicies[at+vOff], -1, -1}
+			if norm != nil {
+				key[1] = indicies[at+nOff]
+			}
+			if uv != nil {
+				key[2] = indicies[at+tOff]
+			}
+			vi, ok := verts[key]
+			if !ok {
+				if len(verts) > math.MaxUint16 {
+					return fmt.Errorf("Invalid .dae geometry %s has too many verticies", g.ID)
+				}
+				vi = uint16(len(verts))
+				verts[key] = vi
+				if err := daeVertex(msh, pos, norm, uv, key); err != nil {
+					return fmt.Errorf("Invalid .dae geometry %s: %s", g.ID, err)
+				}
+			}
+			corners = append(corners, vi)
+		}
+		for cnt := 2; cnt < len(corners); cnt++ {
+			msh.F = append(msh.F, corners[0], corners[cnt-1], corners[cnt])
+		}
+	}
+	return nil
+}
+
+// daeVertex appends the vertex data for the given position, normal, and
+// texture coordinate indicies. Texture coordinates are flipped to
+// match the image orientation.
+func daeVertex(msh *MshData, pos, norm, uv *daeSource, key [3]int) error {
+	v, err := pos.value(key[0], 3)
+	if err != nil {
+		return err
+	}
+	msh.V = append(msh.V, v...)
+	if norm != nil {
+		n, err := norm.value(key[1], 3)
+		if err != nil {
+			return err
+		}
+		msh.N = append(msh.N, n...)
+	}
+	if uv != nil {
+		t, err := uv.value(key[2], 2)
+		if err != nil {
+			return err
+		}
+		msh.T = append(msh.T, t[0], 1-t[1])
+	}
+	return nil
+}
+
+// value returns the first n values of the indexed source element.
+func (s *daeSource) value(index, n int) ([]float32, error) {
+	if s == nil {
+		return nil, fmt.Errorf("missing source")
+	}
+	at := index * s.Accessor.Stride
+	if index < 0 || s.Accessor.Stride < n || at+n > len(s.values) {
+		return nil, fmt.Errorf("bad index %d for %s", index, s.ID)
+	}
+	return s.values[at : at+n], nil
+}
+
+// material sets the mesh lighting colors and diffuse texture from
+// the common profile effect of the given material.
+func (c *dae) material(id string, sm *ScnMesh) {
+	sm.MtlData = *newMtlData()
+	m, ok := c.materials[id]
+	if !ok {
+		return
+	}
+	e, ok := c.effects[strings.TrimPrefix(m.Effect.URL, "#")]
+	if !ok {
+		return
+	}
+	for _, s := range e.Profile.Technique.Shading {
+		switch s.XMLName.Local {
+		case "phong", "blinn", "lambert", "constant":
+		default:
+			continue
+		}
+		if rgba := s.Ambient.rgba(); rgba != nil {
+			sm.KaR, sm.KaG, sm.KaB = rgba[0], rgba[1], rgba[2]
+		}
+		if rgba := s.Diffuse.rgba(); rgba != nil {
+			sm.KdR, sm.KdG, sm.KdB = rgba[0], rgba[1], rgba[2]
+		}
+		if rgba := s.Specular.rgba(); rgba != nil {
+			sm.KsR, sm.KsG, sm.KsB = rgba[0], rgba[1], rgba[2]
+		}
+		if f, ok := s.Shininess.value(); ok {
+			sm.Ns = f
+		}
+		sm.Alpha = s.alpha()
+		if s.Diffuse != nil && s.Diffuse.Texture != nil {
+			if img := c.image(e, s.Diffuse.Texture.Texture); img != "" {
+				sm.MapKd = img
+				sm.TMap = []TexMap{{Name: img, F0: 0, Fn: uint32(len(sm.F) / 3)}}
+			}
+		}
+		return
+	}
+}
+
+// alpha returns the opacity using the transparent color and
+// the transparency value. See the COLLADA transparency modes.
+func (s *daeShading) alpha() float32 {
+	rgba := s.Transparent.rgba()
+	if rgba == nil {
+		return 1
+	}
+	f, ok := s.Transparency.value()
+	if !ok {
+		f = 1
+	}
+	if s.Transparent.Opaque == "RGB_ZERO" {
+		luminance := rgba[0]*0.212671 + rgba[1]*0.715160 + rgba[2]*0.072169
+		return 1 - luminance*f
+	}
+	return rgba[3] * f // A_ONE
+}
+
+// image returns the texture name for an effect texture reference.
+// The reference is a sampler parameter, or directly an image id.
+func (c *dae) image(e *daeEffect, ref string) string {
+	id := ref
+	for _, sampler := range e.Profile.Params {
+		if sampler.Sid != ref {
+			continue
+		}
+		if url := sampler.Sampler.Instance.URL; url != "" {
+			id = strings.TrimPrefix(url, "#") // COLLADA 1.5
+			break
+		}
+		for _, surface := range e.Profile.Params {
+			if surface.Sid == sampler.Sampler.Source {
+				id = strings.TrimSpace(surface.Surface.InitFrom)
+			}
+		}
+	}
+	img, ok := c.images[id]
+	if !ok {
+		return ""
+	}
+	file := strings.TrimSpace(img.InitFrom.Ref)
+	if file == "" {
+		file = strings.TrimSpace(img.InitFrom.File)
+	}
+	file = path.Base(strings.Replace(file, "\\", "/", -1))
+	return strings.TrimSuffix(file, path.Ext(file))
+}
+
+// rgba returns the color values, or nil if there is no color.
+func (dc *daeColor) rgba() []float32 {
+	if dc == nil {
+		return nil
+	}
+	if v := daeFloats(dc.Color); len(v) == 4 {
+		return v
+	} else if len(v) == 3 {
+		return append(v, 1)
+	}
+	return nil
+}
+
+// value returns the float value, if there is one.
+func (df *daeFloat) value() (float32, bool) {
+	if df == nil {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(df.Float), 32)
+	return float32(f), err == nil
+}
+
+// daeFloats parses a whitespace separated list of floats.
+// Values that are not numbers are skipped.
+func daeFloats(s string) []float32 {
+	fields := strings.Fields(s)
+	vals := make([]float32, 0, len(fields))
+	for _, field := range fields {
+		if f, err := strconv.ParseFloat(field, 32); err == nil {
+			vals = append(vals, float32(f))
+		}
+	}
+	return vals
+}
+
+// daeInts parses a whitespace separated list of non-negative integers.
+func daeInts(s string) ([]int, error) {
+	fields := strings.Fields(s)
+	vals := make([]int, len(fields))
+	for cnt, field := range fields {
+		i, err := strconv.Atoi(field)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("bad index %s", field)
+		}
+		vals[cnt] = i
+	}
+	return vals, nil
+}
+
+// daeMatrix is a row major matrix that transforms column vectors.
+type daeMatrix [16]float64
+
+// daeIdentity returns a new identity matrix.
+func daeIdentity() *daeMatrix {
+	return &daeMatrix{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+}
+
+// daeTranslate returns a new translation matrix.
+func daeTranslate(x, y, z float64) *daeMatrix {
+	return &daeMatrix{1, 0, 0, x, 0, 1, 0, y, 0, 0, 1, z, 0, 0, 0, 1}
+}
+
+// daeScale returns a new scale matrix.
+func daeScale(x, y, z float64) *daeMatrix {
+	return &daeMatrix{x, 0, 0, 0, 0, y, 0, 0, 0, 0, z, 0, 0, 0, 0, 1}
+}
+
+// daeRotate returns a new rotation matrix for the given axis and angle in degrees.
+func daeRotate(x, y, z, deg float64) *daeMatrix {
+	if l := math.Sqrt(x*x + y*y + z*z); l > 0 {
+		x, y, z = x/l, y/l, z/l
+	}
+	s, c := math.Sincos(lin.Rad(deg))
+	t := 1 - c
+	return &daeMatrix{
+		t*x*x + c, t*x*y - s*z, t*x*z + s*y, 0,
+		t*x*y + s*z, t*y*y + c, t*y*z - s*x, 0,
+		t*x*z - s*y, t*y*z + s*x, t*z*z + c, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// mul returns a new matrix that applies b and then m.
+func (m *daeMatrix) mul(b *daeMatrix) *daeMatrix {
+	out := &daeMatrix{}
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			for k := 0; k < 4; k++ {
+				out[row*4+col] += m[row*4+k] * b[k*4+col]
+			}
+		}
+	}
+	return out
+}
+
+// m4 returns the matrix in the engine row vector layout. See trs.
+func (m *daeMatrix) m4() *lin.M4 {
+	return &lin.M4{
+		Xx: m[0], Xy: m[4], Xz: m[8], Xw: m[12],
+		Yx: m[1], Yy: m[5], Yz: m[9], Yw: m[13],
+		Zx: m[2], Zy: m[6], Zz: m[10], Zw: m[14],
+		Wx: m[3], Wy: m[7], Wz: m[11], Ww: m[15],
+	}
+}
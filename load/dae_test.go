@@ -0,0 +1,123 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// daeQuad is a Z up, centimeter scene with a textured quad polygon
+// that is instanced by a child node.
+var daeQuad = `<?xml version="1.0" encoding="utf-8"?>
+<COLLADA xmlns="http://www.collada.org/2005/11/COLLADASchema" version="1.4.1">
+  <asset><unit meter="0.01"/><up_axis>Z_UP</up_axis></asset>
+  <library_images>
+    <image id="wood-img"><init_from>textures\wood.png</init_from></image>
+  </library_images>
+  <library_effects>
+    <effect id="wood-fx"><profile_COMMON>
+      <newparam sid="wood-surface"><surface type="2D"><init_from>wood-img</init_from></surface></newparam>
+      <newparam sid="wood-sampler"><sampler2D><source>wood-surface</source></sampler2D></newparam>
+      <technique sid="common"><phong>
+        <ambient><color>0.1 0.2 0.3 1</color></ambient>
+        <diffuse><texture texture="wood-sampler" texcoord="UVMap"/></diffuse>
+        <specular><color>0.5 0.5 0.5 1</color></specular>
+        <shininess><float>50</float></shininess>
+        <transparent opaque="A_ONE"><color>1 1 1 1</color></transparent>
+        <transparency><float>0.5</float></transparency>
+      </phong></technique>
+    </profile_COMMON></effect>
+  </library_effects>
+  <library_materials>
+    <material id="wood-mat" name="wood"><instance_effect url="#wood-fx"/></material>
+  </library_materials>
+  <library_geometries>
+    <geometry id="quad-mesh" name="quad"><mesh>
+      <source id="quad-pos">
+        <float_array id="quad-pos-array" count="12">0 0 0 1 0 0 1 1 0 0 1 0</float_array>
+        <technique_common><accessor source="#quad-pos-array" count="4" stride="3"/></technique_common>
+      </source>
+      <source id="quad-uv">
+        <float_array id="quad-uv-array" count="8">0 0 1 0 1 1 0 1</float_array>
+        <technique_common><accessor source="#quad-uv-array" count="4" stride="2"/></technique_common>
+      </source>
+      <vertices id="quad-verts"><input semantic="POSITION" source="#quad-pos"/></vertices>
+      <polylist material="wood-sym" count="1">
+        <input semantic="VERTEX" source="#quad-verts" offset="0"/>
+        <input semantic="TEXCOORD" source="#quad-uv" offset="1" set="0"/>
+        <vcount>4</vcount>
+        <p>0 0 1 1 2 2 3 3</p>
+      </polylist>
+    </mesh></geometry>
+  </library_geometries>
+  <library_visual_scenes>
+    <visual_scene id="scene">
+      <node id="root" name="root">
+        <translate sid="location">1 2 3</translate>
+        <node id="child" name="child">
+          <rotate sid="rotationZ">0 0 1 90</rotate>
+          <scale sid="scale">2 2 2</scale>
+          <instance_geometry url="#quad-mesh">
+            <bind_material><technique_common>
+              <instance_material symbol="wood-sym" target="#wood-mat"/>
+            </technique_common></bind_material>
+          </instance_geometry>
+        </node>
+      </node>
+    </visual_scene>
+  </library_visual_scenes>
+  <scene><instance_visual_scene url="#scene"/></scene>
+</COLLADA>`
+
+func TestDae(t *testing.T) {
+	d := &ScnData{}
+	if err := Dae(strings.NewReader(daeQuad), d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Nodes) != 2 || len(d.Meshes) != 1 {
+		t.Fatalf("Expected 2 nodes and 1 mesh, got %d %d", len(d.Nodes), len(d.Meshes))
+	}
+
+	// the root is converted to Y up meters.
+	root, child := d.Nodes[0], d.Nodes[1]
+	if root.Name != "root" || root.Parent != -1 || child.Parent != 0 {
+		t.Errorf("Bad hierarchy %s %d %d", root.Name, root.Parent, child.Parent)
+	}
+	if !root.Loc.Aeq(&lin.V3{X: 0.01, Y: 0.03, Z: -0.02}) || !aeq(float32(root.Scale.X), 0.01) {
+		t.Errorf("Expected Y up meters got %v %v", root.Loc, root.Scale)
+	}
+	if !aeq(float32(child.Scale.Y), 2) || !aeq(float32(math.Abs(child.Rot.Z)), float32(math.Sqrt2/2)) {
+		t.Errorf("Bad child transform %v %v", child.Rot, child.Scale)
+	}
+
+	// the quad is triangulated and the material is found.
+	m := d.Meshes[0]
+	if len(m.V) != 12 || len(m.F) != 6 || len(m.N) != 12 || len(m.X) != 16 {
+		t.Errorf("Bad mesh %d %d %d %d", len(m.V), len(m.F), len(m.N), len(m.X))
+	}
+	if m.Name != "quad" || m.MapKd != "wood" || len(m.TMap) != 1 || m.TMap[0].Fn != 2 {
+		t.Errorf("Bad texture %s %s %v", m.Name, m.MapKd, m.TMap)
+	}
+	if m.KaB != 0.3 || m.KsR != 0.5 || m.Ns != 50 || m.Alpha != 0.5 {
+		t.Errorf("Bad material %+v", m.MtlData)
+	}
+}
+
+func TestDaeInvalid(t *testing.T) {
+	bad := strings.Replace(daeQuad, "<p>0 0 1 1 2 2 3 3</p>", "<p>0 0 1 1 2 2 9 3</p>", 1)
+	if err := Dae(strings.NewReader(bad), &ScnData{}); err == nil {
+		t.Error("Expected bad index error")
+	}
+	bad = strings.Replace(daeQuad, `source="#quad-uv" offset="1"`, `source="#quad-uv" offset="-1"`, 1)
+	if err := Dae(strings.NewReader(bad), &ScnData{}); err == nil {
+		t.Error("Expected bad offset error")
+	}
+	if err := Dae(strings.NewReader("<COLLADA></COLLADA>"), &ScnData{}); err == nil {
+		t.Error("Expected no scene error")
+	}
+}
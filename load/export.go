@@ -0,0 +1,207 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// export.go writes mesh data back out to model file formats so that
+// generated meshes can be inspected in modelling tools like Blender.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WriteObj writes the mesh data as a Wavefront OBJ model. Vertex positions,
+// texture coordinates, normals, and triangle faces are written. The texture
+// coordinates are flipped to match the OBJ bottom left origin.
+// The Writer w is expected to be opened and closed by the caller.
+func WriteObj(w io.Writer, d *MshData) error {
+	if err := exportable(d); err != nil {
+		return err
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "# vu mesh export\no %s\n", d.Name)
+	for cnt := 0; cnt+2 < len(d.V); cnt += 3 {
+		fmt.Fprintf(out, "v %g %g %g\n", d.V[cnt], d.V[cnt+1], d.V[cnt+2])
+	}
+	for cnt := 0; cnt+1 < len(d.T); cnt += 2 {
+		fmt.Fprintf(out, "vt %g %g\n", d.T[cnt], 1-d.T[cnt+1])
+	}
+	for cnt := 0; cnt+2 < len(d.N); cnt += 3 {
+		fmt.Fprintf(out, "vn %g %g %g\n", d.N[cnt], d.N[cnt+1], d.N[cnt+2])
+	}
+	hasT, hasN := len(d.T) > 0, len(d.N) > 0
+	for cnt := 0; cnt < len(d.F); cnt += 3 {
+		out.WriteString("f")
+		for _, f := range d.F[cnt : cnt+3] {
+			i := int(f) + 1 // obj indicies start at 1.
+			switch {
+			case hasT && hasN:
+				fmt.Fprintf(out, " %d/%d/%d", i, i, i)
+			case hasN:
+				fmt.Fprintf(out, " %d//%d", i, i)
+			case hasT:
+				fmt.Fprintf(out, " %d/%d", i, i)
+			default:
+				fmt.Fprintf(out, " %d", i)
+			}
+		}
+		out.WriteString("\n")
+	}
+	return out.Flush()
+}
+
+// WriteGltf writes the mesh data as a glTF 2.0 JSON scene with one node
+// and an embedded buffer. Vertex positions, normals, texture coordinates,
+// tangents, and triangle faces are written.
+// The Writer w is expected to be opened and closed by the caller.
+func WriteGltf(w io.Writer, d *MshData) error {
+	if err := exportable(d); err != nil {
+		return err
+	}
+	g := &gltfExport{}
+	g.Asset.Version = "2.0"
+	g.Asset.Generator = "vu"
+	g.Scenes = []gltfExportScene{{Nodes: []int{0}}}
+	g.Nodes = []gltfExportNode{{Name: d.Name, Mesh: 0}}
+	prim := gltfExportPrim{Attributes: map[string]int{}, Mode: 4}
+	verts := len(d.V) / 3
+	buf := &bytes.Buffer{}
+	prim.Attributes["POSITION"] = g.floats(buf, d.V, verts, "VEC3", true)
+	if len(d.N) == verts*3 {
+		prim.Attributes["NORMAL"] = g.floats(buf, d.N, verts, "VEC3", false)
+	}
+	if len(d.T) == verts*2 {
+		prim.Attributes["TEXCOORD_0"] = g.floats(buf, d.T, verts, "VEC2", false)
+	}
+	if len(d.X) == verts*4 {
+		prim.Attributes["TANGENT"] = g.floats(buf, d.X, verts, "VEC4", false)
+	}
+
+	// faces are the last buffer view so no padding is needed.
+	g.BufferViews = append(g.BufferViews, gltfExportView{ByteOffset: buf.Len(),
+		ByteLength: len(d.F) * 2, Target: 34963})
+	g.Accessors = append(g.Accessors, gltfExportAccessor{BufferView: len(g.BufferViews) - 1,
+		ComponentType: 5123, Count: len(d.F), Type: "SCALAR"})
+	prim.Indices = len(g.Accessors) - 1
+	binary.Write(buf, binary.LittleEndian, d.F)
+	g.Meshes = []gltfExportMesh{{Name: d.Name, Primitives: []gltfExportPrim{prim}}}
+	g.Buffers = []gltfExportBuffer{{ByteLength: buf.Len(),
+		URI: "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())}}
+	js, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(js)
+	return err
+}
+
+// exportable checks that the mesh data is consistent.
+func exportable(d *MshData) error {
+	verts := len(d.V) / 3
+	if verts == 0 || len(d.V)%3 != 0 || len(d.F)%3 != 0 {
+		return fmt.Errorf("Invalid mesh %s for export", d.Name)
+	}
+	for _, f := range d.F {
+		if int(f) >= verts {
+			return fmt.Errorf("Invalid mesh %s face index %d", d.Name, f)
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// internal implementation for writing glTF files.
+
+// gltfExport is the subset of the glTF JSON written by WriteGltf.
+type gltfExport struct {
+	Asset struct {
+		Version   string `json:"version"`
+		Generator string `json:"generator"`
+	} `json:"asset"`
+	Scene       int                  `json:"scene"`
+	Scenes      []gltfExportScene    `json:"scenes"`
+	Nodes       []gltfExportNode     `json:"nodes"`
+	Meshes      []gltfExportMesh     `json:"meshes"`
+	Accessors   []gltfExportAccessor `json:"accessors"`
+	BufferViews []gltfExportView     `json:"bufferViews"`
+	Buffers     []gltfExportBuffer   `json:"buffers"`
+}
+
+// gltfExportScene lists the root nodes of a scene.
+type gltfExportScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+// gltfExportNode places a mesh in the scene.
+type gltfExportNode struct {
+	Name string `json:"name,omitempty"`
+	Mesh int    `json:"mesh"`
+}
+
+// gltfExportMesh groups the mesh primitives.
+type gltfExportMesh struct {
+	Name       string           `json:"name,omitempty"`
+	Primitives []gltfExportPrim `json:"primitives"`
+}
+
+// gltfExportPrim is a set of triangles and their vertex attributes.
+type gltfExportPrim struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Mode       int            `json:"mode"`
+}
+
+// gltfExportAccessor describes typed data in a buffer view.
+type gltfExportAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+// gltfExportView is a slice of the buffer.
+type gltfExportView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+// gltfExportBuffer is the embedded data uri buffer.
+type gltfExportBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// floats appends the vertex data to the buffer, adding a buffer
+// view and float accessor. Bounds are required for positions.
+// Returns the accessor index.
+func (g *gltfExport) floats(buf *bytes.Buffer, vals []float32, count int, kind string, bounds bool) int {
+	g.BufferViews = append(g.BufferViews, gltfExportView{ByteOffset: buf.Len(),
+		ByteLength: len(vals) * 4, Target: 34962})
+	a := gltfExportAccessor{BufferView: len(g.BufferViews) - 1,
+		ComponentType: 5126, Count: count, Type: kind}
+	if bounds {
+		span := len(vals) / count
+		a.Min, a.Max = make([]float32, span), make([]float32, span)
+		for i := range a.Min {
+			a.Min[i], a.Max[i] = math.MaxFloat32, -math.MaxFloat32
+		}
+		for cnt, v := range vals {
+			a.Min[cnt%span] = float32(math.Min(float64(a.Min[cnt%span]), float64(v)))
+			a.Max[cnt%span] = float32(math.Max(float64(a.Max[cnt%span]), float64(v)))
+		}
+	}
+	g.Accessors = append(g.Accessors, a)
+	binary.Write(buf, binary.LittleEndian, vals)
+	return len(g.Accessors) - 1
+}
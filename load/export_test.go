@@ -0,0 +1,79 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// corners collects the position and texture coordinate of each
+// triangle corner so that meshes can be compared after reordering.
+// Values are rounded since flipped texture coordinates are not exact.
+func corners(d *MshData) map[[5]float32]int {
+	c := map[[5]float32]int{}
+	round := func(f float32) float32 { return float32(math.Floor(float64(f)*1e4+0.5) / 1e4) }
+	for _, f := range d.F {
+		v, t := d.V[f*3:f*3+3], d.T[f*2:f*2+2]
+		c[[5]float32{round(v[0]), round(v[1]), round(v[2]), round(t[0]), round(t[1])}]++
+	}
+	return c
+}
+
+func TestWriteObj(t *testing.T) {
+	msh := grid(3, true)
+	msh.Name = "grid"
+	msh.GenNormals()
+	buf := &bytes.Buffer{}
+	if err := WriteObj(buf, msh); err != nil {
+		t.Fatal(err)
+	}
+	d := &MshData{}
+	if err := Obj(buf, d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "grid" || len(d.F) != len(msh.F) || len(d.N) != len(d.V) {
+		t.Fatalf("Bad round trip %s %d %d", d.Name, len(d.F), len(d.N))
+	}
+	if !reflect.DeepEqual(corners(d), corners(msh)) {
+		t.Errorf("Expected the same triangles after round trip")
+	}
+}
+
+func TestWriteGltf(t *testing.T) {
+	msh := grid(3, true)
+	msh.Name = "grid"
+	msh.GenNormals()
+	msh.GenTangents()
+	buf := &bytes.Buffer{}
+	if err := WriteGltf(buf, msh); err != nil {
+		t.Fatal(err)
+	}
+	d := &ScnData{}
+	if err := Gltf(buf, d, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Nodes) != 1 || len(d.Meshes) != 1 {
+		t.Fatalf("Expected 1 node and mesh got %d %d", len(d.Nodes), len(d.Meshes))
+	}
+	m := &d.Meshes[0].MshData
+	if m.Name != "grid" || len(m.F) != len(msh.F) || len(m.X) != len(msh.X) {
+		t.Fatalf("Bad round trip %s %d %d", m.Name, len(m.F), len(m.X))
+	}
+	if !reflect.DeepEqual(corners(m), corners(msh)) {
+		t.Errorf("Expected the same triangles after round trip")
+	}
+}
+
+func TestWriteInvalid(t *testing.T) {
+	bad := &MshData{V: []float32{0, 0, 0}, F: []uint16{0, 0, 1}}
+	if err := WriteObj(&bytes.Buffer{}, bad); err == nil {
+		t.Error("Expected bad face error")
+	}
+	if err := WriteGltf(&bytes.Buffer{}, &MshData{}); err == nil {
+		t.Error("Expected empty mesh error")
+	}
+}
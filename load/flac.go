@@ -0,0 +1,402 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// flac.go decodes FLAC lossless audio.
+// FUTURE: Check the frame CRCs and the STREAMINFO MD5 signature.
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/bits"
+)
+
+// Flac attempts to load FLAC based audio data into SndData.
+// Samples are converted to 16 bits. The format is described at:
+//     https://xiph.org/flac/format.html
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import overwrites the data in SndData.
+func Flac(r io.Reader, d *SndData) (err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("Invalid .flac audio file: %s", err)
+	}
+	f, err := newFlacStream(data)
+	if err != nil {
+		return fmt.Errorf("Invalid .flac audio file: %s", err)
+	}
+	size := f.samples * uint64(f.channels) * 2
+	if size > uint64(len(data))*16 {
+		size = 0 // don't trust the sample count.
+	}
+	pcm := make([]byte, 0, size)
+	for {
+		block, err := f.frame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Corrupt .flac audio file: %s", err)
+		}
+		pcm = flacPCM(pcm, block, f.bps)
+	}
+	d.Attrs = &SndAttributes{Channels: uint16(f.channels), Frequency: f.rate,
+		DataSize: uint32(len(pcm)), SampleBits: 16}
+	d.Data = pcm
+	return nil
+}
+
+// =============================================================================
+// internal implementation for loading FLAC files.
+
+// flacSndStream decodes FLAC frames as they are needed. The compressed
+// data is read into memory since it is much smaller than the samples.
+func flacSndStream(r io.Reader) (*SndStream, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid .flac audio file: %s", err)
+	}
+	f, err := newFlacStream(data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid .flac audio file: %s", err)
+	}
+	next := func() ([]byte, error) {
+		block, err := f.frame()
+		switch {
+		case err == io.EOF:
+			return nil, err
+		case err != nil:
+			return nil, fmt.Errorf("Corrupt .flac audio file: %s", err)
+		}
+		return flacPCM(nil, block, f.bps), nil
+	}
+	attrs := &SndAttributes{Channels: uint16(f.channels), Frequency: f.rate, SampleBits: 16}
+	return &SndStream{Attrs: attrs, next: next}, nil
+}
+
+// flacStream holds the STREAMINFO needed to decode the audio frames.
+type flacStream struct {
+	bits     flacBits // Audio frame data.
+	rate     uint32   // Samples per second.
+	channels int      // Number of channels, 1 to 8.
+	bps      int      // Bits per sample, 4 to 32.
+	samples  uint64   // Samples per channel. 0 if unknown.
+}
+
+// newFlacStream reads the metadata blocks up to the first audio frame.
+func newFlacStream(data []byte) (*flacStream, error) {
+	if len(data) < 4 || string(data[:4]) != "fLaC" {
+		return nil, fmt.Errorf("missing fLaC marker")
+	}
+	f := &flacStream{bits: flacBits{data: data, pos: 32}}
+	b := &f.bits
+	for last := false; !last; {
+		last = b.read(1) == 1
+		kind, size := b.read(7), int(b.read(24))
+		start := b.pos
+		if kind == 0 { // STREAMINFO
+			b.read(16) // minimum block size.
+			b.read(16) // maximum block size.
+			b.read(24) // minimum frame size.
+			b.read(24) // maximum frame size.
+			f.rate = uint32(b.read(20))
+			f.channels = int(b.read(3)) + 1
+			f.bps = int(b.read(5)) + 1
+			f.samples = b.read(36)
+		}
+		b.pos = start + size*8
+		if b.err != nil || b.pos > len(data)*8 {
+			return nil, fmt.Errorf("bad metadata")
+		}
+	}
+	if f.rate == 0 || f.bps < 4 {
+		return nil, fmt.Errorf("missing STREAMINFO")
+	}
+	return f, nil
+}
+
+// frame decodes the next audio frame, returning the samples for each
+// channel. Returns io.EOF when there are no more frames.
+func (f *flacStream) frame() ([][]int64, error) {
+	b := &f.bits
+	if b.pos >= len(b.data)*8 {
+		return nil, io.EOF
+	}
+	if b.read(14) != 0x3FFE {
+		return nil, fmt.Errorf("lost frame sync")
+	}
+	b.read(2) // reserved and blocking strategy.
+	sizeCode, rateCode := b.read(4), b.read(4)
+	chanCode, bpsCode := b.read(4), b.read(3)
+	b.read(1) // reserved.
+
+	// skip the utf-8 style coded frame or sample number.
+	first := uint8(b.read(8))
+	for cnt := 1; cnt < bits.LeadingZeros8(^first); cnt++ {
+		b.read(8)
+	}
+	size := 0
+	switch {
+	case sizeCode == 1:
+		size = 192
+	case sizeCode >= 2 && sizeCode <= 5:
+		size = 576 << (sizeCode - 2)
+	case sizeCode == 6:
+		size = int(b.read(8)) + 1
+	case sizeCode == 7:
+		size = int(b.read(16)) + 1
+	case sizeCode >= 8:
+		size = 256 << (sizeCode - 8)
+	default:
+		return nil, fmt.Errorf("reserved block size")
+	}
+	switch rateCode { // STREAMINFO sample rate is used.
+	case 12:
+		b.read(8)
+	case 13, 14:
+		b.read(16)
+	}
+	b.read(8) // crc-8
+	bps := f.bps
+	switch bpsCode {
+	case 1, 2:
+		bps = int(bpsCode)*4 + 4 // 8 or 12 bits.
+	case 4, 5, 6:
+		bps = int(bpsCode) * 4 // 16, 20, or 24 bits.
+	case 7:
+		bps = 32
+	case 3:
+		return nil, fmt.Errorf("reserved sample size")
+	}
+	channels := int(chanCode) + 1
+	if chanCode >= 8 && chanCode <= 10 {
+		channels = 2 // stereo decorrelation.
+	}
+	if chanCode > 10 || channels != f.channels {
+		return nil, fmt.Errorf("bad channel assignment %d", chanCode)
+	}
+	block := make([][]int64, channels)
+	for ch := range block {
+		sbps := bps
+		if (chanCode == 8 || chanCode == 10) && ch == 1 || chanCode == 9 && ch == 0 {
+			sbps++ // side channels have an extra bit.
+		}
+		block[ch] = make([]int64, size)
+		if err := b.subframe(block[ch], sbps); err != nil {
+			return nil, err
+		}
+	}
+	left, right := block[0], block[len(block)-1]
+	switch chanCode {
+	case 8: // left and side.
+		for cnt := range right {
+			right[cnt] = left[cnt] - right[cnt]
+		}
+	case 9: // side and right.
+		for cnt := range left {
+			left[cnt] += right[cnt]
+		}
+	case 10: // mid and side.
+		for cnt := range left {
+			mid, side := left[cnt]<<1|right[cnt]&1, right[cnt]
+			left[cnt], right[cnt] = (mid+side)>>1, (mid-side)>>1
+		}
+	}
+	b.pos = (b.pos + 7) &^ 7 // frame footer is byte aligned.
+	b.read(16)               // crc-16
+	return block, b.err
+}
+
+// subframe decodes the samples for one channel.
+func (b *flacBits) subframe(out []int64, bps int) error {
+	b.read(1) // zero padding.
+	kind := b.read(6)
+	wasted := 0
+	if b.read(1) == 1 {
+		for wasted = 1; b.read(1) == 0 && b.err == nil; wasted++ {
+		}
+	}
+	bps -= wasted
+	switch {
+	case kind == 0: // constant.
+		v := b.signed(bps)
+		for cnt := range out {
+			out[cnt] = v
+		}
+	case kind == 1: // verbatim.
+		for cnt := range out {
+			out[cnt] = b.signed(bps)
+		}
+	case kind >= 8 && kind <= 12: // fixed predictor.
+		order := int(kind - 8)
+		if err := b.warmup(out, order, bps); err != nil {
+			return err
+		}
+		for cnt := order; cnt < len(out); cnt++ {
+			switch order {
+			case 1:
+				out[cnt] += out[cnt-1]
+			case 2:
+				out[cnt] += 2*out[cnt-1] - out[cnt-2]
+			case 3:
+				out[cnt] += 3*out[cnt-1] - 3*out[cnt-2] + out[cnt-3]
+			case 4:
+				out[cnt] += 4*out[cnt-1] - 6*out[cnt-2] + 4*out[cnt-3] - out[cnt-4]
+			}
+		}
+	case kind >= 32: // linear predictor.
+		order := int(kind - 31)
+		if order > len(out) {
+			return fmt.Errorf("bad lpc order %d", order)
+		}
+		for cnt := 0; cnt < order; cnt++ {
+			out[cnt] = b.signed(bps)
+		}
+		precision := int(b.read(4)) + 1
+		shift := b.signed(5)
+		if precision > 15 || shift < 0 {
+			return fmt.Errorf("bad lpc coefficients")
+		}
+		coefs := make([]int64, order)
+		for cnt := range coefs {
+			coefs[cnt] = b.signed(precision)
+		}
+		if err := b.residual(out, order); err != nil {
+			return err
+		}
+		for cnt := order; cnt < len(out); cnt++ {
+			sum := int64(0)
+			for j, c := range coefs {
+				sum += c * out[cnt-1-j]
+			}
+			out[cnt] += sum >> uint(shift)
+		}
+	default:
+		return fmt.Errorf("reserved subframe type %d", kind)
+	}
+	if wasted > 0 {
+		for cnt := range out {
+			out[cnt] <<= uint(wasted)
+		}
+	}
+	return b.err
+}
+
+// warmup reads the unencoded starting samples for a fixed
+// predictor followed by the residual.
+func (b *flacBits) warmup(out []int64, order, bps int) error {
+	if order > len(out) {
+		return fmt.Errorf("bad predictor order %d", order)
+	}
+	for cnt := 0; cnt < order; cnt++ {
+		out[cnt] = b.signed(bps)
+	}
+	return b.residual(out, order)
+}
+
+// residual reads the rice coded prediction errors that follow
+// the warmup samples.
+func (b *flacBits) residual(out []int64, order int) error {
+	method := b.read(2)
+	if method > 1 {
+		return fmt.Errorf("reserved residual coding %d", method)
+	}
+	kbits, escape := uint(4), uint64(15)
+	if method == 1 {
+		kbits, escape = 5, 31
+	}
+	porder := uint(b.read(4))
+	psize := len(out) >> porder
+	if psize<<porder != len(out) || psize < order {
+		return fmt.Errorf("bad partition order %d", porder)
+	}
+	at := order
+	for p := 0; p < 1<<porder; p++ {
+		n := psize
+		if p == 0 {
+			n -= order
+		}
+		k := b.read(kbits)
+		if k == escape {
+			raw := int(b.read(5))
+			for cnt := 0; cnt < n; cnt++ {
+				out[at] = b.signed(raw)
+				at++
+			}
+			continue
+		}
+		for cnt := 0; cnt < n && b.err == nil; cnt++ {
+			v := b.unary()<<k | b.read(uint(k))
+			out[at] = int64(v>>1) ^ -int64(v&1)
+			at++
+		}
+	}
+	return b.err
+}
+
+// flacBits reads most significant bit first values from a byte slice.
+// Reading past the end sets err and returns zeros.
+type flacBits struct {
+	data []byte // Bytes being read.
+	pos  int    // Bit position.
+	err  error  // Set if reading past the end.
+}
+
+// read returns the next n bits, up to 64.
+func (b *flacBits) read(n uint) (v uint64) {
+	for n > 0 {
+		at := b.pos >> 3
+		if at >= len(b.data) {
+			b.err = io.ErrUnexpectedEOF
+			return 0
+		}
+		avail := 8 - uint(b.pos&7)
+		take := avail
+		if n < take {
+			take = n
+		}
+		v = v<<take | uint64(b.data[at]>>(avail-take))&(1<<take-1)
+		b.pos += int(take)
+		n -= take
+	}
+	return v
+}
+
+// signed returns the next n bits as a two's complement value.
+func (b *flacBits) signed(n int) int64 {
+	if n <= 0 {
+		return 0
+	}
+	v := b.read(uint(n))
+	if v&(1<<uint(n-1)) != 0 {
+		return int64(v) - int64(1)<<uint(n)
+	}
+	return int64(v)
+}
+
+// unary returns the number of zero bits before the next one bit.
+func (b *flacBits) unary() (n uint64) {
+	for b.read(1) == 0 && b.err == nil {
+		n++
+	}
+	return n
+}
+
+// flacPCM appends the block samples, interleaved and scaled to
+// 16 bits, as little endian bytes.
+func flacPCM(pcm []byte, block [][]int64, bps int) []byte {
+	for cnt := range block[0] {
+		for ch := range block {
+			s := block[ch][cnt]
+			if bps > 16 {
+				s >>= uint(bps - 16)
+			} else {
+				s <<= uint(16 - bps)
+			}
+			pcm = append(pcm, byte(s), byte(s>>8))
+		}
+	}
+	return pcm
+}
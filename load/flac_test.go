@@ -0,0 +1,203 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// flacWriter writes most significant bit first values for test streams.
+type flacWriter struct {
+	data []byte
+	pos  int
+}
+
+func (w *flacWriter) write(v uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		if w.pos>>3 >= len(w.data) {
+			w.data = append(w.data, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.data[w.pos>>3] |= 0x80 >> uint(w.pos&7)
+		}
+		w.pos++
+	}
+}
+
+func (w *flacWriter) signed(v int64, n uint) { w.write(uint64(v)&(1<<n-1), n) }
+func (w *flacWriter) align()                 { w.pos = (w.pos + 7) &^ 7 }
+
+// frame writes a frame header for a block of size samples.
+func (w *flacWriter) frame(num, size, chanCode uint64) {
+	w.write(0x3FFE, 14)
+	w.write(0, 2)
+	w.write(6, 4) // 8 bit block size follows the frame number.
+	w.write(0, 4)
+	w.write(chanCode, 4)
+	w.write(4, 3) // 16 bits per sample.
+	w.write(0, 1)
+	w.write(num, 8)
+	w.write(size-1, 8)
+	w.write(0, 8)
+}
+
+// flacStereo has a left/side frame with a fixed predictor and a
+// constant, followed by an independent frame with a verbatim and a
+// constant with wasted bits.
+func flacStereo() (data []byte, left, right []int64) {
+	w := &flacWriter{}
+	w.write(0x664C6143, 32) // fLaC
+	w.write(1, 1)
+	w.write(0, 7)
+	w.write(34, 24)
+	w.write(8, 16)
+	w.write(8, 16)
+	w.write(0, 24)
+	w.write(0, 24)
+	w.write(44100, 20)
+	w.write(1, 3)
+	w.write(15, 5)
+	w.write(12, 36)
+	w.write(0, 64) // md5
+	w.write(0, 64)
+
+	// left and side frame.
+	w.frame(0, 8, 8)
+	w.write(0, 1)
+	w.write(10, 6) // fixed order 2.
+	w.write(0, 1)
+	w.signed(100, 16)
+	w.signed(110, 16)
+	w.write(0, 2)
+	w.write(0, 4)
+	k := uint(2)
+	w.write(uint64(k), 4)
+	left = []int64{100, 110}
+	for _, r := range []int64{1, -1, 0, 2, -3, 0} {
+		u := uint64(r<<1 ^ r>>63)
+		w.write(1, uint(u>>k)+1) // unary quotient.
+		w.write(u&(1<<k-1), k)
+		left = append(left, 2*left[len(left)-1]-left[len(left)-2]+r)
+	}
+	w.write(0, 1)
+	w.write(0, 6) // constant side.
+	w.write(0, 1)
+	w.signed(-3, 17)
+	for _, l := range left {
+		right = append(right, l+3)
+	}
+	w.align()
+	w.write(0, 16)
+
+	// independent frame.
+	w.frame(1, 4, 1)
+	w.write(0, 1)
+	w.write(1, 6) // verbatim.
+	w.write(0, 1)
+	for _, s := range []int64{-32768, -1, 0, 32767} {
+		w.signed(s, 16)
+		left = append(left, s)
+	}
+	w.write(0, 1)
+	w.write(0, 6) // constant with 3 wasted bits.
+	w.write(1, 1)
+	w.write(1, 3)
+	w.signed(-5, 13)
+	right = append(right, -40, -40, -40, -40)
+	w.align()
+	w.write(0, 16)
+	return w.data, left, right
+}
+
+func TestFlac(t *testing.T) {
+	data, left, right := flacStereo()
+	d := &SndData{}
+	if err := Flac(bytes.NewReader(data), d); err != nil {
+		t.Fatal(err)
+	}
+	a := d.Attrs
+	if a.Channels != 2 || a.Frequency != 44100 || a.SampleBits != 16 || int(a.DataSize) != len(d.Data) {
+		t.Fatalf("Bad attributes %+v", a)
+	}
+	if len(d.Data) != len(left)*4 {
+		t.Fatalf("Expected %d bytes got %d", len(left)*4, len(d.Data))
+	}
+	for cnt := range left {
+		l := int16(binary.LittleEndian.Uint16(d.Data[cnt*4:]))
+		r := int16(binary.LittleEndian.Uint16(d.Data[cnt*4+2:]))
+		if int64(l) != left[cnt] || int64(r) != right[cnt] {
+			t.Errorf("Sample %d expected %d %d got %d %d", cnt, left[cnt], right[cnt], l, r)
+		}
+	}
+}
+
+// Streamed frames match the fully decoded sound.
+func TestFlacStream(t *testing.T) {
+	data, left, _ := flacStereo()
+	s, err := flacSndStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := ioutil.ReadAll(s)
+	if err != nil || len(pcm) != len(left)*4 || s.Attrs.Channels != 2 {
+		t.Errorf("Expected %d streamed bytes got %d %s", len(left)*4, len(pcm), err)
+	}
+}
+
+func TestFlacInvalid(t *testing.T) {
+	data, _, _ := flacStereo()
+	if err := Flac(bytes.NewReader(data[1:]), &SndData{}); err == nil {
+		t.Error("Expected missing marker error")
+	}
+	if err := Flac(bytes.NewReader(data[:len(data)-3]), &SndData{}); err == nil {
+		t.Error("Expected truncated frame error")
+	}
+}
+
+// reference decodes an eg/audio fixture that was made from tone.wav by
+// a reference encoder. Returns the fixture and source sounds. Skips the
+// test if the fixture has not been generated, using:
+//    flac -8 -o tone.flac tone.wav
+//    oggenc -q 4 -o tone.ogg tone.wav
+func reference(t *testing.T, name string, decode func(io.Reader, *SndData) error) (got, src *SndData) {
+	f, err := os.Open("../eg/audio/" + name)
+	if os.IsNotExist(err) {
+		t.Skipf("Reference encoder fixture %s not found", name)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got = &SndData{}
+	if err = decode(f, got); err != nil {
+		t.Fatal(err)
+	}
+	w, err := os.Open("../eg/audio/tone.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	src = &SndData{}
+	if err = Wav(w, src); err != nil {
+		t.Fatal(err)
+	}
+	if *got.Attrs != *src.Attrs {
+		t.Fatalf("Expected attributes %+v got %+v", src.Attrs, got.Attrs)
+	}
+	return got, src
+}
+
+// A stream from the reference flac encoder decodes to the exact
+// samples that were encoded.
+func TestFlacReference(t *testing.T) {
+	got, src := reference(t, "tone.flac", Flac)
+	if !bytes.Equal(got.Data, src.Data) {
+		t.Errorf("Expected lossless samples from the reference encoder")
+	}
+}
@@ -0,0 +1,901 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// gltf.go imports glTF 2.0 scenes. The specification is at:
+//    https://github.com/KhronosGroup/glTF/tree/master/specification/2.0
+// FUTURE: Sparse accessors, morph targets, cameras, lights, and
+//         node animations on nodes that are not skin joints.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register jpeg decoding for embedded images.
+	_ "image/png"  // register png decoding for embedded images.
+	"io"
+	"io/ioutil"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Gltf reads a glTF 2.0 scene in either the JSON .gltf or the binary .glb
+// format. Buffers that are not embedded in the file are found using the
+// Locator. Images that are not embedded are expected to be loaded
+// separately by name as textures, ie: "images/wood.png" is "wood".
+//
+// Only triangle primitives are imported. The PBR materials are approximated
+// with the ambient, diffuse, and specular MtlData colors. Skin animations
+// are sampled at a fixed frame rate.
+func Gltf(r io.Reader, d *ScnData, l Locator) (err error) {
+	var data, bin []byte
+	if data, err = ioutil.ReadAll(r); err != nil {
+		return fmt.Errorf("Invalid glTF file: %s", err)
+	}
+	if len(data) >= 4 && string(data[:4]) == "glTF" {
+		if data, bin, err = glbChunks(data); err != nil {
+			return err
+		}
+	}
+	g := &gltf{name: d.Name}
+	if err = json.Unmarshal(data, g); err != nil {
+		return fmt.Errorf("Invalid glTF json: %s", err)
+	}
+	if !strings.HasPrefix(g.Asset.Version, "2") {
+		return fmt.Errorf("Invalid glTF version %s", g.Asset.Version)
+	}
+	if err = g.loadBuffers(bin, l); err != nil {
+		return err
+	}
+	d.Images = map[string]*ImgData{}
+	if err = g.loadImages(d); err != nil {
+		return err
+	}
+	if err = g.loadMeshes(d); err != nil {
+		return err
+	}
+	return g.loadNodes(d)
+}
+
+// glbChunks returns the JSON and binary chunks from a binary glTF file.
+func glbChunks(data []byte) (js, bin []byte, err error) {
+	if len(data) < 20 || binary.LittleEndian.Uint32(data[4:]) != 2 {
+		return nil, nil, fmt.Errorf("Invalid glb header")
+	}
+	for at := 12; at+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[at:]))
+		kind := binary.LittleEndian.Uint32(data[at+4:])
+		if at+8+size > len(data) {
+			return nil, nil, fmt.Errorf("Invalid glb chunk")
+		}
+		switch kind {
+		case 0x4E4F534A: // "JSON"
+			js = data[at+8 : at+8+size]
+		case 0x004E4942: // "BIN"
+			bin = data[at+8 : at+8+size]
+		}
+		at += 8 + size
+	}
+	if js == nil {
+		return nil, nil, fmt.Errorf("Invalid glb missing json")
+	}
+	return js, bin, nil
+}
+
+// gltfRate is the frames per second used to sample skin animations.
+const gltfRate = 30
+
+// =============================================================================
+// The JSON structures for a glTF file. Only the fields used by the
+// importer are included. Field names match the JSON case insensitively.
+
+// gltf is the top level glTF JSON object.
+type gltf struct {
+	Asset struct {
+		Version string
+	}
+	Scene       *int
+	Scenes      []struct{ Nodes []int }
+	Nodes       []gltfNode
+	Meshes      []gltfMesh
+	Materials   []gltfMaterial
+	Textures    []struct{ Source *int }
+	Images      []gltfImage
+	Accessors   []gltfAccessor
+	BufferViews []gltfView
+	Buffers     []gltfBuffer
+	Skins       []gltfSkin
+	Animations  []gltfAnimation
+
+	// Import values calculated from the JSON data.
+	name    string   // Scene name used to name embedded images.
+	bufs    [][]byte // Buffer data.
+	imgs    []string // Image names.
+	prims   [][]int  // Imported ScnData.Meshes for each glTF mesh.
+	parents []int    // Parent node for each node, -1 for root nodes.
+}
+
+// gltfNode is one element in the scene hierarchy.
+type gltfNode struct {
+	Name        string
+	Children    []int
+	Mesh        *int
+	Skin        *int
+	Matrix      []float64 // Column major 4x4 matrix.
+	Translation []float64 // X, Y, Z.
+	Rotation    []float64 // Quaternion X, Y, Z, W.
+	Scale       []float64 // X, Y, Z.
+}
+
+// gltfMesh is a group of primitives that share a node.
+type gltfMesh struct {
+	Name       string
+	Primitives []struct {
+		Attributes map[string]int // Accessors for vertex data.
+		Indices    *int           // Accessor for triangle faces.
+		Material   *int
+		Mode       *int // Default 4 for triangles.
+	}
+}
+
+// gltfMaterial is a physically based material.
+type gltfMaterial struct {
+	Name string
+	Pbr  struct {
+		BaseColorFactor  []float64
+		BaseColorTexture *struct{ Index int }
+		MetallicFactor   *float64
+		RoughnessFactor  *float64
+	} `json:"pbrMetallicRoughness"`
+}
+
+// gltfImage is either an external file, a data uri, or a buffer view.
+type gltfImage struct {
+	Name       string
+	URI        string
+	BufferView *int
+}
+
+// gltfAccessor describes typed data in a buffer view.
+type gltfAccessor struct {
+	BufferView    *int
+	ByteOffset    int
+	ComponentType int
+	Normalized    bool
+	Count         int
+	Type          string
+}
+
+// gltfView is a slice of a buffer.
+type gltfView struct {
+	Buffer     int
+	ByteOffset int
+	ByteLength int
+	ByteStride int
+}
+
+// gltfBuffer is either embedded in a glb file, a data uri, or a file.
+type gltfBuffer struct {
+	URI        string
+	ByteLength int
+}
+
+// gltfSkin lists the joint nodes that influence a skinned mesh.
+type gltfSkin struct {
+	InverseBindMatrices *int
+	Joints              []int
+}
+
+// gltfAnimation animates node translation, rotation, and scale.
+type gltfAnimation struct {
+	Name     string
+	Channels []struct {
+		Sampler int
+		Target  struct {
+			Node *int
+			Path string // translation, rotation, or scale.
+		}
+	}
+	Samplers []struct {
+		Input         int    // Accessor for key frame times.
+		Output        int    // Accessor for key frame values.
+		Interpolation string // LINEAR, STEP, or CUBICSPLINE.
+	}
+}
+
+// =============================================================================
+// glTF data access.
+
+// loadBuffers reads all the buffer data. The buffer without a uri
+// is the glb binary chunk.
+func (g *gltf) loadBuffers(bin []byte, l Locator) (err error) {
+	g.bufs = make([][]byte, len(g.Buffers))
+	for cnt, b := range g.Buffers {
+		var data []byte
+		switch {
+		case b.URI == "":
+			data = bin
+		case strings.HasPrefix(b.URI, "data:"):
+			if data, err = dataURI(b.URI); err != nil {
+				return err
+			}
+		default:
+			var reader io.ReadCloser
+			if reader, err = l.GetResource(b.URI); err != nil {
+				return fmt.Errorf("Could not load glTF buffer %s: %s", b.URI, err)
+			}
+			data, err = ioutil.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				return fmt.Errorf("Could not read glTF buffer %s: %s", b.URI, err)
+			}
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("Invalid glTF buffer %d size", cnt)
+		}
+		g.bufs[cnt] = data
+	}
+	return nil
+}
+
+// dataURI decodes base64 embedded data.
+func dataURI(uri string) ([]byte, error) {
+	at := strings.Index(uri, ";base64,")
+	if at < 0 {
+		return nil, fmt.Errorf("Invalid glTF data uri")
+	}
+	return base64.StdEncoding.DecodeString(uri[at+len(";base64,"):])
+}
+
+// view returns the bytes for a buffer view.
+func (g *gltf) view(index int) (data []byte, stride int, err error) {
+	if index < 0 || index >= len(g.BufferViews) {
+		return nil, 0, fmt.Errorf("Invalid glTF buffer view %d", index)
+	}
+	v := g.BufferViews[index]
+	if v.Buffer < 0 || v.Buffer >= len(g.bufs) || v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteStride < 0 ||
+		v.ByteOffset+v.ByteLength > len(g.bufs[v.Buffer]) {
+		return nil, 0, fmt.Errorf("Invalid glTF buffer view %d", index)
+	}
+	return g.bufs[v.Buffer][v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+// Number of values for each accessor type.
+var gltfSpans = map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT4": 16}
+
+// Size in bytes of each accessor component type.
+var gltfSizes = map[int]int{5120: 1, 5121: 1, 5122: 2, 5123: 2, 5125: 4, 5126: 4}
+
+// floats returns the accessor values converted to floats along with
+// the number of values per element.
+func (g *gltf) floats(index int) (vals []float32, span int, err error) {
+	if index < 0 || index >= len(g.Accessors) {
+		return nil, 0, fmt.Errorf("Invalid glTF accessor %d", index)
+	}
+	a := g.Accessors[index]
+	span, size := gltfSpans[a.Type], gltfSizes[a.ComponentType]
+	if span == 0 || size == 0 {
+		return nil, 0, fmt.Errorf("Invalid glTF accessor %d type", index)
+	}
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return nil, 0, fmt.Errorf("Invalid glTF accessor %d size", index)
+	}
+	vals = make([]float32, a.Count*span)
+	if a.BufferView == nil {
+		return vals, span, nil // all zeros.
+	}
+	data, stride, err := g.view(*a.BufferView)
+	if err != nil {
+		return nil, 0, err
+	}
+	if stride == 0 {
+		stride = span * size // tightly packed.
+	}
+	if a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+span*size > len(data) {
+		return nil, 0, fmt.Errorf("Invalid glTF accessor %d size", index)
+	}
+	for cnt := 0; cnt < a.Count; cnt++ {
+		at := a.ByteOffset + cnt*stride
+		for c := 0; c < span; c++ {
+			vals[cnt*span+c] = gltfValue(data[at+c*size:], a.ComponentType, a.Normalized)
+		}
+	}
+	return vals, span, nil
+}
+
+// gltfValue converts one accessor component to a float.
+func gltfValue(b []byte, kind int, normalized bool) float32 {
+	v, scale := 0.0, 1.0
+	switch kind {
+	case 5120: // byte
+		v, scale = float64(int8(b[0])), 127
+	case 5121: // unsigned byte
+		v, scale = float64(b[0]), 255
+	case 5122: // short
+		v, scale = float64(int16(binary.LittleEndian.Uint16(b))), 32767
+	case 5123: // unsigned short
+		v, scale = float64(binary.LittleEndian.Uint16(b)), 65535
+	case 5125: // unsigned int
+		return float32(binary.LittleEndian.Uint32(b))
+	default: // float
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	if normalized {
+		return float32(math.Max(v/scale, -1))
+	}
+	return float32(v)
+}
+
+// =============================================================================
+// glTF import.
+
+// loadImages names each image. Embedded images are decoded. External
+// images are named after their file.
+func (g *gltf) loadImages(d *ScnData) error {
+	g.imgs = make([]string, len(g.Images))
+	for cnt, img := range g.Images {
+		var data []byte
+		var err error
+		switch {
+		case img.BufferView != nil:
+			data, _, err = g.view(*img.BufferView)
+		case strings.HasPrefix(img.URI, "data:"):
+			data, err = dataURI(img.URI)
+		default:
+			base := path.Base(img.URI)
+			g.imgs[cnt] = strings.TrimSuffix(base, path.Ext(base))
+			continue // loaded separately.
+		}
+		if err != nil {
+			return err
+		}
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("Invalid glTF image %d: %s", cnt, err)
+		}
+		name := g.name + "-image" + strconv.Itoa(cnt)
+		d.Images[name] = &ImgData{Img: toNRGBA(decoded)}
+		g.imgs[cnt] = name
+	}
+	return nil
+}
+
+// loadMeshes imports each triangle primitive as a separate mesh.
+func (g *gltf) loadMeshes(d *ScnData) error {
+	g.prims = make([][]int, len(g.Meshes))
+	for mc, m := range g.Meshes {
+		name := m.Name
+		if name == "" {
+			name = g.name + "-mesh" + strconv.Itoa(mc)
+		}
+		for pc, p := range m.Primitives {
+			if p.Mode != nil && *p.Mode != 4 {
+				continue // only triangles are supported.
+			}
+			sm := ScnMesh{Skin: -1}
+			sm.Name = name
+			if len(m.Primitives) > 1 {
+				sm.Name = name + "-" + strconv.Itoa(pc)
+			}
+			pos, ok := p.Attributes["POSITION"]
+			if !ok {
+				return fmt.Errorf("Invalid glTF mesh %s without positions", sm.Name)
+			}
+			var err error
+			if sm.V, _, err = g.floats(pos); err != nil {
+				return err
+			}
+			verts := len(sm.V) / 3
+			if verts > math.MaxUint16 {
+				return fmt.Errorf("Invalid glTF mesh %s has %d verticies", sm.Name, verts)
+			}
+			if at, ok := p.Attributes["NORMAL"]; ok {
+				if sm.N, _, err = g.floats(at); err != nil {
+					return err
+				}
+			}
+			if at, ok := p.Attributes["TEXCOORD_0"]; ok {
+				if sm.T, _, err = g.floats(at); err != nil {
+					return err
+				}
+			}
+			if at, ok := p.Attributes["TANGENT"]; ok {
+				if sm.X, _, err = g.floats(at); err != nil {
+					return err
+				}
+			}
+			if p.Indices != nil {
+				faces, _, err := g.floats(*p.Indices)
+				if err != nil {
+					return err
+				}
+				sm.F = make([]uint16, len(faces))
+				for cnt, f := range faces {
+					sm.F[cnt] = uint16(f)
+				}
+			} else {
+				sm.F = make([]uint16, verts)
+				for cnt := range sm.F {
+					sm.F[cnt] = uint16(cnt)
+				}
+			}
+			sm.GenNormals()
+			sm.GenTangents()
+			if err = g.loadSkinData(p.Attributes, &sm); err != nil {
+				return err
+			}
+			g.loadMaterial(p.Material, &sm)
+			sm.Optimize()
+			g.prims[mc] = append(g.prims[mc], len(d.Meshes))
+			d.Meshes = append(d.Meshes, sm)
+		}
+	}
+	return nil
+}
+
+// loadSkinData imports the per vertex joint indicies and weights.
+// The joints are remapped when the skin is attached by a node.
+func (g *gltf) loadSkinData(attrs map[string]int, sm *ScnMesh) error {
+	ja, jok := attrs["JOINTS_0"]
+	wa, wok := attrs["WEIGHTS_0"]
+	if !jok || !wok {
+		return nil
+	}
+	joints, _, err := g.floats(ja)
+	if err != nil {
+		return err
+	}
+	weights, _, err := g.floats(wa)
+	if err != nil {
+		return err
+	}
+	sm.Blends = make([]byte, len(joints))
+	for cnt, j := range joints {
+		sm.Blends[cnt] = byte(j)
+	}
+	sm.Weights = make([]byte, len(weights))
+	for cnt, w := range weights {
+		sm.Weights[cnt] = byte(lin.Clamp(float64(w), 0, 1)*255 + 0.5)
+	}
+	return nil
+}
+
+// loadMaterial approximates the PBR material and finds the base color
+// texture, if any.
+func (g *gltf) loadMaterial(index *int, sm *ScnMesh) {
+	kd, alpha, metal, rough := [3]float64{1, 1, 1}, 1.0, 1.0, 1.0
+	if index != nil && *index >= 0 && *index < len(g.Materials) {
+		pbr := g.Materials[*index].Pbr
+		if f := pbr.BaseColorFactor; len(f) == 4 {
+			kd, alpha = [3]float64{f[0], f[1], f[2]}, f[3]
+		}
+		if pbr.MetallicFactor != nil {
+			metal = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			rough = *pbr.RoughnessFactor
+		}
+		if t := pbr.BaseColorTexture; t != nil && t.Index >= 0 && t.Index < len(g.Textures) {
+			if src := g.Textures[t.Index].Source; src != nil && *src >= 0 && *src < len(g.imgs) {
+				sm.TMap = []TexMap{{Name: g.imgs[*src], F0: 0, Fn: uint32(len(sm.F) / 3)}}
+			}
+		}
+	}
+	ks := [3]float64{}
+	for cnt := range ks {
+		ks[cnt] = lin.Lerp(0.04, kd[cnt], metal) // dielectrics reflect 4%.
+	}
+	sm.KaR, sm.KaG, sm.KaB = float32(kd[0]*0.2), float32(kd[1]*0.2), float32(kd[2]*0.2)
+	sm.KdR, sm.KdG, sm.KdB = float32(kd[0]), float32(kd[1]), float32(kd[2])
+	sm.KsR, sm.KsG, sm.KsB = float32(ks[0]), float32(ks[1]), float32(ks[2])
+	sm.Ns = float32((1-rough)*(1-rough)*128 + 1)
+	sm.Alpha = float32(alpha)
+}
+
+// loadNodes flattens the scene hierarchy so parents precede children.
+// Skins are resolved once the node hierarchy is known.
+func (g *gltf) loadNodes(d *ScnData) error {
+	g.parents = make([]int, len(g.Nodes))
+	for cnt := range g.parents {
+		g.parents[cnt] = -1
+	}
+	for cnt, n := range g.Nodes {
+		for _, child := range n.Children {
+			if child >= 0 && child < len(g.Nodes) {
+				g.parents[child] = cnt
+			}
+		}
+	}
+	for cnt := range g.parents {
+		depth := 0
+		for p := g.parents[cnt]; p >= 0; p = g.parents[p] {
+			if depth++; depth > len(g.Nodes) {
+				return fmt.Errorf("Invalid glTF node %d cycle", cnt)
+			}
+		}
+	}
+	for cnt, s := range g.Skins {
+		for _, node := range s.Joints {
+			if node < 0 || node >= len(g.Nodes) {
+				return fmt.Errorf("Invalid glTF skin %d joint %d", cnt, node)
+			}
+		}
+	}
+	roots := []int{}
+	if scene := 0; len(g.Scenes) > 0 {
+		if g.Scene != nil && *g.Scene >= 0 && *g.Scene < len(g.Scenes) {
+			scene = *g.Scene
+		}
+		roots = g.Scenes[scene].Nodes
+	} else {
+		for cnt, parent := range g.parents {
+			if parent < 0 {
+				roots = append(roots, cnt)
+			}
+		}
+	}
+	skinned := map[int]int{} // imported mesh to skin.
+	added := make([]bool, len(g.Nodes))
+	for _, root := range roots {
+		if err := g.addNode(d, root, -1, skinned, added); err != nil {
+			return err
+		}
+	}
+	anims := map[int]AnmData{} // skin animations.
+	for mi, skin := range skinned {
+		sm := &d.Meshes[mi]
+		if _, ok := anims[skin]; !ok {
+			anims[skin] = g.loadSkin(skin)
+		}
+		anm := anims[skin]
+		sm.AnmData.Movements, sm.Joints, sm.Frames = anm.Movements, anm.Joints, anm.Frames
+		sm.Binds = anm.Binds
+		sm.Names = anm.Names
+		sm.Skin = skin
+		order := g.jointOrder(skin)
+		for cnt, b := range sm.Blends {
+			if int(b) < len(order) {
+				sm.Blends[cnt] = byte(order[b])
+			}
+		}
+	}
+	return nil
+}
+
+// addNode adds the node and its children to the flattened hierarchy.
+// Nodes can only be added once, which stops node cycles.
+func (g *gltf) addNode(d *ScnData, index, parent int, skinned map[int]int, added []bool) error {
+	if index < 0 || index >= len(g.Nodes) {
+		return nil
+	}
+	if added[index] {
+		return fmt.Errorf("Invalid glTF node %d added twice", index)
+	}
+	added[index] = true
+	n := g.Nodes[index]
+	sn := ScnNode{Name: n.Name, Parent: parent}
+	g.local(index, &sn.Loc, &sn.Rot, &sn.Scale)
+	if n.Mesh != nil && *n.Mesh >= 0 && *n.Mesh < len(g.prims) {
+		sn.Meshes = append(sn.Meshes, g.prims[*n.Mesh]...)
+		if n.Skin != nil && *n.Skin >= 0 && *n.Skin < len(g.Skins) {
+			for _, mi := range g.prims[*n.Mesh] {
+				if len(d.Meshes[mi].Blends) > 0 {
+					skinned[mi] = *n.Skin
+				}
+			}
+		}
+	}
+	at := len(d.Nodes)
+	d.Nodes = append(d.Nodes, sn)
+	for _, child := range n.Children {
+		if err := g.addNode(d, child, at, skinned, added); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// local returns the node rest pose local transform.
+func (g *gltf) local(index int, loc *lin.V3, rot *lin.Q, scale *lin.V3) {
+	n := g.Nodes[index]
+	loc.SetS(0, 0, 0)
+	rot.Set(lin.QI)
+	scale.SetS(1, 1, 1)
+	if len(n.Matrix) == 16 {
+		m := &lin.M4{}
+		m.Xx, m.Xy, m.Xz, m.Xw = n.Matrix[0], n.Matrix[1], n.Matrix[2], n.Matrix[3]
+		m.Yx, m.Yy, m.Yz, m.Yw = n.Matrix[4], n.Matrix[5], n.Matrix[6], n.Matrix[7]
+		m.Zx, m.Zy, m.Zz, m.Zw = n.Matrix[8], n.Matrix[9], n.Matrix[10], n.Matrix[11]
+		m.Wx, m.Wy, m.Wz, m.Ww = n.Matrix[12], n.Matrix[13], n.Matrix[14], n.Matrix[15]
+		m.Decompose(loc, rot, scale)
+		return
+	}
+	if t := n.Translation; len(t) == 3 {
+		loc.SetS(t[0], t[1], t[2])
+	}
+	if r := n.Rotation; len(r) == 4 {
+		rot.SetS(r[0], r[1], r[2], r[3])
+	}
+	if s := n.Scale; len(s) == 3 {
+		scale.SetS(s[0], s[1], s[2])
+	}
+}
+
+// jointOrder returns the new index of each skin joint where
+// the new indicies list parent joints before child joints.
+func (g *gltf) jointOrder(skin int) []int {
+	joints := g.Skins[skin].Joints
+	depth := func(node int) (d int) {
+		for p := g.parents[node]; p >= 0; p = g.parents[p] {
+			d++
+		}
+		return d
+	}
+	sorted := byDepth{old: make([]int, len(joints)), depth: make([]int, len(joints))}
+	for cnt, node := range joints {
+		sorted.old[cnt], sorted.depth[cnt] = cnt, depth(node)
+	}
+	sort.Stable(sorted)
+	order := make([]int, len(joints))
+	for at, old := range sorted.old {
+		order[old] = at
+	}
+	return order
+}
+
+// byDepth sorts joint indicies by their depth in the node hierarchy.
+type byDepth struct {
+	old   []int // Joint indicies.
+	depth []int // Depth of each joint.
+}
+
+func (b byDepth) Len() int           { return len(b.old) }
+func (b byDepth) Less(i, j int) bool { return b.depth[i] < b.depth[j] }
+func (b byDepth) Swap(i, j int) {
+	b.old[i], b.old[j] = b.old[j], b.old[i]
+	b.depth[i], b.depth[j] = b.depth[j], b.depth[i]
+}
+
+// loadSkin samples each animation to create skin animation frames.
+// A skin without animations gets a single frame rest pose movement.
+// The frames are generated so that the animation pose for a joint is
+//
+//	inverseBind * jointLocal * parentPose
+//
+// See iqm.go genFrame.
+func (g *gltf) loadSkin(skin int) (anm AnmData) {
+	s := g.Skins[skin]
+	order := g.jointOrder(skin)
+	jointCnt := len(s.Joints)
+	nodes := make([]int, jointCnt) // joint nodes in new order.
+	for old, at := range order {
+		nodes[at] = s.Joints[old]
+	}
+
+	// find each joint parent. Joints without a parent joint
+	// include the rest transform of their ancestors.
+	jointAt := map[int]int{}
+	for at, node := range nodes {
+		jointAt[node] = at
+	}
+	anm.Joints = make([]int32, jointCnt)
+	anm.Names = make([]string, jointCnt)
+	ancestors := make([]*lin.M4, jointCnt)
+	for at, node := range nodes {
+		anm.Joints[at] = -1
+		anm.Names[at] = g.Nodes[node].Name
+		for p := g.parents[node]; p >= 0; p = g.parents[p] {
+			if pj, ok := jointAt[p]; ok {
+				anm.Joints[at] = int32(pj)
+				break
+			}
+		}
+		if anm.Joints[at] < 0 {
+			ancestors[at] = g.restWorld(g.parents[node])
+		}
+	}
+
+	// inverse bind and bind matricies.
+	ibm, bind := make([]*lin.M4, jointCnt), make([]*lin.M4, jointCnt)
+	anm.Binds = make([]lin.V3, jointCnt)
+	var mats []float32
+	if s.InverseBindMatrices != nil {
+		mats, _, _ = g.floats(*s.InverseBindMatrices)
+	}
+	for old, at := range order {
+		ibm[at] = lin.NewM4I()
+		if len(mats) >= (old+1)*16 {
+			setM4(ibm[at], mats[old*16:])
+		}
+		bind[at] = lin.NewM4().Inv(ibm[at])
+		anm.Binds[at].SetS(bind[at].Wx, bind[at].Wy, bind[at].Wz)
+	}
+
+	// sample the animations.
+	pose := newGltfPose(g, nodes)
+	frame := func() {
+		for at := range nodes {
+			m := pose.matrix(at)
+			m.Mult(ibm[at], m) // inverseBind * local
+			if parent := anm.Joints[at]; parent >= 0 {
+				m.Mult(m, bind[parent])
+			} else {
+				m.Mult(m, ancestors[at])
+			}
+			anm.Frames = append(anm.Frames, m)
+		}
+	}
+	for ac, a := range g.Animations {
+		name := a.Name
+		if name == "" {
+			name = "animation" + strconv.Itoa(ac)
+		}
+		duration := pose.duration(a)
+		fn := int(duration*gltfRate) + 1
+		anm.Movements = append(anm.Movements, Movement{Name: name,
+			F0: uint32(len(anm.Frames) / jointCnt), Fn: uint32(fn), Rate: gltfRate})
+		for fc := 0; fc < fn; fc++ {
+			pose.rest()
+			pose.sample(a, float64(fc)/gltfRate)
+			frame()
+		}
+	}
+	if len(anm.Movements) == 0 {
+		anm.Movements = append(anm.Movements, Movement{Name: "rest", F0: 0, Fn: 1, Rate: gltfRate})
+		pose.rest()
+		frame()
+	}
+	return anm
+}
+
+// restWorld returns the rest pose world transform of the given node.
+func (g *gltf) restWorld(node int) *lin.M4 {
+	world := lin.NewM4I()
+	loc, rot, scale := &lin.V3{}, &lin.Q{}, &lin.V3{}
+	for n := node; n >= 0; n = g.parents[n] {
+		g.local(n, loc, rot, scale)
+		world.Mult(world, trs(loc, rot, scale))
+	}
+	return world
+}
+
+// gltfPose tracks the local transforms for skin joints while sampling.
+type gltfPose struct {
+	g       *gltf
+	nodes   []int             // Joint nodes.
+	jointAt map[int]int       // Joint index for node.
+	locs    []lin.V3          // Joint translation.
+	rots    []lin.Q           // Joint rotation.
+	scales  []lin.V3          // Joint scale.
+	keys    map[int][]float32 // Cached accessor values.
+}
+
+// newGltfPose creates a pose for the given joint nodes.
+func newGltfPose(g *gltf, nodes []int) *gltfPose {
+	p := &gltfPose{g: g, nodes: nodes, jointAt: map[int]int{}}
+	p.locs, p.rots, p.scales = make([]lin.V3, len(nodes)), make([]lin.Q, len(nodes)), make([]lin.V3, len(nodes))
+	p.keys = map[int][]float32{}
+	for at, node := range nodes {
+		p.jointAt[node] = at
+	}
+	return p
+}
+
+// rest resets the joints to their rest pose.
+func (p *gltfPose) rest() {
+	for at, node := range p.nodes {
+		p.g.local(node, &p.locs[at], &p.rots[at], &p.scales[at])
+	}
+}
+
+// values returns the cached accessor values.
+func (p *gltfPose) values(accessor int) []float32 {
+	if v, ok := p.keys[accessor]; ok {
+		return v
+	}
+	v, _, _ := p.g.floats(accessor)
+	p.keys[accessor] = v
+	return v
+}
+
+// duration returns the last key frame time in the animation.
+func (p *gltfPose) duration(a gltfAnimation) (d float64) {
+	for _, s := range a.Samplers {
+		if times := p.values(s.Input); len(times) > 0 {
+			d = math.Max(d, float64(times[len(times)-1]))
+		}
+	}
+	return d
+}
+
+// sample applies the animation channels that affect joints at time t.
+func (p *gltfPose) sample(a gltfAnimation, t float64) {
+	for _, c := range a.Channels {
+		if c.Target.Node == nil || c.Sampler < 0 || c.Sampler >= len(a.Samplers) {
+			continue
+		}
+		at, ok := p.jointAt[*c.Target.Node]
+		if !ok {
+			continue // FUTURE: node animations.
+		}
+		s := a.Samplers[c.Sampler]
+		times, vals := p.values(s.Input), p.values(s.Output)
+		span := map[string]int{"translation": 3, "rotation": 4, "scale": 3}[c.Target.Path]
+		if span == 0 || len(times) == 0 {
+			continue
+		}
+		cubic := s.Interpolation == "CUBICSPLINE"
+		if (cubic && len(vals) < len(times)*span*3) || (!cubic && len(vals) < len(times)*span) {
+			continue // invalid sampler.
+		}
+		k0, k1, ratio := keyFrames(times, t)
+		if s.Interpolation == "STEP" {
+			k1, ratio = k0, 0
+		}
+		value := func(k, c int) float64 {
+			if cubic {
+				return float64(vals[(k*3+1)*span+c]) // skip the tangents.
+			}
+			return float64(vals[k*span+c])
+		}
+		v := [4]float64{}
+		for cnt := 0; cnt < span; cnt++ {
+			v[cnt] = lin.Lerp(value(k0, cnt), value(k1, cnt), ratio)
+		}
+		switch c.Target.Path {
+		case "translation":
+			p.locs[at].SetS(v[0], v[1], v[2])
+		case "scale":
+			p.scales[at].SetS(v[0], v[1], v[2])
+		case "rotation":
+			q0 := &lin.Q{X: value(k0, 0), Y: value(k0, 1), Z: value(k0, 2), W: value(k0, 3)}
+			q1 := &lin.Q{X: value(k1, 0), Y: value(k1, 1), Z: value(k1, 2), W: value(k1, 3)}
+			p.rots[at].Nlerp(q0, q1, ratio)
+		}
+	}
+}
+
+// matrix returns a new local transform matrix for the given joint.
+func (p *gltfPose) matrix(at int) *lin.M4 {
+	return trs(&p.locs[at], &p.rots[at], &p.scales[at])
+}
+
+// keyFrames returns the key frames on either side of time t
+// and the ratio of t between them.
+func keyFrames(times []float32, t float64) (k0, k1 int, ratio float64) {
+	last := len(times) - 1
+	switch {
+	case t <= float64(times[0]):
+		return 0, 0, 0
+	case t >= float64(times[last]):
+		return last, last, 0
+	}
+	k1 = sort.Search(len(times), func(i int) bool { return float64(times[i]) > t })
+	k0 = k1 - 1
+	t0, t1 := float64(times[k0]), float64(times[k1])
+	return k0, k1, (t - t0) / (t1 - t0)
+}
+
+// trs returns a new matrix that scales, rotates, and then translates.
+// The matrix layout matches the iqm joint transforms.
+func trs(loc *lin.V3, rot *lin.Q, scale *lin.V3) *lin.M4 {
+	q := (&lin.Q{}).Set(rot).Unit()
+	return (&lin.TRS{Loc: loc, Rot: q, Scale: scale}).M4(lin.NewM4())
+}
+
+// setM4 copies 16 column major floats into m.
+func setM4(m *lin.M4, f []float32) {
+	m.Xx, m.Xy, m.Xz, m.Xw = float64(f[0]), float64(f[1]), float64(f[2]), float64(f[3])
+	m.Yx, m.Yy, m.Yz, m.Yw = float64(f[4]), float64(f[5]), float64(f[6]), float64(f[7])
+	m.Zx, m.Zy, m.Zz, m.Zw = float64(f[8]), float64(f[9]), float64(f[10]), float64(f[11])
+	m.Wx, m.Wy, m.Wz, m.Ww = float64(f[12]), float64(f[13]), float64(f[14]), float64(f[15])
+}
@@ -0,0 +1,127 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// A skinned triangle with one joint that moves 1 unit along X over 1 second.
+func TestGltf(t *testing.T) {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})          // 0:36 positions
+	binary.Write(buf, binary.LittleEndian, []uint16{0, 1, 2, 0})                          // 36:6 indicies, padded.
+	binary.Write(buf, binary.LittleEndian, make([]byte, 12))                              // 44:12 joints
+	binary.Write(buf, binary.LittleEndian, []float32{1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0}) // 56:48 weights
+	binary.Write(buf, binary.LittleEndian, []float32{0, 1})                               // 104:8 times
+	binary.Write(buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0})                   // 112:24 translations
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	js := fmt.Sprintf(`{
+	  "asset": {"version": "2.0"},
+	  "scenes": [{"nodes": [0, 2]}],
+	  "nodes": [
+	    {"name": "root", "translation": [0, 2, 0], "children": [1]},
+	    {"name": "bone"},
+	    {"name": "tri", "mesh": 0, "skin": 0}
+	  ],
+	  "meshes": [{"name": "tri", "primitives": [{"attributes":
+	    {"POSITION": 0, "JOINTS_0": 2, "WEIGHTS_0": 3}, "indices": 1, "material": 0}]}],
+	  "materials": [{"pbrMetallicRoughness": {"baseColorFactor": [1, 0, 0, 0.5]}}],
+	  "skins": [{"joints": [1]}],
+	  "animations": [{"name": "slide",
+	    "channels": [{"sampler": 0, "target": {"node": 1, "path": "translation"}}],
+	    "samplers": [{"input": 4, "output": 5}]}],
+	  "accessors": [
+	    {"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+	    {"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"},
+	    {"bufferView": 2, "componentType": 5121, "count": 3, "type": "VEC4"},
+	    {"bufferView": 3, "componentType": 5126, "count": 3, "type": "VEC4"},
+	    {"bufferView": 4, "componentType": 5126, "count": 2, "type": "SCALAR"},
+	    {"bufferView": 5, "componentType": 5126, "count": 2, "type": "VEC3"}
+	  ],
+	  "bufferViews": [
+	    {"buffer": 0, "byteOffset": 0, "byteLength": 36},
+	    {"buffer": 0, "byteOffset": 36, "byteLength": 6},
+	    {"buffer": 0, "byteOffset": 44, "byteLength": 12},
+	    {"buffer": 0, "byteOffset": 56, "byteLength": 48},
+	    {"buffer": 0, "byteOffset": 104, "byteLength": 8},
+	    {"buffer": 0, "byteOffset": 112, "byteLength": 24}
+	  ],
+	  "buffers": [{"byteLength": 136, "uri": "%s"}]
+	}`, uri)
+	scn := &ScnData{Name: "test"}
+	if err := Gltf(bytes.NewBufferString(js), scn, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(scn.Nodes) != 3 || scn.Nodes[1].Parent != 0 || scn.Nodes[2].Parent != -1 {
+		t.Fatalf("Expected node hierarchy, got %+v", scn.Nodes)
+	}
+	if n := scn.Nodes[0]; !lin.Aeq(n.Loc.Y, 2) || !n.Rot.Aeq(lin.QI) {
+		t.Errorf("Expected node transform, got %+v", n)
+	}
+	if len(scn.Meshes) != 1 || len(scn.Nodes[2].Meshes) != 1 {
+		t.Fatalf("Expected one mesh, got %d", len(scn.Meshes))
+	}
+	m := scn.Meshes[0]
+	if len(m.V) != 9 || len(m.F) != 3 || m.F[2] != 2 || m.Weights[0] != 255 {
+		t.Errorf("Expected triangle data, got %v %v %v", m.V, m.F, m.Weights)
+	}
+	if m.KdR != 1 || m.KdG != 0 || m.Alpha != 0.5 {
+		t.Errorf("Expected red material, got %f %f %f", m.KdR, m.KdG, m.Alpha)
+	}
+	if m.Skin != 0 || len(m.Movements) != 1 || m.Movements[0].Fn != 31 || len(m.Frames) != 31 {
+		t.Fatalf("Expected one 31 frame movement, got %+v %d", m.Movements, len(m.Frames))
+	}
+	if len(m.Names) != 1 || m.Names[0] != "bone" {
+		t.Errorf("Expected named joint, got %v", m.Names)
+	}
+	if len(m.Binds) != 1 {
+		t.Errorf("Expected joint bind location, got %v", m.Binds)
+	}
+
+	// The joint includes its parent rest transform.
+	if f := m.Frames[30]; !lin.Aeq(f.Wx, 1) || !lin.Aeq(f.Wy, 2) {
+		t.Errorf("Expected last frame at 1,2,0, got %f %f %f", f.Wx, f.Wy, f.Wz)
+	}
+}
+
+// Corrupt files return errors instead of crashing.
+func TestGltfInvalid(t *testing.T) {
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(make([]byte, 36))
+	mesh := `"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+	  "buffers": [{"byteLength": 36, "uri": "` + uri + `"}],`
+	for name, js := range map[string]string{
+		"cycle":  `"scenes": [{"nodes": [0]}], "nodes": [{"children": [1]}, {"children": [0]}]`,
+		"self":   `"nodes": [{"children": [0]}]`,
+		"joint":  `"nodes": [{}], "skins": [{"joints": [-1]}]`,
+		"offset": mesh + `"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+		  "bufferViews": [{"buffer": 0, "byteOffset": -4, "byteLength": 36}]`,
+		"count": mesh + `"accessors": [{"bufferView": 0, "componentType": 5126, "count": -3, "type": "VEC3"}],
+		  "bufferViews": [{"buffer": 0, "byteLength": 36}]`,
+		"accessor": mesh + `"accessors": [{"bufferView": 0, "byteOffset": -12, "componentType": 5126, "count": 3, "type": "VEC3"}],
+		  "bufferViews": [{"buffer": 0, "byteLength": 36}]`,
+	} {
+		js = `{"asset": {"version": "2.0"}, ` + js + `}`
+		if err := Gltf(bytes.NewBufferString(js), &ScnData{Name: name}, nil); err == nil {
+			t.Errorf("Expected %s error", name)
+		}
+	}
+}
+
+// A trs matrix decomposes back into its parts.
+func TestDecompose(t *testing.T) {
+	q := lin.NewQ().SetAa(0, 1, 0, lin.Rad(120))
+	m := trs(&lin.V3{X: 1, Y: 2, Z: 3}, q, &lin.V3{X: 2, Y: 2, Z: 2})
+	loc, rot, scale := &lin.V3{}, &lin.Q{}, &lin.V3{}
+	m.Decompose(loc, rot, scale)
+	if !loc.Aeq(&lin.V3{X: 1, Y: 2, Z: 3}) || !rot.Aeq(q) || !scale.Aeq(&lin.V3{X: 2, Y: 2, Z: 2}) {
+		t.Errorf("Expected trs, got %v %v %v", loc, rot, scale)
+	}
+}
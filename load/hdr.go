@@ -0,0 +1,156 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strings"
+)
+
+// Hdr populates image data using the given reader. Radiance HDR files
+// store high dynamic range RGBE pixels, and are commonly used for sky
+// and environment maps. Flat and run length encoded scanlines are
+// supported. See the file format description at:
+//    http://www.graphics.cornell.edu/~bjw/rgbe.html
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import replaces the image in ImgData with a new FloatImage.
+func Hdr(r io.Reader, d *ImgData) error {
+	br := bufio.NewReader(r)
+	magic, err := br.ReadString('\n')
+	if err != nil || !strings.HasPrefix(magic, "#?") {
+		return fmt.Errorf("Invalid .hdr file")
+	}
+
+	// header lines end with a blank line.
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("Invalid .hdr header %s", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return fmt.Errorf("Unsupported .hdr %s", line)
+		}
+	}
+
+	// resolution line, ie: "-Y 512 +X 1024" for top to bottom rows.
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("Invalid .hdr resolution %s", err)
+	}
+	var ydir, xdir string
+	var w, h int
+	if _, err := fmt.Sscanf(line, "%s %d %s %d", &ydir, &h, &xdir, &w); err != nil {
+		return fmt.Errorf("Invalid .hdr resolution %s", err)
+	}
+	if (ydir != "-Y" && ydir != "+Y") || xdir != "+X" || w <= 0 || h <= 0 {
+		return fmt.Errorf("Unsupported .hdr resolution %s", strings.TrimSpace(line))
+	}
+	img := NewFloatImage(image.Rect(0, 0, w, h))
+	scan := make([]byte, w*4)
+	for row := 0; row < h; row++ {
+		if err := hdrScanline(br, scan); err != nil {
+			return fmt.Errorf("Invalid .hdr scanline %d %s", row, err)
+		}
+		y := row
+		if ydir == "+Y" {
+			y = h - 1 - row // bottom to top.
+		}
+		pix := img.Pix[y*img.Stride:]
+		for x := 0; x < w; x++ {
+			rgbe := scan[x*4 : x*4+4]
+			r, g, b := rgbeFloats(rgbe[0], rgbe[1], rgbe[2], rgbe[3])
+			pix[x*4], pix[x*4+1], pix[x*4+2], pix[x*4+3] = r, g, b, 1
+		}
+	}
+	d.Img = img
+	return nil
+}
+
+// =============================================================================
+// internal implementation for loading HDR files.
+
+// hdrScanline reads one scanline of RGBE pixels. Scanlines are either
+// new style run length encoded with separate channels, or flat pixels
+// that may contain old style repeat markers.
+func hdrScanline(br *bufio.Reader, scan []byte) error {
+	w := len(scan) / 4
+	start, err := br.Peek(4)
+	if err != nil {
+		return err
+	}
+	if w < 8 || w > 0x7fff || start[0] != 2 || start[1] != 2 || start[2]&0x80 != 0 {
+		return hdrFlat(br, scan)
+	}
+	if int(start[2])<<8|int(start[3]) != w {
+		return fmt.Errorf("scanline width mismatch")
+	}
+	br.Discard(4)
+	for ch := 0; ch < 4; ch++ {
+		for x := 0; x < w; {
+			count, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+			run, repeat := int(count), count > 128
+			if repeat {
+				run -= 128
+			}
+			if run == 0 || x+run > w {
+				return fmt.Errorf("bad run length")
+			}
+			var v byte
+			for cnt := 0; cnt < run; cnt++ {
+				if !repeat || cnt == 0 {
+					if v, err = br.ReadByte(); err != nil {
+						return err
+					}
+				}
+				scan[(x+cnt)*4+ch] = v
+			}
+			x += run
+		}
+	}
+	return nil
+}
+
+// hdrFlat reads uncompressed RGBE pixels where a 1,1,1,n pixel
+// repeats the previous pixel n times.
+func hdrFlat(br *bufio.Reader, scan []byte) error {
+	shift := uint(0)
+	for x := 0; x < len(scan)/4; {
+		p := scan[x*4 : x*4+4]
+		if _, err := io.ReadFull(br, p); err != nil {
+			return err
+		}
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 && x > 0 {
+			run := int(p[3]) << shift
+			for cnt := 0; cnt < run && x < len(scan)/4; cnt++ {
+				copy(scan[x*4:x*4+4], scan[(x-1)*4:x*4])
+				x++
+			}
+			shift += 8
+			continue
+		}
+		shift = 0
+		x++
+	}
+	return nil
+}
+
+// rgbeFloats converts a shared exponent RGBE pixel to float values.
+func rgbeFloats(r, g, b, e byte) (float32, float32, float32) {
+	if e == 0 {
+		return 0, 0, 0
+	}
+	f := float32(math.Ldexp(1, int(e)-(128+8)))
+	return float32(r) * f, float32(g) * f, float32(b) * f
+}
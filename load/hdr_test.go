@@ -0,0 +1,33 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Run length encoded scanlines decode to values above 1.
+func TestHdr(t *testing.T) {
+	buff := bytes.NewBufferString("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 8\n")
+	buff.Write([]byte{2, 2, 0, 8})
+	buff.Write([]byte{128 + 8, 128})                   // red: 8 repeated.
+	buff.Write([]byte{8, 0, 16, 32, 64, 128, 0, 0, 0}) // green: 8 literal.
+	buff.Write([]byte{128 + 8, 0})                     // blue.
+	buff.Write([]byte{128 + 8, 130})                   // exponent: 2^(130-128).
+	img := &ImgData{}
+	if err := Hdr(buff, img); err != nil {
+		t.Fatal(err)
+	}
+	f, ok := img.Img.(*FloatImage)
+	if !ok || f.Rect.Dx() != 8 || f.Rect.Dy() != 1 {
+		t.Fatalf("Expected 8x1 FloatImage, got %T", img.Img)
+	}
+	if r, g := f.Pix[0], f.Pix[5]; r != 2 || g != 0.25 {
+		t.Errorf("Expected 2 and 0.25, got %f %f", r, g)
+	}
+	if err := Hdr(bytes.NewBufferString("not hdr"), img); err == nil {
+		t.Error("Expected error for invalid file")
+	}
+}
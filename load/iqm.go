@@ -197,15 +197,13 @@ func loadIqmAnims(hdr *iqmheader, data []byte, mod *ModData, scr *scratch) (err
 		return fmt.Errorf("Invalid .iqm file: %s", err)
 	}
 	mod.Joints = make([]int32, hdr.NumJoints)
+	mod.Names = make([]string, hdr.NumJoints)
 
 	// process the joint base transforms using an intermediate form.
 	basePoses := []*transform{}
 	for cnt, j := range jnts {
 		mod.Joints[cnt] = j.Parent // save the joint parent data
-
-		// FUTURE: use joint names as attachment points where the convention
-		//         is to have "attachment" in the joint name, and not have
-		//         the joint affect any verticies.
+		mod.Names[cnt] = scr.labels[j.Name]
 
 		// put the pose data into a transform ready structure.
 		t := &lin.V3{X: float64(j.Translate[0]), Y: float64(j.Translate[1]), Z: float64(j.Translate[2])}
@@ -214,6 +212,10 @@ func loadIqmAnims(hdr *iqmheader, data []byte, mod *ModData, scr *scratch) (err
 		basePoses = append(basePoses, &transform{t, r, s})
 	}
 	createBaseFrames(mod, basePoses, scr)
+	mod.Binds = make([]lin.V3, len(scr.baseframe))
+	for cnt, m := range scr.baseframe {
+		mod.Binds[cnt].SetS(m.Wx, m.Wy, m.Wz) // model space joint locations.
+	}
 
 	// Get the per frame pose data.
 	buff.Seek(int64(hdr.OfsPoses-iqmheaderSize), 0)
@@ -14,4 +14,7 @@ func TestLoadIqm(t *testing.T) {
 	if err != nil || len(m.V) <= 0 {
 		t.Error(err)
 	}
+	if len(m.Names) != len(m.Joints) || len(m.Names) == 0 || m.Names[0] == "" {
+		t.Errorf("Expected named joints, got %v", m.Names)
+	}
 }
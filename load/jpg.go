@@ -0,0 +1,36 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+)
+
+// Jpg populates image data using the given reader. The decoded image
+// is converted to NRGBA so that it can be bound to a GPU texture.
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import replaces the image in ImgData with a new image.
+func Jpg(r io.Reader, d *ImgData) error {
+	img, err := jpeg.Decode(r)
+	if err != nil {
+		return err
+	}
+	d.Img = toNRGBA(img)
+	return nil
+}
+
+// toNRGBA converts decoded images, like the YCbCr images from jpeg,
+// to NRGBA. RGBA and NRGBA images are returned unchanged.
+func toNRGBA(img image.Image) image.Image {
+	switch img.(type) {
+	case *image.RGBA, *image.NRGBA:
+		return img
+	}
+	nrgba := image.NewNRGBA(img.Bounds())
+	draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return nrgba
+}
@@ -0,0 +1,172 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// Ktx2 populates image data using the given reader. KTX2 files hold
+// GPU ready textures. Uncompressed 8 bit RGBA images become NRGBA images.
+// Uncompressed 16 and 32 bit float RGBA images become FloatImages. The
+// BC1, BC3, BC7, and ETC2 block compressed formats become CompressedImages
+// that include all the mipmap levels. Supercompressed files, ie: Basis
+// Universal, are not supported. See the specification at:
+//    https://github.khronos.org/KTX-Specification/
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import replaces the image in ImgData with a new image.
+func Ktx2(r io.Reader, d *ImgData) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("Invalid .ktx2 file %s", err)
+	}
+	hdr := ktx2Header{}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &hdr); err != nil {
+		return fmt.Errorf("Invalid .ktx2 header %s", err)
+	}
+	if hdr.Identifier != ktx2Identifier {
+		return fmt.Errorf("Invalid .ktx2 identifier")
+	}
+	switch {
+	case hdr.Supercompression != 0:
+		return fmt.Errorf("Unsupported .ktx2 supercompression %d", hdr.Supercompression)
+	case hdr.Depth > 1 || hdr.Layers > 1 || hdr.Faces != 1 || hdr.Height == 0:
+		return fmt.Errorf("Unsupported .ktx2 image shape")
+	}
+
+	// the level index follows the header.
+	nlevels := int(hdr.Levels)
+	if nlevels == 0 {
+		nlevels = 1 // mipmaps expected to be generated.
+	}
+	if binary.Size(hdr)+nlevels*24 > len(data) {
+		return fmt.Errorf("Invalid .ktx2 level index size %d", nlevels)
+	}
+	levels := make([][]byte, nlevels)
+	index := bytes.NewReader(data[binary.Size(hdr):])
+	for cnt := range levels {
+		var lvl [3]uint64 // offset, length, uncompressed length.
+		if err := binary.Read(index, binary.LittleEndian, &lvl); err != nil {
+			return fmt.Errorf("Invalid .ktx2 level index %s", err)
+		}
+		if lvl[0] > uint64(len(data)) || lvl[1] > uint64(len(data))-lvl[0] {
+			return fmt.Errorf("Invalid .ktx2 level %d", cnt)
+		}
+		levels[cnt] = data[lvl[0] : lvl[0]+lvl[1]]
+	}
+	w, h := int(hdr.Width), int(hdr.Height)
+	rect := image.Rect(0, 0, w, h)
+	if glFormat, ok := ktx2Compressed[hdr.Format]; ok {
+		d.Img = &CompressedImage{Format: glFormat, Levels: levels, Rect: rect}
+		return nil
+	}
+
+	// uncompressed images only need the largest level.
+	pix := levels[0]
+	switch hdr.Format {
+	case vkR8G8B8A8Unorm, vkR8G8B8A8Srgb:
+		if len(pix) < w*h*4 {
+			return fmt.Errorf("Invalid .ktx2 image size")
+		}
+		img := image.NewNRGBA(rect)
+		copy(img.Pix, pix)
+		d.Img = img
+	case vkR16G16B16A16Sfloat:
+		if len(pix) < w*h*8 {
+			return fmt.Errorf("Invalid .ktx2 image size")
+		}
+		img := NewFloatImage(rect)
+		for cnt := range img.Pix {
+			img.Pix[cnt] = halfFloat(binary.LittleEndian.Uint16(pix[cnt*2:]))
+		}
+		d.Img = img
+	case vkR32G32B32A32Sfloat:
+		if len(pix) < w*h*16 {
+			return fmt.Errorf("Invalid .ktx2 image size")
+		}
+		img := NewFloatImage(rect)
+		for cnt := range img.Pix {
+			img.Pix[cnt] = math.Float32frombits(binary.LittleEndian.Uint32(pix[cnt*4:]))
+		}
+		d.Img = img
+	default:
+		return fmt.Errorf("Unsupported .ktx2 format %d", hdr.Format)
+	}
+	return nil
+}
+
+// =============================================================================
+// internal implementation for loading KTX2 files.
+
+// ktx2Header is the fixed size start of a KTX2 file.
+type ktx2Header struct {
+	Identifier       [12]byte // ktx2Identifier.
+	Format           uint32   // Vulkan format.
+	TypeSize         uint32   // Size of the data type in bytes.
+	Width, Height    uint32   // Image size in pixels.
+	Depth            uint32   // 0 for 2D images.
+	Layers           uint32   // 0 for non-array textures.
+	Faces            uint32   // 6 for cube maps, otherwise 1.
+	Levels           uint32   // Mipmap levels. 0 to generate mipmaps.
+	Supercompression uint32   // 0 for none.
+	DfdOffset        uint32   // Data format descriptor.
+	DfdLength        uint32   // ...
+	KvdOffset        uint32   // Key value data.
+	KvdLength        uint32   // ...
+	SgdOffset        uint64   // Supercompression global data.
+	SgdLength        uint64   // ...
+}
+
+// ktx2Identifier starts every KTX2 file.
+var ktx2Identifier = [12]byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+// Supported uncompressed Vulkan formats.
+const (
+	vkR8G8B8A8Unorm      = 37
+	vkR8G8B8A8Srgb       = 43
+	vkR16G16B16A16Sfloat = 97
+	vkR32G32B32A32Sfloat = 109
+)
+
+// ktx2Compressed maps the supported Vulkan block compressed
+// formats to OpenGL compressed internal formats.
+var ktx2Compressed = map[uint32]uint32{
+	131: 0x83F0, // BC1_RGB_UNORM    : COMPRESSED_RGB_S3TC_DXT1
+	132: 0x8C4C, // BC1_RGB_SRGB     : COMPRESSED_SRGB_S3TC_DXT1
+	133: 0x83F1, // BC1_RGBA_UNORM   : COMPRESSED_RGBA_S3TC_DXT1
+	134: 0x8C4D, // BC1_RGBA_SRGB    : COMPRESSED_SRGB_ALPHA_S3TC_DXT1
+	137: 0x83F3, // BC3_UNORM        : COMPRESSED_RGBA_S3TC_DXT5
+	138: 0x8C4F, // BC3_SRGB         : COMPRESSED_SRGB_ALPHA_S3TC_DXT5
+	145: 0x8E8C, // BC7_UNORM        : COMPRESSED_RGBA_BPTC_UNORM
+	146: 0x8E8D, // BC7_SRGB         : COMPRESSED_SRGB_ALPHA_BPTC_UNORM
+	147: 0x9274, // ETC2_R8G8B8_UNORM: COMPRESSED_RGB8_ETC2
+	148: 0x9275, // ETC2_R8G8B8_SRGB : COMPRESSED_SRGB8_ETC2
+	151: 0x9278, // ETC2_RGBA8_UNORM : COMPRESSED_RGBA8_ETC2_EAC
+	152: 0x9279, // ETC2_RGBA8_SRGB  : COMPRESSED_SRGB8_ALPHA8_ETC2_EAC
+}
+
+// halfFloat converts a 16 bit IEEE half precision float.
+func halfFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp, mant := uint32(h>>10)&0x1F, uint32(h)&0x3FF
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign) // zero.
+	case exp == 0: // subnormal.
+		f := float32(mant) / 1024 / 16384
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case exp == 0x1F: // infinity or NaN.
+		return math.Float32frombits(sign | 0x7F800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+}
@@ -0,0 +1,56 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+// ktx2File creates a single level 2D KTX2 file.
+func ktx2File(format, w, h uint32, pix []byte) []byte {
+	hdr := ktx2Header{Identifier: ktx2Identifier, Format: format, Width: w, Height: h, Faces: 1, Levels: 1}
+	buff := &bytes.Buffer{}
+	binary.Write(buff, binary.LittleEndian, hdr)
+	offset := uint64(binary.Size(hdr) + 24)
+	binary.Write(buff, binary.LittleEndian, []uint64{offset, uint64(len(pix)), uint64(len(pix))})
+	buff.Write(pix)
+	return buff.Bytes()
+}
+
+// Uncompressed, float, and block compressed images are imported.
+func TestKtx2(t *testing.T) {
+	img := &ImgData{}
+	rgba := []byte{1, 2, 3, 4}
+	if err := Ktx2(bytes.NewReader(ktx2File(vkR8G8B8A8Unorm, 1, 1, rgba)), img); err != nil {
+		t.Fatal(err)
+	}
+	if nrgba, ok := img.Img.(*image.NRGBA); !ok || nrgba.Pix[3] != 4 {
+		t.Errorf("Expected NRGBA image, got %T", img.Img)
+	}
+	half := []byte{0x00, 0x3C, 0x00, 0x40, 0x00, 0xB8, 0x00, 0x00} // 1, 2, -0.5, 0
+	if err := Ktx2(bytes.NewReader(ktx2File(vkR16G16B16A16Sfloat, 1, 1, half)), img); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := img.Img.(*FloatImage); !ok || f.Pix[0] != 1 || f.Pix[1] != 2 || f.Pix[2] != -0.5 {
+		t.Errorf("Expected float image, got %v", img.Img)
+	}
+	bc1 := make([]byte, 8) // one 4x4 block.
+	if err := Ktx2(bytes.NewReader(ktx2File(133, 4, 4, bc1)), img); err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := img.Img.(*CompressedImage); !ok || c.Format != 0x83F1 || len(c.Levels) != 1 {
+		t.Errorf("Expected compressed image, got %T", img.Img)
+	}
+	if err := Ktx2(bytes.NewReader(ktx2File(1, 1, 1, rgba)), img); err == nil {
+		t.Error("Expected unsupported format error")
+	}
+	many := ktx2File(vkR8G8B8A8Unorm, 1, 1, rgba)
+	binary.LittleEndian.PutUint32(many[40:], 0xFFFFFFFF) // levels.
+	if err := Ktx2(bytes.NewReader(many), img); err == nil {
+		t.Error("Expected level index size error")
+	}
+}
@@ -4,20 +4,30 @@
 // Package load fetches disk based 3D assets. Assets are loaded into
 // one of the following intermediate data structures:
 //    FntData.Load uses Fnt to load bitmapped characters.
-//    ImgData.Load uses Png to load model textures.
+//    FntData.LoadSdf uses Ttf, SdfFont, or Sdf to generate distance fields.
+//    ImgData.Load uses Png, Jpg, Tga, Hdr, or Ktx2 to load model textures.
+//    TexData.Process uses Tex to resize, pack, and mipmap textures.
+//    CubeData.Load uses ImgData and Equirect to load cube map textures.
 //    ModData.Load uses Iqm to load animated models.
 //    MshData.Load uses Obj to load static models.
 //    MtlData.Load uses Mtl to load model lighting data.
+//    MatData.Load uses Mat to load model materials.
+//    ScnData.Load uses Gltf or ObjScene to load model hierarchies.
 //    ShdData.Load uses Src to load GPU shader programs.
-//    SndData.Load uses Wav to load 3D audio.
+//    SndData.Load uses Wav, Ogg, or Flac to load 3D audio.
+//    OpenSnd streams Wav, Ogg, or Flac audio for long sounds like music.
+//    BankData.Load uses Bank to load sound playback settings.
 // Each intermediate data format is currently associated with one file
 // format. Asset loading is currently intended for smaller 3D applications
 // where data is loaded directly from disk to memory, i.e. no database.
 //
 // A default file Locator is provided. It can be replaced with
 // a different Locator that follows a different string based naming
-// convention for finding disk based assets. Overall package load
-// attempts to shield users from knowledge about:
+// convention for finding disk based assets. Shipped applications can
+// Mount pack files, or MountFS embedded file systems, instead of
+// providing asset directories. Each file format function, ie: Obj,
+// reads from an io.Reader for assets that are streamed from elsewhere.
+// Overall package load attempts to shield users from knowledge about:
 //    File Formats  : how asset contents are stored on disk.
 //    File Types    : how file types map to asset data structs.
 //    File Locations: where asset files are stored on disk.
@@ -28,7 +38,11 @@ package load
 import (
 	"fmt"
 	"image"
+	"image/color"
 	"io"
+	"io/ioutil"
+	"path"
+	"strings"
 
 	"github.com/gazed/vu/math/lin"
 )
@@ -76,6 +90,44 @@ func (d *FntData) Load(name string, l Locator) (err error) {
 	return Fnt(reader, d)
 }
 
+// LoadSdf generates a signed distance field font. Existing FntData is
+// overwritten and the generated texture atlas replaces the image in img.
+// A TrueType name.ttf font is rendered directly from its outlines when
+// available, see SdfFont. Otherwise the name.fnt bitmapped font texture
+// image is converted, see Sdf.
+func (d *FntData) LoadSdf(name string, l Locator, img *ImgData) error {
+	if reader, err := l.GetResource(name + ".ttf"); err == nil {
+		defer reader.Close()
+		ttf := &TtfData{}
+		if err := Ttf(reader, ttf); err != nil {
+			return fmt.Errorf("Could not load font %s.ttf: %s", name, err)
+		}
+		chars := []rune{}
+		for r := rune(32); r < 256; r++ {
+			if r < 127 || r >= 160 { // printable latin characters.
+				chars = append(chars, r)
+			}
+		}
+		SdfFont(ttf, chars, sdfSize, sdfSpread, d, img)
+		return nil
+	}
+	if err := d.Load(name, l); err != nil {
+		return err
+	}
+	if err := img.Load(name, l); err != nil {
+		return err
+	}
+	img.Img = Sdf(img.Img, sdfBitmapSpread)
+	return nil
+}
+
+// Distance field font generation values in pixels.
+const (
+	sdfSize         = 48 // TrueType em size.
+	sdfSpread       = 6  // TrueType outline distance range.
+	sdfBitmapSpread = 2  // Limited by the space between bitmap characters.
+)
+
 // FntData
 // =============================================================================
 // ImgData
@@ -87,7 +139,8 @@ func (d *FntData) Load(name string, l Locator) (err error) {
 //    rgba, _ := img.(*image.(N)RGBA)
 // Note that golang NRGBA are images with an alpha channel, but without alpha
 // pre-multiplication. RGBA are images originally without an alpha channel,
-// but assigned an alpha of 1 when read in.
+// but assigned an alpha of 1 when read in. High dynamic range images are
+// FloatImage and GPU compressed images are CompressedImage.
 //
 // This is an intermediate data format that needs further processing by
 // something like vu/Model to bind the data to a GPU based texture.
@@ -95,20 +148,211 @@ type ImgData struct {
 	Img image.Image
 }
 
-// Load image data. Existing ImgData is discarded and
-// replaced with information found by the Locator.
+// Load image data. Existing ImgData is discarded and replaced with
+// information found by the Locator. The image file extensions are
+// tried in the order: .png, .jpg, .tga, .hdr, .ktx2, unless the name
+// includes one of the extensions.
+// Images with a .tex texture import file are processed after loading,
+// see Tex.
 func (d *ImgData) Load(name string, l Locator) (err error) {
-	fname := name + ".png" // FUTURE: other image file formats.
-	var reader io.ReadCloser
-	if reader, err = l.GetResource(fname); err != nil {
-		return fmt.Errorf("Could not load image from %s: %s\n", fname, err)
+	if _, ok := imgDecoders[path.Ext(name)]; !ok {
+		var reader io.ReadCloser
+		if reader, err = l.GetResource(name + ".tex"); err == nil {
+			defer reader.Close()
+			td := &TexData{}
+			if err = Tex(reader, td); err != nil {
+				return fmt.Errorf("Could not load image from %s: %s\n", name, err)
+			}
+			return td.Process(name, l, d)
+		}
 	}
-	defer reader.Close()
-	return Png(reader, d)
+	return d.load(name, l)
+}
+
+// load image data without import processing.
+func (d *ImgData) load(name string, l Locator) (err error) {
+	fnames := []string{}
+	for _, ext := range imgExts {
+		fnames = append(fnames, name+ext)
+	}
+	if _, ok := imgDecoders[path.Ext(name)]; ok {
+		fnames = []string{name}
+	}
+	for _, fname := range fnames {
+		var reader io.ReadCloser
+		if reader, err = l.GetResource(fname); err == nil {
+			defer reader.Close()
+			return imgDecoders[path.Ext(fname)](reader, d)
+		}
+	}
+	return fmt.Errorf("Could not load image from %s: %s\n", name, err)
+}
+
+// imgExts are the supported image file extensions in search order.
+var imgExts = []string{".png", ".jpg", ".tga", ".hdr", ".ktx2"}
+
+// imgDecoders are the image file format functions by file extension.
+var imgDecoders = map[string]func(r io.Reader, d *ImgData) error{
+	".png":  Png,
+	".jpg":  Jpg,
+	".tga":  Tga,
+	".hdr":  Hdr,
+	".ktx2": Ktx2,
+}
+
+// FloatImage is a high dynamic range image with R,G,B,A float values
+// that are not limited to the 0 to 1 range, ie: environment maps.
+// It is intended for binding to a floating point GPU texture.
+// The image.Image color values are clamped to the 0 to 1 range.
+type FloatImage struct {
+	Pix    []float32       // R,G,B,A values starting at the top left.
+	Stride int             // Pix values between vertically adjacent pixels.
+	Rect   image.Rectangle // Image bounds.
+}
+
+// NewFloatImage returns a FloatImage with the given bounds.
+func NewFloatImage(r image.Rectangle) *FloatImage {
+	return &FloatImage{Pix: make([]float32, 4*r.Dx()*r.Dy()), Stride: 4 * r.Dx(), Rect: r}
+}
+
+// ColorModel is the image.Image color model.
+func (f *FloatImage) ColorModel() color.Model { return color.NRGBA64Model }
+
+// Bounds is the image.Image bounds.
+func (f *FloatImage) Bounds() image.Rectangle { return f.Rect }
+
+// At returns the image.Image clamped color at the given pixel.
+func (f *FloatImage) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(f.Rect)) {
+		return color.NRGBA64{}
+	}
+	i := (y-f.Rect.Min.Y)*f.Stride + (x-f.Rect.Min.X)*4
+	c := [4]uint16{}
+	for cnt := range c {
+		c[cnt] = uint16(lin.Clamp(float64(f.Pix[i+cnt]), 0, 1)*0xffff + 0.5)
+	}
+	return color.NRGBA64{R: c[0], G: c[1], B: c[2], A: c[3]}
+}
+
+// FloatPix returns the unclamped R,G,B,A values.
+func (f *FloatImage) FloatPix() []float32 { return f.Pix }
+
+// CompressedImage holds GPU compressed image blocks for one or more
+// mipmap levels. It is intended for binding directly to a GPU texture
+// without being decoded. The image.Image colors are not decoded and
+// are always transparent black.
+type CompressedImage struct {
+	Format uint32          // OpenGL compressed internal format.
+	Levels [][]byte        // Mipmap levels from largest to smallest.
+	Rect   image.Rectangle // Image bounds of the largest level.
+}
+
+// ColorModel is the image.Image color model.
+func (c *CompressedImage) ColorModel() color.Model { return color.NRGBAModel }
+
+// Bounds is the image.Image bounds.
+func (c *CompressedImage) Bounds() image.Rectangle { return c.Rect }
+
+// At returns transparent black since the blocks are not decoded.
+func (c *CompressedImage) At(x, y int) color.Color { return color.NRGBA{} }
+
+// Compressed returns the compressed format and mipmap levels.
+func (c *CompressedImage) Compressed() (format uint32, levels [][]byte) {
+	return c.Format, c.Levels
+}
+
+// MipImage holds an image along with its smaller mipmap levels.
+// It is intended for binding to a GPU texture without generating
+// the mipmaps. The image.Image colors are from the largest level.
+type MipImage struct {
+	Levels []image.Image // Mipmap levels from largest to smallest.
+}
+
+// ColorModel is the image.Image color model.
+func (m *MipImage) ColorModel() color.Model { return m.Levels[0].ColorModel() }
+
+// Bounds is the image.Image bounds.
+func (m *MipImage) Bounds() image.Rectangle { return m.Levels[0].Bounds() }
+
+// At returns the color of the largest level at the given pixel.
+func (m *MipImage) At(x, y int) color.Color { return m.Levels[0].At(x, y) }
+
+// Mipmaps returns the mipmap levels.
+func (m *MipImage) Mipmaps() []image.Image { return m.Levels }
+
+// TexData holds the optional import processing settings for an image.
+// See Tex.
+type TexData struct {
+	Pow2    bool      // Resize to the nearest power of two.
+	MaxSize int       // Limit image sides to MaxSize pixels. 0 for no limit.
+	Mipmaps bool      // Generate mipmaps on import.
+	Pack    []TexPack // Channels copied from other images.
+}
+
+// TexPack copies one channel of an image into a channel of the
+// processed image. Channels are 0:red, 1:green, 2:blue, 3:alpha.
+type TexPack struct {
+	File string // Source image name.
+	Src  int    // Source image channel.
+	Dst  int    // Processed image channel.
 }
 
 // FntData
 // =============================================================================
+// CubeData
+
+// CubeData holds the six square faces of a cube map texture in the order
+// +X, -X, +Y, -Y, +Z, -Z. Cube maps are sampled using a direction instead
+// of texture coordinates, ie: skyboxes and environment reflections.
+//
+// This is an intermediate data format that needs further processing by
+// something like vu/Model to bind the data to a GPU based cube texture.
+type CubeData struct {
+	Faces [6]image.Image
+}
+
+// Load cube map data. Existing CubeData is discarded and replaced with
+// information found by the Locator. Six face images with the name suffixes
+// _px, _nx, _py, _ny, _pz, _nz are tried first. Otherwise the name is
+// loaded as a single equirectangular panorama image and converted to
+// cube faces, see Equirect. The face images are loaded using ImgData.
+func (d *CubeData) Load(name string, l Locator) error {
+	faces := [6]image.Image{}
+	for cnt, suffix := range cubeSuffixes {
+		img := &ImgData{}
+		if err := img.Load(name+suffix, l); err != nil {
+			if cnt > 0 {
+				return fmt.Errorf("Missing cube face %s%s: %s", name, suffix, err)
+			}
+			break // no face images. Try for a panorama.
+		}
+		faces[cnt] = img.Img
+	}
+	if faces[0] == nil {
+		img := &ImgData{}
+		if err := img.Load(name, l); err != nil {
+			return fmt.Errorf("Could not load cube map from %s: %s", name, err)
+		}
+		if _, ok := img.Img.(*CompressedImage); ok {
+			return fmt.Errorf("Compressed panorama %s not supported", name)
+		}
+		faces = Equirect(img.Img, 0)
+	}
+	size := faces[0].Bounds().Size()
+	for _, face := range faces {
+		if fs := face.Bounds().Size(); fs.X != fs.Y || fs != size {
+			return fmt.Errorf("Cube map %s faces must be square and the same size", name)
+		}
+	}
+	d.Faces = faces
+	return nil
+}
+
+// cubeSuffixes are the file name endings for cube faces in face order.
+var cubeSuffixes = []string{"_px", "_nx", "_py", "_ny", "_pz", "_nz"}
+
+// CubeData
+// =============================================================================
 // ModData
 
 // ModData combines vertex data, animation data and some texture
@@ -129,7 +373,9 @@ type AnmData struct {
 	Blends    []byte     // Vertex blend indicies. Arranged as [][4]byte
 	Weights   []byte     // Vertex blend weights.  Arranged as [][4]byte
 	Joints    []int32    // Joint parent information for each joint.
+	Names     []string   // Joint names for each joint. Optional.
 	Frames    []*lin.M4  // Animation transforms: [NumFrames][NumJoints].
+	Binds     []lin.V3   // Bind pose joint locations in model space. Optional.
 }
 
 // Movement marks a number of frames as a particular animated move that
@@ -167,6 +413,9 @@ func (d *ModData) Load(name string, l Locator) (err error) {
 // MshData stores vertex data from .obj files.
 // It is intended for populating rendered models.
 // The V,F buffers are expected to have data. The N,T,X buffers are optional.
+// Missing normals and tangents can be created with GenNormals and
+// GenTangents. Imported meshes generate them when needed and are
+// then optimized for rendering, see Optimize and Simplify.
 //
 // MshData is an intermediate data format that needs further processing
 // by something like vu/Model to bind the data to a GPU.
@@ -175,7 +424,7 @@ type MshData struct {
 	V    []float32 // Vertex positions.    Arranged as [][3]float32
 	N    []float32 // Vertex normals.      Arranged as [][3]float32
 	T    []float32 // Texture coordinates. Arranged as [][2]float32
-	X    []float32 // Vertex tangents.     Arranged as [][2]float32
+	X    []float32 // Vertex tangents.     Arranged as [][4]float32
 	F    []uint16  // Triangle faces.      Arranged as [][3]uint16
 }
 
@@ -207,6 +456,8 @@ type MtlData struct {
 	KsR, KsG, KsB float32 // Specular color.
 	Ns            float32 // Specular exponent.
 	Alpha         float32 // Transparency
+	MapKd         string  // Diffuse texture name. Optional.
+	MapKs         string  // Specular texture name. Optional.
 }
 
 // Load model lighting material data. Existing MtlData is
@@ -221,7 +472,113 @@ func (d *MtlData) Load(name string, l Locator) (err error) {
 	return Mtl(reader, d)
 }
 
-// MshData
+// MtlData
+// =============================================================================
+// MatData
+
+// MatData is a material that describes how a model is rendered. It extends
+// the MtlData lighting colors with the shader, physically based rendering
+// values, per channel textures, blending, and custom shader uniforms.
+//
+// MatData is an intermediate data format that needs further processing
+// by something like vu/Pov.LoadMat to set up a rendered model.
+type MatData struct {
+	MtlData                        // Lighting colors for non PBR shaders.
+	Shader    string               // Shader name. Optional.
+	Color     [4]float32           // Base color RGBA. Default white.
+	Metallic  float32              // 0 for dielectric, 1 for metal.
+	Roughness float32              // 0 for smooth, 1 for rough. Default 1.
+	Emissive  [3]float32           // Emitted light color.
+	Textures  []MatTex             // Textures in shader sampler order.
+	Blend     string               // One of opaque, alpha, add, cutout.
+	Cutoff    float32              // Alpha cutoff for cutout blending.
+	TwoSided  bool                 // True to render back faces.
+	Uniforms  map[string][]float32 // Custom shader uniform values.
+}
+
+// MatTex is a texture for one material channel, ie: "albedo", "normal".
+type MatTex struct {
+	Channel string // Material channel.
+	Name    string // Texture name.
+}
+
+// Load a material. Existing MatData is overwritten
+// with information found by the Locator.
+func (d *MatData) Load(name string, l Locator) (err error) {
+	fname := name + ".mat"
+	var reader io.ReadCloser
+	if reader, err = l.GetResource(fname); err != nil {
+		return fmt.Errorf("could not open %s %s", fname, err)
+	}
+	defer reader.Close()
+	return Mat(reader, d)
+}
+
+// MatData
+// =============================================================================
+// ScnData
+
+// ScnData holds a scene hierarchy of nodes along with the meshes, materials,
+// skin animations, and embedded images referenced by the nodes. It is
+// intended for populating a hierarchy of rendered models.
+//
+// ScnData is an intermediate data format that needs further processing
+// by something like vu/Pov to create the node hierarchy and bind the
+// mesh and image data to a GPU.
+type ScnData struct {
+	Name   string              // Imported scene name.
+	Nodes  []ScnNode           // Scene hierarchy. Parents precede children.
+	Meshes []ScnMesh           // Meshes referenced by the nodes.
+	Images map[string]*ImgData // Embedded images by texture name.
+}
+
+// ScnNode is one element of the scene hierarchy. The node transform is
+// relative to its parent. Expected to be used as part of ScnData.
+type ScnNode struct {
+	Name   string // Node name. Optional.
+	Parent int    // Index of the parent node. -1 for root nodes.
+	Loc    lin.V3 // Location relative to the parent.
+	Rot    lin.Q  // Orientation relative to the parent.
+	Scale  lin.V3 // Scale.
+	Meshes []int  // Indicies into ScnData.Meshes. Optional.
+}
+
+// ScnMesh combines vertex data, optional skin animation data, texture
+// names, and lighting colors for one model. Expected to be used as
+// part of ScnData. Texture names are either ScnData.Images or
+// separately loaded textures.
+type ScnMesh struct {
+	ModData     // Vertex, animation, and texture data.
+	MtlData     // Lighting colors.
+	Skin    int // Skin index for animated meshes. -1 for static meshes.
+}
+
+// Load a scene hierarchy. Existing ScnData is overwritten with information
+// found by the Locator. The name may include the .gltf, .glb, .obj, or
+// .dae extension.
+func (d *ScnData) Load(name string, l Locator) (err error) {
+	fnames := []string{name + ".gltf", name + ".glb", name + ".obj", name + ".dae"}
+	if ext := path.Ext(name); ext == ".gltf" || ext == ".glb" || ext == ".obj" || ext == ".dae" {
+		fnames, name = []string{name}, strings.TrimSuffix(name, ext)
+	}
+	for _, fname := range fnames {
+		var reader io.ReadCloser
+		if reader, err = l.GetResource(fname); err == nil {
+			defer reader.Close()
+			*d = ScnData{Name: path.Base(name)}
+			switch path.Ext(fname) {
+			case ".obj":
+				return ObjScene(reader, d, l)
+			case ".dae":
+				return Dae(reader, d)
+			}
+			return Gltf(reader, d, l)
+		}
+	}
+	return fmt.Errorf("Could not load scene from %s: %s\n", name, err)
+}
+
+// ScnData
 // =============================================================================
 // ShdData
 
@@ -284,14 +641,200 @@ type SndAttributes struct {
 	SampleBits uint16 // 8 bits = 8, 16 bits = 16, etc.
 }
 
-// Load sound data. Existing SoundData is
-// overwritten with information found by the Locator.
+// Load sound data. Existing SoundData is overwritten with information
+// found by the Locator. The sound file extensions are tried in the
+// order: .wav, .ogg, .flac, unless the name includes one of the extensions.
 func (d *SndData) Load(name string, l Locator) (err error) {
-	fname := name + ".wav" // FUTURE: other 3D audio file formats.
+	for _, fname := range sndFiles(name) {
+		var reader io.ReadCloser
+		if reader, err = l.GetResource(fname); err == nil {
+			defer reader.Close()
+			return sndDecoders[path.Ext(fname)](reader, d)
+		}
+	}
+	return fmt.Errorf("Load sound error %s: %s\n", name, err)
+}
+
+// SndStream decodes sound data a piece at a time as it is read. Streams
+// are used for long sounds, like music tracks, that would use too much
+// memory if they were fully loaded. Read returns the same sound data
+// bytes as SndData.Load, ending with io.EOF.
+type SndStream struct {
+	Attrs *SndAttributes // Attributes describing the sound data.
+
+	next func() ([]byte, error)     // decodes the next piece of sound data.
+	pcm  []byte                     // decoded sound data that has not been read.
+	file io.Closer                  // sound file. Closed with the stream.
+	open func() (*SndStream, error) // restarts decoding for Seek.
+}
+
+// OpenSnd opens the named sound for streaming. The sound file extensions
+// are tried in the same order as SndData.Load. Attrs.DataSize is 0 since
+// the size is not known until the whole sound is decoded. The caller is
+// expected to Close the stream.
+func OpenSnd(name string, l Locator) (s *SndStream, err error) {
+	for _, fname := range sndFiles(name) {
+		var reader io.ReadCloser
+		if reader, err = l.GetResource(fname); err == nil {
+			if s, err = sndStream(fname, reader); err != nil {
+				return nil, err
+			}
+			s.open = func() (*SndStream, error) {
+				reader, err := l.GetResource(fname)
+				if err != nil {
+					return nil, err
+				}
+				return sndStream(fname, reader)
+			}
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("Open sound error %s: %s\n", name, err)
+}
+
+// sndStream starts decoding the given sound file. The file
+// is closed if it cannot be decoded.
+func sndStream(fname string, reader io.ReadCloser) (*SndStream, error) {
+	s, err := sndStreamers[path.Ext(fname)](reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	s.file = reader
+	return s, nil
+}
+
+// Read implements io.Reader by decoding sound data as needed.
+func (s *SndStream) Read(p []byte) (n int, err error) {
+	for len(s.pcm) == 0 {
+		if s.pcm, err = s.next(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, s.pcm)
+	s.pcm = s.pcm[n:]
+	return n, nil
+}
+
+// Seek implements io.Seeker for offsets from the start of the sound
+// data, ie: to return to a loop start. Seeking reopens the sound file
+// and decodes up to the offset. Offsets are expected to be on a sample
+// boundary. Only io.SeekStart is supported.
+func (s *SndStream) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart || offset < 0 || s.open == nil {
+		return 0, fmt.Errorf("Invalid sound seek %d %d", offset, whence)
+	}
+	restart, err := s.open()
+	if err != nil {
+		return 0, err
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.next, s.pcm, s.file = restart.next, nil, restart.file
+	n, err := io.CopyN(ioutil.Discard, s, offset)
+	if err == io.EOF {
+		err = fmt.Errorf("Invalid sound seek %d past end %d", offset, n)
+	}
+	return n, err
+}
+
+// Close implements io.Closer by closing the sound file.
+func (s *SndStream) Close() error {
+	s.next = func() ([]byte, error) { return nil, io.EOF }
+	s.pcm = nil
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
+
+// sndExts are the supported sound file extensions in search order.
+var sndExts = []string{".wav", ".ogg", ".flac"}
+
+// sndDecoders are the sound file format functions by file extension.
+var sndDecoders = map[string]func(r io.Reader, d *SndData) error{
+	".wav":  Wav,
+	".ogg":  Ogg,
+	".flac": Flac,
+}
+
+// sndStreamers start decoding sound files by file extension.
+var sndStreamers = map[string]func(r io.Reader) (*SndStream, error){
+	".wav":  wavStream,
+	".ogg":  oggStream,
+	".flac": flacSndStream,
+}
+
+// sndBlock is the amount of sound data read from a file at a time
+// when streaming uncompressed sounds.
+const sndBlock = 32 * 1024
+
+// sndFiles returns the names of the sound files to try for the
+// given sound in search order.
+func sndFiles(name string) []string {
+	if _, ok := sndDecoders[path.Ext(name)]; ok {
+		return []string{name}
+	}
+	fnames := []string{}
+	for _, ext := range sndExts {
+		fnames = append(fnames, name+ext)
+	}
+	return fnames
+}
+
+// SndData
+// =============================================================================
+// BankData
+
+// BankData describes a group of sounds and how each sound is played.
+// BankData is an intermediate data format where the sound files are
+// loaded separately using SndData.
+type BankData struct {
+	Sounds []BankSound // Sounds in the order they appear in the bank.
+}
+
+// BankSound describes one sound from an audio bank.
+type BankSound struct {
+	Name      string     // Unique sound name.
+	Files     []string   // Sound files played in round robin order.
+	Bus       string     // Mixer bus: sfx, music, or voice.
+	Gain      float64    // Default volume from 0 to 1.
+	Pitch     [2]float64 // Random pitch range, 1 is unchanged.
+	Loop      bool       // True to repeat the sound.
+	LoopStart float64    // Optional loop start in seconds.
+	LoopEnd   float64    // Optional loop end in seconds. 0 for the full sound.
+
+	// Distance attenuation for sounds played at a location.
+	RefDistance float64 // Full volume up to this distance.
+	MaxDistance float64 // No further fading past this distance. 0 for no limit.
+	Rolloff     float64 // Fade rate. 0 for no fading.
+
+	// Priority decides which sounds keep playing when there are more
+	// sounds than voices. Higher priority sounds are kept.
+	Priority int
+
+	// Triggers are the engine events that automatically play the sound.
+	Triggers []BankTrigger
+}
+
+// BankTrigger plays a bank sound when the named event happens.
+// Events with a strength, like collision impulses, scale the sound
+// volume from silent at Min to full volume at Max. Min and Max are
+// both 0 for events that always play at full volume.
+type BankTrigger struct {
+	Event    string  // Event name, ie: impact.
+	Min, Max float64 // Event strength range.
+}
+
+// Load an audio bank. Existing BankData is overwritten
+// with information found by the Locator.
+func (d *BankData) Load(name string, l Locator) (err error) {
+	fname := name + ".bank"
 	var reader io.ReadCloser
 	if reader, err = l.GetResource(fname); err != nil {
-		return fmt.Errorf("Load sound error %s: %s\n", fname, err)
+		return fmt.Errorf("could not open %s %s", fname, err)
 	}
 	defer reader.Close()
-	return Wav(reader, d)
+	return Bank(reader, d)
 }
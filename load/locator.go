@@ -29,13 +29,18 @@ type Locator interface {
 
 // NewLocator returns the default asset locator. The default Locator
 // looks directly to disk for development builds and for a zip file for
-// production builds. The default asset locator expects all locations
+// production builds. Any pack files added using Mount are searched
+// before the zip file and disk. The default asset locator expects all locations
 // are directories relative to the application location.
 // The default Locator maps the following file types to the given directories.
-//    PNG               : "images"
-//    WAV               : "audio"
-//    OBJ, IQM, MTL     : "models"
+//    PNG, JPG, TGA     : "images"
+//    HDR, KTX2, TEX    : "images"
+//    WAV, OGG, FLAC    : "audio"
+//    BANK              : "audio"
+//    OBJ, IQM, MTL, MAT: "models"
+//    GLTF, GLB, BIN    : "models"
 //    FNT, VSH, FSH, TXT: "source"
+//    TTF               : "source"
 func NewLocator() Locator { return newLocator() }
 
 // ===========================================================================
@@ -81,13 +86,27 @@ func newLocator() *locator {
 		"OBJ":  "models",
 		"IQM":  "models",
 		"MTL":  "models",
+		"MAT":  "models",
+		"GLTF": "models",
+		"GLB":  "models",
+		"DAE":  "models",
+		"BIN":  "models",
 		"WAV":  "audio",
+		"OGG":  "audio",
+		"FLAC": "audio",
+		"BANK": "audio",
 		"TXT":  "source",
 		"VSH":  "source",
 		"FSH":  "source",
 		"FNT":  "source",
+		"TTF":  "source",
 		"JSON": "source",
 		"PNG":  "images",
+		"JPG":  "images",
+		"TGA":  "images",
+		"HDR":  "images",
+		"KTX2": "images",
+		"TEX":  "images",
 	}
 	return l
 }
@@ -106,6 +125,9 @@ func (l *locator) GetResource(name string) (file io.ReadCloser, err error) {
 		prefix = val
 	}
 	filePath := strings.TrimSpace(path.Join(prefix, name))
+	if rc, ok := mounts.open(filePath); ok {
+		return rc, nil // mounted pack files are searched first.
+	}
 	if l.reader != nil {
 		for _, resource := range l.reader.File {
 			if filePath == resource.Name {
@@ -0,0 +1,132 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Mat loads a vu material file. Material files are text files that extend
+// the MTL lighting statements with a shader, physically based rendering
+// values, per channel textures, blending, and custom shader uniforms.
+// Each line holds one statement. Lines starting with # are comments.
+//    shader    name          : shader used to render the material.
+//    color     r g b [a]     : base color. Also sets the diffuse color.
+//    metallic  f             : 0 for dielectric, 1 for metal.
+//    roughness f             : 0 for smooth, 1 for rough.
+//    emissive  r g b         : emitted light color.
+//    tex       channel file  : texture for a channel, ie: "tex normal wood_n.png".
+//    blend     mode [cutoff] : opaque, alpha, add, or cutout.
+//    twosided                : render back faces.
+//    uniform   name f...     : shader uniform values.
+//    Ka, Kd, Ks, Ns, d       : MTL lighting statements.
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import overwrites the data in MatData.
+func Mat(r io.Reader, d *MatData) error {
+	*d = *newMatData()
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if err := matLine(scanner.Text(), d); err != nil {
+			return fmt.Errorf("Invalid .mat line %d: %s", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// newMatData creates the default opaque white material.
+func newMatData() *MatData {
+	return &MatData{MtlData: *newMtlData(), Color: [4]float32{1, 1, 1, 1},
+		Roughness: 1, Blend: "opaque", Uniforms: map[string][]float32{}}
+}
+
+// matLine parses one line of a material file into the given material.
+func matLine(line string, d *MatData) error {
+	tokens := strings.Fields(line)
+	if len(tokens) == 0 || strings.HasPrefix(tokens[0], "#") {
+		return nil
+	}
+	vals, err := matFloats(tokens[1:])
+	switch tokens[0] {
+	case "shader":
+		if len(tokens) != 2 {
+			return fmt.Errorf("expected shader name")
+		}
+		d.Shader = tokens[1]
+	case "color":
+		if err != nil || len(vals) < 3 || len(vals) > 4 {
+			return fmt.Errorf("expected color r g b [a]")
+		}
+		copy(d.Color[:], vals)
+		d.KdR, d.KdG, d.KdB, d.Alpha = d.Color[0], d.Color[1], d.Color[2], d.Color[3]
+	case "metallic":
+		if err != nil || len(vals) != 1 {
+			return fmt.Errorf("expected metallic value")
+		}
+		d.Metallic = vals[0]
+	case "roughness":
+		if err != nil || len(vals) != 1 {
+			return fmt.Errorf("expected roughness value")
+		}
+		d.Roughness = vals[0]
+		d.Ns = (1-d.Roughness)*(1-d.Roughness)*128 + 1 // same as glTF.
+	case "emissive":
+		if err != nil || len(vals) != 3 {
+			return fmt.Errorf("expected emissive r g b")
+		}
+		copy(d.Emissive[:], vals)
+	case "tex":
+		if len(tokens) != 3 {
+			return fmt.Errorf("expected tex channel file")
+		}
+		d.Textures = append(d.Textures, MatTex{Channel: tokens[1], Name: mtlTexture(tokens)})
+	case "blend":
+		if len(tokens) < 2 {
+			return fmt.Errorf("expected blend mode")
+		}
+		switch d.Blend = tokens[1]; d.Blend {
+		case "opaque", "alpha", "add":
+		case "cutout":
+			d.Cutoff = 0.5
+			if len(tokens) > 2 {
+				c, err := strconv.ParseFloat(tokens[2], 32)
+				if err != nil {
+					return fmt.Errorf("expected cutoff value")
+				}
+				d.Cutoff = float32(c)
+			}
+		default:
+			return fmt.Errorf("unknown blend mode %s", d.Blend)
+		}
+	case "twosided":
+		d.TwoSided = true
+	case "uniform":
+		if len(tokens) < 3 {
+			return fmt.Errorf("expected uniform name and values")
+		}
+		if vals, err = matFloats(tokens[2:]); err != nil {
+			return fmt.Errorf("expected uniform values")
+		}
+		d.Uniforms[tokens[1]] = vals
+	default:
+		return mtlLine(line, &d.MtlData)
+	}
+	return nil
+}
+
+// matFloats parses the tokens as float values.
+func matFloats(tokens []string) ([]float32, error) {
+	vals := make([]float32, len(tokens))
+	for cnt, t := range tokens {
+		f, err := strconv.ParseFloat(t, 32)
+		if err != nil {
+			return nil, err
+		}
+		vals[cnt] = float32(f)
+	}
+	return vals, nil
+}
@@ -0,0 +1,49 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Uses vu/eg resource directories.
+func TestLoadMat(t *testing.T) {
+	m := &MatData{}
+	if err := m.Load("tile", NewLocator().Dir("MAT", "../eg/models")); err != nil {
+		t.Fatalf("Should be able to load a valid material file %s", err)
+	}
+	if m.Shader != "uv" || m.Color != [4]float32{0.9, 0.9, 0.8, 1} || m.Metallic != 0.1 || m.Roughness != 0.6 {
+		t.Errorf("Bad material values %+v", m)
+	}
+	texs := []MatTex{{"albedo", "tile"}, {"normal", "tile_nrm"}, {"roughness", "tile_spec"}}
+	if !reflect.DeepEqual(m.Textures, texs) {
+		t.Errorf("Expected textures %v got %v", texs, m.Textures)
+	}
+	if m.Blend != "cutout" || m.Cutoff != 0.3 || !m.TwoSided {
+		t.Errorf("Bad blending %s %f %t", m.Blend, m.Cutoff, m.TwoSided)
+	}
+	if !reflect.DeepEqual(m.Uniforms["tiling"], []float32{4, 4}) {
+		t.Errorf("Bad uniform %v", m.Uniforms)
+	}
+	if m.KdR != 0.9 || m.KsR != 0.2 || m.KaR != 0.2 {
+		t.Errorf("Expected lighting colors %+v", m.MtlData)
+	}
+}
+
+func TestMatDefaults(t *testing.T) {
+	m := &MatData{Shader: "old"}
+	if err := Mat(strings.NewReader("# empty\n"), m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Shader != "" || m.Color[3] != 1 || m.Roughness != 1 || m.Blend != "opaque" || m.Alpha != 1 {
+		t.Errorf("Bad defaults %+v", m)
+	}
+	for _, bad := range []string{"color 1 0", "blend glow", "metallic shiny", "uniform x", "tex albedo"} {
+		if err := Mat(strings.NewReader(bad), m); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// mount.go adds asset sources to the default Locator search.
+// DESIGN: Mounted sources are shared by all Locators so that asset
+//         sources can be set up once, on startup, before the engine
+//         creates its Locators.
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mount adds the named pack file to the resources searched by all
+// Locators. Mounted sources are searched before any zipped or disk based
+// resources. Sources mounted later are searched first so that a patch
+// pack can replace the assets in an earlier pack. Pack resources
+// use the Locator directory names, ie: "models/cube.obj".
+// See PackWriter and PackDir for creating pack files.
+func Mount(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("Mount %s: %s", file, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("Mount %s: %s", file, err)
+	}
+	pk, err := openPack(file, f, info.Size())
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("Mount %s: %s", file, err)
+	}
+	pk.closer = f
+	mounts.add(pk)
+	return nil
+}
+
+// MountPack adds pack data of the given size to the resources searched
+// by all Locators. Useful for packs that are embedded in the application
+// or downloaded into memory, ie:
+//     load.MountPack("assets", bytes.NewReader(data), int64(len(data)))
+// The name is used to Unmount the pack.
+func MountPack(name string, r io.ReaderAt, size int64) error {
+	pk, err := openPack(name, r, size)
+	if err != nil {
+		return fmt.Errorf("MountPack %s: %s", name, err)
+	}
+	mounts.add(pk)
+	return nil
+}
+
+// MountFS adds a file system to the resources searched by all Locators.
+// Useful for assets embedded using go:embed, ie:
+//     //go:embed models images source
+//     var assets embed.FS
+//     ...
+//     load.MountFS("assets", assets)
+// File system resources use the Locator directory names, ie:
+// "models/cube.obj". The name is used to Unmount the file system.
+func MountFS(name string, fsys fs.FS) { mounts.add(&fsSource{name: name, fsys: fsys}) }
+
+// Unmount removes the named pack file or file system from the resources
+// searched by all Locators. Do nothing if nothing is mounted by that name.
+// Resources from a pack file that are still being read can be read until
+// they are closed. The pack file is closed after the last one is closed.
+func Unmount(name string) { mounts.remove(name) }
+
+// =============================================================================
+
+// source is a mounted collection of resources.
+type source interface {
+	label() string                          // Mount name.
+	open(name string) (io.ReadCloser, bool) // Resource reader, if found.
+	close()                                 // Release the source.
+}
+
+// fsSource is a mounted file system.
+type fsSource struct {
+	name string // Mount name.
+	fsys fs.FS  // Resource files.
+}
+
+// label returns the mount name.
+func (fss *fsSource) label() string { return fss.name }
+
+// close does nothing since the file system is owned by the application.
+func (fss *fsSource) close() {}
+
+// open returns a reader for the named file.
+func (fss *fsSource) open(name string) (io.ReadCloser, bool) {
+	if !fs.ValidPath(name) {
+		return nil, false
+	}
+	f, err := fss.fsys.Open(name)
+	if err != nil {
+		return nil, false
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, false
+	}
+	return f, true
+}
+
+// sources are the mounted resource sources. Sources are
+// searched from the most recently mounted.
+type sources struct {
+	mutex sync.RWMutex // Locators are used on multiple goroutines.
+	list  []source     // Mounted sources.
+}
+
+// mounts are the sources searched by all Locators.
+var mounts = &sources{}
+
+// add mounts a source, replacing any source with the same name.
+func (ss *sources) add(s source) {
+	ss.remove(s.label())
+	ss.mutex.Lock()
+	ss.list = append(ss.list, s)
+	ss.mutex.Unlock()
+}
+
+// remove unmounts the named source.
+func (ss *sources) remove(name string) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	for cnt, s := range ss.list {
+		if s.label() == name {
+			s.close()
+			ss.list = append(ss.list[:cnt], ss.list[cnt+1:]...)
+			return
+		}
+	}
+}
+
+// open returns a reader for the named resource from the most
+// recently mounted source that has the resource.
+func (ss *sources) open(name string) (io.ReadCloser, bool) {
+	name = strings.TrimPrefix(name, "./")
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+	for cnt := len(ss.list) - 1; cnt >= 0; cnt-- {
+		if rc, ok := ss.list[cnt].open(name); ok {
+			return rc, true
+		}
+	}
+	return nil, false
+}
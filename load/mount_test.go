@@ -0,0 +1,46 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+)
+
+// Later mounts replace resources from earlier mounts.
+func TestMountFS(t *testing.T) {
+	MountFS("base", fstest.MapFS{
+		"source/a.txt": {Data: []byte("base a")},
+		"source/b.txt": {Data: []byte("base b")},
+	})
+	defer Unmount("base")
+	buff := &bytes.Buffer{}
+	pw := NewPackWriter(buff)
+	pw.Add("source/b.txt", []byte("patch b"), false)
+	pw.Close()
+	if err := MountPack("patch", bytes.NewReader(buff.Bytes()), int64(buff.Len())); err != nil {
+		t.Fatal(err)
+	}
+	defer Unmount("patch")
+	loc := NewLocator()
+	for name, want := range map[string]string{"a.txt": "base a", "b.txt": "patch b"} {
+		rc, err := loc.GetResource(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if string(got) != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+	Unmount("patch")
+	if rc, ok := mounts.open("source/b.txt"); !ok {
+		t.Error("Expected base resource after unmount")
+	} else {
+		rc.Close()
+	}
+}
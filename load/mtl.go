@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"path"
 	"strconv"
 	"strings"
 )
@@ -19,37 +20,99 @@ import (
 // The Reader r is expected to be opened and closed by the caller.
 // A successful import overwrites the data in MtlData.
 func Mtl(r io.Reader, d *MtlData) error {
-	var f1, f2, f3 float32
-	reader := bufio.NewReader(r)
-	line, e1 := reader.ReadString('\n')
-	for ; e1 == nil; line, e1 = reader.ReadString('\n') {
-		tokens := strings.Split(line, " ")
-		switch tokens[0] {
-		case "Ka": // ambient
-			if _, e := fmt.Sscanf(line, "Ka %f %f %f", &f1, &f2, &f3); e != nil {
-				return fmt.Errorf("could not parse ambient values %s", e)
-			}
-			d.KaR, d.KaG, d.KaB = f1, f2, f3
-		case "Kd": // diffuse
-			if _, e := fmt.Sscanf(line, "Kd %f %f %f", &f1, &f2, &f3); e != nil {
-				return fmt.Errorf("could not parse diffuse values %s", e)
-			}
-			d.KdR, d.KdG, d.KdB = f1, f2, f3
-		case "Ks": // specular
-			if _, e := fmt.Sscanf(line, "Ks %f %f %f", &f1, &f2, &f3); e != nil {
-				return fmt.Errorf("could not parse specular values %s", e)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if err := mtlLine(scanner.Text(), d); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// MtlLib loads all the materials in a Wavefront MTL file by material name.
+// Material values that are not in the file are defaulted to an opaque
+// white material. The Reader r is expected to be opened and closed by
+// the caller. Loaded materials are added to, or replace, the materials
+// in the given map.
+func MtlLib(r io.Reader, mtls map[string]*MtlData) error {
+	var d *MtlData
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "newmtl") {
+			d = newMtlData()
+			mtls[strings.TrimSpace(strings.TrimPrefix(line, "newmtl"))] = d
+			continue
+		}
+		if d != nil {
+			if err := mtlLine(line, d); err != nil {
+				return err
 			}
-			d.KsR, d.KsG, d.KsB = f1, f2, f3
-		case "d": // transparency
-			a, _ := strconv.ParseFloat(strings.TrimSpace(tokens[1]), 32)
+		}
+	}
+	return scanner.Err()
+}
+
+// newMtlData creates the default opaque white material.
+func newMtlData() *MtlData {
+	return &MtlData{
+		KaR: 0.2, KaG: 0.2, KaB: 0.2,
+		KdR: 1, KdG: 1, KdB: 1,
+		Ns: 1, Alpha: 1,
+	}
+}
+
+// mtlLine parses one line of an MTL file into the given material.
+func mtlLine(line string, d *MtlData) error {
+	var f1, f2, f3 float32
+	line = strings.TrimSpace(line)
+	tokens := strings.Fields(line)
+	if len(tokens) == 0 {
+		return nil
+	}
+	switch tokens[0] {
+	case "Ka": // ambient
+		if _, e := fmt.Sscanf(line, "Ka %f %f %f", &f1, &f2, &f3); e != nil {
+			return fmt.Errorf("could not parse ambient values %s", e)
+		}
+		d.KaR, d.KaG, d.KaB = f1, f2, f3
+	case "Kd": // diffuse
+		if _, e := fmt.Sscanf(line, "Kd %f %f %f", &f1, &f2, &f3); e != nil {
+			return fmt.Errorf("could not parse diffuse values %s", e)
+		}
+		d.KdR, d.KdG, d.KdB = f1, f2, f3
+	case "Ks": // specular
+		if _, e := fmt.Sscanf(line, "Ks %f %f %f", &f1, &f2, &f3); e != nil {
+			return fmt.Errorf("could not parse specular values %s", e)
+		}
+		d.KsR, d.KsG, d.KsB = f1, f2, f3
+	case "d": // transparency
+		if len(tokens) > 1 {
+			a, _ := strconv.ParseFloat(tokens[1], 32)
 			d.Alpha = float32(a)
-		case "Ns": // specular exponent
-			ns, _ := strconv.ParseFloat(strings.TrimSpace(tokens[1]), 32)
+		}
+	case "Ns": // specular exponent
+		if len(tokens) > 1 {
+			ns, _ := strconv.ParseFloat(tokens[1], 32)
 			d.Ns = float32(ns)
-		case "newmtl": // material name
-		case "Ni": // optical density - scaler. Ignored for now.
-		case "illum": // illumination model - int. Ignored for now.
 		}
+	case "map_Kd": // diffuse texture.
+		d.MapKd = mtlTexture(tokens)
+	case "map_Ks": // specular texture.
+		d.MapKs = mtlTexture(tokens)
+	case "newmtl": // material name
+	case "Ni": // optical density - scaler. Ignored for now.
+	case "illum": // illumination model - int. Ignored for now.
 	}
 	return nil
 }
+
+// mtlTexture returns the texture name for a texture map file,
+// ie: "textures\wood.png" is "wood". Texture map options are ignored.
+func mtlTexture(tokens []string) string {
+	if len(tokens) < 2 {
+		return ""
+	}
+	file := path.Base(strings.Replace(tokens[len(tokens)-1], "\\", "/", -1))
+	return strings.TrimSuffix(file, path.Ext(file))
+}
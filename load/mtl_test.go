@@ -5,6 +5,7 @@ package load
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -19,3 +20,22 @@ func TestLoadMtl(t *testing.T) {
 		t.Errorf(format, got, want)
 	}
 }
+
+func TestMtlLib(t *testing.T) {
+	lib := "newmtl red\nKd 1 0 0\nmap_Kd textures\\brick.png\n" +
+		"newmtl glass\nd 0.5\nmap_Ks -bm 0.2 shine.png"
+	mtls := map[string]*MtlData{}
+	if err := MtlLib(strings.NewReader(lib), mtls); err != nil {
+		t.Fatal(err)
+	}
+	red, glass := mtls["red"], mtls["glass"]
+	if red == nil || glass == nil {
+		t.Fatalf("Expected 2 materials, got %d", len(mtls))
+	}
+	if red.KdG != 0 || red.MapKd != "brick" || red.Alpha != 1 {
+		t.Errorf("Improper red material %v", red)
+	}
+	if glass.KdG != 1 || glass.MapKs != "shine" || glass.Alpha != 0.5 {
+		t.Errorf("Improper glass material %v", glass)
+	}
+}
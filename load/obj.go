@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/gazed/vu/math/lin"
@@ -16,41 +17,148 @@ import (
 // Obj loads a Wavefront OBJ file containing one or more mesh descriptions.
 // A Wavefront OBJ file is a text representation of one or more 3D models.
 // This loader supports a limited subset of the full specification. It is
-// specifically looking for a single object triangle mesh with normals.
+// specifically looking for the triangle mesh of the first object. Use
+// ObjScene to import all the objects, groups, and materials.
 //    https://en.wikipedia.org/wiki/Wavefront_.obj_file#File_format
 //    http://www.martinreddy.net/gfx/3d/OBJ.spec
 // The Reader r is expected to be opened and closed by the caller.
 // A successful import overwrites the data in ObjData.
 func Obj(r io.Reader, d *MshData) error {
-	objs := obj2Strings(r)
-	if len(objs) <= 0 {
+	odata, err := obj2Data(r)
+	if err != nil {
+		return fmt.Errorf("obj2Data %s", err)
+	}
+	if len(odata.parts) <= 0 {
 		return fmt.Errorf("No objects in .obj file")
 	}
-	odata := &objData{}
-	faces, derr := obj2Data(objs[0].lines, odata)
-	if derr != nil {
-		return fmt.Errorf("obj2Data %s", derr)
+
+	// merge the groups and materials of the first object.
+	first := odata.parts[0]
+	faces := [][]objIndex{}
+	for _, p := range odata.parts {
+		if p.oi == first.oi {
+			faces = append(faces, p.faces...)
+		}
+	}
+	meshes, err := obj2MshData(first.object, odata, faces)
+	if err != nil {
+		return err
+	}
+	if len(meshes) > 1 {
+		return fmt.Errorf("Too many verticies in %s", first.object)
 	}
-	return obj2MshData(objs[0].name, odata, faces, d)
+	*d = *meshes[0]
+	return nil
+}
+
+// ObjScene loads a Wavefront OBJ file as a scene. Each object is a node.
+// Each object group is a child node. Objects and groups are split into
+// one mesh for each material, and again if the mesh has more verticies
+// than can be indexed by MshData faces. Materials are found using the
+// Locator from the .mtl files listed by the OBJ file. The material
+// diffuse texture, if any, is the mesh texture.
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import appends to the data in ScnData.
+func ObjScene(r io.Reader, d *ScnData, l Locator) error {
+	odata, err := obj2Data(r)
+	if err != nil {
+		return fmt.Errorf("obj2Data %s", err)
+	}
+	if len(odata.parts) <= 0 {
+		return fmt.Errorf("No objects in .obj file")
+	}
+	mtls := map[string]*MtlData{}
+	for _, lib := range odata.mtllibs {
+		if l == nil {
+			break
+		}
+		reader, err := l.GetResource(lib)
+		if err != nil {
+			log.Printf("ObjScene: missing material library %s", lib)
+			continue
+		}
+		err = MtlLib(reader, mtls)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("MtlLib %s: %s", lib, err)
+		}
+	}
+
+	// create the object and group nodes as needed.
+	objects := map[int]int{}   // object index to node index.
+	groups := map[string]int{} // object and group name to node index.
+	for _, p := range odata.parts {
+		node, ok := objects[p.oi]
+		if !ok {
+			node = len(d.Nodes)
+			objects[p.oi] = node
+			d.Nodes = append(d.Nodes, objNode(p.object, -1))
+		}
+		if p.group != "" {
+			key := strconv.Itoa(p.oi) + "/" + p.group
+			if gnode, ok := groups[key]; ok {
+				node = gnode
+			} else {
+				groups[key] = len(d.Nodes)
+				d.Nodes = append(d.Nodes, objNode(p.group, node))
+				node = len(d.Nodes) - 1
+			}
+		}
+		name := p.object
+		if p.group != "" {
+			name += "-" + p.group
+		}
+		if p.material != "" {
+			name += "-" + p.material
+		}
+		meshes, err := obj2MshData(name, odata, p.faces)
+		if err != nil {
+			return err
+		}
+		mtl, ok := mtls[p.material]
+		if !ok {
+			mtl = newMtlData()
+		}
+		for _, msh := range meshes {
+			sm := ScnMesh{Skin: -1, MtlData: *mtl}
+			sm.MshData = *msh
+			if mtl.MapKd != "" {
+				sm.TMap = []TexMap{{Name: mtl.MapKd, F0: 0, Fn: uint32(len(msh.F) / 3)}}
+			}
+			d.Nodes[node].Meshes = append(d.Nodes[node].Meshes, len(d.Meshes))
+			d.Meshes = append(d.Meshes, sm)
+		}
+	}
+	return nil
+}
+
+// objNode creates an untransformed scene node.
+func objNode(name string, parent int) ScnNode {
+	return ScnNode{Name: name, Parent: parent, Rot: *lin.NewQI(), Scale: lin.V3{X: 1, Y: 1, Z: 1}}
 }
 
 // public inteface
 // =============================================================================
 // internal implementation for loading OBJ files.
 
-// objStrings is an intermediate data structure used in parsing.
-type objStrings struct {
-	name  string
-	lines []string
-}
-
 // objData is an intermediate data structure used in parsing.
 // Each .obj file keeps a global count of the data below.  This is referenced
 // from the face data.
 type objData struct {
-	v []dataPoint // vertices
-	n []dataPoint // normals
-	t []uvPoint   // texture coordinates
+	v       []dataPoint // vertices
+	n       []dataPoint // normals
+	t       []uvPoint   // texture coordinates
+	parts   []*objPart  // faces by object, group, and material.
+	mtllibs []string    // material library file names.
+}
+
+// objPart holds the faces for one object, group, and material combination.
+type objPart struct {
+	oi       int          // object index. Unique for each object.
+	object   string       // object name.
+	group    string       // group name. Optional.
+	material string       // material name. Optional.
+	faces    [][]objIndex // polygons with 3 or more points.
 }
 
 // dataPoint is an internal structure for passing vertices or normals.
@@ -63,77 +171,155 @@ type uvPoint struct {
 	u, v float32
 }
 
-// face is an internal structure for passing face indexes.
-type face struct {
-	s []string // each point is a "x/y/z" value.
-}
-
-// obj2Strings reads in all the file data grouped by object name. This is needed
-// because a single wavefront file can hold many objects. Separating the objects
-// makes parsing easier.
-func obj2Strings(file io.Reader) (objs []*objStrings) {
-	objs = []*objStrings{}
-	name := ""
-	var curr *objStrings
-	reader := bufio.NewReader(file)
-	line, e1 := reader.ReadString('\n')
-	for ; e1 == nil; line, e1 = reader.ReadString('\n') {
-		line = strings.TrimSpace(line)
-		tokens := strings.Split(line, " ")
-		if len(tokens) == 2 && tokens[0] == "o" {
-			name = strings.TrimSpace(tokens[1])
-			curr = &objStrings{name, []string{}}
-			objs = append(objs, curr)
-		} else if len(name) > 0 {
-			curr.lines = append(curr.lines, strings.TrimSpace(line))
-		}
-	}
-	return
+// objIndex holds the zero based vertex, texture, and normal indicies
+// for one face point. Missing texture and normal indicies are -1.
+type objIndex struct {
+	v, t, n int
 }
 
-// obj2Data turns a wavefront object into numbers and temporary data structures.
+// obj2Data turns a wavefront file into numbers and temporary data structures.
+// Faces before the first object belong to an unnamed object.
 //
 // Note that the OBJ files refer to vertices and normals through a absolute
-// count from the beginning of the file. OBJ files can be created from Blender.
-func obj2Data(lines []string, odata *objData) (faces []face, err error) {
-	for _, line := range lines {
-		tokens := strings.Split(line, " ")
-		var f1, f2, f3 float32
-		var s1, s2, s3 string
+// count from the beginning of the file, or a negative count back from the
+// current position. OBJ files can be created from Blender.
+func obj2Data(r io.Reader) (odata *objData, err error) {
+	odata = &objData{}
+	oi, object, group, material := 0, "", "", ""
+	var curr *objPart
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // allow long lines.
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
 		switch tokens[0] {
 		case "v":
-			if _, e := fmt.Sscanf(line, "v %f %f %f", &f1, &f2, &f3); e != nil {
+			var f []float32
+			if f, err = objFloats(tokens, 3); err != nil {
 				log.Printf("Bad vertex: %s\n", line)
-				return faces, fmt.Errorf("could not parse vertex %s", e)
+				return odata, fmt.Errorf("could not parse vertex %s", err)
 			}
-			odata.v = append(odata.v, dataPoint{f1, f2, f3})
+			odata.v = append(odata.v, dataPoint{f[0], f[1], f[2]})
 		case "vn":
-			if _, e := fmt.Sscanf(line, "vn %f %f %f", &f1, &f2, &f3); e != nil {
+			var f []float32
+			if f, err = objFloats(tokens, 3); err != nil {
 				log.Printf("Bad normal: %s\n", line)
-				return faces, fmt.Errorf("could not parse normal %s", e)
+				return odata, fmt.Errorf("could not parse normal %s", err)
 			}
-			odata.n = append(odata.n, dataPoint{f1, f2, f3})
+			odata.n = append(odata.n, dataPoint{f[0], f[1], f[2]})
 		case "vt":
-			if _, e := fmt.Sscanf(line, "vt %f %f", &f1, &f2); e != nil {
+			var f []float32
+			if f, err = objFloats(tokens, 2); err != nil {
 				log.Printf("Bad texture coord: %s\n", line)
-				return faces, fmt.Errorf("could not texture coordinate %s", e)
+				return odata, fmt.Errorf("could not texture coordinate %s", err)
 			}
-			odata.t = append(odata.t, uvPoint{f1, 1 - f2})
+			odata.t = append(odata.t, uvPoint{f[0], 1 - f[1]})
 		case "f":
-			if _, e := fmt.Sscanf(line, "f %s %s %s", &s1, &s2, &s3); e != nil {
+			if len(tokens) < 4 {
 				log.Printf("Bad face: %s\n", line)
-				return faces, fmt.Errorf("could not parse face %s", e)
+				return odata, fmt.Errorf("could not parse face %s", line)
+			}
+			polygon := make([]objIndex, len(tokens)-1)
+			for cnt, token := range tokens[1:] {
+				if polygon[cnt], err = odata.parseFaceIndex(token); err != nil {
+					log.Printf("Bad face: %s\n", line)
+					return odata, fmt.Errorf("could not parse face %s", err)
+				}
 			}
-			faces = append(faces, face{[]string{s1, s2, s3}})
-		case "o": // mesh name is processed before this method is called.
+			if curr == nil {
+				curr = odata.part(oi, object, group, material)
+			}
+			curr.faces = append(curr.faces, polygon)
+		case "o":
+			oi, object, group, curr = oi+1, objName(line), "", nil
+		case "g":
+			group, curr = objName(line), nil
+		case "usemtl":
+			material, curr = objName(line), nil
+		case "mtllib":
+			odata.mtllibs = append(odata.mtllibs, tokens[1:]...)
 		case "s": // FUTURE: smoothing group - ignored for now.
-		case "mtllib": // materials loaded separately and explicitly.
-		case "usemtl": // material name - ignored, see above.
 		}
 	}
-	return
+	return odata, scanner.Err()
+}
+
+// objName returns everything after the first token on a line.
+func objName(line string) string {
+	if i := strings.IndexAny(line, " \t"); i > 0 {
+		return strings.TrimSpace(line[i:])
+	}
+	return ""
+}
+
+// objFloats parses at least the minimum number of floats following
+// the first token. Optional trailing values, like w, are ignored.
+func objFloats(tokens []string, min int) (f []float32, err error) {
+	if len(tokens) <= min {
+		return nil, fmt.Errorf("expected %d values", min)
+	}
+	f = make([]float32, min)
+	for cnt := range f {
+		var v float64
+		if v, err = strconv.ParseFloat(tokens[cnt+1], 32); err != nil {
+			return nil, err
+		}
+		f[cnt] = float32(v)
+	}
+	return f, nil
 }
 
+// part returns the faces for the given object, group, and material,
+// creating a new part if one does not already exist.
+func (odata *objData) part(oi int, object, group, material string) *objPart {
+	for _, p := range odata.parts {
+		if p.oi == oi && p.group == group && p.material == material {
+			return p
+		}
+	}
+	p := &objPart{oi: oi, object: object, group: group, material: material}
+	odata.parts = append(odata.parts, p)
+	return p
+}
+
+// parseFaceIndex turns a face index point string of the form "v", "v/t",
+// "v//n", or "v/t/n" into zero based indicies. Missing indicies are -1.
+// Negative indicies count back from the most recently read values.
+func (odata *objData) parseFaceIndex(findex string) (fi objIndex, err error) {
+	fi = objIndex{-1, -1, -1}
+	fields := strings.Split(findex, "/")
+	if len(fields) > 3 || fields[0] == "" {
+		return fi, fmt.Errorf("Bad face (%s)\n", findex)
+	}
+	counts := []int{len(odata.v), len(odata.t), len(odata.n)}
+	indicies := []*int{&fi.v, &fi.t, &fi.n}
+	for cnt, field := range fields {
+		if field == "" {
+			continue // optional texture or normal.
+		}
+		index, err := strconv.Atoi(field)
+		switch {
+		case err != nil || index == 0:
+			return fi, fmt.Errorf("Bad face (%s)\n", findex)
+		case index < 0:
+			index = counts[cnt] + index // relative to current position.
+		default:
+			index = index - 1
+		}
+		if index < 0 || index >= counts[cnt] {
+			return fi, fmt.Errorf("Face index out of range (%s)\n", findex)
+		}
+		*indicies[cnt] = index
+	}
+	return fi, nil
+}
+
+// objMaxVerts is the most verticies that can be indexed by MshData faces.
+const objMaxVerts = 65536
+
 // obj2MshData turns the data from .obj format into an internal OpenGL friendly
 // format. The following information needs to be created for each mesh.
 //
@@ -143,73 +329,88 @@ func obj2Data(lines []string, odata *objData) (faces []face, err error) {
 //    mesh.F = append(mesh.F, ...3-uint16)	- refers to above zero indexed values
 //
 // odata holds the global vertex, texture, and normal point information.
-// faces are the indexes for this mesh.
+// faces are the polygons for this mesh. Polygons are triangulated as a fan.
+// A new mesh is started when the verticies can no longer be indexed.
 //
 // Additionally the normals at each vertex are generated as the sum of the
-// normals for each face that shares that vertex.
-func obj2MshData(name string, odata *objData, faces []face, data *MshData) (err error) {
-	data.Name = name
-	vmap := make(map[string]int) // the unique vertex data points for this face.
-	vcnt := -1
-
-	// process each vertex of each face.  Each one represents a combination vertex,
-	// texture coordinate, and normal.
-	v0 := &lin.V3{} // scratch
-	v1 := &lin.V3{} // scratch
-	for _, face := range faces {
-		for pi := 0; pi < 3; pi++ {
-			facei := face.s[pi]
-			v, t, n := -1, -1, -1
-			if v, t, n, err = parseFaceIndex(facei); err != nil {
-				return fmt.Errorf("could not parse face data %s", err)
+// normals for each face that shares that vertex. Face normals are used
+// where the vertex normals are missing.
+func obj2MshData(name string, odata *objData, faces [][]objIndex) (meshes []*MshData, err error) {
+	hasUV := false
+	for _, polygon := range faces {
+		for _, fi := range polygon {
+			hasUV = hasUV || fi.t >= 0
+		}
+	}
+	v0, v1, v2 := &lin.V3{}, &lin.V3{}, &lin.V3{} // scratch
+	var data *MshData
+	var vmap map[[2]int]int // the unique vertex data points for this mesh.
+	for _, polygon := range faces {
+		for pi := 1; pi < len(polygon)-1; pi++ {
+			tri := [3]objIndex{polygon[0], polygon[pi], polygon[pi+1]}
+			if data == nil || len(data.V)/3+3 > objMaxVerts {
+				data = &MshData{Name: name}
+				if len(meshes) > 0 {
+					data.Name = fmt.Sprintf("%s-%d", name, len(meshes))
+				}
+				vmap = map[[2]int]int{}
+				meshes = append(meshes, data)
 			}
 
-			// cut down the amount of information passed around by reusing points
-			// where the vertex and the texture coordinate information is the same.
-			vertexIndex := fmt.Sprintf("%d/%d", v, t)
-			if _, ok := vmap[vertexIndex]; !ok {
-
-				// add a new data point.
-				vcnt++
-				vmap[vertexIndex] = vcnt
-				data.V = append(data.V, odata.v[v].x, odata.v[v].y, odata.v[v].z)
-				data.N = append(data.N, odata.n[n].x, odata.n[n].y, odata.n[n].z)
-				if t != -1 {
-					data.T = append(data.T, odata.t[t].u, odata.t[t].v)
+			// face normal for points without normals.
+			p0, p1, p2 := odata.v[tri[0].v], odata.v[tri[1].v], odata.v[tri[2].v]
+			v0.SetS(float64(p1.x-p0.x), float64(p1.y-p0.y), float64(p1.z-p0.z))
+			v1.SetS(float64(p2.x-p0.x), float64(p2.y-p0.y), float64(p2.z-p0.z))
+			fn := v2.Cross(v0, v1)
+			for _, fi := range tri {
+				n := dataPoint{float32(fn.X), float32(fn.Y), float32(fn.Z)}
+				if fi.n >= 0 {
+					n = odata.n[fi.n]
 				}
-			} else {
 
-				// update the normal at the vertex to be a combination of
-				// all the normals of each face that shares the vertex.
-				ni := vmap[vertexIndex] * 3
-				n1 := v0.SetS(float64(data.N[ni]), float64(data.N[ni+1]), float64(data.N[ni+2]))
-				n2 := v1.SetS(float64(odata.n[n].x), float64(odata.n[n].y), float64(odata.n[n].z))
-				n2.Add(n2, n1).Unit()
-				data.N[ni], data.N[ni+1], data.N[ni+2] = float32(n2.X), float32(n2.Y), float32(n2.Z)
+				// cut down the amount of information passed around by reusing points
+				// where the vertex and the texture coordinate information is the same.
+				key := [2]int{fi.v, fi.t}
+				vi, ok := vmap[key]
+				if !ok {
+
+					// add a new data point.
+					vi = len(data.V) / 3
+					vmap[key] = vi
+					v := odata.v[fi.v]
+					data.V = append(data.V, v.x, v.y, v.z)
+					data.N = append(data.N, n.x, n.y, n.z)
+					if hasUV {
+						uv := uvPoint{}
+						if fi.t >= 0 {
+							uv = odata.t[fi.t]
+						}
+						data.T = append(data.T, uv.u, uv.v)
+					}
+				} else {
+
+					// update the normal at the vertex to be a combination of
+					// all the normals of each face that shares the vertex.
+					ni := vi * 3
+					data.N[ni], data.N[ni+1], data.N[ni+2] = data.N[ni]+n.x, data.N[ni+1]+n.y, data.N[ni+2]+n.z
+				}
+				data.F = append(data.F, uint16(vi))
 			}
-			data.F = append(data.F, uint16(vmap[vertexIndex]))
 		}
 	}
-	if len(data.V) <= 0 || len(data.F) <= 0 {
-		return fmt.Errorf("Minimally need vertex and face data for %s", name)
+	if len(meshes) <= 0 || len(meshes[0].V) <= 0 || len(meshes[0].F) <= 0 {
+		return meshes, fmt.Errorf("Minimally need vertex and face data for %s", name)
 	}
-	return err
-}
-
-// parseFace turns a face index point string (representing multiple indices)
-// into 3 integer indices. The texture index is optional and is returned with
-// a -1 value if it is not there.
-func parseFaceIndex(findex string) (v, t, n int, err error) {
-	v, t, n = -1, -1, -1
-	if _, err = fmt.Sscanf(findex, "%d//%d", &v, &n); err != nil {
-		if _, err = fmt.Sscanf(findex, "%d/%d/%d", &v, &t, &n); err != nil {
-			return v, t, n, fmt.Errorf("Bad face (%s)\n", findex)
+	for _, data := range meshes {
+		for ni := 0; ni < len(data.N); ni += 3 {
+			n := v0.SetS(float64(data.N[ni]), float64(data.N[ni+1]), float64(data.N[ni+2]))
+			if n.Len() > 0 {
+				n.Unit()
+			}
+			data.N[ni], data.N[ni+1], data.N[ni+2] = float32(n.X), float32(n.Y), float32(n.Z)
 		}
+		data.GenTangents()
+		data.Optimize()
 	}
-	v = int(v - 1)
-	n = int(n - 1) // should all have the same value.
-	if t != -1 {
-		t = int(t - 1)
-	}
-	return
+	return meshes, nil
 }
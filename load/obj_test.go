@@ -4,6 +4,7 @@
 package load
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -55,3 +56,27 @@ func TestLoadObj3(t *testing.T) {
 		t.Error("Improper sizes in block.obj", len(msh.V), len(msh.N), len(msh.F))
 	}
 }
+
+// Objects and groups are split by material. Quads are triangulated
+// and negative indicies count back from the latest verticies.
+func TestObjScene(t *testing.T) {
+	obj := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\n" +
+		"o plane\ng front\nusemtl red\nf -4 -3 -2 -1\n" +
+		"g back\nusemtl blue\nf 4/ 3/ 2/\nusemtl red\nf 1 3 4"
+	scn := &ScnData{}
+	if err := ObjScene(strings.NewReader(obj), scn, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(scn.Nodes) != 3 || scn.Nodes[1].Parent != 0 || scn.Nodes[2].Name != "back" {
+		t.Fatalf("Expected an object with 2 groups, got %v", scn.Nodes)
+	}
+	if len(scn.Meshes) != 3 || len(scn.Nodes[2].Meshes) != 2 {
+		t.Fatalf("Expected 3 meshes, got %d", len(scn.Meshes))
+	}
+	if front := scn.Meshes[0]; len(front.V) != 12 || len(front.F) != 6 || front.Alpha != 1 {
+		t.Errorf("Improper quad sizes %d %d", len(front.V), len(front.F))
+	}
+	if n := scn.Meshes[1].N; n[2] != -1 { // clockwise face.
+		t.Errorf("Expected generated normals, got %v", n)
+	}
+}
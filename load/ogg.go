@@ -0,0 +1,199 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// ogg.go reads Vorbis audio from Ogg containers. The container format is:
+//    https://xiph.org/ogg/doc/framing.html
+// FUTURE: Chained streams. Only the first logical stream is decoded.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Ogg attempts to load Ogg Vorbis audio data into SndData.
+// Samples are decoded to 16 bits.
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import overwrites the data in SndData.
+func Ogg(r io.Reader, d *SndData) (err error) {
+	o := &oggReader{r: bufio.NewReader(r)}
+	v, err := newVorbis(o)
+	if err != nil {
+		return fmt.Errorf("Invalid .ogg audio file: %s", err)
+	}
+	pcm := []byte{}
+	for {
+		packet, err := o.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Corrupt .ogg audio file: %s", err)
+		}
+		samples, err := v.decode(packet)
+		if err != nil {
+			return fmt.Errorf("Corrupt .ogg audio file: %s", err)
+		}
+		pcm = vorbisPCM(pcm, samples)
+	}
+
+	// the last granule position is the number of samples in the stream.
+	if size := o.granule * int64(v.channels) * 2; size >= 0 && size < int64(len(pcm)) {
+		pcm = pcm[:size]
+	}
+	d.Attrs = &SndAttributes{Channels: uint16(v.channels), Frequency: v.rate,
+		DataSize: uint32(len(pcm)), SampleBits: 16}
+	d.Data = pcm
+	return nil
+}
+
+// =============================================================================
+// internal implementation for reading Ogg pages.
+
+// oggStream decodes Ogg Vorbis packets as they are needed. Each packet
+// is held back until the next packet is read so that the last packet
+// can be trimmed to the stream length.
+func oggStream(r io.Reader) (*SndStream, error) {
+	o := &oggReader{r: bufio.NewReader(r)}
+	v, err := newVorbis(o)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid .ogg audio file: %s", err)
+	}
+	var ahead []byte // last decoded packet.
+	size := int64(0) // bytes returned so far.
+	next := func() ([]byte, error) {
+		for {
+			packet, err := o.next()
+			if err == io.EOF {
+				pcm := ahead
+				if end := o.granule*int64(v.channels)*2 - size; end >= 0 && end < int64(len(pcm)) {
+					pcm = pcm[:end]
+				}
+				if ahead = nil; len(pcm) == 0 {
+					return nil, io.EOF
+				}
+				size += int64(len(pcm))
+				return pcm, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Corrupt .ogg audio file: %s", err)
+			}
+			samples, err := v.decode(packet)
+			if err != nil {
+				return nil, fmt.Errorf("Corrupt .ogg audio file: %s", err)
+			}
+			pcm := ahead
+			if ahead = vorbisPCM(nil, samples); len(pcm) > 0 {
+				size += int64(len(pcm))
+				return pcm, nil
+			}
+		}
+	}
+	attrs := &SndAttributes{Channels: uint16(v.channels), Frequency: v.rate, SampleBits: 16}
+	return &SndStream{Attrs: attrs, next: next}, nil
+}
+
+// oggReader returns the packets of the first logical bitstream in an
+// Ogg container. Packets may span pages.
+type oggReader struct {
+	r       io.Reader // Ogg container data.
+	serial  uint32    // Logical bitstream being read.
+	started bool      // True once the first page has been read.
+	eos     bool      // True once the last page has been read.
+	segs    []byte    // Remaining lacing values for the current page.
+	body    []byte    // Remaining data for the current page.
+	granule int64     // Last granule position.
+}
+
+// next returns the next complete packet, or io.EOF at the end of the stream.
+func (o *oggReader) next() ([]byte, error) {
+	packet := []byte{}
+	for {
+		for len(o.segs) > 0 {
+			size := int(o.segs[0])
+			packet = append(packet, o.body[:size]...)
+			o.segs, o.body = o.segs[1:], o.body[size:]
+			if size < 255 {
+				return packet, nil
+			}
+		}
+		if o.eos {
+			return nil, io.EOF
+		}
+		if err := o.page(); err != nil {
+			if err == io.EOF && len(packet) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+}
+
+// page reads the next page of the logical bitstream.
+func (o *oggReader) page() error {
+	for {
+		hdr := make([]byte, 27)
+		if _, err := io.ReadFull(o.r, hdr); err != nil {
+			return err
+		}
+		if string(hdr[:4]) != "OggS" || hdr[4] != 0 {
+			return fmt.Errorf("invalid ogg page")
+		}
+		segs := make([]byte, hdr[26])
+		if _, err := io.ReadFull(o.r, segs); err != nil {
+			return err
+		}
+		size := 0
+		for _, s := range segs {
+			size += int(s)
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(o.r, body); err != nil {
+			return err
+		}
+		crc := binary.LittleEndian.Uint32(hdr[22:])
+		hdr[22], hdr[23], hdr[24], hdr[25] = 0, 0, 0, 0
+		if oggCRC(oggCRC(oggCRC(0, hdr), segs), body) != crc {
+			return fmt.Errorf("bad ogg page checksum")
+		}
+		serial := binary.LittleEndian.Uint32(hdr[14:])
+		if !o.started {
+			o.serial, o.started = serial, true
+		}
+		if serial != o.serial {
+			continue // skip other logical bitstreams.
+		}
+		if granule := int64(binary.LittleEndian.Uint64(hdr[6:])); granule != -1 {
+			o.granule = granule
+		}
+		o.segs, o.body, o.eos = segs, body, hdr[5]&4 != 0
+		return nil
+	}
+}
+
+// oggCRC updates the page checksum with the given data.
+func oggCRC(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// oggCRCTable is the lookup table for the 0x04c11db7 polynomial.
+var oggCRCTable = func() (table [256]uint32) {
+	for cnt := range table {
+		r := uint32(cnt) << 24
+		for bit := 0; bit < 8; bit++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[cnt] = r
+	}
+	return table
+}()
@@ -0,0 +1,679 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// optimize.go improves mesh data for smaller size and faster rendering.
+// DESIGN: Vertex data is remapped one vertex at a time so that all vertex
+//         attributes, including animation blend data, stay in step.
+//         Reorder uses Tom Forsyth's linear speed vertex cache optimization:
+//         https://tomforsyth1000.github.io/papers/fast_vert_cache_opt.html
+//         Simplify uses half edge collapses ordered by quadric error:
+//         http://mgarland.org/files/papers/quadrics.pdf
+//         Collapsing onto an existing vertex means that no new vertex
+//         data needs to be interpolated.
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Optimize welds duplicate vertices and then reorders the mesh for the
+// GPU vertex cache. Imported meshes are optimized as they are loaded.
+func (d *MshData) Optimize() {
+	d.Weld()
+	d.Reorder()
+}
+
+// Weld merges vertices that have identical vertex data.
+func (d *MshData) Weld() { d.F = weld(d.streams(), d.F) }
+
+// Reorder rearranges the triangle faces to reuse recently transformed
+// vertices, then rearranges the vertex data in the order it is used.
+func (d *MshData) Reorder() {
+	ss := d.streams()
+	if validFaces(ss, d.F) {
+		cacheOrder(d.F, vertexCount(ss))
+		d.F = fetchOrder(ss, d.F, false)
+	}
+}
+
+// Simplify reduces the mesh to at most the given number of triangles.
+// Vertices on mesh borders and texture seams are kept so the result
+// may have more triangles than requested. Unused vertices are removed.
+func (d *MshData) Simplify(tris int) {
+	ss := d.streams()
+	if alive := simplify(ss, d.F, tris); alive != nil {
+		d.F = fetchOrder(ss, dropFaces(d.F, alive), true)
+	}
+}
+
+// Optimize welds duplicate vertices and then reorders the mesh for the
+// GPU vertex cache. Faces are kept within their texture mapped ranges.
+func (d *ModData) Optimize() {
+	d.Weld()
+	d.Reorder()
+}
+
+// Weld merges vertices that have identical vertex and blend data.
+func (d *ModData) Weld() { d.F = weld(d.streams(), d.F) }
+
+// Reorder rearranges the triangle faces within each texture mapped
+// range for the GPU vertex cache, then rearranges the vertex and blend
+// data in the order it is used.
+func (d *ModData) Reorder() {
+	ss := d.streams()
+	if validFaces(ss, d.F) {
+		for _, r := range d.faceRanges() {
+			cacheOrder(d.F[r[0]*3:r[1]*3], vertexCount(ss))
+		}
+		d.F = fetchOrder(ss, d.F, false)
+	}
+}
+
+// Simplify reduces the mesh to at most the given number of triangles,
+// updating the texture mapped face ranges. See MshData.Simplify.
+func (d *ModData) Simplify(tris int) {
+	ss := d.streams()
+	alive := simplify(ss, d.F, tris)
+	if alive == nil {
+		return
+	}
+	for cnt, tm := range d.TMap {
+		if int(tm.F0+tm.Fn) <= len(alive) {
+			f0, fn := count(alive[:tm.F0]), count(alive[tm.F0:tm.F0+tm.Fn])
+			d.TMap[cnt].F0, d.TMap[cnt].Fn = uint32(f0), uint32(fn)
+		}
+	}
+	d.F = fetchOrder(ss, dropFaces(d.F, alive), true)
+}
+
+// =============================================================================
+// internal helpers for optimizing meshes.
+
+// vstream is one buffer of per vertex data.
+// Only one of floats or bytes is set.
+type vstream struct {
+	floats *[]float32 // Float vertex data, or nil.
+	bytes  *[]byte    // Byte vertex data, or nil.
+	span   int        // Values per vertex.
+}
+
+// size returns the number of values in the stream.
+func (s vstream) size() int {
+	if s.floats != nil {
+		return len(*s.floats)
+	}
+	return len(*s.bytes)
+}
+
+// key appends the stream values for vertex v.
+func (s vstream) key(b []byte, v int) []byte {
+	if s.bytes != nil {
+		return append(b, (*s.bytes)[v*s.span:(v+1)*s.span]...)
+	}
+	for _, f := range (*s.floats)[v*s.span : (v+1)*s.span] {
+		u := math.Float32bits(f)
+		b = append(b, byte(u), byte(u>>8), byte(u>>16), byte(u>>24))
+	}
+	return b
+}
+
+// remap replaces the stream buffer with the vertex data moved to
+// the new vertex locations. Vertices mapped to -1 are dropped.
+func (s vstream) remap(remap []int, verts int) {
+	if s.bytes != nil {
+		data := make([]byte, verts*s.span)
+		for v, nv := range remap {
+			if nv >= 0 {
+				copy(data[nv*s.span:], (*s.bytes)[v*s.span:(v+1)*s.span])
+			}
+		}
+		*s.bytes = data
+		return
+	}
+	data := make([]float32, verts*s.span)
+	for v, nv := range remap {
+		if nv >= 0 {
+			copy(data[nv*s.span:], (*s.floats)[v*s.span:(v+1)*s.span])
+		}
+	}
+	*s.floats = data
+}
+
+// streams returns the vertex data that has one entry for each vertex.
+// The vertex positions are always first. Partial data is ignored.
+func (d *MshData) streams() []vstream {
+	return matching([]vstream{
+		{floats: &d.V, span: 3}, {floats: &d.N, span: 3},
+		{floats: &d.T, span: 2}, {floats: &d.X, span: 4},
+	})
+}
+
+// streams returns the vertex data, including the blend data,
+// that has one entry for each vertex.
+func (d *ModData) streams() []vstream {
+	return matching(append(d.MshData.streams(),
+		vstream{bytes: &d.Blends, span: 4}, vstream{bytes: &d.Weights, span: 4}))
+}
+
+// matching returns the streams that have data for each vertex position.
+func matching(ss []vstream) []vstream {
+	verts := vertexCount(ss)
+	out := ss[:0]
+	for cnt, s := range ss {
+		if cnt == 0 || s.size() == verts*s.span {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// vertexCount returns the number of vertex positions.
+func vertexCount(ss []vstream) int { return ss[0].size() / 3 }
+
+// faceRanges returns the texture mapped face ranges as first and last+1
+// triangle indicies. The whole mesh is one range if the texture
+// mapping does not fit the faces.
+func (d *ModData) faceRanges() [][2]int {
+	tris := len(d.F) / 3
+	ranges := [][2]int{}
+	for _, tm := range d.TMap {
+		f0, fn := int(tm.F0), int(tm.F0+tm.Fn)
+		if fn > tris || f0 > fn {
+			return [][2]int{{0, tris}}
+		}
+		ranges = append(ranges, [2]int{f0, fn})
+	}
+	if len(ranges) == 0 {
+		ranges = append(ranges, [2]int{0, tris})
+	}
+	return ranges
+}
+
+// validFaces returns true if the faces are triangles that
+// reference existing vertices.
+func validFaces(ss []vstream, f []uint16) bool {
+	verts := vertexCount(ss)
+	for _, vi := range f {
+		if int(vi) >= verts {
+			return false
+		}
+	}
+	return len(f)%3 == 0
+}
+
+// weld merges vertices with identical data, returning the updated faces.
+func weld(ss []vstream, f []uint16) []uint16 {
+	if !validFaces(ss, f) {
+		return f
+	}
+	verts := vertexCount(ss)
+	unique := map[string]int{}
+	remap := make([]int, verts)
+	key := []byte{}
+	for v := 0; v < verts; v++ {
+		key = key[:0]
+		for _, s := range ss {
+			key = s.key(key, v)
+		}
+		if nv, ok := unique[string(key)]; ok {
+			remap[v] = -1 - nv // duplicate of an earlier vertex.
+			continue
+		}
+		remap[v] = len(unique)
+		unique[string(key)] = remap[v]
+	}
+	if len(unique) == verts {
+		return f
+	}
+	for cnt, vi := range f {
+		if nv := remap[vi]; nv < 0 {
+			f[cnt] = uint16(-1 - nv)
+		} else {
+			f[cnt] = uint16(nv)
+		}
+	}
+	for v, nv := range remap {
+		if nv < 0 {
+			remap[v] = -1 // data is already copied from the original.
+		}
+	}
+	for _, s := range ss {
+		s.remap(remap, len(unique))
+	}
+	return f
+}
+
+// fetchOrder renumbers the vertices in the order they are used by the
+// faces. Unused vertices are removed if drop is true, otherwise they
+// follow the used vertices.
+func fetchOrder(ss []vstream, f []uint16, drop bool) []uint16 {
+	verts := vertexCount(ss)
+	remap := make([]int, verts)
+	for v := range remap {
+		remap[v] = -1
+	}
+	next := 0
+	for _, vi := range f {
+		if remap[vi] < 0 {
+			remap[vi] = next
+			next++
+		}
+	}
+	for cnt, vi := range f {
+		f[cnt] = uint16(remap[vi])
+	}
+	for v := range remap {
+		if remap[v] < 0 && !drop {
+			remap[v] = next
+			next++
+		}
+	}
+	for _, s := range ss {
+		s.remap(remap, next)
+	}
+	return f
+}
+
+// dropFaces removes the faces that are no longer alive.
+func dropFaces(f []uint16, alive []bool) []uint16 {
+	out := f[:0]
+	for t, ok := range alive {
+		if ok {
+			out = append(out, f[t*3], f[t*3+1], f[t*3+2])
+		}
+	}
+	return out
+}
+
+// count returns the number of true values.
+func count(flags []bool) (n int) {
+	for _, ok := range flags {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// =============================================================================
+// vertex cache optimization.
+
+// Vertex cache scoring values recommended by Forsyth.
+const (
+	cacheSize         = 32   // Simulated vertex cache size.
+	cacheDecayPower   = 1.5  // Score falloff for older cache entries.
+	lastTriScore      = 0.75 // Score for the last triangle's vertices.
+	valenceBoostScale = 2.0  // Favour vertices with few triangles left...
+	valenceBoostPower = 0.5  // ...so that they get finished.
+)
+
+// vertexScore returns how much a vertex wants its triangles added next.
+func vertexScore(cachePos, remaining int) float64 {
+	if remaining == 0 {
+		return -1
+	}
+	score := 0.0
+	switch {
+	case cachePos < 0: // not in cache.
+	case cachePos < 3:
+		score = lastTriScore
+	default:
+		scaler := 1.0 / (cacheSize - 3)
+		score = math.Pow(1-float64(cachePos-3)*scaler, cacheDecayPower)
+	}
+	return score + valenceBoostScale*math.Pow(float64(remaining), -valenceBoostPower)
+}
+
+// cacheOrder reorders the triangle faces, in place, so that each
+// triangle reuses vertices from recently added triangles.
+func cacheOrder(f []uint16, verts int) {
+	tris := len(f) / 3
+	vtris := make([][]int, verts) // Triangles, not yet added, for each vertex.
+	for t := 0; t < tris; t++ {
+		for _, vi := range f[t*3 : t*3+3] {
+			vtris[vi] = append(vtris[vi], t)
+		}
+	}
+	pos := make([]int, verts)        // Cache position for each vertex.
+	vscore := make([]float64, verts) // Score for each vertex.
+	for v := range pos {
+		pos[v] = -1
+		vscore[v] = vertexScore(-1, len(vtris[v]))
+	}
+	tscore := make([]float64, tris) // Score for each triangle.
+	for t := range tscore {
+		tscore[t] = vscore[f[t*3]] + vscore[f[t*3+1]] + vscore[f[t*3+2]]
+	}
+	added := make([]bool, tris)
+	out := make([]uint16, 0, len(f))
+	cache := make([]int, 0, cacheSize+3)
+	next := make([]int, 0, cacheSize+3)
+	best, unused := -1, 0
+	for len(out) < len(f) {
+		if best < 0 {
+			for added[unused] {
+				unused++ // start again with the next unused triangle.
+			}
+			best = unused
+		}
+		added[best] = true
+		tri := f[best*3 : best*3+3]
+		out = append(out, tri...)
+
+		// move the triangle vertices to the front of the cache.
+		next = next[:0]
+		for cnt, vi := range tri {
+			vtris[vi] = removeInt(vtris[vi], best)
+			if cnt == 0 || (cnt == 1 && vi != tri[0]) || (cnt == 2 && vi != tri[0] && vi != tri[1]) {
+				next = append(next, int(vi))
+			}
+		}
+		for _, v := range cache {
+			if v != int(tri[0]) && v != int(tri[1]) && v != int(tri[2]) {
+				next = append(next, v)
+			}
+		}
+
+		// rescore the cached vertices and their triangles.
+		for cnt, v := range next {
+			pos[v] = -1
+			if cnt < cacheSize {
+				pos[v] = cnt
+			}
+			vscore[v] = vertexScore(pos[v], len(vtris[v]))
+		}
+		best = -1
+		bestScore := 0.0
+		for _, v := range next {
+			for _, t := range vtris[v] {
+				tscore[t] = vscore[f[t*3]] + vscore[f[t*3+1]] + vscore[f[t*3+2]]
+				if best < 0 || tscore[t] > bestScore {
+					best, bestScore = t, tscore[t]
+				}
+			}
+		}
+		if len(next) > cacheSize {
+			next = next[:cacheSize]
+		}
+		cache, next = next, cache
+	}
+	copy(f, out)
+}
+
+// removeInt removes the first occurrence of value from the list.
+func removeInt(list []int, value int) []int {
+	for cnt, v := range list {
+		if v == value {
+			list[cnt] = list[len(list)-1]
+			return list[:len(list)-1]
+		}
+	}
+	return list
+}
+
+// =============================================================================
+// mesh simplification.
+
+// quadric is the symmetric 4x4 error matrix for a set of planes.
+// Stored as the upper triangle: a2, ab, ac, ad, b2, bc, bd, c2, cd, d2.
+type quadric [10]float64
+
+// addPlane adds the weighted plane ax+by+cz+d=0 to the quadric.
+func (q *quadric) addPlane(a, b, c, d, weight float64) {
+	q[0] += a * a * weight
+	q[1] += a * b * weight
+	q[2] += a * c * weight
+	q[3] += a * d * weight
+	q[4] += b * b * weight
+	q[5] += b * c * weight
+	q[6] += b * d * weight
+	q[7] += c * c * weight
+	q[8] += c * d * weight
+	q[9] += d * d * weight
+}
+
+// add combines two quadrics.
+func (q *quadric) add(o *quadric) {
+	for cnt := range q {
+		q[cnt] += o[cnt]
+	}
+}
+
+// eval returns the squared distance error for the point p.
+func (q *quadric) eval(p *lin.V3) float64 {
+	x, y, z := p.X, p.Y, p.Z
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z + q[9]
+}
+
+// collapse is a candidate move of vertex a onto vertex b.
+type collapse struct {
+	cost   float64 // Quadric error.
+	a, b   int     // Vertex indicies.
+	va, vb int     // Vertex versions when the cost was calculated.
+}
+
+// collapses is a min heap of collapse costs.
+type collapses []*collapse
+
+func (cs collapses) Len() int            { return len(cs) }
+func (cs collapses) Less(i, j int) bool  { return cs[i].cost < cs[j].cost }
+func (cs collapses) Swap(i, j int)       { cs[i], cs[j] = cs[j], cs[i] }
+func (cs *collapses) Push(x interface{}) { *cs = append(*cs, x.(*collapse)) }
+func (cs *collapses) Pop() interface{} {
+	old := *cs
+	c := old[len(old)-1]
+	*cs = old[:len(old)-1]
+	return c
+}
+
+// simplifier holds the state for collapsing mesh edges.
+type simplifier struct {
+	p       []lin.V3   // Vertex positions.
+	f       []uint16   // Triangle faces, updated as vertices collapse.
+	alive   []bool     // Faces that remain.
+	vfaces  [][]int    // Faces for each vertex.
+	q       []quadric  // Error quadric for each vertex.
+	locked  []bool     // Border and seam vertices that can't move.
+	dead    []bool     // Vertices that have been collapsed.
+	version []int      // Incremented when vertex data changes.
+	heap    *collapses // Candidates ordered by cost.
+}
+
+// simplify collapses edges until the number of triangles is at or below
+// the target. Faces are updated in place. The returned flags mark the
+// faces that remain, or nil if nothing was changed.
+func simplify(ss []vstream, f []uint16, target int) []bool {
+	tris := len(f) / 3
+	if !validFaces(ss, f) || tris <= target {
+		return nil
+	}
+	verts := vertexCount(ss)
+	s := &simplifier{
+		p:       make([]lin.V3, verts),
+		f:       f,
+		alive:   make([]bool, tris),
+		vfaces:  make([][]int, verts),
+		q:       make([]quadric, verts),
+		locked:  make([]bool, verts),
+		dead:    make([]bool, verts),
+		version: make([]int, verts),
+		heap:    &collapses{},
+	}
+	for v := range s.p {
+		vertex(*ss[0].floats, v, &s.p[v])
+	}
+
+	// edges that are not shared by exactly two faces are borders or
+	// texture seams. Their vertices are locked in place.
+	edges := map[[2]uint16]int{}
+	n := &lin.V3{}
+	for t := 0; t < tris; t++ {
+		s.alive[t] = true
+		tri := f[t*3 : t*3+3]
+		for cnt, vi := range tri {
+			s.vfaces[vi] = append(s.vfaces[vi], t)
+			a, b := vi, tri[(cnt+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			edges[[2]uint16{a, b}]++
+		}
+		if s.normal(tri, n) {
+			area := n.Len()
+			n.Unit()
+			plane := -n.Dot(&s.p[tri[0]])
+			for _, vi := range tri {
+				s.q[vi].addPlane(n.X, n.Y, n.Z, plane, area)
+			}
+		}
+	}
+	for e, cnt := range edges {
+		if cnt != 2 {
+			s.locked[e[0]], s.locked[e[1]] = true, true
+		}
+	}
+	for t := 0; t < tris; t++ {
+		tri := f[t*3 : t*3+3]
+		for cnt := range tri {
+			s.push(int(tri[cnt]), int(tri[(cnt+1)%3]))
+			s.push(int(tri[(cnt+1)%3]), int(tri[cnt]))
+		}
+	}
+
+	// collapse the cheapest edges first.
+	for tris > target && s.heap.Len() > 0 {
+		c := heap.Pop(s.heap).(*collapse)
+		if s.dead[c.a] || s.dead[c.b] || c.va != s.version[c.a] || c.vb != s.version[c.b] {
+			continue // stale candidate.
+		}
+		if s.valid(c.a, c.b) {
+			tris -= s.collapse(c.a, c.b)
+		}
+	}
+	return s.alive
+}
+
+// normal sets n to the triangle normal scaled by twice the triangle area.
+// Returns false for degenerate triangles.
+func (s *simplifier) normal(tri []uint16, n *lin.V3) bool {
+	e1, e2 := &lin.V3{}, &lin.V3{}
+	e1.Sub(&s.p[tri[1]], &s.p[tri[0]])
+	e2.Sub(&s.p[tri[2]], &s.p[tri[0]])
+	return n.Cross(e1, e2).Len() > lin.Epsilon*lin.Epsilon
+}
+
+// push adds the collapse of vertex a onto vertex b as a candidate.
+func (s *simplifier) push(a, b int) {
+	if a == b || s.locked[a] {
+		return
+	}
+	q := s.q[a]
+	q.add(&s.q[b])
+	c := &collapse{cost: q.eval(&s.p[b]), a: a, b: b, va: s.version[a], vb: s.version[b]}
+	heap.Push(s.heap, c)
+}
+
+// valid returns true if vertex a can collapse onto vertex b without
+// changing the mesh topology or flipping any triangles.
+func (s *simplifier) valid(a, b int) bool {
+	shared := 0
+	na := map[int]bool{} // neighbours of a.
+	for _, t := range s.vfaces[a] {
+		if !s.alive[t] {
+			continue
+		}
+		tri := s.f[t*3 : t*3+3]
+		hasB := false
+		for _, vi := range tri {
+			na[int(vi)] = true
+			hasB = hasB || int(vi) == b
+		}
+		if hasB {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return false // no longer neighbours.
+	}
+	common := 0
+	nb := map[int]bool{} // neighbours of b.
+	for _, t := range s.vfaces[b] {
+		if s.alive[t] {
+			for _, vi := range s.f[t*3 : t*3+3] {
+				if int(vi) != a && int(vi) != b && na[int(vi)] && !nb[int(vi)] {
+					common++
+				}
+				nb[int(vi)] = true
+			}
+		}
+	}
+	if common != shared {
+		return false // would create non-manifold edges.
+	}
+
+	// check that the remaining faces around a keep their orientation.
+	before, after := &lin.V3{}, &lin.V3{}
+	moved := make([]uint16, 3)
+	for _, t := range s.vfaces[a] {
+		tri := s.f[t*3 : t*3+3]
+		if !s.alive[t] || int(tri[0]) == b || int(tri[1]) == b || int(tri[2]) == b {
+			continue
+		}
+		for cnt, vi := range tri {
+			moved[cnt] = vi
+			if int(vi) == a {
+				moved[cnt] = uint16(b)
+			}
+		}
+		if s.normal(tri, before) && (!s.normal(moved, after) || before.Dot(after) <= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// collapse moves vertex a onto vertex b, returning the number of faces removed.
+func (s *simplifier) collapse(a, b int) (removed int) {
+	for _, t := range s.vfaces[a] {
+		if !s.alive[t] {
+			continue
+		}
+		tri := s.f[t*3 : t*3+3]
+		if int(tri[0]) == b || int(tri[1]) == b || int(tri[2]) == b {
+			s.alive[t] = false
+			removed++
+			continue
+		}
+		for cnt, vi := range tri {
+			if int(vi) == a {
+				tri[cnt] = uint16(b)
+			}
+		}
+		s.vfaces[b] = append(s.vfaces[b], t)
+	}
+	s.q[b].add(&s.q[a])
+	s.dead[a] = true
+	s.vfaces[a] = nil
+	s.version[a]++
+	s.version[b]++
+
+	// the cost of moving to or from b has changed.
+	faces := s.vfaces[b][:0]
+	for _, t := range s.vfaces[b] {
+		if s.alive[t] {
+			faces = append(faces, t)
+			for _, vi := range s.f[t*3 : t*3+3] {
+				s.push(int(vi), b)
+				s.push(b, int(vi))
+			}
+		}
+	}
+	s.vfaces[b] = faces
+	return removed
+}
@@ -0,0 +1,127 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// grid creates a flat uv mapped square of size x size quads
+// facing +Z with shuffled triangles.
+func grid(size int, shuffle bool) *MshData {
+	msh := &MshData{}
+	for y := 0; y <= size; y++ {
+		for x := 0; x <= size; x++ {
+			msh.V = append(msh.V, float32(x), float32(y), 0)
+			msh.T = append(msh.T, float32(x)/float32(size), float32(y)/float32(size))
+		}
+	}
+	row := uint16(size + 1)
+	tris := [][3]uint16{}
+	for y := uint16(0); y < uint16(size); y++ {
+		for x := uint16(0); x < uint16(size); x++ {
+			v := y*row + x
+			tris = append(tris, [3]uint16{v, v + 1, v + row + 1}, [3]uint16{v, v + row + 1, v + row})
+		}
+	}
+	if shuffle {
+		random := rand.New(rand.NewSource(1))
+		random.Shuffle(len(tris), func(i, j int) { tris[i], tris[j] = tris[j], tris[i] })
+	}
+	for _, tri := range tris {
+		msh.F = append(msh.F, tri[:]...)
+	}
+	return msh
+}
+
+// cacheMisses counts the vertex transforms for a FIFO vertex cache.
+func cacheMisses(f []uint16, size int) (misses int) {
+	fifo := []uint16{}
+	for _, vi := range f {
+		hit := false
+		for _, cached := range fifo {
+			hit = hit || cached == vi
+		}
+		if !hit {
+			misses++
+			if fifo = append(fifo, vi); len(fifo) > size {
+				fifo = fifo[1:]
+			}
+		}
+	}
+	return misses
+}
+
+func TestWeld(t *testing.T) {
+	msh := &MshData{
+		V: []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 0, 0, 1, 1, 0, 0, 1, 0},
+		T: []float32{0, 0, 1, 0, 1, 1, 0, 0, 1, 1, 0, 1},
+		F: []uint16{0, 1, 2, 3, 4, 5},
+	}
+	msh.Weld()
+	if len(msh.V) != 12 || len(msh.T) != 8 {
+		t.Fatalf("Expected 4 welded verticies got %d", len(msh.V)/3)
+	}
+	if msh.F[3] != 0 || msh.F[4] != 2 || msh.F[5] != 3 {
+		t.Errorf("Expected faces to use welded verticies got %v", msh.F)
+	}
+
+	// different blend data keeps the vertices apart.
+	mod := &ModData{MshData: MshData{
+		V: []float32{0, 0, 0, 1, 0, 0, 0, 0, 0},
+		F: []uint16{0, 1, 2},
+	}}
+	mod.Blends = []byte{1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0}
+	mod.Weights = []byte{255, 0, 0, 0, 255, 0, 0, 0, 255, 0, 0, 0}
+	mod.Weld()
+	if len(mod.V) != 9 || len(mod.Blends) != 12 {
+		t.Errorf("Expected blended verticies to remain got %d", len(mod.V)/3)
+	}
+}
+
+func TestReorder(t *testing.T) {
+	msh := grid(16, true)
+	before := cacheMisses(msh.F, 16)
+	msh.V = append(msh.V, 9, 9, 9) // unused vertex.
+	msh.T = append(msh.T, 0.5, 0.5)
+	msh.Reorder()
+	after := cacheMisses(msh.F, 16)
+	if after >= before {
+		t.Errorf("Expected fewer cache misses %d >= %d", after, before)
+	}
+	if msh.F[0] != 0 || msh.F[1] != 1 || msh.F[2] != 2 {
+		t.Errorf("Expected verticies in first use order got %v", msh.F[:3])
+	}
+	if verts := len(msh.V) / 3; verts != 17*17+1 || msh.V[verts*3-1] != 9 {
+		t.Errorf("Expected unused vertex to be kept at end")
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	msh := grid(8, false)
+	tris := len(msh.F) / 3
+	msh.Simplify(tris / 4)
+	if got := len(msh.F) / 3; got >= tris || got < 28 {
+		t.Errorf("Expected %d triangles to reduce to at least the border %d", tris, got)
+	}
+	if len(msh.V)/3 >= 81 || len(msh.T)/2 != len(msh.V)/3 {
+		t.Errorf("Expected unused verticies to be removed")
+	}
+	for cnt := 0; cnt < len(msh.F); cnt += 3 {
+		n := &MshData{V: msh.V, F: msh.F[cnt : cnt+3]}
+		n.GenNormals()
+		if vi := msh.F[cnt] * 3; n.N[vi+2] != 1 {
+			t.Errorf("Expected face %d to face +Z", cnt/3)
+		}
+	}
+
+	// texture ranges are updated for the removed faces.
+	mod := &ModData{MshData: *grid(8, false)}
+	mod.TMap = []TexMap{{Name: "a", F0: 0, Fn: 64}, {Name: "b", F0: 64, Fn: 64}}
+	mod.Simplify(32)
+	if tm := mod.TMap; tm[0].F0 != 0 || tm[1].F0 != tm[0].Fn || int(tm[1].F0+tm[1].Fn) != len(mod.F)/3 {
+		t.Errorf("Texture ranges do not match faces %v %d", tm, len(mod.F)/3)
+	}
+}
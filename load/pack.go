@@ -0,0 +1,270 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// pack.go reads and writes asset pack files.
+// DESIGN: A pack file is the asset files followed by an index so that
+//         a pack can be written in one pass. Mounted packs are opened
+//         once and resources are read directly from the pack file
+//         at the indexed offsets. The layout is little endian:
+//            header : "VUPK", version uint32.
+//            data   : asset file bytes, optionally deflated.
+//            index  : entry count uint32 followed by the entries:
+//                     name length uint16, name, offset uint64,
+//                     stored size uint64, size uint64, method uint8.
+//            footer : index offset uint64.
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PackWriter creates a pack file. Files are added to the pack using
+// the Locator directory names, ie: "models/cube.obj". Close must be
+// called after the last file is added to complete the pack.
+type PackWriter struct {
+	w       io.Writer   // Destination for the pack file.
+	at      uint64      // Current write offset.
+	entries []packEntry // Index of added files.
+	err     error       // First write error.
+}
+
+// NewPackWriter starts a pack file on the given Writer. The Writer is
+// expected to be opened and closed by the caller.
+func NewPackWriter(w io.Writer) *PackWriter {
+	pw := &PackWriter{w: w}
+	hdr := make([]byte, packHeaderSize)
+	copy(hdr, packMagic)
+	binary.LittleEndian.PutUint32(hdr[4:], packVersion)
+	pw.write(hdr)
+	return pw
+}
+
+// Add the named file data to the pack. Compressed data is smaller
+// on disk and takes longer to load. Already compressed assets,
+// like PNG images, are best left uncompressed.
+func (pw *PackWriter) Add(name string, data []byte, compress bool) error {
+	e := packEntry{name: name, offset: pw.at, size: uint64(len(data)), method: packStore}
+	if compress {
+		buff := &bytes.Buffer{}
+		zw, _ := flate.NewWriter(buff, flate.BestCompression)
+		zw.Write(data)
+		zw.Close()
+		if buff.Len() < len(data) {
+			data, e.method = buff.Bytes(), packDeflate
+		}
+	}
+	e.stored = uint64(len(data))
+	if pw.write(data); pw.err == nil {
+		pw.entries = append(pw.entries, e)
+	}
+	return pw.err
+}
+
+// Close writes the pack index. The Writer itself is not closed.
+func (pw *PackWriter) Close() error {
+	index := pw.at
+	buff := &bytes.Buffer{}
+	binary.Write(buff, binary.LittleEndian, uint32(len(pw.entries)))
+	for _, e := range pw.entries {
+		binary.Write(buff, binary.LittleEndian, uint16(len(e.name)))
+		buff.WriteString(e.name)
+		binary.Write(buff, binary.LittleEndian, []uint64{e.offset, e.stored, e.size})
+		buff.WriteByte(e.method)
+	}
+	binary.Write(buff, binary.LittleEndian, index)
+	pw.write(buff.Bytes())
+	return pw.err
+}
+
+// write tracks the write offset and the first write error.
+func (pw *PackWriter) write(data []byte) {
+	if pw.err == nil {
+		var n int
+		n, pw.err = pw.w.Write(data)
+		pw.at += uint64(n)
+	}
+}
+
+// PackDir creates the named pack file from all the files in the given
+// directory and its sub-directories. Pack names are relative to the
+// directory, ie: "assets/models/cube.obj" is "models/cube.obj"
+// when packing the directory "assets".
+func PackDir(pak, dir string, compress bool) (err error) {
+	f, err := os.Create(pak)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	pw := NewPackWriter(f)
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Clean(file) == filepath.Clean(pak) {
+			return err
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return pw.Add(filepath.ToSlash(name), data, compress)
+	})
+	if err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// Pack file layout constants.
+const (
+	packMagic      = "VUPK"
+	packVersion    = 1
+	packHeaderSize = 8 // magic, version.
+	packFooterSize = 8 // index offset.
+	packStore      = 0 // Uncompressed entry.
+	packDeflate    = 1 // Deflate compressed entry.
+)
+
+// packEntry is one indexed file in a pack.
+type packEntry struct {
+	name   string // Resource name.
+	offset uint64 // Location of the data in the pack.
+	stored uint64 // Data size in the pack.
+	size   uint64 // Uncompressed data size.
+	method uint8  // packStore or packDeflate.
+}
+
+// pack is a mounted pack file. An unmounted pack file is closed
+// once the resource readers that are still in use are closed.
+type pack struct {
+	name    string               // Mount name, ie: pack file name.
+	r       io.ReaderAt          // Pack data.
+	closer  io.Closer            // Closes the pack file. Optional.
+	entries map[string]packEntry // Index of resources by name.
+
+	mutex   sync.Mutex // Readers are used on multiple goroutines.
+	readers int        // Number of open resource readers.
+	unmount bool       // True once the pack is unmounted.
+}
+
+// openPack reads the pack index from pack data of the given size.
+func openPack(name string, r io.ReaderAt, size int64) (*pack, error) {
+	hdr, ftr := make([]byte, packHeaderSize), make([]byte, packFooterSize)
+	if size < packHeaderSize+packFooterSize {
+		return nil, fmt.Errorf("not a pack file")
+	}
+	if _, err := r.ReadAt(hdr, 0); err != nil {
+		return nil, fmt.Errorf("invalid pack header %s", err)
+	}
+	if string(hdr[:4]) != packMagic {
+		return nil, fmt.Errorf("not a pack file")
+	}
+	if version := binary.LittleEndian.Uint32(hdr[4:]); version != packVersion {
+		return nil, fmt.Errorf("unsupported pack version %d", version)
+	}
+	if _, err := r.ReadAt(ftr, size-packFooterSize); err != nil {
+		return nil, fmt.Errorf("invalid pack footer %s", err)
+	}
+	index := int64(binary.LittleEndian.Uint64(ftr))
+	if index < packHeaderSize || index > size-packFooterSize {
+		return nil, fmt.Errorf("invalid pack index offset %d", index)
+	}
+	ir := io.NewSectionReader(r, index, size-packFooterSize-index)
+	var cnt uint32
+	if err := binary.Read(ir, binary.LittleEndian, &cnt); err != nil {
+		return nil, fmt.Errorf("invalid pack index %s", err)
+	}
+	pk := &pack{name: name, r: r, entries: map[string]packEntry{}}
+	for ; cnt > 0; cnt-- {
+		var nlen uint16
+		if err := binary.Read(ir, binary.LittleEndian, &nlen); err != nil {
+			return nil, fmt.Errorf("invalid pack index %s", err)
+		}
+		ename := make([]byte, nlen)
+		sizes := make([]uint64, 3)
+		method := make([]byte, 1)
+		if _, err := io.ReadFull(ir, ename); err != nil {
+			return nil, fmt.Errorf("invalid pack index %s", err)
+		}
+		if err := binary.Read(ir, binary.LittleEndian, sizes); err != nil {
+			return nil, fmt.Errorf("invalid pack index %s", err)
+		}
+		if _, err := io.ReadFull(ir, method); err != nil {
+			return nil, fmt.Errorf("invalid pack index %s", err)
+		}
+		e := packEntry{name: string(ename), offset: sizes[0], stored: sizes[1], size: sizes[2], method: method[0]}
+		pk.entries[e.name] = e
+	}
+	return pk, nil
+}
+
+// label returns the mount name.
+func (pk *pack) label() string { return pk.name }
+
+// close releases the pack file, if any, once there are no open readers.
+func (pk *pack) close() {
+	pk.mutex.Lock()
+	defer pk.mutex.Unlock()
+	pk.unmount = true
+	if pk.readers == 0 && pk.closer != nil {
+		pk.closer.Close()
+	}
+}
+
+// release is called when a resource reader is closed.
+// Close the pack file if it was unmounted while the reader was open.
+func (pk *pack) release() {
+	pk.mutex.Lock()
+	defer pk.mutex.Unlock()
+	if pk.readers--; pk.readers == 0 && pk.unmount && pk.closer != nil {
+		pk.closer.Close()
+	}
+}
+
+// open returns a reader for the named resource.
+func (pk *pack) open(name string) (io.ReadCloser, bool) {
+	e, ok := pk.entries[name]
+	if !ok {
+		return nil, false
+	}
+	pk.mutex.Lock()
+	defer pk.mutex.Unlock()
+	if pk.unmount {
+		return nil, false
+	}
+	pk.readers++
+	data := io.NewSectionReader(pk.r, int64(e.offset), int64(e.stored))
+	if e.method == packDeflate {
+		return &packReader{ReadCloser: flate.NewReader(data), pk: pk}, true
+	}
+	return &packReader{ReadCloser: ioutil.NopCloser(data), pk: pk}, true
+}
+
+// packReader reads one pack resource. It keeps the pack file
+// open until the reader is closed.
+type packReader struct {
+	io.ReadCloser
+	pk   *pack     // Pack being read.
+	once sync.Once // Release the pack once.
+}
+
+// Close the resource reader and release the pack.
+func (pr *packReader) Close() error {
+	err := pr.ReadCloser.Close()
+	pr.once.Do(pr.pk.release)
+	return err
+}
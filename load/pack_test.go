@@ -0,0 +1,112 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Stored and compressed files are read back from a pack.
+func TestPack(t *testing.T) {
+	buff := &bytes.Buffer{}
+	pw := NewPackWriter(buff)
+	text := strings.Repeat("vertex data ", 100)
+	pw.Add("models/a.obj", []byte(text), true)
+	pw.Add("source/b.txt", []byte("short"), false)
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	pk, err := openPack("test", bytes.NewReader(buff.Bytes()), int64(buff.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := pk.entries["models/a.obj"]; e.method != packDeflate || e.stored >= e.size {
+		t.Errorf("Expected compressed entry, got %+v", e)
+	}
+	for name, want := range map[string]string{"models/a.obj": text, "source/b.txt": "short"} {
+		rc, ok := pk.open(name)
+		if !ok {
+			t.Fatalf("Expected %s in pack", name)
+		}
+		got, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if string(got) != want {
+			t.Errorf("Expected %s contents, got %d bytes", name, len(got))
+		}
+	}
+	if _, err := openPack("bad", bytes.NewReader([]byte("not a pack file!")), 16); err == nil {
+		t.Error("Expected invalid pack error")
+	}
+}
+
+// Mounted packs are found by the Locator before disk files.
+func TestMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "source"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "source", "packed.txt"), []byte("packed"), 0644)
+	pak := filepath.Join(dir, "assets.pak")
+	if err := PackDir(pak, dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Mount(pak); err != nil {
+		t.Fatal(err)
+	}
+	defer Unmount(pak)
+	rc, err := NewLocator().GetResource("packed.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if got, _ := ioutil.ReadAll(rc); string(got) != "packed" {
+		t.Errorf("Expected packed contents, got %s", got)
+	}
+}
+
+// Resources being read when their pack is unmounted can still be read.
+func TestUnmountReading(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "source"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "source", "packed.txt"), []byte("packed"), 0644)
+	pak := filepath.Join(dir, "assets.pak")
+	if err := PackDir(pak, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := Mount(pak); err != nil {
+		t.Fatal(err)
+	}
+	pk := mounts.list[len(mounts.list)-1].(*pack)
+	rc, err := NewLocator().GetResource("packed.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := make([]byte, 1)
+	if _, err := rc.Read(start); err != nil {
+		t.Fatal(err)
+	}
+	Unmount(pak)
+	if got, err := ioutil.ReadAll(rc); err != nil || string(start)+string(got) != "packed" {
+		t.Errorf("Expected packed contents after unmount, got %s %v", got, err)
+	}
+	if _, err := pk.closer.(*os.File).Stat(); err != nil {
+		t.Errorf("Expected pack file open while reading, got %s", err)
+	}
+	rc.Close()
+	rc.Close() // closing twice only releases the pack once.
+	if _, err := pk.closer.(*os.File).Stat(); err == nil {
+		t.Errorf("Expected pack file closed after reading")
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// sdf.go generates signed distance field font textures. Distance fields
+// store the distance to the nearest glyph edge instead of coverage, so
+// text stays sharp when scaled and shaders can cheaply add outlines
+// and glows. Field values are 0.5 on the glyph edge, increasing inside
+// the glyph and decreasing outside. See:
+//    http://www.valvesoftware.com/publications/2007/SIGGRAPH2007_AlphaTestedMagnification.pdf
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// SdfFont renders the given characters from a TrueType font into a
+// distance field texture atlas. Characters that are not in the font are
+// skipped. Each character image is a full line high, with spread pixels
+// of padding on all sides. The character mapping data, relative to the
+// bottom left of the line, is written to fnt.
+//    size  : em square size in pixels.
+//    spread: pixel distance covered by the field on either side of an edge.
+func SdfFont(ttf *TtfData, chars []rune, size, spread int, fnt *FntData, img *ImgData) {
+	scale := float32(size) / float32(ttf.UnitsPerEm)
+	descent := float32(math.Floor(float64(float32(ttf.Descent) * scale)))
+	lineH := int(math.Ceil(float64(float32(ttf.Ascent)*scale-descent))) + 2*spread
+	glyphs, mapped := []*TtfGlyph{}, []ChrData{}
+	for _, r := range chars {
+		if g, ok := ttf.Glyph(r); ok {
+			ch := ChrData{Char: r, Xa: int(float32(g.Advance)*scale + 0.5)}
+			if len(g.Contours) > 0 {
+				ch.Xo = int(math.Floor(float64(float32(g.Xmin)*scale))) - spread
+				ch.W = int(math.Ceil(float64(float32(g.Xmax)*scale))) + spread - ch.Xo
+				ch.H, ch.Yo = lineH, -spread
+			}
+			glyphs, mapped = append(glyphs, g), append(mapped, ch)
+		}
+	}
+
+	// pack the character images in rows, growing the atlas until it is
+	// at least as wide as it is high.
+	const gap = 1 // pixels between characters.
+	w, h := 64, 0
+	for ; ; w *= 2 {
+		x, y, fits := 0, 0, true
+		for cnt := range mapped {
+			ch := &mapped[cnt]
+			if ch.W == 0 {
+				continue
+			}
+			if x+ch.W > w {
+				x, y = 0, y+lineH+gap
+			}
+			ch.X, ch.Y, x = x, y, x+ch.W+gap
+			fits = fits && ch.W <= w
+		}
+		if h = y + lineH; fits && h <= w {
+			break
+		}
+	}
+	fnt.W, fnt.H, fnt.Chars = w, 1, mapped
+	for fnt.H < h {
+		fnt.H *= 2
+	}
+
+	// render the distance to each glyph outline.
+	atlas := image.NewNRGBA(image.Rect(0, 0, fnt.W, fnt.H))
+	for cnt, ch := range fnt.Chars {
+		if ch.W == 0 {
+			continue
+		}
+		segs := [][]float32{}
+		for _, contour := range glyphs[cnt].Contours {
+			line := make([]float32, len(contour))
+			for i := 0; i+1 < len(contour); i += 2 {
+				line[i] = contour[i]*scale - float32(ch.Xo)
+				line[i+1] = float32(lineH-spread) + descent - contour[i+1]*scale
+			}
+			segs = append(segs, line)
+		}
+		for y := 0; y < ch.H; y++ {
+			for x := 0; x < ch.W; x++ {
+				d := sdfDistance(segs, float32(x)+0.5, float32(y)+0.5)
+				a := lin.Clamp(0.5+float64(d)/float64(2*spread), 0, 1)
+				atlas.SetNRGBA(ch.X+x, ch.Y+y, color.NRGBA{255, 255, 255, uint8(a*255 + 0.5)})
+			}
+		}
+	}
+	img.Img = atlas
+}
+
+// Sdf converts a bitmapped image into a distance field image of the same
+// size. Pixels are inside when their coverage is at least half. Coverage
+// is the alpha value, or the brightness for images without transparency.
+// The spread is the pixel distance covered by the field on either side
+// of an edge and should be no more than the spacing between the
+// characters of a bitmapped font.
+func Sdf(img image.Image, spread int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	alpha, lum := make([]float64, w*h), make([]float64, w*h)
+	opaque := true
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA64Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA64)
+			alpha[y*w+x] = float64(c.A) / 0xffff
+			lum[y*w+x] = (0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)) / 0xffff
+			opaque = opaque && c.A == 0xffff
+		}
+	}
+	if opaque {
+		alpha = lum
+	}
+	inside := make([]bool, w*h)
+	for cnt, a := range alpha {
+		inside[cnt] = a >= 0.5
+	}
+	toInside := sdfTransform(inside, w, h, true)
+	toOutside := sdfTransform(inside, w, h, false)
+	sdf := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for cnt, in := range inside {
+		d := 0.5 - math.Sqrt(toInside[cnt]) // distance outside the edge.
+		if in {
+			d = math.Sqrt(toOutside[cnt]) - 0.5
+		}
+		a := lin.Clamp(0.5+d/float64(2*spread), 0, 1)
+		sdf.Pix[cnt*4+0], sdf.Pix[cnt*4+1], sdf.Pix[cnt*4+2] = 255, 255, 255
+		sdf.Pix[cnt*4+3] = uint8(a*255 + 0.5)
+	}
+	return sdf
+}
+
+// =============================================================================
+// internal implementation for distance fields.
+
+// sdfDistance returns the signed distance from the point to the closed
+// outlines. Distances are positive inside using the non-zero winding rule.
+func sdfDistance(outlines [][]float32, px, py float32) float32 {
+	best, winding := float32(math.MaxFloat32), 0
+	for _, line := range outlines {
+		n := len(line) / 2
+		for i := 0; i < n; i++ {
+			j := (i + 1) % n
+			x0, y0, x1, y1 := line[2*i], line[2*i+1], line[2*j], line[2*j+1]
+
+			// distance to the closest point on the segment.
+			dx, dy := x1-x0, y1-y0
+			t := float32(0)
+			if l := dx*dx + dy*dy; l > 0 {
+				t = float32(lin.Clamp(float64(((px-x0)*dx+(py-y0)*dy)/l), 0, 1))
+			}
+			ex, ey := px-(x0+t*dx), py-(y0+t*dy)
+			if d := ex*ex + ey*ey; d < best {
+				best = d
+			}
+
+			// count crossings of a ray towards +x.
+			if (y0 <= py) != (y1 <= py) {
+				if x0+(py-y0)/(y1-y0)*dx > px {
+					if y1 > y0 {
+						winding++
+					} else {
+						winding--
+					}
+				}
+			}
+		}
+	}
+	dist := float32(math.Sqrt(float64(best)))
+	if winding != 0 {
+		return dist
+	}
+	return -dist
+}
+
+// sdfTransform returns the squared distance from each pixel to the nearest
+// pixel that matches target using the exact Euclidean distance transform.
+// See: http://cs.brown.edu/people/pfelzens/papers/dt-final.pdf
+func sdfTransform(inside []bool, w, h int, target bool) []float64 {
+	const far = 1e20
+	grid := make([]float64, w*h)
+	for cnt, in := range inside {
+		if in != target {
+			grid[cnt] = far
+		}
+	}
+	n := w
+	if h > n {
+		n = h
+	}
+	f, d := make([]float64, n), make([]float64, n)
+	v, z := make([]int, n), make([]float64, n+1)
+	for x := 0; x < w; x++ { // columns.
+		for y := 0; y < h; y++ {
+			f[y] = grid[y*w+x]
+		}
+		sdfTransform1D(f[:h], d[:h], v, z)
+		for y := 0; y < h; y++ {
+			grid[y*w+x] = d[y]
+		}
+	}
+	for y := 0; y < h; y++ { // rows.
+		copy(f[:w], grid[y*w:y*w+w])
+		sdfTransform1D(f[:w], d[:w], v, z)
+		copy(grid[y*w:y*w+w], d[:w])
+	}
+	return grid
+}
+
+// sdfTransform1D is the one dimensional squared distance transform
+// using the lower envelope of parabolas rooted at each sample.
+func sdfTransform1D(f, d []float64, v []int, z []float64) {
+	k := 0
+	v[0], z[0], z[1] = 0, math.Inf(-1), math.Inf(1)
+	intersect := func(q, r int) float64 {
+		return ((f[q] + float64(q*q)) - (f[r] + float64(r*r))) / float64(2*q-2*r)
+	}
+	for q := 1; q < len(f); q++ {
+		s := intersect(q, v[k])
+		for s <= z[k] {
+			k--
+			s = intersect(q, v[k])
+		}
+		k++
+		v[k], z[k], z[k+1] = q, s, math.Inf(1)
+	}
+	k = 0
+	for q := range f {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		r := v[k]
+		d[q] = float64((q-r)*(q-r)) + f[r]
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Glyph outlines render as distances inside and outside the edges.
+func TestSdfFont(t *testing.T) {
+	ttf := &TtfData{}
+	if err := Ttf(bytes.NewReader(ttfFont()), ttf); err != nil {
+		t.Fatal(err)
+	}
+	fnt, img := &FntData{}, &ImgData{}
+	SdfFont(ttf, []rune("A C"), 200, 4, fnt, img)
+	if len(fnt.Chars) != 2 || fnt.W != 256 || fnt.H != 256 {
+		t.Fatalf("Expected 2 chars in a 256 atlas got %d %d %d", len(fnt.Chars), fnt.W, fnt.H)
+	}
+	a := fnt.Chars[0]
+	if a.Char != 'A' || a.Xo != 16 || a.W != 88 || a.H != 208 || a.Yo != -4 || a.Xa != 120 {
+		t.Errorf("Bad character mapping %+v", a)
+	}
+	atlas := img.Img.(*image.NRGBA)
+	alpha := func(x, y int) uint8 { return atlas.NRGBAAt(a.X+x, a.Y+y).A }
+	if alpha(44, 124) != 255 || alpha(0, 0) != 0 {
+		t.Errorf("Expected inside and outside values got %d %d", alpha(44, 124), alpha(0, 0))
+	}
+	if in, out := alpha(4, 124), alpha(3, 124); in != 143 || out != 112 {
+		t.Errorf("Expected values around the edge got %d %d", in, out)
+	}
+}
+
+// Bitmaps convert to distances from the nearest pixel edge.
+func TestSdf(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 8; y < 24; y++ {
+		for x := 8; x < 24; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{A: 255})
+		}
+	}
+	sdf := Sdf(img, 4)
+	alpha := func(x, y int) uint8 { return sdf.NRGBAAt(x, y).A }
+	if alpha(16, 16) != 255 || alpha(0, 0) != 0 {
+		t.Errorf("Expected inside and outside values got %d %d", alpha(16, 16), alpha(0, 0))
+	}
+	if in, out := alpha(8, 16), alpha(7, 16); in != 143 || out != 112 {
+		t.Errorf("Expected values around the edge got %d %d", in, out)
+	}
+}
+
+// Bitmapped fonts are converted when there is no TrueType font.
+// Uses vu/eg resource directories.
+func TestLoadSdf(t *testing.T) {
+	loc := NewLocator().Dir("FNT", "../eg/source").Dir("PNG", "../eg/images")
+	fnt, img := &FntData{}, &ImgData{}
+	if err := fnt.LoadSdf("lucidiaSu22", loc, img); err != nil {
+		t.Fatal(err)
+	}
+	if len(fnt.Chars) == 0 || img.Img.Bounds().Dx() != fnt.W {
+		t.Errorf("Expected font mapping and distance field image")
+	}
+	if err := fnt.LoadSdf("missing", loc, img); err == nil {
+		t.Error("Expected missing font error")
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// tangent.go generates vertex normals and tangents for meshes that
+// do not include them.
+// DESIGN: Face contributions are weighted by the face angle at each
+//         vertex so that results do not depend on how a surface is
+//         triangulated. Tangents are orthogonalized against the vertex
+//         normal and store the bitangent handedness in w, following the
+//         conventions used by MikkTSpace. See:
+//         http://www.terathon.com/code/tangent.html
+
+import (
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// GenNormals creates smooth vertex normals if the mesh has none.
+func (d *MshData) GenNormals() {
+	if len(d.N) != len(d.V) {
+		d.N = Normals(d.V, d.F)
+	}
+}
+
+// GenTangents creates vertex tangents if the mesh has texture coordinates
+// and no tangents. Normals are generated first if they are missing.
+// Tangents are read by normal map shaders like vu "tnmap".
+func (d *MshData) GenTangents() {
+	if len(d.T) > 0 && len(d.X) != len(d.V)/3*4 {
+		d.GenNormals()
+		d.X = Tangents(d.V, d.N, d.T, d.F)
+	}
+}
+
+// Normals returns smooth vertex normals, arranged as [][3]float32,
+// for the given vertex positions and triangle faces. Useful for
+// procedurally generated meshes.
+func Normals(v []float32, f []uint16) []float32 {
+	n := make([]float32, len(v))
+	e1, e2, fn := &lin.V3{}, &lin.V3{}, &lin.V3{}
+	for cnt := 0; cnt+2 < len(f); cnt += 3 {
+		tri := [3]int{int(f[cnt]), int(f[cnt+1]), int(f[cnt+2])}
+		if !inRange(tri, len(v)/3) {
+			continue
+		}
+		vertex(v, tri[0], e1)
+		vertex(v, tri[1], e2)
+		fn.Cross(e2.Sub(e2, e1), vertex(v, tri[2], fn).Sub(fn, e1))
+		if fn.Len() == 0 {
+			continue // degenerate face.
+		}
+		fn.Unit()
+		for corner := range tri {
+			angle := cornerAngle(v, tri, corner)
+			ni := tri[corner] * 3
+			n[ni] += float32(fn.X * angle)
+			n[ni+1] += float32(fn.Y * angle)
+			n[ni+2] += float32(fn.Z * angle)
+		}
+	}
+	for ni := 0; ni < len(n); ni += 3 {
+		vn := e1.SetS(float64(n[ni]), float64(n[ni+1]), float64(n[ni+2]))
+		if vn.Len() > 0 {
+			vn.Unit()
+		}
+		n[ni], n[ni+1], n[ni+2] = float32(vn.X), float32(vn.Y), float32(vn.Z)
+	}
+	return n
+}
+
+// Tangents returns vertex tangents, arranged as [][4]float32, for the
+// given vertex positions, normals, texture coordinates and triangle faces.
+// The tangent w is 1 or -1 and gives the bitangent direction as:
+//     bitangent = w * cross(normal, tangent)
+// Useful for normal mapping procedurally generated meshes.
+func Tangents(v, n, t []float32, f []uint16) []float32 {
+	verts := len(v) / 3
+	if len(n) < len(v) || len(t) < verts*2 {
+		return make([]float32, verts*4)
+	}
+	tan := make([]lin.V3, verts) // accumulated tangents.
+	bit := make([]lin.V3, verts) // accumulated bitangents.
+	e1, e2, p0 := &lin.V3{}, &lin.V3{}, &lin.V3{}
+	sdir, tdir := &lin.V3{}, &lin.V3{}
+	for cnt := 0; cnt+2 < len(f); cnt += 3 {
+		tri := [3]int{int(f[cnt]), int(f[cnt+1]), int(f[cnt+2])}
+		if !inRange(tri, verts) {
+			continue
+		}
+		vertex(v, tri[0], p0)
+		vertex(v, tri[1], e1).Sub(e1, p0)
+		vertex(v, tri[2], e2).Sub(e2, p0)
+		du1, dv1 := float64(t[tri[1]*2]-t[tri[0]*2]), float64(t[tri[1]*2+1]-t[tri[0]*2+1])
+		du2, dv2 := float64(t[tri[2]*2]-t[tri[0]*2]), float64(t[tri[2]*2+1]-t[tri[0]*2+1])
+		r := du1*dv2 - du2*dv1
+		if math.Abs(r) < lin.Epsilon {
+			continue // degenerate texture mapping.
+		}
+		sdir.SetS((e1.X*dv2-e2.X*dv1)/r, (e1.Y*dv2-e2.Y*dv1)/r, (e1.Z*dv2-e2.Z*dv1)/r)
+		tdir.SetS((e2.X*du1-e1.X*du2)/r, (e2.Y*du1-e1.Y*du2)/r, (e2.Z*du1-e1.Z*du2)/r)
+		for corner, vi := range tri {
+			angle := cornerAngle(v, tri, corner)
+			tan[vi].X, tan[vi].Y, tan[vi].Z = tan[vi].X+sdir.X*angle, tan[vi].Y+sdir.Y*angle, tan[vi].Z+sdir.Z*angle
+			bit[vi].X, bit[vi].Y, bit[vi].Z = bit[vi].X+tdir.X*angle, bit[vi].Y+tdir.Y*angle, bit[vi].Z+tdir.Z*angle
+		}
+	}
+
+	// Gram-Schmidt orthogonalize each tangent against its normal.
+	x := make([]float32, verts*4)
+	vn, vt := &lin.V3{}, &lin.V3{}
+	for vi := 0; vi < verts; vi++ {
+		vertex(n, vi, vn)
+		vt.Set(&tan[vi]).Sub(vt, e1.Set(vn).Scale(e1, vn.Dot(&tan[vi])))
+		if vt.Len() < lin.Epsilon {
+			perpendicular(vn, vt) // no texture mapping direction.
+		}
+		vt.Unit()
+		w := float32(1)
+		if e1.Cross(vn, vt).Dot(&bit[vi]) < 0 {
+			w = -1
+		}
+		x[vi*4], x[vi*4+1], x[vi*4+2], x[vi*4+3] = float32(vt.X), float32(vt.Y), float32(vt.Z), w
+	}
+	return x
+}
+
+// =============================================================================
+// internal helpers for generating normals and tangents.
+
+// vertex sets v to vertex i from the [][3]float32 data.
+func vertex(data []float32, i int, v *lin.V3) *lin.V3 {
+	return v.SetS(float64(data[i*3]), float64(data[i*3+1]), float64(data[i*3+2]))
+}
+
+// inRange returns true if the triangle indicies are valid.
+func inRange(tri [3]int, verts int) bool {
+	return tri[0] < verts && tri[1] < verts && tri[2] < verts
+}
+
+// cornerAngle returns the angle in radians of the triangle at the given corner.
+func cornerAngle(v []float32, tri [3]int, corner int) float64 {
+	a, b, c := &lin.V3{}, &lin.V3{}, &lin.V3{}
+	vertex(v, tri[corner], a)
+	vertex(v, tri[(corner+1)%3], b).Sub(b, a)
+	vertex(v, tri[(corner+2)%3], c).Sub(c, a)
+	if b.Len() == 0 || c.Len() == 0 {
+		return 0
+	}
+	return math.Acos(lin.Clamp(b.Dot(c)/(b.Len()*c.Len()), -1, 1))
+}
+
+// perpendicular sets p to a unit vector perpendicular to unit vector n.
+func perpendicular(n, p *lin.V3) {
+	if math.Abs(n.X) < 0.9 {
+		p.Cross(n, &lin.V3{X: 1})
+	} else {
+		p.Cross(n, &lin.V3{Y: 1})
+	}
+}
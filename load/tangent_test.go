@@ -0,0 +1,67 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"math"
+	"testing"
+)
+
+// quad is a uv mapped unit square in the XY plane facing +Z.
+func quad() *MshData {
+	return &MshData{
+		V: []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0},
+		T: []float32{0, 0, 1, 0, 1, 1, 0, 1},
+		F: []uint16{0, 1, 2, 0, 2, 3},
+	}
+}
+
+func TestGenNormals(t *testing.T) {
+	msh := quad()
+	msh.GenNormals()
+	if len(msh.N) != len(msh.V) {
+		t.Fatalf("Expected %d normals got %d", len(msh.V), len(msh.N))
+	}
+	for cnt := 0; cnt < len(msh.N); cnt += 3 {
+		if msh.N[cnt] != 0 || msh.N[cnt+1] != 0 || msh.N[cnt+2] != 1 {
+			t.Errorf("Expected +Z normal got %v", msh.N[cnt:cnt+3])
+		}
+	}
+}
+
+func TestSmoothNormals(t *testing.T) {
+	// two faces sharing an edge at right angles.
+	v := []float32{0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1}
+	f := []uint16{0, 1, 2, 0, 3, 1}
+	n := Normals(v, f)
+	want := float32(1 / math.Sqrt2)
+	if !aeq(n[0], 0) || !aeq(n[1], want) || !aeq(n[2], want) {
+		t.Errorf("Expected shared normal averaged, got %v", n[0:3])
+	}
+}
+
+func TestGenTangents(t *testing.T) {
+	msh := quad()
+	msh.GenTangents()
+	if len(msh.X) != len(msh.V)/3*4 {
+		t.Fatalf("Expected %d tangent values got %d", len(msh.V)/3*4, len(msh.X))
+	}
+	for cnt := 0; cnt < len(msh.X); cnt += 4 {
+		x := msh.X[cnt : cnt+4]
+		if !aeq(x[0], 1) || !aeq(x[1], 0) || !aeq(x[2], 0) || x[3] != 1 {
+			t.Errorf("Expected +X tangent got %v", x)
+		}
+	}
+
+	// mirrored texture coordinates flip the handedness.
+	msh = quad()
+	msh.T = []float32{0, 0, 0, 1, 1, 1, 1, 0} // u,v swapped.
+	msh.GenTangents()
+	if !aeq(msh.X[0], 0) || !aeq(msh.X[1], 1) || msh.X[3] != -1 {
+		t.Errorf("Expected mirrored +Y tangent got %v", msh.X[0:4])
+	}
+}
+
+// aeq returns true if the floats are almost equal.
+func aeq(a, b float32) bool { return math.Abs(float64(a-b)) < 0.0001 }
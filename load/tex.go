@@ -0,0 +1,275 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tex loads a vu texture import file. Texture import files are optional
+// text files, sharing the image name, that describe how an image is
+// processed when it is loaded. Each line holds one statement. Lines
+// starting with # are comments.
+//    pow2               : resize to the nearest power of two.
+//    maxsize n          : shrink so that neither side is more than n pixels.
+//    mipmaps            : generate the mipmap levels on import.
+//    pack    c file [s] : copy channel s, default r, of file into channel c.
+// Channels are one of r, g, b, a. Packing combines single channel images,
+// ie: "pack g wood_rough", "pack b wood_metal", "pack r wood_ao".
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import overwrites the data in TexData.
+func Tex(r io.Reader, d *TexData) error {
+	*d = TexData{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if err := texLine(scanner.Text(), d); err != nil {
+			return fmt.Errorf("Invalid .tex line %d: %s", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Process applies the import settings to the named image. Packed images
+// start with the named image, if there is one, and replace the packed
+// channels. Only 8 bit images can be processed.
+func (d *TexData) Process(name string, l Locator, img *ImgData) error {
+	var dst *image.NRGBA
+	base := &ImgData{}
+	err := base.load(name, l)
+	switch {
+	case err == nil:
+		if dst, err = texNRGBA(name, base.Img); err != nil {
+			return err
+		}
+	case len(d.Pack) == 0:
+		return err
+	}
+	for _, p := range d.Pack {
+		src := &ImgData{}
+		if err := src.load(p.File, l); err != nil {
+			return err
+		}
+		sn, err := texNRGBA(p.File, src.Img)
+		if err != nil {
+			return err
+		}
+		if dst == nil {
+			dst = image.NewNRGBA(sn.Bounds())
+			for cnt := 3; cnt < len(dst.Pix); cnt += 4 {
+				dst.Pix[cnt] = 255 // opaque black.
+			}
+		}
+		if sn.Bounds().Size() != dst.Bounds().Size() {
+			sn = texResize(sn, dst.Bounds().Dx(), dst.Bounds().Dy())
+		}
+		for cnt := 0; cnt < len(dst.Pix); cnt += 4 {
+			dst.Pix[cnt+p.Dst] = sn.Pix[cnt+p.Src]
+		}
+	}
+
+	// resize before generating mipmaps.
+	w, h := dst.Bounds().Dx(), dst.Bounds().Dy()
+	if d.Pow2 {
+		w, h = texPow2(w), texPow2(h)
+	}
+	for d.MaxSize > 0 && (w > d.MaxSize || h > d.MaxSize) {
+		if d.Pow2 {
+			w, h = texHalf(w), texHalf(h)
+		} else {
+			scale := float64(d.MaxSize) / float64(w)
+			if h > w {
+				scale = float64(d.MaxSize) / float64(h)
+			}
+			w, h = texAtLeast1(int(float64(w)*scale+0.5)), texAtLeast1(int(float64(h)*scale+0.5))
+		}
+	}
+	if w != dst.Bounds().Dx() || h != dst.Bounds().Dy() {
+		dst = texResize(dst, w, h)
+	}
+	if d.Mipmaps {
+		img.Img = &MipImage{Levels: texMipmaps(dst)}
+		return nil
+	}
+	img.Img = dst
+	return nil
+}
+
+// =============================================================================
+// internal implementation for texture import processing.
+
+// texChannels maps channel names to NRGBA pixel offsets.
+var texChannels = map[string]int{"r": 0, "g": 1, "b": 2, "a": 3}
+
+// texLine parses one line of a texture import file.
+func texLine(line string, d *TexData) error {
+	tokens := strings.Fields(line)
+	if len(tokens) == 0 || strings.HasPrefix(tokens[0], "#") {
+		return nil
+	}
+	switch tokens[0] {
+	case "pow2":
+		d.Pow2 = true
+	case "mipmaps":
+		d.Mipmaps = true
+	case "maxsize":
+		if len(tokens) != 2 {
+			return fmt.Errorf("expected maxsize value")
+		}
+		size, err := strconv.Atoi(tokens[1])
+		if err != nil || size < 1 {
+			return fmt.Errorf("expected maxsize value")
+		}
+		d.MaxSize = size
+	case "pack":
+		if len(tokens) < 3 || len(tokens) > 4 {
+			return fmt.Errorf("expected pack channel file [channel]")
+		}
+		p := TexPack{File: tokens[2]}
+		var ok bool
+		if p.Dst, ok = texChannels[tokens[1]]; !ok {
+			return fmt.Errorf("unknown channel %s", tokens[1])
+		}
+		if len(tokens) == 4 {
+			if p.Src, ok = texChannels[tokens[3]]; !ok {
+				return fmt.Errorf("unknown channel %s", tokens[3])
+			}
+		}
+		d.Pack = append(d.Pack, p)
+	default:
+		return fmt.Errorf("unknown statement %s", tokens[0])
+	}
+	return nil
+}
+
+// texNRGBA returns a non pre-multiplied copy of an 8 bit image.
+func texNRGBA(name string, img image.Image) (*image.NRGBA, error) {
+	switch img.(type) {
+	case *FloatImage, *CompressedImage, *MipImage:
+		return nil, fmt.Errorf("Invalid .tex image %s: expected 8 bit image", name)
+	}
+	b := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	return nrgba, nil
+}
+
+// texPow2 returns the power of two nearest to n, rounding up on ties.
+func texPow2(n int) int {
+	p := 1
+	for p*2 <= n {
+		p *= 2
+	}
+	if n-p >= p*2-n {
+		return p * 2
+	}
+	return p
+}
+
+// texHalf returns half the image side length.
+func texHalf(n int) int { return texAtLeast1(n / 2) }
+
+// texAtLeast1 keeps image sides from going to zero.
+func texAtLeast1(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// texResize scales the image to the given size. Images are halved using
+// a box filter until they are less than twice the requested size and then
+// sampled using bilinear filtering.
+func texResize(img *image.NRGBA, w, h int) *image.NRGBA {
+	for img.Bounds().Dx() >= 2*w && img.Bounds().Dy() >= 2*h {
+		img = texShrink(img)
+	}
+	sw, sh := img.Bounds().Dx(), img.Bounds().Dy()
+	if sw == w && sh == h {
+		return img
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	clamp := func(v, max int) int {
+		if v < 0 {
+			return 0
+		}
+		if v >= max {
+			return max - 1
+		}
+		return v
+	}
+	for y := 0; y < h; y++ {
+		py := (float64(y)+0.5)*float64(sh)/float64(h) - 0.5
+		y0 := int(math.Floor(py))
+		fy := py - float64(y0)
+		ya, yb := clamp(y0, sh), clamp(y0+1, sh)
+		for x := 0; x < w; x++ {
+			px := (float64(x)+0.5)*float64(sw)/float64(w) - 0.5
+			x0 := int(math.Floor(px))
+			fx := px - float64(x0)
+			xa, xb := clamp(x0, sw), clamp(x0+1, sw)
+			for c := 0; c < 4; c++ {
+				at := func(x, y int) float64 { return float64(img.Pix[y*img.Stride+x*4+c]) }
+				top := at(xa, ya)*(1-fx) + at(xb, ya)*fx
+				bot := at(xa, yb)*(1-fx) + at(xb, yb)*fx
+				dst.Pix[y*dst.Stride+x*4+c] = uint8(top*(1-fy) + bot*fy + 0.5)
+			}
+		}
+	}
+	return dst
+}
+
+// texShrink halves the image using a 2x2 box filter. Colors are weighted
+// by alpha so that transparent pixels do not darken the edges.
+func texShrink(img *image.NRGBA) *image.NRGBA {
+	sw, sh := img.Bounds().Dx(), img.Bounds().Dy()
+	w, h := texHalf(sw), texHalf(sh)
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var rgb [3]float64
+			var alpha float64
+			for _, p := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				sx, sy := 2*x+p[0], 2*y+p[1]
+				if sx >= sw {
+					sx = sw - 1
+				}
+				if sy >= sh {
+					sy = sh - 1
+				}
+				i := sy*img.Stride + sx*4
+				a := float64(img.Pix[i+3])
+				for c := range rgb {
+					rgb[c] += float64(img.Pix[i+c]) * a
+				}
+				alpha += a
+			}
+			i := y*dst.Stride + x*4
+			if alpha > 0 {
+				for c := range rgb {
+					dst.Pix[i+c] = uint8(rgb[c]/alpha + 0.5)
+				}
+			}
+			dst.Pix[i+3] = uint8(alpha/4 + 0.5)
+		}
+	}
+	return dst
+}
+
+// texMipmaps returns the image followed by each smaller mipmap level
+// down to a 1x1 image.
+func texMipmaps(img *image.NRGBA) []image.Image {
+	levels := []image.Image{img}
+	for img.Bounds().Dx() > 1 || img.Bounds().Dy() > 1 {
+		img = texShrink(img)
+		levels = append(levels, img)
+	}
+	return levels
+}
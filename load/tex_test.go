@@ -0,0 +1,102 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTex(t *testing.T) {
+	d := &TexData{}
+	if err := Tex(strings.NewReader("# orm\npow2\nmaxsize 256\nmipmaps\npack g rough\npack b metal a\n"), d); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Pow2 || d.MaxSize != 256 || !d.Mipmaps || len(d.Pack) != 2 {
+		t.Errorf("Bad import settings %+v", d)
+	}
+	if d.Pack[1] != (TexPack{File: "metal", Src: 3, Dst: 2}) {
+		t.Errorf("Bad channel packing %+v", d.Pack[1])
+	}
+	for _, bad := range []string{"maxsize", "maxsize 0", "pack x rough", "pack r rough y", "pack r", "sharpen"} {
+		if err := Tex(strings.NewReader(bad), d); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestTexSizes(t *testing.T) {
+	for n, want := range map[int]int{1: 1, 3: 4, 5: 4, 6: 8, 100: 128, 700: 512, 1024: 1024} {
+		if got := texPow2(n); got != want {
+			t.Errorf("Expected %d for %d got %d", want, n, got)
+		}
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 5, 3))
+	levels := texMipmaps(img)
+	if len(levels) != 3 || levels[1].Bounds().Dx() != 2 || levels[2].Bounds().Dy() != 1 {
+		t.Errorf("Expected 5x3, 2x1, 1x1 mipmaps got %d levels", len(levels))
+	}
+}
+
+// Texture import files are applied when images are loaded.
+func TestTexProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, ext, data string, img image.Image) {
+		f, _ := os.Create(filepath.Join(dir, name+ext))
+		if img != nil {
+			png.Encode(f, img)
+		} else {
+			f.WriteString(data)
+		}
+		f.Close()
+	}
+	gray := func(w, h int, v uint8) image.Image {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for cnt := range img.Pix {
+			img.Pix[cnt] = v
+		}
+		return img
+	}
+	write("rough", ".png", "", gray(8, 8, 200))
+	write("metal", ".png", "", gray(4, 4, 50))
+	write("orm", ".tex", "pack g rough\npack b metal\nmipmaps\n", nil)
+	write("big", ".png", "", image.NewNRGBA(image.Rect(0, 0, 300, 120)))
+	write("big", ".tex", "pow2\nmaxsize 128\n", nil)
+	write("bad", ".tex", "pack r missing\n", nil)
+	loc := NewLocator().Dir("PNG", dir).Dir("TEX", dir)
+
+	img := &ImgData{}
+	if err := img.Load("orm", loc); err != nil {
+		t.Fatal(err)
+	}
+	mip, ok := img.Img.(*MipImage)
+	if !ok || len(mip.Levels) != 4 {
+		t.Fatalf("Expected 8x8 image with mipmaps got %T", img.Img)
+	}
+	if c := mip.Levels[3].At(0, 0).(color.NRGBA); c != (color.NRGBA{0, 200, 50, 255}) {
+		t.Errorf("Expected packed channels got %v", c)
+	}
+	if err := img.Load("big", loc); err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Img.Bounds(); b.Dx() != 128 || b.Dy() != 64 {
+		t.Errorf("Expected 128x64 image got %v", b)
+	}
+	if err := img.Load("bad", loc); err == nil {
+		t.Error("Expected missing packed image error")
+	}
+	if err := img.Load("rough", loc); err != nil || img.Img.Bounds().Dx() != 8 {
+		t.Errorf("Expected unprocessed image %s", err)
+	}
+}
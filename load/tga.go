@@ -0,0 +1,184 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Tga populates image data using the given reader. Truevision TGA files
+// are a simple, often uncompressed, image format. Color mapped, true color,
+// and grayscale images are supported along with their run length encoded
+// variants. See the specification at:
+//    http://www.gamers.org/dEngine/quake3/TGA.txt
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import replaces the image in ImgData with a new NRGBA image.
+func Tga(r io.Reader, d *ImgData) error {
+	br := bufio.NewReader(r)
+	hdr := tgaHeader{}
+	if err := binary.Read(br, binary.LittleEndian, &hdr); err != nil {
+		return fmt.Errorf("Invalid .tga header %s", err)
+	}
+	if _, err := br.Discard(int(hdr.IDLength)); err != nil {
+		return fmt.Errorf("Invalid .tga id %s", err)
+	}
+	itype, rle := hdr.ImageType&^8, hdr.ImageType&8 != 0
+	if itype < tgaMapped || itype > tgaGray || hdr.Width == 0 || hdr.Height == 0 {
+		return fmt.Errorf("Unsupported .tga image type %d", hdr.ImageType)
+	}
+	if itype != tgaTrue && hdr.Depth != 8 && hdr.Depth != 16 {
+		return fmt.Errorf("Unsupported .tga bit depth %d", hdr.Depth)
+	}
+
+	// the color map is needed for color mapped images.
+	var cmap []color.NRGBA
+	if hdr.ColorMapType == 1 {
+		cmap = make([]color.NRGBA, int(hdr.MapStart)+int(hdr.MapLength))
+		for cnt := int(hdr.MapStart); cnt < len(cmap); cnt++ {
+			c, err := tgaColor(br, hdr.MapDepth, hdr.Desc)
+			if err != nil {
+				return fmt.Errorf("Invalid .tga color map %s", err)
+			}
+			cmap[cnt] = c
+		}
+	}
+	pixel := func() (color.NRGBA, error) {
+		switch itype {
+		case tgaMapped:
+			index, err := tgaIndex(br, hdr.Depth)
+			if err != nil || index >= len(cmap) {
+				return color.NRGBA{}, fmt.Errorf("bad color index %d %v", index, err)
+			}
+			return cmap[index], nil
+		case tgaGray: // 8 bit gray or 16 bit gray and alpha.
+			b := make([]byte, (hdr.Depth+7)/8)
+			if _, err := io.ReadFull(br, b); err != nil {
+				return color.NRGBA{}, err
+			}
+			c := color.NRGBA{R: b[0], G: b[0], B: b[0], A: 255}
+			if len(b) > 1 {
+				c.A = b[1]
+			}
+			return c, nil
+		}
+		return tgaColor(br, hdr.Depth, hdr.Desc)
+	}
+
+	// read the pixels, unpacking run length encoded packets.
+	// The image is only allocated once all the pixels have been
+	// read so that a bad header can't claim a huge image.
+	w, h := int(hdr.Width), int(hdr.Height)
+	pixels := []color.NRGBA{}
+	run, raw, c := 0, false, color.NRGBA{}
+	for cnt := 0; cnt < w*h; cnt++ {
+		var err error
+		switch {
+		case !rle:
+			c, err = pixel()
+		case run > 0 && raw:
+			c, err = pixel()
+		case run > 0: // repeat last color.
+		default:
+			var packet byte
+			if packet, err = br.ReadByte(); err == nil {
+				run, raw = int(packet&0x7F)+1, packet&0x80 == 0
+				c, err = pixel()
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid .tga pixels %s", err)
+		}
+		run--
+		pixels = append(pixels, c)
+	}
+
+	// the image origin defaults to the bottom left.
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for cnt, c := range pixels {
+		x, y := cnt%w, cnt/w
+		if hdr.Desc&0x10 != 0 {
+			x = w - 1 - x // right to left.
+		}
+		if hdr.Desc&0x20 == 0 {
+			y = h - 1 - y // bottom to top.
+		}
+		img.SetNRGBA(x, y, c)
+	}
+	d.Img = img
+	return nil
+}
+
+// =============================================================================
+// internal implementation for loading TGA files.
+
+// tgaHeader is the fixed size image information at the start of the file.
+type tgaHeader struct {
+	IDLength     uint8  // Bytes of image identification after the header.
+	ColorMapType uint8  // 1 if there is a color map.
+	ImageType    uint8  // One of the tga image types. Plus 8 if RLE.
+	MapStart     uint16 // First color map index.
+	MapLength    uint16 // Number of color map entries.
+	MapDepth     uint8  // Bits per color map entry.
+	X, Y         uint16 // Image origin. Ignored.
+	Width        uint16 // Image width in pixels.
+	Height       uint16 // Image height in pixels.
+	Depth        uint8  // Bits per pixel.
+	Desc         uint8  // Alpha bits and pixel ordering.
+}
+
+// tga image types.
+const (
+	tgaMapped = 1 // Color mapped.
+	tgaTrue   = 2 // True color.
+	tgaGray   = 3 // Grayscale.
+)
+
+// tgaIndex reads a color map index.
+func tgaIndex(br *bufio.Reader, depth uint8) (int, error) {
+	b := make([]byte, (depth+7)/8)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return 0, err
+	}
+	index := 0
+	for cnt := len(b) - 1; cnt >= 0; cnt-- {
+		index = index<<8 | int(b[cnt])
+	}
+	return index, nil
+}
+
+// tgaColor reads a 15, 16, 24, or 32 bit BGR(A) color. Alpha is
+// only used when the descriptor lists alpha bits.
+func tgaColor(br *bufio.Reader, depth, desc uint8) (color.NRGBA, error) {
+	b := make([]byte, (depth+7)/8)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return color.NRGBA{}, err
+	}
+	hasAlpha := desc&0x0F != 0
+	switch depth {
+	case 15, 16:
+		v := uint16(b[0]) | uint16(b[1])<<8
+		c := color.NRGBA{R: tga5(v >> 10), G: tga5(v >> 5), B: tga5(v), A: 255}
+		if hasAlpha && depth == 16 && v&0x8000 == 0 {
+			c.A = 0
+		}
+		return c, nil
+	case 24:
+		return color.NRGBA{R: b[2], G: b[1], B: b[0], A: 255}, nil
+	case 32:
+		c := color.NRGBA{R: b[2], G: b[1], B: b[0], A: 255}
+		if hasAlpha {
+			c.A = b[3]
+		}
+		return c, nil
+	}
+	return color.NRGBA{}, fmt.Errorf("unsupported bit depth %d", depth)
+}
+
+// tga5 scales a 5 bit color value to 8 bits.
+func tga5(v uint16) uint8 { return uint8((v & 0x1F) * 255 / 31) }
@@ -0,0 +1,56 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// A bottom left origin run length encoded image is flipped
+// so that the first row is the top row.
+func TestTga(t *testing.T) {
+	hdr := []byte{0, 0, 10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 2, 0, 32, 8}
+	pixels := []byte{
+		0x81, 255, 0, 0, 255, // 2 blue pixels in the bottom row.
+		0x00, 0, 255, 0, 128, // 1 green pixel with half alpha.
+		0x00, 0, 0, 255, 255, // 1 red pixel.
+	}
+	img := &ImgData{}
+	if err := Tga(bytes.NewReader(append(hdr, pixels...)), img); err != nil {
+		t.Fatal(err)
+	}
+	nrgba, ok := img.Img.(*image.NRGBA)
+	if !ok || nrgba.Bounds().Dx() != 2 {
+		t.Fatalf("Expected 2x2 NRGBA image, got %T", img.Img)
+	}
+	if c := nrgba.NRGBAAt(0, 1); c != (color.NRGBA{0, 0, 255, 255}) {
+		t.Errorf("Expected bottom left blue, got %v", c)
+	}
+	if c := nrgba.NRGBAAt(0, 0); c != (color.NRGBA{0, 255, 0, 128}) {
+		t.Errorf("Expected top left green, got %v", c)
+	}
+	if c := nrgba.NRGBAAt(1, 0); c != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("Expected top right red, got %v", c)
+	}
+	if err := Tga(bytes.NewReader(hdr), img); err == nil {
+		t.Error("Expected error for missing pixels")
+	}
+}
+
+// Headers with bad bit depths or sizes are rejected before any pixels
+// are allocated.
+func TestTgaInvalid(t *testing.T) {
+	for name, hdr := range map[string][]byte{
+		"gray":   {0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0},
+		"mapped": {0, 1, 1, 0, 0, 1, 0, 24, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0},
+		"huge":   {0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF, 32, 8, 0, 0, 0, 0},
+	} {
+		if err := Tga(bytes.NewReader(hdr), &ImgData{}); err == nil {
+			t.Errorf("Expected %s error", name)
+		}
+	}
+}
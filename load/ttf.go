@@ -0,0 +1,400 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// Ttf reads the glyph outlines, metrics, and character mapping of a
+// TrueType font. Only the cmap, head, hhea, hmtx, loca, maxp, and glyf
+// tables are used. Hinting and kerning are ignored. See:
+//    https://developer.apple.com/fonts/TrueType-Reference-Manual/
+// The Reader r is expected to be opened and closed by the caller.
+// A successful import overwrites the data in TtfData.
+func Ttf(r io.Reader, d *TtfData) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("Invalid .ttf file %s", err)
+	}
+	if len(data) < 12 {
+		return fmt.Errorf("Invalid .ttf header")
+	}
+	tables := map[string][]byte{}
+	for cnt := 0; cnt < int(be16(data[4:])); cnt++ {
+		rec := 12 + cnt*16
+		if rec+16 > len(data) {
+			return fmt.Errorf("Invalid .ttf table directory")
+		}
+		offset, length := be32(data[rec+8:]), be32(data[rec+12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return fmt.Errorf("Invalid .ttf table %s", data[rec:rec+4])
+		}
+		tables[string(data[rec:rec+4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"cmap", "head", "hhea", "hmtx", "loca", "maxp", "glyf"} {
+		if _, ok := tables[tag]; !ok {
+			return fmt.Errorf("Missing .ttf table %s", tag)
+		}
+	}
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return fmt.Errorf("Invalid .ttf font header")
+	}
+	t := TtfData{UnitsPerEm: int(be16(head[18:]))}
+	t.Ascent, t.Descent = int(int16(be16(hhea[4:]))), int(int16(be16(hhea[6:])))
+	t.glyf, t.hmtx, t.metrics = tables["glyf"], tables["hmtx"], int(be16(hhea[34:]))
+	if t.UnitsPerEm == 0 || t.metrics == 0 || len(t.hmtx) < 4*t.metrics {
+		return fmt.Errorf("Invalid .ttf font metrics")
+	}
+
+	// glyph offsets are either 16 bit halved values, or 32 bit values.
+	loca, long := tables["loca"], int16(be16(head[50:])) == 1
+	t.loca = make([]uint32, int(be16(maxp[4:]))+1)
+	for cnt := range t.loca {
+		switch {
+		case long && 4*cnt+4 <= len(loca):
+			t.loca[cnt] = be32(loca[4*cnt:])
+		case !long && 2*cnt+2 <= len(loca):
+			t.loca[cnt] = uint32(be16(loca[2*cnt:])) * 2
+		default:
+			return fmt.Errorf("Invalid .ttf glyph locations")
+		}
+	}
+	if t.cmap, err = ttfCmap(tables["cmap"]); err != nil {
+		return err
+	}
+	*d = t
+	return nil
+}
+
+// TtfData holds the glyph outlines and metrics of a TrueType font.
+// Distances are in font units. Use UnitsPerEm to scale to pixels.
+// It is intended for generating font textures, see SdfFont.
+type TtfData struct {
+	UnitsPerEm int // Font units per em square.
+	Ascent     int // Distance above the baseline.
+	Descent    int // Distance below the baseline. Usually negative.
+
+	cmap    map[rune]uint16 // Character to glyph index.
+	loca    []uint32        // Glyph locations in the glyf table.
+	glyf    []byte          // Glyph outlines.
+	hmtx    []byte          // Horizontal metrics.
+	metrics int             // Number of advances in hmtx.
+}
+
+// TtfGlyph is one character outline with y up from the baseline.
+// Glyphs without outlines, ie: space, only have an advance.
+type TtfGlyph struct {
+	Advance                int         // Distance to the next character.
+	Xmin, Ymin, Xmax, Ymax int         // Outline bounds.
+	Contours               [][]float32 // Closed x,y outlines.
+}
+
+// Glyph returns the outline for the given character.
+// Curves are flattened into straight line segments.
+func (d *TtfData) Glyph(r rune) (g *TtfGlyph, ok bool) {
+	index, ok := d.cmap[r]
+	if !ok {
+		return nil, false
+	}
+	g = &TtfGlyph{Contours: d.outline(index, 0)}
+	if i := int(index); i < d.metrics {
+		g.Advance = int(be16(d.hmtx[4*i:]))
+	} else {
+		g.Advance = int(be16(d.hmtx[4*(d.metrics-1):]))
+	}
+	xmin, ymin := math.MaxFloat64, math.MaxFloat64
+	xmax, ymax := -math.MaxFloat64, -math.MaxFloat64
+	for _, contour := range g.Contours {
+		for cnt := 0; cnt+1 < len(contour); cnt += 2 {
+			x, y := float64(contour[cnt]), float64(contour[cnt+1])
+			xmin, xmax = math.Min(xmin, x), math.Max(xmax, x)
+			ymin, ymax = math.Min(ymin, y), math.Max(ymax, y)
+		}
+	}
+	if len(g.Contours) > 0 {
+		g.Xmin, g.Ymin = int(math.Floor(xmin)), int(math.Floor(ymin))
+		g.Xmax, g.Ymax = int(math.Ceil(xmax)), int(math.Ceil(ymax))
+	}
+	return g, true
+}
+
+// =============================================================================
+// internal implementation for reading TrueType files.
+
+// be16 and be32 read big endian TrueType values.
+func be16(b []byte) uint16 { return binary.BigEndian.Uint16(b) }
+func be32(b []byte) uint32 { return binary.BigEndian.Uint32(b) }
+
+// ttfCmap reads the unicode character to glyph mapping.
+// Character map subtable formats 4 and 12 are supported.
+func ttfCmap(b []byte) (map[rune]uint16, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("Invalid .ttf character map")
+	}
+	best, rank := -1, 0
+	for cnt := 0; cnt < int(be16(b[2:])); cnt++ {
+		rec := 4 + cnt*8
+		if rec+8 > len(b) {
+			return nil, fmt.Errorf("Invalid .ttf character map")
+		}
+		platform, encoding, offset := be16(b[rec:]), be16(b[rec+2:]), int(be32(b[rec+4:]))
+		r := 0
+		switch {
+		case platform == 3 && encoding == 10, platform == 0 && encoding == 4:
+			r = 2 // full unicode.
+		case platform == 3 && encoding == 1, platform == 0:
+			r = 1 // basic multilingual plane.
+		}
+		if r > rank && offset+2 <= len(b) {
+			best, rank = offset, r
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("Unsupported .ttf character map")
+	}
+	sub, chars := b[best:], map[rune]uint16{}
+	switch be16(sub) {
+	case 4: // segments of 16 bit characters.
+		if len(sub) < 14 {
+			return nil, fmt.Errorf("Invalid .ttf character map")
+		}
+		segs := int(be16(sub[6:])) / 2
+		ends, starts, deltas, ranges := 14, 16+2*segs, 16+4*segs, 16+6*segs
+		if len(sub) < 16+8*segs {
+			return nil, fmt.Errorf("Invalid .ttf character map")
+		}
+		for s := 0; s < segs; s++ {
+			start, end := uint32(be16(sub[starts+2*s:])), uint32(be16(sub[ends+2*s:]))
+			delta, offset := be16(sub[deltas+2*s:]), int(be16(sub[ranges+2*s:]))
+			for c := start; c <= end && c != 0xffff; c++ {
+				glyph := uint16(c) + delta
+				if offset != 0 {
+					i := ranges + 2*s + offset + 2*int(c-start)
+					if i+2 > len(sub) {
+						break
+					}
+					if glyph = be16(sub[i:]); glyph != 0 {
+						glyph += delta
+					}
+				}
+				if glyph != 0 {
+					chars[rune(c)] = glyph
+				}
+			}
+		}
+	case 12: // groups of 32 bit characters.
+		if len(sub) < 16 || int(be32(sub[12:])) > (len(sub)-16)/12 {
+			return nil, fmt.Errorf("Invalid .ttf character map")
+		}
+		for g := 0; g < int(be32(sub[12:])); g++ {
+			i := 16 + 12*g
+			start, end, glyph := be32(sub[i:]), be32(sub[i+4:]), be32(sub[i+8:])
+			for c := start; c <= end && c <= 0x10ffff; c++ {
+				chars[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported .ttf character map format %d", be16(sub))
+	}
+	return chars, nil
+}
+
+// outline returns the flattened contours for the given glyph index.
+// Composite glyphs are limited in depth to guard against bad data.
+func (d *TtfData) outline(index uint16, depth int) [][]float32 {
+	i := int(index)
+	if i+1 >= len(d.loca) || depth > 8 {
+		return nil
+	}
+	start, end := d.loca[i], d.loca[i+1]
+	if end < start+10 || int(end) > len(d.glyf) {
+		return nil // no outline.
+	}
+	b := d.glyf[start:end]
+	if n := int(int16(be16(b))); n >= 0 {
+		return ttfSimple(b, n)
+	}
+	return d.composite(b, depth)
+}
+
+// ttfSimple reads a glyph made of n contours.
+func ttfSimple(b []byte, n int) [][]float32 {
+	p := 10 + 2*n
+	if n == 0 || p+2 > len(b) {
+		return nil
+	}
+	ends := make([]int, n)
+	for cnt := range ends {
+		ends[cnt] = int(be16(b[10+2*cnt:]))
+	}
+	p += 2 + int(be16(b[p:])) // skip instructions.
+	pts := ends[n-1] + 1
+	flags := make([]byte, 0, pts)
+	for len(flags) < pts {
+		if p >= len(b) {
+			return nil
+		}
+		f := b[p]
+		flags, p = append(flags, f), p+1
+		if f&0x08 != 0 { // repeated flag.
+			if p >= len(b) {
+				return nil
+			}
+			for repeat := int(b[p]); repeat > 0 && len(flags) < pts; repeat-- {
+				flags = append(flags, f)
+			}
+			p++
+		}
+	}
+	xs, ys := make([]float32, pts), make([]float32, pts)
+	ok := false
+	if p, ok = ttfCoords(b, p, flags, xs, 0x02, 0x10); !ok {
+		return nil
+	}
+	if _, ok = ttfCoords(b, p, flags, ys, 0x04, 0x20); !ok {
+		return nil
+	}
+	contours, first := [][]float32{}, 0
+	for _, last := range ends {
+		if last < first || last >= pts {
+			return nil
+		}
+		contours = append(contours, ttfContour(xs[first:last+1], ys[first:last+1], flags[first:last+1]))
+		first = last + 1
+	}
+	return contours
+}
+
+// ttfCoords reads the delta encoded x or y coordinates starting at p.
+// Returns the position after the coordinates.
+func ttfCoords(b []byte, p int, flags []byte, vals []float32, short, same byte) (int, bool) {
+	v := 0
+	for cnt, f := range flags {
+		switch {
+		case f&short != 0: // one byte with the sign in the flag.
+			if p >= len(b) {
+				return p, false
+			}
+			if f&same != 0 {
+				v += int(b[p])
+			} else {
+				v -= int(b[p])
+			}
+			p++
+		case f&same == 0: // two byte signed delta.
+			if p+2 > len(b) {
+				return p, false
+			}
+			v += int(int16(be16(b[p:])))
+			p += 2
+		}
+		vals[cnt] = float32(v)
+	}
+	return p, true
+}
+
+// ttfContour flattens quadratic curves into a closed outline.
+// Consecutive off curve points have an implied on curve point between them.
+func ttfContour(xs, ys []float32, flags []byte) []float32 {
+	n := len(xs)
+	start := 0
+	for start < n && flags[start]&0x01 == 0 {
+		start++
+	}
+	sx, sy, first := float32(0), float32(0), start+1
+	if start == n { // only off curve points.
+		sx, sy, first = (xs[n-1]+xs[0])/2, (ys[n-1]+ys[0])/2, 0
+	} else {
+		sx, sy = xs[start], ys[start]
+	}
+	line := []float32{sx, sy}
+	px, py, cx, cy, curve := sx, sy, float32(0), float32(0), false
+	for cnt := 0; cnt < n; cnt++ {
+		i := (first + cnt) % n
+		x, y := xs[i], ys[i]
+		switch {
+		case flags[i]&0x01 != 0 && curve:
+			line = ttfQuad(line, px, py, cx, cy, x, y)
+			px, py, curve = x, y, false
+		case flags[i]&0x01 != 0:
+			line = append(line, x, y)
+			px, py = x, y
+		case curve: // implied on curve midpoint.
+			mx, my := (cx+x)/2, (cy+y)/2
+			line = ttfQuad(line, px, py, cx, cy, mx, my)
+			px, py, cx, cy = mx, my, x, y
+		default:
+			cx, cy, curve = x, y, true
+		}
+	}
+	if curve {
+		line = ttfQuad(line, px, py, cx, cy, sx, sy)
+	}
+	return line
+}
+
+// ttfQuad appends the points of a quadratic curve, excluding the start.
+func ttfQuad(line []float32, x0, y0, cx, cy, x1, y1 float32) []float32 {
+	const steps = 8
+	for s := 1; s <= steps; s++ {
+		t := float32(s) / steps
+		a, b, c := (1-t)*(1-t), 2*(1-t)*t, t*t
+		line = append(line, a*x0+b*cx+c*x1, a*y0+b*cy+c*y1)
+	}
+	return line
+}
+
+// composite reads a glyph made of transformed copies of other glyphs.
+// Components positioned by matching points are placed at the origin.
+func (d *TtfData) composite(b []byte, depth int) (contours [][]float32) {
+	f2dot14 := func(p int) float32 { return float32(int16(be16(b[p:]))) / 16384 }
+	for p := 10; p+4 <= len(b); {
+		flags, index := be16(b[p:]), be16(b[p+2:])
+		p += 4
+		dx, dy := float32(0), float32(0)
+		if flags&0x01 != 0 { // 16 bit arguments.
+			if p+4 > len(b) {
+				return contours
+			}
+			dx, dy = float32(int16(be16(b[p:]))), float32(int16(be16(b[p+2:])))
+			p += 4
+		} else {
+			if p+2 > len(b) {
+				return contours
+			}
+			dx, dy = float32(int8(b[p])), float32(int8(b[p+1]))
+			p += 2
+		}
+		if flags&0x02 == 0 {
+			dx, dy = 0, 0 // point matching is not supported.
+		}
+		xx, xy, yx, yy := float32(1), float32(0), float32(0), float32(1)
+		switch {
+		case flags&0x08 != 0 && p+2 <= len(b): // uniform scale.
+			xx = f2dot14(p)
+			yy, p = xx, p+2
+		case flags&0x40 != 0 && p+4 <= len(b): // x and y scale.
+			xx, yy, p = f2dot14(p), f2dot14(p+2), p+4
+		case flags&0x80 != 0 && p+8 <= len(b): // two by two transform.
+			xx, xy, yx, yy, p = f2dot14(p), f2dot14(p+2), f2dot14(p+4), f2dot14(p+6), p+8
+		}
+		for _, contour := range d.outline(index, depth+1) {
+			for cnt := 0; cnt+1 < len(contour); cnt += 2 {
+				x, y := contour[cnt], contour[cnt+1]
+				contour[cnt], contour[cnt+1] = xx*x+yx*y+dx, xy*x+yy*y+dy
+			}
+			contours = append(contours, contour)
+		}
+		if flags&0x20 == 0 { // no more components.
+			break
+		}
+	}
+	return contours
+}
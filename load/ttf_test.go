@@ -0,0 +1,93 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ttfFont creates a small TrueType font with 1000 units per em where A is
+// a 400 unit square, B is the square moved by 50,100 as a composite glyph,
+// and C is a curved diamond with only off curve points.
+func ttfFont() []byte {
+	be := func(vals ...interface{}) []byte {
+		b := &bytes.Buffer{}
+		for _, v := range vals {
+			binary.Write(b, binary.BigEndian, v)
+		}
+		return b.Bytes()
+	}
+	square := be(int16(1), int16(100), int16(0), int16(500), int16(400), uint16(3), uint16(0),
+		[]byte{1, 1, 1, 1}, []int16{100, 400, 0, -400}, []int16{0, 0, 400, 0})
+	moved := be(int16(-1), int16(150), int16(100), int16(550), int16(500),
+		uint16(0x0003), uint16(1), int16(50), int16(100))
+	diamond := be(int16(1), int16(0), int16(0), int16(600), int16(600), uint16(3), uint16(0),
+		[]byte{0, 0, 0, 0}, []int16{300, 300, -300, -300}, []int16{0, 300, 300, -300})
+	glyf := append(append(append([]byte{}, square...), moved...), diamond...)
+	loca := be([]uint16{0, 0, uint16(len(square) / 2),
+		uint16((len(square) + len(moved)) / 2), uint16(len(glyf) / 2)})
+	cmap := be(uint16(0), uint16(1), uint16(3), uint16(1), uint32(12), // one subtable.
+		uint16(4), uint16(32), uint16(0), uint16(4), uint16(4), uint16(1), uint16(0), // format 4.
+		[]uint16{67, 0xffff}, uint16(0), []uint16{65, 0xffff}, // end and start codes.
+		[]int16{1 - 65, 1}, []uint16{0, 0}) // deltas and range offsets.
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 1000) // units per em.
+	hhea := make([]byte, 36)
+	binary.BigEndian.PutUint16(hhea[4:], 800)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(0xffff-199)) // -200.
+	binary.BigEndian.PutUint16(hhea[34:], 4)
+	maxp := be(uint32(0x00005000), uint16(4))
+	hmtx := be([]uint16{500, 0, 600, 0, 700, 0, 800, 0})
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap}, {"glyf", glyf}, {"head", head}, {"hhea", hhea},
+		{"hmtx", hmtx}, {"loca", loca}, {"maxp", maxp}}
+	font := be(uint32(0x00010000), uint16(len(tables)), uint16(0), uint16(0), uint16(0))
+	offset := len(font) + 16*len(tables)
+	data := []byte{}
+	for _, t := range tables {
+		font = append(font, be([]byte(t.tag), uint32(0), uint32(offset+len(data)), uint32(len(t.data)))...)
+		data = append(data, t.data...)
+	}
+	return append(font, data...)
+}
+
+func TestTtf(t *testing.T) {
+	d := &TtfData{}
+	if err := Ttf(bytes.NewReader(ttfFont()), d); err != nil {
+		t.Fatal(err)
+	}
+	if d.UnitsPerEm != 1000 || d.Ascent != 800 || d.Descent != -200 {
+		t.Errorf("Bad metrics %d %d %d", d.UnitsPerEm, d.Ascent, d.Descent)
+	}
+	a, _ := d.Glyph('A')
+	if a.Advance != 600 || len(a.Contours) != 1 || len(a.Contours[0]) != 10 {
+		t.Errorf("Bad square glyph %d %v", a.Advance, a.Contours)
+	}
+	b, _ := d.Glyph('B')
+	if b.Advance != 700 || b.Xmin != 150 || b.Ymin != 100 || b.Xmax != 550 || b.Ymax != 500 {
+		t.Errorf("Bad composite glyph %+v", b)
+	}
+	c, _ := d.Glyph('C')
+	if len(c.Contours[0]) != 2*(1+4*8) || c.Ymin != 75 || c.Xmax != 525 {
+		t.Errorf("Bad curved glyph %d %+v", len(c.Contours[0]), c)
+	}
+	if _, ok := d.Glyph('D'); ok {
+		t.Error("Expected no glyph for unmapped character")
+	}
+}
+
+func TestTtfInvalid(t *testing.T) {
+	font := ttfFont()
+	if err := Ttf(bytes.NewReader(font[:40]), &TtfData{}); err == nil {
+		t.Error("Expected truncated table error")
+	}
+	copy(font[12:], "xxxx") // rename cmap.
+	if err := Ttf(bytes.NewReader(font), &TtfData{}); err == nil {
+		t.Error("Expected missing table error")
+	}
+}
@@ -0,0 +1,1067 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+// vorbis.go decodes Vorbis I audio packets. The specification is at:
+//    https://xiph.org/vorbis/doc/Vorbis_I_spec.html
+// DESIGN: The decoder follows the specification step by step rather than
+//         the heavily optimized reference decoder. The inverse MDCT is
+//         computed using a quarter size complex FFT.
+// FUTURE: Floor type 0, which is not used by any current encoder.
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// vorbis holds the stream setup from the header packets along with the
+// state needed to overlap consecutive audio packets.
+type vorbis struct {
+	channels  int             // Number of audio channels.
+	rate      uint32          // Samples per second.
+	blocksize [2]int          // Short and long block sizes.
+	books     []vorbisBook    // Huffman and vector codebooks.
+	floors    []vorbisFloor   // Spectral envelopes.
+	residues  []vorbisResidue // Spectral details.
+	mappings  []vorbisMapping // Channel to floor and residue mappings.
+	modes     []vorbisMode    // Block size and mapping for each packet.
+
+	// decoding state.
+	prev    [][]float32          // Previous windowed block for each channel.
+	windows map[[3]int][]float32 // Windows by size and neighbour sizes.
+	mdcts   map[int]*vorbisMdct  // Inverse MDCTs by block size.
+}
+
+// newVorbis reads the identification, comment, and setup header packets.
+func newVorbis(o *oggReader) (*vorbis, error) {
+	v := &vorbis{windows: map[[3]int][]float32{}, mdcts: map[int]*vorbisMdct{}}
+	for kind := uint32(1); kind <= 5; kind += 2 {
+		packet, err := o.next()
+		if err != nil {
+			return nil, err
+		}
+		b := &vorbisBits{data: packet}
+		if b.read(8) != kind || string(packet[1:7]) != "vorbis" {
+			return nil, fmt.Errorf("missing vorbis header %d", kind)
+		}
+		b.pos = 7 * 8
+		switch kind {
+		case 1:
+			err = v.identification(b)
+		case 5:
+			err = v.setup(b)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	v.prev = make([][]float32, v.channels)
+	return v, nil
+}
+
+// identification reads the audio properties.
+func (v *vorbis) identification(b *vorbisBits) error {
+	if b.read(32) != 0 {
+		return fmt.Errorf("unsupported vorbis version")
+	}
+	v.channels = int(b.read(8))
+	v.rate = b.read(32)
+	b.read(32) // maximum bitrate.
+	b.read(32) // nominal bitrate.
+	b.read(32) // minimum bitrate.
+	sizes := b.read(8)
+	v.blocksize = [2]int{1 << (sizes & 0xF), 1 << (sizes >> 4)}
+	if v.channels == 0 || v.rate == 0 || sizes&0xF < 6 || sizes>>4 > 13 ||
+		v.blocksize[0] > v.blocksize[1] || b.read(1) != 1 || b.eop {
+		return fmt.Errorf("invalid vorbis identification header")
+	}
+	return nil
+}
+
+// setup reads the codebooks, floors, residues, mappings, and modes.
+func (v *vorbis) setup(b *vorbisBits) error {
+	v.books = make([]vorbisBook, b.read(8)+1)
+	for cnt := range v.books {
+		if err := v.books[cnt].setup(b); err != nil {
+			return err
+		}
+	}
+	for cnt := b.read(6) + 1; cnt > 0; cnt-- {
+		if b.read(16) != 0 {
+			return fmt.Errorf("invalid vorbis time domain transform")
+		}
+	}
+	v.floors = make([]vorbisFloor, b.read(6)+1)
+	for cnt := range v.floors {
+		if err := v.floors[cnt].setup(b, v.books); err != nil {
+			return err
+		}
+	}
+	v.residues = make([]vorbisResidue, b.read(6)+1)
+	for cnt := range v.residues {
+		if err := v.residues[cnt].setup(b, v.books); err != nil {
+			return err
+		}
+	}
+	v.mappings = make([]vorbisMapping, b.read(6)+1)
+	for cnt := range v.mappings {
+		if err := v.mappings[cnt].setup(b, v); err != nil {
+			return err
+		}
+	}
+	v.modes = make([]vorbisMode, b.read(6)+1)
+	for cnt := range v.modes {
+		m := &v.modes[cnt]
+		m.long = b.read(1) == 1
+		window, transform, mapping := b.read(16), b.read(16), int(b.read(8))
+		if window != 0 || transform != 0 || mapping >= len(v.mappings) {
+			return fmt.Errorf("invalid vorbis mode %d", cnt)
+		}
+		m.mapping = mapping
+	}
+	if b.read(1) != 1 || b.eop {
+		return fmt.Errorf("invalid vorbis setup header")
+	}
+	return nil
+}
+
+// decode returns the audio samples for each channel from one audio
+// packet. The first packet returns no samples since it is only used
+// to start the overlap with the following packet.
+func (v *vorbis) decode(packet []byte) ([][]float32, error) {
+	if len(packet) == 0 {
+		return nil, nil // empty packets are allowed.
+	}
+	b := &vorbisBits{data: packet}
+	if b.read(1) != 0 {
+		return nil, fmt.Errorf("not an audio packet")
+	}
+	mode := int(b.read(ilog(uint32(len(v.modes) - 1))))
+	if mode >= len(v.modes) {
+		return nil, fmt.Errorf("invalid mode %d", mode)
+	}
+	m := v.modes[mode]
+	n, prevLong, nextLong := v.blocksize[0], false, false
+	if m.long {
+		n = v.blocksize[1]
+		prevLong, nextLong = b.read(1) == 1, b.read(1) == 1
+	}
+	if b.eop {
+		return nil, fmt.Errorf("truncated audio packet")
+	}
+	mp := &v.mappings[m.mapping]
+
+	// decode the floors, and then the residues for channels with floors.
+	ys := make([][]int, v.channels)
+	skip := make([]bool, v.channels)
+	for ch := range ys {
+		ys[ch] = v.floors[mp.floors[mp.mux[ch]]].decode(b, v.books)
+		skip[ch] = ys[ch] == nil
+	}
+	for _, c := range mp.couplings {
+		if !skip[c[0]] || !skip[c[1]] {
+			skip[c[0]], skip[c[1]] = false, false
+		}
+	}
+	half := n / 2
+	vs := make([][]float32, v.channels)
+	for ch := range vs {
+		vs[ch] = make([]float32, half)
+	}
+	for sub, res := range mp.residues {
+		chans, chanSkip := [][]float32{}, []bool{}
+		for ch := range vs {
+			if mp.mux[ch] == sub {
+				chans, chanSkip = append(chans, vs[ch]), append(chanSkip, skip[ch])
+			}
+		}
+		v.residues[res].decode(b, v.books, chans, chanSkip)
+	}
+
+	// undo the channel coupling.
+	for cnt := len(mp.couplings) - 1; cnt >= 0; cnt-- {
+		mag, ang := vs[mp.couplings[cnt][0]], vs[mp.couplings[cnt][1]]
+		for i := range mag {
+			m, a := mag[i], ang[i]
+			switch {
+			case m > 0 && a > 0:
+				mag[i], ang[i] = m, m-a
+			case m > 0:
+				mag[i], ang[i] = m+a, m
+			case a > 0:
+				mag[i], ang[i] = m, m+a
+			default:
+				mag[i], ang[i] = m-a, m
+			}
+		}
+	}
+
+	// apply the floor curve, transform, and overlap with the previous block.
+	curve := make([]float32, half)
+	window := v.window(n, m.long, prevLong, nextLong)
+	mdct := v.mdct(n)
+	out := make([][]float32, v.channels)
+	for ch := range vs {
+		block := make([]float32, n)
+		if ys[ch] != nil {
+			v.floors[mp.floors[mp.mux[ch]]].curve(ys[ch], curve)
+			for i := range vs[ch] {
+				vs[ch][i] *= curve[i]
+			}
+			mdct.inverse(vs[ch], block)
+			for i := range block {
+				block[i] *= window[i]
+			}
+		}
+		if prev := v.prev[ch]; prev != nil {
+			pn := len(prev)
+			out[ch] = make([]float32, pn/4+n/4)
+			for k := range out[ch] {
+				if j := n/4 - pn/4 + k; j >= 0 {
+					out[ch][k] += block[j]
+				}
+				if p := pn/2 + k; p < pn {
+					out[ch][k] += prev[p]
+				}
+			}
+		}
+		v.prev[ch] = block
+	}
+	if out[0] == nil {
+		return nil, nil // first audio packet.
+	}
+	return out, nil
+}
+
+// window returns the overlap window for a block of size n.
+// Long blocks have shorter slopes next to short blocks.
+func (v *vorbis) window(n int, long, prevLong, nextLong bool) []float32 {
+	key := [3]int{n, 0, 0}
+	if long && !prevLong {
+		key[1] = 1
+	}
+	if long && !nextLong {
+		key[2] = 1
+	}
+	if w, ok := v.windows[key]; ok {
+		return w
+	}
+	short := v.blocksize[0]
+	ls, le, ln := 0, n/2, n/2
+	if key[1] == 1 {
+		ls, le, ln = n/4-short/4, n/4+short/4, short/2
+	}
+	rs, re, rn := n/2, n, n/2
+	if key[2] == 1 {
+		rs, re, rn = n*3/4-short/4, n*3/4+short/4, short/2
+	}
+	w := make([]float32, n)
+	for i := ls; i < le; i++ {
+		s := math.Sin((float64(i-ls) + 0.5) / float64(ln) * math.Pi / 2)
+		w[i] = float32(math.Sin(math.Pi / 2 * s * s))
+	}
+	for i := le; i < rs; i++ {
+		w[i] = 1
+	}
+	for i := rs; i < re; i++ {
+		s := math.Sin((float64(i-rs)+0.5)/float64(rn)*math.Pi/2 + math.Pi/2)
+		w[i] = float32(math.Sin(math.Pi / 2 * s * s))
+	}
+	v.windows[key] = w
+	return w
+}
+
+// mdct returns the inverse MDCT for blocks of size n.
+func (v *vorbis) mdct(n int) *vorbisMdct {
+	if m, ok := v.mdcts[n]; ok {
+		return m
+	}
+	m := newVorbisMdct(n)
+	v.mdcts[n] = m
+	return m
+}
+
+// vorbisPCM appends the samples, interleaved and converted to
+// 16 bits, as little endian bytes.
+func vorbisPCM(pcm []byte, samples [][]float32) []byte {
+	if len(samples) == 0 {
+		return pcm
+	}
+	for cnt := range samples[0] {
+		for ch := range samples {
+			s := int32(samples[ch][cnt] * 32767)
+			switch {
+			case s > 32767:
+				s = 32767
+			case s < -32768:
+				s = -32768
+			}
+			pcm = append(pcm, byte(s), byte(s>>8))
+		}
+	}
+	return pcm
+}
+
+// =============================================================================
+
+// vorbisBits reads least significant bit first values from a packet.
+// Reading past the end of the packet sets eop and returns zeros.
+type vorbisBits struct {
+	data []byte // Packet data.
+	pos  int    // Bit position.
+	eop  bool   // True if the end of packet was reached.
+}
+
+// read returns the next n bits, up to 32.
+func (b *vorbisBits) read(n uint) (v uint32) {
+	for got := uint(0); got < n; {
+		at := b.pos >> 3
+		if at >= len(b.data) {
+			b.eop = true
+			return 0
+		}
+		off := uint(b.pos & 7)
+		take := 8 - off
+		if n-got < take {
+			take = n - got
+		}
+		v |= uint32(b.data[at]>>off) & (1<<take - 1) << got
+		got += take
+		b.pos += int(take)
+	}
+	return v
+}
+
+// ilog returns the number of bits needed to hold x.
+func ilog(x uint32) uint { return uint(bits.Len32(x)) }
+
+// =============================================================================
+
+// vorbisBook is a Huffman codebook that decodes entry numbers. Books with
+// vector lookups also map each entry to a vector of dims values.
+type vorbisBook struct {
+	dims    int        // Values for each vector entry.
+	lengths []uint8    // Codeword lengths. 0 for unused entries.
+	tree    [][2]int32 // Decode tree. Leaves are -entry-1.
+	single  int        // The entry for books with one codeword, otherwise -1.
+	vectors []float32  // Vector values. Nil for scalar books.
+}
+
+// setup reads the codebook from the setup header.
+func (cb *vorbisBook) setup(b *vorbisBits) error {
+	if b.read(24) != 0x564342 {
+		return fmt.Errorf("invalid vorbis codebook")
+	}
+	cb.dims = int(b.read(16))
+	entries := int(b.read(24))
+	if entries > len(b.data)*8 {
+		return fmt.Errorf("invalid vorbis codebook size %d", entries)
+	}
+	cb.lengths = make([]uint8, entries)
+	if b.read(1) == 0 { // unordered.
+		sparse := b.read(1) == 1
+		for cnt := range cb.lengths {
+			if !sparse || b.read(1) == 1 {
+				cb.lengths[cnt] = uint8(b.read(5) + 1)
+			}
+		}
+	} else {
+		length := int(b.read(5)) + 1
+		for entry := 0; entry < entries; length++ {
+			num := int(b.read(ilog(uint32(entries - entry))))
+			if entry+num > entries || length > 32 {
+				return fmt.Errorf("invalid vorbis codebook lengths")
+			}
+			for ; num > 0; num-- {
+				cb.lengths[entry] = uint8(length)
+				entry++
+			}
+		}
+	}
+	if err := cb.build(); err != nil {
+		return err
+	}
+
+	// vector lookup tables.
+	switch lookup := b.read(4); lookup {
+	case 0:
+	case 1, 2:
+		min, delta := float32Unpack(b.read(32)), float32Unpack(b.read(32))
+		vbits, sequence := uint(b.read(4))+1, b.read(1) == 1
+		values := entries * cb.dims
+		if lookup == 1 {
+			values = lookup1Values(entries, cb.dims)
+		}
+		if cb.dims == 0 || values == 0 || values > len(b.data)*8 {
+			return fmt.Errorf("invalid vorbis codebook lookup")
+		}
+		mults := make([]float32, values)
+		for cnt := range mults {
+			mults[cnt] = float32(b.read(vbits))
+		}
+		cb.vectors = make([]float32, entries*cb.dims)
+		for entry := 0; entry < entries; entry++ {
+			last, div := float32(0), 1
+			for d := 0; d < cb.dims; d++ {
+				off := entry*cb.dims + d
+				if lookup == 1 {
+					off = entry / div % values
+					div *= values
+				}
+				val := mults[off]*delta + min + last
+				if sequence {
+					last = val
+				}
+				cb.vectors[entry*cb.dims+d] = val
+			}
+		}
+	default:
+		return fmt.Errorf("invalid vorbis codebook lookup %d", lookup)
+	}
+	if b.eop {
+		return fmt.Errorf("truncated vorbis codebook")
+	}
+	return nil
+}
+
+// build creates the decode tree by assigning each used entry, in order,
+// the lowest available codeword of its length.
+func (cb *vorbisBook) build() error {
+	cb.single = -1
+	used := 0
+	for entry, length := range cb.lengths {
+		if length > 0 {
+			used++
+			cb.single = entry
+		}
+	}
+	if used <= 1 {
+		return nil // any codeword of the given length decodes the entry.
+	}
+	cb.single = -1
+	cb.tree = make([][2]int32, 1, used)
+	var available [33]uint64 // next free codeword at each length, left aligned.
+	first := true
+	for entry, length := range cb.lengths {
+		if length == 0 {
+			continue
+		}
+		code := uint64(0)
+		if first {
+			first = false
+			for i := 1; i <= int(length); i++ {
+				available[i] = 1 << uint(32-i)
+			}
+		} else {
+			z := int(length)
+			for z > 0 && available[z] == 0 {
+				z--
+			}
+			if z == 0 {
+				return fmt.Errorf("invalid vorbis codebook overspecified")
+			}
+			code = available[z]
+			available[z] = 0
+			for y := int(length); y > z; y-- {
+				available[y] = code + 1<<uint(32-y)
+			}
+		}
+		if err := cb.insert(uint32(code>>uint(32-length)), uint(length), entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert adds the codeword for the entry to the decode tree.
+func (cb *vorbisBook) insert(code uint32, length uint, entry int) error {
+	node := 0
+	for i := int(length) - 1; i >= 0; i-- {
+		bit := (code >> uint(i)) & 1
+		next := cb.tree[node][bit]
+		switch {
+		case next < 0 || (i == 0 && next != 0):
+			return fmt.Errorf("invalid vorbis codebook tree")
+		case i == 0:
+			cb.tree[node][bit] = int32(-entry - 1)
+		case next == 0:
+			cb.tree = append(cb.tree, [2]int32{})
+			next = int32(len(cb.tree) - 1)
+			cb.tree[node][bit] = next
+		}
+		node = int(next)
+	}
+	return nil
+}
+
+// decode returns the next entry, or -1 at the end of the packet.
+func (cb *vorbisBook) decode(b *vorbisBits) int {
+	if cb.tree == nil {
+		if cb.single < 0 {
+			return -1
+		}
+		b.read(uint(cb.lengths[cb.single]))
+		if b.eop {
+			return -1
+		}
+		return cb.single
+	}
+	node := int32(0)
+	for {
+		node = cb.tree[node][b.read(1)]
+		switch {
+		case b.eop || node == 0:
+			return -1
+		case node < 0:
+			return int(-node - 1)
+		}
+	}
+}
+
+// vector returns the vector values for the next entry,
+// or nil at the end of the packet.
+func (cb *vorbisBook) vector(b *vorbisBits) []float32 {
+	entry := cb.decode(b)
+	if entry < 0 {
+		return nil
+	}
+	return cb.vectors[entry*cb.dims : (entry+1)*cb.dims]
+}
+
+// float32Unpack converts the vorbis packed float format.
+func float32Unpack(x uint32) float32 {
+	mantissa := float64(x & 0x1fffff)
+	if x&0x80000000 != 0 {
+		mantissa = -mantissa
+	}
+	return float32(math.Ldexp(mantissa, int((x&0x7fe00000)>>21)-788))
+}
+
+// lookup1Values returns the largest integer r where r to the power
+// of dims is less than or equal to entries.
+func lookup1Values(entries, dims int) int {
+	if dims == 0 {
+		return 0
+	}
+	r := int(math.Floor(math.Pow(float64(entries), 1/float64(dims))))
+	pow := func(r int) int {
+		p := 1
+		for cnt := 0; cnt < dims && p <= entries; cnt++ {
+			p *= r
+		}
+		return p
+	}
+	for pow(r+1) <= entries {
+		r++
+	}
+	for r > 0 && pow(r) > entries {
+		r--
+	}
+	return r
+}
+
+// =============================================================================
+
+// vorbisFloor is a type 1 floor: a piecewise linear spectral envelope.
+type vorbisFloor struct {
+	classes    []int   // Partition class for each partition.
+	dims       []int   // Dimensions for each class.
+	subs       []uint  // Subclass bits for each class.
+	masters    []int   // Master book for each class.
+	books      [][]int // Subclass books for each class. -1 for none.
+	multiplier int     // Amplitude scale.
+	xs         []int   // Curve point positions.
+	sorted     []int   // Curve point indicies in increasing x order.
+	low, high  []int   // Neighbouring curve points used for prediction.
+}
+
+// floor1Range is the amplitude range for each multiplier.
+var floor1Range = [4]int{256, 128, 86, 64}
+
+// setup reads the floor from the setup header.
+func (f *vorbisFloor) setup(b *vorbisBits, books []vorbisBook) error {
+	if kind := b.read(16); kind != 1 {
+		return fmt.Errorf("unsupported vorbis floor type %d", kind)
+	}
+	f.classes = make([]int, b.read(5))
+	classes := 0
+	for cnt := range f.classes {
+		f.classes[cnt] = int(b.read(4))
+		if f.classes[cnt] >= classes {
+			classes = f.classes[cnt] + 1
+		}
+	}
+	f.dims, f.subs = make([]int, classes), make([]uint, classes)
+	f.masters, f.books = make([]int, classes), make([][]int, classes)
+	for c := 0; c < classes; c++ {
+		f.dims[c] = int(b.read(3)) + 1
+		f.subs[c] = uint(b.read(2))
+		if f.subs[c] > 0 {
+			if f.masters[c] = int(b.read(8)); f.masters[c] >= len(books) {
+				return fmt.Errorf("invalid vorbis floor book")
+			}
+		}
+		f.books[c] = make([]int, 1<<f.subs[c])
+		for j := range f.books[c] {
+			if f.books[c][j] = int(b.read(8)) - 1; f.books[c][j] >= len(books) {
+				return fmt.Errorf("invalid vorbis floor book")
+			}
+		}
+	}
+	f.multiplier = int(b.read(2)) + 1
+	rangeBits := uint(b.read(4))
+	f.xs = []int{0, 1 << rangeBits}
+	for _, c := range f.classes {
+		for j := 0; j < f.dims[c]; j++ {
+			f.xs = append(f.xs, int(b.read(rangeBits)))
+		}
+	}
+	if len(f.xs) > 65 {
+		return fmt.Errorf("invalid vorbis floor size")
+	}
+
+	// sort the points and find the neighbours of each point.
+	f.sorted = make([]int, len(f.xs))
+	for i := range f.sorted {
+		f.sorted[i] = i
+		for j := i; j > 0 && f.xs[f.sorted[j]] < f.xs[f.sorted[j-1]]; j-- {
+			f.sorted[j], f.sorted[j-1] = f.sorted[j-1], f.sorted[j]
+		}
+	}
+	for i := 1; i < len(f.sorted); i++ {
+		if f.xs[f.sorted[i]] == f.xs[f.sorted[i-1]] {
+			return fmt.Errorf("invalid vorbis floor points")
+		}
+	}
+	f.low, f.high = make([]int, len(f.xs)), make([]int, len(f.xs))
+	for i := 2; i < len(f.xs); i++ {
+		f.low[i], f.high[i] = 0, 1
+		for j := 0; j < i; j++ {
+			if f.xs[j] < f.xs[i] && f.xs[j] > f.xs[f.low[i]] {
+				f.low[i] = j
+			}
+			if f.xs[j] > f.xs[i] && f.xs[j] < f.xs[f.high[i]] {
+				f.high[i] = j
+			}
+		}
+	}
+	return nil
+}
+
+// decode reads the curve amplitudes, returning nil if the channel
+// is unused for this packet.
+func (f *vorbisFloor) decode(b *vorbisBits, books []vorbisBook) []int {
+	if b.read(1) == 0 {
+		return nil
+	}
+	ys := make([]int, len(f.xs))
+	ybits := ilog(uint32(floor1Range[f.multiplier-1] - 1))
+	ys[0], ys[1] = int(b.read(ybits)), int(b.read(ybits))
+	at := 2
+	for _, c := range f.classes {
+		cbits, cval := f.subs[c], 0
+		if cbits > 0 {
+			if cval = books[f.masters[c]].decode(b); cval < 0 {
+				return nil
+			}
+		}
+		for j := 0; j < f.dims[c]; j++ {
+			book := f.books[c][cval&(1<<cbits-1)]
+			cval >>= cbits
+			if book >= 0 {
+				if ys[at+j] = books[book].decode(b); ys[at+j] < 0 {
+					return nil
+				}
+			}
+		}
+		at += f.dims[c]
+	}
+	if b.eop {
+		return nil
+	}
+	return ys
+}
+
+// curve computes the floor curve from the decoded amplitudes.
+func (f *vorbisFloor) curve(ys []int, out []float32) {
+	rng := floor1Range[f.multiplier-1]
+	final, used := make([]int, len(ys)), make([]bool, len(ys))
+	final[0], final[1], used[0], used[1] = ys[0], ys[1], true, true
+	for i := 2; i < len(ys); i++ {
+		lo, hi := f.low[i], f.high[i]
+		predicted := renderPoint(f.xs[lo], final[lo], f.xs[hi], final[hi], f.xs[i])
+		val, highroom, lowroom := ys[i], rng-predicted, predicted
+		room := lowroom * 2
+		if highroom < lowroom {
+			room = highroom * 2
+		}
+		if val == 0 {
+			final[i] = predicted
+			continue
+		}
+		used[lo], used[hi], used[i] = true, true, true
+		switch {
+		case val >= room && highroom > lowroom:
+			final[i] = val - lowroom + predicted
+		case val >= room:
+			final[i] = predicted - val + highroom - 1
+		case val&1 == 1:
+			final[i] = predicted - (val+1)/2
+		default:
+			final[i] = predicted + val/2
+		}
+	}
+	lx, ly := 0, final[f.sorted[0]]*f.multiplier
+	hx, hy := 0, 0
+	for _, i := range f.sorted[1:] {
+		if used[i] {
+			hx, hy = f.xs[i], final[i]*f.multiplier
+			renderLine(lx, ly, hx, hy, out)
+			lx, ly = hx, hy
+		}
+	}
+	if hx < len(out) {
+		renderLine(hx, hy, len(out), hy, out)
+	}
+}
+
+// renderPoint predicts the amplitude at x on the line between two points.
+func renderPoint(x0, y0, x1, y1, x int) int {
+	dy, adx := y1-y0, x1-x0
+	off := abs(dy) * (x - x0) / adx
+	if dy < 0 {
+		return y0 - off
+	}
+	return y0 + off
+}
+
+// renderLine draws the integer line from x0 to x1, excluding x1,
+// converting the amplitudes to linear floor values.
+func renderLine(x0, y0, x1, y1 int, out []float32) {
+	dy, adx := y1-y0, x1-x0
+	base := dy / adx
+	sy := base + 1
+	if dy < 0 {
+		sy = base - 1
+	}
+	ady := abs(dy) - abs(base)*adx
+	y, err := y0, 0
+	if x0 < len(out) {
+		out[x0] = floor1DB(y)
+	}
+	for x := x0 + 1; x < x1 && x < len(out); x++ {
+		if err += ady; err >= adx {
+			err -= adx
+			y += sy
+		} else {
+			y += base
+		}
+		out[x] = floor1DB(y)
+	}
+}
+
+// floor1DB converts a floor amplitude to a linear value. The amplitudes
+// cover 140dB in 256 steps.
+func floor1DB(y int) float32 {
+	if y < 0 {
+		y = 0
+	} else if y > 255 {
+		y = 255
+	}
+	return floor1Table[y]
+}
+
+// floor1Table is the inverse dB table for floor amplitudes.
+var floor1Table = func() (table [256]float32) {
+	for cnt := range table {
+		table[cnt] = float32(math.Pow(10, float64(cnt-255)*140/256/20))
+	}
+	return table
+}()
+
+// abs returns the absolute value of an integer.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// =============================================================================
+
+// vorbisResidue holds the spectral details left after removing the floor.
+type vorbisResidue struct {
+	kind       int      // Residue type 0, 1, or 2.
+	begin, end int      // Range of coded values.
+	size       int      // Values in each partition.
+	classes    int      // Number of partition classes.
+	classbook  int      // Book for partition classes.
+	books      [][8]int // Book for each class and pass. -1 for none.
+}
+
+// setup reads the residue from the setup header.
+func (r *vorbisResidue) setup(b *vorbisBits, books []vorbisBook) error {
+	r.kind = int(b.read(16))
+	r.begin, r.end, r.size = int(b.read(24)), int(b.read(24)), int(b.read(24))+1
+	r.classes, r.classbook = int(b.read(6))+1, int(b.read(8))
+	if r.kind > 2 || r.classbook >= len(books) || books[r.classbook].dims == 0 {
+		return fmt.Errorf("invalid vorbis residue")
+	}
+	cascade := make([]uint32, r.classes)
+	for cnt := range cascade {
+		cascade[cnt] = b.read(3)
+		if b.read(1) == 1 {
+			cascade[cnt] |= b.read(5) << 3
+		}
+	}
+	r.books = make([][8]int, r.classes)
+	for cnt := range r.books {
+		for pass := range r.books[cnt] {
+			r.books[cnt][pass] = -1
+			if cascade[cnt]&(1<<uint(pass)) != 0 {
+				book := int(b.read(8))
+				if book >= len(books) || books[book].vectors == nil {
+					return fmt.Errorf("invalid vorbis residue book")
+				}
+				r.books[cnt][pass] = book
+			}
+		}
+	}
+	return nil
+}
+
+// decode adds the residue values to the channel vectors.
+// Channels with skip set are not decoded.
+func (r *vorbisResidue) decode(b *vorbisBits, books []vorbisBook, vs [][]float32, skip []bool) {
+	if r.kind != 2 {
+		r.partitions(b, books, vs, skip)
+		return
+	}
+
+	// type 2 interleaves the channels into one vector.
+	for cnt := range skip {
+		if !skip[cnt] {
+			ch, n := len(vs), len(vs[0])
+			all := make([]float32, ch*n)
+			r.partitions(b, books, [][]float32{all}, []bool{false})
+			for i, s := range all {
+				vs[i%ch][i/ch] += s
+			}
+			return
+		}
+	}
+}
+
+// partitions decodes the classified partitions of each vector.
+func (r *vorbisResidue) partitions(b *vorbisBits, books []vorbisBook, vs [][]float32, skip []bool) {
+	size := len(vs[0])
+	begin, end := r.begin, r.end
+	if begin > size {
+		begin = size
+	}
+	if end > size {
+		end = size
+	}
+	cb := &books[r.classbook]
+	per, count := cb.dims, (end-begin)/r.size
+	if count <= 0 {
+		return
+	}
+	classes := make([][]int, len(vs))
+	for ch := range classes {
+		classes[ch] = make([]int, count+per)
+	}
+	for pass := 0; pass < 8; pass++ {
+		for p := 0; p < count; {
+			if pass == 0 {
+				for ch := range vs {
+					if skip[ch] {
+						continue
+					}
+					temp := cb.decode(b)
+					if temp < 0 {
+						return
+					}
+					for i := per - 1; i >= 0; i-- {
+						classes[ch][i+p] = temp % r.classes
+						temp /= r.classes
+					}
+				}
+			}
+			for i := 0; i < per && p < count; i, p = i+1, p+1 {
+				for ch := range vs {
+					if skip[ch] {
+						continue
+					}
+					book := r.books[classes[ch][p]][pass]
+					if book < 0 {
+						continue
+					}
+					at := begin + p*r.size
+					if !r.vq(b, &books[book], vs[ch][at:at+r.size]) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// vq adds the vector values for one partition. Type 0 residues
+// interleave the vector values. Returns false at the end of the packet.
+func (r *vorbisResidue) vq(b *vorbisBits, cb *vorbisBook, v []float32) bool {
+	if r.kind == 0 {
+		step := len(v) / cb.dims
+		for i := 0; i < step; i++ {
+			vec := cb.vector(b)
+			if vec == nil {
+				return false
+			}
+			for j, val := range vec {
+				v[i+j*step] += val
+			}
+		}
+		return true
+	}
+	for i := 0; i < len(v); {
+		vec := cb.vector(b)
+		if vec == nil {
+			return false
+		}
+		for j := 0; j < len(vec) && i < len(v); j, i = j+1, i+1 {
+			v[i] += vec[j]
+		}
+	}
+	return true
+}
+
+// =============================================================================
+
+// vorbisMapping assigns each channel to a submap of floor and residue.
+type vorbisMapping struct {
+	couplings [][2]int // Magnitude and angle channel pairs.
+	mux       []int    // Submap for each channel.
+	floors    []int    // Floor for each submap.
+	residues  []int    // Residue for each submap.
+}
+
+// setup reads the mapping from the setup header.
+func (mp *vorbisMapping) setup(b *vorbisBits, v *vorbis) error {
+	if b.read(16) != 0 {
+		return fmt.Errorf("invalid vorbis mapping type")
+	}
+	submaps := 1
+	if b.read(1) == 1 {
+		submaps = int(b.read(4)) + 1
+	}
+	if b.read(1) == 1 {
+		cbits := ilog(uint32(v.channels - 1))
+		for steps := b.read(8) + 1; steps > 0; steps-- {
+			mag, ang := int(b.read(cbits)), int(b.read(cbits))
+			if mag == ang || mag >= v.channels || ang >= v.channels {
+				return fmt.Errorf("invalid vorbis channel coupling")
+			}
+			mp.couplings = append(mp.couplings, [2]int{mag, ang})
+		}
+	}
+	if b.read(2) != 0 {
+		return fmt.Errorf("invalid vorbis mapping")
+	}
+	mp.mux = make([]int, v.channels)
+	if submaps > 1 {
+		for ch := range mp.mux {
+			if mp.mux[ch] = int(b.read(4)); mp.mux[ch] >= submaps {
+				return fmt.Errorf("invalid vorbis mapping submap")
+			}
+		}
+	}
+	for cnt := 0; cnt < submaps; cnt++ {
+		b.read(8) // unused time configuration.
+		floor, residue := int(b.read(8)), int(b.read(8))
+		if floor >= len(v.floors) || residue >= len(v.residues) {
+			return fmt.Errorf("invalid vorbis mapping submap")
+		}
+		mp.floors, mp.residues = append(mp.floors, floor), append(mp.residues, residue)
+	}
+	return nil
+}
+
+// vorbisMode gives the block size and mapping for an audio packet.
+type vorbisMode struct {
+	long    bool // True for long blocks.
+	mapping int  // Index into the mappings.
+}
+
+// =============================================================================
+
+// vorbisMdct computes the inverse modified discrete cosine transform
+//     y[i] = sum(x[k] * cos(pi/n * (2i + 1 + n/2) * (2k + 1) / 2))
+// for n outputs from n/2 inputs. The transform is calculated as a
+// DCT-IV using an n/4 point complex FFT with pre and post twiddles.
+type vorbisMdct struct {
+	n       int          // Block size.
+	twiddle []complex128 // Pre and post FFT rotations.
+	roots   []complex128 // FFT roots of unity.
+	rev     []int        // FFT bit reversed indicies.
+	z       []complex128 // FFT work buffer.
+	u       []float64    // DCT-IV values.
+}
+
+// newVorbisMdct creates the transform tables for block size n.
+func newVorbisMdct(n int) *vorbisMdct {
+	half, quarter := n/2, n/4
+	m := &vorbisMdct{n: n}
+	m.twiddle = make([]complex128, quarter)
+	for k := range m.twiddle {
+		s, c := math.Sincos(math.Pi * (float64(k) + 0.125) / float64(half))
+		m.twiddle[k] = complex(c, s)
+	}
+	m.roots = make([]complex128, quarter/2)
+	for k := range m.roots {
+		s, c := math.Sincos(2 * math.Pi * float64(k) / float64(quarter))
+		m.roots[k] = complex(c, s)
+	}
+	m.rev = make([]int, quarter)
+	shift := uint(bits.LeadingZeros(uint(quarter)) + 1)
+	for k := range m.rev {
+		m.rev[k] = int(bits.Reverse(uint(k)) >> shift)
+	}
+	m.z, m.u = make([]complex128, quarter), make([]float64, half)
+	return m
+}
+
+// inverse transforms the n/2 values in x to the n values in y.
+func (m *vorbisMdct) inverse(x, y []float32) {
+	half, quarter := m.n/2, m.n/4
+	z := m.z
+	for p := range z {
+		z[m.rev[p]] = complex(float64(x[2*p]), -float64(x[half-1-2*p])) * m.twiddle[p]
+	}
+	for size := 2; size <= quarter; size <<= 1 {
+		step := quarter / size
+		for start := 0; start < quarter; start += size {
+			for k := 0; k < size/2; k++ {
+				a, b := z[start+k], z[start+k+size/2]*m.roots[k*step]
+				z[start+k], z[start+k+size/2] = a+b, a-b
+			}
+		}
+	}
+	for q := range z {
+		c := z[q] * m.twiddle[q]
+		m.u[2*q], m.u[half-1-2*q] = real(c), imag(c)
+	}
+
+	// unfold the DCT-IV into the symmetric MDCT output.
+	for i := range y {
+		switch k := i + half/2; {
+		case k < half:
+			y[i] = float32(m.u[k])
+		case k < 2*half:
+			y[i] = float32(-m.u[2*half-1-k])
+		default:
+			y[i] = float32(-m.u[k-2*half])
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestVorbisMdct(t *testing.T) {
+	for _, n := range []int{64, 256} {
+		x, y := make([]float32, n/2), make([]float32, n)
+		for k := range x {
+			x[k] = float32(math.Sin(float64(k*k)) + 0.25)
+		}
+		newVorbisMdct(n).inverse(x, y)
+		for i := range y {
+			want := 0.0
+			for k := range x {
+				want += float64(x[k]) * math.Cos(math.Pi/float64(n)*
+					(float64(2*i+1)+float64(n)/2)*float64(2*k+1)/2)
+			}
+			if math.Abs(float64(y[i])-want) > 1e-3 {
+				t.Fatalf("n=%d y[%d] expected %f got %f", n, i, want, y[i])
+			}
+		}
+	}
+}
+
+// Codeword example from the Vorbis I specification.
+func TestVorbisBook(t *testing.T) {
+	cb := &vorbisBook{lengths: []uint8{2, 4, 4, 4, 4, 2, 3, 3}}
+	if err := cb.build(); err != nil {
+		t.Fatal(err)
+	}
+	codes := []string{"00", "0100", "0101", "0110", "0111", "10", "110", "111"}
+	w := &vorbisWriter{}
+	for _, code := range codes {
+		for _, c := range code {
+			w.write(uint32(c-'0'), 1)
+		}
+	}
+	b := &vorbisBits{data: w.data}
+	for entry := range codes {
+		if got := cb.decode(b); got != entry {
+			t.Errorf("Expected entry %d got %d", entry, got)
+		}
+	}
+	over := &vorbisBook{lengths: []uint8{1, 1, 1}}
+	if err := over.build(); err == nil {
+		t.Error("Expected overspecified error")
+	}
+}
+
+func TestVorbisUnpack(t *testing.T) {
+	if v := float32Unpack(788<<21 | 3); v != 3 {
+		t.Errorf("Expected 3 got %f", v)
+	}
+	if v := float32Unpack(0x80000000 | 787<<21 | 1); v != -0.5 {
+		t.Errorf("Expected -0.5 got %f", v)
+	}
+	if r := lookup1Values(81, 4); r != 3 {
+		t.Errorf("Expected 3 got %d", r)
+	}
+	if r := lookup1Values(80, 4); r != 2 {
+		t.Errorf("Expected 2 got %d", r)
+	}
+}
+
+func TestOgg(t *testing.T) {
+	d := &SndData{}
+	if err := Ogg(bytes.NewReader(vorbisStream()), d); err != nil {
+		t.Fatal(err)
+	}
+	a := d.Attrs
+	if a.Channels != 1 || a.Frequency != 8000 || a.SampleBits != 16 || int(a.DataSize) != len(d.Data) {
+		t.Fatalf("Bad attributes %+v", a)
+	}
+
+	// 32+80+80 samples are decoded and trimmed to the final granule.
+	if len(d.Data) != 150*2 {
+		t.Fatalf("Expected 300 bytes got %d", len(d.Data))
+	}
+	loud := 0
+	for cnt := 0; cnt < len(d.Data); cnt += 2 {
+		if s := int16(binary.LittleEndian.Uint16(d.Data[cnt:])); s > 100 || s < -100 {
+			loud++
+		}
+	}
+	if loud == 0 {
+		t.Error("Expected decoded audio")
+	}
+}
+
+// Streamed packets match the fully decoded sound.
+func TestOggStream(t *testing.T) {
+	d := &SndData{}
+	if err := Ogg(bytes.NewReader(vorbisStream()), d); err != nil {
+		t.Fatal(err)
+	}
+	s, err := oggStream(bytes.NewReader(vorbisStream()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := ioutil.ReadAll(s)
+	if err != nil || !bytes.Equal(pcm, d.Data) || s.Attrs.Frequency != 8000 {
+		t.Errorf("Expected %d streamed bytes got %d %s", len(d.Data), len(pcm), err)
+	}
+}
+
+func TestOggInvalid(t *testing.T) {
+	data := vorbisStream()
+	data[len(data)-1] ^= 0xFF
+	if err := Ogg(bytes.NewReader(data), &SndData{}); err == nil {
+		t.Error("Expected checksum error")
+	}
+	if err := Ogg(bytes.NewReader(data[:20]), &SndData{}); err == nil {
+		t.Error("Expected truncated error")
+	}
+}
+
+// A stream from the reference libvorbis encoder decodes close to the
+// samples that were encoded. Vorbis is lossy so the decoded tones are
+// compared using the signal to noise ratio.
+func TestVorbisReference(t *testing.T) {
+	got, src := reference(t, "tone.ogg", Ogg)
+	if len(got.Data) != len(src.Data) {
+		t.Fatalf("Expected %d bytes got %d", len(src.Data), len(got.Data))
+	}
+	signal, noise := 0.0, 0.0
+	for cnt := 0; cnt < len(src.Data); cnt += 2 {
+		s := float64(int16(binary.LittleEndian.Uint16(src.Data[cnt:])))
+		g := float64(int16(binary.LittleEndian.Uint16(got.Data[cnt:])))
+		signal, noise = signal+s*s, noise+(s-g)*(s-g)
+	}
+	if snr := 10 * math.Log10(signal/noise); noise > 0 && snr < 20 {
+		t.Errorf("Expected decoded tones to match, got %.1f dB SNR", snr)
+	}
+}
+
+func TestVorbisPCM(t *testing.T) {
+	pcm := vorbisPCM(nil, [][]float32{{2, 0.5}, {-2, 0}})
+	want := []int16{32767, -32768, 16383, 0}
+	for cnt, w := range want {
+		if s := int16(binary.LittleEndian.Uint16(pcm[cnt*2:])); s != w {
+			t.Errorf("Sample %d expected %d got %d", cnt, w, s)
+		}
+	}
+}
+
+// vorbisWriter writes least significant bit first values for test packets.
+type vorbisWriter struct {
+	data []byte
+	pos  int
+}
+
+func (w *vorbisWriter) write(v uint32, n uint) {
+	for i := uint(0); i < n; i++ {
+		if w.pos>>3 >= len(w.data) {
+			w.data = append(w.data, 0)
+		}
+		if v>>i&1 == 1 {
+			w.data[w.pos>>3] |= 1 << uint(w.pos&7)
+		}
+		w.pos++
+	}
+}
+
+func (w *vorbisWriter) header(kind uint32) {
+	w.write(kind, 8)
+	for _, c := range []byte("vorbis") {
+		w.write(uint32(c), 8)
+	}
+}
+
+// vorbisStream creates a mono stream with 64 and 256 sample blocks,
+// one scalar codebook, a flat floor, and a type 1 residue.
+func vorbisStream() []byte {
+	id := &vorbisWriter{}
+	id.header(1)
+	id.write(0, 32)
+	id.write(1, 8)
+	id.write(8000, 32)
+	id.write(0, 32)
+	id.write(0, 32)
+	id.write(0, 32)
+	id.write(6|8<<4, 8)
+	id.write(1, 1)
+
+	comment := &vorbisWriter{}
+	comment.header(3)
+	comment.write(0, 32)
+	comment.write(0, 32)
+	comment.write(1, 1)
+
+	setup := &vorbisWriter{}
+	setup.header(5)
+	setup.write(0, 8) // one codebook with two one bit entries 0 and 1.
+	setup.write(0x564342, 24)
+	setup.write(1, 16)
+	setup.write(2, 24)
+	setup.write(0, 2)
+	setup.write(0, 5)
+	setup.write(0, 5)
+	setup.write(1, 4)
+	setup.write(0, 32)
+	setup.write(788<<21|1, 32)
+	setup.write(0, 4)
+	setup.write(0, 1)
+	setup.write(0, 1)
+	setup.write(1, 1)
+	setup.write(0, 6) // time domain.
+	setup.write(0, 16)
+	setup.write(0, 6) // one floor with only the end points.
+	setup.write(1, 16)
+	setup.write(0, 5)
+	setup.write(1, 2)
+	setup.write(5, 4)
+	setup.write(0, 6) // one residue, 4 partitions of 8 values.
+	setup.write(1, 16)
+	setup.write(0, 24)
+	setup.write(32, 24)
+	setup.write(7, 24)
+	setup.write(0, 6)
+	setup.write(0, 8)
+	setup.write(1, 3)
+	setup.write(0, 1)
+	setup.write(0, 8)
+	setup.write(0, 6) // one mapping.
+	setup.write(0, 16)
+	setup.write(0, 1)
+	setup.write(0, 1)
+	setup.write(0, 2)
+	setup.write(0, 8)
+	setup.write(0, 8)
+	setup.write(0, 8)
+	setup.write(1, 6) // short and long modes.
+	for long := uint32(0); long < 2; long++ {
+		setup.write(long, 1)
+		setup.write(0, 16)
+		setup.write(0, 16)
+		setup.write(0, 8)
+	}
+	setup.write(1, 1)
+
+	// silent short, loud short, loud long, silent short.
+	audio := [][]byte{}
+	for _, mode := range []uint32{0, 2, 3, 0} {
+		w := &vorbisWriter{}
+		w.write(0, 1)
+		w.write(mode&1, 1)
+		if mode&1 == 1 {
+			w.write(0, 2)
+		}
+		w.write(mode>>1, 1)
+		if mode>>1 == 1 {
+			w.write(120, 7)
+			w.write(120, 7)
+			for p := 0; p < 4; p++ {
+				w.write(0, 1)    // partition class.
+				w.write(0xFF, 8) // partition values.
+			}
+		}
+		audio = append(audio, w.data)
+	}
+	data := oggPage(0, 2, 0, id.data)
+	data = append(data, oggPage(1, 0, 0, comment.data, setup.data)...)
+	data = append(data, oggPage(2, 4, 150, audio...)...)
+	return data
+}
+
+// oggPage wraps the packets in one Ogg page.
+func oggPage(seq uint32, flags byte, granule int64, packets ...[]byte) []byte {
+	segs, body := []byte{}, []byte{}
+	for _, p := range packets {
+		for size := len(p); ; size -= 255 {
+			if size < 255 {
+				segs = append(segs, byte(size))
+				break
+			}
+			segs = append(segs, 255)
+		}
+		body = append(body, p...)
+	}
+	hdr := make([]byte, 27)
+	copy(hdr, "OggS")
+	hdr[5] = flags
+	binary.LittleEndian.PutUint64(hdr[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(hdr[14:], 0x1234)
+	binary.LittleEndian.PutUint32(hdr[18:], seq)
+	hdr[26] = byte(len(segs))
+	binary.LittleEndian.PutUint32(hdr[22:], oggCRC(oggCRC(oggCRC(0, hdr), segs), body))
+	return append(append(hdr, segs...), body...)
+}
@@ -15,15 +15,9 @@ import (
 // The Reader r is expected to be opened and closed by the caller.
 // A successful import overwrites the data in SndData.
 func Wav(r io.Reader, d *SndData) (err error) {
-	hdr := &wavHeader{}
-	if err = binary.Read(r, binary.LittleEndian, hdr); err != nil {
-		return fmt.Errorf("Invalid .wav audio file: %s", err)
-	}
-
-	// check that it really is a WAVE file.
-	riff, wave := string(hdr.RiffID[:]), string(hdr.WaveID[:])
-	if riff != "RIFF" || wave != "WAVE" {
-		return fmt.Errorf("Invalid .wav audio file")
+	hdr, err := wavRead(r)
+	if err != nil {
+		return err
 	}
 
 	// read the audio data.
@@ -47,7 +41,47 @@ func Wav(r io.Reader, d *SndData) (err error) {
 	return nil
 }
 
-// wavHeader is an internal implementation for loading WAV files.
+// =============================================================================
+// internal implementation for loading WAV files.
+
+// wavRead reads and checks the WAV file header.
+func wavRead(r io.Reader) (*wavHeader, error) {
+	hdr := &wavHeader{}
+	if err := binary.Read(r, binary.LittleEndian, hdr); err != nil {
+		return nil, fmt.Errorf("Invalid .wav audio file: %s", err)
+	}
+
+	// check that it really is a WAVE file.
+	riff, wave := string(hdr.RiffID[:]), string(hdr.WaveID[:])
+	if riff != "RIFF" || wave != "WAVE" {
+		return nil, fmt.Errorf("Invalid .wav audio file")
+	}
+	return hdr, nil
+}
+
+// wavStream reads the WAV audio data in blocks as it is needed.
+func wavStream(r io.Reader) (*SndStream, error) {
+	hdr, err := wavRead(r)
+	if err != nil {
+		return nil, err
+	}
+	data := io.LimitReader(r, int64(hdr.DataSize))
+	block := make([]byte, sndBlock)
+	next := func() ([]byte, error) {
+		n, err := io.ReadFull(data, block)
+		switch {
+		case n > 0:
+			return block[:n], nil
+		case err == io.ErrUnexpectedEOF:
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	attrs := &SndAttributes{Channels: hdr.Channels, Frequency: hdr.Frequency, SampleBits: hdr.SampleBits}
+	return &SndStream{Attrs: attrs, next: next}, nil
+}
+
+// wavHeader is the fixed size PCM WAV file header.
 type wavHeader struct {
 	RiffID      [4]byte // "RIFF"
 	FileSize    uint32  // Total file size minus 8 bytes.
@@ -4,6 +4,9 @@
 package load
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"testing"
 )
 
@@ -15,3 +18,38 @@ func TestLoadWave(t *testing.T) {
 		t.Errorf("Loading wave failed %s", err)
 	}
 }
+
+// Streamed sounds match the loaded sound and are read in pieces.
+// Uses vu/eg resource directories.
+func TestOpenSnd(t *testing.T) {
+	loc := NewLocator().Dir("WAV", "../eg/audio")
+	snd := &SndData{}
+	if err := snd.Load("bloop", loc); err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenSnd("bloop", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	piece := make([]byte, 100)
+	if n, err := s.Read(piece); n != 100 || err != nil {
+		t.Fatalf("Expected a piece of sound got %d %s", n, err)
+	}
+	rest, err := ioutil.ReadAll(s)
+	if err != nil || !bytes.Equal(append(piece, rest...), snd.Data) {
+		t.Errorf("Expected %d streamed bytes got %d %s", len(snd.Data), len(piece)+len(rest), err)
+	}
+	if n, err := s.Seek(100, io.SeekStart); n != 100 || err != nil {
+		t.Fatalf("Expected seek to 100 got %d %s", n, err)
+	}
+	if rest, err = ioutil.ReadAll(s); err != nil || !bytes.Equal(rest, snd.Data[100:]) {
+		t.Errorf("Expected sound after the seek got %d bytes %s", len(rest), err)
+	}
+	if _, err := s.Seek(int64(len(snd.Data)+1), io.SeekStart); err == nil {
+		t.Error("Expected seek past end error")
+	}
+	if _, err := OpenSnd("missing", loc); err == nil {
+		t.Error("Expected missing sound error")
+	}
+}
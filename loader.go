@@ -13,6 +13,8 @@ package vu
 import (
 	"fmt"
 	"log"
+	"path"
+	"strings"
 
 	"github.com/gazed/vu/load"
 	"github.com/gazed/vu/render"
@@ -27,6 +29,7 @@ import (
 // imported data for multiple model and noise instances.
 type loader struct {
 	assets map[aid]string // Track asset existence.
+	diags  []*Diagnostic  // Problems found loading the current requests.
 
 	// loader goroutine communication.
 	loc    load.Locator        // Locates the asset data on disk.
@@ -66,56 +69,42 @@ func runLoader(machine chan msg, load chan map[aid]string,
 			assets := map[aid]asset{}
 			for id, name := range requests {
 				a := id.dataType()
+				var la asset
+				var err error
 				switch a {
 				case anm:
-					msh := newMesh(name)
-					anm := newAnimation(name)
-					if la, lm := l.loadAnim(anm, msh); la == nil || lm == nil {
-						log.Printf("Animation %s failed to load", name) // dev error.
-					} else {
-						assets[la.aid()] = la
+					var lm *mesh
+					if la, lm, err = l.loadAnim(newAnimation(name), newMesh(name)); err == nil {
 						assets[lm.aid()] = lm
 					}
 				case msh:
-					if lm, err := l.loadMesh(newMesh(name)); err != nil {
-						log.Printf("Mesh %s failed to load %s", name, err) // dev error.
-					} else {
-						assets[lm.aid()] = lm
-					}
+					la, err = l.loadMesh(newMesh(name))
 				case tex:
-					if lt, err := l.loadTexture(newTexture(name)); err != nil {
-						log.Printf("Texture %s failed to load %s", name, err) // dev error.
-					} else {
-						assets[lt.aid()] = lt
-					}
+					la, err = l.loadTexture(newTexture(name))
+				case cub:
+					la, err = l.loadTexture(newCubeTexture(name))
 				case shd:
-					if ls, err := l.loadShader(newShader(name)); err != nil {
-						log.Printf("Shader %s failed to load %s", name, err) // dev error.
-					} else {
-						assets[ls.aid()] = ls
-					}
+					la, err = l.loadShader(newShader(name))
 				case fnt:
-					if lf, err := l.loadFont(newFont(name)); err != nil {
-						log.Printf("Font %s failed to load %s", name, err) // dev error.
-					} else {
-						assets[lf.aid()] = lf
-					}
+					la, err = l.loadFont(newFont(name))
 				case mat:
-					if lm, err := l.loadMaterial(newMaterial(name)); err != nil {
-						log.Printf("Material %s failed to load %s", name, err) // dev error.
-					} else {
-						assets[lm.aid()] = lm
-					}
+					la, err = l.loadMaterial(newMaterial(name))
 				case snd:
-					if ls, err := l.loadSound(newSound(name)); err != nil {
-						log.Printf("Sound %s failed to load %s", name, err) // dev error.
-					} else {
-						assets[ls.aid()] = ls
-					}
+					la, err = l.loadSound(newSound(name))
 				default:
-					log.Printf("loader: unknown request %T", a)
+					err = fmt.Errorf("loader: unknown request %d", a)
 					// FUTURE: handle releaseData requests. See eng.dispose design note.
 				}
+				if err != nil {
+					l.diags = append(l.diags, diagnose(id, name, err))
+					assets[id] = &failure{id: id, name: name}
+					continue
+				}
+				assets[la.aid()] = la
+			}
+			if len(l.diags) > 0 {
+				assets[diagnosisID] = &diagnosis{diags: l.diags}
+				l.diags = nil
 			}
 			l.returnAssets(assets)
 		}
@@ -147,9 +136,7 @@ func (l *loader) loadSound(s *sound) (*sound, error) {
 	if err := l.importSound(s); err != nil {
 		return nil, err
 	}
-	bindReply := make(chan error)
-	l.bind <- &bindData{data: s, reply: bindReply} // request bind.
-	if err := <-bindReply; err != nil {            // wait for bind.
+	if err := l.bindAsset(s); err != nil {
 		return nil, err
 	}
 	l.cache.store(s)
@@ -179,9 +166,7 @@ func (l *loader) loadShader(s *shader) (*shader, error) {
 	if err := l.importShader(s); err != nil {
 		return nil, err
 	}
-	bindReply := make(chan error)
-	l.bind <- &bindData{data: s, reply: bindReply} // request bind.
-	if err := <-bindReply; err != nil {            // wait for bind.
+	if err := l.bindAsset(s); err != nil {
 		return nil, err
 	}
 	l.cache.store(s)
@@ -218,19 +203,22 @@ func (l *loader) loadMesh(m *mesh) (*mesh, error) {
 	if err := l.importMesh(m); err != nil {
 		return nil, err
 	}
-	if err := l.bindMesh(m); err != nil {
+	if err := l.bindAsset(m); err != nil {
 		return nil, err
 	}
 	l.cache.store(m)
 	return m, nil
 }
 
-// bindMesh submits a mesh for binding or rebinding. This transfers
-// the mesh data to the GPU.
-func (l *loader) bindMesh(m *mesh) error {
+// bindAsset submits an asset for binding. This transfers the
+// asset data to the GPU or audio card.
+func (l *loader) bindAsset(a asset) error {
 	bindReply := make(chan error)
-	l.bind <- &bindData{data: m, reply: bindReply} // request bind.
-	return <-bindReply                             // wait for bind.
+	l.bind <- &bindData{data: a, reply: bindReply} // request bind.
+	if err := <-bindReply; err != nil {            // wait for bind.
+		return bindError{err}
+	}
+	return nil
 }
 
 // importMesh transfers data loaded from disk to the render object.
@@ -239,6 +227,9 @@ func (l *loader) importMesh(m *mesh) error {
 	if err := msh.Load(m.name, l.loc); err != nil {
 		return fmt.Errorf("loader.loadMesh: could not load %s %s", m.name, err)
 	}
+	if err := l.check(checkMesh("msh:"+m.name, msh)); err != nil {
+		return err
+	}
 	transferMesh(msh, m)
 	return nil
 }
@@ -246,6 +237,10 @@ func (l *loader) importMesh(m *mesh) error {
 // loadTexture returns a loaded texture immediately if it is cached.
 // Otherwise the texture is returned after it is loaded and bound.
 func (l *loader) loadTexture(t *texture) (*texture, error) {
+	if path.Ext(t.name) == ".sdf" {
+		_, t, err := l.loadSdf(t.name)
+		return t, err
+	}
 	data := asset(t)
 	if err := l.cache.fetch(&data); err == nil {
 		return data.(*texture), nil
@@ -255,9 +250,7 @@ func (l *loader) loadTexture(t *texture) (*texture, error) {
 	if err := l.importTexture(t); err != nil {
 		return nil, err
 	}
-	bindReply := make(chan error)
-	l.bind <- &bindData{data: t, reply: bindReply} // request bind.
-	if err := <-bindReply; err != nil {            // wait for bind.
+	if err := l.bindAsset(t); err != nil {
 		return nil, err
 	}
 	l.cache.store(t)
@@ -265,7 +258,16 @@ func (l *loader) loadTexture(t *texture) (*texture, error) {
 }
 
 // importTexture transfers data loaded from disk to the render object.
+// Cube map textures are loaded from six faces or a panorama.
 func (l *loader) importTexture(t *texture) error {
+	if t.cube {
+		cube := &load.CubeData{}
+		if err := cube.Load(t.name, l.loc); err != nil {
+			return fmt.Errorf("loader.loadTexture: could not load cube map %s %s", t.name, err)
+		}
+		t.faces = cube.Faces
+		return nil
+	}
 	img := &load.ImgData{}
 	err := img.Load(t.name, l.loc)
 	if err != nil {
@@ -304,6 +306,10 @@ func (l *loader) importMaterial(m *material) error {
 // loadFont returns a loaded font immediately if it is cached.
 // Otherwise the font is returned after it is loaded and bound.
 func (l *loader) loadFont(f *font) (*font, error) {
+	if path.Ext(f.name) == ".sdf" {
+		f, _, err := l.loadSdf(f.name)
+		return f, err
+	}
 	data := asset(f)
 	if err := l.cache.fetch(&data); err == nil {
 		return data.(*font), nil
@@ -327,35 +333,55 @@ func (l *loader) importFont(f *font) error {
 	return nil
 }
 
+// loadSdf returns a generated distance field font and its texture atlas.
+// Both are created together from the font name without the .sdf extension,
+// so whichever is requested first creates, binds, and caches both.
+func (l *loader) loadSdf(name string) (*font, *texture, error) {
+	f, t := newFont(name), newTexture(name)
+	fdata, tdata := asset(f), asset(t)
+	if l.cache.fetch(&fdata) == nil && l.cache.fetch(&tdata) == nil {
+		return fdata.(*font), tdata.(*texture), nil
+	}
+	fnt, img := &load.FntData{}, &load.ImgData{}
+	if err := fnt.LoadSdf(strings.TrimSuffix(name, ".sdf"), l.loc, img); err != nil {
+		return nil, nil, fmt.Errorf("loader.loadSdf: could not load %s %s", name, err)
+	}
+	transferFont(fnt, f)
+	t.Set(img.Img)
+	if err := l.bindAsset(t); err != nil {
+		return nil, nil, err
+	}
+	l.cache.store(f)
+	l.cache.store(t)
+	return f, t, nil
+}
+
 // loadAnim loads an animated model from disk. This will create
 // multiple model assets including a mesh, textures, and animation data.
-func (l *loader) loadAnim(a *animation, m *mesh) (*animation, *mesh) {
+func (l *loader) loadAnim(a *animation, m *mesh) (*animation, *mesh, error) {
 	data := asset(a)
 	if err := l.cache.fetch(&data); err == nil {
 		a = data.(*animation) // got the animation.
 		data := asset(m)      // now load the mesh.
 		if err := l.cache.fetch(&data); err == nil {
 			m = data.(*mesh)
-			return a, m
+			return a, m, nil
 		}
 	}
 
 	// Otherwise the animation and mesh need to be loaded.
 	// Textures are loaded, bound, and cached within importAnim.
-	var err error
-	if err = l.importAnim(a, m); err != nil {
-		log.Printf("Animation load %s: %s", a.name, err)
-		return nil, nil // discard load failures
+	if err := l.importAnim(a, m); err != nil {
+		return nil, nil, err
 	}
 
 	// And the mesh needs to be bound.
-	if err := l.bindMesh(m); err != nil {
-		log.Printf("Animation bind %s: %s", m.name, err)
-		return nil, nil // discard bind failures
+	if err := l.bindAsset(m); err != nil {
+		return nil, nil, err
 	}
 	l.cache.store(a)
 	l.cache.store(m)
-	return a, m
+	return a, m, nil
 }
 
 // importAnim loads the animation, mesh, and texture for an
@@ -365,10 +391,25 @@ func (l *loader) importAnim(a *animation, m *mesh) (err error) {
 	if err = mod.Load(a.name, l.loc); err != nil {
 		return err
 	}
+	if err = l.check(checkMesh("mod:"+a.name, &mod.MshData)); err != nil {
+		return err
+	}
 	transferAnim(mod, m, a)
 	return nil
 }
 
+// check keeps the warnings from a list of diagnostics and
+// returns the first fatal diagnostic as an error.
+func (l *loader) check(diags []*Diagnostic) error {
+	for _, d := range diags {
+		if d.Fatal {
+			return d
+		}
+		l.diags = append(l.diags, d)
+	}
+	return nil
+}
+
 // release is called when the cached data is no
 // longer needed and can be discarded entirely.
 func (l *loader) release(data interface{}) {
@@ -430,6 +471,9 @@ func transferMesh(data *load.MshData, m *mesh) {
 	if len(data.T) > 0 {
 		m.InitData(2, 2, render.StaticDraw, false).SetData(2, data.T)
 	}
+	if len(data.X) > 0 {
+		m.InitData(6, 4, render.StaticDraw, false).SetData(6, data.X)
+	}
 }
 
 // transferMaterial moves data from the loading system to the engine instance.
@@ -477,7 +521,7 @@ func transferAnim(data *load.ModData, m *mesh, a *animation) {
 				rate: float64(ia.Rate)}
 			moves = append(moves, movement)
 		}
-		a.setData(data.Frames, data.Joints, moves)
+		a.setData(data.Frames, data.Joints, data.Names, data.Binds, moves)
 	}
 }
 
@@ -4,6 +4,15 @@
 package vu
 
 // material.go handles model surface color data.
+// DESIGN: Material files are read on the engine goroutine, like scene
+//         files, so that the model shader can be set before the model
+//         assets are sent to the loader.
+
+import (
+	"fmt"
+
+	"github.com/gazed/vu/load"
+)
 
 // material is used to color a mesh. It specifies the surface color and
 // how the surface is lit. Materials are applied to a rendered model by
@@ -29,6 +38,62 @@ func newMaterial(name string) *material {
 func (m *material) aid() aid      { return m.tag }  // hashed type and name.
 func (m *material) label() string { return m.name } // asset name
 
+// loadMat reads the named material file and applies it to the model
+// of the given Pov, creating the model if it does not exist.
+func (eng *engine) loadMat(p *Pov, name string) (Model, error) {
+	if eng.loc == nil {
+		eng.loc = load.NewLocator()
+	}
+	md := &load.MatData{}
+	if err := md.Load(name, eng.loc); err != nil {
+		return nil, fmt.Errorf("LoadMat %s: %s", name, err)
+	}
+	m, ok := eng.models.data[p.id]
+	switch {
+	case !ok && md.Shader == "":
+		m = eng.models.create(p.id, "phong")
+	case !ok:
+		m = eng.models.create(p.id, md.Shader)
+	case md.Shader != "":
+		if m.shd != nil && m.shd.name != md.Shader {
+			return nil, fmt.Errorf("LoadMat %s: model shader already loaded", name)
+		}
+		m.setShader(md.Shader)
+	}
+
+	// lighting colors and textures.
+	m.mat = newMaterial(name)
+	transferMaterial(&md.MtlData, m.mat)
+	m.alpha = float64(m.mat.tr)
+	for _, t := range md.Textures {
+		if t.Channel == "occlusion" {
+			m.Load("ao:" + t.Name)
+		} else {
+			m.Load("tex:" + t.Name)
+		}
+	}
+
+	// physically based values are passed as shader uniforms.
+	m.uniforms["basecolor"] = append([]float32{}, md.Color[:]...)
+	m.uniforms["metallic"] = []float32{md.Metallic}
+	m.uniforms["roughness"] = []float32{md.Roughness}
+	m.uniforms["emissive"] = append([]float32{}, md.Emissive[:]...)
+	if md.Blend == "cutout" {
+		m.uniforms["cutoff"] = []float32{md.Cutoff}
+	}
+	for uniform, values := range md.Uniforms {
+		m.uniforms[uniform] = values
+	}
+	switch md.Blend {
+	case "alpha":
+		m.blend, m.blended = BlendAlpha, true
+	case "add":
+		m.blend, m.blended = BlendAdd, true
+	}
+	m.twoSided = md.TwoSided
+	return m, nil
+}
+
 // material
 // ===========================================================================
 // rgb
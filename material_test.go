@@ -0,0 +1,35 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/load"
+)
+
+// Material files set up the model shader, textures, and uniforms.
+// Uses vu/eg resource directories.
+func TestLoadMat(t *testing.T) {
+	eng := newEngine(nil)
+	eng.loc = load.NewLocator().Dir("MAT", "eg/models")
+	p := eng.root().NewPov()
+	p.NewModel("phong", "msh:floor")
+	if _, err := p.LoadMat("tile"); err != nil {
+		t.Fatal(err)
+	}
+	m := eng.models.data[p.id]
+	if _, ok := m.assets[assetID(shd, "uv")]; !ok || len(m.assets) != 5 || len(m.tids) != 3 {
+		t.Errorf("Expected uv shader, mesh, and 3 textures, got %v", m.assets)
+	}
+	if m.mat.kd.G != 0.9 || m.Uniform("roughness")[0] != 0.6 || m.Uniform("cutoff")[0] != 0.3 {
+		t.Errorf("Bad material values %v %v", m.mat, m.uniforms)
+	}
+	if !m.twoSided || m.blended || len(m.Uniform("tiling")) != 2 {
+		t.Errorf("Bad surface %t %t %v", m.twoSided, m.blended, m.uniforms)
+	}
+	if _, err := eng.root().NewPov().LoadMat("missing"); err == nil {
+		t.Error("Expected missing material error")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+// Batch operations work on slices of points or matrices at once.
+// The render layer uses float32, so points are packed x,y,z float32
+// values, ie: mesh vertex data, and are transformed using float32 math
+// which avoids converting each value to and from float64. The tight
+// loops over packed data suit compiler vectorization, and large batches
+// are split across the available CPUs.
+
+import (
+	"runtime"
+	"sync"
+)
+
+// batchMin is the smallest amount of work split across goroutines.
+// Smaller batches are cheaper to do on the calling goroutine.
+const batchMin = 4096
+
+// batch calls fn over the range 0 to n, splitting large ranges into
+// pieces that are run in parallel. Returns once all pieces are done.
+func batch(n int, fn func(start, end int)) {
+	pieces := runtime.GOMAXPROCS(0)
+	if n < batchMin*2 || pieces < 2 {
+		fn(0, n)
+		return
+	}
+	if most := n / batchMin; pieces > most {
+		pieces = most
+	}
+	var wg sync.WaitGroup
+	size := (n + pieces - 1) / pieces
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// AppPoints applies the transform matrix m to each of the x,y,z points
+// in src, storing the results in dst. Matrix m is expected to be an affine
+// transform, ie: a model or view matrix, so the W column is ignored.
+// Only complete points are transformed, and dst must be at least as long
+// as src. Dst may be src. The updated dst is returned.
+//    x' = x*Xx + y*Yx + z*Zx + Wx
+//    y' = x*Xy + y*Yy + z*Zy + Wy
+//    z' = x*Xz + y*Yz + z*Zz + Wz
+func (m *M4) AppPoints(dst, src []float32) []float32 {
+	xx, xy, xz := float32(m.Xx), float32(m.Xy), float32(m.Xz)
+	yx, yy, yz := float32(m.Yx), float32(m.Yy), float32(m.Yz)
+	zx, zy, zz := float32(m.Zx), float32(m.Zy), float32(m.Zz)
+	wx, wy, wz := float32(m.Wx), float32(m.Wy), float32(m.Wz)
+	batch(len(src)/3, func(start, end int) {
+		for i := start * 3; i < end*3; i += 3 {
+			x, y, z := src[i], src[i+1], src[i+2]
+			dst[i] = x*xx + y*yx + z*zx + wx
+			dst[i+1] = x*xy + y*yy + z*zy + wy
+			dst[i+2] = x*xz + y*yz + z*zz + wz
+		}
+	})
+	return dst
+}
+
+// AppDirs is like AppPoints except that translation is not applied.
+// This is for directions, ie: normals when m has no scaling.
+func (m *M4) AppDirs(dst, src []float32) []float32 {
+	xx, xy, xz := float32(m.Xx), float32(m.Xy), float32(m.Xz)
+	yx, yy, yz := float32(m.Yx), float32(m.Yy), float32(m.Yz)
+	zx, zy, zz := float32(m.Zx), float32(m.Zy), float32(m.Zz)
+	batch(len(src)/3, func(start, end int) {
+		for i := start * 3; i < end*3; i += 3 {
+			x, y, z := src[i], src[i+1], src[i+2]
+			dst[i] = x*xx + y*yx + z*zx
+			dst[i+1] = x*xy + y*yy + z*zy
+			dst[i+2] = x*xz + y*yz + z*zz
+		}
+	})
+	return dst
+}
+
+// MultM4s updates each matrix in dst to be the multiplication of the
+// matrix at the same index in a, and matrix b. Ie: dst[i] = a[i] x b.
+// This is useful for moving many transforms into the same space,
+// ie: animation joints into model space. Only the matrices in both dst
+// and a are updated. Dst may be a.
+func MultM4s(dst, a []M4, b *M4) {
+	n := len(a)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	r := *b // in case b is one of the updated matrices.
+	batch(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			dst[i].Mult(&a[i], &r)
+		}
+	})
+}
+
+// Floats32 appends the 16 values of each matrix to f as float32 values
+// in the memory layout expected by the graphics layer. The extended
+// slice is returned. Reuse f, ie: Floats32(f[:0], mats), to avoid
+// allocating each time.
+func Floats32(f []float32, mats []M4) []float32 {
+	for i := range mats {
+		m := &mats[i]
+		f = append(f,
+			float32(m.Xx), float32(m.Xy), float32(m.Xz), float32(m.Xw),
+			float32(m.Yx), float32(m.Yy), float32(m.Yz), float32(m.Yw),
+			float32(m.Zx), float32(m.Zy), float32(m.Zz), float32(m.Zw),
+			float32(m.Wx), float32(m.Wy), float32(m.Wz), float32(m.Ww))
+	}
+	return f
+}
@@ -0,0 +1,97 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"testing"
+)
+
+// batchM4 is a rotate, scale, and translate test matrix.
+func batchM4() *M4 {
+	return NewM4().SetQ(NewQ().SetAa(1, 2, 3, Rad(30))).ScaleSM(2, 3, 4).TranslateMT(1, -2, 5)
+}
+
+// Batches give the same results as transforming each point. Large
+// batches are split up so check that every point is transformed.
+func TestAppPoints(t *testing.T) {
+	m := batchM4()
+	for _, size := range []int{1, batchMin*3 + 1} {
+		src := make([]float32, size*3+2) // ignore the incomplete point.
+		for i := range src {
+			src[i] = float32(i%7) - 3
+		}
+		pts, dirs := m.AppPoints(make([]float32, len(src)), src), m.AppDirs(make([]float32, len(src)), src)
+		for i := 0; i+2 < len(src); i += 3 {
+			p, d := &V4{float64(src[i]), float64(src[i+1]), float64(src[i+2]), 1}, &V4{}
+			d.Set(p).W = 0
+			p.MultvM(p, m)
+			d.MultvM(d, m)
+			if !almost(pts[i:i+3], p) || !almost(dirs[i:i+3], d) {
+				t.Fatalf("Point %d: got %v %v expected %s %s", i/3, pts[i:i+3], dirs[i:i+3], p.Dump(), d.Dump())
+			}
+		}
+		if tail := pts[len(pts)-2:]; tail[0] != 0 || tail[1] != 0 {
+			t.Errorf("Expected incomplete point to be ignored, got %v", tail)
+		}
+	}
+}
+
+// almost returns true if the float32 values are close to v.
+func almost(f []float32, v *V4) bool {
+	const eps = 0.0001
+	return float64(f[0])-v.X < eps && v.X-float64(f[0]) < eps &&
+		float64(f[1])-v.Y < eps && v.Y-float64(f[1]) < eps &&
+		float64(f[2])-v.Z < eps && v.Z-float64(f[2]) < eps
+}
+
+func TestMultM4s(t *testing.T) {
+	m, want := batchM4(), &M4{}
+	mats := make([]M4, batchMin*2+5)
+	for i := range mats {
+		mats[i].Set(M4I).TranslateMT(float64(i), 0, 0)
+	}
+	MultM4s(mats, mats, m)
+	for i := range mats {
+		if want.Set(M4I).TranslateMT(float64(i), 0, 0).Mult(want, m); !mats[i].Aeq(want) {
+			t.Fatalf(format, mats[i].Dump(), want.Dump())
+		}
+	}
+	b, a1 := mats[0], mats[1]
+	MultM4s(mats[:2], mats, &mats[0]) // b is updated.
+	if want.Mult(&a1, &b); !want.Aeq(&mats[1]) {
+		t.Errorf("Expected original b to be used")
+	}
+}
+
+func TestFloats32(t *testing.T) {
+	f := Floats32(nil, []M4{*M4I, *batchM4()})
+	if len(f) != 32 || f[0] != 1 || f[5] != 1 || f[15] != 1 || f[28] != 1 || f[30] != 5 {
+		t.Errorf("Expected matrix layout, got %v", f)
+	}
+}
+
+// unit tests
+// ============================================================================
+// benchmarking.
+
+// Compare transforming 100,000 packed points at once to transforming
+// them one at a time. Run 'go test -bench=Points' to get something like:
+//     BenchmarkAppPoints        3000     416954 ns/op
+//     BenchmarkAppOnePoints     2000     856349 ns/op
+func BenchmarkAppPoints(b *testing.B) {
+	m, pts := batchM4(), make([]float32, 300000)
+	for cnt := 0; cnt < b.N; cnt++ {
+		m.AppPoints(pts, pts)
+	}
+}
+func BenchmarkAppOnePoints(b *testing.B) {
+	m, pts, v := batchM4(), make([]float32, 300000), &V4{}
+	for cnt := 0; cnt < b.N; cnt++ {
+		for i := 0; i < len(pts); i += 3 {
+			v.SetS(float64(pts[i]), float64(pts[i+1]), float64(pts[i+2]), 1)
+			v.MultvM(v, m)
+			pts[i], pts[i+1], pts[i+2] = float32(v.X), float32(v.Y), float32(v.Z)
+		}
+	}
+}
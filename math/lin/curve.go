@@ -0,0 +1,168 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import "sort"
+
+// Curve maps a time to a value using keyframes, ie: particle size
+// over its life, a volume fade, or remapping terrain heights. Values
+// between keys are blended using the curve Mode. Times before the first
+// key or after the last key get the first or last key value.
+type Curve struct {
+	Mode int        // CurveLinear, CurveStep, or CurveSmooth.
+	keys []CurveKey // sorted by time.
+}
+
+// CurveKey is a value at a given time.
+type CurveKey struct {
+	T float64 // Key time.
+	V float64 // Key value.
+}
+
+// Curve modes control how values are blended between keys.
+const (
+	CurveLinear = iota // Straight lines between keys.
+	CurveStep          // Hold each key value until the next key.
+	CurveSmooth        // Smooth curve through each key.
+)
+
+// NewCurve creates a keyframed curve using the given blend mode.
+// Keys need not be in time order.
+func NewCurve(mode int, keys ...CurveKey) *Curve {
+	c := &Curve{Mode: mode}
+	for _, k := range keys {
+		c.Add(k.T, k.V)
+	}
+	return c
+}
+
+// Add sets the value at time t, replacing any key already at time t.
+// The updated curve c is returned.
+func (c *Curve) Add(t, v float64) *Curve {
+	i := sort.Search(len(c.keys), func(i int) bool { return c.keys[i].T >= t })
+	if i < len(c.keys) && c.keys[i].T == t {
+		c.keys[i].V = v
+		return c
+	}
+	c.keys = append(c.keys, CurveKey{})
+	copy(c.keys[i+1:], c.keys[i:])
+	c.keys[i] = CurveKey{t, v}
+	return c
+}
+
+// Keys returns the curve keys in time order.
+// The returned keys should not be changed.
+func (c *Curve) Keys() []CurveKey { return c.keys }
+
+// At returns the curve value at time t.
+// Returns 0 if the curve has no keys.
+func (c *Curve) At(t float64) float64 {
+	i, ratio := curveSeg(len(c.keys), func(i int) float64 { return c.keys[i].T }, t)
+	if i < 0 {
+		return 0
+	}
+	k := c.keys
+	if ratio <= 0 || c.Mode == CurveStep {
+		return k[i].V
+	}
+	if c.Mode != CurveSmooth {
+		return Lerp(k[i].V, k[i+1].V, ratio)
+	}
+
+	// Cubic Hermite using key slopes scaled to the segment length.
+	dt := k[i+1].T - k[i].T
+	m0, m1 := c.slope(i)*dt, c.slope(i+1)*dt
+	r2 := ratio * ratio
+	r3 := r2 * ratio
+	return (2*r3-3*r2+1)*k[i].V + (r3-2*r2+ratio)*m0 + (-2*r3+3*r2)*k[i+1].V + (r3-r2)*m1
+}
+
+// slope is the rate of change at key i based on its neighbours.
+// The end keys use the slope of their only segment.
+func (c *Curve) slope(i int) float64 {
+	lo, hi := i-1, i+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi >= len(c.keys) {
+		hi = len(c.keys) - 1
+	}
+	return (c.keys[hi].V - c.keys[lo].V) / (c.keys[hi].T - c.keys[lo].T)
+}
+
+// Gradient maps a time to a color using keyframes, ie: particle color
+// over its life or terrain color by height. Colors are R,G,B,A values
+// in a V4 and are blended in a straight line between keys. Times before
+// the first key or after the last key get the first or last key color.
+type Gradient struct {
+	keys []GradientKey // sorted by time.
+}
+
+// GradientKey is a color at a given time.
+type GradientKey struct {
+	T float64 // Key time.
+	C V4      // Key color as R,G,B,A.
+}
+
+// NewGradient creates a color gradient. Keys need not be in time order.
+func NewGradient(keys ...GradientKey) *Gradient {
+	g := &Gradient{}
+	for _, k := range keys {
+		g.Add(k.T, &k.C)
+	}
+	return g
+}
+
+// Add sets the color at time t, replacing any key already at time t.
+// The updated gradient g is returned.
+func (g *Gradient) Add(t float64, c *V4) *Gradient {
+	i := sort.Search(len(g.keys), func(i int) bool { return g.keys[i].T >= t })
+	if i < len(g.keys) && g.keys[i].T == t {
+		g.keys[i].C = *c
+		return g
+	}
+	g.keys = append(g.keys, GradientKey{})
+	copy(g.keys[i+1:], g.keys[i:])
+	g.keys[i] = GradientKey{t, *c}
+	return g
+}
+
+// Keys returns the gradient keys in time order.
+// The returned keys should not be changed.
+func (g *Gradient) Keys() []GradientKey { return g.keys }
+
+// At updates color c to be the gradient color at time t.
+// Color c is set to 0,0,0,0 if the gradient has no keys.
+// The updated color c is returned.
+func (g *Gradient) At(c *V4, t float64) *V4 {
+	i, ratio := curveSeg(len(g.keys), func(i int) float64 { return g.keys[i].T }, t)
+	switch {
+	case i < 0:
+		c.X, c.Y, c.Z, c.W = 0, 0, 0, 0
+	case ratio <= 0:
+		*c = g.keys[i].C
+	default:
+		a, b := &g.keys[i].C, &g.keys[i+1].C
+		c.X, c.Y = Lerp(a.X, b.X, ratio), Lerp(a.Y, b.Y, ratio)
+		c.Z, c.W = Lerp(a.Z, b.Z, ratio), Lerp(a.W, b.W, ratio)
+	}
+	return c
+}
+
+// curveSeg finds the keys around time t for n keys in time order.
+// Returns the index of the key at or before t and how far t is
+// towards the next key. The ratio is 0 when t is outside the keys.
+// Returns -1 when there are no keys.
+func curveSeg(n int, time func(i int) float64, t float64) (index int, ratio float64) {
+	switch {
+	case n == 0:
+		return -1, 0
+	case t <= time(0):
+		return 0, 0
+	case t >= time(n-1):
+		return n - 1, 0
+	}
+	i := sort.Search(n, func(i int) bool { return time(i) > t }) - 1
+	return i, (t - time(i)) / (time(i+1) - time(i))
+}
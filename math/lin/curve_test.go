@@ -0,0 +1,60 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"testing"
+)
+
+func TestCurve(t *testing.T) {
+	c := NewCurve(CurveLinear, CurveKey{1, 4}, CurveKey{0, 0}, CurveKey{0.5, 2})
+	if k := c.Keys(); len(k) != 3 || k[0].T != 0 || k[2].T != 1 {
+		t.Fatalf("Expected keys in time order, got %v", k)
+	}
+	for _, tv := range [][2]float64{{-1, 0}, {0.25, 1}, {0.75, 3}, {2, 4}} {
+		if got := c.At(tv[0]); !Aeq(got, tv[1]) {
+			t.Errorf("Linear at %f: got %f expected %f", tv[0], got, tv[1])
+		}
+	}
+	if c.Mode = CurveStep; c.At(0.75) != 2 || c.At(1) != 4 {
+		t.Errorf("Expected step to hold the key value, got %f", c.At(0.75))
+	}
+	if c.Add(0.5, 1).At(0.5) != 1 || len(c.Keys()) != 3 {
+		t.Errorf("Expected add to replace the key")
+	}
+	if NewCurve(CurveLinear).At(1) != 0 || NewCurve(CurveStep, CurveKey{2, 7}).At(0) != 7 {
+		t.Errorf("Expected empty and single key curves")
+	}
+}
+
+// Smooth curves go through each key and match straight lines
+// when the keys are in a straight line.
+func TestCurveSmooth(t *testing.T) {
+	c := NewCurve(CurveSmooth, CurveKey{0, 0}, CurveKey{1, 2}, CurveKey{3, 6})
+	for _, tv := range [][2]float64{{0, 0}, {0.5, 1}, {1, 2}, {2, 4}, {3, 6}} {
+		if got := c.At(tv[0]); !Aeq(got, tv[1]) {
+			t.Errorf("Smooth at %f: got %f expected %f", tv[0], got, tv[1])
+		}
+	}
+	c = NewCurve(CurveSmooth, CurveKey{0, 0}, CurveKey{1, 1}, CurveKey{2, 0})
+	if peak := c.At(1); !Aeq(peak, 1) || c.At(0.9) >= peak || c.At(1.1) >= peak {
+		t.Errorf("Expected smooth peak at the middle key, got %f %f", c.At(0.9), c.At(1.1))
+	}
+}
+
+func TestGradient(t *testing.T) {
+	g, c := NewGradient(GradientKey{1, V4{0, 0, 1, 0}}, GradientKey{0, V4{1, 0, 0, 1}}), &V4{}
+	if !g.At(c, 0.25).Aeq(&V4{0.75, 0, 0.25, 0.75}) {
+		t.Errorf("Expected blended color, got %s", c.Dump())
+	}
+	if !g.At(c, -1).Aeq(&V4{1, 0, 0, 1}) || !g.At(c, 5).Aeq(&V4{0, 0, 1, 0}) {
+		t.Errorf("Expected end colors outside the keys, got %s", c.Dump())
+	}
+	if g.Add(0.5, &V4{0, 1, 0, 1}); !g.At(c, 0.5).Aeq(&V4{0, 1, 0, 1}) || len(g.Keys()) != 3 {
+		t.Errorf("Expected added key color, got %s", c.Dump())
+	}
+	if !NewGradient().At(c, 0).Aeq(&V4{}) {
+		t.Errorf("Expected empty gradient to be clear, got %s", c.Dump())
+	}
+}
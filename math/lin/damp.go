@@ -0,0 +1,105 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+// Damped motion moves a value towards a target each update in a way that
+// starts smoothly, never overshoots, and copes with changing targets and
+// uneven update times. The caller keeps the velocity between updates.
+// See Game Programming Gems 4, chapter 1.10, for SmoothDamp and
+// http://allenchou.net/2015/04/game-math-precise-control-over-numeric-springing
+// for Spring.
+
+// SmoothDamp returns the next value of current as it moves towards target
+// where smooth is roughly the time in seconds to reach the target and dt
+// is the elapsed time in seconds. The motion is critically damped so it
+// does not overshoot. Velocity vel is updated and is expected to be kept
+// and passed into the next call, ie: start with 0. For example:
+//    x = lin.SmoothDamp(x, target, &xvel, 0.3, dt)
+func SmoothDamp(current, target float64, vel *float64, smooth, dt float64) float64 {
+	if dt <= 0 {
+		return current
+	}
+	if smooth <= 0 {
+		*vel = 0
+		return target
+	}
+	omega := 2 / smooth
+	x := omega * dt
+	exp := 1 / (1 + x + 0.48*x*x + 0.235*x*x*x) // approximates e^-x.
+	change := current - target
+	temp := (*vel + omega*change) * dt
+	*vel = (*vel - omega*temp) * exp
+	next := target + (change+temp)*exp
+	if change != 0 && (target > current) == (next > target) {
+		*vel = 0
+		return target // stop overshoot from large time steps.
+	}
+	return next
+}
+
+// SmoothDamp updates v to be the next location of current as it moves
+// towards target. Velocity vel is updated and is expected to be passed
+// into the next call. See the lin.SmoothDamp function for details.
+// The updated vector v is returned. Vector v may be current.
+func (v *V3) SmoothDamp(current, target, vel *V3, smooth, dt float64) *V3 {
+	v.X = SmoothDamp(current.X, target.X, &vel.X, smooth, dt)
+	v.Y = SmoothDamp(current.Y, target.Y, &vel.Y, smooth, dt)
+	v.Z = SmoothDamp(current.Z, target.Z, &vel.Z, smooth, dt)
+	return v
+}
+
+// SmoothDamp updates q to be the next rotation of current as it turns
+// towards target, ie: a follow camera turning to face the player.
+// Velocity vel holds the rate of change of each quaternion value and is
+// expected to be passed into the next call, ie: start with &Q{}.
+// See the lin.SmoothDamp function for details.
+// The updated quaternion q is returned. Quaternion q may be current.
+func (q *Q) SmoothDamp(current, target, vel *Q, smooth, dt float64) *Q {
+	tx, ty, tz, tw := target.X, target.Y, target.Z, target.W
+	if current.Dot(target) < 0 {
+		tx, ty, tz, tw = -tx, -ty, -tz, -tw // turn the short way around.
+	}
+	q.X = SmoothDamp(current.X, tx, &vel.X, smooth, dt)
+	q.Y = SmoothDamp(current.Y, ty, &vel.Y, smooth, dt)
+	q.Z = SmoothDamp(current.Z, tz, &vel.Z, smooth, dt)
+	q.W = SmoothDamp(current.W, tw, &vel.W, smooth, dt)
+	return q.Unit()
+}
+
+// Spring returns the next value of current as it is pulled towards target
+// by a spring that oscillates freq times a second. Damping of 1 stops
+// without overshooting, less than 1 bounces, and greater than 1 is slower.
+// Velocity vel is updated and is expected to be kept and passed into the
+// next call. The spring is stable for any time step dt in seconds.
+func Spring(current, target float64, vel *float64, freq, damping, dt float64) float64 {
+	if dt <= 0 {
+		return current
+	}
+	omega := PIx2 * freq
+	f := 1 + 2*dt*damping*omega
+	hoo := dt * omega * omega
+	hhoo := dt * hoo
+	inv := 1 / (f + hhoo)
+	next := (f*current + *vel*dt + hhoo*target) * inv
+	*vel = (*vel + hoo*(target-current)) * inv
+	return next
+}
+
+// Spring updates v to be the next location of current as it is pulled
+// towards target by a spring. Velocity vel is updated and is expected to
+// be passed into the next call. See the lin.Spring function for details.
+// The updated vector v is returned. Vector v may be current.
+func (v *V3) Spring(current, target, vel *V3, freq, damping, dt float64) *V3 {
+	v.X = Spring(current.X, target.X, &vel.X, freq, damping, dt)
+	v.Y = Spring(current.Y, target.Y, &vel.Y, freq, damping, dt)
+	v.Z = Spring(current.Z, target.Z, &vel.Z, freq, damping, dt)
+	return v
+}
+
+// SmoothDampAngle is SmoothDamp for angles in radians. The value turns
+// the short way around towards target, ie: a camera yaw.
+func SmoothDampAngle(current, target float64, vel *float64, smooth, dt float64) float64 {
+	target = current + Nang(target-current)
+	return Nang(SmoothDamp(current, target, vel, smooth, dt))
+}
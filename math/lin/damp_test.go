@@ -0,0 +1,81 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"math"
+	"testing"
+)
+
+// Smooth damping gets close to the target in about the smooth time
+// and never goes past it, even with large time steps.
+func TestSmoothDamp(t *testing.T) {
+	x, vel := 0.0, 0.0
+	for cnt := 0; cnt < 60; cnt++ {
+		if x = SmoothDamp(x, 10, &vel, 0.5, 1.0/60); x > 10 {
+			t.Fatalf("Expected no overshoot, got %f", x)
+		}
+	}
+	if x < 9 || x > 10 {
+		t.Errorf("Expected close to target after a second, got %f", x)
+	}
+	if x = SmoothDamp(0, 10, new(float64), 0.5, 100); x > 10 || x < 9.99 {
+		t.Errorf("Expected large time step to stop at the target, got %f", x)
+	}
+	if x = SmoothDamp(3, 10, &vel, 0, 0.1); x != 10 || vel != 0 {
+		t.Errorf("Expected no smoothing to snap to the target, got %f %f", x, vel)
+	}
+	if x = SmoothDampAngle(Rad(170), Rad(-170), &vel, 0.5, 0.1); x < Rad(170) {
+		t.Errorf("Expected angle to turn the short way, got %f", Deg(x))
+	}
+}
+
+func TestSmoothDampV3(t *testing.T) {
+	v, vel, target := &V3{}, &V3{}, &V3{1, -2, 3}
+	for cnt := 0; cnt < 120; cnt++ {
+		v.SmoothDamp(v, target, vel, 0.25, 1.0/60)
+	}
+	if v.Dist(target) > 0.001 {
+		t.Errorf("Expected vector to reach target, got %s", v.Dump())
+	}
+}
+
+func TestSmoothDampQ(t *testing.T) {
+	q, vel := NewQI(), &Q{}
+	target := NewQ().SetAa(0, 1, 0, Rad(90))
+	target.Scale(-1) // same rotation the long way around.
+	prev := q.Ang(target)
+	for cnt := 0; cnt < 120; cnt++ {
+		q.SmoothDamp(q, target, vel, 0.25, 1.0/60)
+		if !Aeq(q.Len(), 1) || q.Ang(target) > prev+Epsilon {
+			t.Fatalf("Expected unit rotation turning towards the target")
+		}
+		prev = q.Ang(target)
+	}
+	if q.Ang(target) > 0.001 {
+		t.Errorf("Expected rotation to reach target, got %s", q.Dump())
+	}
+}
+
+// Springs settle on the target. Low damping bounces past the target
+// and critical damping does not.
+func TestSpring(t *testing.T) {
+	for _, damping := range []float64{0.2, 1} {
+		x, vel, most := 0.0, 0.0, 0.0
+		for cnt := 0; cnt < 600; cnt++ {
+			x = Spring(x, 1, &vel, 2, damping, 1.0/60)
+			most = math.Max(most, x)
+		}
+		if !Aeq(x, 1) || (damping < 1) != (most > 1.1) {
+			t.Errorf("Damping %f: got %f peak %f", damping, x, most)
+		}
+	}
+	if x := Spring(0, 1, new(float64), 2, 1, 1000); math.IsNaN(x) || x < 0.99 || x > 1.01 {
+		t.Errorf("Expected large time steps to stay stable, got %f", x)
+	}
+	v := (&V3{}).Spring(&V3{}, &V3{1, 2, 3}, &V3{}, 1, 1, 0)
+	if !v.Aeq(&V3{}) {
+		t.Errorf("Expected no time to leave the vector, got %s", v.Dump())
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import "math"
+
+// Frustum is the six planes, left, right, bottom, top, near, far,
+// bounding a camera view. Each plane normal points into the view volume.
+// See: http://www.cs.otago.ac.nz/postgrads/alexis/planeExtraction.pdf
+type Frustum [6]Plane
+
+// SetM4 updates f to be the planes of the view volume of matrix m,
+// where m is a view matrix multiplied by a projection matrix. The planes
+// are in world space, or in view space when m is only a projection.
+// The updated frustum f is returned.
+func (f *Frustum) SetM4(m *M4) *Frustum {
+	f[0].set(m.Xw+m.Xx, m.Yw+m.Yx, m.Zw+m.Zx, m.Ww+m.Wx) // left
+	f[1].set(m.Xw-m.Xx, m.Yw-m.Yx, m.Zw-m.Zx, m.Ww-m.Wx) // right
+	f[2].set(m.Xw+m.Xy, m.Yw+m.Yy, m.Zw+m.Zy, m.Ww+m.Wy) // bottom
+	f[3].set(m.Xw-m.Xy, m.Yw-m.Yy, m.Zw-m.Zy, m.Ww-m.Wy) // top
+	f[4].set(m.Xw+m.Xz, m.Yw+m.Yz, m.Zw+m.Zz, m.Ww+m.Wz) // near
+	f[5].set(m.Xw-m.Xz, m.Yw-m.Yz, m.Zw-m.Zz, m.Ww-m.Wz) // far
+	return f
+}
+
+// set updates plane p from the unnormalized plane values x,y,z,d.
+func (p *Plane) set(x, y, z, d float64) {
+	if l := math.Sqrt(x*x + y*y + z*z); l > 0 {
+		x, y, z, d = x/l, y/l, z/l, d/l
+	}
+	p.N.X, p.N.Y, p.N.Z, p.D = x, y, z, d
+}
+
+// ContainsPoint returns true if point at is inside the frustum.
+func (f *Frustum) ContainsPoint(at *V3) bool { return f.ContainsSphere(at, 0) }
+
+// ContainsSphere returns true if any part of the sphere at center
+// with radius r is inside the frustum.
+func (f *Frustum) ContainsSphere(center *V3, r float64) bool {
+	for cnt := range f {
+		if f[cnt].Dist(center) < -r {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAabb returns true if any part of box ab is inside the frustum.
+// Large boxes near frustum corners may be reported as inside.
+func (f *Frustum) ContainsAabb(ab *Aabb) bool { return ab.InPlanes(f[:]) }
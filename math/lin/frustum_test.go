@@ -0,0 +1,27 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"testing"
+)
+
+// A camera at 0,0,10 looking down -Z with a 90 degree field of view.
+func TestFrustum(t *testing.T) {
+	vp := NewM4().Set(M4I).TranslateMT(0, 0, -10)
+	vp.Mult(vp, NewPersp(90, 1, 1, 100))
+	f := (&Frustum{}).SetM4(vp)
+	if !f[0].N.Aeq((&V3{1, 0, -1}).Unit()) || !Aeq(f[4].Dist(&V3{}), 9) {
+		t.Errorf("Expected unit planes facing inwards, got %s %f", f[0].N.Dump(), f[4].Dist(&V3{}))
+	}
+	if !f.ContainsPoint(&V3{}) || !f.ContainsPoint(&V3{9, -9, 0}) || f.ContainsPoint(&V3{11, 0, 0}) || f.ContainsPoint(&V3{0, 0, 9.5}) {
+		t.Errorf("Expected points inside the view")
+	}
+	if !f.ContainsSphere(&V3{11, 0, 0}, 1) || !f.ContainsSphere(&V3{0, 0, -90.5}, 1) || f.ContainsSphere(&V3{0, 0, -150}, 10) {
+		t.Errorf("Expected spheres touching the view")
+	}
+	if !f.ContainsAabb(&Aabb{Min: V3{10, 0, 0}, Max: V3{12, 1, 1}}) || f.ContainsAabb(&Aabb{Min: V3{0, 0, 10}, Max: V3{1, 1, 12}}) {
+		t.Errorf("Expected boxes touching the view")
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+// Geometric primitives with intersection and distance tests for culling,
+// picking, and gameplay queries. See "Real-Time Collision Detection"
+// by Christer Ericson for the details behind the tests.
+
+import "math"
+
+// Plane is an infinite flat surface where all points p on the plane
+// satisfy N·p + D = 0. N is expected to be a unit normal. Points on the
+// side of the plane that N points to have positive distances.
+type Plane struct {
+	N V3      // Unit normal.
+	D float64 // Distance from the origin along -N.
+}
+
+// SetPoint updates plane p to have normal n and go through point at.
+// Normal n is expected to be unit length. The updated plane p is returned.
+func (p *Plane) SetPoint(n, at *V3) *Plane {
+	p.N.Set(n)
+	p.D = -n.Dot(at)
+	return p
+}
+
+// SetTri updates plane p to go through the points of triangle a, b, c.
+// The normal faces towards the side where a, b, c are counter clockwise.
+// The updated plane p is returned.
+func (p *Plane) SetTri(a, b, c *V3) *Plane {
+	ab, ac := &V3{}, &V3{}
+	p.N.Cross(ab.Sub(b, a), ac.Sub(c, a)).Unit()
+	p.D = -p.N.Dot(a)
+	return p
+}
+
+// Dist returns the signed distance from the plane to point at.
+func (p *Plane) Dist(at *V3) float64 { return p.N.Dot(at) + p.D }
+
+// Closest updates v to be the point on plane p closest to point at.
+// The updated vector v is returned. Vector v may be used as at.
+func (p *Plane) Closest(v, at *V3) *V3 {
+	d := p.Dist(at)
+	v.X, v.Y, v.Z = at.X-p.N.X*d, at.Y-p.N.Y*d, at.Z-p.N.Z*d
+	return v
+}
+
+// Ray is a half line from an origin heading in a direction.
+// Hits are reported as distances t along the ray where the hit
+// location is Origin + t*Dir. Distances are in world units
+// when Dir is unit length.
+type Ray struct {
+	Origin V3 // Start of the ray.
+	Dir    V3 // Direction of the ray, usually unit length.
+}
+
+// At updates v to be the point at distance t along the ray.
+// The updated vector v is returned.
+func (r *Ray) At(v *V3, t float64) *V3 {
+	v.X, v.Y, v.Z = r.Origin.X+r.Dir.X*t, r.Origin.Y+r.Dir.Y*t, r.Origin.Z+r.Dir.Z*t
+	return v
+}
+
+// Closest returns the distance t along the ray for the point on the
+// ray that is closest to point at. 0 is returned for points behind
+// the ray origin.
+func (r *Ray) Closest(at *V3) (t float64) {
+	dd := r.Dir.Dot(&r.Dir)
+	if dd < Epsilon {
+		return 0
+	}
+	t = ((at.X-r.Origin.X)*r.Dir.X + (at.Y-r.Origin.Y)*r.Dir.Y + (at.Z-r.Origin.Z)*r.Dir.Z) / dd
+	return math.Max(t, 0)
+}
+
+// Plane returns the distance t along the ray to where it hits plane p.
+// False is returned if the ray is parallel to, or pointing away from,
+// the plane.
+func (r *Ray) Plane(p *Plane) (t float64, hit bool) {
+	nd := p.N.Dot(&r.Dir)
+	if math.Abs(nd) < Epsilon {
+		return 0, false
+	}
+	if t = -p.Dist(&r.Origin) / nd; t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// Sphere returns the distance t along the ray to where it first hits
+// the sphere at center c with radius rad. The ray hits at 0 if it starts
+// inside the sphere. False is returned if the ray misses.
+func (r *Ray) Sphere(c *V3, rad float64) (t float64, hit bool) {
+	mx, my, mz := r.Origin.X-c.X, r.Origin.Y-c.Y, r.Origin.Z-c.Z
+	a := r.Dir.Dot(&r.Dir)
+	b := mx*r.Dir.X + my*r.Dir.Y + mz*r.Dir.Z
+	cc := mx*mx + my*my + mz*mz - rad*rad
+	if cc <= 0 {
+		return 0, true // inside.
+	}
+	disc := b*b - a*cc
+	if b > 0 || disc < 0 || a < Epsilon {
+		return 0, false // pointing away or missed.
+	}
+	return (-b - math.Sqrt(disc)) / a, true
+}
+
+// Triangle returns the distance t along the ray to where it hits
+// triangle a, b, c. Both sides of the triangle are hit. False is returned
+// if the ray misses. Uses the Möller–Trumbore algorithm.
+func (r *Ray) Triangle(a, b, c *V3) (t float64, hit bool) {
+	e1x, e1y, e1z := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+	e2x, e2y, e2z := c.X-a.X, c.Y-a.Y, c.Z-a.Z
+	px, py, pz := r.Dir.Y*e2z-r.Dir.Z*e2y, r.Dir.Z*e2x-r.Dir.X*e2z, r.Dir.X*e2y-r.Dir.Y*e2x
+	det := e1x*px + e1y*py + e1z*pz
+	if math.Abs(det) < Epsilon {
+		return 0, false // parallel to the triangle.
+	}
+	inv := 1 / det
+	sx, sy, sz := r.Origin.X-a.X, r.Origin.Y-a.Y, r.Origin.Z-a.Z
+	u := (sx*px + sy*py + sz*pz) * inv
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	qx, qy, qz := sy*e1z-sz*e1y, sz*e1x-sx*e1z, sx*e1y-sy*e1x
+	v := (r.Dir.X*qx + r.Dir.Y*qy + r.Dir.Z*qz) * inv
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	if t = (e2x*qx + e2y*qy + e2z*qz) * inv; t < 0 {
+		return 0, false // behind the ray.
+	}
+	return t, true
+}
+
+// Aabb returns the distance t along the ray to where it first hits
+// the axis aligned box ab. The ray hits at 0 if it starts inside the box.
+// False is returned if the ray misses.
+func (r *Ray) Aabb(ab *Aabb) (t float64, hit bool) {
+	return slabs(&r.Origin, &r.Dir, &ab.Min, &ab.Max)
+}
+
+// Obb returns the distance t along the ray to where it first hits
+// the oriented box ob. The ray hits at 0 if it starts inside the box.
+// False is returned if the ray misses.
+func (r *Ray) Obb(ob *Obb) (t float64, hit bool) {
+	inv := &Q{}
+	inv.Inv(&ob.Rot)
+	o, d := &V3{}, &V3{}
+	o.Sub(&r.Origin, &ob.Center).MultQ(o, inv) // ray in box space.
+	d.MultQ(&r.Dir, inv)
+	min := &V3{-ob.Half.X, -ob.Half.Y, -ob.Half.Z}
+	return slabs(o, d, min, &ob.Half)
+}
+
+// slabs intersects a ray with an axis aligned box by clipping the ray
+// against the pair of planes on each axis.
+func slabs(o, d, min, max *V3) (t float64, hit bool) {
+	tmin, tmax := 0.0, math.Inf(1)
+	for axis := 0; axis < 3; axis++ {
+		oa, da, lo, hi := o.X, d.X, min.X, max.X
+		switch axis {
+		case 1:
+			oa, da, lo, hi = o.Y, d.Y, min.Y, max.Y
+		case 2:
+			oa, da, lo, hi = o.Z, d.Z, min.Z, max.Z
+		}
+		if math.Abs(da) < Epsilon {
+			if oa < lo || oa > hi {
+				return 0, false // parallel and outside the slab.
+			}
+			continue
+		}
+		t0, t1 := (lo-oa)/da, (hi-oa)/da
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tmin, tmax = math.Max(tmin, t0), math.Min(tmax, t1)
+		if tmin > tmax {
+			return 0, false
+		}
+	}
+	return tmin, true
+}
+
+// Aabb is an axis aligned bounding box given by its minimum
+// and maximum corners.
+type Aabb struct {
+	Min V3 // Minimum corner.
+	Max V3 // Maximum corner.
+}
+
+// Contains returns true if point at is inside or on box ab.
+func (ab *Aabb) Contains(at *V3) bool {
+	return at.X >= ab.Min.X && at.X <= ab.Max.X &&
+		at.Y >= ab.Min.Y && at.Y <= ab.Max.Y &&
+		at.Z >= ab.Min.Z && at.Z <= ab.Max.Z
+}
+
+// Overlaps returns true if boxes ab and a touch or overlap.
+func (ab *Aabb) Overlaps(a *Aabb) bool {
+	return ab.Min.X <= a.Max.X && ab.Max.X >= a.Min.X &&
+		ab.Min.Y <= a.Max.Y && ab.Max.Y >= a.Min.Y &&
+		ab.Min.Z <= a.Max.Z && ab.Max.Z >= a.Min.Z
+}
+
+// Closest updates v to be the point in box ab closest to point at.
+// Points inside the box are unchanged. The updated vector v is returned.
+// Vector v may be used as at.
+func (ab *Aabb) Closest(v, at *V3) *V3 {
+	v.X = Clamp(at.X, ab.Min.X, ab.Max.X)
+	v.Y = Clamp(at.Y, ab.Min.Y, ab.Max.Y)
+	v.Z = Clamp(at.Z, ab.Min.Z, ab.Max.Z)
+	return v
+}
+
+// Dist returns the distance from box ab to point at.
+// Points inside the box have 0 distance.
+func (ab *Aabb) Dist(at *V3) float64 {
+	c := &V3{}
+	return ab.Closest(c, at).Dist(at)
+}
+
+// InPlanes returns true if any part of box ab is on the positive side
+// of all the given planes, ie: the planes of a view frustum. Large boxes
+// near frustum corners may be reported as inside.
+func (ab *Aabb) InPlanes(planes []Plane) bool {
+	for cnt := range planes {
+		p := &planes[cnt]
+		x, y, z := ab.Min.X, ab.Min.Y, ab.Min.Z // corner furthest along the plane normal.
+		if p.N.X >= 0 {
+			x = ab.Max.X
+		}
+		if p.N.Y >= 0 {
+			y = ab.Max.Y
+		}
+		if p.N.Z >= 0 {
+			z = ab.Max.Z
+		}
+		if p.N.X*x+p.N.Y*y+p.N.Z*z+p.D < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Obb is an oriented bounding box. It is an axis aligned box, given by
+// its half size along each axis, that has been rotated and moved.
+type Obb struct {
+	Center V3 // Box center.
+	Half   V3 // Half the box size along each local axis.
+	Rot    Q  // Box orientation.
+}
+
+// axes returns the world directions of the box local axes.
+func (ob *Obb) axes() (ax, ay, az V3) {
+	ax.MultQ(&V3{1, 0, 0}, &ob.Rot)
+	ay.MultQ(&V3{0, 1, 0}, &ob.Rot)
+	az.MultQ(&V3{0, 0, 1}, &ob.Rot)
+	return ax, ay, az
+}
+
+// Contains returns true if point at is inside or on box ob.
+func (ob *Obb) Contains(at *V3) bool {
+	ax, ay, az := ob.axes()
+	d := &V3{}
+	d.Sub(at, &ob.Center)
+	return math.Abs(d.Dot(&ax)) <= ob.Half.X+Epsilon &&
+		math.Abs(d.Dot(&ay)) <= ob.Half.Y+Epsilon &&
+		math.Abs(d.Dot(&az)) <= ob.Half.Z+Epsilon
+}
+
+// Closest updates v to be the point in box ob closest to point at.
+// The updated vector v is returned. Vector v may be used as at.
+func (ob *Obb) Closest(v, at *V3) *V3 {
+	ax, ay, az := ob.axes()
+	d := &V3{}
+	d.Sub(at, &ob.Center)
+	dx := Clamp(d.Dot(&ax), -ob.Half.X, ob.Half.X)
+	dy := Clamp(d.Dot(&ay), -ob.Half.Y, ob.Half.Y)
+	dz := Clamp(d.Dot(&az), -ob.Half.Z, ob.Half.Z)
+	v.X = ob.Center.X + ax.X*dx + ay.X*dy + az.X*dz
+	v.Y = ob.Center.Y + ax.Y*dx + ay.Y*dy + az.Y*dz
+	v.Z = ob.Center.Z + ax.Z*dx + ay.Z*dy + az.Z*dz
+	return v
+}
+
+// Dist returns the distance from box ob to point at.
+// Points inside the box have 0 distance.
+func (ob *Obb) Dist(at *V3) float64 {
+	c := &V3{}
+	return ob.Closest(c, at).Dist(at)
+}
+
+// Aabb updates ab to be the axis aligned box that encloses box ob.
+// The updated box ab is returned.
+func (ob *Obb) Aabb(ab *Aabb) *Aabb {
+	ax, ay, az := ob.axes()
+	ex := math.Abs(ax.X)*ob.Half.X + math.Abs(ay.X)*ob.Half.Y + math.Abs(az.X)*ob.Half.Z
+	ey := math.Abs(ax.Y)*ob.Half.X + math.Abs(ay.Y)*ob.Half.Y + math.Abs(az.Y)*ob.Half.Z
+	ez := math.Abs(ax.Z)*ob.Half.X + math.Abs(ay.Z)*ob.Half.Y + math.Abs(az.Z)*ob.Half.Z
+	ab.Min.SetS(ob.Center.X-ex, ob.Center.Y-ey, ob.Center.Z-ez)
+	ab.Max.SetS(ob.Center.X+ex, ob.Center.Y+ey, ob.Center.Z+ez)
+	return ab
+}
+
+// Overlaps returns true if boxes ob and o touch or overlap. The boxes
+// are checked for a separating axis along each box axis and along the
+// cross product of each pair of box axes.
+func (ob *Obb) Overlaps(o *Obb) bool {
+	a0, a1, a2 := ob.axes()
+	b0, b1, b2 := o.axes()
+	a, b := [3]V3{a0, a1, a2}, [3]V3{b0, b1, b2}
+	ha, hb := [3]float64{ob.Half.X, ob.Half.Y, ob.Half.Z}, [3]float64{o.Half.X, o.Half.Y, o.Half.Z}
+	d := &V3{}
+	d.Sub(&o.Center, &ob.Center)
+	separated := func(axis *V3) bool {
+		if axis.Dot(axis) < Epsilon {
+			return false // parallel edges give no axis.
+		}
+		ra, rb := 0.0, 0.0
+		for cnt := 0; cnt < 3; cnt++ {
+			ra += ha[cnt] * math.Abs(a[cnt].Dot(axis))
+			rb += hb[cnt] * math.Abs(b[cnt].Dot(axis))
+		}
+		return math.Abs(d.Dot(axis)) > ra+rb
+	}
+	axis := &V3{}
+	for i := 0; i < 3; i++ {
+		if separated(&a[i]) || separated(&b[i]) {
+			return false
+		}
+		for j := 0; j < 3; j++ {
+			if separated(axis.Cross(&a[i], &b[j])) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"testing"
+)
+
+func TestPlaneDist(t *testing.T) {
+	p, v := &Plane{}, &V3{}
+	p.SetTri(&V3{0, 1, 0}, &V3{0, 1, 1}, &V3{1, 1, 0})
+	if !p.N.Aeq(&V3{0, 1, 0}) || !Aeq(p.Dist(&V3{5, 3, 5}), 2) {
+		t.Errorf("Expected upward plane at y=1, got %s %f", p.N.Dump(), p.D)
+	}
+	if !p.Closest(v, &V3{5, -3, 2}).Aeq(&V3{5, 1, 2}) {
+		t.Errorf("Expected point on plane, got %s", v.Dump())
+	}
+	r := &Ray{Origin: V3{0, 5, 0}, Dir: V3{0, -1, 0}}
+	if d, hit := r.Plane(p); !hit || !Aeq(d, 4) {
+		t.Errorf("Expected ray to hit plane at 4, got %f", d)
+	}
+	if _, hit := r.Plane(p.SetPoint(&V3{0, 1, 0}, &V3{0, 6, 0})); hit {
+		t.Errorf("Expected plane behind ray to be missed")
+	}
+}
+
+func TestRay(t *testing.T) {
+	r, v := &Ray{Origin: V3{0, 0, -5}, Dir: V3{0, 0, 1}}, &V3{}
+	if !r.At(v, 2).Aeq(&V3{0, 0, -3}) || !Aeq(r.Closest(&V3{3, 3, 0}), 5) || r.Closest(&V3{0, 0, -9}) != 0 {
+		t.Errorf("Expected points along the ray")
+	}
+	if d, hit := r.Sphere(&V3{0, 0, 0}, 1); !hit || !Aeq(d, 4) {
+		t.Errorf("Expected sphere hit at 4, got %f", d)
+	}
+	if _, hit := r.Sphere(&V3{2, 0, 0}, 1); hit {
+		t.Errorf("Expected sphere miss")
+	}
+	if d, hit := r.Triangle(&V3{-1, -1, 0}, &V3{1, -1, 0}, &V3{0, 1, 0}); !hit || !Aeq(d, 5) {
+		t.Errorf("Expected triangle hit at 5, got %f", d)
+	}
+	if _, hit := r.Triangle(&V3{1, 1, 0}, &V3{2, 1, 0}, &V3{1, 2, 0}); hit {
+		t.Errorf("Expected triangle miss")
+	}
+	ab := &Aabb{Min: V3{-1, -1, -1}, Max: V3{1, 1, 1}}
+	if d, hit := r.Aabb(ab); !hit || !Aeq(d, 4) {
+		t.Errorf("Expected box hit at 4, got %f", d)
+	}
+	if d, hit := (&Ray{Dir: V3{1, 0, 0}}).Aabb(ab); !hit || d != 0 {
+		t.Errorf("Expected hit from inside the box, got %f", d)
+	}
+	if _, hit := (&Ray{Origin: V3{0, 2, -5}, Dir: V3{0, 0, 1}}).Aabb(ab); hit {
+		t.Errorf("Expected box miss")
+	}
+
+	// box turned 45 degrees about Y has corners at ±√2 along Z.
+	ob := &Obb{Half: V3{1, 1, 1}}
+	ob.Rot.SetAa(0, 1, 0, Rad(45))
+	if d, hit := r.Obb(ob); !hit || !Aeq(d, 5-Sqrt2) {
+		t.Errorf("Expected oriented box hit at %f, got %f", 5-Sqrt2, d)
+	}
+}
+
+func TestAabb(t *testing.T) {
+	ab, v := &Aabb{Min: V3{0, 0, 0}, Max: V3{2, 2, 2}}, &V3{}
+	if !ab.Contains(&V3{1, 2, 0}) || ab.Contains(&V3{1, 2.1, 0}) {
+		t.Errorf("Expected contains to include edges")
+	}
+	if !ab.Overlaps(&Aabb{Min: V3{2, 2, 2}, Max: V3{3, 3, 3}}) || ab.Overlaps(&Aabb{Min: V3{3, 0, 0}, Max: V3{4, 1, 1}}) {
+		t.Errorf("Expected overlaps to include touching boxes")
+	}
+	if !ab.Closest(v, &V3{5, 1, -1}).Aeq(&V3{2, 1, 0}) || !Aeq(ab.Dist(&V3{5, 1, 2}), 3) || ab.Dist(&V3{1, 1, 1}) != 0 {
+		t.Errorf("Expected closest point on the box, got %s", v.Dump())
+	}
+
+	// planes facing into a 10 unit cube around the origin.
+	planes := make([]Plane, 6)
+	for cnt, n := range []V3{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}} {
+		planes[cnt].N, planes[cnt].D = n, 5
+	}
+	if !ab.InPlanes(planes) || !(&Aabb{Min: V3{4, 4, 4}, Max: V3{6, 6, 6}}).InPlanes(planes) {
+		t.Errorf("Expected boxes inside the planes")
+	}
+	if (&Aabb{Min: V3{6, 0, 0}, Max: V3{7, 1, 1}}).InPlanes(planes) {
+		t.Errorf("Expected box outside the planes")
+	}
+}
+
+func TestObb(t *testing.T) {
+	ob, v := &Obb{Center: V3{1, 0, 0}, Half: V3{2, 1, 1}}, &V3{}
+	ob.Rot.SetAa(0, 0, 1, Rad(90)) // long side along Y.
+	if !ob.Contains(&V3{1, 1.9, 0}) || ob.Contains(&V3{2.9, 0, 0}) {
+		t.Errorf("Expected rotated box contents")
+	}
+	if !ob.Closest(v, &V3{5, 5, 0}).Aeq(&V3{2, 2, 0}) || !Aeq(ob.Dist(&V3{1, 0, 3}), 2) {
+		t.Errorf("Expected closest point at the corner, got %s", v.Dump())
+	}
+	ab := &Aabb{}
+	if ob.Aabb(ab); !ab.Min.Aeq(&V3{0, -2, -1}) || !ab.Max.Aeq(&V3{2, 2, 1}) {
+		t.Errorf("Expected enclosing box, got %s %s", ab.Min.Dump(), ab.Max.Dump())
+	}
+	o := &Obb{Center: V3{3.5, 0, 0}, Half: V3{1, 1, 1}}
+	o.Rot.SetAa(0, 0, 1, Rad(45))
+	if ob.Overlaps(o) {
+		t.Errorf("Expected turned boxes apart")
+	}
+	o.Center.X = 3.3
+	if !ob.Overlaps(o) || !o.Overlaps(ob) {
+		t.Errorf("Expected turned boxes to overlap")
+	}
+}
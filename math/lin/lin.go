@@ -2,7 +2,9 @@
 // Use is governed by a BSD-style license found in the LICENSE file.
 
 // Package lin provides a linear math library that includes vectors,
-// matrices, quaternions, transforms and some utility functions.
+// matrices, quaternions, transforms, splines, keyframed curves and color
+// gradients, intersection tests for planes, rays, boxes and triangles,
+// seeded random numbers, and some utility functions.
 // Linear math operations are useful in 3D applications for describing
 // and transforming virtual objects as well as simulating physics.
 //
@@ -23,7 +25,8 @@ package lin
 //
 // 3) Wikipedia states: "In linear algebra, real numbers are called scalars...".
 //    Currently the default scalar size is float64 since the underlying go math
-//    package uses this size.
+//    package uses this size. The batch operations work directly on float32
+//    data since that is what gets sent to the GPU.
 
 import "math"
 
@@ -64,6 +67,22 @@ func Aeq(a, b float64) bool { return math.Abs(a-b) < Epsilon }
 // Lerp returns the linear interpolation of a to b by the given ratio.
 func Lerp(a, b, ratio float64) float64 { return (b-a)*ratio + a }
 
+// Easing functions map a linear ratio from 0 to 1 into a ratio from
+// 0 to 1 that changes speed along the way. Use with Lerp for smoother
+// starts and stops. See http://easings.net.
+
+// EaseLinear returns the ratio unchanged.
+func EaseLinear(ratio float64) float64 { return ratio }
+
+// EaseIn starts slow and speeds up.
+func EaseIn(ratio float64) float64 { return ratio * ratio }
+
+// EaseOut starts fast and slows down.
+func EaseOut(ratio float64) float64 { return ratio * (2 - ratio) }
+
+// EaseInOut starts slow, speeds up, then slows down.
+func EaseInOut(ratio float64) float64 { return ratio * ratio * (3 - 2*ratio) }
+
 // Max3 returns the largest of the 3 numbers.
 func Max3(a, b, c float64) float64 { return math.Max(a, math.Max(b, c)) }
 
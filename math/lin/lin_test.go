@@ -33,6 +33,17 @@ func TestLerp(t *testing.T) {
 	}
 }
 
+func TestEasing(t *testing.T) {
+	for _, ease := range []func(float64) float64{EaseLinear, EaseIn, EaseOut, EaseInOut} {
+		if !AeqZ(ease(0)) || !Aeq(ease(1), 1) {
+			t.Error("Easing ends")
+		}
+	}
+	if EaseIn(0.25) >= 0.25 || EaseOut(0.25) <= 0.25 || !Aeq(EaseInOut(0.5), 0.5) {
+		t.Error("Easing")
+	}
+}
+
 // Check that the results of Atan2 and Atan2F are similar.
 func TestAtan2F(t *testing.T) {
 	if !Aeq(math.Atan2(1, 0), Atan2F(1, 0)) || !Aeq(math.Atan2(-1, 0), Atan2F(-1, 0)) {
@@ -490,6 +490,76 @@ func (m *M4) SetQ(q *Q) *M4 {
 	return m
 }
 
+// Decompose extracts the translation, rotation, and scale from a model
+// matrix m that was created by applying scale first, then rotation,
+// then translation, ie: a world transform matrix. Only the upper 3x3
+// values and the translation row are used. Shear is not extracted.
+// Negative scale is returned on the X axis for mirrored matrices.
+// Matrix m is unchanged, loc, rot, and scale are updated.
+func (m *M4) Decompose(loc *V3, rot *Q, scale *V3) {
+	loc.SetS(m.Wx, m.Wy, m.Wz)
+	sx := math.Sqrt(m.Xx*m.Xx + m.Xy*m.Xy + m.Xz*m.Xz)
+	sy := math.Sqrt(m.Yx*m.Yx + m.Yy*m.Yy + m.Yz*m.Yz)
+	sz := math.Sqrt(m.Zx*m.Zx + m.Zy*m.Zy + m.Zz*m.Zz)
+	r := &M3{}
+	r.SetM4(m)
+	if r.Det() < 0 {
+		sx = -sx // mirrored.
+	}
+	scale.SetS(sx, sy, sz)
+	if sx == 0 || sy == 0 || sz == 0 {
+		rot.Set(QI)
+		return // no rotation can be recovered.
+	}
+
+	// the rows are the transposed rotation matrix. See M4.SetQ.
+	r.Xx, r.Xy, r.Xz = m.Xx/sx, m.Yx/sy, m.Zx/sz
+	r.Yx, r.Yy, r.Yz = m.Xy/sx, m.Yy/sy, m.Zy/sz
+	r.Zx, r.Zy, r.Zz = m.Xz/sx, m.Yz/sy, m.Zz/sz
+	switch trace := r.Xx + r.Yy + r.Zz; {
+	case trace > 0:
+		s := math.Sqrt(trace+1) * 2 // s=4*qw
+		rot.SetS((r.Zy-r.Yz)/s, (r.Xz-r.Zx)/s, (r.Yx-r.Xy)/s, 0.25*s)
+	case r.Xx > r.Yy && r.Xx > r.Zz:
+		s := math.Sqrt(r.Xx-r.Yy-r.Zz+1) * 2 // s=4*qx
+		rot.SetS(0.25*s, (r.Xy+r.Yx)/s, (r.Xz+r.Zx)/s, (r.Zy-r.Yz)/s)
+	case r.Yy > r.Zz:
+		s := math.Sqrt(r.Yy-r.Xx-r.Zz+1) * 2 // s=4*qy
+		rot.SetS((r.Xy+r.Yx)/s, 0.25*s, (r.Yz+r.Zy)/s, (r.Xz-r.Zx)/s)
+	default:
+		s := math.Sqrt(r.Zz-r.Xx-r.Yy+1) * 2 // s=4*qz
+		rot.SetS((r.Xz+r.Zx)/s, (r.Yz+r.Zy)/s, 0.25*s, (r.Yx-r.Xy)/s)
+	}
+	rot.Unit()
+}
+
+// LookAt sets matrix m to be a view matrix for a viewer at eye looking
+// towards at where up is the general up direction, ie: 0, 1, 0.
+// The viewer looks down its -Z axis. Matrix m is not updated if eye
+// and at are the same or if up is the same direction as the view.
+// The updated matrix m is returned. The viewer axes x, y, z and the
+// eye location e fill the following matrix locations:
+//    [  xx    yx    zx   0 ]    [ Xx Xy Xz Xw ]
+//    [  xy    yy    zy   0 ] => [ Yx Yy Yz Yw ]
+//    [  xz    yz    zz   0 ]    [ Zx Zy Zz Zw ]
+//    [ -x·e  -y·e  -z·e  1 ]    [ Wx Wy Wz Ww ]
+func (m *M4) LookAt(eye, at, up *V3) *M4 {
+	z := &V3{eye.X - at.X, eye.Y - at.Y, eye.Z - at.Z} // backwards.
+	x := &V3{}
+	if x.Cross(up, z); z.AeqZ() || x.AeqZ() {
+		return m
+	}
+	z.Unit()
+	x.Unit()
+	y := &V3{}
+	y.Cross(z, x)
+	m.Xx, m.Xy, m.Xz, m.Xw = x.X, y.X, z.X, 0
+	m.Yx, m.Yy, m.Yz, m.Yw = x.Y, y.Y, z.Y, 0
+	m.Zx, m.Zy, m.Zz, m.Zw = x.Z, y.Z, z.Z, 0
+	m.Wx, m.Wy, m.Wz, m.Ww = -x.Dot(eye), -y.Dot(eye), -z.Dot(eye), 1
+	return m
+}
+
 // SetSkewSym sets the matrix m to be a skew-symetric matrix based
 // on the elements of vector v. Wikipedia states:
 //    "A skew-symmetric matrix is a square matrix
@@ -636,6 +706,80 @@ func (m *M4) Ortho(left, right, bottom, top, near, far float64) *M4 {
 	return m
 }
 
+// Det returns the determinant of matrix m.
+func (m *M4) Det() float64 {
+	s0 := m.Xx*m.Yy - m.Yx*m.Xy
+	s1 := m.Xx*m.Yz - m.Yx*m.Xz
+	s2 := m.Xx*m.Yw - m.Yx*m.Xw
+	s3 := m.Xy*m.Yz - m.Yy*m.Xz
+	s4 := m.Xy*m.Yw - m.Yy*m.Xw
+	s5 := m.Xz*m.Yw - m.Yz*m.Xw
+	c5 := m.Zz*m.Ww - m.Wz*m.Zw
+	c4 := m.Zy*m.Ww - m.Wy*m.Zw
+	c3 := m.Zy*m.Wz - m.Wy*m.Zz
+	c2 := m.Zx*m.Ww - m.Wx*m.Zw
+	c1 := m.Zx*m.Wz - m.Wx*m.Zz
+	c0 := m.Zx*m.Wy - m.Wx*m.Zy
+	return s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+}
+
+// Inv updates m to be the inverse of matrix a. The updated matrix m
+// is returned. Matrix m is not updated if the matrix has no inverse.
+// Use the specialized inverses, like PerspInv, where possible.
+// General inverse based on the Laplace expansion theorem:
+//    http://www.geometrictools.com/Documentation/LaplaceExpansionTheorem.pdf
+func (m *M4) Inv(a *M4) *M4 {
+	s0 := a.Xx*a.Yy - a.Yx*a.Xy
+	s1 := a.Xx*a.Yz - a.Yx*a.Xz
+	s2 := a.Xx*a.Yw - a.Yx*a.Xw
+	s3 := a.Xy*a.Yz - a.Yy*a.Xz
+	s4 := a.Xy*a.Yw - a.Yy*a.Xw
+	s5 := a.Xz*a.Yw - a.Yz*a.Xw
+	c5 := a.Zz*a.Ww - a.Wz*a.Zw
+	c4 := a.Zy*a.Ww - a.Wy*a.Zw
+	c3 := a.Zy*a.Wz - a.Wy*a.Zz
+	c2 := a.Zx*a.Ww - a.Wx*a.Zw
+	c1 := a.Zx*a.Wz - a.Wx*a.Zz
+	c0 := a.Zx*a.Wy - a.Wx*a.Zy
+	det := s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+	if det == 0 {
+		return m
+	}
+	d := 1 / det
+	xx := (a.Yy*c5 - a.Yz*c4 + a.Yw*c3) * d
+	xy := (-a.Xy*c5 + a.Xz*c4 - a.Xw*c3) * d
+	xz := (a.Wy*s5 - a.Wz*s4 + a.Ww*s3) * d
+	xw := (-a.Zy*s5 + a.Zz*s4 - a.Zw*s3) * d
+	yx := (-a.Yx*c5 + a.Yz*c2 - a.Yw*c1) * d
+	yy := (a.Xx*c5 - a.Xz*c2 + a.Xw*c1) * d
+	yz := (-a.Wx*s5 + a.Wz*s2 - a.Ww*s1) * d
+	yw := (a.Zx*s5 - a.Zz*s2 + a.Zw*s1) * d
+	zx := (a.Yx*c4 - a.Yy*c2 + a.Yw*c0) * d
+	zy := (-a.Xx*c4 + a.Xy*c2 - a.Xw*c0) * d
+	zz := (a.Wx*s4 - a.Wy*s2 + a.Ww*s0) * d
+	zw := (-a.Zx*s4 + a.Zy*s2 - a.Zw*s0) * d
+	wx := (-a.Yx*c3 + a.Yy*c1 - a.Yz*c0) * d
+	wy := (a.Xx*c3 - a.Xy*c1 + a.Xz*c0) * d
+	wz := (-a.Wx*s3 + a.Wy*s1 - a.Wz*s0) * d
+	ww := (a.Zx*s3 - a.Zy*s1 + a.Zz*s0) * d
+	m.Xx, m.Xy, m.Xz, m.Xw = xx, xy, xz, xw
+	m.Yx, m.Yy, m.Yz, m.Yw = yx, yy, yz, yw
+	m.Zx, m.Zy, m.Zz, m.Zw = zx, zy, zz, zw
+	m.Wx, m.Wy, m.Wz, m.Ww = wx, wy, wz, ww
+	return m
+}
+
+// OrthoInv sets matrix m to be the inverse of the given orthographic
+// matrix values (see Ortho()). This is used when going from screen
+// x,y coordinates to 3D coordinates in an orthographic view.
+func (m *M4) OrthoInv(left, right, bottom, top, near, far float64) *M4 {
+	m.Xx, m.Xy, m.Xz, m.Xw = (right-left)/2, 0, 0, 0
+	m.Yx, m.Yy, m.Yz, m.Yw = 0, (top-bottom)/2, 0, 0
+	m.Zx, m.Zy, m.Zz, m.Zw = 0, 0, -(far-near)/2, 0
+	m.Wx, m.Wy, m.Wz, m.Ww = (right+left)/2, (top+bottom)/2, -(far+near)/2, 1
+	return m
+}
+
 // Persp sets matrix m with projection values needed to
 // transform a 3 dimensional model to a 2 dimensional plane.
 // Objects that are further away from the viewer will appear smaller.
@@ -723,3 +867,18 @@ func NewM3I() *M3 { return &M3{Xx: 1, Yy: 1, Zz: 1} }
 //    [ 0 0 1 0 ]    [ Zx Zy Zz Zw ]
 //    [ 0 0 0 1 ]    [ Wx Wy Wz Ww ]
 func NewM4I() *M4 { return &M4{Xx: 1, Yy: 1, Zz: 1, Ww: 1} }
+
+// NewPersp creates a new perspective projection matrix.
+// See M4.Persp for the meaning of the input values.
+func NewPersp(fov, aspect, near, far float64) *M4 {
+	return (&M4{}).Persp(fov, aspect, near, far)
+}
+
+// NewOrtho creates a new orthographic projection matrix.
+// See M4.Ortho for the meaning of the input values.
+func NewOrtho(left, right, bottom, top, near, far float64) *M4 {
+	return (&M4{}).Ortho(left, right, bottom, top, near, far)
+}
+
+// NewLookAt creates a new view matrix. See M4.LookAt.
+func NewLookAt(eye, at, up *V3) *M4 { return NewM4I().LookAt(eye, at, up) }
@@ -342,6 +342,58 @@ func TestPerspectiveInv(t *testing.T) {
 	}
 }
 
+func TestInverse(t *testing.T) {
+	m := &M4{}
+	a := NewM4().SetQ(NewQ().SetAa(1, 2, 3, Rad(30))).ScaleMS(2, 3, 4).TranslateMT(1, -2, 5)
+	ia := NewM4().Inv(a)
+	if !m.Mult(a, ia).Aeq(M4I) {
+		t.Errorf(format, m.Dump(), M4I.Dump())
+	}
+	if det := NewM4I().ScaleMS(2, 3, 4).Det(); !Aeq(det, 24) {
+		t.Errorf("Expected determinant 24, got %f", det)
+	}
+}
+
+func TestOrthographicInv(t *testing.T) {
+	m := &M4{}
+	o := NewM4().Ortho(-2, 6, 1, 5, 0.1, 50)
+	io := NewM4().OrthoInv(-2, 6, 1, 5, 0.1, 50)
+	if !m.Mult(o, io).Aeq(M4I) {
+		t.Errorf(format, m.Dump(), M4I.Dump())
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	loc, rot, scale := &V3{}, &Q{}, &V3{}
+	q := NewQ().SetAa(1, 2, 3, Rad(130))
+	m := NewM4().SetQ(NewQ().Inv(q)).ScaleSM(2, 3, 4).TranslateMT(1, -2, 5)
+	m.Decompose(loc, rot, scale)
+	if !loc.Aeq(&V3{1, -2, 5}) || !rot.Aeq(q) || !scale.Aeq(&V3{2, 3, 4}) {
+		t.Errorf("Got %s %s %s", loc.Dump(), rot.Dump(), scale.Dump())
+	}
+	if m.ScaleSM(-1, 1, 1).Decompose(loc, rot, scale); !rot.Aeq(q) || !scale.Aeq(&V3{-2, 3, 4}) {
+		t.Errorf("Expected mirrored scale, got %s %s", rot.Dump(), scale.Dump())
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	m, v := NewLookAt(&V3{0, 0, 5}, &V3{0, 0, 0}, &V3{0, 1, 0}), &V4{}
+	want := &M4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, -5, 1}
+	if !m.Aeq(want) {
+		t.Errorf(format, m.Dump(), want.Dump())
+	}
+	m.LookAt(&V3{3, 0, 0}, &V3{0, 0, 0}, &V3{0, 1, 0}) // looking down -X.
+	if v.MultvM(&V4{0, 0, 0, 1}, m); !v.Aeq(&V4{0, 0, -3, 1}) {
+		t.Errorf("Expected origin in front of the viewer, got %s", v.Dump())
+	}
+	if v.MultvM(&V4{0, 0, -1, 1}, m); !v.Aeq(&V4{1, 0, -3, 1}) {
+		t.Errorf("Expected -Z to the viewers right, got %s", v.Dump())
+	}
+	if !NewPersp(45, 1, 0.1, 50).Aeq(NewM4().Persp(45, 1, 0.1, 50)) {
+		t.Errorf("Expected perspective matrix")
+	}
+}
+
 // unit tests
 // ============================================================================
 // benchmarking.
@@ -159,6 +159,140 @@ func (q *Q) Nlerp(r, s *Q, ratio float64) *Q {
 	return q.Unit()
 }
 
+// Slerp updates q to be the spherical linear interpolation between
+// quaternions r and s where ratio is expected to be between 0 and 1.
+// Unlike Nlerp the rotation moves at a constant speed, and it always
+// takes the shortest way around. Nlerp is used when r and s are very
+// close. The input quaternions r and s are not changed. See:
+//    https://en.wikipedia.org/wiki/Slerp
+// The updated calling quaternion q is returned.
+func (q *Q) Slerp(r, s *Q, ratio float64) *Q {
+	sx, sy, sz, sw := s.X, s.Y, s.Z, s.W
+	cos := r.Dot(s)
+	if cos < 0 {
+		cos, sx, sy, sz, sw = -cos, -sx, -sy, -sz, -sw // shortest way around.
+	}
+	a, b := 1-ratio, ratio
+	if cos < 1-Epsilon {
+		angle := math.Acos(cos)
+		sin := 1 / math.Sin(angle)
+		a, b = math.Sin(a*angle)*sin, math.Sin(b*angle)*sin
+	}
+	q.X, q.Y, q.Z, q.W = r.X*a+sx*b, r.Y*a+sy*b, r.Z*a+sz*b, r.W*a+sw*b
+	return q.Unit()
+}
+
+// Squad updates q to be the spherical quadrangle interpolation between
+// quaternions r and s using the inner control quaternions a and b.
+// Squad gives smooth changes in rotation speed when interpolating
+// through a list of keyframe rotations. Use SquadControl to get the
+// control quaternions for each keyframe. Ratio is expected to be
+// between 0 and 1. The inputs are not changed. See:
+//    http://www.geometrictools.com/Documentation/Quaternions.pdf
+// The updated calling quaternion q is returned.
+func (q *Q) Squad(r, s, a, b *Q, ratio float64) *Q {
+	outer, inner := &Q{}, &Q{}
+	outer.Slerp(r, s, ratio)
+	inner.slerpLong(a, b, ratio)
+	return q.slerpLong(outer, inner, 2*ratio*(1-ratio))
+}
+
+// slerpLong is Slerp without taking the shortest way around.
+// Squad needs this to keep the curve between its control quaternions.
+func (q *Q) slerpLong(r, s *Q, ratio float64) *Q {
+	cos := Clamp(r.Dot(s), -1, 1)
+	a, b := 1-ratio, ratio
+	if math.Abs(cos) < 1-Epsilon {
+		angle := math.Acos(cos)
+		sin := 1 / math.Sin(angle)
+		a, b = math.Sin(a*angle)*sin, math.Sin(b*angle)*sin
+	}
+	q.X, q.Y, q.Z, q.W = r.X*a+s.X*b, r.Y*a+s.Y*b, r.Z*a+s.Z*b, r.W*a+s.W*b
+	return q.Unit()
+}
+
+// SquadControl updates q to be the Squad control quaternion for keyframe
+// rotation r given the previous keyframe rotation prev and next keyframe
+// rotation next. Use r for prev or next at the ends of the keyframes.
+// Neighbouring keyframes are expected to be on the same side, ie: their
+// dot product is positive. The inputs are not changed.
+// The updated calling quaternion q is returned.
+func (q *Q) SquadControl(prev, r, next *Q) *Q {
+	inv, a, b := &Q{}, &Q{}, &Q{}
+	inv.Inv(r)
+	a.Mult(inv, next).log() // log(r⁻¹ next)
+	b.Mult(inv, prev).log() // log(r⁻¹ prev)
+	a.X, a.Y, a.Z, a.W = (a.X+b.X)*-0.25, (a.Y+b.Y)*-0.25, (a.Z+b.Z)*-0.25, 0
+	return q.Mult(r, a.exp())
+}
+
+// log updates unit quaternion q to be its natural logarithm.
+// The result is a pure quaternion, ie: W is 0.
+func (q *Q) log() *Q {
+	sin := math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	scale := 0.0
+	if sin > Epsilon {
+		scale = math.Atan2(sin, q.W) / sin
+	}
+	q.X, q.Y, q.Z, q.W = q.X*scale, q.Y*scale, q.Z*scale, 0
+	return q
+}
+
+// exp updates pure quaternion q, ie: W is 0, to be its exponential.
+// This is the inverse of log.
+func (q *Q) exp() *Q {
+	angle := math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	scale := 1.0
+	if angle > Epsilon {
+		scale = math.Sin(angle) / angle
+	}
+	q.X, q.Y, q.Z, q.W = q.X*scale, q.Y*scale, q.Z*scale, math.Cos(angle)
+	return q
+}
+
+// SwingTwist splits the rotation of q into a twist rotation around the
+// given axis and a swing rotation of the axis itself, so that q is
+// Mult(twist, swing), ie: the twist is applied first. The swing moves
+// the axis the same way q does. This is useful for limiting joint
+// rotations, like separating the twist of a forearm from its bend.
+// The axis is expected to be unit length. Quaternion q is not changed,
+// swing and twist are updated.
+func (q *Q) SwingTwist(axis *V3, swing, twist *Q) {
+	d := q.X*axis.X + q.Y*axis.Y + q.Z*axis.Z // rotation part along the axis.
+	twist.SetS(axis.X*d, axis.Y*d, axis.Z*d, q.W)
+	if twist.Dot(twist) < Epsilon*Epsilon {
+		twist.Set(QI) // 180 degree swing, no twist.
+	} else {
+		twist.Unit()
+	}
+	inv := &Q{}
+	swing.Mult(inv.Inv(twist), q)
+}
+
+// SetRotation updates q to be the shortest rotation that turns
+// direction from into direction to. The directions need not be unit
+// length. Opposite directions are turned 180 degrees around an axis
+// perpendicular to from. The updated quaternion q is returned.
+// The quaternion q is not updated if either direction has no length.
+func (q *Q) SetRotation(from, to *V3) *Q {
+	fl, tl := from.Len(), to.Len()
+	if fl < Epsilon || tl < Epsilon {
+		return q
+	}
+	cos := from.Dot(to) / (fl * tl)
+	if cos < -1+Epsilon {
+		p, r := &V3{}, &V3{}
+		f := &V3{from.X / fl, from.Y / fl, from.Z / fl}
+		f.Plane(p, r) // any perpendicular axis.
+		q.X, q.Y, q.Z, q.W = p.X, p.Y, p.Z, 0
+		return q
+	}
+	c := &V3{}
+	c.Cross(from, to)
+	q.X, q.Y, q.Z, q.W = c.X, c.Y, c.Z, fl*tl+from.Dot(to) // half angle trick.
+	return q.Unit()
+}
+
 // quaternion operations
 // ============================================================================
 // quaternion-vector operations
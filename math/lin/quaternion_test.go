@@ -151,3 +151,61 @@ func TestMultTransformQ(t *testing.T) {
 		t.Errorf(format, q.Dump(), want.Dump())
 	}
 }
+
+func TestSlerpQ(t *testing.T) {
+	r, s, q := NewQI(), NewQ().SetAa(0, 1, 0, Rad(90)), &Q{}
+	want := NewQ().SetAa(0, 1, 0, Rad(30))
+	if !q.Slerp(r, s, 1.0/3).Aeq(want) {
+		t.Errorf(format, q.Dump(), want.Dump())
+	}
+	s.Scale(-1) // same rotation the long way around.
+	if !q.Slerp(r, s, 1.0/3).Aeq(want) {
+		t.Errorf(format, q.Dump(), want.Dump())
+	}
+	if !q.Slerp(r, r, 0.5).Aeq(r) {
+		t.Errorf(format, q.Dump(), r.Dump())
+	}
+}
+
+func TestSquadQ(t *testing.T) {
+	keys := []*Q{NewQI(), NewQ().SetAa(0, 1, 0, Rad(40)), NewQ().SetAa(0, 1, 0, Rad(80)), NewQ().SetAa(0, 1, 0, Rad(120))}
+	a, b, q := &Q{}, &Q{}, &Q{}
+	a.SquadControl(keys[0], keys[1], keys[2])
+	b.SquadControl(keys[1], keys[2], keys[3])
+	if !q.Squad(keys[1], keys[2], a, b, 0).Aeq(keys[1]) || !q.Squad(keys[1], keys[2], a, b, 1).Aeq(keys[2]) {
+		t.Errorf("Expected squad to pass through the keyframes")
+	}
+
+	// evenly spaced keys about one axis interpolate evenly.
+	want := NewQ().SetAa(0, 1, 0, Rad(60))
+	if !q.Squad(keys[1], keys[2], a, b, 0.5).Aeq(want) {
+		t.Errorf(format, q.Dump(), want.Dump())
+	}
+}
+
+func TestSwingTwistQ(t *testing.T) {
+	swing, twist := NewQ().SetAa(1, 0, 0, Rad(30)), NewQ().SetAa(0, 1, 0, Rad(50))
+	q, gs, gt := NewQ().Mult(twist, swing), &Q{}, &Q{}
+	axis, qa, sa := &V3{0, 1, 0}, &V3{}, &V3{}
+	q.SwingTwist(axis, gs, gt)
+	if !gt.Aeq(twist) || !gs.Aeq(swing) {
+		t.Errorf(format, gt.Dump(), twist.Dump())
+	}
+	if !qa.MultvQ(axis, q).Aeq(sa.MultvQ(axis, gs)) {
+		t.Errorf("Expected swing to move the axis like q, got %s %s", sa.Dump(), qa.Dump())
+	}
+	if !NewQ().Mult(gt, gs).Aeq(q) {
+		t.Errorf("Expected twist then swing to be the rotation")
+	}
+}
+
+func TestSetRotationQ(t *testing.T) {
+	q, v := NewQ().SetRotation(&V3{2, 0, 0}, &V3{0, 3, 0}), &V3{}
+	want := NewQ().SetAa(0, 0, 1, Rad(90))
+	if !q.Aeq(want) {
+		t.Errorf(format, q.Dump(), want.Dump())
+	}
+	if !q.SetRotation(&V3{0, 0, 1}, &V3{0, 0, -1}).Aeq(q.Unit()) || !v.MultvQ(&V3{0, 0, 1}, q).Aeq(&V3{0, 0, -1}) {
+		t.Errorf("Expected half turn, got %s", v.Dump())
+	}
+}
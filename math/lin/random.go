@@ -0,0 +1,158 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+// random.go provides seeded random numbers with the distributions
+// needed by procedural systems like particles, scattering, and loot.
+// The generator is PCG32. It is small, fast, and has better statistical
+// quality than the simple generators often used in games. See:
+//    http://www.pcg-random.org/
+//    http://mathworld.wolfram.com/SpherePointPicking.html
+
+import "math"
+
+// Rand is a seeded random number generator. Each seed and stream pair
+// gives its own repeatable sequence of numbers. Rand is not safe for
+// use by multiple goroutines, give each goroutine its own stream instead.
+type Rand struct {
+	state uint64 // changes with each number.
+	inc   uint64 // selects the stream, always odd.
+}
+
+// NewRand creates a random number generator. The seed picks the starting
+// point and the stream picks one of 2^63 independent sequences, ie: one
+// stream for each particle emitter using the same level seed.
+func NewRand(seed, stream uint64) *Rand {
+	return (&Rand{}).Seed(seed, stream)
+}
+
+// Seed resets r to the start of the sequence for the given seed and
+// stream. The updated generator r is returned.
+func (r *Rand) Seed(seed, stream uint64) *Rand {
+	r.state, r.inc = 0, stream<<1|1
+	r.Uint32()
+	r.state += seed
+	r.Uint32()
+	return r
+}
+
+// Uint32 returns a random 32 bit number.
+func (r *Rand) Uint32() uint32 {
+	old := r.state
+	r.state = old*6364136223846793005 + r.inc
+	shifted := uint32(((old >> 18) ^ old) >> 27)
+	rot := uint32(old >> 59)
+	return shifted>>rot | shifted<<((-rot)&31)
+}
+
+// Uint64 returns a random 64 bit number.
+func (r *Rand) Uint64() uint64 { return uint64(r.Uint32())<<32 | uint64(r.Uint32()) }
+
+// Float64 returns a random number in the range [0, 1).
+func (r *Rand) Float64() float64 { return float64(r.Uint64()>>11) / (1 << 53) }
+
+// Range returns a random number in the range [lo, hi).
+func (r *Rand) Range(lo, hi float64) float64 { return lo + (hi-lo)*r.Float64() }
+
+// Intn returns a random number in the range [0, n). Every number in
+// the range is equally likely. Returns 0 if n is not positive.
+func (r *Rand) Intn(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	if n > math.MaxUint32 {
+		return int(r.Uint64() % uint64(n)) // bias too small to matter.
+	}
+	bound := uint32(n)
+	threshold := -bound % bound // reject the uneven part of the range.
+	for {
+		if v := r.Uint32(); v >= threshold {
+			return int(v % bound)
+		}
+	}
+}
+
+// Normal returns a normally distributed random number with the given
+// mean and standard deviation using the Box-Muller transform.
+func (r *Rand) Normal(mean, dev float64) float64 {
+	u := 1 - r.Float64() // (0, 1] avoids log of 0.
+	return mean + dev*math.Sqrt(-2*math.Log(u))*math.Cos(PIx2*r.Float64())
+}
+
+// Sphere updates v to be a random unit direction. All directions are
+// equally likely. The updated vector v is returned.
+func (r *Rand) Sphere(v *V3) *V3 {
+	z := 2*r.Float64() - 1 // slices of a sphere have equal area.
+	s, angle := math.Sqrt(1-z*z), PIx2*r.Float64()
+	v.X, v.Y, v.Z = s*math.Cos(angle), s*math.Sin(angle), z
+	return v
+}
+
+// InSphere updates v to be a random point inside the unit sphere.
+// All points are equally likely. The updated vector v is returned.
+func (r *Rand) InSphere(v *V3) *V3 {
+	r.Sphere(v)
+	return v.Scale(v, math.Cbrt(r.Float64()))
+}
+
+// Disk returns a random point inside the unit circle.
+// All points are equally likely.
+func (r *Rand) Disk() (x, y float64) {
+	radius, angle := math.Sqrt(r.Float64()), PIx2*r.Float64()
+	return radius * math.Cos(angle), radius * math.Sin(angle)
+}
+
+// Cone updates v to be a random unit direction within angle radians
+// of the unit direction dir. All directions in the cone are equally
+// likely. The updated vector v is returned.
+func (r *Rand) Cone(v, dir *V3, angle float64) *V3 {
+	z := 1 - r.Float64()*(1-math.Cos(angle)) // uniform over the cap.
+	s, spin := math.Sqrt(1-z*z), PIx2*r.Float64()
+	x, y := s*math.Cos(spin), s*math.Sin(spin)
+	q := NewQI().SetRotation(&V3{0, 0, 1}, dir)
+	v.X, v.Y, v.Z = MultSQ(x, y, z, q)
+	return v
+}
+
+// Weighted returns a random index into weights where each index is
+// picked in proportion to its weight, ie: weights 1, 3 picks index 1
+// three times as often as index 0. Negative weights count as 0.
+// Returns -1 if there are no positive weights.
+func (r *Rand) Weighted(weights []float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += math.Max(w, 0)
+	}
+	if total <= 0 {
+		return -1
+	}
+	pick, last := r.Float64()*total, -1
+	for i, w := range weights {
+		if w > 0 {
+			if last = i; pick < w {
+				return i
+			}
+			pick -= w
+		}
+	}
+	return last // rounding.
+}
+
+// Shuffle randomly orders n items using the Fisher-Yates shuffle.
+// All orders are equally likely. Swap is called to swap items i and j.
+func (r *Rand) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, r.Intn(i+1))
+	}
+}
+
+// Perm returns a random ordering of the numbers [0, n).
+func (r *Rand) Perm(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	r.Shuffle(n, func(i, j int) { p[i], p[j] = p[j], p[i] })
+	return p
+}
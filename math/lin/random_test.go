@@ -0,0 +1,108 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"math"
+	"testing"
+)
+
+// Match the reference PCG32 output for seed 42 and stream 54.
+func TestRandSequence(t *testing.T) {
+	r := NewRand(42, 54)
+	for cnt, want := range []uint32{0xa15c02b7, 0x7b47f409, 0xba1d3330, 0x83d2f293, 0xbfa4784b, 0xcbed606e} {
+		if got := r.Uint32(); got != want {
+			t.Fatalf("Number %d: got %#x expected %#x", cnt, got, want)
+		}
+	}
+	a, b := NewRand(42, 54), NewRand(42, 55)
+	if a.Uint64() == b.Uint64() {
+		t.Errorf("Expected different streams to differ")
+	}
+	first := a.Seed(42, 54).Uint32()
+	if first != 0xa15c02b7 {
+		t.Errorf("Expected reseed to restart the sequence, got %#x", first)
+	}
+}
+
+func TestRandRange(t *testing.T) {
+	r, counts := NewRand(1, 0), make([]int, 5)
+	for cnt := 0; cnt < 10000; cnt++ {
+		if f := r.Float64(); f < 0 || f >= 1 {
+			t.Fatalf("Expected [0,1) got %f", f)
+		}
+		if f := r.Range(-2, 3); f < -2 || f >= 3 {
+			t.Fatalf("Expected [-2,3) got %f", f)
+		}
+		counts[r.Intn(5)]++
+	}
+	for i, c := range counts {
+		if c < 1800 || c > 2200 {
+			t.Errorf("Expected about 2000 of %d, got %d", i, c)
+		}
+	}
+	if r.Intn(0) != 0 || r.Intn(-3) != 0 {
+		t.Errorf("Expected 0 for an empty range")
+	}
+	sum, sq := 0.0, 0.0
+	for cnt := 0; cnt < 10000; cnt++ {
+		n := r.Normal(5, 2)
+		sum, sq = sum+n, sq+n*n
+	}
+	mean := sum / 10000
+	if dev := math.Sqrt(sq/10000 - mean*mean); math.Abs(mean-5) > 0.1 || math.Abs(dev-2) > 0.1 {
+		t.Errorf("Expected mean 5 deviation 2, got %f %f", mean, dev)
+	}
+}
+
+func TestRandDirections(t *testing.T) {
+	r, v, sum := NewRand(7, 0), &V3{}, &V3{}
+	dir := (&V3{1, 1, 0}).Unit()
+	for cnt := 0; cnt < 1000; cnt++ {
+		if !Aeq(r.Sphere(v).Len(), 1) {
+			t.Fatalf("Expected unit direction, got %s", v.Dump())
+		}
+		sum.Add(sum, v)
+		if r.InSphere(v).Len() > 1 {
+			t.Fatalf("Expected point in sphere, got %s", v.Dump())
+		}
+		if x, y := r.Disk(); x*x+y*y > 1 {
+			t.Fatalf("Expected point in disk, got %f %f", x, y)
+		}
+		r.Cone(v, dir, Rad(10))
+		if !Aeq(v.Len(), 1) || v.Ang(dir) > Rad(10)+Epsilon {
+			t.Fatalf("Expected direction in cone, got %s", v.Dump())
+		}
+	}
+	if sum.Len() > 100 {
+		t.Errorf("Expected directions spread evenly, got %s", sum.Dump())
+	}
+}
+
+func TestRandWeighted(t *testing.T) {
+	r, counts := NewRand(3, 0), make([]int, 4)
+	for cnt := 0; cnt < 8000; cnt++ {
+		counts[r.Weighted([]float64{1, 0, -5, 3})]++
+	}
+	if counts[1] != 0 || counts[2] != 0 || counts[0] < 1800 || counts[0] > 2200 {
+		t.Errorf("Expected picks proportional to weight, got %v", counts)
+	}
+	if r.Weighted(nil) != -1 || r.Weighted([]float64{0, -1}) != -1 {
+		t.Errorf("Expected no pick without positive weights")
+	}
+}
+
+func TestRandPerm(t *testing.T) {
+	r, seen := NewRand(9, 0), map[int]bool{}
+	p := r.Perm(20)
+	for _, i := range p {
+		seen[i] = true
+	}
+	if len(p) != 20 || len(seen) != 20 {
+		t.Errorf("Expected each number once, got %v", p)
+	}
+	if q := NewRand(9, 0).Perm(20); q[0] != p[0] || q[19] != p[19] {
+		t.Errorf("Expected the same seed to give the same order")
+	}
+}
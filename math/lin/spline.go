@@ -0,0 +1,221 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import "math"
+
+// Bezier is a smooth curve made from cubic Bézier segments, useful for
+// camera rails, roads, and projectile paths. Each segment starts and
+// ends at a point and bends towards two control points in between.
+// Curve locations are given by a parameter t from 0 at the start of the
+// first segment to 1 at the end of the last segment. Segments are not
+// the same length, so use Dist and AtDist to move along the curve at an
+// even speed. See: https://en.wikipedia.org/wiki/Bézier_curve
+type Bezier struct {
+	segs  [][4]V3   // start, control, control, end for each segment.
+	table []float64 // arc length at evenly spaced t for each segment.
+}
+
+// NewBezier creates a curve from a start point followed by two control
+// points and an end point for each segment, ie: 4 points for one segment,
+// 7 points for two segments. Extra points that don't make up a complete
+// segment are ignored. A curve without a complete segment stays at its
+// start point.
+func NewBezier(points ...V3) *Bezier {
+	b := &Bezier{}
+	for cnt := 0; cnt+3 < len(points); cnt += 3 {
+		b.segs = append(b.segs, [4]V3{points[cnt], points[cnt+1], points[cnt+2], points[cnt+3]})
+	}
+	if len(b.segs) == 0 && len(points) > 0 {
+		p := points[0]
+		b.segs = append(b.segs, [4]V3{p, p, p, p}) // no length.
+	}
+	b.measure()
+	return b
+}
+
+// CatmullRom is a smooth curve that passes through each of its points.
+// It is often easier to use than Bezier since there are no control points,
+// ie: a path or camera rail can go through the spots it needs to visit.
+// See: https://en.wikipedia.org/wiki/Cubic_Hermite_spline#Catmull.E2.80.93Rom_spline
+//
+// The curve is kept as the equivalent Bézier segments, so it has all the
+// Bezier methods.
+type CatmullRom struct {
+	Bezier
+}
+
+// NewCatmullRom creates a curve that goes through each of the given
+// points. A closed curve also joins the last point back to the first.
+// A single point gives a curve with no length that stays at the point.
+func NewCatmullRom(points []V3, closed bool) *CatmullRom {
+	c := &CatmullRom{}
+	n := len(points)
+	if n == 0 {
+		return c
+	}
+	if n == 1 {
+		c.Bezier = *NewBezier(points[0])
+		return c
+	}
+	at := func(i int) *V3 {
+		switch {
+		case closed:
+			return &points[(i+n)%n]
+		case i < 0:
+			return &points[0]
+		case i >= n:
+			return &points[n-1]
+		}
+		return &points[i]
+	}
+	segs := n - 1
+	if closed {
+		segs = n
+	}
+	for i := 0; i < segs; i++ {
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+
+		// the tangent at each point is half the difference of its
+		// neighbours, giving control points a third of the way along.
+		c1, c2 := &V3{}, &V3{}
+		c1.Sub(p2, p0).Scale(c1, 1.0/6).Add(c1, p1)
+		c2.Sub(p3, p1).Scale(c2, -1.0/6).Add(c2, p2)
+		c.segs = append(c.segs, [4]V3{*p1, *c1, *c2, *p2})
+	}
+	c.measure()
+	return c
+}
+
+// splineSamples is the number of arc length samples for each segment.
+const splineSamples = 16
+
+// measure creates the arc length table by summing the distances
+// between points sampled evenly along each segment.
+func (b *Bezier) measure() {
+	b.table = make([]float64, len(b.segs)*splineSamples+1)
+	prev, next := &V3{}, &V3{}
+	b.At(prev, 0)
+	for cnt := 1; cnt < len(b.table); cnt++ {
+		b.At(next, float64(cnt)/float64(len(b.table)-1))
+		b.table[cnt] = b.table[cnt-1] + prev.Dist(next)
+		prev.Set(next)
+	}
+}
+
+// segment returns the segment and segment parameter for curve parameter t.
+func (b *Bezier) segment(t float64) (seg *[4]V3, st float64) {
+	t = Clamp(t, 0, 1) * float64(len(b.segs))
+	index := int(t)
+	if index >= len(b.segs) {
+		index = len(b.segs) - 1
+	}
+	return &b.segs[index], t - float64(index)
+}
+
+// At updates v to be the curve location for t from 0 to 1.
+// The updated vector v is returned. v is not changed for empty curves.
+func (b *Bezier) At(v *V3, t float64) *V3 {
+	if len(b.segs) == 0 {
+		return v
+	}
+	s, st := b.segment(t)
+	u := 1 - st
+	w0, w1, w2, w3 := u*u*u, 3*u*u*st, 3*u*st*st, st*st*st
+	v.X = w0*s[0].X + w1*s[1].X + w2*s[2].X + w3*s[3].X
+	v.Y = w0*s[0].Y + w1*s[1].Y + w2*s[2].Y + w3*s[3].Y
+	v.Z = w0*s[0].Z + w1*s[1].Z + w2*s[2].Z + w3*s[3].Z
+	return v
+}
+
+// Tangent updates v to be the direction of the curve for t from 0 to 1.
+// The tangent is not normalized, its length is the speed of the curve
+// with respect to the segment parameter. The updated vector v is returned.
+// v is not changed for empty curves.
+func (b *Bezier) Tangent(v *V3, t float64) *V3 {
+	if len(b.segs) == 0 {
+		return v
+	}
+	s, st := b.segment(t)
+	u := 1 - st
+	w0, w1, w2, w3 := -3*u*u, 3*u*u-6*u*st, 6*u*st-3*st*st, 3*st*st
+	v.X = w0*s[0].X + w1*s[1].X + w2*s[2].X + w3*s[3].X
+	v.Y = w0*s[0].Y + w1*s[1].Y + w2*s[2].Y + w3*s[3].Y
+	v.Z = w0*s[0].Z + w1*s[1].Z + w2*s[2].Z + w3*s[3].Z
+	return v
+}
+
+// Len returns the approximate length of the curve.
+func (b *Bezier) Len() float64 {
+	if len(b.table) == 0 {
+		return 0
+	}
+	return b.table[len(b.table)-1]
+}
+
+// Dist returns the approximate distance along the curve to parameter t.
+func (b *Bezier) Dist(t float64) float64 {
+	at := Clamp(t, 0, 1) * float64(len(b.table)-1)
+	index := int(at)
+	if index < 0 || index >= len(b.table)-1 {
+		return b.Len()
+	}
+	return Lerp(b.table[index], b.table[index+1], at-float64(index))
+}
+
+// Param returns the curve parameter t that is the given distance
+// along the curve. This is the inverse of Dist.
+func (b *Bezier) Param(dist float64) float64 {
+	last := len(b.table) - 1
+	if last <= 0 || dist <= 0 || b.Len() <= 0 {
+		return 0
+	}
+	if dist >= b.Len() {
+		return 1
+	}
+	lo, hi := 0, last // binary search for the enclosing samples.
+	for hi-lo > 1 {
+		if mid := (lo + hi) / 2; b.table[mid] <= dist {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	span := b.table[hi] - b.table[lo]
+	if span <= 0 {
+		return float64(lo) / float64(last)
+	}
+	return (float64(lo) + (dist-b.table[lo])/span) / float64(last)
+}
+
+// AtDist updates v to be the curve location that is the given distance
+// along the curve. Moving dist evenly moves evenly along the curve.
+// The updated vector v is returned.
+func (b *Bezier) AtDist(v *V3, dist float64) *V3 { return b.At(v, b.Param(dist)) }
+
+// Closest returns the curve parameter t for the curve location closest
+// to point p. The closest sample is refined by checking ever smaller
+// steps on either side.
+func (b *Bezier) Closest(p *V3) (t float64) {
+	last := len(b.table) - 1
+	if last <= 0 {
+		return 0
+	}
+	at, best := &V3{}, math.Inf(1)
+	for cnt := 0; cnt <= last; cnt++ {
+		st := float64(cnt) / float64(last)
+		if d := b.At(at, st).DistSqr(p); d < best {
+			t, best = st, d
+		}
+	}
+	for step := 0.5 / float64(last); step > Epsilon*Epsilon; step *= 0.5 {
+		for side := -1.0; side <= 1; side += 2 {
+			st := Clamp(t+side*step, 0, 1)
+			if d := b.At(at, st).DistSqr(p); d < best {
+				t, best = st, d
+			}
+		}
+	}
+	return t
+}
@@ -0,0 +1,77 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBezier(t *testing.T) {
+	b := NewBezier(V3{0, 0, 0}, V3{1, 0, 0}, V3{2, 0, 0}, V3{3, 0, 0}, V3{3, 1, 0}, V3{3, 2, 0}, V3{3, 3, 0})
+	v := &V3{}
+	if !b.At(v, 0.5).Aeq(&V3{3, 0, 0}) || !b.At(v, 1).Aeq(&V3{3, 3, 0}) {
+		t.Errorf("Expected segment ends, got %s", v.Dump())
+	}
+	if !b.Tangent(v, 0.75).Unit().Aeq(&V3{0, 1, 0}) {
+		t.Errorf("Expected tangent up the second segment, got %s", v.Dump())
+	}
+	if !Aeq(b.Len(), 6) || !Aeq(b.Dist(0.5), 3) {
+		t.Errorf("Expected straight line lengths, got %f %f", b.Len(), b.Dist(0.5))
+	}
+	if !b.AtDist(v, 4.5).Aeq(&V3{3, 1.5, 0}) {
+		t.Errorf("Expected half way up the second segment, got %s", v.Dump())
+	}
+	if ct := b.Closest(&V3{5, 2, 0}); !b.At(v, ct).Aeq(&V3{3, 2, 0}) {
+		t.Errorf("Expected closest point at 3,2, got %s", v.Dump())
+	}
+}
+
+func TestCatmullRom(t *testing.T) {
+	points := []V3{{0, 0, 0}, {1, 1, 0}, {2, 0, 0}, {3, 1, 0}}
+	c, v := NewCatmullRom(points, false), &V3{}
+	for cnt, p := range points {
+		if !c.At(v, float64(cnt)/3).Aeq(&p) {
+			t.Errorf("Expected curve through %s, got %s", p.Dump(), v.Dump())
+		}
+	}
+	if !c.Tangent(v, 1.0/3).Unit().Aeq(&V3{1, 0, 0}) {
+		t.Errorf("Expected flat tangent at the peak, got %s", v.Dump())
+	}
+	closed := NewCatmullRom(points, true)
+	if !closed.At(v, 1).Aeq(&points[0]) || closed.Len() <= c.Len() {
+		t.Errorf("Expected closed curve to end at the start, got %s", v.Dump())
+	}
+	if empty := NewCatmullRom(points[:1], false); empty.Len() != 0 || empty.Param(1) != 0 {
+		t.Errorf("Expected empty curve")
+	}
+}
+
+// Evenly spaced distances give evenly spaced curve locations.
+func TestSplineParam(t *testing.T) {
+	c := NewCatmullRom([]V3{{0, 0, 0}, {3, 0, 0}, {6, 0, 2}, {8, 0, 6}}, false)
+	prev, next, step := &V3{}, &V3{}, c.Len()/20
+	for cnt := 1; cnt <= 20; cnt++ {
+		c.AtDist(prev, float64(cnt-1)*step)
+		if gap := c.AtDist(next, float64(cnt)*step).Dist(prev); math.Abs(gap-step) > step*0.02 {
+			t.Errorf("Expected even steps of %f, got %f", step, gap)
+		}
+		if d := float64(cnt) * step; math.Abs(c.Dist(c.Param(d))-d) > 1e-9 {
+			t.Errorf("Expected Dist to invert Param at %f", d)
+		}
+	}
+}
+
+// Curves made from a single point stay at that point.
+func TestSplinePoint(t *testing.T) {
+	p, v := V3{1, 2, 3}, &V3{}
+	for _, b := range []*Bezier{NewBezier(p), &NewCatmullRom([]V3{p}, false).Bezier} {
+		if !b.At(v, 0.5).Aeq(&p) || !b.AtDist(v, 1).Aeq(&p) || b.Len() != 0 {
+			t.Errorf("Expected curve at the point, got %s length %f", v.Dump(), b.Len())
+		}
+	}
+	if NewBezier().At(v.SetS(0, 0, 0), 0.5); !v.Aeq(&V3{}) {
+		t.Errorf("Expected empty curve to leave the vector, got %s", v.Dump())
+	}
+}
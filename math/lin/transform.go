@@ -165,6 +165,80 @@ func (t *T) Integrate(a *T, linv, angv *V3, dt float64) *T {
 	return t
 }
 
+// ============================================================================
+// scaled transforms.
+
+// TRS is a 3D transform for scale, rotation, and translation. It is T with
+// scale, and is useful for pulling the parts of a world matrix back out
+// with SetM4, or for blending between transforms with Lerp. TRS is applied
+// the same way as a model matrix: scale first, then rotation, then
+// translation.
+type TRS struct {
+	Loc   *V3 // Location (translation, origin).
+	Rot   *Q  // Rotation (direction, orientation).
+	Scale *V3 // Scale along each local axis.
+}
+
+// Aeq (~=) almost-equals returns true if all the elements in transform t have
+// essentially the same value as the corresponding elements in transform a.
+func (t *TRS) Aeq(a *TRS) bool {
+	return t.Loc.Aeq(a.Loc) && t.Rot.Aeq(a.Rot) && t.Scale.Aeq(a.Scale)
+}
+
+// Set (=, copy, clone) assigns all the elements values from transform a to the
+// corresponding element values in transform t. The updated transform t is returned.
+func (t *TRS) Set(a *TRS) *TRS {
+	t.Loc.Set(a.Loc)
+	t.Rot.Set(a.Rot)
+	t.Scale.Set(a.Scale)
+	return t
+}
+
+// SetI updates transform t to be the identity transform.
+// The updated transform t is returned.
+func (t *TRS) SetI() *TRS {
+	t.Loc.SetS(0, 0, 0)
+	t.Rot.Set(QI)
+	t.Scale.SetS(1, 1, 1)
+	return t
+}
+
+// SetM4 updates transform t to be the translation, rotation, and scale
+// of model matrix m. See M4.Decompose. The updated transform t is returned.
+func (t *TRS) SetM4(m *M4) *TRS {
+	m.Decompose(t.Loc, t.Rot, t.Scale)
+	return t
+}
+
+// M4 updates matrix m to be the model matrix for transform t.
+// The updated matrix m is returned.
+func (t *TRS) M4(m *M4) *M4 {
+	inv := &Q{}
+	m.SetQ(inv.Inv(t.Rot))                          // rows are the transposed rotation.
+	m.ScaleSM(t.Scale.X, t.Scale.Y, t.Scale.Z)      // scale is applied first: left of rotation.
+	return m.TranslateMT(t.Loc.X, t.Loc.Y, t.Loc.Z) // translation is right of rotation.
+}
+
+// App applies transform t, scale then rotation then translation,
+// to vector v. The updated vector v is returned.
+func (t *TRS) App(v *V3) *V3 {
+	v.Mult(v, t.Scale)
+	v.MultvQ(v, t.Rot)
+	return v.Add(v, t.Loc)
+}
+
+// Lerp updates transform t to be the interpolation between transforms
+// a and b by the given ratio from 0 to 1. Locations and scales are
+// linearly interpolated and rotations are spherically interpolated.
+// See Q.Slerp. Transform t may be used as one of the inputs.
+// The updated transform t is returned.
+func (t *TRS) Lerp(a, b *TRS, ratio float64) *TRS {
+	t.Loc.Lerp(a.Loc, b.Loc, ratio)
+	t.Scale.Lerp(a.Scale, b.Scale, ratio)
+	t.Rot.Slerp(a.Rot, b.Rot, ratio)
+	return t
+}
+
 // ============================================================================
 // convenience functions for allocating transforms. Nothing else should allocate.
 
@@ -172,3 +246,9 @@ func (t *T) Integrate(a *T, linv, angv *V3, dt float64) *T {
 func NewT() *T {
 	return &T{&V3{}, &Q{0, 0, 0, 1}}
 }
+
+// NewTRS creates and returns a transform at the origin with no rotation
+// and a scale of 1.
+func NewTRS() *TRS {
+	return &TRS{&V3{}, &Q{0, 0, 0, 1}, &V3{1, 1, 1}}
+}
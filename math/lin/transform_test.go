@@ -151,3 +151,29 @@ func TestApplyBoth(t *testing.T) {
 		t.Errorf(format, v2.Dump(), want2.Dump())
 	}
 }
+
+func TestTRS(t *testing.T) {
+	trs, m := NewTRS(), &M4{}
+	trs.Loc.SetS(1, 2, 3)
+	trs.Rot.SetAa(0, 1, 0, Rad(90))
+	trs.Scale.SetS(2, 2, 2)
+	if v := trs.App(&V3{1, 0, 0}); !v.Aeq(&V3{1, 2, 1}) {
+		t.Errorf(format, v.Dump(), "1, 2, 1")
+	}
+	v, want := &V4{}, &V4{1, 2, 1, 1}
+	if v.MultvM(&V4{1, 0, 0, 1}, trs.M4(m)); !v.Aeq(want) {
+		t.Errorf(format, v.Dump(), want.Dump())
+	}
+	if back := NewTRS().SetM4(m); !back.Aeq(trs) {
+		t.Errorf("Expected matrix to decompose to the same transform")
+	}
+	half := NewTRS().Lerp(NewTRS(), trs, 0.5)
+	rot := NewQ().SetAa(0, 1, 0, Rad(45))
+	if !half.Loc.Aeq(&V3{0.5, 1, 1.5}) || !half.Scale.Aeq(&V3{1.5, 1.5, 1.5}) || !half.Rot.Aeq(rot) {
+		t.Errorf("Expected half way transform, got %s %s", half.Loc.Dump(), half.Rot.Dump())
+	}
+	trs.Rot.Scale(-1) // same rotation on the far side.
+	if half.Lerp(NewTRS(), trs, 0.5); !half.Rot.Aeq(rot) {
+		t.Errorf("Expected shortest rotation, got %s", half.Rot.Dump())
+	}
+}
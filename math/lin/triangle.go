@@ -0,0 +1,143 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+// Triangle and line segment queries for decals, navigation meshes, and
+// mesh picking. See "Real-Time Collision Detection" by Christer Ericson,
+// sections 3.4, 5.1.2, and 5.1.5.
+
+import "math"
+
+// Barycentric returns the weights u, v, w of point p relative to
+// triangle a, b, c such that p = u*a + v*b + w*c and u+v+w = 1.
+// Point p is inside the triangle when all weights are between 0 and 1.
+// Points off the triangle plane are projected onto it. Returns 1, 0, 0
+// for triangles with no area.
+func Barycentric(p, a, b, c *V3) (u, v, w float64) {
+	v0, v1, v2 := &V3{}, &V3{}, &V3{}
+	v0.Sub(b, a)
+	v1.Sub(c, a)
+	v2.Sub(p, a)
+	d00, d01, d11 := v0.Dot(v0), v0.Dot(v1), v1.Dot(v1)
+	d20, d21 := v2.Dot(v0), v2.Dot(v1)
+	denom := d00*d11 - d01*d01
+	if math.Abs(denom) < Epsilon {
+		return 1, 0, 0
+	}
+	v = (d11*d20 - d01*d21) / denom
+	w = (d00*d21 - d01*d20) / denom
+	return 1 - v - w, v, w
+}
+
+// SetBary updates v to be the point with barycentric weights
+// wa, wb, wc relative to triangle a, b, c. This is the inverse of
+// Barycentric. The updated vector v is returned.
+func (v *V3) SetBary(a, b, c *V3, wa, wb, wc float64) *V3 {
+	v.X = a.X*wa + b.X*wb + c.X*wc
+	v.Y = a.Y*wa + b.Y*wb + c.Y*wc
+	v.Z = a.Z*wa + b.Z*wb + c.Z*wc
+	return v
+}
+
+// ClosestSeg updates v to be the point on line segment a, b that is
+// closest to point at. The updated vector v is returned.
+// Vector v may be one of the input vectors.
+func (v *V3) ClosestSeg(at, a, b *V3) *V3 {
+	abx, aby, abz := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+	lsq := abx*abx + aby*aby + abz*abz
+	t := 0.0
+	if lsq > Epsilon {
+		t = Clamp(((at.X-a.X)*abx+(at.Y-a.Y)*aby+(at.Z-a.Z)*abz)/lsq, 0, 1)
+	}
+	v.X, v.Y, v.Z = a.X+abx*t, a.Y+aby*t, a.Z+abz*t
+	return v
+}
+
+// ClosestTri updates v to be the point on triangle a, b, c that is
+// closest to point at. The point may be inside the triangle or on one
+// of its edges or corners. The updated vector v is returned.
+// Vector v may be one of the input vectors.
+func (v *V3) ClosestTri(at, a, b, c *V3) *V3 {
+	ab, ac, ap := &V3{}, &V3{}, &V3{}
+	ab.Sub(b, a)
+	ac.Sub(c, a)
+	ap.Sub(at, a)
+	d1, d2 := ab.Dot(ap), ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return v.Set(a) // corner a.
+	}
+	bp := &V3{}
+	bp.Sub(at, b)
+	d3, d4 := ab.Dot(bp), ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return v.Set(b) // corner b.
+	}
+	if vc := d1*d4 - d3*d2; vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return v.Add(a, ab.Scale(ab, d1/(d1-d3))) // edge ab.
+	}
+	cp := &V3{}
+	cp.Sub(at, c)
+	d5, d6 := ab.Dot(cp), ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return v.Set(c) // corner c.
+	}
+	if vb := d5*d2 - d1*d6; vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return v.Add(a, ac.Scale(ac, d2/(d2-d6))) // edge ac.
+	}
+	if va := d3*d6 - d5*d4; va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		bc := &V3{}
+		bc.Sub(c, b)
+		return v.Add(b, bc.Scale(bc, (d4-d3)/((d4-d3)+(d5-d6)))) // edge bc.
+	}
+
+	// inside the triangle.
+	va, vb, vc := d3*d6-d5*d4, d5*d2-d1*d6, d1*d4-d3*d2
+	inv := 1 / (va + vb + vc)
+	return v.SetBary(a, b, c, va*inv, vb*inv, vc*inv)
+}
+
+// TriOverlap returns true if triangles a0, a1, a2 and b0, b1, b2 touch
+// or overlap. The triangles are checked for a separating axis along each
+// triangle normal, the cross product of each pair of edges, and, for
+// triangles in the same plane, the edge directions within that plane.
+func TriOverlap(a0, a1, a2, b0, b1, b2 *V3) bool {
+	a, b := [3]*V3{a0, a1, a2}, [3]*V3{b0, b1, b2}
+	var ea, eb [3]V3
+	for cnt := 0; cnt < 3; cnt++ {
+		ea[cnt].Sub(a[(cnt+1)%3], a[cnt])
+		eb[cnt].Sub(b[(cnt+1)%3], b[cnt])
+	}
+	separated := func(axis *V3) bool {
+		if axis.Dot(axis) < Epsilon*Epsilon {
+			return false // parallel edges give no axis.
+		}
+		amin, amax := math.Inf(1), math.Inf(-1)
+		bmin, bmax := math.Inf(1), math.Inf(-1)
+		for cnt := 0; cnt < 3; cnt++ {
+			pa, pb := axis.Dot(a[cnt]), axis.Dot(b[cnt])
+			amin, amax = math.Min(amin, pa), math.Max(amax, pa)
+			bmin, bmax = math.Min(bmin, pb), math.Max(bmax, pb)
+		}
+		return amax < bmin || bmax < amin
+	}
+	na, nb, axis := &V3{}, &V3{}, &V3{}
+	na.Cross(&ea[0], &ea[1])
+	nb.Cross(&eb[0], &eb[1])
+	if separated(na) || separated(nb) {
+		return false
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if separated(axis.Cross(&ea[i], &eb[j])) {
+				return false
+			}
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if separated(axis.Cross(na, &ea[i])) || separated(axis.Cross(na, &eb[i])) {
+			return false // needed for triangles in the same plane.
+		}
+	}
+	return true
+}
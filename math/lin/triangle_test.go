@@ -0,0 +1,82 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package lin
+
+import (
+	"testing"
+)
+
+func TestBarycentric(t *testing.T) {
+	a, b, c, v := &V3{0, 0, 0}, &V3{4, 0, 0}, &V3{0, 4, 0}, &V3{}
+	if u, bv, w := Barycentric(&V3{1, 2, 5}, a, b, c); !Aeq(u, 0.25) || !Aeq(bv, 0.25) || !Aeq(w, 0.5) {
+		t.Errorf("Expected weights 0.25 0.25 0.5, got %f %f %f", u, bv, w)
+	}
+	if u, bv, w := Barycentric(&V3{4, 4, 0}, a, b, c); !(w > 0 && bv > 0 && u < 0) {
+		t.Errorf("Expected point outside the triangle, got %f %f %f", u, bv, w)
+	}
+	if !v.SetBary(a, b, c, 0.25, 0.25, 0.5).Aeq(&V3{1, 2, 0}) {
+		t.Errorf("Expected point from weights, got %s", v.Dump())
+	}
+	if u, _, _ := Barycentric(&V3{1, 1, 1}, a, b, &V3{8, 0, 0}); u != 1 {
+		t.Errorf("Expected flat triangle to use the first corner")
+	}
+}
+
+func TestClosestSeg(t *testing.T) {
+	a, b, v := &V3{0, 0, 0}, &V3{0, 0, 10}, &V3{}
+	for _, pv := range [][2]V3{{{3, 0, 4}, {0, 0, 4}}, {{1, 1, -5}, {0, 0, 0}}, {{0, 2, 20}, {0, 0, 10}}} {
+		if !v.ClosestSeg(&pv[0], a, b).Aeq(&pv[1]) {
+			t.Errorf("Expected %s got %s", pv[1].Dump(), v.Dump())
+		}
+	}
+	if !v.ClosestSeg(&V3{5, 5, 5}, a, a).Aeq(a) {
+		t.Errorf("Expected segment with no length to be a point")
+	}
+}
+
+// Points near each region of the triangle: inside, edges, and corners.
+func TestClosestTri(t *testing.T) {
+	a, b, c, v := &V3{0, 0, 0}, &V3{4, 0, 0}, &V3{0, 4, 0}, &V3{}
+	cases := [][2]V3{
+		{{1, 1, 3}, {1, 1, 0}},   // inside.
+		{{-1, -1, 0}, {0, 0, 0}}, // corner a.
+		{{6, -1, 1}, {4, 0, 0}},  // corner b.
+		{{-1, 6, 0}, {0, 4, 0}},  // corner c.
+		{{2, -3, 0}, {2, 0, 0}},  // edge ab.
+		{{-2, 1, 0}, {0, 1, 0}},  // edge ac.
+		{{3, 3, -1}, {2, 2, 0}},  // edge bc.
+		{{2, 2, 0}, {2, 2, 0}},   // on edge bc.
+		{{0.5, 0.5, 0}, {0.5, 0.5, 0}},
+	}
+	for _, pv := range cases {
+		if !v.ClosestTri(&pv[0], a, b, c).Aeq(&pv[1]) {
+			t.Errorf("Point %s: got %s expected %s", pv[0].Dump(), v.Dump(), pv[1].Dump())
+		}
+	}
+}
+
+func TestTriOverlap(t *testing.T) {
+	a0, a1, a2 := &V3{0, 0, 0}, &V3{4, 0, 0}, &V3{0, 4, 0}
+	cases := []struct {
+		b0, b1, b2 V3
+		overlap    bool
+	}{
+		{V3{1, 1, -1}, V3{1, 1, 1}, V3{2, 2, 1}, true},   // crossing through.
+		{V3{1, 1, 1}, V3{2, 1, 1}, V3{1, 2, 1}, false},   // above.
+		{V3{3, 3, -1}, V3{3, 3, 1}, V3{5, 5, 0}, false},  // beside the long edge.
+		{V3{1, 1, 0}, V3{2, 1, 0}, V3{1, 2, 0}, true},    // inside, same plane.
+		{V3{3, 3, 0}, V3{5, 3, 0}, V3{3, 5, 0}, false},   // apart, same plane.
+		{V3{4, 0, 0}, V3{6, 0, 0}, V3{4, 2, 0}, true},    // touching corners.
+		{V3{2, -1, -1}, V3{2, -1, 1}, V3{2, 1, 0}, true}, // through an edge.
+		{V3{-1, -1, -1}, V3{-1, -1, 1}, V3{-2, 0, 0}, false},
+	}
+	for cnt, c := range cases {
+		if got := TriOverlap(a0, a1, a2, &c.b0, &c.b1, &c.b2); got != c.overlap {
+			t.Errorf("Case %d: got %t expected %t", cnt, got, c.overlap)
+		}
+		if got := TriOverlap(&c.b0, &c.b1, &c.b2, a0, a1, a2); got != c.overlap {
+			t.Errorf("Case %d reversed: got %t expected %t", cnt, got, c.overlap)
+		}
+	}
+}
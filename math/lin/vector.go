@@ -45,6 +45,13 @@ func (v *V3) Aeq(a *V3) bool {
 	return Aeq(v.X, a.X) && Aeq(v.Y, a.Y) && Aeq(v.Z, a.Z)
 }
 
+// Aeq (~=) almost-equals returns true if all the elements in vector v have
+// essentially the same value as the corresponding elements in vector a.
+// Same behaviour as V3.Aeq().
+func (v *V4) Aeq(a *V4) bool {
+	return Aeq(v.X, a.X) && Aeq(v.Y, a.Y) && Aeq(v.Z, a.Z) && Aeq(v.W, a.W)
+}
+
 // AeqZ (~=) almost equals zero returns true if the square length of the vector
 // is close enough to zero that it makes no difference.
 func (v *V3) AeqZ() bool { return v.Dot(v) < Epsilon }
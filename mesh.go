@@ -6,6 +6,12 @@ package vu
 // mesh.go maps mesh data to the GPU and keeps the GPU reference.
 
 import (
+	"fmt"
+	"io"
+	"math"
+	"path"
+
+	"github.com/gazed/vu/load"
 	"github.com/gazed/vu/render"
 )
 
@@ -27,6 +33,7 @@ import (
 //    Vertex normals   lloc=1 span=3_floats_per_vertex.
 //    UV tex coords    lloc=2 span=2_floats_per_vertex.
 //    Color            lloc=3 span=4_floats_per_vertex.
+//    Tangents         lloc=6 span=4_floats_per_vertex.
 // Note each data buffer must refer to the same number of verticies,
 // and the number of verticies in one mesh must be less than 65,000.
 type Mesh interface {
@@ -36,6 +43,32 @@ type Mesh interface {
 	SetFaces(data []uint16)                // Indicies to vertex positions.
 }
 
+// ExportMesh writes the mesh vertex positions, normals, texture coordinates,
+// tangents, and faces to w. The file format is given by the name extension,
+// ie: "terrain.obj" or "terrain.gltf". Useful for inspecting generated
+// meshes in modelling tools like Blender.
+func ExportMesh(m Mesh, name string, w io.Writer) error {
+	msh, ok := m.(*mesh)
+	if !ok || msh.faces == nil {
+		return fmt.Errorf("ExportMesh %s: no mesh data", name)
+	}
+	ext := path.Ext(name)
+	d := &load.MshData{Name: name[:len(name)-len(ext)]}
+	d.F, _ = msh.faces.Get().([]uint16)
+	for lloc, data := range map[uint32]*[]float32{0: &d.V, 1: &d.N, 2: &d.T, 6: &d.X} {
+		if vd, ok := msh.vdata[lloc]; ok {
+			*data, _ = vd.Get().([]float32)
+		}
+	}
+	switch ext {
+	case ".obj":
+		return load.WriteObj(w, d)
+	case ".gltf":
+		return load.WriteGltf(w, d)
+	}
+	return fmt.Errorf("ExportMesh %s: unsupported format", name)
+}
+
 // Mesh
 // =============================================================================
 // mesh implements Mesh
@@ -52,6 +85,10 @@ type mesh struct {
 	// Per-vertex and vertex index data.
 	faces render.Data            // Triangle face indicies.
 	vdata map[uint32]render.Data // Per-vertex data values.
+
+	// Bounds from the vertex locations, ie: vertex data 0.
+	span   uint32  // Values per vertex location.
+	radius float64 // Distance from origin to furthest vertex. 0 if unknown.
 }
 
 // newMesh allocates space for a mesh structure,
@@ -71,15 +108,40 @@ func (m *mesh) InitData(lloc, span, usage uint32, normalize bool) Mesh {
 	if _, ok := m.vdata[lloc]; !ok {
 		vd := render.NewVertexData(lloc, span, usage, normalize)
 		m.vdata[lloc] = vd
+		if lloc == 0 {
+			m.span = span
+		}
 	}
 	return m
 }
 
 // SetData stores data in the specified vertex buffer.
+// Vertex locations also update the mesh bounding radius.
 func (m *mesh) SetData(lloc uint32, data interface{}) {
 	if _, ok := m.vdata[lloc]; ok {
 		m.vdata[lloc].Set(data)
+		if lloc == 0 {
+			m.radius = boundingRadius(data, int(m.span))
+		}
+	}
+}
+
+// boundingRadius returns the distance from the origin to the furthest
+// of the given vertex locations. Returns 0 for unsupported data.
+func boundingRadius(data interface{}, span int) float64 {
+	v, ok := data.([]float32)
+	if !ok || span <= 0 {
+		return 0
+	}
+	max := 0.0
+	for cnt := 0; cnt+span <= len(v); cnt += span {
+		dsq := 0.0
+		for _, f := range v[cnt : cnt+span] {
+			dsq += float64(f) * float64(f)
+		}
+		max = math.Max(max, dsq)
 	}
+	return math.Sqrt(max)
 }
 
 // InitFaces creates a triangle face index buffer.
@@ -0,0 +1,35 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gazed/vu/render"
+)
+
+// Generated meshes can be written out for inspection.
+func TestExportMesh(t *testing.T) {
+	m := newMesh("tri")
+	m.InitData(0, 3, render.StaticDraw, false).SetData(0, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	m.InitFaces(render.StaticDraw).SetFaces([]uint16{0, 1, 2})
+	buf := &bytes.Buffer{}
+	if err := ExportMesh(m, "tri.obj", buf); err != nil {
+		t.Fatal(err)
+	}
+	if obj := buf.String(); !strings.Contains(obj, "o tri\n") || !strings.Contains(obj, "f 1 2 3\n") {
+		t.Errorf("Unexpected obj export %s", obj)
+	}
+	if err := ExportMesh(m, "tri.gltf", &bytes.Buffer{}); err != nil {
+		t.Error(err)
+	}
+	if err := ExportMesh(m, "tri.fbx", &bytes.Buffer{}); err == nil {
+		t.Error("Expected unsupported format error")
+	}
+	if err := ExportMesh(newMesh("empty"), "empty.obj", &bytes.Buffer{}); err == nil {
+		t.Error("Expected no mesh data error")
+	}
+}
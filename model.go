@@ -32,6 +32,17 @@ import (
 // to ensure the Models shader is provided with its expected asset data.
 // Ie: if the shader expects a texture, ensure a texture is loaded.
 // Mismatches generate runtime logs.
+//
+// Textures with a texture import file, ie: "tex:wood" with "wood.tex",
+// are resized, channel packed, or mipmapped when loaded, see load.Tex.
+//
+// A baked ambient occlusion map is loaded using "ao:name". It is kept
+// separate from the ordered textures and darkens the ambient lighting
+// in shaders that support it, ie: "nmap", "tnmap".
+//
+// An environment cube map is loaded using "cube:name", see load.CubeData.
+// It is also kept separate from the ordered textures and is available to
+// shaders as the samplerCube uniform "cube", ie: "skybox".
 type Model interface {
 	Shader() (name string)       // Rendered models have a shader.
 	Load(assets ...string) Model // Create and import assets.
@@ -61,10 +72,21 @@ type Model interface {
 	// Layers are used for shadows or render to texture.
 	UseLayer(l Layer) // Use render pass texture.
 
+	// UseProjector maps a texture from the projector onto the model.
+	// The projected texture is the models second texture.
+	UseProjector(p *Projector)
+
 	// Set/get shader uniform values where id is the shader uniform name.
 	Uniform(id string) (value []float32)         // Uniform name/values.
 	SetUniform(id string, floats ...interface{}) // Individual values.
 
+	// Shadow controls. Casting and receiving shadows implies a scene
+	// with a light and a shadow map capable shader. Shadow only models
+	// are not rendered, but still cast shadows.
+	CastShadow(cast bool) Model       // Default false.
+	ReceiveShadow(receive bool) Model // Default false.
+	ShadowOnly(only bool) Model       // Default false. Implies CastShadow.
+
 	// Alpha is model transparency used in shaders.
 	Alpha() (a float64) // 0 for fully transparent, to 1 fully opaque.
 	SetAlpha(a float64) // Overrides any material alpha values.
@@ -82,6 +104,7 @@ type model struct {
 	assets  map[aid]string  // Model is comprised of named assets.
 	clamps  map[string]bool // Collects requests for repeating textures.
 	rebinds []asset         // Collects rebind requests.
+	loads   []string        // Requested assets as "type:name" for manifests.
 
 	// Loaded asset instances.
 	shd    *shader         // Mandatory GPU render program.
@@ -91,13 +114,24 @@ type model struct {
 	msh    *mesh           // Mandatory vertex buffer data.
 	effect *particleEffect // Optional particle effect.
 	layer  *layer          // Optional previous render pass.
+	proj   *Projector      // Optional texture projector.
+	ao     *texture        // Optional baked ambient occlusion map.
+	aoid   aid             // Ambient occlusion texture asset id.
+	cube   *texture        // Optional environment cube map.
+	cubeid aid             // Cube map texture asset id.
 
 	// Optional animated model control information.
-	anm     *animation // Optional: bone animation info.
-	frame   float64    // Frame counter.
-	move    int        // Aurrent animation defaults to 0.
-	nFrames int        // Number of frames in the current movement.
-	pose    []lin.M4   // Pose refreshed each update.
+	anm     *animation  // Optional: bone animation info.
+	frame   float64     // Frame counter.
+	move    int         // Aurrent animation defaults to 0.
+	nFrames int         // Number of frames in the current movement.
+	pose    []lin.M4    // Pose refreshed each update.
+	iks     []*IK       // Optional IK chains solved after each update.
+	events  []animEvent // Optional named frames reported to onEvent.
+	onEvent AnimEvent   // Optional application event callback.
+	motion  *rootMotion // Optional root motion that moves the Pov.
+	shown   float64     // Frame of the last pose.
+	posed   bool        // False until the first pose of an action.
 
 	// Optional font information.
 	fnt  *font  // Optional: font layout data.
@@ -107,10 +141,15 @@ type model struct {
 	wrap int    // Optional string wrap in pixels. Used if positive.
 
 	// Rendering attributes.
-	castShadow bool // Model to cast a shadow. Default false.
-	hasShadows bool // Model to reveal a shadow. Default false.
-	depth      bool // Depth buffer on by default.
-	drawMode   int  // Render mesh as Triangles, Points, Lines.
+	castShadow bool   // Model to cast a shadow. Default false.
+	hasShadows bool   // Model to reveal a shadow. Default false.
+	shadowOnly bool   // Model only casts a shadow. Default false.
+	depth      bool   // Depth buffer on by default.
+	drawMode   int    // Render mesh as Triangles, Points, Lines.
+	blend      int    // Blend mode BlendAlpha or BlendAdd.
+	blended    bool   // Model always drawn as transparent. Default false.
+	twoSided   bool   // Render back faces. Default false.
+	lightMask  uint32 // Lights that affect this model. Default AllLayers.
 
 	// Shader dependent uniform data.
 	alpha    float64              // Transparency between 0 and 1.
@@ -125,7 +164,9 @@ type model struct {
 // later.
 func newModel(shaderName string, attrs ...string) *model {
 	m := &model{alpha: 1, depth: true, assets: map[aid]string{}}
+	m.lightMask = AllLayers
 	m.assets[assetID(shd, shaderName)] = shaderName
+	m.loads = append(m.loads, "shd:"+shaderName)
 	m.clamps = map[string]bool{}
 
 	m.time = time.Now()
@@ -158,14 +199,39 @@ func (m *model) Load(attrs ...string) Model {
 			aid := assetID(tex, name)
 			m.assets[aid] = name
 			m.tids = append(m.tids, newTexid(name, aid))
+		case "ao": // ambient occlusion map. Not one of the ordered textures.
+			m.aoid = assetID(tex, name)
+			m.assets[m.aoid] = name
+		case "cube": // cube map. Not one of the ordered textures.
+			m.cubeid = assetID(cub, name)
+			m.assets[m.cubeid] = name
 		case "fnt": // font mapping
 			m.assets[assetID(fnt, name)] = name
 			m.msh = newMesh("phrase") // dynamic mesh for phrase backing.
+		default:
+			continue
 		}
+		m.loads = append(m.loads, attribute)
 	}
 	return m
 }
 
+// setShader replaces the shader requested when the model was created.
+// Only effective before the model shader has been loaded.
+func (m *model) setShader(name string) {
+	for aid := range m.assets {
+		if aid.dataType() == shd {
+			delete(m.assets, aid)
+		}
+	}
+	m.assets[assetID(shd, name)] = name
+	for cnt, load := range m.loads {
+		if strings.HasPrefix(load, "shd:") {
+			m.loads[cnt] = "shd:" + name
+		}
+	}
+}
+
 // Make is used to create objects where the data is filled by the
 // application instead of the loader. Called during App.Update.
 func (m *model) Make(attrs ...string) Model {
@@ -275,13 +341,46 @@ func (m *model) UseLayer(l Layer) {
 		m.layer = layer
 		switch m.layer.attr {
 		case render.ImageBuffer:
-			m.texs = append(m.texs, m.layer.tex)
+			m.texs = append(m.texs, m.layer.tex, m.layer.db) // color, depth.
 		case render.DepthBuffer:
 			// shadow maps are handled in toDraw.
 		}
 	}
 }
 
+// CastShadow enables or disables the model shadow. Disabling the
+// shadow also disables any shadow only mode.
+func (m *model) CastShadow(cast bool) Model {
+	m.castShadow = cast
+	if !cast {
+		m.shadowOnly = false
+	}
+	return m
+}
+
+// ReceiveShadow enables or disables rendering shadows onto the model.
+func (m *model) ReceiveShadow(receive bool) Model {
+	m.hasShadows = receive
+	if !receive && m.layer != nil && m.layer.attr == render.DepthBuffer {
+		m.layer = nil // stop using the shadow map.
+	}
+	return m
+}
+
+// ShadowOnly hides the model while it continues to cast a shadow.
+// Useful for cheap stand-in shadow casters or off screen occluders.
+func (m *model) ShadowOnly(only bool) Model {
+	m.shadowOnly = only
+	if only {
+		m.castShadow = true
+	}
+	return m
+}
+
+// UseProjector has the model receive a projected texture. A nil
+// projector stops the projection.
+func (m *model) UseProjector(p *Projector) { m.proj = p }
+
 // SetStr changes the text phrase and causes a mesh rebind.
 func (m *model) SetStr(str string) Labeler {
 	if len(str) > 0 && m.str != str {
@@ -297,6 +396,12 @@ func (m *model) SetWrap(w int) Labeler    { m.wrap = w; return m }
 func (m *model) StrSize() (w, h int)      { return m.strw, m.strh }
 func (m *model) StrColor(r, g, b float64) { m.SetUniform("kd", r, g, b) }
 func (m *model) isEmptyStr() bool         { return m.fnt != nil && m.str == "" }
+func (m *model) StrOutline(r, g, b, width float64) {
+	m.SetUniform("outline", r, g, b, width)
+}
+func (m *model) StrGlow(r, g, b, size float64) {
+	m.SetUniform("glow", r, g, b, size)
+}
 
 // SetUniform combines floats values into a slice of float32's
 // that will be passed to the rendering layer and used to set
@@ -329,6 +434,10 @@ func (m *model) Animate(move, frame int) bool {
 		if frame < m.nFrames {
 			m.frame = float64(frame)
 		}
+		if m.motion != nil {
+			m.motion.move = -1 // no root motion until the next pose.
+		}
+		m.posed = false
 	}
 	return move == m.move // was the requested movement available.
 }
@@ -341,6 +450,49 @@ func (m *model) Actions() []string {
 	}
 	return []string{}
 }
+func (m *model) Joints() []string {
+	if m.anm != nil {
+		return m.anm.jointNames()
+	}
+	return []string{}
+}
+func (m *model) Joint(name string) int {
+	if m.anm != nil {
+		return m.anm.joint(name)
+	}
+	return -1
+}
+
+func (m *model) JointAt(joint int) (x, y, z float64) {
+	if m.anm != nil && joint >= 0 && joint < len(m.pose) && len(m.anm.binds) == len(m.pose) {
+		v := m.anm.jointAt(joint, m.pose, &lin.V3{})
+		return v.X, v.Y, v.Z
+	}
+	return 0, 0, 0
+}
+func (m *model) AddIK(ik *IK) {
+	m.RemoveIK(ik)
+	m.iks = append(m.iks, ik)
+}
+func (m *model) RemoveIK(ik *IK) {
+	for cnt, chain := range m.iks {
+		if chain == ik {
+			m.iks = append(m.iks[:cnt], m.iks[cnt+1:]...)
+			return
+		}
+	}
+}
+
+func (m *model) AddEvent(action int, frame float64, name string) {
+	m.events = append(m.events, animEvent{move: action, frame: frame, name: name})
+}
+func (m *model) OnEvent(call AnimEvent) { m.onEvent = call }
+func (m *model) SetRootMotion(joint int) {
+	m.motion = nil
+	if joint >= 0 {
+		m.motion = &rootMotion{joint: joint, move: -1}
+	}
+}
 
 // Pose returns the bone transform, or the identity matrix
 // if there was no transform for the model. The returned matrix
@@ -361,12 +513,79 @@ func (m *model) SetEffect(mover ParticleEffect, maxParticles int) {
 }
 
 // animate is called to reposition the poses for an animated model.
-func (m *model) animate(dt float64) {
+// Returns the horizontal root motion in model space, if any.
+func (m *model) animate(dt float64) (dx, dz float64) {
+	posed := m.frame // frame of the new pose.
 	m.frame = m.anm.animate(dt, m.frame, m.move, m.pose)
+	if m.motion != nil {
+		dx, dz = m.rootMotion(posed)
+	}
+	for _, ik := range m.iks {
+		m.anm.solve(ik, m.pose)
+	}
+	m.passEvents(posed)
 	nextFrame := int(math.Floor(m.frame + 1))
 	if nextFrame >= m.nFrames {
 		m.frame -= float64(m.nFrames - 1)
 	}
+	return dx, dz
+}
+
+// passEvents calls the application for each event frame passed since
+// the last pose, up to and including the given posed frame. The first
+// pose of an action calls the events on its starting frame. Movements
+// loop after the last frame, so events at frame 0 are passed on each loop.
+func (m *model) passEvents(posed float64) {
+	shown, started := m.shown, !m.posed
+	m.shown, m.posed = posed, true
+	if m.onEvent == nil || len(m.events) == 0 {
+		return
+	}
+	last := float64(m.nFrames - 1)
+	for _, e := range m.events {
+		if e.move != m.move {
+			continue
+		}
+		passed := shown < e.frame && e.frame <= posed
+		switch {
+		case started:
+			passed = e.frame == posed
+		case posed < shown: // looped back to the start.
+			passed = (shown < e.frame && e.frame <= last) || e.frame <= posed
+		}
+		if passed {
+			m.onEvent(e.move, e.name)
+		}
+	}
+}
+
+// rootMotion returns how far the root joint moved along the ground
+// since the last pose, and keeps the root joint in place by moving
+// all the joints back to where the root joint started the movement.
+func (m *model) rootMotion(frame float64) (dx, dz float64) {
+	rm, a := m.motion, m.anm
+	if rm.joint >= a.jointCnt || a.joints[rm.joint] >= 0 || m.move >= len(a.moves) {
+		return 0, 0 // only top level joints move the model.
+	}
+	start, at := &lin.V3{}, &lin.V3{}
+	a.rootAt(m.move, 0, rm.joint, start)
+	a.jointAt(rm.joint, m.pose, at)
+	switch {
+	case rm.move != m.move:
+		// new or restarted movement: no motion until the next pose.
+	case frame >= rm.frame:
+		dx, dz = at.X-rm.at.X, at.Z-rm.at.Z
+	default:
+		// looped: the rest of the movement plus the start of the next loop.
+		end := a.rootAt(m.move, m.nFrames-1, rm.joint, &lin.V3{})
+		dx, dz = end.X-rm.at.X+at.X-start.X, end.Z-rm.at.Z+at.Z-start.Z
+	}
+	rm.move, rm.frame, rm.at = m.move, frame, *at
+	ox, oz := at.X-start.X, at.Z-start.Z
+	for cnt := range m.pose {
+		m.pose[cnt].TranslateMT(-ox, 0, -oz)
+	}
+	return dx, dz
 }
 
 // =============================================================================
@@ -386,6 +605,25 @@ func SetDepth(enabled bool) ModAttr {
 	return func(m Model) { m.(*model).depth = enabled }
 }
 
+// LightMask limits the lights that affect a model to lights whose
+// Light.Mask shares a bit with the given mask. Use to group models,
+// ie: a UI light rig that does not light the game world.
+func LightMask(mask uint32) ModAttr {
+	return func(m Model) { m.(*model).lightMask = mask }
+}
+
+// BlendMode draws the model with the transparent models using the
+// given blend mode, BlendAlpha or BlendAdd. Models are otherwise only
+// treated as transparent when their alpha is less than 1.
+func BlendMode(mode int) ModAttr {
+	return func(m Model) { m.(*model).blend, m.(*model).blended = mode, true }
+}
+
+// TwoSided toggles rendering of back faces, ie: for leaves or cloth.
+func TwoSided(enabled bool) ModAttr {
+	return func(m Model) { m.(*model).twoSided = enabled }
+}
+
 // CastShadow marks a model that can cast shadows. Casting shadows
 // implies a scene with a light and objects that receive shadows.
 // It also implies a shadow map capable shader.
@@ -458,9 +696,12 @@ func (ms *models) dispose(id eid) {
 	if m, ok := ms.data[id]; ok {
 		m.msh = nil
 		m.shd = nil
-		m.anm = nil
+		m.anm, m.iks = nil, nil
+		m.events, m.onEvent, m.motion = nil, nil, nil
 		m.fnt = nil
 		m.mat = nil
+		m.ao = nil
+		m.cube = nil
 		m.texs = []*texture{} // garbage collect all old textures.
 	}
 	delete(ms.data, id)
@@ -488,7 +729,7 @@ func (ms *models) refresh(dts float64) {
 	// Process ongoing activity on active models.
 	// FUTURE Don't traverse all active models. Have separate
 	//        lists for animated models and particle models.
-	for _, m := range ms.active {
+	for id, m := range ms.active {
 
 		if m.effect != nil {
 			// udpate and rebind particle effects first since
@@ -498,7 +739,9 @@ func (ms *models) refresh(dts float64) {
 		if m.anm != nil {
 			// animations update the bone position matricies.
 			// These are bound as uniforms at draw time.
-			m.animate(dts)
+			if dx, dz := m.animate(dts); m.motion != nil {
+				ms.move(id, dx, dz, dts)
+			}
 		}
 
 		// handle any data updates with rebind requests.
@@ -515,6 +758,27 @@ func (ms *models) refresh(dts float64) {
 	}
 }
 
+// move applies the model space root motion dx, dz to the models Pov.
+// Solid physics bodies have their ground velocity set to cover the
+// distance over the next physics step.
+func (ms *models) move(id eid, dx, dz, dts float64) {
+	p := ms.eng.povs.get(id)
+	if p == nil {
+		return
+	}
+	dx, dz = dx*p.S.X, dz*p.S.Z
+	if _, solid := ms.eng.bodies.solids[id]; solid && dts > 0 {
+		b := ms.eng.bodies.get(id)
+		wx, _, wz := lin.MultSQ(dx, 0, dz, p.T.Rot)
+		vx, _, vz := b.Speed()
+		b.Push(wx/dts-vx, 0, wz/dts-vz)
+		return
+	}
+	if dx != 0 || dz != 0 {
+		p.Move(dx, 0, dz, p.T.Rot)
+	}
+}
+
 // queueLoads ensures new models are passed through the loading system.
 // Overall there are few assets used by lots of models.
 func (ms *models) queueLoads() {
@@ -549,6 +813,12 @@ func (ms *models) finishLoads(assets map[aid]asset) {
 					m.msh = at
 					delete(m.assets, aid)
 				case *texture:
+					if aid == m.aoid {
+						m.ao = at
+					}
+					if aid == m.cubeid {
+						m.cube = at
+					}
 					if len(m.texs) == 0 {
 						m.texs = make([]*texture, len(m.tids))
 					}
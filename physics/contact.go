@@ -20,6 +20,10 @@ type contactPair struct {
 	pocs  []*pointOfContact // The current points of contact.
 	valid bool              // Broadphase check for deleted bodies.
 
+	// Narrowphase tracks when the bodies start touching.
+	touching bool // Pair had contact points the last narrowphase.
+	impact   bool // Pair started touching the last narrowphase.
+
 	// The following fields are used only by the solver.
 	processingLimit float64 // Bodies outside this range are ignored.
 	breakingLimit   float64 // Bodies outside this range are not contacting.
@@ -68,6 +68,18 @@ type Physics interface {
 	// the current physics simulation. Bodies positions and velocities
 	// are not updated. Provided for occasional or one-off checks.
 	Collide(a, b Body) bool
+
+	// Impacts returns the pairs of bodies that started touching during
+	// the last Step, ie: for playing collision sounds. The returned
+	// impacts are reused and only valid until the next Step.
+	Impacts() []Impact
+}
+
+// Impact is a collision between two bodies that were not touching
+// the previous simulation step.
+type Impact struct {
+	A, B    Body    // Colliding bodies.
+	Impulse float64 // Total collision impulse applied to separate the bodies.
 }
 
 // Physics interface
@@ -82,6 +94,7 @@ type physics struct {
 	col        *collider               // Checks for collisions, updates collision contacts.
 	sol        *solver                 // Resolves collisions, updates bodies locations.
 	overlapped map[uint64]*contactPair // Overlapping pairs. Updated during broadphase.
+	impacts    []Impact                // Newly touching pairs from the last Step.
 
 	// scratch variables keep memory so that temp variables
 	// don't have to be continually allocated and garbage collected
@@ -131,12 +144,31 @@ func (px *physics) Step(bodies []Body, timestep float64) {
 			px.sol.solve(colliding, px.overlapped)
 		}
 	}
+	px.gatherImpacts(px.overlapped)
 
 	// adjust body locations based on velocities
 	px.updateBodyLocations(bodies, timestep)
 	px.clearForces(bodies)
 }
 
+// Physics interface implementation.
+func (px *physics) Impacts() []Impact { return px.impacts }
+
+// gatherImpacts records the pairs that started touching during narrowphase
+// along with the impulses the solver applied to their contact points.
+func (px *physics) gatherImpacts(pairs map[uint64]*contactPair) {
+	px.impacts = px.impacts[:0]
+	for _, pair := range pairs {
+		if pair.impact {
+			impulse := 0.0
+			for _, poc := range pair.pocs {
+				impulse += poc.sp.warmImpulse
+			}
+			px.impacts = append(px.impacts, Impact{A: pair.bodyA, B: pair.bodyB, Impulse: impulse})
+		}
+	}
+}
+
 // predictBodyLocations applies motion to moving/awake bodies as if there
 // was nothing else around.
 //
@@ -229,6 +261,8 @@ func (px *physics) narrowphase(pairs map[uint64]*contactPair) (colliding map[uin
 		algorithm := px.col.algorithms[bodyA.shape.Type()][bodyB.shape.Type()]
 		bA, bB, manifold := algorithm(bodyA, bodyB, scrManifold)
 		cpair.bodyA, cpair.bodyB = bA.(*body), bB.(*body) // handle potential body swaps.
+		cpair.impact = len(manifold) > 0 && !cpair.touching
+		cpair.touching = len(manifold) > 0
 
 		// bodies are colliding if there are contact points in the manifold.
 		// Update any contact points and prepare for the solver.
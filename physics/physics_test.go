@@ -42,6 +42,29 @@ func TestSphereAt(t *testing.T) {
 	}
 }
 
+// A falling ball reports one impact when it lands on the slab.
+func TestImpacts(t *testing.T) {
+	px := newPhysics()
+	slab := newBody(NewBox(100, 25, 100)).SetMaterial(0, 0)
+	slab.World().Loc.SetS(0, -25, 0)
+	ball := newBody(NewSphere(1)).SetMaterial(1, 0)
+	ball.World().Loc.SetS(0, 5, 0)
+	bodies := []Body{slab, ball}
+	impacts, impulse := 0, 0.0
+	for cnt := 0; cnt < 100; cnt++ {
+		px.Step(bodies, 0.02)
+		for _, im := range px.Impacts() {
+			if !im.A.Eq(ball) && !im.B.Eq(ball) {
+				t.Errorf("Expected ball impact")
+			}
+			impacts, impulse = impacts+1, im.Impulse
+		}
+	}
+	if impacts != 1 || impulse <= 0 {
+		t.Errorf("Expected one impact got %d with impulse %f", impacts, impulse)
+	}
+}
+
 // Check that basic collision works independent of general collision resolution.
 func TestCollide(t *testing.T) {
 	px := newPhysics()
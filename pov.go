@@ -10,6 +10,7 @@ package vu
 //  o Pov passes user object creation requests through the engine entity manager.
 
 import (
+	"github.com/gazed/vu/audio"
 	"github.com/gazed/vu/math/lin"
 	"github.com/gazed/vu/physics"
 	"github.com/gazed/vu/render"
@@ -183,15 +184,41 @@ func (p *Pov) SetSolid(mass, bounce float64) {
 // AddSound loads audio data associated with this Pov. Played sounds occur
 // at the associated Pov's location. Sounds that are played will be louder
 // as the distance between the played noise and listener decreases.
-// Place the single global noise listener at this Pov.
+// Place the single global noise listener at this Pov. Names of sounds
+// from a previously loaded audio bank use the bank settings. See
+// Eng.LoadBank. Sounds moving relative to the listener have their pitch
+// shifted using the doppler effect. See Doppler.
 func (p *Pov) AddSound(name string) { p.eng.addSound(p.id, name) }
 
+// AddGenerator adds a sound that plays the samples produced by the
+// generator, ie: an engine hum that follows the engine RPM. Generated
+// sounds are played, located, and controlled like added sounds, and
+// share the same sound indexes. The generator is called for samples
+// while the sound plays. Generated sounds can be played after the next
+// update. The name identifies the sound in diagnostics.
+func (p *Pov) AddGenerator(name string, g *audio.Generator) {
+	p.eng.addGenerator(p.id, name, g)
+}
+
 // PlaySound plays the sound associated with this Pov.
 // The sound index corresponds to the order the sound was added
-// to the Pov. The first sound added has index 0.
-func (p *Pov) PlaySound(index int) { p.eng.playSound(p.id, index) }
+// to the Pov. The first sound added has index 0. The returned Sound
+// controls the playing sound. It is nil if the sound is not loaded.
+func (p *Pov) PlaySound(index int) *Sound { return p.eng.playSound(p.id, index) }
+
+// Trigger plays the sounds added to this Pov that the audio bank maps
+// to the named event, ie: "click" for a button or "step" for a footstep.
+// Sounds with a bank trigger strength range are scaled by the given
+// event strength. Solid bodies trigger "impact" events when they collide,
+// using the collision impulse as the strength. See Eng.LoadBank.
+func (p *Pov) Trigger(event string, strength float64) {
+	p.eng.trigger(p.id, event, strength)
+}
 
 // SetListener sets the location of the listener to be this Pov.
+// Sounds are heard relative to the listener location and orientation,
+// which is the camera location and orientation for a Pov with a camera.
+// The listener and playing sounds are updated each frame.
 func (p *Pov) SetListener() { p.eng.sounds.setListener(p) }
 
 // Light returns nil if no there light for this Pov.
@@ -202,6 +229,12 @@ func (p *Pov) Light() *Light { return p.eng.lights.get(p.id) }
 // and all child pov's.
 func (p *Pov) NewLight() *Light { return p.eng.lights.create(p.id) }
 
+// Projector returns nil if there is no projector for this Pov.
+func (p *Pov) Projector() *Projector { return p.eng.projs.get(p.id) }
+
+// NewProjector creates a texture projector at this Pov.
+func (p *Pov) NewProjector() *Projector { return p.eng.projs.create(p.id, p) }
+
 // Layer returns nil if there is no layer for this Pov.
 func (p *Pov) Layer() Layer { return p.eng.layer(p.id) }
 
@@ -213,9 +246,15 @@ func (p *Pov) NewLayer() Layer { return p.eng.newLayer(p.id, render.ImageBuffer)
 // NewLabel creates a Model that displays a small white text phrase.
 //   shader      expected to be a font aware shader like "txt" or "sdf".
 //   font        identifies bot the font mapping file and font texture file.
+// Font names ending with ".sdf", ie: "DejaVuSans.sdf", generate a signed
+// distance field font for the "sdf" shader when the font is loaded. The
+// field is rendered from a TrueType font.ttf, or converted from a bitmapped
+// font.fnt and its texture, see load.FntData.LoadSdf.
 func (p *Pov) NewLabel(shader, font string) Labeler {
 	m := p.NewModel(shader, "fnt:"+font, "tex:"+font)
-	m.StrColor(1, 1, 1) // default white.
+	m.StrColor(1, 1, 1)      // default white.
+	m.StrOutline(0, 0, 0, 0) // default no outline.
+	m.StrGlow(0, 0, 0, 0)    // default no glow.
 	return m
 }
 
@@ -228,6 +267,24 @@ func (p *Pov) NewModel(shader string, attrs ...string) Model {
 	return p.eng.models.create(p.id, shader, attrs...)
 }
 
+// LoadModel imports a glTF, Wavefront OBJ, or COLLADA scene file, ie:
+// "scene.gltf", "scene.glb", "scene.obj", or "scene.dae", creating a child
+// Pov for each node in the scene hierarchy along with a Model for each
+// node mesh. The scene is read immediately while the
+// model shaders and external textures are loaded like any other Model.
+// Models use the "anim" shader when skinned, "uv" when textured,
+// and "phong" otherwise.
+func (p *Pov) LoadModel(name string) error { return p.eng.loadModel(p, name) }
+
+// LoadMat reads a .mat material file, ie: "wood" for "wood.mat", and
+// applies it to this Pov's Model, creating the Model if there is none.
+// The material sets the shader, lighting colors, textures, blend mode,
+// two sided rendering, and shader uniforms. The PBR values are passed
+// to shaders as the "basecolor", "metallic", "roughness", "emissive",
+// and "cutoff" uniforms. A material shader can only replace the model
+// shader before the model is loaded, ie: just after NewModel.
+func (p *Pov) LoadMat(name string) (Model, error) { return p.eng.loadMat(p, name) }
+
 // remChild is used by a pov removing itself from the hierarchy.
 func (p *Pov) remChild(c *Pov) {
 	for index, c := range p.kids {
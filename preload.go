@@ -0,0 +1,143 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// preload.go loads groups of assets ahead of the models that use them.
+// DESIGN: preloaded assets go through the regular loader so they end up
+//         cached and bound. Models created later find their assets in the
+//         loader cache and become active on the next update.
+
+import (
+	"sort"
+	"strings"
+)
+
+// preload tracks one group of assets waiting to be loaded.
+type preload struct {
+	pending map[aid]string // Assets that have not been returned.
+	failed  []string       // Assets that could not be loaded.
+	done    func([]string) // Completion notification.
+}
+
+// preloads is the preload component manager.
+type preloads struct {
+	eng  *engine    // Needed to submit load requests.
+	list []*preload // Outstanding preload groups.
+}
+
+// newPreloads creates the preload component manager.
+func newPreloads(eng *engine) *preloads { return &preloads{eng: eng} }
+
+// create submits the assets for loading. Unrecognized asset
+// names are reported as failed.
+func (pl *preloads) create(assets []string, done func(failed []string)) {
+	p := &preload{pending: map[aid]string{}, done: done}
+	for _, attribute := range assets {
+		attr := strings.Split(attribute, ":")
+		if len(attr) != 2 {
+			p.failed = append(p.failed, attribute)
+			continue
+		}
+		name := attr[1]
+		switch attr[0] {
+		case "mod": // animated model and its default texture.
+			p.pending[assetID(anm, name)] = name
+			p.pending[assetID(tex, name+"0")] = name + "0"
+		case "msh":
+			p.pending[assetID(msh, name)] = name
+		case "tex", "ao":
+			p.pending[assetID(tex, name)] = name
+		case "cube":
+			p.pending[assetID(cub, name)] = name
+		case "mat":
+			p.pending[assetID(mat, name)] = name
+		case "fnt":
+			p.pending[assetID(fnt, name)] = name
+		case "shd":
+			p.pending[assetID(shd, name)] = name
+		case "snd": // sound file or audio bank sound.
+			for _, file := range pl.eng.sounds.files(name) {
+				p.pending[assetID(snd, file)] = file
+			}
+		default:
+			p.failed = append(p.failed, attribute)
+		}
+	}
+	pl.list = append(pl.list, p)
+	if len(p.pending) > 0 {
+		reqs := map[aid]string{}
+		for aid, name := range p.pending {
+			reqs[aid] = name
+		}
+		pl.eng.submitLoadReqs(reqs)
+		return
+	}
+	pl.finishLoads(map[aid]asset{}) // nothing to load.
+}
+
+// finishLoads removes returned assets from the outstanding preloads
+// and notifies the application of any completed preloads.
+func (pl *preloads) finishLoads(assets map[aid]asset) {
+	for cnt := 0; cnt < len(pl.list); cnt++ {
+		p := pl.list[cnt]
+		for aid, name := range p.pending {
+			if a, ok := assets[aid]; ok {
+				if _, ok := a.(*failure); ok {
+					p.failed = append(p.failed, name)
+				}
+				delete(p.pending, aid)
+			}
+		}
+		if len(p.pending) == 0 {
+			pl.list = append(pl.list[:cnt], pl.list[cnt+1:]...)
+			cnt--
+			if p.done != nil {
+				p.done(p.failed)
+			}
+		}
+	}
+}
+
+// manifest collects the assets used by the models and sounds
+// of the given pov and its children.
+func (pl *preloads) manifest(p *Pov) []string {
+	unique := map[string]bool{}
+	pl.collect(p, unique)
+	assets := []string{}
+	for name := range unique {
+		assets = append(assets, name)
+	}
+	sort.Strings(assets)
+	return assets
+}
+
+// collect is the recursive part of manifest.
+func (pl *preloads) collect(p *Pov, unique map[string]bool) {
+	if m, ok := pl.eng.models.data[p.id]; ok {
+		for _, name := range m.loads {
+			unique[name] = true
+		}
+	}
+	for _, c := range pl.eng.sounds.cues[p.id] {
+		for _, file := range c.files {
+			unique["snd:"+file] = true
+		}
+	}
+	for _, kid := range p.kids {
+		pl.collect(kid, unique)
+	}
+}
+
+// =============================================================================
+
+// failure is returned by the loader in place of an asset that could
+// not be loaded. This lets preloads complete when assets are missing.
+type failure struct {
+	id   aid    // Identifier of the requested asset.
+	name string // Name of the requested asset.
+}
+
+// aid is used to uniquely identify assets.
+func (f *failure) aid() aid      { return f.id }   // hashed type and name.
+func (f *failure) label() string { return f.name } // asset name
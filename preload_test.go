@@ -0,0 +1,54 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Preloads complete once all their assets are returned by the loader.
+func TestPreload(t *testing.T) {
+	eng := newEngine(nil)
+	calls, failed := 0, []string{}
+	done := func(f []string) { calls, failed = calls+1, f }
+	eng.Preload([]string{"msh:box", "tex:wood", "snd:bloop", "bad"}, done)
+	eng.loads.finishLoads(map[aid]asset{assetID(msh, "box"): newMesh("box")})
+	if calls != 0 {
+		t.Fatalf("Expected preload to be waiting")
+	}
+	tid := assetID(tex, "wood")
+	eng.loads.finishLoads(map[aid]asset{
+		tid:                   newTexture("wood"),
+		assetID(snd, "bloop"): &failure{id: assetID(snd, "bloop"), name: "bloop"},
+	})
+	if calls != 1 || !reflect.DeepEqual(failed, []string{"bad", "bloop"}) {
+		t.Errorf("Expected one notification with failures, got %d %v", calls, failed)
+	}
+	if len(eng.loads.list) != 0 {
+		t.Errorf("Expected completed preloads to be removed")
+	}
+
+	// nothing to load completes immediately.
+	eng.Preload([]string{}, done)
+	if calls != 2 || len(failed) != 0 {
+		t.Errorf("Expected immediate notification, got %d %v", calls, failed)
+	}
+}
+
+// Manifests collect model and sound assets from a pov hierarchy.
+func TestManifest(t *testing.T) {
+	eng := newEngine(nil)
+	level := eng.root().NewPov()
+	level.NewModel("uv", "msh:box", "tex:wood", "bad")
+	kid := level.NewPov()
+	kid.NewModel("uv", "msh:box", "mat:red")
+	kid.AddSound("bloop")
+	kid.NewPov().NewModel("phong").Make("msh:generated")
+	eng.root().NewPov().NewModel("other", "msh:elsewhere")
+	want := []string{"mat:red", "msh:box", "shd:phong", "shd:uv", "snd:bloop", "tex:wood"}
+	if got := eng.Manifest(level); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+}
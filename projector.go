@@ -0,0 +1,115 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// projector.go projects textures onto models.
+// DESIGN: A projector is similar to a camera in that it has a view and
+//         projection. Instead of rendering what it sees it provides a
+//         matrix that shaders use to map a texture onto the models it sees.
+//         The projected texture is loaded by each model using the projector.
+
+import (
+	"github.com/gazed/vu/math/lin"
+)
+
+// Projector shines a texture onto models, like a slide projector.
+// Useful for caustics, stained glass patterns, and blob shadows
+// independent of the lights. A projector is attached to a Pov which
+// provides its location and orientation. It projects along the Pov's
+// -Z axis. Models opt in using Model.UseProjector along with a
+// projector aware shader, ie: "proj", and the projected texture
+// as their second texture. For example:
+//     beam := root.NewPov().SetAt(0, 10, 0)
+//     beam.Spin(-90, 0, 0) // face down.
+//     proj := beam.NewProjector()
+//     proj.SetPerspective(30, 1, 0.1, 50)
+//     floor.NewModel("proj", "msh:floor", "tex:floor", "tex:caustics").UseProjector(proj)
+type Projector struct {
+	Strength float64 // Projected texture intensity from 0 to 1. Default 1.
+	Additive bool    // Add light, ie: caustics, rather than darken, ie: blobs.
+
+	pov *Pov    // Location and orientation.
+	vm  *lin.M4 // Projector view matrix.
+	pm  *lin.M4 // Projector projection matrix.
+	vpm *lin.M4 // View-projection-bias matrix.
+	bm  *lin.M4 // Bias matrix maps clip space to texture space.
+}
+
+// newProjector creates a default 45 degree projector.
+func newProjector(p *Pov) *Projector {
+	pr := &Projector{Strength: 1, pov: p}
+	pr.vm = lin.NewM4I()
+	pr.pm = &lin.M4{}
+	pr.vpm = &lin.M4{}
+	pr.bm = &lin.M4{
+		Xx: 0.5, Xy: 0.0, Xz: 0.0, Xw: 0.0,
+		Yx: 0.0, Yy: 0.5, Yz: 0.0, Yw: 0.0,
+		Zx: 0.0, Zy: 0.0, Zz: 0.5, Zw: 0.0,
+		Wx: 0.5, Wy: 0.5, Wz: 0.5, Ww: 1.0,
+	}
+	pr.SetPerspective(45, 1, 0.1, 100)
+	return pr
+}
+
+// SetPerspective projects the texture so that it grows with distance.
+func (pr *Projector) SetPerspective(fov, ratio, near, far float64) {
+	pr.pm.Persp(fov, ratio, near, far)
+}
+
+// SetOrthographic projects the texture with a constant size.
+func (pr *Projector) SetOrthographic(left, right, bottom, top, near, far float64) {
+	pr.pm.Ortho(left, right, bottom, top, near, far)
+}
+
+// update the projector transform from its Pov world location
+// and local orientation. Expected to be called once per frame
+// after the Pov world transforms have been updated.
+func (pr *Projector) update() {
+	mm := pr.pov.mm
+	pr.vm.SetQ(pr.pov.T.Rot)
+	pr.vm.TranslateTM(-mm.Wx, -mm.Wy, -mm.Wz)
+	pr.vpm.Mult(pr.vm, pr.pm)
+	pr.vpm.Mult(pr.vpm, pr.bm)
+}
+
+// =============================================================================
+
+// projectors manages all the active Projector instances.
+type projectors struct {
+	data map[eid]*Projector // Projector instance data.
+}
+
+// newProjectors creates a projector component manager.
+// Expected to be called once on startup.
+func newProjectors() *projectors { return &projectors{data: map[eid]*Projector{}} }
+
+// get returns the projector for the given entity, or nil
+// if there is no such projector.
+func (ps *projectors) get(id eid) *Projector {
+	if pr, ok := ps.data[id]; ok {
+		return pr
+	}
+	return nil
+}
+
+// create ensures there is only one projector per entity.
+func (ps *projectors) create(id eid, p *Pov) *Projector {
+	if pr, ok := ps.data[id]; ok {
+		return pr // Don't allow creating over existing projector.
+	}
+	pr := newProjector(p)
+	ps.data[id] = pr
+	return pr
+}
+
+// update refreshes all projector transforms.
+func (ps *projectors) update() {
+	for _, pr := range ps.data {
+		pr.update()
+	}
+}
+
+// dispose the projector for the given entity. Do nothing
+// if no such projector exists.
+func (ps *projectors) dispose(id eid) { delete(ps.data, id) }
@@ -0,0 +1,25 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// A point straight in front of a projector maps to the
+// center of the projected texture.
+func TestProjector(t *testing.T) {
+	p := newPov(nil, 0, nil)
+	p.SetAt(0, 5, 0)
+	p.mm.Set(lin.M4I).TranslateMT(0, 5, 0)
+	pr := newProjector(p)
+	pr.update()
+	v := (&lin.V4{}).SetS(0, 5, -10, 1)
+	v.MultvM(v, pr.vpm)
+	if u, w := v.X/v.W, v.Y/v.W; !lin.Aeq(u, 0.5) || !lin.Aeq(w, 0.5) {
+		t.Errorf("Expected center of projection, got %f %f", u, w)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// rays.go provides a volumetric light scattering (god rays) post effect.
+// DESIGN: The scene is rendered to an ImageBuffer layer. A screen model
+//         using the "rays" shader draws the layer color and depth textures,
+//         blurring unoccluded scene pixels away from the light. See:
+//         http://http.developer.nvidia.com/GPUGems3/gpugems3_ch13.html
+
+import (
+	"github.com/gazed/vu/math/lin"
+)
+
+// Rays is a screen space light shaft effect tied to one light. A scene
+// is rendered to a layer which is then displayed by a screen model using
+// the "rays" shader. Scene depth is used to decide which pixels are open
+// sky that let light through and which pixels are occluders. For example:
+//     scene := eng.Root().NewPov()
+//     cam := scene.NewCam()
+//     scene.NewLayer()
+//     ...
+//     screen := eng.Root().NewPov() // with its own camera.
+//     model := screen.NewPov().NewModel("rays", "msh:flipboard")
+//     model.UseLayer(scene.Layer())
+//     rays := vu.NewRays(cam, sun, model)
+//     ...
+//     rays.Update() // once per App.Update.
+type Rays struct {
+	Density  float64 // Fraction of the distance to the light sampled.
+	Decay    float64 // Per sample falloff from 0 to 1.
+	Weight   float64 // Per sample intensity.
+	Exposure float64 // Overall brightness of the rays.
+
+	on     bool    // Effect is enabled by default.
+	cam    *Camera // Scene camera used to project the light.
+	light  *Pov    // Light source Pov.
+	screen Model   // Model using the "rays" shader.
+	v0     *lin.V4 // Scratch for light projection.
+}
+
+// NewRays creates a light scattering effect for the light at the
+// given Pov as seen by the given scene camera. The screen Model is
+// expected to use the "rays" shader with the scene render layer.
+func NewRays(cam *Camera, light *Pov, screen Model) *Rays {
+	r := &Rays{cam: cam, light: light, screen: screen, on: true}
+	r.Density, r.Decay, r.Weight, r.Exposure = 0.8, 0.95, 0.3, 0.4
+	r.v0 = &lin.V4{}
+	return r
+}
+
+// On returns true if the effect is enabled.
+func (r *Rays) On() bool { return r.on }
+
+// SetOn enables or disables the effect. A disabled effect
+// displays the scene layer without light shafts.
+func (r *Rays) SetOn(on bool) { r.on = on }
+
+// Update projects the light into screen space and sets the shader
+// uniforms. Expected to be called each update after the light
+// and camera have moved.
+func (r *Rays) Update() {
+	lx, ly, lz := r.light.World()
+	sx, sy, visible := r.project(lx, ly, lz)
+	exposure := r.Exposure
+	if !r.on || !visible {
+		exposure = 0 // no light shafts.
+	}
+	r.screen.SetUniform("lsp", sx, sy)
+	r.screen.SetUniform("rays", r.Density, r.Decay, r.Weight, exposure)
+}
+
+// project returns the world location in texture coordinates,
+// range 0:1, for the scene camera. Locations behind the camera
+// are not visible.
+func (r *Rays) project(wx, wy, wz float64) (sx, sy float64, visible bool) {
+	vec := r.v0.SetS(wx, wy, wz, 1)
+	vec.MultvM(vec, r.cam.vm) // apply view matrix.
+	vec.MultvM(vec, r.cam.pm) // apply projection matrix.
+	if vec.W <= 0 {
+		return 0.5, 0.5, false // behind the camera.
+	}
+	return vec.X*0.5/vec.W + 0.5, vec.Y*0.5/vec.W + 0.5, true
+}
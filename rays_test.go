@@ -0,0 +1,23 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// A light straight ahead of the camera is at the center of the screen
+// while a light behind the camera is not visible.
+func TestRaysProject(t *testing.T) {
+	cam, _, _ := initScene()
+	r := NewRays(cam, nil, nil)
+	if sx, sy, ok := r.project(0, 0, -10); !ok || !lin.Aeq(sx, 0.5) || !lin.Aeq(sy, 0.5) {
+		t.Errorf("Expected center screen light, got %f %f %t", sx, sy, ok)
+	}
+	if _, _, ok := r.project(0, 0, 10); ok {
+		t.Errorf("Expected light behind camera to be hidden")
+	}
+}
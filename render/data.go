@@ -12,6 +12,7 @@ import (
 // A model is often comprised of multiple sets of data.
 type Data interface {
 	Set(data interface{}) // Copy data in. Invalid types are logged.
+	Get() interface{}     // Copy data out as []float32, []byte, or []uint16.
 	Len() int             // Number of elements.
 	Size() uint32         // Number of bytes
 }
@@ -89,6 +90,15 @@ func (vd *vertexData) Set(data interface{}) {
 	}
 }
 
+// Get returns a copy of the vertex data. Float data is returned as
+// []float32 and byte data as []byte.
+func (vd *vertexData) Get() interface{} {
+	if len(vd.bytes) > 0 {
+		return append([]byte{}, vd.bytes...)
+	}
+	return append([]float32{}, vd.floats...)
+}
+
 // Size returns the current buffer data size in bytes.
 func (vd *vertexData) Size() uint32 {
 	if len(vd.floats) > 0 {
@@ -129,6 +139,9 @@ func (fd *faceData) Set(data interface{}) {
 	}
 }
 
+// Get returns a copy of the face data as []uint16.
+func (fd *faceData) Get() interface{} { return append([]uint16{}, fd.data...) }
+
 // Size returns the size of the face data in bytes.
 func (fd *faceData) Size() uint32 { return uint32(len(fd.data)) * 2 }
 
@@ -28,6 +28,8 @@ type Draw struct {
 	Mode   int    // Points, Lines, Triangles
 	Texs   []tex  // GPU bound texture references.
 	Shtex  uint32 // GPU bound texture shadow depth map.
+	Aotex  uint32 // GPU bound ambient occlusion map.
+	Cbtex  uint32 // GPU bound cube map, ie: skybox or reflections.
 	Tag    uint64 // Application tag for debugging. Commonly an Entity id.
 
 	// Shader uniform data.
@@ -35,18 +37,27 @@ type Draw struct {
 	Floats   map[string][]float32 // Uniform values.
 
 	// Rendering hints.
+	Order   int     // Camera render order. Lower orders rendered first.
 	Bucket  int     // Used to sort draws. Lower buckets rendered first.
 	Tocam   float64 // Distance to Camera for sorting by distance.
 	Depth   bool    // True to render with depth.
+	Blend   int     // Blend mode: BlendAlpha or BlendAdd.
+	Sided   bool    // True to render back faces when culling is enabled.
 	Fbo     uint32  // Framebuffer id. 0 for default.
 	FaceCnt int32   // Number of triangles to be rendered.
 	VertCnt int32   // Number of verticies to be rendered.
 
+	// Render pass clearing. Draws with the same camera share a pass.
+	Pass  uint32     // Render pass id.
+	Clear int        // Pass clear mode. ClearDefault, ClearColor, ...
+	Cc    [4]float32 // Pass clear color for ClearColor.
+
 	// Transform data.
 	Mv   *m4   // Model View.
 	Mvp  *m4   // Model View projection.
 	Pm   *m4   // Projection only.
 	Dbm  *m4   // Depth bias matrix for shadow maps.
+	Ptm  *m4   // Projector texture matrix.
 	Pose []m34 // Per render frame of animation bone data.
 }
 
@@ -58,6 +69,7 @@ func NewDraw() *Draw {
 	d.Pm = &m4{}
 	d.Mvp = &m4{}
 	d.Dbm = &m4{}
+	d.Ptm = &m4{}
 	d.Floats = map[string][]float32{} // Float uniform values.
 	return d
 }
@@ -74,6 +86,9 @@ func (d *Draw) SetPm(pm *lin.M4) { d.Pm.tom4(pm) }
 // SetDbm sets the depth bias matrix for shadow maps.
 func (d *Draw) SetDbm(dbm *lin.M4) { d.Dbm.tom4(dbm) }
 
+// SetPtm sets the projector texture matrix for projected textures.
+func (d *Draw) SetPtm(ptm *lin.M4) { d.Ptm.tom4(ptm) }
+
 // SetScale sets the scaling factors per axis.
 func (d *Draw) SetScale(sx, sy, sz float64) {
 	d.SetFloats("scale", float32(sx), float32(sy), float32(sz))
@@ -105,6 +120,24 @@ func (d *Draw) SetHints(bucket int, toCam float64, depth bool, fbo uint32) {
 	d.Bucket, d.Tocam, d.Depth, d.Fbo = bucket, toCam, depth, fbo
 }
 
+// SetSurface sets how the draw is combined with previous draws.
+//   blend    : BlendAlpha or BlendAdd. Used when blending is enabled.
+//   twoSided : True to render back faces, ie: leaves and cloth.
+func (d *Draw) SetSurface(blend int, twoSided bool) { d.Blend, d.Sided = blend, twoSided }
+
+// SetOrder sets the camera render order. Draws are sorted by order
+// before bucket so that all the draws for one camera are rendered
+// before the draws for cameras with a higher order.
+func (d *Draw) SetOrder(order int) { d.Order = order }
+
+// SetClear sets how the render pass is cleared before its first draw.
+// The pass and framebuffer together identify a render target that is
+// cleared at most once each frame.
+func (d *Draw) SetClear(pass uint32, mode int, r, g, b, a float32) {
+	d.Pass, d.Clear = pass, mode
+	d.Cc[0], d.Cc[1], d.Cc[2], d.Cc[3] = r, g, b, a
+}
+
 // SetCounts specifies how many verticies and how many triangle
 // faces for this draw object. This must match the vertex and
 // face data.
@@ -146,6 +179,14 @@ func (d *Draw) SetTex(count, index int, tid, f0, fn uint32) {
 // SetShadowmap sets the texture id of the shadow map.
 func (d *Draw) SetShadowmap(tid uint32) { d.Shtex = tid }
 
+// SetAomap sets the texture id of the ambient occlusion map.
+// Use 0 to clear the ambient occlusion map.
+func (d *Draw) SetAomap(tid uint32) { d.Aotex = tid }
+
+// SetCubemap sets the texture id of the environment cube map.
+// Use 0 to clear the cube map.
+func (d *Draw) SetCubemap(tid uint32) { d.Cbtex = tid }
+
 // SetUniforms for the shader. String keys match the variables expected
 // by the shader source. Each shader variable is expected to have
 // corresponding values in SetFloats.
@@ -165,13 +206,16 @@ func (d *Draw) SetFloats(key string, floats ...float32) {
 
 type draws []*Draw
 
-// Sort parts ordered by bucket first, and distance next.
+// Sort parts ordered by camera order, bucket, and distance.
 func (d draws) Len() int      { return len(d) }
 func (d draws) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
 func (d draws) Less(i, j int) bool {
 	di, dj := d[i], d[j]
+	if di.Order != dj.Order {
+		return di.Order < dj.Order // First sort by camera.
+	}
 	if di.Bucket != dj.Bucket {
-		return di.Bucket < dj.Bucket // First sort into buckets.
+		return di.Bucket < dj.Bucket // Then sort into buckets.
 	}
 	if di.Bucket == Transparent {
 		if !lin.Aeq(di.Tocam, dj.Tocam) {
@@ -181,7 +225,7 @@ func (d draws) Less(i, j int) bool {
 	return di.Tag < dj.Tag // Sort by eid.
 }
 
-// SortDraws sorts draw requests by buckets then by
+// SortDraws sorts draw requests by camera order, then buckets, then by
 // distance to camera, and finally by object creation order
 // with earlier objects rendered before later objects.
 func SortDraws(frame []*Draw) { sort.Sort(draws(frame)) }
@@ -145,9 +145,11 @@ func genPreamble(gout *os.File, pkgname string, typedefs []string) {
 // Needed to create a link for the latest opengl library as follows:
 //     sudo ln -s /usr/lib/nvidia-319-updates/libGL.so.1 /usr/lib/libGL.so
 var cPreamble = []string{
-	"// #cgo darwin  LDFLAGS: -framework OpenGL", // needed to compile on OSX
-	"// #cgo linux   LDFLAGS: -lGL -ldl",         // only tested on Ubuntu
-	"// #cgo windows LDFLAGS: -lopengl32",
+	"// #cgo darwin,!ios    LDFLAGS: -framework OpenGL", // needed to compile on OSX
+	"// #cgo ios            LDFLAGS: -framework OpenGLES",
+	"// #cgo linux,!android LDFLAGS: -lGL -ldl", // only tested on Ubuntu
+	"// #cgo android        LDFLAGS: -lGLESv3 -ldl",
+	"// #cgo windows        LDFLAGS: -lopengl32",
 	"// ",
 	"// #include <stdlib.h>",
 	"// #if defined(__APPLE__)",
@@ -180,7 +182,11 @@ var cPreamble = []string{
 	"// 	return GetProcAddress(hmod, (LPCSTR)name);",
 	"// #else",
 	"// 	if(plib == NULL) {",
+	"// #ifdef __ANDROID__",
+	"// 		plib = dlopen(\"libGLESv3.so\", RTLD_LAZY);",
+	"// #else",
 	"// 		plib = dlopen(\"libGL.so\", RTLD_LAZY);",
+	"// #endif",
 	"// 	}",
 	"// 	return dlsym(plib, name);",
 	"// #endif",
@@ -8,9 +8,11 @@
 // Package gl is provided as part of the vu (virtual universe) 3D engine.
 package gl
 
-// #cgo darwin  LDFLAGS: -framework OpenGL
-// #cgo linux   LDFLAGS: -lGL -ldl
-// #cgo windows LDFLAGS: -lopengl32
+// #cgo darwin,!ios    LDFLAGS: -framework OpenGL
+// #cgo ios            LDFLAGS: -framework OpenGLES
+// #cgo linux,!android LDFLAGS: -lGL -ldl
+// #cgo android        LDFLAGS: -lGLESv3 -ldl
+// #cgo windows        LDFLAGS: -lopengl32
 //
 // #include <stdlib.h>
 // #if defined(__APPLE__)
@@ -43,7 +45,11 @@ package gl
 // 	return GetProcAddress(hmod, (LPCSTR)name);
 // #else
 // 	if(plib == NULL) {
+// #ifdef __ANDROID__
+// 		plib = dlopen("libGLESv3.so", RTLD_LAZY);
+// #else
 // 		plib = dlopen("libGL.so", RTLD_LAZY);
+// #endif
 // 	}
 // 	return dlsym(plib, name);
 // #endif
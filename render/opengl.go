@@ -16,14 +16,28 @@ import (
 // for comments. See the OpenGL documentation for OpenGL methods and constants.
 type opengl struct {
 	depthTest bool   // Track current depth setting to reduce state switching.
+	cullFace  bool   // Track the enabled backface culling setting.
+	culling   bool   // Track current culling, off for two sided draws.
+	blend     int    // Track current blend mode.
 	shader    uint32 // Track the current shader to reduce shader switching.
 	fbo       uint32 // Track current framebuffer object to reduce switching.
+	screen    uint32 // Framebuffer for the display. Not always 0 on mobile.
 	vw, vh    int32  // Remember the viewport size for framebuffer switching.
+
+	// Framebuffer texture sizes for framebuffer switching.
+	frames map[uint32]int32
+
+	// Mipmap levels for textures that provide their own mipmaps.
+	levels map[uint32]int32
+
+	// Render passes that have been cleared this frame.
+	cleared map[uint64]bool
+	bg      [4]float32 // Background clear color.
 }
 
 // newRenderer returns an OpenGL implementation of Renderer.
 func newRenderer() Renderer {
-	gc := &opengl{}
+	gc := &opengl{frames: map[uint32]int32{}, levels: map[uint32]int32{}, cleared: map[uint64]bool{}}
 	return gc
 }
 
@@ -43,12 +57,52 @@ const (
 // Renderer implementation.
 func (gc *opengl) Init() error {
 	gl.Init()
+
+	// iOS draws the display into a framebuffer from the native layer.
+	var screen int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &screen)
+	gc.screen = uint32(screen)
+	if gles {
+		return nil // OpenGL ES 3 cube maps are always seamless.
+	}
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS) // filter across cube map face edges.
 	return gc.validate()
 }
 
 // Renderer implementation.
-func (gc *opengl) Color(r, g, b, a float32) { gl.ClearColor(r, g, b, a) }
-func (gc *opengl) Clear()                   { gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT) }
+func (gc *opengl) Color(r, g, b, a float32) {
+	gc.bg = [4]float32{r, g, b, a}
+	gl.ClearColor(r, g, b, a)
+}
+func (gc *opengl) Clear() {
+	for key := range gc.cleared {
+		delete(gc.cleared, key) // new frame.
+	}
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+// clear the render pass for the given draw. Passes are only cleared
+// before their first draw each frame.
+func (gc *opengl) clear(d *Draw) {
+	key := uint64(d.Fbo)<<32 | uint64(d.Pass)
+	if gc.cleared[key] {
+		return
+	}
+	gc.cleared[key] = true
+	switch d.Clear {
+	case ClearColor:
+		gl.ClearColor(d.Cc[0], d.Cc[1], d.Cc[2], d.Cc[3])
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		gl.ClearColor(gc.bg[0], gc.bg[1], gc.bg[2], gc.bg[3])
+	case ClearDepth:
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+	case ClearNone:
+	default:
+		if d.Fbo != 0 {
+			gl.Clear(gl.DEPTH_BUFFER_BIT) // layers clear depth.
+		}
+	}
+}
 func (gc *opengl) Viewport(width int, height int) {
 	gc.vw, gc.vh = int32(width), int32(height)
 	gl.Viewport(0, 0, int32(width), int32(height))
@@ -58,6 +112,9 @@ func (gc *opengl) Viewport(width int, height int) {
 func (gc *opengl) Enable(attribute uint32, enabled bool) {
 	switch attribute {
 	case CullFace, DepthTest:
+		if attribute == CullFace {
+			gc.cullFace, gc.culling = enabled, enabled
+		}
 		if enabled {
 			gl.Enable(attribute)
 		} else {
@@ -69,6 +126,7 @@ func (gc *opengl) Enable(attribute uint32, enabled bool) {
 
 			// Using non pre-multiplied alpha color data so...
 			gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+			gc.blend = BlendAlpha
 		} else {
 			gl.Disable(attribute)
 		}
@@ -95,18 +153,39 @@ func (gc *opengl) Render(d *Draw) {
 		gc.depthTest = d.Depth
 	}
 
+	// two sided draws turn off backface culling.
+	if culling := gc.cullFace && !d.Sided; gc.culling != culling {
+		if culling {
+			gl.Enable(gl.CULL_FACE)
+		} else {
+			gl.Disable(gl.CULL_FACE)
+		}
+		gc.culling = culling
+	}
+	if gc.blend != d.Blend {
+		switch d.Blend {
+		case BlendAdd:
+			gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+		default:
+			gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		}
+		gc.blend = d.Blend
+	}
+
 	// switch render framebuffer only if necessary. The framebuffer
 	// is used to render to a texture associated with a framebuffer.
 	if gc.fbo != d.Fbo {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, d.Fbo)
 		if d.Fbo == 0 {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, gc.screen)
 			gl.Viewport(0, 0, gc.vw, gc.vh)
 		} else {
-			gl.Clear(gl.DEPTH_BUFFER_BIT)
-			gl.Viewport(0, 0, LayerSize, LayerSize) // framebuffer texture.
+			gl.BindFramebuffer(gl.FRAMEBUFFER, d.Fbo)
+			size := gc.frames[d.Fbo]
+			gl.Viewport(0, 0, size, size) // framebuffer texture.
 		}
 		gc.fbo = d.Fbo
 	}
+	gc.clear(d)
 
 	// switch shaders only if necessary.
 	if gc.shader != d.Shader {
@@ -122,10 +201,18 @@ func (gc *opengl) Render(d *Draw) {
 	gl.BindVertexArray(d.Vao)
 	switch d.Mode {
 	case Lines:
+		if gles {
+			gl.DrawElements(gl.LINES, d.FaceCnt, gl.UNSIGNED_SHORT, 0)
+			break
+		}
 		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 		gl.DrawElements(gl.LINES, d.FaceCnt, gl.UNSIGNED_SHORT, 0)
 		gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
 	case Points:
+		if gles {
+			gl.DrawArrays(gl.POINTS, 0, d.VertCnt) // gl_PointSize always on.
+			break
+		}
 		gl.Enable(gl.PROGRAM_POINT_SIZE)
 		gl.DrawArrays(gl.POINTS, 0, d.VertCnt)
 		gl.Disable(gl.PROGRAM_POINT_SIZE)
@@ -147,6 +234,8 @@ func (gc *opengl) bindUniforms(d *Draw) {
 			gc.bindUniform(ref, x4, 1, d.Dbm.Pointer())
 		case "pm":
 			gc.bindUniform(ref, x4, 1, d.Pm.Pointer())
+		case "ptm":
+			gc.bindUniform(ref, x4, 1, d.Ptm.Pointer())
 		case "uv":
 			gc.useTexture(ref, 0, d.Texs[0].tid)
 		case "uv0":
@@ -181,6 +270,10 @@ func (gc *opengl) bindUniforms(d *Draw) {
 			gc.useTexture(ref, 14, d.Texs[14].tid)
 		case "sm":
 			gc.useTexture(ref, 15, d.Shtex) // always use 15 for shadow maps.
+		case "ao":
+			gc.useTexture(ref, int32(len(d.Texs)), d.Aotex) // first unit after the uvN textures.
+		case "cube":
+			gc.useCubeMap(ref, int32(len(d.Texs)+1), d.Cbtex) // unit after the occlusion map.
 		case "bpos": // bone position animation data.
 			if d.Pose != nil && len(d.Pose) > 0 {
 				gc.bindUniform(ref, x34, len(d.Pose), d.Pose[0].Pointer())
@@ -324,6 +417,9 @@ func (gc *opengl) bindFaceBuffer(fdata Data) {
 func (gc *opengl) BindShader(vsh, fsh []string, uniforms map[string]int32,
 	layouts map[string]uint32) (program uint32, err error) {
 	program = gl.CreateProgram()
+	if gles {
+		vsh, fsh = esShader(vsh), esShader(fsh)
+	}
 
 	// compile and link the shader program.
 	if glerr := gl.BindProgram(program, vsh, fsh); glerr != nil {
@@ -340,6 +436,20 @@ func (gc *opengl) BindShader(vsh, fsh []string, uniforms map[string]int32,
 	return
 }
 
+// esShader returns a copy of the OpenGL 3.3 shader source with the
+// version changed to OpenGL ES 3. ES fragment shaders need a default
+// float precision, which is harmless in vertex shaders.
+func esShader(src []string) []string {
+	es := make([]string, len(src))
+	for cnt, line := range src {
+		if strings.HasPrefix(strings.TrimSpace(line), "#version") {
+			line = "#version 300 es\nprecision highp float;\n"
+		}
+		es[cnt] = line
+	}
+	return es
+}
+
 // Renderer implementation.
 // BindTexture makes the texture available on the GPU.
 func (gc *opengl) BindTexture(tid *uint32, img image.Image) (err error) {
@@ -357,18 +467,51 @@ func (gc *opengl) BindTexture(tid *uint32, img image.Image) (err error) {
 	var ptr gl.Pointer
 	bounds := img.Bounds()
 	width, height := int32(bounds.Dx()), int32(bounds.Dy())
+	delete(gc.levels, *tid)
 	switch imgType := img.(type) {
 	case *image.RGBA:
 		i := img.(*image.RGBA)
 		ptr = gl.Pointer(&(i.Pix[0]))
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, ptr)
 	case *image.NRGBA:
 		i := img.(*image.NRGBA)
 		ptr = gl.Pointer(&(i.Pix[0]))
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, ptr)
+	case floatImage: // high dynamic range.
+		pix := imgType.FloatPix()
+		ptr = gl.Pointer(&pix[0])
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, width, height, 0, gl.RGBA, gl.FLOAT, ptr)
+	case compressedImage: // includes its own mipmaps.
+		format, levels := imgType.Compressed()
+		for lvl, data := range levels {
+			w, h := width>>uint(lvl), height>>uint(lvl)
+			if w < 1 {
+				w = 1
+			}
+			if h < 1 {
+				h = 1
+			}
+			gl.CompressedTexImage2D(gl.TEXTURE_2D, int32(lvl), format, w, h, 0, int32(len(data)), gl.Pointer(&data[0]))
+		}
+		gc.levels[*tid] = int32(len(levels) - 1)
+	case mipImage: // includes its own mipmaps.
+		levels := imgType.Mipmaps()
+		for lvl, level := range levels {
+			i, ok := level.(*image.NRGBA)
+			if !ok {
+				return fmt.Errorf("Unsupported mipmap format %T", level)
+			}
+			b := i.Bounds()
+			ptr = gl.Pointer(&(i.Pix[0]))
+			gl.TexImage2D(gl.TEXTURE_2D, int32(lvl), gl.RGBA, int32(b.Dx()), int32(b.Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, ptr)
+		}
+		gc.levels[*tid] = int32(len(levels) - 1)
 	default:
 		return fmt.Errorf("Unsupported image format %T", imgType)
 	}
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, ptr)
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+	if _, ok := gc.levels[*tid]; !ok {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
 	gc.SetTextureMode(*tid, false) // no repeat by default.
 	if glerr := gl.GetError(); glerr != gl.NO_ERROR {
 		err = fmt.Errorf("Failed binding texture %d\n", glerr)
@@ -376,11 +519,75 @@ func (gc *opengl) BindTexture(tid *uint32, img image.Image) (err error) {
 	return err
 }
 
+// Renderer implementation.
+// BindCubeMap makes the six cube map faces available on the GPU as one
+// cube texture. Faces are expected to be square and the same size.
+func (gc *opengl) BindCubeMap(tid *uint32, faces [6]image.Image) (err error) {
+	if glerr := gl.GetError(); glerr != gl.NO_ERROR {
+		log.Printf("opengl:bindCubeMap need to find and fix prior error %X", glerr)
+	}
+	if *tid == 0 {
+		gl.GenTextures(1, tid)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, *tid)
+	for cnt, img := range faces {
+		if img == nil {
+			return fmt.Errorf("Missing cube map face %d", cnt)
+		}
+		target := uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X + cnt)
+		bounds := img.Bounds()
+		width, height := int32(bounds.Dx()), int32(bounds.Dy())
+		switch imgType := img.(type) {
+		case *image.RGBA:
+			gl.TexImage2D(target, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Pointer(&imgType.Pix[0]))
+		case *image.NRGBA:
+			gl.TexImage2D(target, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Pointer(&imgType.Pix[0]))
+		case floatImage: // high dynamic range.
+			pix := imgType.FloatPix()
+			gl.TexImage2D(target, 0, gl.RGBA16F, width, height, 0, gl.RGBA, gl.FLOAT, gl.Pointer(&pix[0]))
+		default:
+			return fmt.Errorf("Unsupported cube map face format %T", imgType)
+		}
+	}
+	gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	if glerr := gl.GetError(); glerr != gl.NO_ERROR {
+		err = fmt.Errorf("Failed binding cube map %d\n", glerr)
+	}
+	return err
+}
+
+// floatImage is a high dynamic range image, ie: load.FloatImage.
+type floatImage interface {
+	image.Image
+	FloatPix() []float32 // R,G,B,A values.
+}
+
+// compressedImage is a GPU compressed image, ie: load.CompressedImage.
+type compressedImage interface {
+	image.Image
+	Compressed() (format uint32, levels [][]byte)
+}
+
+// mipImage is an image with its own mipmap levels, ie: load.MipImage.
+type mipImage interface {
+	image.Image
+	Mipmaps() []image.Image // Largest to smallest.
+}
+
 // SetTextureMode is used to switch to a clamped
 // texture instead of a repeating texture.
 func (gc *opengl) SetTextureMode(tid uint32, clamp bool) {
 	gl.BindTexture(gl.TEXTURE_2D, tid)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 7)
+	maxLevel, ok := gc.levels[tid]
+	if !ok {
+		maxLevel = 7 // generated mipmaps.
+	}
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, maxLevel)
 	if clamp {
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
@@ -395,10 +602,11 @@ func (gc *opengl) SetTextureMode(tid uint32, clamp bool) {
 // BindFrame creates a framebuffer object with an associated texture.
 //    http://www.opengl-tutorial.org/intermediate-tutorials/tutorial-14-render-to-texture/
 //    http://www.opengl-tutorial.org/intermediate-tutorials/tutorial-16-shadow-mapping/
-func (gc *opengl) BindFrame(buf int, fbo, tid, db *uint32) (err error) {
-	size := int32(LayerSize)
+func (gc *opengl) BindFrame(buf, fsize int, fbo, tid, db *uint32) (err error) {
+	size := int32(fsize)
 	gl.GenFramebuffers(1, fbo)
 	gl.BindFramebuffer(gl.FRAMEBUFFER, *fbo)
+	gc.frames[*fbo] = size
 
 	// Create a texture specifically for the framebuffer.
 	gl.GenTextures(1, tid)
@@ -410,29 +618,38 @@ func (gc *opengl) BindFrame(buf int, fbo, tid, db *uint32) (err error) {
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 
-		// Add a depth buffer to mimic the normal framebuffer behaviour for 3D objects.
-		gl.GenRenderbuffers(1, db)
-		gl.BindRenderbuffer(gl.RENDERBUFFER, *db)
-		gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, size, size)
-		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, *db)
+		// Add a depth texture to mimic the normal framebuffer behaviour for 3D
+		// objects. A texture, instead of a renderbuffer, lets shaders read depth.
+		gl.GenTextures(1, db)
+		gl.BindTexture(gl.TEXTURE_2D, *db)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24, size, size,
+			0, gl.DEPTH_COMPONENT, gl.UNSIGNED_INT, gl.Pointer(nil))
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, *db, 0)
 
 		// Associate the texture with the framebuffer.
-		gl.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, *tid, 0)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, *tid, 0)
 		buffType := uint32(gl.COLOR_ATTACHMENT0)
 		gl.DrawBuffers(1, &buffType)
 	case DepthBuffer:
 		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT16, size, size,
-			0, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Pointer(nil))
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+			0, gl.DEPTH_COMPONENT, gl.UNSIGNED_INT, gl.Pointer(nil))
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+
+		// Raw depth values, not comparisons, are returned so that shaders
+		// can do their own shadow filtering. See Light.ShadowFilter.
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.NONE)
 
 		// Associate the texture with the framebuffer.
-		gl.FramebufferTexture(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, *tid, 0)
-		gl.DrawBuffer(gl.NONE)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, *tid, 0)
+		buffType := uint32(gl.NONE) // OpenGL ES 3 has no DrawBuffer.
+		gl.DrawBuffers(1, &buffType)
 	default:
 		return fmt.Errorf("BindFrame unrecognized buffer type.")
 	}
@@ -445,7 +662,7 @@ func (gc *opengl) BindFrame(buf int, fbo, tid, db *uint32) (err error) {
 	if glerr := gl.GetError(); glerr != gl.NO_ERROR {
 		err = fmt.Errorf("Failed binding framebuffer %X", glerr)
 	}
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0) // clean up by resetting to default framebuffer.
+	gl.BindFramebuffer(gl.FRAMEBUFFER, gc.screen) // clean up by resetting to default framebuffer.
 	return err
 }
 
@@ -505,12 +722,23 @@ func (gc *opengl) useTexture(sampler, texUnit int32, tid uint32) {
 	gl.BindTexture(gl.TEXTURE_2D, tid)
 }
 
+// useCubeMap makes the given cube map the active texture.
+func (gc *opengl) useCubeMap(sampler, texUnit int32, tid uint32) {
+	gc.bindUniform(sampler, i1, 1, texUnit)
+	gl.ActiveTexture(gl.TEXTURE0 + uint32(texUnit))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, tid)
+}
+
 // Remove graphic resources.
-func (gc *opengl) ReleaseMesh(vao uint32)    { gl.DeleteVertexArrays(1, &vao) }
-func (gc *opengl) ReleaseShader(sid uint32)  { gl.DeleteProgram(sid) }
-func (gc *opengl) ReleaseTexture(tid uint32) { gl.DeleteTextures(1, &tid) }
+func (gc *opengl) ReleaseMesh(vao uint32)   { gl.DeleteVertexArrays(1, &vao) }
+func (gc *opengl) ReleaseShader(sid uint32) { gl.DeleteProgram(sid) }
+func (gc *opengl) ReleaseTexture(tid uint32) {
+	gl.DeleteTextures(1, &tid)
+	delete(gc.levels, tid)
+}
 func (gc *opengl) ReleaseFrame(fbo, tid, db uint32) {
 	gl.DeleteFramebuffers(1, &fbo)
+	delete(gc.frames, fbo)
 	gl.DeleteTextures(1, &tid)
-	gl.DeleteRenderbuffers(1, &db)
+	gl.DeleteTextures(1, &db)
 }
@@ -0,0 +1,9 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build !android,!ios
+
+package render
+
+// gles is false when rendering with desktop OpenGL 3.3.
+const gles = false
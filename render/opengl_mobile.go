@@ -0,0 +1,11 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+// +build android ios
+
+package render
+
+// gles is true when rendering with OpenGL ES 3 on mobile devices.
+// OpenGL ES 3 is a subset of OpenGL 3.3, so the shaders and a few
+// desktop only calls are adjusted when gles is true.
+const gles = true
@@ -35,21 +35,23 @@ type Renderer interface {
 	BindShader(vsh, fsh []string, uniforms map[string]int32,
 		layouts map[string]uint32) (program uint32, err error)
 	BindTexture(tid *uint32, img image.Image) error
+	BindCubeMap(tid *uint32, faces [6]image.Image) error // +X,-X,+Y,-Y,+Z,-Z
 	SetTextureMode(tid uint32, clamp bool)
 	Render(d *Draw) // Render bound data, textures with bound shaders.
 
 	// BindFrame creates a framebuffer object with an associated texture.
 	//   buf : DEPTH_BUFF, for depth, or IMAGE_BUFF, for color and depth.
+	//   size: texture width and height in pixels, ie: LayerSize.
 	//   fbo : returned frame buffer object identifier.
 	//   tid : returned texture identifier.
-	//   db  : returned depth buffer render buffer.
-	BindFrame(buf int, fbo, tid, db *uint32) error
+	//   db  : returned depth texture for IMAGE_BUFF.
+	BindFrame(buf, size int, fbo, tid, db *uint32) error
 
 	// Releasing frees up previous bound graphics card data.
 	ReleaseMesh(vao uint32)           // Free bound vao reference.
 	ReleaseShader(sid uint32)         // Free bound shader reference.
 	ReleaseTexture(tid uint32)        // Free bound texture reference.
-	ReleaseFrame(fbo, tid, db uint32) // Free framebuffer and texture.
+	ReleaseFrame(fbo, tid, db uint32) // Free framebuffer and textures.
 }
 
 // New provides the render implementation as determined by the build.
@@ -71,5 +73,19 @@ const (
 	// BindFrame buffer types.
 	DepthBuffer        // For depth only.
 	ImageBuffer        // For color and depth.
-	LayerSize   = 1024 // Render pass texture size.
+	LayerSize   = 1024 // Default render pass texture size.
+)
+
+// Blend modes for transparent draws. Used in Draw.SetSurface.
+const (
+	BlendAlpha = iota // Source alpha over the destination. Default.
+	BlendAdd          // Source added to the destination, ie: glows.
+)
+
+// Clear modes for the first draw of each render pass. Used in Draw.SetClear.
+const (
+	ClearDefault = iota // Screen cleared with background. Layers clear depth.
+	ClearColor          // Clear color and depth using the pass clear color.
+	ClearDepth          // Clear depth only. Keep previous pass colors.
+	ClearNone           // No clear. Draw over previous passes.
 )
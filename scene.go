@@ -0,0 +1,88 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// scene.go creates Pov hierarchies from imported scene files.
+// DESIGN: The scene file is read on the engine goroutine since the Pov
+//         hierarchy is needed immediately. The scene meshes, materials,
+//         and embedded images are generated model data, see Model.Make,
+//         leaving the shaders and external textures to the loader.
+
+import (
+	"fmt"
+
+	"github.com/gazed/vu/load"
+	"github.com/gazed/vu/math/lin"
+)
+
+// loadModel imports the named scene and attaches it to the given Pov.
+func (eng *engine) loadModel(p *Pov, name string) error {
+	if eng.loc == nil {
+		eng.loc = load.NewLocator()
+	}
+	scn := &load.ScnData{}
+	if err := scn.Load(name, eng.loc); err != nil {
+		return fmt.Errorf("LoadModel %s: %s", name, err)
+	}
+	nodes := make([]*Pov, len(scn.Nodes))
+	for cnt, n := range scn.Nodes {
+		parent := p
+		if n.Parent >= 0 && n.Parent < cnt {
+			parent = nodes[n.Parent]
+		}
+		pov := parent.NewPov()
+		pov.SetAt(n.Loc.X, n.Loc.Y, n.Loc.Z)
+		pov.SetView(&n.Rot)
+		pov.SetScale(n.Scale.X, n.Scale.Y, n.Scale.Z)
+		nodes[cnt] = pov
+
+		// Each Pov has one model so nodes with multiple meshes
+		// use child Pov's for the meshes.
+		for _, mi := range n.Meshes {
+			mp := pov
+			if len(n.Meshes) > 1 {
+				mp = pov.NewPov()
+			}
+			eng.sceneModel(mp, scn, &scn.Meshes[mi])
+		}
+	}
+	return nil
+}
+
+// sceneModel creates a model from an imported scene mesh.
+func (eng *engine) sceneModel(p *Pov, scn *load.ScnData, sm *load.ScnMesh) {
+	shader := "phong"
+	switch {
+	case sm.Skin >= 0:
+		shader = "anim"
+	case len(sm.TMap) > 0:
+		shader = "uv"
+	}
+	m := eng.models.create(p.id, shader)
+	m.Make("msh:" + sm.Name)
+	if sm.Skin >= 0 {
+		m.anm = newAnimation(sm.Name)
+		transferAnim(&sm.ModData, m.msh, m.anm)
+		m.nFrames = m.anm.maxFrames(0)
+		m.pose = make([]lin.M4, len(m.anm.joints))
+	} else {
+		transferMesh(&sm.MshData, m.msh)
+	}
+	m.Mesh() // bind the generated mesh once the model is loaded.
+
+	// lighting colors.
+	m.mat = newMaterial(sm.Name)
+	transferMaterial(&sm.MtlData, m.mat)
+	m.alpha = float64(m.mat.tr)
+
+	// embedded images are generated textures.
+	for _, tm := range sm.TMap {
+		if img, ok := scn.Images[tm.Name]; ok {
+			m.Make("tex:" + tm.Name)
+			m.Tex(len(m.texs) - 1).Set(img.Img)
+		} else {
+			m.Load("tex:" + tm.Name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/load"
+	"github.com/gazed/vu/math/lin"
+)
+
+// Scene meshes become generated models with lighting colors.
+func TestSceneModel(t *testing.T) {
+	eng := newEngine(nil)
+	scn := &load.ScnData{Meshes: []load.ScnMesh{{Skin: -1}}}
+	sm := &scn.Meshes[0]
+	sm.Name, sm.V, sm.F = "tri", []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, []uint16{0, 1, 2}
+	sm.KdR, sm.Alpha = 0.5, 0.25
+	p := eng.root().NewPov()
+	eng.sceneModel(p, scn, sm)
+	m := eng.models.get(p.id)
+	if _, ok := m.assets[assetID(shd, "phong")]; !ok || m.msh == nil || m.msh.name != "tri" {
+		t.Fatalf("Expected phong model with mesh")
+	}
+	if m.mat.kd.R != 0.5 || m.alpha != 0.25 {
+		t.Errorf("Expected material colors, got %f %f", m.mat.kd.R, m.alpha)
+	}
+}
+
+// Skinned scene meshes keep their joint names for lookups.
+func TestSceneJoints(t *testing.T) {
+	eng := newEngine(nil)
+	scn := &load.ScnData{Meshes: []load.ScnMesh{{Skin: 0}}}
+	sm := &scn.Meshes[0]
+	sm.Name, sm.V, sm.F = "arm", []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, []uint16{0, 1, 2}
+	sm.Joints, sm.Names = []int32{-1, 0}, []string{"shoulder", "hand"}
+	sm.Frames = []*lin.M4{lin.NewM4I(), lin.NewM4I()}
+	sm.Movements = []load.Movement{{Name: "rest", F0: 0, Fn: 1, Rate: 30}}
+	p := eng.root().NewPov()
+	eng.sceneModel(p, scn, sm)
+	m := eng.models.get(p.id)
+	if m.Joint("hand") != 1 || m.Joint("foot") != -1 || len(m.Joints()) != 2 {
+		t.Errorf("Expected joint lookups, got %v", m.Joints())
+	}
+	if len(m.Actions()) != 1 || len(m.pose) != 2 {
+		t.Errorf("Expected rest action with 2 poses, got %v %d", m.Actions(), len(m.pose))
+	}
+}
@@ -93,16 +93,50 @@ var shaderLibrary = map[string]func() (vsh, fsh []string){
 	"uvc":     uvcShader,
 	"bump":    bumpShader,
 	"nmap":    nmapShader,
+	"tnmap":   tnmapShader,
 	"bb":      bbShader,
 	"bbr":     bbrShader,
 	"anim":    animShader,
 	"depth":   depthShader,
 	"shadow":  shadowShader,
+	"sky":     skyShader,
+	"skybox":  skyboxShader,
+	"rays":    raysShader,
+	"proj":    projShader,
+	"gi":      giShader,
+	"tonemap": tonemapShader,
+	"dof":     dofShader,
 }
 
 // FUTURE: Add edge-detect and emboss shaders, see:
 //         http://www.processing.org/tutorials/pshader/
 
+// logDepthSource is vertex shader code shared by the shaders that
+// support a logarithmic depth buffer, see Camera.SetLogDepth. Vertex
+// shaders pass their clip space position through logDepth.
+var logDepthSource = []string{
+	"uniform float logz;", // logarithmic depth scale. 0 for standard depth.
+	"vec4 logDepth(vec4 p) {",
+	"   if (logz > 0.0) {",
+	"      p.z = (log2(max(1e-6, 1.0+p.w))*logz - 1.0) * p.w;",
+	"   }",
+	"   return p;",
+	"}",
+}
+
+// logDepth adds the shared logarithmic depth code to the given vertex
+// shader source just before its main function.
+func logDepth(vsh []string) []string {
+	for cnt, line := range vsh {
+		if strings.HasPrefix(line, "void main") {
+			src := append([]string{}, vsh[:cnt]...)
+			src = append(src, logDepthSource...)
+			return append(src, vsh[cnt:]...)
+		}
+	}
+	return vsh
+}
+
 // ===========================================================================
 
 // solidShader shades all verticies the given diffuse color.
@@ -115,7 +149,7 @@ func solidShader() (vsh, fsh []string) {
 		"uniform vec3 kd;",   // material diffuse value
 		"out     vec4 v_c;",  // vertex color
 		"void main(void) {",
-		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"   gl_Position = logDepth(mvpm * vec4(in_v, 1.0));",
 		"	v_c = vec4(kd, 1.0);",
 		"}",
 	}
@@ -127,7 +161,7 @@ func solidShader() (vsh, fsh []string) {
 		"   ffc = v_c;",
 		"}",
 	}
-	return vsh, fsh
+	return logDepth(vsh), fsh
 }
 
 // ===========================================================================
@@ -179,12 +213,12 @@ func diffuseShader() (vsh, fsh []string) {
 		"uniform float alpha;", // transparency
 		"out     vec4  v_c;",   // vertex color
 		"void main() {",
-		"   vec4 vpos = vec4(in_v, 1.0);",                    // vertex in model space.
+		"   vec4 vpos = vec4(in_v, 1.0);", // vertex in model space.
 		"   vec3 nm = normalize((mvm * vec4(in_n, 0)).xyz);", // unit normal in world space.
 		"   vec3 lightDir = normalize(lp - vec3(mvm*vpos));",
 		"   vec3 color = lc * kd * max(dot(lightDir, nm), 0.0);",
-		"   v_c = vec4(color, alpha);",  // pass on the amount of diffuse light.
-		"   gl_Position = mvpm * vpos;", // pass on the transformed vertex position
+		"   v_c = vec4(color, alpha);",            // pass on the amount of diffuse light.
+		"   gl_Position = logDepth(mvpm * vpos);", // pass on the transformed vertex position
 		"}",
 	}
 	fsh = []string{
@@ -195,7 +229,7 @@ func diffuseShader() (vsh, fsh []string) {
 		"   ffc = v_c;",
 		"}",
 	}
-	return vsh, fsh
+	return logDepth(vsh), fsh
 }
 
 // ===========================================================================
@@ -223,7 +257,7 @@ func gouraudShader() (vsh, fsh []string) {
 		"uniform float alpha;", // transparency
 		"out     vec4  v_c;",   // vertex color
 		"void main() {",
-		"   vec4 vmod = vec4(in_v, 1.0);",                    // vertex in model space.
+		"   vec4 vmod = vec4(in_v, 1.0);", // vertex in model space.
 		"   vec3 nm = normalize((mvm * vec4(in_n, 0)).xyz);", // unit normal in world space.
 		"   vec4 vworld = mvm * vmod;",                       // vertex in world space
 		"   vec3 s = normalize(lp - vworld.xyz);",            // light vector
@@ -237,7 +271,7 @@ func gouraudShader() (vsh, fsh []string) {
 		"      spec = lc * ks * pow( max( dot(r,v), 0.0 ), ns );",
 		"   vec3 color = ambient + diffuse + spec;", // combine all the values.
 		"   v_c = vec4(color, alpha);",              // pass on the vertex color
-		"   gl_Position = mvpm * vmod;",             // pass on the transformed vertex
+		"   gl_Position = logDepth(mvpm * vmod);",   // pass on the transformed vertex
 		"}",
 	}
 	fsh = []string{
@@ -248,7 +282,7 @@ func gouraudShader() (vsh, fsh []string) {
 		"   ffc = v_c;",
 		"}",
 	}
-	return vsh, fsh
+	return logDepth(vsh), fsh
 }
 
 // ===========================================================================
@@ -276,7 +310,7 @@ func phongShader() (vsh, fsh []string) {
 		"   v_s = normalize(lp - vworld.xyz);",           // light vector
 		"   v_e = normalize(-vworld.xyz);",               // view vector
 		"   v_n = normalize((mvm * vec4(in_n, 0)).xyz);", // unit normal in world space.
-		"   gl_Position = mvpm * vmod;",                  // vertex in clip space",
+		"   gl_Position = logDepth(mvpm * vmod);",        // vertex in clip space",
 		"}",
 	}
 	fsh = []string{
@@ -303,7 +337,7 @@ func phongShader() (vsh, fsh []string) {
 		"   ffc = vec4(color, alpha);",              // final fragment color
 		"}",
 	}
-	return vsh, fsh
+	return logDepth(vsh), fsh
 }
 
 // ===========================================================================
@@ -311,6 +345,9 @@ func phongShader() (vsh, fsh []string) {
 // sdfShader displays signed distance field fonts.
 //           This shader expects a font image file signed distance field
 //           values for the font images - it makes the images look blurry.
+//           The edge smoothing adapts to the screen size of the text.
+//           Optional outline and glow colors are drawn outside the edge
+//           where the width and size are in field units from 0 to 0.5.
 func sdfShader() (vsh, fsh []string) {
 	vsh = []string{
 		"#version 330",
@@ -330,17 +367,27 @@ func sdfShader() (vsh, fsh []string) {
 	}
 	fsh = []string{
 		"#version 330",
-		"in      vec2      t_uv;",  // texture coords.
-		"in      vec3      v_c;",   // color from vertex shader
-		"uniform sampler2D uv;",    // 2D texture sampler.
-		"uniform float     alpha;", // transparency
-		"out     vec4      ffc; ",  // final fragment color.
+		"in      vec2      t_uv;",    // texture coords.
+		"in      vec3      v_c;",     // color from vertex shader
+		"uniform sampler2D uv;",      // 2D texture sampler.
+		"uniform float     alpha;",   // transparency
+		"uniform vec4      outline;", // outline color and width.
+		"uniform vec4      glow;",    // glow color and size.
+		"out     vec4      ffc; ",    // final fragment color.
 		"",
-		"const float smoothing = 1.0/16.0;",
 		"void main() {",
 		"   float distance = texture(uv, t_uv).a;", // distance field value.
-		"   float clamp = smoothstep(0.5 - smoothing, 0.5 + smoothing, distance);",
-		"   ffc = vec4(v_c, alpha*clamp);",
+		"   float smoothing = max(fwidth(distance), 1.0/255.0);", // about one pixel.
+		"   float fill = smoothstep(0.5 - smoothing, 0.5 + smoothing, distance);",
+		"   float edge = 0.5 - outline.a;",
+		"   float line = smoothstep(edge - smoothing, edge + smoothing, distance);",
+		"   float halo = 0.0;",
+		"   if (glow.a > 0.0) {",
+		"      halo = smoothstep(edge - glow.a, edge, distance) * (1.0 - line);",
+		"   }",
+		"   vec3 text = mix(outline.rgb, v_c, fill/max(line, 1e-4));", // fill over outline.
+		"   vec3 color = mix(glow.rgb, text, line/max(line+halo, 1e-4));",
+		"   ffc = vec4(color, alpha*(line+halo));",
 		"}",
 	}
 	return vsh, fsh
@@ -393,7 +440,7 @@ func uvShader() (vsh, fsh []string) {
 		"uniform mat4  mvpm;", // projection * model_view
 		"out     vec2  t_uv;", // pass uv coordinates through
 		"void main() {",
-		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"   gl_Position = logDepth(mvpm * vec4(in_v, 1.0));",
 		"   t_uv = in_t;",
 		"}",
 	}
@@ -408,7 +455,7 @@ func uvShader() (vsh, fsh []string) {
 		"   ffc.a *= alpha;",
 		"}",
 	}
-	return vsh, fsh
+	return logDepth(vsh), fsh
 }
 
 // ===========================================================================
@@ -487,13 +534,13 @@ func nmapShader() (vsh, fsh []string) {
 		"out vec3 v_v;",      // view vector: ie: camera to vertex
 		"",
 		"void main() {",
-		"	vec4 vmod = vec4(in_v, 1.0);", // vertex in local model space.
-		"	vec4 vcam = mvm * vmod;", // vertex in camera view space
-		"	v_l = normalize(lp - vcam.xyz);", // normalized vertex to light vector
-		"	v_v = -vcam.xyz;", // non-normalized vertex view vector in view space
-		"   v_n = (mvm * vec4(in_n, 0)).xyz;", // non-normalized vertex normal in view space.
-		"   t_uv = in_t;",                     // vertex UV texture coordinates.
-		"   gl_Position = mvpm * vmod;",       // vertex position in clip space.
+		"	vec4 vmod = vec4(in_v, 1.0);",           // vertex in local model space.
+		"	vec4 vcam = mvm * vmod;",                // vertex in camera view space
+		"	v_l = normalize(lp - vcam.xyz);",        // normalized vertex to light vector
+		"	v_v = -vcam.xyz;",                       // non-normalized vertex view vector in view space
+		"   v_n = (mvm * vec4(in_n, 0)).xyz;",     // non-normalized vertex normal in view space.
+		"   t_uv = in_t;",                         // vertex UV texture coordinates.
+		"   gl_Position = logDepth(mvpm * vmod);", // vertex position in clip space.
 		"}",
 	}
 
@@ -524,6 +571,8 @@ func nmapShader() (vsh, fsh []string) {
 		"uniform sampler2D uv;",  // base diffuse color
 		"uniform sampler2D uv1;", // normal map texture in tangent space
 		"uniform sampler2D uv2;", // specular texture
+		"uniform sampler2D ao;",  // optional baked ambient occlusion.
+		"uniform float     aon;", // 1 if there is an ambient occlusion map.
 		"uniform vec3      lc;",  // light color
 		"uniform vec3      ka;",  // material ambient color
 		"uniform vec3      kd;",  // material diffuse color
@@ -556,7 +605,8 @@ func nmapShader() (vsh, fsh []string) {
 		"    vec3 normal = normalize(v_n);",
 		"    normal = perturb_normal(normal, v_v, t_uv);", // normal in view space.
 		"    vec3 nv_v = normalize(v_v);",
-		"    vec3 ambient = lc * ka;",
+		"    float occ = mix(1.0, texture(ao, t_uv).r, aon);", // ambient occlusion.
+		"    vec3 ambient = lc * ka * occ;",
 		"    float intensity = max(dot(v_l, normal), 0.0);",
 		"    vec3 diffuse = lc * kd * intensity;",
 		"", // Blinn-Phong half vector.
@@ -565,13 +615,94 @@ func nmapShader() (vsh, fsh []string) {
 		"    float specFac = pow(clamp(specAngle, 0.0, 1.0), ns);",
 		"    vec3 smap = texture(uv2, t_uv).rgb;",
 		"    vec3 specular = lc * ks * smap * specFac;",
-		"", // Combine into final fragment color.
-		"    vec4 t = texture(uv, t_uv);",                            // pure texture color.
+		"",                                // Combine into final fragment color.
+		"    vec4 t = texture(uv, t_uv);", // pure texture color.
 		"    vec3 color = ambient*t.rgb + diffuse*t.rgb + specular;", // combine all the values.
 		"    ffc = vec4(color, t.a);",                                // final fragment color
 		" }",
 	}
-	return vsh, fsh
+	return logDepth(vsh), fsh
+}
+
+// ===========================================================================
+
+// tnmap is a normal map shader that uses the vertex tangents of the mesh,
+// see load.MshData.GenTangents, instead of generating the cotangent frame
+// for each pixel. The tangent w component gives the handedness of the
+// bitangent. Lighting matches the nmap shader.
+func tnmapShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330 core",
+		"layout(location = 0) in vec3 in_v;", // vertex position in modelspace
+		"layout(location = 1) in vec3 in_n;", // vertex normal in modespace
+		"layout(location = 2) in vec2 in_t;", // vertex uv texture coordinates
+		"layout(location = 6) in vec4 in_x;", // vertex tangent and handedness.
+		"",
+		"uniform mat4 mvpm;", // modelViewProject matrix for clipspace transform.
+		"uniform mat4 mvm;",  // modelView matrix (and 3x3 normal matrix). Local -> view space.
+		"uniform vec3 lp;",   // directional light.
+		"out vec2 t_uv;",     // vertex texture coords
+		"out vec3 v_n;",      // vertex normal
+		"out vec3 v_x;",      // vertex tangent
+		"out vec3 v_b;",      // vertex bitangent
+		"out vec3 v_l;",      // light to vertex vector
+		"out vec3 v_v;",      // view vector: ie: camera to vertex
+		"",
+		"void main() {",
+		"	vec4 vmod = vec4(in_v, 1.0);",           // vertex in local model space.
+		"	vec4 vcam = mvm * vmod;",                // vertex in camera view space
+		"	v_l = normalize(lp - vcam.xyz);",        // normalized vertex to light vector
+		"	v_v = -vcam.xyz;",                       // non-normalized vertex view vector in view space
+		"   v_n = (mvm * vec4(in_n, 0)).xyz;",     // non-normalized vertex normal in view space.
+		"   v_x = (mvm * vec4(in_x.xyz, 0)).xyz;", // non-normalized vertex tangent in view space.
+		"   v_b = cross(v_n, v_x) * in_x.w;",      // bitangent from the tangent handedness.
+		"   t_uv = in_t;",                         // vertex UV texture coordinates.
+		"   gl_Position = logDepth(mvpm * vmod);", // vertex position in clip space.
+		"}",
+	}
+	fsh = []string{
+		"#version 330 core",
+		"in vec2 t_uv;",          // vertex texture coords
+		"in vec3 v_n;",           // non-normalized vertex normal
+		"in vec3 v_x;",           // non-normalized vertex tangent
+		"in vec3 v_b;",           // non-normalized vertex bitangent
+		"in vec3 v_v;",           // non-normalized view vector
+		"in vec3 v_l;",           // normalized light to vertex vector
+		"uniform sampler2D uv;",  // base diffuse color
+		"uniform sampler2D uv1;", // normal map texture in tangent space
+		"uniform sampler2D uv2;", // specular texture
+		"uniform sampler2D ao;",  // optional baked ambient occlusion.
+		"uniform float     aon;", // 1 if there is an ambient occlusion map.
+		"uniform vec3      lc;",  // light color
+		"uniform vec3      ka;",  // material ambient color
+		"uniform vec3      kd;",  // material diffuse color
+		"uniform vec3      ks;",  // material specular color
+		"uniform float     ns;",  // material specular shininess.
+		"out vec4 ffc;",
+		"",
+		"void main() {",
+		"    vec3 map = texture(uv1, t_uv).xyz;", // perturbed normal in tangent space
+		"    map = map * 255./127. - 128./127.;", // unsigned normal map encoding.
+		"    mat3 TBN = mat3(normalize(v_x), normalize(v_b), normalize(v_n));",
+		"    vec3 normal = normalize(TBN * map);", // normal in view space.
+		"    vec3 nv_v = normalize(v_v);",
+		"    float occ = mix(1.0, texture(ao, t_uv).r, aon);", // ambient occlusion.
+		"    vec3 ambient = lc * ka * occ;",
+		"    float intensity = max(dot(v_l, normal), 0.0);",
+		"    vec3 diffuse = lc * kd * intensity;",
+		"", // Blinn-Phong half vector.
+		"    vec3 halfDir = normalize(v_l + nv_v);",
+		"    float specAngle = max(dot(halfDir, normal), 0.0);",
+		"    float specFac = pow(clamp(specAngle, 0.0, 1.0), ns);",
+		"    vec3 smap = texture(uv2, t_uv).rgb;",
+		"    vec3 specular = lc * ks * smap * specFac;",
+		"",                                // Combine into final fragment color.
+		"    vec4 t = texture(uv, t_uv);", // pure texture color.
+		"    vec3 color = ambient*t.rgb + diffuse*t.rgb + specular;", // combine all the values.
+		"    ffc = vec4(color, t.a);",                                // final fragment color
+		" }",
+	}
+	return logDepth(vsh), fsh
 }
 
 // ===========================================================================
@@ -656,7 +787,7 @@ func animShader() (vsh, fsh []string) {
 		"   m += bpos[int(joint.z)] * weight.z;",
 		"   m += bpos[int(joint.w)] * weight.w;",
 		"   vec4 mpos = vec4(vec4(in_v, 1.0) * m, 1.0);", // Row-Major pre-multiply.
-		"   gl_Position = mvpm * mpos;",
+		"   gl_Position = logDepth(mvpm * mpos);",
 		"   t_uv = in_t;",
 		"}",
 	}
@@ -672,7 +803,7 @@ func animShader() (vsh, fsh []string) {
 		"   ffc.a = ffc.a*alpha;",
 		"}",
 	}
-	return vsh, fsh
+	return logDepth(vsh), fsh
 }
 
 // =============================================================================
@@ -702,41 +833,376 @@ func depthShader() (vsh, fsh []string) {
 
 // =============================================================================
 
-// shadowShader incorporates a shadow depth map into lighting calculations. See:
+// shadowShader incorporates a shadow depth map into lighting calculations.
+// The shadow map is filtered according to the lights shadow filter mode.
+// Percentage closer soft shadows first average the nearby blocker depths
+// to estimate the penumbra size. See:
 // http://www.opengl-tutorial.org/intermediate-tutorials/tutorial-16-shadow-mapping
+// http://developer.download.nvidia.com/shaderlibrary/docs/shadow_PCSS.pdf
 func shadowShader() (vsh, fsh []string) {
 	vsh = []string{
 		"#version 330 core",
 		"layout(location=0) in vec3 in_v;", // verticies
+		"layout(location=1) in vec3 in_n;", // vertex normals
 		"layout(location=2) in vec2 in_t;", // texture coordinates
 		"uniform mat4       mvpm;",         // model view projection matrix
 		"uniform mat4       dbm;",          // depth bias matrix
+		"uniform vec2       sbias;",        // depth bias, normal bias.
 		"out     vec2       t_uv;",         // pass uv coordinates through
 		"out     vec4       s_uv;",         // create shadow uv coordinates
 		"void main(){",
 		"    gl_Position = mvpm * vec4(in_v, 1.0);",
-		"    s_uv = dbm * vec4(in_v, 1.0);",
+		"    s_uv = dbm * vec4(in_v + in_n*sbias.y, 1.0);", // normal offset.
 		"    t_uv = in_t;",
 		"}",
 	}
 	fsh = []string{
 		"#version 330 core",
-		"in      vec2            t_uv;",      // interpolated uv coordinates
-		"in      vec4            s_uv;",      // interpolated shadow uv coordinates
-		"uniform sampler2D       uv;",        // object material texture sampler
-		"uniform sampler2DShadow sm;",        // shadow map depth texture sampler
+		"in      vec2      t_uv;",            // interpolated uv coordinates
+		"in      vec4      s_uv;",            // interpolated shadow uv coordinates
+		"uniform sampler2D uv;",              // object material texture sampler
+		"uniform sampler2D sm;",              // shadow map depth texture sampler
+		"uniform vec2      sbias;",           // depth bias, normal bias.
+		"uniform vec2      sfilt;",           // filter mode, shadow map size.
 		"layout(location = 0) out vec4 ffc;", // final fragment color
+		"",
+		"", // lit returns 1 if the depth at xy is not closer than z.
+		"float lit(vec2 xy, float z) {",
+		"    return step(z, texture(sm, xy).r);",
+		"}",
+		"",
+		"", // pcf averages lit samples over a square of the given texel radius.
+		"float pcf(vec2 xy, float z, float radius) {",
+		"    vec2 texel = radius / vec2(sfilt.y);",
+		"    float sum = 0.0;",
+		"    for (int x = -1; x <= 1; x++) {",
+		"        for (int y = -1; y <= 1; y++) {",
+		"            sum += lit(xy + vec2(x, y)*texel, z);",
+		"        }",
+		"    }",
+		"    return sum / 9.0;",
+		"}",
+		"",
+		"", // pcss averages blocker depths to size the pcf penumbra.
+		"float pcss(vec2 xy, float z) {",
+		"    vec2 texel = 4.0 / vec2(sfilt.y);",
+		"    float blockers = 0.0;",
+		"    float depth = 0.0;",
+		"    for (int x = -2; x <= 2; x++) {",
+		"        for (int y = -2; y <= 2; y++) {",
+		"            float d = texture(sm, xy + vec2(x, y)*texel).r;",
+		"            if (d < z) { blockers += 1.0; depth += d; }",
+		"        }",
+		"    }",
+		"    if (blockers == 0.0) { return 1.0; }",
+		"    depth = depth / blockers;",
+		"    float penumbra = (z - depth) / depth;",
+		"    return pcf(xy, z, clamp(penumbra*64.0, 1.0, 8.0));",
+		"}",
+		"",
 		"void main(){",
-		"    vec4 lightColor = vec4(1,1,1,1);",        // white light
+		"    vec4 lightColor = vec4(1,1,1,1);", // white light
 		"    vec4 diffuseColor = texture(uv, t_uv); ", // object color from texture
 		"",
 		"", // compare the depth found in the texture at xy
 		"", // with the depth at z.
 		"    vec2 suv = vec2((s_uv.xy)/s_uv.w);",
-		"    float visibility = texture(sm, vec3(suv, (s_uv.z)/s_uv.w));",
+		"    float z = (s_uv.z)/s_uv.w - sbias.x;",
+		"    float visibility = lit(suv, z);", // ShadowHard
+		"    if (sfilt.x > 1.5) {",
+		"        visibility = pcss(suv, z);", // ShadowPCSS
+		"    } else if (sfilt.x > 0.5) {",
+		"        visibility = pcf(suv, z, 1.0);", // ShadowPCF
+		"    }",
 		"    visibility = visibility + (1.0-visibility)*0.75;", // map 1-0 to 1-0.75
 		"    ffc = visibility * diffuseColor * lightColor;",
 		"}",
 	}
 	return vsh, fsh
 }
+
+// =============================================================================
+
+// skyShader colors the inside of a sky dome with a gradient from the
+// horizon to the zenith along with a sun disc. It expects the uniforms
+// set by Sky and a dome mesh centered on its model origin.
+func skyShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330",
+		"layout(location=0) in vec3 in_v;", // verticies
+		"uniform mat4  mvpm;",              // model view projection matrix
+		"out     vec3  v_d;",               // direction from dome center.
+		"void main() {",
+		"   v_d = in_v;",
+		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"}",
+	}
+	fsh = []string{
+		"#version 330",
+		"in      vec3 v_d;", // interpolated direction from dome center.
+		"uniform vec3 zc;",  // zenith color.
+		"uniform vec3 hc;",  // horizon color.
+		"uniform vec3 sc;",  // sun color.
+		"uniform vec3 sd;",  // unit direction towards the sun.
+		"out     vec4 ffc;", // final fragment color
+		"void main() {",
+		"   vec3 d = normalize(v_d);",
+		"   float h = pow(clamp(d.y, 0.0, 1.0), 0.5);",                // gradient height.
+		"   vec3 sky = mix(hc, zc, h);",                               // horizon to zenith.
+		"   float sun = pow(max(dot(d, normalize(sd)), 0.0), 512.0);", // sun disc.
+		"   float glow = pow(max(dot(d, normalize(sd)), 0.0), 8.0);",  // sun halo.
+		"   ffc = vec4(sky + sc*sun + sc*glow*0.25, 1.0);",
+		"}",
+	}
+	return vsh, fsh
+}
+
+// =============================================================================
+
+// skyboxShader draws a cube map around the camera. The model mesh is
+// expected to be centered on the camera, ie: a large dome or box, so that
+// each vertex position is also the direction into the cube map.
+func skyboxShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330",
+		"layout(location=0) in vec3 in_v;", // verticies
+		"uniform mat4  mvpm;",              // model view projection matrix
+		"out     vec3  v_d;",               // direction from the box center.
+		"void main() {",
+		"   v_d = in_v;",
+		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"}",
+	}
+	fsh = []string{
+		"#version 330",
+		"in      vec3        v_d;",  // interpolated direction from box center.
+		"uniform samplerCube cube;", // environment cube map.
+		"out     vec4        ffc;",  // final fragment color
+		"void main() {",
+		"   ffc = vec4(texture(cube, normalize(v_d)).rgb, 1.0);",
+		"}",
+	}
+	return vsh, fsh
+}
+
+// =============================================================================
+
+// raysShader is a light scattering post effect. It draws a scene layer
+// texture and adds light shafts by sampling along the line from each pixel
+// to the light screen position. Only pixels without scene depth, ie: sky,
+// let light through. See:
+// http://http.developer.nvidia.com/GPUGems3/gpugems3_ch13.html
+func raysShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330",
+		"layout(location=0) in vec3 in_v;", // verticies
+		"layout(location=2) in vec2 in_t;", // texture coordinates
+		"uniform mat4 mvpm;",               // model view projection matrix
+		"out     vec2 t_uv;",               // pass uv coordinates through
+		"void main() {",
+		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"   t_uv = in_t;",
+		"}",
+	}
+	fsh = []string{
+		"#version 330",
+		"in      vec2      t_uv;", // interpolated uv coordinates
+		"uniform sampler2D uv;",   // scene color layer.
+		"uniform sampler2D uv1;",  // scene depth layer.
+		"uniform vec2      lsp;",  // light screen position in uv space.
+		"uniform vec4      rays;", // density, decay, weight, exposure.
+		"out     vec4      ffc;",  // final fragment color
+		"const   int       samples = 64;",
+		"void main() {",
+		"   vec3 color = texture(uv, t_uv).rgb;",
+		"   vec2 delta = (t_uv - lsp) * rays.x / float(samples);",
+		"   vec2 suv = t_uv;",
+		"   float illum = 1.0;",
+		"   vec3 shafts = vec3(0.0);",
+		"   for (int i = 0; i < samples; i++) {",
+		"      suv -= delta;",
+		"      float open = step(1.0, texture(uv1, suv).r);", // no occluder.
+		"      shafts += texture(uv, suv).rgb * open * illum * rays.z;",
+		"      illum *= rays.y;",
+		"   }",
+		"   ffc = vec4(color + shafts*rays.w, 1.0);",
+		"}",
+	}
+	return vsh, fsh
+}
+
+// =============================================================================
+
+// projShader displays a texture along with a texture from a Projector.
+// The projected texture either brightens or darkens the model texture.
+func projShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330",
+		"layout(location=0) in vec3 in_v;", // verticies
+		"layout(location=2) in vec2 in_t;", // texture coordinates
+		"uniform mat4 mvpm;",               // model view projection matrix
+		"uniform mat4 ptm;",                // projector texture matrix
+		"out     vec2 t_uv;",               // pass uv coordinates through
+		"out     vec4 p_uv;",               // projector uv coordinates
+		"void main() {",
+		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"   p_uv = ptm * vec4(in_v, 1.0);",
+		"   t_uv = in_t;",
+		"}",
+	}
+	fsh = []string{
+		"#version 330",
+		"in      vec2      t_uv;",  // interpolated uv coordinates
+		"in      vec4      p_uv;",  // interpolated projector coordinates
+		"uniform sampler2D uv;",    // model texture.
+		"uniform sampler2D uv1;",   // projected texture.
+		"uniform vec2      proj;",  // strength, additive.
+		"uniform float     alpha;", // transparency
+		"out     vec4      ffc;",   // final fragment color
+		"void main() {",
+		"   vec4 color = texture(uv, t_uv);",
+		"   vec2 puv = p_uv.xy / p_uv.w;",
+		"   float inside = step(0.0, p_uv.w) * step(0.0, puv.x) * step(puv.x, 1.0) *",
+		"                  step(0.0, puv.y) * step(puv.y, 1.0);", // in front and in frame.
+		"   vec4 pc = texture(uv1, puv);",
+		"   float s = proj.x * pc.a * inside;",
+		"   vec3 lit = color.rgb + pc.rgb*s;",                  // additive.
+		"   vec3 dim = color.rgb * mix(vec3(1.0), pc.rgb, s);", // darken.
+		"   ffc = vec4(mix(dim, lit, proj.y), color.a*alpha);",
+		"}",
+	}
+	return vsh, fsh
+}
+
+// ===========================================================================
+
+// giShader approximates one bounce of indirect light in screen space.
+// Scene pixels near each pixel, and at a similar depth, contribute their
+// color to the pixel as bounced light tinted by the pixel color.
+// The depth is linearized using the scene projection matrix values.
+func giShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330",
+		"layout(location=0) in vec3 in_v;", // verticies
+		"layout(location=2) in vec2 in_t;", // texture coordinates
+		"uniform mat4 mvpm;",               // model view projection matrix
+		"out     vec2 t_uv;",               // pass uv coordinates through
+		"void main() {",
+		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"   t_uv = in_t;",
+		"}",
+	}
+	fsh = []string{
+		"#version 330",
+		"in      vec2      t_uv;", // interpolated uv coordinates
+		"uniform sampler2D uv;",   // scene color layer.
+		"uniform sampler2D uv1;",  // scene depth layer.
+		"uniform vec3      gi;",   // radius, intensity, falloff.
+		"uniform vec2      gip;",  // projection Zz, Wz for linear depth.
+		"out     vec4      ffc;",  // final fragment color
+		"const   int       samples = 16;",
+		"float depth(vec2 suv) {", // view space distance to camera.
+		"   float ndc = texture(uv1, suv).r * 2.0 - 1.0;",
+		"   return gip.y / (ndc + gip.x);",
+		"}",
+		"void main() {",
+		"   vec3 color = texture(uv, t_uv).rgb;",
+		"   float d = depth(t_uv);",
+		"   vec3 bounce = vec3(0.0);",
+		"   for (int i = 0; i < samples; i++) {", // spiral out from the pixel.
+		"      float f = float(i+1) / float(samples);",
+		"      float a = float(i) * 2.39996;", // golden angle.
+		"      vec2 suv = t_uv + vec2(cos(a), sin(a)) * gi.x * f;",
+		"      float dd = abs(depth(suv) - d);",
+		"      float w = (1.0 - smoothstep(0.0, gi.z, dd)) * (1.0 - f*0.5);",
+		"      bounce += texture(uv, suv).rgb * w;",
+		"   }",
+		"   bounce = bounce / float(samples) * gi.y;",
+		"   ffc = vec4(color + bounce*color, 1.0);",
+		"}",
+	}
+	return vsh, fsh
+}
+
+// ===========================================================================
+
+// tonemapShader displays a high dynamic range scene layer after scaling the
+// scene light by the camera exposure. The ACES filmic curve, fit by
+//     https://knarkowicz.wordpress.com/2016/01/06/aces-filmic-tone-mapping-curve/
+// maps the scene light to displayable colors.
+func tonemapShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330",
+		"layout(location=0) in vec3 in_v;", // verticies
+		"layout(location=2) in vec2 in_t;", // texture coordinates
+		"uniform mat4 mvpm;",               // model view projection matrix
+		"out     vec2 t_uv;",               // pass uv coordinates through
+		"void main() {",
+		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"   t_uv = in_t;",
+		"}",
+	}
+	fsh = []string{
+		"#version 330",
+		"in      vec2      t_uv;",     // interpolated uv coordinates
+		"uniform sampler2D uv;",       // scene color layer.
+		"uniform float     exposure;", // camera exposure.
+		"out     vec4      ffc;",      // final fragment color
+		"void main() {",
+		"   vec3 c = texture(uv, t_uv).rgb * exposure;",
+		"   c = clamp((c*(2.51*c+0.03))/(c*(2.43*c+0.59)+0.14), 0.0, 1.0);",
+		"   ffc = vec4(pow(c, vec3(1.0/2.2)), 1.0);", // gamma correct.
+		"}",
+	}
+	return vsh, fsh
+}
+
+// ===========================================================================
+
+// dofShader blurs a scene layer based on the distance from the camera focus.
+// The circle of confusion grows with the distance from the focus and with
+// larger apertures. Samples are taken in a golden angle spiral disc and
+// weighted by their own blur so that sharp foreground objects don't bleed.
+func dofShader() (vsh, fsh []string) {
+	vsh = []string{
+		"#version 330",
+		"layout(location=0) in vec3 in_v;", // verticies
+		"layout(location=2) in vec2 in_t;", // texture coordinates
+		"uniform mat4 mvpm;",               // model view projection matrix
+		"out     vec2 t_uv;",               // pass uv coordinates through
+		"void main() {",
+		"   gl_Position = mvpm * vec4(in_v, 1.0);",
+		"   t_uv = in_t;",
+		"}",
+	}
+	fsh = []string{
+		"#version 330",
+		"in      vec2      t_uv;", // interpolated uv coordinates
+		"uniform sampler2D uv;",   // scene color layer.
+		"uniform sampler2D uv1;",  // scene depth layer.
+		"uniform vec3      dof;",  // focus distance, 1/fstop, max blur.
+		"uniform vec2      dofp;", // projection Zz, Wz for linear depth.
+		"out     vec4      ffc;",  // final fragment color
+		"const   int       samples = 32;",
+		"float coc(vec2 suv) {", // circle of confusion from 0 to 1.
+		"   float ndc = texture(uv1, suv).r * 2.0 - 1.0;",
+		"   float d = dofp.y / (ndc + dofp.x);",
+		"   return clamp(abs(d - dof.x) / d * dof.y, 0.0, 1.0);",
+		"}",
+		"void main() {",
+		"   float r = coc(t_uv) * dof.z;",
+		"   vec3 color = texture(uv, t_uv).rgb;",
+		"   float total = 1.0;",
+		"   for (int i = 0; i < samples; i++) {",
+		"      float f = sqrt(float(i+1) / float(samples));",
+		"      float a = float(i) * 2.39996;", // golden angle.
+		"      vec2 suv = t_uv + vec2(cos(a), sin(a)) * r * f;",
+		"      float w = max(coc(suv), 0.1);",
+		"      color += texture(uv, suv).rgb * w;",
+		"      total += w;",
+		"   }",
+		"   ffc = vec4(color / total, 1.0);",
+		"}",
+	}
+	return vsh, fsh
+}
@@ -0,0 +1,143 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// sky.go provides a simple day/night cycle for outdoor scenes.
+// DESIGN: The sky is a helper that an application updates each frame.
+//         It positions the Pov holding the scene (sun) Light and sets
+//         uniforms on an optional sky dome Model using the "sky" shader.
+// FUTURE: Atmospheric scattering, moon, and stars.
+
+import (
+	"math"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// Sky calculates the sun direction and color, along with the sky
+// gradient colors, from a time of day. Sky is used to drive a
+// directional (sun) light and a sky dome Model. For example:
+//     sun := root.NewPov()
+//     sun.NewLight()
+//     dome := root.NewPov().SetScale(500, 500, 500)
+//     sky := vu.NewSky(sun, dome.NewModel("sky", "msh:sphere"))
+//     ...
+//     sky.Advance(in.Dt * hoursPerSecond) // once per App.Update.
+// The sky dome is viewed from the inside, so the dome mesh is expected
+// to have inward facing triangles or be rendered with State.CullBacks off.
+type Sky struct {
+	Hour     float64 // Time of day from 0 to 24. Noon is 12.
+	Tilt     float64 // Sun path tilt away from overhead in degrees.
+	Distance float64 // Sun Pov distance from its parent origin.
+
+	sun  *Pov  // Sun light position. Optional.
+	dome Model // Sky dome using the "sky" shader. Optional.
+
+	// Values calculated from the time of day.
+	dx, dy, dz float64 // Unit direction towards the sun.
+	lc         rgb     // Sun light color.
+	zc         rgb     // Sky color directly overhead.
+	hc         rgb     // Sky color at the horizon.
+}
+
+// NewSky creates a sky that updates the given sun Pov and sky dome.
+// Either can be nil. The sky starts at noon.
+func NewSky(sun *Pov, dome Model) *Sky {
+	s := &Sky{sun: sun, dome: dome, Tilt: 30, Distance: 100}
+	s.SetHour(12)
+	return s
+}
+
+// Advance moves the time of day forward by the given number of hours,
+// wrapping around at midnight. Expected to be called once per update
+// with an elapsed time value, ie: Input.Dt scaled to game hours.
+func (s *Sky) Advance(hours float64) { s.SetHour(s.Hour + hours) }
+
+// SetHour sets the time of day in hours where 6 is sunrise, 12 is noon,
+// and 18 is sunset. The sun Pov, Light, and sky dome are updated.
+func (s *Sky) SetHour(hour float64) {
+	hour = math.Mod(hour, 24)
+	if hour < 0 {
+		hour += 24
+	}
+	s.Hour = hour
+
+	// The sun rises in the east (+X), is highest at noon,
+	// and sets in the west (-X). The tilt leans the sun path south (-Z).
+	angle := (hour - 6) / 24 * lin.PIx2
+	tilt := lin.Rad(s.Tilt)
+	s.dx = math.Cos(angle)
+	s.dy = math.Sin(angle) * math.Cos(tilt)
+	s.dz = -math.Sin(angle) * math.Sin(tilt)
+	s.colors(s.dy)
+
+	// update the scene objects.
+	if s.sun != nil {
+		s.sun.SetAt(s.dx*s.Distance, s.dy*s.Distance, s.dz*s.Distance)
+		if l := s.sun.Light(); l != nil {
+			l.SetColor(float64(s.lc.R), float64(s.lc.G), float64(s.lc.B))
+		}
+	}
+	if s.dome != nil {
+		s.dome.SetUniform("zc", s.zc.R, s.zc.G, s.zc.B)
+		s.dome.SetUniform("hc", s.hc.R, s.hc.G, s.hc.B)
+		s.dome.SetUniform("sc", s.lc.R, s.lc.G, s.lc.B)
+		s.dome.SetUniform("sd", s.dx, s.dy, s.dz)
+	}
+}
+
+// Sun returns the unit direction pointing towards the sun.
+func (s *Sky) Sun() (dx, dy, dz float64) { return s.dx, s.dy, s.dz }
+
+// SunColor returns the current sun light color.
+func (s *Sky) SunColor() (r, g, b float64) {
+	return float64(s.lc.R), float64(s.lc.G), float64(s.lc.B)
+}
+
+// Gradient returns the current overhead (zenith) and horizon sky colors.
+func (s *Sky) Gradient() (zr, zg, zb, hr, hg, hb float64) {
+	return float64(s.zc.R), float64(s.zc.G), float64(s.zc.B),
+		float64(s.hc.R), float64(s.hc.G), float64(s.hc.B)
+}
+
+// colors blends between night, twilight, and day colors
+// based on the sun elevation, where elevation is the sine
+// of the sun angle above the horizon.
+func (s *Sky) colors(elevation float64) {
+	switch {
+	case elevation < 0:
+		ratio := lin.Clamp(-elevation/skyNightStart, 0, 1)
+		s.lc = skyBlend(skyTwilight[0], skyNight[0], ratio)
+		s.zc = skyBlend(skyTwilight[1], skyNight[1], ratio)
+		s.hc = skyBlend(skyTwilight[2], skyNight[2], ratio)
+	default:
+		ratio := lin.Clamp(elevation/skyDayStart, 0, 1)
+		s.lc = skyBlend(skyTwilight[0], skyDay[0], ratio)
+		s.zc = skyBlend(skyTwilight[1], skyDay[1], ratio)
+		s.hc = skyBlend(skyTwilight[2], skyDay[2], ratio)
+	}
+}
+
+// Sun elevations where the sky is fully night or fully day.
+const (
+	skyNightStart = 0.2 // Sun elevation below the horizon.
+	skyDayStart   = 0.3 // Sun elevation above the horizon.
+)
+
+// Sky key colors as sun light, zenith, and horizon.
+var (
+	skyNight    = [3]rgb{{0.05, 0.05, 0.1}, {0.01, 0.01, 0.04}, {0.03, 0.03, 0.08}}
+	skyTwilight = [3]rgb{{1.0, 0.55, 0.3}, {0.2, 0.25, 0.45}, {0.95, 0.5, 0.3}}
+	skyDay      = [3]rgb{{1.0, 0.98, 0.92}, {0.25, 0.45, 0.85}, {0.7, 0.8, 0.95}}
+)
+
+// skyBlend linearly interpolates between colors a and b.
+func skyBlend(a, b rgb, ratio float64) rgb {
+	r := float32(ratio)
+	return rgb{
+		R: (b.R-a.R)*r + a.R,
+		G: (b.G-a.G)*r + a.G,
+		B: (b.B-a.B)*r + a.B,
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+
+	"github.com/gazed/vu/math/lin"
+)
+
+// The sun should be above the horizon during the day,
+// overhead (with tilt) at noon, and below at midnight.
+func TestSkySun(t *testing.T) {
+	sky := NewSky(nil, nil)
+	sky.Tilt = 0
+	sky.SetHour(12)
+	if dx, dy, dz := sky.Sun(); !lin.AeqZ(dx) || !lin.Aeq(dy, 1) || !lin.AeqZ(dz) {
+		t.Errorf("Expected noon sun overhead, got %f %f %f", dx, dy, dz)
+	}
+	sky.SetHour(6)
+	if dx, dy, _ := sky.Sun(); !lin.Aeq(dx, 1) || !lin.AeqZ(dy) {
+		t.Errorf("Expected sunrise in the east, got %f %f", dx, dy)
+	}
+	sky.Advance(18) // wraps to midnight.
+	if _, dy, _ := sky.Sun(); sky.Hour != 0 || dy > -0.99 {
+		t.Errorf("Expected midnight sun below, got %f at %f", dy, sky.Hour)
+	}
+}
+
+// Daylight should be brighter than night.
+func TestSkyColors(t *testing.T) {
+	sky := NewSky(nil, nil)
+	dr, dg, db := sky.SunColor()
+	sky.SetHour(0)
+	nr, ng, nb := sky.SunColor()
+	if dr+dg+db <= nr+ng+nb {
+		t.Errorf("Expected day %f %f %f brighter than night %f %f %f", dr, dg, db, nr, ng, nb)
+	}
+	zr, zg, zb, _, _, _ := sky.Gradient()
+	night := skyNight[1]
+	if !lin.Aeq(zr, float64(night.R)) || !lin.Aeq(zg, float64(night.G)) || !lin.Aeq(zb, float64(night.B)) {
+		t.Errorf("Expected night zenith, got %f %f %f", zr, zg, zb)
+	}
+}
@@ -6,9 +6,57 @@ package vu
 // sound.go wraps the audio package and controls all engine sounds.
 
 import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+
 	"github.com/gazed/vu/audio"
+	"github.com/gazed/vu/load"
+	"github.com/gazed/vu/math/lin"
 )
 
+// Sound controls a sound after it has been played using Pov.PlaySound.
+// Each sound file is played by a single audio source, so playing the
+// same sound again restarts it and is controlled by the same Sound.
+// Controls are applied in the order they are made at the end of the
+// current update. A nil Sound ignores controls.
+type Sound struct {
+	sid uint64  // Audio card source identifier.
+	ss  *sounds // Sound component that queues the controls.
+}
+
+// Stop the sound. Stopped sounds play from the start when played again.
+func (s *Sound) Stop() { s.control(stopSound, 0) }
+
+// Pause the sound. Paused sounds continue from where they were paused.
+func (s *Sound) Pause() { s.control(pauseSound, 0) }
+
+// Resume a paused sound.
+func (s *Sound) Resume() { s.control(resumeSound, 0) }
+
+// FadeOut lowers the sound volume to zero over the given seconds
+// and then stops the sound.
+func (s *Sound) FadeOut(seconds float64) { s.control(fadeSound, seconds) }
+
+// SetVolume changes the volume of the playing sound.
+// Valid values are 0 to 1.
+func (s *Sound) SetVolume(zeroToOne float64) { s.control(gainSound, zeroToOne) }
+
+// SetPitch changes the pitch of the playing sound where 1 is unchanged,
+// 0.5 is an octave lower, and 2 is an octave higher.
+func (s *Sound) SetPitch(pitch float64) { s.control(pitchSound, pitch) }
+
+// control queues a control request for the machine.
+func (s *Sound) control(op int, value float64) {
+	if s != nil {
+		s.ss.ops = append(s.ss.ops, &controlSound{sid: s.sid, op: op, value: value})
+	}
+}
+
+// =============================================================================
+
 // sound is an engine sound asset. Expected to be accessed through
 // the sounds component.
 type sound struct {
@@ -18,6 +66,10 @@ type sound struct {
 	did        uint64      // Audio data reference identifier.
 	lx, ly, lz float64     // noise location.
 	data       *audio.Data // noise data.
+
+	// stream is read while the sound plays, ie: an audio.Generator.
+	// Streamed sounds are bound without loading and have no data bytes.
+	stream io.ReadCloser
 }
 
 // newSound allocates space for a texture object.
@@ -31,101 +83,495 @@ func (s *sound) label() string { return s.name } // asset name
 
 // =============================================================================
 
+// cue is a sound that can be played by an entity. Cues from an audio bank
+// have playback settings and may have more than one sound variation.
+// Generated cues have a single streamed sound and no files.
+type cue struct {
+	name   string     // Sound or bank sound name.
+	files  []string   // One or more sound variations.
+	snds   []*sound   // Loaded variations. Nil until loaded.
+	loaded int        // Number of loaded variations.
+	gain   float64    // Volume from 0 to 1.
+	pitch  [2]float64 // Random pitch range.
+	loop   bool       // True to repeat the sound.
+	points [2]float64 // Loop start and end seconds. End 0 for the whole sound.
+	bus    int        // Mixer bus, ie: SfxBus.
+	next   int        // Next round robin variation.
+
+	// Distance attenuation: reference distance, max distance, rolloff.
+	ref, max, rolloff float64
+
+	// Voice priority. Higher priority sounds keep playing when there
+	// are more sounds than voices.
+	priority int
+
+	// Engine events that automatically play the sound.
+	triggers []load.BankTrigger
+}
+
+// newCue creates a cue for a plain sound or a bank sound.
+func newCue(name string, bs *load.BankSound) *cue {
+	c := &cue{name: name, files: []string{name}, gain: 1, pitch: [2]float64{1, 1}, bus: SfxBus}
+	c.ref, c.max, c.rolloff = 1, math.MaxFloat32, 1
+	if bs != nil {
+		c.files = bs.Files
+		c.gain, c.pitch, c.loop = bs.Gain, bs.Pitch, bs.Loop
+		c.points = [2]float64{bs.LoopStart, bs.LoopEnd}
+		if bus, ok := busNames[bs.Bus]; ok {
+			c.bus = bus
+		}
+		c.ref, c.rolloff, c.priority = bs.RefDistance, bs.Rolloff, bs.Priority
+		c.triggers = bs.Triggers
+		if bs.MaxDistance > 0 {
+			c.max = bs.MaxDistance
+		}
+	}
+	c.snds = make([]*sound, len(c.files))
+	return c
+}
+
+// busNames map audio bank bus names to mixer buses.
+var busNames = map[string]int{"sfx": SfxBus, "music": MusicBus, "voice": VoiceBus}
+
+// variation returns the next sound in round robin order along with
+// a random pitch from the cue pitch range.
+func (c *cue) variation() (s *sound, pitch float64) {
+	s = c.snds[c.next]
+	c.next = (c.next + 1) % len(c.snds)
+	pitch = c.pitch[0]
+	if c.pitch[1] > c.pitch[0] {
+		pitch += rand.Float64() * (c.pitch[1] - c.pitch[0])
+	}
+	return s, pitch
+}
+
+// =============================================================================
+
 // sounds manages audio instances. Each sound must be loaded with sound data
 // that has been bound to the audio card in order for the sound to be played.
 type sounds struct {
-	eng     *engine          // Needed to load and play sounds.
-	assets  map[aid]string   // Collect assets for load request.
-	loading map[eid][]aid    // New sounds need to be run through loader.
-	active  map[eid][]*sound // Sounds that can be played.
+	eng     *engine                    // Needed to load and play sounds.
+	assets  map[aid]string             // Collect assets for load request.
+	loading map[eid]bool               // New sounds need to be run through loader.
+	cues    map[eid][]*cue             // Sounds by entity in the order added.
+	banks   map[string]*load.BankSound // Audio bank sounds by name.
+	gens    map[eid][]*cue             // Generated sounds waiting to be bound.
 
 	// Sounds are heard by the sound listener at an app set pov.
-	soundListener *Pov    // Single listener for all noises. Current location.
-	sx, sy, sz    float64 // Last location of the sound listener.
+	soundListener *Pov        // Single listener for all noises. Current location.
+	heard         [12]float64 // Last location, forward, up, and velocity.
+	heardFrom     *Pov        // Listener for the last heard location.
+
+	// Sounds follow the pov that last played them.
+	owners map[uint64]eid       // Pov that last played each sound.
+	placed map[uint64]placement // Last location and velocity of each sound.
+
+	// Sound plays and controls in the order they were requested.
+	// Sent to the machine each update.
+	ops []msg
+
+	// Updates not yet taken by the machine are merged into one batch
+	// that a single goroutine forwards to the machine.
+	mu      sync.Mutex    // Guards pending.
+	pending soundBatch    // Waiting for the machine.
+	wake    chan struct{} // Signals a new pending batch.
+	done    chan struct{} // Closed on shutdown.
+}
+
+// soundBatch collects the sound updates since the machine last took
+// them. Plays and controls are kept in order. Only the latest listener
+// and sound placements are kept.
+type soundBatch struct {
+	ops      []msg          // Plays and controls in request order.
+	listener *placeListener // Latest listener, if moved.
+	moved    *placeSounds   // Latest placement for each moved sound.
 }
 
 // newSounds creates the sound component manager.
 // Expected to be called once on startup.
 func newSounds(eng *engine) *sounds {
 	ss := &sounds{eng: eng}
-	ss.assets = map[aid]string{}   // Reused to submit assets for loading.
-	ss.loading = map[eid][]aid{}   // Sounds waiting to be loaded.
-	ss.active = map[eid][]*sound{} // Sounds that can be played.
+	ss.assets = map[aid]string{}            // Reused to submit assets for loading.
+	ss.loading = map[eid]bool{}             // Entities waiting for sounds.
+	ss.cues = map[eid][]*cue{}              // Sounds by entity.
+	ss.banks = map[string]*load.BankSound{} // Sounds from audio banks.
+	ss.gens = map[eid][]*cue{}              // Generated sounds to bind.
+	ss.owners = map[uint64]eid{}            // Played sounds.
+	ss.placed = map[uint64]placement{}      // Played sound locations.
+	ss.wake = make(chan struct{}, 1)        // Pending batch signal.
+	ss.done = make(chan struct{})           // Stops the forwarder.
+	if eng.machine != nil {
+		go ss.forward()
+	}
 	return ss
 }
 
+// shutdown stops forwarding sound updates to the machine.
+// Expected to be called once on engine shutdown.
+func (ss *sounds) shutdown() { close(ss.done) }
+
+// loadBank reads the named audio bank file and registers its sounds.
+// Bank sounds are used in place of sound files with the same name
+// for sounds that are added after the bank is loaded.
+func (ss *sounds) loadBank(name string) error {
+	bd := &load.BankData{}
+	if err := bd.Load(name, ss.locator()); err != nil {
+		return fmt.Errorf("LoadBank %s: %s", name, err)
+	}
+	for cnt := range bd.Sounds {
+		bs := &bd.Sounds[cnt]
+		ss.banks[bs.Name] = bs
+	}
+	return nil
+}
+
+// playMusic opens the named music track and hands it to the machine
+// which streams the track as it plays. Music is not spatialized.
+// Bank sounds play their first sound file using the bank loop settings.
+func (ss *sounds) playMusic(name string, fadeIn float64) error {
+	file := name
+	bs, isBank := ss.banks[name]
+	if isBank {
+		file = bs.Files[0]
+	}
+	s, err := load.OpenSnd(file, ss.locator())
+	if err != nil {
+		return fmt.Errorf("PlayMusic %s: %s", name, err)
+	}
+	at := s.Attrs
+	d := &audio.Data{Name: name, Channels: at.Channels, SampleBits: at.SampleBits, Frequency: at.Frequency}
+	if isBank {
+		d.Loop, d.LoopStart, d.LoopEnd = bs.Loop, bs.LoopStart, bs.LoopEnd
+	}
+	ss.eng.machine <- &playMusic{data: d, stream: s, fadeIn: fadeIn}
+	return nil
+}
+
+// locator returns the engine locator, creating it if necessary.
+func (ss *sounds) locator() load.Locator {
+	if ss.eng.loc == nil {
+		ss.eng.loc = load.NewLocator()
+	}
+	return ss.eng.loc
+}
+
+// files returns the sound files for the given sound or bank sound name.
+func (ss *sounds) files(name string) []string {
+	if bs, ok := ss.banks[name]; ok {
+		return bs.Files
+	}
+	return []string{name}
+}
+
 // create a new sound. Allows multiple sounds to be associated with
 // an entity.
 func (ss *sounds) create(id eid, name string) {
-	aid := assetID(snd, name)
-	ss.assets[aid] = name                        // all sound assets.
-	ss.loading[id] = append(ss.loading[id], aid) // sounds by entity.
+	c := newCue(name, ss.banks[name])
+	for _, file := range c.files {
+		ss.assets[assetID(snd, file)] = file // all sound assets.
+	}
+	ss.cues[id] = append(ss.cues[id], c) // sounds by entity.
+	ss.loading[id] = true
+}
+
+// generate creates a sound that plays the samples produced by the given
+// generator. Generated sounds are bound on the next refresh and can be
+// played once bound. Generated sounds use the default cue settings.
+func (ss *sounds) generate(id eid, name string, g *audio.Generator) {
+	s := newSound(name)
+	s.data, s.stream = g.Data(), g
+	s.data.Name = name
+	c := newCue(name, nil)
+	c.files, c.snds = nil, []*sound{s}
+	ss.cues[id] = append(ss.cues[id], c)
+	ss.gens[id] = append(ss.gens[id], c)
 }
 
 // dispose all sounds associated with the given entity.
+// Generated sounds are released since they are not shared.
 func (ss *sounds) dispose(id eid) {
-	delete(ss.active, id)
+	for _, c := range ss.cues[id] {
+		if c.files == nil && c.loaded > 0 {
+			go ss.eng.release(&releaseData{data: c.snds[0]})
+		}
+	}
+	delete(ss.cues, id)
+	delete(ss.gens, id)
 	delete(ss.loading, id) // Outstanding loads are ignored when they return.
+	for sid, owner := range ss.owners {
+		if owner == id {
+			delete(ss.owners, sid)
+			delete(ss.placed, sid)
+		}
+	}
 }
 
-// refresh passes new sounds through the loading system.
+// refresh passes new sounds through the loading system
+// and binds new generated sounds.
 func (ss *sounds) refresh() {
 	if len(ss.assets) > 0 {
 		ss.eng.submitLoadReqs(ss.assets)
 		ss.assets = map[aid]string{}
 	}
+	if len(ss.gens) > 0 {
+		binds := []asset{}
+		for _, cues := range ss.gens {
+			for _, c := range cues {
+				binds = append(binds, c.snds[0])
+			}
+		}
+		ss.eng.rebind(binds)
+		for id, cues := range ss.gens {
+			for _, c := range cues {
+				c.loaded = 1
+			}
+			delete(ss.gens, id)
+		}
+	}
 }
 
-// finishLoads matches loaded sounds with loading sounds and moves
-// loaded sounds to be active.
+// finishLoads matches loaded sounds with loading sounds. Sounds can be
+// played once all their variations are loaded.
 func (ss *sounds) finishLoads(assets map[aid]asset) {
-	for eid, aids := range ss.loading {
-		for _, aid := range aids {
-			if a, ok := assets[aid]; ok {
-				switch s := a.(type) {
-				case *sound:
-					ss.active[eid] = append(ss.active[eid], s)
+	for eid := range ss.loading {
+		done := true
+		for _, c := range ss.cues[eid] {
+			for cnt, file := range c.files {
+				if c.snds[cnt] != nil {
+					continue
+				}
+				if s, ok := assets[assetID(snd, file)].(*sound); ok {
+					c.snds[cnt] = s
+					c.loaded++
 				}
 			}
+			done = done && c.loaded == len(c.files)
 		}
-		if len(ss.active[eid]) == len(ss.loading[eid]) {
+		if done {
 			delete(ss.loading, eid)
 		}
 	}
 }
 
-// play gets the sounds location and generates a play sound request.
-// The play request is sent to a goroutine allowing the goroutine to block
-// until the machine can service the request.
-func (ss *sounds) play(id eid, index int) {
-	if snds, ok := ss.active[id]; ok {
-		if index < 0 || index >= len(snds) {
-			return
+// play gets the sounds location and queues a play sound request.
+// The cue gain is scaled by the given volume.
+// Returns nil if there is no loaded sound for the given index.
+func (ss *sounds) play(id eid, index int, volume float64) *Sound {
+	if cues, ok := ss.cues[id]; ok {
+		if index < 0 || index >= len(cues) || cues[index].loaded != len(cues[index].snds) {
+			return nil
 		}
-		s := snds[index]
+		c := cues[index]
 		if p := ss.eng.povs.get(id); p != nil {
-			x, y, z := p.At()
-			go func(sid uint64, x, y, z float64) {
-				ss.eng.machine <- &playSound{sid: sid, x: x, y: y, z: z}
-			}(s.sid, x, y, z)
+			s, pitch := c.variation()
+			ps := &playSound{sid: s.sid, bus: c.bus, gain: c.gain * volume, pitch: pitch, loop: c.loop, points: c.points}
+			ps.ref, ps.max, ps.rolloff, ps.priority = c.ref, c.max, c.rolloff, c.priority
+			ps.x, ps.y, ps.z = p.World()
+			ss.owners[s.sid] = id
+			ss.placed[s.sid] = placement{at: [3]float64{ps.x, ps.y, ps.z}}
+			ss.ops = append(ss.ops, ps)
+			return &Sound{sid: s.sid, ss: ss}
+		}
+	}
+	return nil
+}
+
+// impactEvent is triggered by colliding bodies with the
+// collision impulse as the event strength.
+const impactEvent = "impact"
+
+// trigger plays the entity sounds that are triggered by the given event.
+// Triggers with a strength range have their volume scaled by the event
+// strength and are not played for events weaker than the range.
+func (ss *sounds) trigger(id eid, event string, strength float64) {
+	for cnt, c := range ss.cues[id] {
+		for _, t := range c.triggers {
+			if t.Event != event {
+				continue
+			}
+			volume := 1.0
+			if t.Max > t.Min {
+				if strength <= t.Min {
+					continue
+				}
+				volume = math.Min(1, (strength-t.Min)/(t.Max-t.Min))
+			}
+			ss.play(id, cnt, volume)
+		}
+	}
+}
+
+// reposition updates the sound listener location and orientation and
+// moves played sounds to the current location of their pov. Velocities,
+// for the doppler effect, come from the distance moved over the given
+// elapsed seconds. The updates are merged into the pending batch which
+// a goroutine sends when the machine can service the request, so the
+// update never waits on the machine. Expected to be called each update
+// after the world transforms are updated.
+func (ss *sounds) reposition(dt float64) {
+	var listener *placeListener
+	heard := ss.listenerView()
+	if ss.heardFrom == ss.soundListener {
+		was := [3]float64{ss.heard[0], ss.heard[1], ss.heard[2]}
+		vel := velocity(was, [3]float64{heard[0], heard[1], heard[2]}, dt)
+		heard[9], heard[10], heard[11] = vel[0], vel[1], vel[2]
+	}
+	if ss.heardFrom = ss.soundListener; heard != ss.heard {
+		ss.heard = heard
+		h := heard
+		listener = &placeListener{x: h[0], y: h[1], z: h[2],
+			fx: h[3], fy: h[4], fz: h[5], ux: h[6], uy: h[7], uz: h[8],
+			vx: h[9], vy: h[10], vz: h[11]}
+	}
+	moved := &placeSounds{}
+	for sid, id := range ss.owners {
+		p := ss.eng.povs.get(id)
+		if p == nil {
+			delete(ss.owners, sid)
+			delete(ss.placed, sid)
+			continue
+		}
+		x, y, z := p.World()
+		was := ss.placed[sid]
+		now := placement{at: [3]float64{x, y, z}}
+		now.vel = velocity(was.at, now.at, dt)
+		if now != was {
+			ss.placed[sid] = now
+			moved.sids = append(moved.sids, sid)
+			moved.locs = append(moved.locs, now.at)
+			moved.vels = append(moved.vels, now.vel)
+		}
+	}
+	if listener != nil || len(moved.sids) > 0 || len(ss.ops) > 0 {
+		ss.queue(listener, moved, ss.ops)
+		ss.ops = nil
+	}
+}
+
+// queue merges the updates into the pending batch and signals the
+// forwarder. It does not wait for the machine.
+func (ss *sounds) queue(listener *placeListener, moved *placeSounds, ops []msg) {
+	if ss.eng.machine == nil {
+		return // no machine to hear the sounds.
+	}
+	ss.mu.Lock()
+	b := &ss.pending
+	b.ops = append(b.ops, ops...)
+	if listener != nil {
+		b.listener = listener
+	}
+	if len(moved.sids) > 0 {
+		if b.moved == nil {
+			b.moved = &placeSounds{}
+		}
+		b.moved.merge(moved)
+	}
+	ss.mu.Unlock()
+	select {
+	case ss.wake <- struct{}{}:
+	default: // forwarder already signalled.
+	}
+}
+
+// forward sends pending batches to the machine until shutdown.
+// Plays and controls are sent before the placements so that sounds
+// are controlled in the order they were requested, and are then moved
+// to their latest location.
+func (ss *sounds) forward() {
+	for {
+		select {
+		case <-ss.wake:
+		case <-ss.done:
+			return
+		}
+		ss.mu.Lock()
+		b := ss.pending
+		ss.pending = soundBatch{}
+		ss.mu.Unlock()
+		msgs := []msg{}
+		if len(b.ops) > 0 {
+			msgs = append(msgs, &soundOps{ops: b.ops})
+		}
+		if b.listener != nil {
+			msgs = append(msgs, b.listener)
+		}
+		if b.moved != nil {
+			msgs = append(msgs, b.moved)
+		}
+		for _, m := range msgs {
+			select {
+			case ss.eng.machine <- m:
+			case <-ss.done:
+				return
+			}
+		}
+	}
+}
+
+// merge updates ps with the placements in moved, replacing the
+// placements of sounds that moved again.
+func (ps *placeSounds) merge(moved *placeSounds) {
+	for cnt, sid := range moved.sids {
+		at := len(ps.sids)
+		for i, id := range ps.sids {
+			if id == sid {
+				at = i
+				break
+			}
+		}
+		if at == len(ps.sids) {
+			ps.sids = append(ps.sids, sid)
+			ps.locs = append(ps.locs, moved.locs[cnt])
+			ps.vels = append(ps.vels, moved.vels[cnt])
+			continue
+		}
+		ps.locs[at], ps.vels[at] = moved.locs[cnt], moved.vels[cnt]
+	}
+}
+
+// placement is the world location and velocity of a played sound.
+type placement struct {
+	at, vel [3]float64
+}
+
+// velocity returns the velocity needed to move between the given
+// locations in dt seconds.
+func velocity(from, to [3]float64, dt float64) (v [3]float64) {
+	if dt > 0 {
+		for cnt := range v {
+			v[cnt] = (to[cnt] - from[cnt]) / dt
 		}
 	}
+	return v
 }
 
-// repositionSoundListener checks and updates the sound listeners location.
-func (ss *sounds) repositionSoundListener() {
-	x, y, z := ss.soundListener.At()
-	if x != ss.sx || y != ss.sy || z != ss.sz {
-		ss.sx, ss.sy, ss.sz = x, y, z
-		go func(x, y, z float64) {
-			ss.eng.machine <- &placeListener{x: x, y: y, z: z}
-		}(x, y, z)
+// listenerView returns the listener world location followed by the
+// forward and up directions. A listener pov with a camera hears from
+// the camera location and orientation.
+func (ss *sounds) listenerView() (h [12]float64) {
+	p := ss.soundListener
+	if cam := ss.eng.cams.get(p.id); cam != nil {
+		rot := cam.Lookat()
+		h[0], h[1], h[2] = cam.At()
+		h[3], h[4], h[5] = lin.MultSQ(0, 0, -1, rot)
+		h[6], h[7], h[8] = lin.MultSQ(0, 1, 0, rot)
+		return h
 	}
+	h[0], h[1], h[2] = p.World()
+	forward := &lin.V4{X: 0, Y: 0, Z: -1, W: 0}
+	forward.MultvM(forward, p.mm).Unit()
+	up := &lin.V4{X: 0, Y: 1, Z: 0, W: 0}
+	up.MultvM(up, p.mm).Unit()
+	h[3], h[4], h[5] = forward.X, forward.Y, forward.Z
+	h[6], h[7], h[8] = up.X, up.Y, up.Z
+	return h
 }
 
 // setListener locates the point that can hear sounds.
 // There is always only one listener. It is associated with the root pov
 // by default. This changes the listener location to the given pov.
+// Listen from the pov with the scene camera to hear sounds relative
+// to the camera view.
 func (ss *sounds) setListener(p *Pov) {
 	if p != nil {
 		ss.soundListener = p
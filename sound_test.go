@@ -0,0 +1,367 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gazed/vu/audio"
+	"github.com/gazed/vu/load"
+	"github.com/gazed/vu/math/lin"
+	"github.com/gazed/vu/physics"
+)
+
+// Bank sounds load all their variations and play them in turn.
+func TestBankSound(t *testing.T) {
+	eng := newEngine(nil)
+	eng.loc = load.NewLocator().Dir("BANK", "eg/audio")
+	if err := eng.LoadBank("effects"); err != nil {
+		t.Fatal(err)
+	}
+	p := eng.root().NewPov()
+	p.AddSound("hit")
+	p.AddSound("bloop")
+	if len(eng.sounds.assets) != 2 {
+		t.Errorf("Expected shared sound files %v", eng.sounds.assets)
+	}
+	ricochet, bloop := newSound("ricochet"), newSound("bloop")
+	eng.sounds.finishLoads(map[aid]asset{bloop.aid(): bloop})
+	c := eng.sounds.cues[p.id][0]
+	if c.loaded != 1 || !eng.sounds.loading[p.id] {
+		t.Errorf("Expected hit to be waiting for ricochet")
+	}
+	eng.sounds.finishLoads(map[aid]asset{ricochet.aid(): ricochet})
+	if c.loaded != 2 || eng.sounds.loading[p.id] {
+		t.Errorf("Expected sounds to be loaded")
+	}
+	for _, want := range []*sound{ricochet, bloop, ricochet} {
+		s, pitch := c.variation()
+		if s != want || pitch < 0.9 || pitch > 1.1 {
+			t.Errorf("Expected %s got %s at pitch %f", want.name, s.name, pitch)
+		}
+	}
+	if c.bus != SfxBus || newCue("hum", eng.sounds.banks["hum"]).bus != MusicBus {
+		t.Errorf("Expected bank sound mixer buses")
+	}
+	if hum := newCue("hum", eng.sounds.banks["hum"]); c.priority != 0 || hum.priority != 1 {
+		t.Errorf("Expected bank sound priorities got %d %d", c.priority, hum.priority)
+	}
+	if files := eng.sounds.files("hit"); !reflect.DeepEqual(files, []string{"ricochet", "bloop"}) {
+		t.Errorf("Expected bank files got %v", files)
+	}
+	if got := eng.Manifest(p); !reflect.DeepEqual(got, []string{"snd:bloop", "snd:ricochet"}) {
+		t.Errorf("Expected sound files in manifest got %v", got)
+	}
+	if err := eng.LoadBank("missing"); err == nil {
+		t.Error("Expected missing bank error")
+	}
+}
+
+// The listener follows its camera and played sounds follow their pov.
+func TestSoundPlacement(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	scene := eng.root().NewPov()
+	cam := scene.NewCam()
+	cam.SetAt(1, 2, 3)
+	cam.SetYaw(90)
+	scene.SetListener()
+	eng.sounds.reposition(0.1)
+	pl := (<-machine).(*placeListener)
+	if pl.x != 1 || pl.y != 2 || pl.z != 3 || !lin.Aeq(pl.uy, 1) || !lin.Aeq(math.Abs(pl.fx), 1) {
+		t.Errorf("Expected listener at the camera facing sideways got %+v", pl)
+	}
+	eng.sounds.reposition(0.1)
+	select {
+	case m := <-machine:
+		t.Errorf("Expected no update for an unchanged listener got %T", m)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// play a sound and move its pov.
+	p := scene.NewPov()
+	p.AddSound("bloop")
+	bloop := newSound("bloop")
+	bloop.sid = 7
+	eng.sounds.finishLoads(map[aid]asset{bloop.aid(): bloop})
+	p.PlaySound(0)
+	eng.sounds.reposition(0.1)
+	ps := (<-machine).(*soundOps).ops[0].(*playSound)
+	if ps.sid != 7 || ps.ref != 1 || ps.rolloff != 1 || ps.max != math.MaxFloat32 {
+		t.Errorf("Expected default distance settings got %+v", ps)
+	}
+	p.SetAt(4, 5, 6)
+	eng.povs.updateWorldTransforms()
+	eng.sounds.reposition(0.1)
+	moved := (<-machine).(*placeSounds)
+	if len(moved.sids) != 1 || moved.locs[0] != [3]float64{4, 5, 6} {
+		t.Errorf("Expected sound to follow pov got %+v", moved)
+	}
+	if moved.vels[0] != [3]float64{40, 50, 60} {
+		t.Errorf("Expected sound velocity from movement got %v", moved.vels[0])
+	}
+	eng.sounds.reposition(0.1)
+	if stopped := (<-machine).(*placeSounds); stopped.vels[0] != [3]float64{} {
+		t.Errorf("Expected stopped sound to lose its velocity got %v", stopped.vels[0])
+	}
+	cam.SetAt(1, 2, 2)
+	eng.sounds.reposition(0.5)
+	if pl := (<-machine).(*placeListener); pl.vz != -2 {
+		t.Errorf("Expected listener velocity got %f %f %f", pl.vx, pl.vy, pl.vz)
+	}
+	eng.disposePov(p.id)
+	if len(eng.sounds.owners) != 0 {
+		t.Errorf("Expected disposed sounds to stop following")
+	}
+}
+
+// Music is opened by the engine and streamed by the machine.
+// Uses vu/eg resource directories.
+func TestPlayMusic(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	eng.loc = load.NewLocator().Dir("WAV", "eg/audio")
+	go func() {
+		if err := eng.PlayMusic("bloop", 2); err != nil {
+			t.Error(err)
+		}
+		eng.StopMusic(1)
+	}()
+	pm := (<-machine).(*playMusic)
+	defer pm.stream.Close()
+	if pm.data.Name != "bloop" || pm.data.Frequency == 0 || pm.fadeIn != 2 {
+		t.Errorf("Expected music stream got %+v", pm.data)
+	}
+	if sm := (<-machine).(*stopMusic); sm.fadeOut != 1 {
+		t.Errorf("Expected music fade out got %f", sm.fadeOut)
+	}
+
+	// bank sounds loop using the bank loop points.
+	eng.loc = load.NewLocator().Dir("WAV", "eg/audio").Dir("BANK", "eg/audio")
+	if err := eng.LoadBank("effects"); err != nil {
+		t.Fatal(err)
+	}
+	go eng.PlayMusic("hum", 0)
+	pm = (<-machine).(*playMusic)
+	defer pm.stream.Close()
+	if d := pm.data; !d.Loop || d.LoopStart != 0.05 || d.LoopEnd != 0.15 {
+		t.Errorf("Expected bank loop points got %+v", d)
+	}
+	if c := newCue("hum", eng.sounds.banks["hum"]); c.points != [2]float64{0.05, 0.15} {
+		t.Errorf("Expected cue loop points got %v", c.points)
+	}
+	if err := eng.PlayMusic("missing", 0); err == nil {
+		t.Error("Expected missing music error")
+	}
+}
+
+// Bus attributes keep the other bus settings when one changes.
+func TestBuses(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	go eng.Set(BusVolume(MusicBus, 0.5), MuteBus(MusicBus, true), Volume(2), PauseBus(7, true))
+	if b := (<-machine).(*setBus); b.bus != MusicBus || b.gain != 0.5 || b.mute {
+		t.Errorf("Expected music volume got %+v", b)
+	}
+	if b := (<-machine).(*setBus); b.gain != 0.5 || !b.mute {
+		t.Errorf("Expected muted music to keep its volume got %+v", b)
+	}
+	if b := (<-machine).(*setBus); b.bus != MasterBus || b.gain != 1 {
+		t.Errorf("Expected invalid volume to be ignored got %+v", b)
+	}
+	select {
+	case m := <-machine:
+		t.Errorf("Expected unknown bus to be ignored got %+v", m)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// Played sounds are controlled in the order the controls are made.
+func TestSoundControl(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	p := eng.root().NewPov()
+	if s := p.PlaySound(0); s != nil {
+		t.Fatal("Expected nil sound for missing sound")
+	}
+	p.AddSound("bloop")
+	if s := p.PlaySound(0); s != nil {
+		t.Fatal("Expected nil sound before the sound is loaded")
+	}
+	bloop := newSound("bloop")
+	bloop.sid = 3
+	eng.sounds.finishLoads(map[aid]asset{bloop.aid(): bloop})
+	s := p.PlaySound(0)
+	s.SetPitch(1.5)
+	s.Pause()
+	s.Resume()
+	s.FadeOut(2)
+	eng.sounds.reposition(0.1)
+	ops := (<-machine).(*soundOps).ops
+	if len(ops) != 5 {
+		t.Fatalf("Expected play and 4 controls got %d", len(ops))
+	}
+	want := []int{pitchSound, pauseSound, resumeSound, fadeSound}
+	for cnt, op := range ops[1:] {
+		if c := op.(*controlSound); c.sid != 3 || c.op != want[cnt] {
+			t.Errorf("Expected control %d got %+v", want[cnt], c)
+		}
+	}
+	if c := ops[4].(*controlSound); c.value != 2 {
+		t.Errorf("Expected fade seconds got %f", c.value)
+	}
+	var missing *Sound
+	missing.Stop() // ignored.
+	if len(eng.sounds.ops) != 0 {
+		t.Errorf("Expected sent controls to be cleared")
+	}
+}
+
+// Sound messages reach the machine in order across updates.
+func TestSoundOrder(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	p := eng.root().NewPov()
+	p.AddSound("bloop")
+	bloop := newSound("bloop")
+	bloop.sid = 5
+	eng.sounds.finishLoads(map[aid]asset{bloop.aid(): bloop})
+	s := p.PlaySound(0)
+	s.SetVolume(0.5)
+	eng.sounds.reposition(0.1)
+	s.SetVolume(0.2)
+	s.Stop()
+	eng.sounds.reposition(0.1)
+	for cnt := 1; cnt <= 100; cnt++ { // updates don't wait for the machine.
+		p.SetAt(float64(cnt), 0, 0)
+		eng.povs.updateWorldTransforms()
+		eng.sounds.reposition(0.1)
+	}
+	ops, moved := []msg{}, &placeSounds{}
+	for len(ops) < 4 || len(moved.sids) == 0 || moved.locs[0][0] != 100 {
+		select {
+		case m := <-machine:
+			switch t := m.(type) {
+			case *soundOps:
+				ops = append(ops, t.ops...)
+			case *placeSounds:
+				moved = t
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected sound updates got %+v %+v", ops, moved)
+		}
+	}
+	if _, ok := ops[0].(*playSound); !ok || len(ops) != 4 || ops[1].(*controlSound).value != 0.5 {
+		t.Fatalf("Expected the sound to be played first got %+v", ops)
+	}
+	if ops[2].(*controlSound).value != 0.2 || ops[3].(*controlSound).op != stopSound {
+		t.Errorf("Expected the sound to be stopped last got %+v", ops)
+	}
+	eng.sounds.shutdown()
+	eng.sounds.reposition(0.1) // ignored after shutdown.
+}
+
+// Bank triggers play sounds on events scaled by the event strength.
+func TestTrigger(t *testing.T) {
+	eng := newEngine(nil)
+	eng.loc = load.NewLocator().Dir("BANK", "eg/audio")
+	if err := eng.LoadBank("effects"); err != nil {
+		t.Fatal(err)
+	}
+	slab := eng.root().NewPov().SetAt(0, -25, 0)
+	slab.NewBody(physics.NewBody(physics.NewBox(100, 25, 100)))
+	slab.SetSolid(0, 0)
+	ball := eng.root().NewPov().SetAt(0, 5, 0)
+	ball.NewBody(physics.NewBody(physics.NewSphere(1)))
+	ball.SetSolid(1, 0)
+	ball.AddSound("hit")
+	ricochet, bloop := newSound("ricochet"), newSound("bloop")
+	eng.sounds.finishLoads(map[aid]asset{ricochet.aid(): ricochet, bloop.aid(): bloop})
+	ball.Trigger("impact", 2.75)
+	ball.Trigger("impact", 0.5) // too weak.
+	ball.Trigger("click", 1)    // not a hit trigger.
+	if len(eng.sounds.ops) != 1 || !lin.Aeq(eng.sounds.ops[0].(*playSound).gain, 0.4) {
+		t.Fatalf("Expected one scaled impact sound got %v", eng.sounds.ops)
+	}
+	eng.sounds.ops = eng.sounds.ops[:0]
+	for cnt := 0; cnt < 100; cnt++ {
+		eng.bodies.stepVelocities(eng, 0.02)
+	}
+	if len(eng.sounds.ops) != 1 {
+		t.Errorf("Expected one landing sound got %d", len(eng.sounds.ops))
+	}
+}
+
+// Generated sounds are bound on refresh and played like loaded sounds.
+func TestAddGenerator(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	p := eng.root().NewPov()
+	g := audio.NewGenerator(func(out []float32) {})
+	p.AddGenerator("hum", g)
+	if s := p.PlaySound(0); s != nil {
+		t.Fatal("Expected nil sound before the generator is bound")
+	}
+	go func() {
+		bd := (<-machine).(*bindData)
+		hum := bd.data.([]asset)[0].(*sound)
+		if hum.stream != g || hum.data.SampleBits != 16 {
+			t.Errorf("Expected generated stream got %+v", hum)
+		}
+		hum.sid = 7
+		bd.reply <- nil
+	}()
+	eng.sounds.refresh()
+	s := p.PlaySound(0)
+	if s == nil || s.sid != 7 {
+		t.Fatalf("Expected generated sound got %v", s)
+	}
+	if man := eng.loads.manifest(p); len(man) != 0 {
+		t.Errorf("Expected generated sounds outside the manifest %v", man)
+	}
+	eng.sounds.reposition(0.1)
+	for m := range machine {
+		if so, ok := m.(*soundOps); ok {
+			if ps := so.ops[0].(*playSound); ps.sid != 7 {
+				t.Errorf("Expected generated sound play got %+v", ps)
+			}
+			break
+		}
+	}
+	eng.sounds.dispose(p.id)
+	if rd := (<-machine).(*releaseData); rd.data.(*sound).sid != 7 {
+		t.Errorf("Expected generated sound release got %+v", rd)
+	}
+}
+
+// Recordings are opened by the machine.
+func TestRecord(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	go func() {
+		oc := (<-machine).(*openCapture)
+		if d := oc.data; d.Channels != 1 || d.SampleBits != 16 || d.Frequency != 16000 {
+			t.Errorf("Expected 16 bit mono recording got %+v", d)
+		}
+		oc.err = fmt.Errorf("no microphone")
+		oc.reply <- oc
+	}()
+	if r, err := eng.Record(16000); r != nil || err == nil {
+		t.Errorf("Expected record error got %v", err)
+	}
+}
+
+// The voice limit is set by the machine.
+func TestVoices(t *testing.T) {
+	machine := make(chan msg)
+	eng := newEngine(machine)
+	go eng.Set(Voices(8))
+	if sv := (<-machine).(*setVoices); sv.limit != 8 {
+		t.Errorf("Expected 8 voices got %d", sv.limit)
+	}
+}
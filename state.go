@@ -5,6 +5,10 @@ package vu
 
 // state.go exposes the engine state needed by applications.
 
+import (
+	"github.com/gazed/vu/device"
+)
+
 // State is used to communicate current engine wide variable settings.
 // It is refreshed each update and provided to the application.
 // Changing state is done through Eng methods, often Eng.Set().
@@ -16,6 +20,17 @@ type State struct {
 	Blend      bool    // True for texture blending.
 	FullScreen bool    // True when window is full screen.
 	Mute       bool    // True when audio is muted.
+
+	// PW, PH is the window size in drawing pixels. It is larger than
+	// the window size W, H on high resolution displays where Scale
+	// is the number of drawing pixels per window unit. Mouse locations
+	// are in window units while rendering uses the drawing pixels.
+	PW, PH int
+	Scale  float64
+
+	// Monitors are the attached displays and their display modes,
+	// refreshed when the window is resized. See WindowMode.
+	Monitors []device.Monitor
 }
 
 // Screen is a convenience method returning the current window dimensions.
@@ -23,4 +38,5 @@ func (s *State) Screen() (x, y, w, h int) { return s.X, s.Y, s.W, s.H }
 
 // Internal convenience methods.
 func (s *State) setScreen(x, y, w, h int)    { s.X, s.Y, s.W, s.H = x, y, w, h }
+func (s *State) setPixels(w, h int)          { s.PW, s.PH = w, h }
 func (s *State) setColor(r, g, b, a float32) { s.R, s.G, s.B, s.A = r, g, b, a }
@@ -0,0 +1,203 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Caves holds the parameters for carving caves into the solid ground of
+// a volume. Caves are grown with cellular automata: random grid points
+// are opened and then smoothed by making each grid point match most of
+// its neighbours. Separate caves are joined by tunnels so that every
+// cave can be reached, and shafts are dug up to the surface for the
+// cave entrances. For example:
+//    v := synth.NewVolume(64, 32, 64)
+//    v.Fill(synth.NewSimplex(seed), 0.05, 31, 0.2)
+//    entrances := synth.NewCaves().Carve(v, nil, seed)
+//    vb, nb, fb := v.Mesh()
+type Caves struct {
+	Open      float64 // 0 to 1. Chance a grid point starts open. Default 0.5.
+	Passes    int     // Number of smoothing passes. Default 4.
+	MinSize   int     // Smaller caves are filled in. Default 8 grid points.
+	Roof      float64 // Solid grid points between caves and surface. Default 3.
+	Radius    float64 // Radius of tunnels and entrance shafts. Default 1.5.
+	Entrances int     // Number of entrances. Default 1.
+}
+
+// NewCaves returns cave parameters with reasonable defaults.
+func NewCaves() *Caves {
+	return &Caves{Open: 0.5, Passes: 4, MinSize: 8, Roof: 3, Radius: 1.5, Entrances: 1}
+}
+
+// Carve digs caves into the volume and returns the grid locations where
+// the entrances reach the surface. The optional surface gives the ground
+// height in grid points for each volume x, z, ie: the tile heights used to
+// fill the volume. Use nil to treat the top of the volume as the surface.
+// Caves stay inside the volume and below the surface. The random seed is
+// injected so that identical results can be re-created.
+func (c *Caves) Carve(v *Volume, surface [][]float64, seed int64) (entrances [][3]float64) {
+	sx, sy, sz := v.Size()
+	ground := func(x, z int) float64 {
+		if surface == nil {
+			return float64(sy - 1)
+		}
+		return surface[x][z]
+	}
+	inside := func(x, y, z int) bool {
+		return x > 0 && y > 0 && z > 0 && x < sx-1 && z < sz-1 && float64(y) < ground(x, z)-c.Roof
+	}
+	index := func(x, y, z int) int { return (z*sy+y)*sx + x }
+	rgen := rand.New(rand.NewSource(seed))
+
+	// randomly open grid points and smooth them into caves.
+	open := make([]bool, sx*sy*sz)
+	for z := 0; z < sz; z++ {
+		for y := 0; y < sy; y++ {
+			for x := 0; x < sx; x++ {
+				open[index(x, y, z)] = inside(x, y, z) && rgen.Float64() < c.Open
+			}
+		}
+	}
+	for pass := 0; pass < c.Passes; pass++ {
+		next := make([]bool, len(open))
+		for z := 1; z < sz-1; z++ {
+			for y := 1; y < sy-1; y++ {
+				for x := 1; x < sx-1; x++ {
+					if !inside(x, y, z) {
+						continue
+					}
+					count := 0
+					for _, n := range around3D {
+						if open[index(x+n[0], y+n[1], z+n[2])] {
+							count++
+						}
+					}
+					next[index(x, y, z)] = count > 13 || (count == 13 && open[index(x, y, z)])
+				}
+			}
+		}
+		open = next
+	}
+
+	// keep the larger caves, largest first.
+	caves := [][][3]int{}
+	seen := make([]bool, len(open))
+	for z := 0; z < sz; z++ {
+		for y := 0; y < sy; y++ {
+			for x := 0; x < sx; x++ {
+				if open[index(x, y, z)] && !seen[index(x, y, z)] {
+					cave := connected(open, seen, sx, sy, sz, [3]int{x, y, z})
+					if len(cave) >= c.MinSize {
+						caves = append(caves, cave)
+					}
+				}
+			}
+		}
+	}
+	if len(caves) == 0 {
+		return nil
+	}
+	sort.SliceStable(caves, func(i, j int) bool { return len(caves[i]) > len(caves[j]) })
+	for _, cave := range caves {
+		for _, p := range cave {
+			v.Set(p[0], p[1], p[2], math.Min(v.At(p[0], p[1], p[2]), -1))
+		}
+	}
+
+	// join each cave to the closest of the caves already joined.
+	joined := append([][3]int{}, caves[0]...)
+	for _, cave := range caves[1:] {
+		a := closest3D(cave, cave[0])
+		b := closest3D(joined, a)
+		a = closest3D(cave, b)
+		c.tunnel(v, a, b)
+		joined = append(joined, cave...)
+	}
+
+	// dig shafts up from the cave points closest to the surface,
+	// keeping the entrances apart.
+	sort.SliceStable(joined, func(i, j int) bool {
+		pi, pj := joined[i], joined[j]
+		return ground(pi[0], pi[2])-float64(pi[1]) < ground(pj[0], pj[2])-float64(pj[1])
+	})
+	apart := float64(sx+sz) / 8
+	for _, p := range joined {
+		if len(entrances) >= c.Entrances {
+			break
+		}
+		top := [3]float64{float64(p[0]), ground(p[0], p[2]), float64(p[2])}
+		near := false
+		for _, e := range entrances {
+			near = near || math.Hypot(e[0]-top[0], e[2]-top[2]) < apart
+		}
+		if !near {
+			c.tunnel(v, p, [3]int{p[0], int(math.Ceil(top[1])) + 1, p[2]})
+			entrances = append(entrances, top)
+		}
+	}
+	return entrances
+}
+
+// tunnel digs a straight tunnel between grid points a and b.
+func (c *Caves) tunnel(v *Volume, a, b [3]int) {
+	dx, dy, dz := float64(b[0]-a[0]), float64(b[1]-a[1]), float64(b[2]-a[2])
+	steps := int(math.Ceil(math.Sqrt(dx*dx+dy*dy+dz*dz) * 2)) // half grid steps.
+	for s := 0; s <= steps; s++ {
+		t := float64(s) / math.Max(1, float64(steps))
+		v.Dig(float64(a[0])+dx*t, float64(a[1])+dy*t, float64(a[2])+dz*t, c.Radius)
+	}
+}
+
+// around3D are the offsets to the 26 surrounding grid points.
+var around3D [][3]int
+
+// init creates the neighbour offsets.
+func init() {
+	for z := -1; z <= 1; z++ {
+		for y := -1; y <= 1; y++ {
+			for x := -1; x <= 1; x++ {
+				if x != 0 || y != 0 || z != 0 {
+					around3D = append(around3D, [3]int{x, y, z})
+				}
+			}
+		}
+	}
+}
+
+// connected returns the open grid points that can be reached from
+// start by moving along the grid axes, marking them as seen.
+func connected(open, seen []bool, sx, sy, sz int, start [3]int) (cave [][3]int) {
+	index := func(p [3]int) int { return (p[2]*sy+p[1])*sx + p[0] }
+	seen[index(start)] = true
+	todo := [][3]int{start}
+	for len(todo) > 0 {
+		p := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		cave = append(cave, p)
+		for _, n := range [][3]int{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}} {
+			q := [3]int{p[0] + n[0], p[1] + n[1], p[2] + n[2]}
+			if q[0] >= 0 && q[1] >= 0 && q[2] >= 0 && q[0] < sx && q[1] < sy && q[2] < sz &&
+				open[index(q)] && !seen[index(q)] {
+				seen[index(q)] = true
+				todo = append(todo, q)
+			}
+		}
+	}
+	return cave
+}
+
+// closest3D returns the grid point closest to p.
+func closest3D(points [][3]int, p [3]int) (near [3]int) {
+	best := math.MaxInt64
+	for _, q := range points {
+		dx, dy, dz := q[0]-p[0], q[1]-p[1], q[2]-p[2]
+		if d := dx*dx + dy*dy + dz*dz; d < best {
+			near, best = q, d
+		}
+	}
+	return near
+}
@@ -0,0 +1,53 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"testing"
+)
+
+// Every cave can be reached from the entrances.
+func TestCaves(t *testing.T) {
+	v := NewVolume(32, 32, 32)
+	ground := make([][]float64, 32)
+	for x := range ground {
+		ground[x] = make([]float64, 32)
+		for z := range ground[x] {
+			ground[x][z] = 20 + float64(x)/4
+			for y := 0; float64(y) <= ground[x][z]; y++ {
+				v.Set(x, y, z, 1)
+			}
+		}
+	}
+	c := NewCaves()
+	c.Entrances = 2
+	entrances := c.Carve(v, ground, 123)
+	if len(entrances) != 2 || entrances[0][1] != ground[int(entrances[0][0])][int(entrances[0][2])] {
+		t.Fatalf("Expected entrances at the surface %v", entrances)
+	}
+
+	// flood the air down from the sky.
+	open := make([]bool, 32*32*32)
+	cells := 0
+	for z := 0; z < 32; z++ {
+		for y := 0; y < 32; y++ {
+			for x := 0; x < 32; x++ {
+				open[(z*32+y)*32+x] = v.At(x, y, z) < 0
+				if open[(z*32+y)*32+x] && float64(y) < ground[x][z] {
+					cells++
+				}
+			}
+		}
+	}
+	if cells < 100 {
+		t.Fatalf("Expected caves, got %d open underground points", cells)
+	}
+	seen := make([]bool, len(open))
+	connected(open, seen, 32, 32, 32, [3]int{0, 31, 0})
+	for cnt := range open {
+		if open[cnt] && !seen[cnt] {
+			t.Fatalf("Expected connected caves, point %d %d %d is cut off", cnt%32, cnt/32%32, cnt/1024)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Erosion holds the parameters for the erosion passes that turn raw
+// noise heights into valleys and ridgelines. The passes change tile
+// heights in place. Heights are in the same units as the tile spacing,
+// so noise heights from -1 to 1 usually need scaling before erosion.
+// Tiles are eroded independently, so adjacent tiles eroded separately
+// will not match at their edges.
+//
+// Hydraulic erosion simulates rain drops that pick up sediment as
+// they run downhill and drop it as they slow down. Based on:
+//    Hans Beyer, "Implementation of a method for hydraulic erosion", 2015.
+// Thermal erosion moves material from steep slopes to the cells
+// below until slopes are no steeper than the Talus height difference.
+type Erosion struct {
+	Life      int     // Maximum number of steps for a drop.
+	Inertia   float64 // 0 to 1. Higher values keep drops moving straight.
+	Capacity  float64 // Sediment a drop can hold per slope, speed, and water.
+	MinSlope  float64 // Keeps flat areas carrying some sediment.
+	Deposit   float64 // 0 to 1. Amount of extra sediment dropped each step.
+	Erode     float64 // 0 to 1. Amount of missing sediment picked up each step.
+	Evaporate float64 // 0 to 1. Amount of water lost each step.
+	Gravity   float64 // Drop acceleration on slopes.
+
+	Talus float64 // Steepest stable height difference between neighbours.
+	Slide float64 // 0 to 1. Amount of excess height moved each pass.
+}
+
+// NewErosion returns erosion parameters with reasonable defaults.
+func NewErosion() *Erosion {
+	return &Erosion{Life: 30, Inertia: 0.05, Capacity: 4, MinSlope: 0.01,
+		Deposit: 0.3, Erode: 0.3, Evaporate: 0.01, Gravity: 4,
+		Talus: 0.5, Slide: 0.5}
+}
+
+// Hydraulic erodes the tile heights by running the given number of rain
+// drops from random locations. The random seed is injected so that
+// identical results can be re-created.
+func (e *Erosion) Hydraulic(t Tile, drops int, seed int64) {
+	topo := t.Topo()
+	w, h := t.Size()
+	if w < 2 || h < 2 {
+		return
+	}
+	rgen := rand.New(rand.NewSource(seed))
+	for cnt := 0; cnt < drops; cnt++ {
+		x, y := rgen.Float64()*float64(w-1), rgen.Float64()*float64(h-1)
+		dx, dy, speed, water, sediment := 0.0, 0.0, 1.0, 1.0, 0.0
+		for step := 0; step < e.Life; step++ {
+			height, gx, gy := slope(topo, x, y)
+
+			// turn the drop downhill and move one cell.
+			dx = dx*e.Inertia - gx*(1-e.Inertia)
+			dy = dy*e.Inertia - gy*(1-e.Inertia)
+			length := math.Sqrt(dx*dx + dy*dy)
+			if length == 0 {
+				break // stuck in a pit or on a flat.
+			}
+			dx, dy = dx/length, dy/length
+			nx, ny := x+dx, y+dy
+			if nx < 0 || ny < 0 || nx >= float64(w-1) || ny >= float64(h-1) {
+				break // ran off the tile.
+			}
+			dh, _, _ := slope(topo, nx, ny)
+			dh -= height
+
+			// drop sediment when full or going uphill, otherwise pick it up.
+			capacity := math.Max(-dh, e.MinSlope) * speed * water * e.Capacity
+			if sediment > capacity || dh > 0 {
+				amount := (sediment - capacity) * e.Deposit
+				if dh > 0 {
+					amount = math.Min(dh, sediment) // fill the pit.
+				}
+				sediment -= amount
+				spread(topo, x, y, amount)
+			} else {
+				amount := math.Min((capacity-sediment)*e.Erode, -dh)
+				sediment += amount
+				spread(topo, x, y, -amount)
+			}
+			speed = math.Sqrt(math.Max(0, speed*speed-dh*e.Gravity))
+			water *= 1 - e.Evaporate
+			x, y = nx, ny
+		}
+	}
+}
+
+// Thermal erodes the tile heights by moving material from each cell to
+// its lowest neighbour when the height difference is more than Talus.
+// More passes give more stable slopes. Total height is unchanged.
+func (e *Erosion) Thermal(t Tile, passes int) {
+	topo := t.Topo()
+	w, h := t.Size()
+	for cnt := 0; cnt < passes; cnt++ {
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				lx, ly, drop := x, y, 0.0
+				for _, n := range neighbours {
+					ax, ay := x+n.x, y+n.y
+					if ax >= 0 && ay >= 0 && ax < w && ay < h {
+						if d := topo[x][y] - topo[ax][ay]; d > drop {
+							lx, ly, drop = ax, ay, d
+						}
+					}
+				}
+				if drop > e.Talus {
+					amount := (drop - e.Talus) * 0.5 * e.Slide
+					topo[x][y] -= amount
+					topo[lx][ly] += amount
+				}
+			}
+		}
+	}
+}
+
+// neighbours are the offsets to the 4 adjacent cells.
+var neighbours = []point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+
+// slope returns the bilinear interpolated height and gradient at x, y
+// which is expected to be inside the topo cells.
+func slope(topo [][]float64, x, y float64) (height, gx, gy float64) {
+	cx, cy := int(x), int(y)
+	u, v := x-float64(cx), y-float64(cy)
+	h00, h10 := topo[cx][cy], topo[cx+1][cy]
+	h01, h11 := topo[cx][cy+1], topo[cx+1][cy+1]
+	gx = (h10-h00)*(1-v) + (h11-h01)*v
+	gy = (h01-h00)*(1-u) + (h11-h10)*u
+	height = h00*(1-u)*(1-v) + h10*u*(1-v) + h01*(1-u)*v + h11*u*v
+	return height, gx, gy
+}
+
+// spread adds the amount to the 4 cells around x, y weighted
+// by how close x, y is to each cell.
+func spread(topo [][]float64, x, y, amount float64) {
+	cx, cy := int(x), int(y)
+	u, v := x-float64(cx), y-float64(cy)
+	topo[cx][cy] += amount * (1 - u) * (1 - v)
+	topo[cx+1][cy] += amount * u * (1 - v)
+	topo[cx][cy+1] += amount * (1 - u) * v
+	topo[cx+1][cy+1] += amount * u * v
+}
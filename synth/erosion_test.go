@@ -0,0 +1,77 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"testing"
+)
+
+// Rain drops carve the same valleys for the same seed. Sediment
+// carried off the tile is lost.
+func TestHydraulic(t *testing.T) {
+	l := newLand(64, 123)
+	t0, t1 := l.newTile(0, 0, 0), l.newTile(0, 0, 0)
+	scale(t0, 20)
+	scale(t1, 20)
+	before := height(t0)
+	e := NewErosion()
+	e.Hydraulic(t0, 2000, 7)
+	if after := height(t0); after >= before {
+		t.Errorf("Expected eroded heights %f %f", before, after)
+	}
+	e.Hydraulic(t1, 2000, 7)
+	if t0.topo[20][30] != t1.topo[20][30] || t0.topo[40][10] != t1.topo[40][10] {
+		t.Error("Expected repeatable erosion")
+	}
+}
+
+// Steep slopes collapse until they are stable.
+func TestThermal(t *testing.T) {
+	tile := newTile(16, 16, 0, 0, 0)
+	tile.topo[8][8] = 20
+	e := NewErosion()
+	e.Thermal(tile, 500)
+	if total := height(tile); total < 19.999 || total > 20.001 {
+		t.Errorf("Expected height to be moved, not lost %f", total)
+	}
+	if r := roughness(tile); r > e.Talus+0.01 {
+		t.Errorf("Expected stable slopes, got %f", r)
+	}
+}
+
+// scale multiplies the tile heights.
+func scale(t *tile, s float64) {
+	for x := range t.topo {
+		for y := range t.topo[x] {
+			t.topo[x][y] *= s
+		}
+	}
+}
+
+// height returns the sum of the tile heights.
+func height(t *tile) (total float64) {
+	for x := range t.topo {
+		for y := range t.topo[x] {
+			total += t.topo[x][y]
+		}
+	}
+	return total
+}
+
+// roughness returns the steepest height difference between neighbours.
+func roughness(t *tile) (steepest float64) {
+	for x := 0; x < len(t.topo)-1; x++ {
+		for y := 0; y < len(t.topo[x])-1; y++ {
+			for _, d := range []float64{t.topo[x][y] - t.topo[x+1][y], t.topo[x][y] - t.topo[x][y+1]} {
+				if d < 0 {
+					d = -d
+				}
+				if d > steepest {
+					steepest = d
+				}
+			}
+		}
+	}
+	return steepest
+}
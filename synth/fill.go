@@ -0,0 +1,83 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"runtime"
+	"sync"
+)
+
+// fill.go generates blocks of noise values using all the available
+// processors. Generating noise one value at a time dominates the time
+// it takes to create land. Noise generators only read their settings
+// while generating values, so one generator can be shared by many
+// goroutines.
+
+// Fill2D fills dst with noise values for a grid that is width values
+// wide, one row after another. The value for column i, row j is
+// n.Gen2D(x0+i*step, y0+j*step). The rows are split between goroutines.
+// Coordinate products are explicitly rounded so that fused multiply-add
+// does not change the values on some platforms.
+func Fill2D(n Noise, dst []float64, width int, x0, y0, step float64) {
+	if width <= 0 {
+		return
+	}
+	parallel(len(dst)/width, func(row int) {
+		y := y0 + float64(float64(row)*step)
+		values := dst[row*width : (row+1)*width]
+		for i := range values {
+			values[i] = n.Gen2D(x0+float64(float64(i)*step), y)
+		}
+	})
+}
+
+// Fill3D fills dst with noise values for a block that is width values
+// wide and height values high, one row after another and one layer after
+// another. The value for column i, row j, layer k is
+// n.Gen3D(x0+i*step, y0+j*step, z0+k*step). The rows are split between
+// goroutines.
+func Fill3D(n Noise, dst []float64, width, height int, x0, y0, z0, step float64) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	parallel(len(dst)/width, func(row int) {
+		y := y0 + float64(float64(row%height)*step)
+		z := z0 + float64(float64(row/height)*step)
+		values := dst[row*width : (row+1)*width]
+		for i := range values {
+			values[i] = n.Gen3D(x0+float64(float64(i)*step), y, z)
+		}
+	})
+}
+
+// parallel calls work for each of count jobs, sharing the jobs
+// between one goroutine per processor. Returns once all jobs are done.
+func parallel(count int, work func(job int)) {
+	workers := runtime.NumCPU()
+	if workers > count {
+		workers = count
+	}
+	if workers <= 1 {
+		for job := 0; job < count; job++ {
+			work(job)
+		}
+		return
+	}
+	jobs := make(chan int, count)
+	for job := 0; job < count; job++ {
+		jobs <- job
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for cnt := 0; cnt < workers; cnt++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				work(job)
+			}
+		}()
+	}
+	wg.Wait()
+}
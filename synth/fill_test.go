@@ -0,0 +1,59 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"testing"
+)
+
+// Batches match the values generated one at a time.
+func TestFill(t *testing.T) {
+	n := Fbm(NewSimplex(123), 4, 1, 2, 0.5)
+	flat, block := make([]float64, 7*5), make([]float64, 7*5*3)
+	Fill2D(n, flat, 7, 1, 2, 0.25)
+	Fill3D(n, block, 7, 5, 1, 2, 3, 0.25)
+	for k := 0; k < 3; k++ {
+		for j := 0; j < 5; j++ {
+			for i := 0; i < 7; i++ {
+				x, y, z := 1+float64(i)*0.25, 2+float64(j)*0.25, 3+float64(k)*0.25
+				if k == 0 && flat[j*7+i] != n.Gen2D(x, y) {
+					t.Fatalf("Expected 2D value at %d %d", i, j)
+				}
+				if block[(k*5+j)*7+i] != n.Gen3D(x, y, z) {
+					t.Fatalf("Expected 3D value at %d %d %d", i, j, k)
+				}
+			}
+		}
+	}
+}
+
+// Batches match per point values for steps that are not exact in binary.
+// The coordinates are rounded the same way so that platforms with fused
+// multiply-add, ie: arm64, give the same values.
+func TestFillInexactStep(t *testing.T) {
+	n := NewSimplexNoise(123)
+	x0, y0, step := 0.3, -1.7, 0.01
+	dst := make([]float64, 16*16)
+	Fill2D(n, dst, 16, x0, y0, step)
+	for j := 0; j < 16; j++ {
+		for i := 0; i < 16; i++ {
+			x, y := x0+float64(float64(i)*step), y0+float64(float64(j)*step)
+			if got, want := dst[j*16+i], n.Gen2D(x, y); got != want {
+				t.Fatalf("Expected %v at %d %d, got %v", want, i, j, got)
+			}
+		}
+	}
+}
+
+// benchmarks : go test -bench Fill
+//
+// Compare to BenchmarkTile1 which generates 6 octaves per value.
+//   BenchmarkFill2D             80      14342852 ns/op (single processor)
+func BenchmarkFill2D(b *testing.B) {
+	n := Fbm(NewSimplex(123), 6, 1, 2, 0.5)
+	dst := make([]float64, 256*256)
+	for cnt := 0; cnt < b.N; cnt++ {
+		Fill2D(n, dst, 256, 0, 0, 0.01)
+	}
+}
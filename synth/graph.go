@@ -0,0 +1,279 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+)
+
+// graph.go composes noise generators into noise graphs. Each node is
+// a Noise that modifies or combines the values of its source Noise,
+// so complex terrain can be described by chaining nodes. For example
+// mountains that rise out of rolling hills:
+//    base := synth.NewSimplex(seed)
+//    hills := synth.Fbm(base, 4, 2, 2, 0.5)
+//    peaks := synth.ScaleBias(synth.NewRidgedNoise(seed), 2, -1)
+//    mask := synth.Warp(synth.Fbm(base, 2, 0.5, 2, 0.5), base, 0.3)
+//    land := synth.Select(mask, hills, peaks, 0.2, 0.1)
+
+// NewSimplex returns the basic simplex noise generator using the
+// given seed. Generated values are from -1 to 1. Use 0 for the seed
+// to create a new random generator.
+func NewSimplex(seed int64) Noise { return newSimplex(seed) }
+
+// Const returns the value v everywhere.
+func Const(v float64) Noise { return constant(v) }
+
+// constant implements Const.
+type constant float64
+
+// Gen2D implements Noise.
+func (c constant) Gen2D(x, y float64) float64 { return float64(c) }
+
+// Gen3D implements Noise.
+func (c constant) Gen3D(x, y, z float64) float64 { return float64(c) }
+
+// =============================================================================
+
+// Fbm returns fractal brownian motion noise created by summing octaves
+// of noise n. The first octave samples n at the given frequency. Each
+// following octave multiplies the frequency by lacunarity and the
+// amplitude by persistence. The result is normalized so that it has
+// the same range as n.
+func Fbm(n Noise, octaves int, frequency, lacunarity, persistence float64) Noise {
+	return &fbm{n: n, o: octaves, f: frequency, l: lacunarity, p: persistence}
+}
+
+// fbm implements Fbm.
+type fbm struct {
+	n       Noise   // Noise source.
+	o       int     // Number of octaves.
+	f, l, p float64 // Frequency, lacunarity, persistence.
+}
+
+// Gen2D implements Noise.
+func (fb *fbm) Gen2D(x, y float64) float64 {
+	total, max, freq, amplitude := 0.0, 0.0, fb.f, 1.0
+	for o := 0; o < fb.o; o++ {
+		total += float64(fb.n.Gen2D(float64(x*freq), float64(y*freq)) * amplitude)
+		max += amplitude
+		freq *= fb.l
+		amplitude *= fb.p
+	}
+	if max == 0 {
+		return 0
+	}
+	return total / max
+}
+
+// Gen3D implements Noise.
+func (fb *fbm) Gen3D(x, y, z float64) float64 {
+	total, max, freq, amplitude := 0.0, 0.0, fb.f, 1.0
+	for o := 0; o < fb.o; o++ {
+		total += float64(fb.n.Gen3D(float64(x*freq), float64(y*freq), float64(z*freq)) * amplitude)
+		max += amplitude
+		freq *= fb.l
+		amplitude *= fb.p
+	}
+	if max == 0 {
+		return 0
+	}
+	return total / max
+}
+
+// =============================================================================
+
+// Warp returns noise n sampled at coordinates that are displaced by
+// the warp noise w scaled by amount. Domain warping bends and twists
+// the features of n, ie: for eroded looking terrain or swirling clouds.
+func Warp(n, w Noise, amount float64) Noise { return &warp{n: n, w: w, amount: amount} }
+
+// warp implements Warp.
+type warp struct {
+	n, w   Noise   // Noise and warp noise.
+	amount float64 // Warp displacement scale.
+}
+
+// Offsets used to sample independent warp values for each axis.
+const (
+	warpY = 5.2
+	warpZ = 9.7
+)
+
+// Gen2D implements Noise.
+func (wp *warp) Gen2D(x, y float64) float64 {
+	dx := wp.w.Gen2D(x, y)
+	dy := wp.w.Gen2D(x+warpY, y+warpY)
+	return wp.n.Gen2D(x+dx*wp.amount, y+dy*wp.amount)
+}
+
+// Gen3D implements Noise.
+func (wp *warp) Gen3D(x, y, z float64) float64 {
+	dx := wp.w.Gen3D(x, y, z)
+	dy := wp.w.Gen3D(x+warpY, y+warpY, z+warpY)
+	dz := wp.w.Gen3D(x+warpZ, y+warpZ, z+warpZ)
+	return wp.n.Gen3D(x+dx*wp.amount, y+dy*wp.amount, z+dz*wp.amount)
+}
+
+// =============================================================================
+
+// Seamless returns noise n repeated every w, h, and d units along x, y,
+// and z so that it tiles without seams, ie: for textures, or for worlds
+// that wrap around. Use 0 for axes that don't repeat. The repeating value
+// is a blend of n sampled once per period offset, and is rescaled to
+// keep its contrast where the blended samples are evenly weighted.
+func Seamless(n Noise, w, h, d float64) Noise { return &seamless{n: n, w: w, h: h, d: d} }
+
+// seamless implements Seamless.
+type seamless struct {
+	n       Noise   // Noise source.
+	w, h, d float64 // Repeat period along x, y, z.
+}
+
+// Gen2D implements Noise.
+func (s *seamless) Gen2D(x, y float64) float64 {
+	xs, xw := period(x, s.w)
+	ys, yw := period(y, s.h)
+	total, weights := 0.0, 0.0
+	for i := range xs {
+		for j := range ys {
+			if weight := xw[i] * yw[j]; weight > 0 {
+				total += s.n.Gen2D(xs[i], ys[j]) * weight
+				weights += weight * weight
+			}
+		}
+	}
+	return total / math.Sqrt(weights)
+}
+
+// Gen3D implements Noise.
+func (s *seamless) Gen3D(x, y, z float64) float64 {
+	xs, xw := period(x, s.w)
+	ys, yw := period(y, s.h)
+	zs, zw := period(z, s.d)
+	total, weights := 0.0, 0.0
+	for i := range xs {
+		for j := range ys {
+			for k := range zs {
+				if weight := xw[i] * yw[j] * zw[k]; weight > 0 {
+					total += s.n.Gen3D(xs[i], ys[j], zs[k]) * weight
+					weights += weight * weight
+				}
+			}
+		}
+	}
+	return total / math.Sqrt(weights)
+}
+
+// period returns the sample coordinates and blend weights for v
+// repeating every p units. The blend fades from the sample at v
+// to the sample one period back as v crosses the period.
+func period(v, p float64) (samples, weights [2]float64) {
+	if p <= 0 {
+		return [2]float64{v, v}, [2]float64{1, 0}
+	}
+	v = math.Mod(v, p)
+	if v < 0 {
+		v += p
+	}
+	t := v / p
+	return [2]float64{v, v - p}, [2]float64{1 - t, t}
+}
+
+// =============================================================================
+
+// ScaleBias returns the values of noise n multiplied by scale and then
+// added to bias, ie: ScaleBias(n, 0.5, 0.5) maps -1 to 1 into 0 to 1.
+func ScaleBias(n Noise, scale, bias float64) Noise {
+	return &combiner{a: n, b: Const(0), op: func(a, b float64) float64 { return a*scale + bias }}
+}
+
+// Add returns the sum of the values of noise a and b.
+func Add(a, b Noise) Noise {
+	return &combiner{a: a, b: b, op: func(a, b float64) float64 { return a + b }}
+}
+
+// Mul returns the product of the values of noise a and b, ie: for
+// masking one noise by another.
+func Mul(a, b Noise) Noise {
+	return &combiner{a: a, b: b, op: func(a, b float64) float64 { return a * b }}
+}
+
+// Min returns the smaller of the values of noise a and b.
+func Min(a, b Noise) Noise { return &combiner{a: a, b: b, op: math.Min} }
+
+// Max returns the larger of the values of noise a and b.
+func Max(a, b Noise) Noise { return &combiner{a: a, b: b, op: math.Max} }
+
+// combiner implements the nodes that combine two noise values.
+type combiner struct {
+	a, b Noise                      // Noise sources.
+	op   func(a, b float64) float64 // Combines the source values.
+}
+
+// Gen2D implements Noise.
+func (c *combiner) Gen2D(x, y float64) float64 {
+	return c.op(c.a.Gen2D(x, y), c.b.Gen2D(x, y))
+}
+
+// Gen3D implements Noise.
+func (c *combiner) Gen3D(x, y, z float64) float64 {
+	return c.op(c.a.Gen3D(x, y, z), c.b.Gen3D(x, y, z))
+}
+
+// =============================================================================
+
+// Select returns the values of noise a where the control noise ctl is
+// below threshold and the values of noise b where ctl is above threshold.
+// The values are smoothly blended where ctl is within falloff of the
+// threshold. Use 0 falloff for sharp edges.
+func Select(ctl, a, b Noise, threshold, falloff float64) Noise {
+	return &selector{ctl: ctl, a: a, b: b, threshold: threshold, falloff: falloff}
+}
+
+// selector implements Select.
+type selector struct {
+	ctl, a, b          Noise   // Control and source noise.
+	threshold, falloff float64 // Selection boundary.
+}
+
+// Gen2D implements Noise.
+func (s *selector) Gen2D(x, y float64) float64 {
+	switch blend := s.blend(s.ctl.Gen2D(x, y)); blend {
+	case 0:
+		return s.a.Gen2D(x, y)
+	case 1:
+		return s.b.Gen2D(x, y)
+	default:
+		return lerp(s.a.Gen2D(x, y), s.b.Gen2D(x, y), blend)
+	}
+}
+
+// Gen3D implements Noise.
+func (s *selector) Gen3D(x, y, z float64) float64 {
+	switch blend := s.blend(s.ctl.Gen3D(x, y, z)); blend {
+	case 0:
+		return s.a.Gen3D(x, y, z)
+	case 1:
+		return s.b.Gen3D(x, y, z)
+	default:
+		return lerp(s.a.Gen3D(x, y, z), s.b.Gen3D(x, y, z), blend)
+	}
+}
+
+// blend returns how much of b, from 0 to 1, is selected
+// for the control value c.
+func (s *selector) blend(c float64) float64 {
+	switch {
+	case c < s.threshold-s.falloff:
+		return 0
+	case c >= s.threshold+s.falloff:
+		return 1
+	}
+	t := (c - s.threshold + s.falloff) / (2 * s.falloff)
+	return t * t * (3 - 2*t) // smoothstep.
+}
+
+// lerp linearly interpolates from a to b by t.
+func lerp(a, b, t float64) float64 { return a + (b-a)*t }
@@ -0,0 +1,97 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCombiners(t *testing.T) {
+	a, b := Const(0.25), Const(-0.5)
+	for n, want := range map[Noise]float64{
+		Add(a, b): -0.25, Mul(a, b): -0.125, Min(a, b): -0.5, Max(a, b): 0.25,
+		ScaleBias(a, 2, 1): 1.5,
+	} {
+		if got := n.Gen2D(1, 2); got != want || n.Gen3D(1, 2, 3) != want {
+			t.Errorf("expected %f got %f", want, got)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	ctl, a, b := NewSimplex(123), Const(-1), Const(1)
+	sharp, smooth := Select(ctl, a, b, 0, 0), Select(ctl, a, b, 0, 0.5)
+	blended := false
+	for cnt := 0; cnt < 100; cnt++ {
+		x, y := float64(cnt)*0.17, float64(cnt)*0.23
+		c, s := ctl.Gen2D(x, y), sharp.Gen2D(x, y)
+		if (c < 0 && s != -1) || (c >= 0 && s != 1) {
+			t.Fatalf("bad selection %f for control %f", s, c)
+		}
+		v := smooth.Gen2D(x, y)
+		if v < -1 || v > 1 || (c > 0.5 && v != 1) || (c < -0.5 && v != -1) {
+			t.Fatalf("bad blend %f for control %f", v, c)
+		}
+		blended = blended || (v > -1 && v < 1)
+	}
+	if !blended {
+		t.Error("expected blended values")
+	}
+}
+
+// Fbm keeps the range of its source and warping changes the values.
+func TestFbmWarp(t *testing.T) {
+	base := NewSimplex(123)
+	fb := Fbm(base, 6, 1, 2, 0.5)
+	wp := Warp(fb, base, 0.5)
+	if Fbm(Const(0.75), 4, 1, 2, 0.5).Gen2D(3, 4) != 0.75 {
+		t.Error("expected fbm to be normalized")
+	}
+	changed := false
+	for cnt := 0; cnt < 100; cnt++ {
+		x, y, z := float64(cnt)*0.31, float64(cnt)*0.07, float64(cnt)*0.13
+		if v := fb.Gen3D(x, y, z); math.Abs(v) > 1 {
+			t.Fatalf("fbm value %f out of range", v)
+		}
+		changed = changed || wp.Gen2D(x, y) != fb.Gen2D(x, y)
+	}
+	if !changed {
+		t.Error("expected warped values")
+	}
+}
+
+// Noise graphs can be used to create land.
+func TestNoiseLand(t *testing.T) {
+	l := NewNoiseLand(16, Fbm(NewSimplex(123), 4, 4, 2, 0.5))
+	t0, t1 := l.NewTile(1, 0, 0), l.NewTile(1, 0, 0)
+	if t0.Topo()[3][5] != t1.Topo()[3][5] || t0.Topo()[3][5] == t0.Topo()[5][3] {
+		t.Errorf("expected repeatable land heights")
+	}
+	l.Fill3D(t0.SetFace(XPos))
+	if t0.Topo()[3][5] == t1.Topo()[3][5] {
+		t.Errorf("expected cube face heights")
+	}
+}
+
+// Seamless noise repeats along the tiled axes.
+func TestSeamless(t *testing.T) {
+	base := Fbm(NewSimplex(123), 4, 0.2, 2, 0.5)
+	flat, cube := Seamless(base, 16, 8, 0), Seamless(base, 16, 8, 4)
+	for cnt := 0; cnt < 50; cnt++ {
+		x, y, z := float64(cnt)*0.37, float64(cnt)*0.11, float64(cnt)*0.53
+		if v, w := flat.Gen2D(x, y), flat.Gen2D(x+16, y-8); math.Abs(v-w) > 1e-9 {
+			t.Fatalf("expected repeat at %f %f got %f %f", x, y, v, w)
+		}
+		if v, w := cube.Gen3D(x, y, z), cube.Gen3D(x-32, y+8, z+4); math.Abs(v-w) > 1e-9 {
+			t.Fatalf("expected 3D repeat at %f %f %f got %f %f", x, y, z, v, w)
+		}
+		if v, w := flat.Gen3D(x, y, z), flat.Gen3D(x, y, z+4); v == w {
+			t.Fatalf("expected z to not repeat %f", v)
+		}
+	}
+	if v, w := flat.Gen2D(15.999, 3), flat.Gen2D(0, 3); math.Abs(v-w) > 0.01 {
+		t.Errorf("expected seamless edge %f %f", v, w)
+	}
+}
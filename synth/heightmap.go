@@ -0,0 +1,102 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// heightmap.go reads and writes 16-bit heightmaps so that land tiles
+// can be edited in external terrain tools like World Machine or Blender.
+// Heightmap values from 0 to 65535 map to tile heights from low to high,
+// ie: -1 to 1 for generated land. Heightmap rows start at the top of the
+// image while tile heights have 0,0 at the bottom left.
+
+// WriteHeightPNG writes the tile heights as a 16-bit grayscale PNG image.
+// Heights outside low to high are clamped.
+func WriteHeightPNG(w io.Writer, t Tile, low, high float64) error {
+	width, height := t.Size()
+	img := image.NewGray16(image.Rect(0, 0, width, height))
+	topo := t.Topo()
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.SetGray16(x, height-1-y, color.Gray16{Y: toHeight16(topo[x][y], low, high)})
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// ReadHeightPNG reads a grayscale PNG image into a new tile.
+// 8-bit images are accepted, but lose height detail.
+func ReadHeightPNG(r io.Reader, low, high float64) (Tile, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("ReadHeightPNG: %s", err)
+	}
+	b := img.Bounds()
+	t := newTile(uint(b.Dx()), uint(b.Dy()), 0, 0, 0)
+	for x := 0; x < b.Dx(); x++ {
+		for y := 0; y < b.Dy(); y++ {
+			g := color.Gray16Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray16)
+			t.topo[x][b.Dy()-1-y] = fromHeight16(g.Y, low, high)
+		}
+	}
+	return t, nil
+}
+
+// WriteHeightRAW writes the tile heights as headerless little endian
+// 16-bit values, one row at a time. Often saved as a .r16 or .raw file.
+// Heights outside low to high are clamped.
+func WriteHeightRAW(w io.Writer, t Tile, low, high float64) error {
+	width, height := t.Size()
+	topo := t.Topo()
+	bw := bufio.NewWriter(w)
+	var value [2]byte
+	for y := height - 1; y >= 0; y-- {
+		for x := 0; x < width; x++ {
+			binary.LittleEndian.PutUint16(value[:], toHeight16(topo[x][y], low, high))
+			if _, err := bw.Write(value[:]); err != nil {
+				return fmt.Errorf("WriteHeightRAW: %s", err)
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("WriteHeightRAW: %s", err)
+	}
+	return nil
+}
+
+// ReadHeightRAW reads headerless little endian 16-bit values into a new
+// tile with the given width and height. RAW files have no size
+// information, so the size must match the file.
+func ReadHeightRAW(r io.Reader, width, height int, low, high float64) (Tile, error) {
+	values := make([]uint16, width*height)
+	if err := binary.Read(r, binary.LittleEndian, values); err != nil {
+		return nil, fmt.Errorf("ReadHeightRAW: expected %dx%d heights: %s", width, height, err)
+	}
+	t := newTile(uint(width), uint(height), 0, 0, 0)
+	for cnt, v := range values {
+		x, y := cnt%width, height-1-cnt/width
+		t.topo[x][y] = fromHeight16(v, low, high)
+	}
+	return t, nil
+}
+
+// toHeight16 maps a height from the low to high range to 0 to 65535.
+func toHeight16(h, low, high float64) uint16 {
+	ratio := math.Max(0, math.Min(1, (h-low)/(high-low)))
+	return uint16(math.Floor(ratio*math.MaxUint16 + 0.5))
+}
+
+// fromHeight16 maps a 16-bit value from 0 to 65535 to the low to high range.
+func fromHeight16(v uint16, low, high float64) float64 {
+	return low + float64(v)/math.MaxUint16*(high-low)
+}
@@ -0,0 +1,59 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// Heights survive a round trip to within the 16-bit precision.
+func TestHeightmaps(t *testing.T) {
+	tile := newLand(32, 123).newTile(0, 0, 0)
+	tile.topo[3][31] = 5 // clamped to high.
+	for name, trip := range map[string]func(b *bytes.Buffer) (Tile, error){
+		"png": func(b *bytes.Buffer) (Tile, error) {
+			if err := WriteHeightPNG(b, tile, -2, 2); err != nil {
+				return nil, err
+			}
+			return ReadHeightPNG(b, -2, 2)
+		},
+		"raw": func(b *bytes.Buffer) (Tile, error) {
+			if err := WriteHeightRAW(b, tile, -2, 2); err != nil {
+				return nil, err
+			}
+			return ReadHeightRAW(b, 32, 32, -2, 2)
+		},
+	} {
+		got, err := trip(&bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if w, h := got.Size(); w != 32 || h != 32 {
+			t.Fatalf("%s: unexpected size %d %d", name, w, h)
+		}
+		topo := got.Topo()
+		for x := range topo {
+			for y := range topo[x] {
+				want := math.Min(tile.topo[x][y], 2)
+				if math.Abs(topo[x][y]-want) > 4.0/math.MaxUint16 {
+					t.Fatalf("%s: height %f at %d,%d, expected %f", name, topo[x][y], x, y, want)
+				}
+			}
+		}
+	}
+
+	// the top left image pixel is the top left tile height.
+	b := &bytes.Buffer{}
+	WriteHeightPNG(b, tile, -2, 2)
+	if img, _ := png.Decode(b); img.(*image.Gray16).Gray16At(3, 0).Y != math.MaxUint16 {
+		t.Error("Expected top row first")
+	}
+	if _, err := ReadHeightRAW(bytes.NewReader(make([]byte, 10)), 32, 32, -1, 1); err == nil {
+		t.Error("Expected short RAW file error")
+	}
+}
@@ -22,7 +22,8 @@ type Land interface {
 }
 
 // NewLand initializes the procedural land generator. The seed determines
-// land shape, such that lands created from the same seed will be the same.
+// land shape, such that lands created from the same seed will be the same,
+// bit for bit, on all platforms.
 // The zoom determines the overall size of the land (limit to 8
 // or less pending stress testing). For example if tileSize is 256 then
 // increasing the level of detail results in the following sizes:
@@ -52,6 +53,20 @@ func NewLand(tileSize int, seed int64) Land {
 	return newLand(tileSize, seed)
 }
 
+// NewNoiseLand creates a land whose heights come from the given noise,
+// ie: a noise graph created with Fbm, Warp, Add, and Select. The whole
+// land covers noise coordinates 0 to 1 at every zoom, so the noise
+// frequency determines the size of the land features. Noise octaves
+// are not added for higher zooms. Heights are the same bit for bit on
+// all platforms only for SimplexNoise and Fbm of simplex noise. Other
+// noise, ie: ridged, billow, Worley, Warp, and Seamless, may differ in
+// the lowest bits.
+func NewNoiseLand(tileSize int, n Noise) Land {
+	l := newLand(tileSize, 0)
+	l.src = n
+	return l
+}
+
 // Land interface
 // ============================================================================
 // land is a default implementation of Land
@@ -65,6 +80,7 @@ func NewLand(tileSize int, seed int64) Land {
 //   http://www.microimages.com/documentation/TechGuides/76BingStructure.pdf
 type land struct {
 	n    *simplex // expected to be  simplex noise maker.
+	src  Noise    // optional noise graph used instead of n.
 	seed int64    // for all random calcuations.
 	size int      // land tile width and height.
 }
@@ -100,7 +116,11 @@ func (l *land) newTile(atZoom, x, y int) *tile {
 // 0 to mapSize-1.
 func (l *land) Fill(landTile Tile) {
 	t, _ := landTile.(*tile)
-	if len(t.topo) == l.size && len(t.topo[0]) == l.size {
+	switch {
+	case len(t.topo) != l.size || len(t.topo[0]) != l.size:
+	case l.src != nil:
+		t.octaves2D(l.src, 1, 1, 1)
+	default:
 		t.gen2D(l.n)
 	}
 }
@@ -108,7 +128,11 @@ func (l *land) Fill(landTile Tile) {
 // Fill3D implements Land.
 func (l *land) Fill3D(landTile Tile) {
 	t, _ := landTile.(*tile)
-	if len(t.topo) == l.size && len(t.topo[0]) == l.size {
+	switch {
+	case len(t.topo) != l.size || len(t.topo[0]) != l.size:
+	case l.src != nil:
+		t.octaves3D(l.src, 1, 1, 1)
+	default:
 		t.gen3D(l.n)
 	}
 }
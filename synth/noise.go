@@ -3,7 +3,14 @@
 
 package synth
 
-// Noise exposes noise generating algorithms.
+import (
+	"math"
+	"time"
+)
+
+// Noise exposes noise generating algorithms. The noise generators
+// SimplexNoise, RidgedNoise, BillowNoise, and WorleyNoise are all Noise
+// so they can be used interchangeably for terrain and textures.
 type Noise interface {
 	Gen2D(x, y float64) float64
 	Gen3D(x, y, z float64) float64
@@ -37,11 +44,219 @@ func (sn *SimplexNoise) Gen2D(x, y float64) float64 {
 	nfreq := sn.F
 	amplitude := sn.G
 	for o := 0; o < sn.O; o++ {
-		xval := float64(x) * nfreq
-		yval := float64(y) * nfreq
-		total += sn.N.Gen2D(xval, yval) * amplitude
+		xval := float64(x * nfreq)
+		yval := float64(y * nfreq)
+		total += float64(sn.N.Gen2D(xval, yval) * amplitude)
+		nfreq *= sn.L
+		amplitude *= sn.G
+	}
+	return total
+}
+
+// Gen3D returns a generated noise value for the given x,y,z coordinate.
+// Used to generate 3D textures and volumes.
+func (sn *SimplexNoise) Gen3D(x, y, z float64) float64 {
+	total := 0.0
+	nfreq := sn.F
+	amplitude := sn.G
+	for o := 0; o < sn.O; o++ {
+		total += float64(sn.N.Gen3D(float64(x*nfreq), float64(y*nfreq), float64(z*nfreq)) * amplitude)
 		nfreq *= sn.L
 		amplitude *= sn.G
 	}
 	return total
 }
+
+// =============================================================================
+
+// RidgedNoise is ridged multifractal noise. It folds simplex noise
+// about zero to create sharp ridges, ie: for mountain ranges and
+// lightning. Each octave is weighted by the previous octave so
+// valleys stay smooth while ridges get more detailed.
+// Generated values are positive.
+type RidgedNoise struct {
+	F float64 // Higher frequency results in finer features.
+	G float64 // Gain limits final value amount.
+	L float64 // Using 2.0 gives scales of 1, 1/2, 1/4 for the octaves.
+	O int     // More octaves for sharper features.
+	W float64 // Octave weighting. Higher values give rougher ridges.
+	N Noise   // Simplex noise algorithm.
+}
+
+// NewRidgedNoise initializes a ridged noise generator using the given seed.
+func NewRidgedNoise(seed int64) *RidgedNoise {
+	return &RidgedNoise{F: 0.5, G: 0.55, L: 2.0, O: 6, W: 2.0, N: newSimplex(seed)}
+}
+
+// Gen2D returns a generated noise value for the given x,y coordinate.
+func (rn *RidgedNoise) Gen2D(x, y float64) float64 {
+	total, nfreq, amplitude, weight := 0.0, rn.F, rn.G, 1.0
+	for o := 0; o < rn.O; o++ {
+		total, weight = rn.ridge(rn.N.Gen2D(x*nfreq, y*nfreq), total, amplitude, weight)
+		nfreq *= rn.L
+		amplitude *= rn.G
+	}
+	return total
+}
+
+// Gen3D returns a generated noise value for the given x,y,z coordinate.
+func (rn *RidgedNoise) Gen3D(x, y, z float64) float64 {
+	total, nfreq, amplitude, weight := 0.0, rn.F, rn.G, 1.0
+	for o := 0; o < rn.O; o++ {
+		total, weight = rn.ridge(rn.N.Gen3D(x*nfreq, y*nfreq, z*nfreq), total, amplitude, weight)
+		nfreq *= rn.L
+		amplitude *= rn.G
+	}
+	return total
+}
+
+// ridge adds one octave of noise value n to the total. The returned
+// weight, from 0 to 1, is used for the next octave.
+func (rn *RidgedNoise) ridge(n, total, amplitude, weight float64) (float64, float64) {
+	signal := 1 - math.Abs(n)
+	signal *= signal * weight
+	return total + signal*amplitude, math.Max(0, math.Min(1, signal*rn.W))
+}
+
+// =============================================================================
+
+// BillowNoise folds simplex noise about zero to create rounded
+// lumps, ie: for clouds, rocks, and rolling hills.
+type BillowNoise struct {
+	F float64 // Higher frequency results in finer features.
+	G float64 // Gain limits final value amount.
+	L float64 // Using 2.0 gives scales of 1, 1/2, 1/4 for the octaves.
+	O int     // More octaves for sharper features.
+	N Noise   // Simplex noise algorithm.
+}
+
+// NewBillowNoise initializes a billow noise generator using the given seed.
+func NewBillowNoise(seed int64) *BillowNoise {
+	return &BillowNoise{F: 0.5, G: 0.55, L: 2.0, O: 6, N: newSimplex(seed)}
+}
+
+// Gen2D returns a generated noise value for the given x,y coordinate.
+func (bn *BillowNoise) Gen2D(x, y float64) float64 {
+	total, nfreq, amplitude := 0.0, bn.F, bn.G
+	for o := 0; o < bn.O; o++ {
+		total += (2*math.Abs(bn.N.Gen2D(x*nfreq, y*nfreq)) - 1) * amplitude
+		nfreq *= bn.L
+		amplitude *= bn.G
+	}
+	return total
+}
+
+// Gen3D returns a generated noise value for the given x,y,z coordinate.
+func (bn *BillowNoise) Gen3D(x, y, z float64) float64 {
+	total, nfreq, amplitude := 0.0, bn.F, bn.G
+	for o := 0; o < bn.O; o++ {
+		total += (2*math.Abs(bn.N.Gen3D(x*nfreq, y*nfreq, z*nfreq)) - 1) * amplitude
+		nfreq *= bn.L
+		amplitude *= bn.G
+	}
+	return total
+}
+
+// =============================================================================
+
+// WorleyNoise is cellular noise. Space is divided into cells that each
+// have one randomly placed feature point. The generated value is based
+// on the distances to the nearest feature points, ie: for stone walls,
+// scales, and cracked ground. Values range from 0 to about 1.
+//    https://en.wikipedia.org/wiki/Worley_noise
+type WorleyNoise struct {
+	F    float64 // Higher frequency results in smaller cells.
+	Cell int     // One of WorleyNear, WorleySecond, WorleyEdge.
+	seed uint64  // feature point locations.
+}
+
+// Worley noise cell values. WorleyNear is the distance to the nearest
+// feature point giving dark cell centers. WorleySecond is the distance to
+// the second nearest feature point. WorleyEdge is the difference between
+// the two giving dark cell borders.
+const (
+	WorleyNear = iota
+	WorleySecond
+	WorleyEdge
+)
+
+// NewWorleyNoise initializes a cellular noise generator using the given
+// seed. Use 0 for the seed to create new random cells.
+func NewWorleyNoise(seed int64) *WorleyNoise {
+	if seed == 0 { // create random seed if one not specified.
+		seed = time.Now().UnixNano()
+	}
+	return &WorleyNoise{F: 0.5, Cell: WorleyNear, seed: uint64(seed)}
+}
+
+// Gen2D returns a generated noise value for the given x,y coordinate.
+func (wn *WorleyNoise) Gen2D(x, y float64) float64 {
+	x, y = x*wn.F, y*wn.F
+	cx, cy := math.Floor(x), math.Floor(y)
+	near, second := math.MaxFloat64, math.MaxFloat64
+	for i := -1.0; i <= 1; i++ {
+		for j := -1.0; j <= 1; j++ {
+			h := wn.hash(cx+i, cy+j, 0)
+			dx := cx + i + unit(h) - x
+			dy := cy + j + unit(h>>21) - y
+			near, second = nearest(dx*dx+dy*dy, near, second)
+		}
+	}
+	return wn.value(near, second)
+}
+
+// Gen3D returns a generated noise value for the given x,y,z coordinate.
+func (wn *WorleyNoise) Gen3D(x, y, z float64) float64 {
+	x, y, z = x*wn.F, y*wn.F, z*wn.F
+	cx, cy, cz := math.Floor(x), math.Floor(y), math.Floor(z)
+	near, second := math.MaxFloat64, math.MaxFloat64
+	for i := -1.0; i <= 1; i++ {
+		for j := -1.0; j <= 1; j++ {
+			for k := -1.0; k <= 1; k++ {
+				h := wn.hash(cx+i, cy+j, cz+k)
+				dx := cx + i + unit(h) - x
+				dy := cy + j + unit(h>>21) - y
+				dz := cz + k + unit(h>>42) - z
+				near, second = nearest(dx*dx+dy*dy+dz*dz, near, second)
+			}
+		}
+	}
+	return wn.value(near, second)
+}
+
+// value returns the cell value for the squared distances
+// to the nearest and second nearest feature points.
+func (wn *WorleyNoise) value(near, second float64) float64 {
+	switch wn.Cell {
+	case WorleySecond:
+		return math.Sqrt(second)
+	case WorleyEdge:
+		return math.Sqrt(second) - math.Sqrt(near)
+	}
+	return math.Sqrt(near)
+}
+
+// hash scrambles the seed and cell coordinates so that each
+// cell has its own feature point. Uses the splitmix64 finalizer.
+func (wn *WorleyNoise) hash(x, y, z float64) uint64 {
+	h := wn.seed ^ uint64(int64(x))*0x9e3779b97f4a7c15
+	h ^= uint64(int64(y)) * 0xc2b2ae3d27d4eb4f
+	h ^= uint64(int64(z)) * 0x165667b19e3779f9
+	h = (h ^ h>>30) * 0xbf58476d1ce4e5b9
+	h = (h ^ h>>27) * 0x94d049bb133111eb
+	return h ^ h>>31
+}
+
+// unit returns a value from 0 to 1 using the low 21 bits of h.
+func unit(h uint64) float64 { return float64(h&0x1fffff) / 0x200000 }
+
+// nearest keeps the two smallest distances.
+func nearest(d, near, second float64) (float64, float64) {
+	switch {
+	case d < near:
+		return d, near
+	case d < second:
+		return near, d
+	}
+	return near, second
+}
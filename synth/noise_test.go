@@ -0,0 +1,58 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"testing"
+)
+
+// All the noise generators can be used as Noise and
+// give the same values for the same seed.
+func TestNoiseGenerators(t *testing.T) {
+	gens := map[string]func(seed int64) Noise{
+		"simplex": func(seed int64) Noise { return NewSimplexNoise(seed) },
+		"ridged":  func(seed int64) Noise { return NewRidgedNoise(seed) },
+		"billow":  func(seed int64) Noise { return NewBillowNoise(seed) },
+		"worley":  func(seed int64) Noise { return NewWorleyNoise(seed) },
+	}
+	for name, gen := range gens {
+		n0, n1, n2 := gen(123), gen(123), gen(321)
+		same, diff := true, false
+		for cnt := 0; cnt < 100; cnt++ {
+			x, y, z := float64(cnt)*0.37, float64(cnt)*-0.53, float64(cnt)*0.11
+			v0, v1 := n0.Gen2D(x, y), n1.Gen2D(x, y)
+			same = same && v0 == v1 && n0.Gen3D(x, y, z) == n1.Gen3D(x, y, z)
+			diff = diff || v0 != n2.Gen2D(x, y)
+		}
+		if !same || !diff {
+			t.Errorf("%s: expected values to depend only on the seed", name)
+		}
+	}
+}
+
+// Ridged values are positive and Worley values are distances.
+func TestNoiseRanges(t *testing.T) {
+	rn, wn := NewRidgedNoise(123), NewWorleyNoise(123)
+	edges := NewWorleyNoise(123)
+	edges.Cell = WorleyEdge
+	for cnt := 0; cnt < 1000; cnt++ {
+		x, y := float64(cnt)*0.91, float64(cnt%37)*1.3
+		if v := rn.Gen2D(x, y); v < 0 || v > 1.5 {
+			t.Fatalf("ridged value %f out of range at %f,%f", v, x, y)
+		}
+		if v := wn.Gen2D(x, y); v < 0 || v > 1.5 {
+			t.Fatalf("worley value %f out of range at %f,%f", v, x, y)
+		}
+		if v := edges.Gen3D(x, y, x); v < 0 || v > 1.8 {
+			t.Fatalf("worley edge value %f out of range at %f,%f", v, x, y)
+		}
+	}
+
+	// The value at a feature point is 0.
+	h := wn.hash(2, 3, 0)
+	fx, fy := (2+unit(h))/wn.F, (3+unit(h>>21))/wn.F
+	if v := wn.Gen2D(fx, fy); v > 1e-9 {
+		t.Errorf("expected 0 at feature point, got %f", v)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+)
+
+// Road holds the parameters for carving roads and paths into land tile
+// heights. A road follows a smooth curve through the given path points.
+// The land under the road is flattened to a road height that follows
+// the land, then blended back into the surrounding land. For example:
+//    road := synth.NewRoad()
+//    path := [][2]float64{{4, 4}, {30, 12}, {40, 60}}
+//    vb, nb, tb, fb := road.Carve(tile, path)
+// The returned road strip mesh sits just above the carved land.
+type Road struct {
+	Width  float64 // Road width in cells. Default 4.
+	Blend  float64 // Distance in cells to blend the road edges. Default 3.
+	Step   float64 // Distance in cells between road mesh sections. Default 1.
+	Smooth float64 // Distance in cells to smooth road heights. Default 8.
+	Lift   float64 // Height of the road mesh above the land. Default 0.01.
+}
+
+// NewRoad returns road parameters with reasonable defaults.
+func NewRoad() *Road { return &Road{Width: 4, Blend: 3, Step: 1, Smooth: 8, Lift: 0.01} }
+
+// Carve flattens the land along a Catmull-Rom curve through the path
+// points and returns a road strip mesh as vertex positions, normals,
+// texture coordinates, and triangle faces, ie: for Mesh.SetData and
+// Mesh.SetFaces. Path points are tile cell x, y locations. Tile cell x, y
+// is at vertex x, road height, y. Texture coordinates go from 0 to 1
+// across the road and increase by 1 for each Width along the road.
+func (r *Road) Carve(t Tile, path [][2]float64) (vb, nb, tb []float32, fb []uint16) {
+	topo := t.Topo()
+	w, h := t.Size()
+	centre := r.centre(path)
+	if len(centre) < 2 || w < 2 || h < 2 {
+		return nil, nil, nil, nil
+	}
+	heights := r.heights(topo, centre)
+
+	// find the closest road location for the cells near the road.
+	reach := r.Width/2 + r.Blend
+	dist, level := grid(w, h), grid(w, h)
+	for x := range dist {
+		for y := range dist[x] {
+			dist[x][y] = math.Inf(1)
+		}
+	}
+	for cnt := 0; cnt < len(centre)-1; cnt++ {
+		a, b := centre[cnt], centre[cnt+1]
+		x0, x1 := int(math.Max(0, math.Min(a[0], b[0])-reach)), int(math.Min(float64(w-1), math.Max(a[0], b[0])+reach+1))
+		y0, y1 := int(math.Max(0, math.Min(a[1], b[1])-reach)), int(math.Min(float64(h-1), math.Max(a[1], b[1])+reach+1))
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				d, along := closest(a, b, float64(x), float64(y))
+				if d < dist[x][y] {
+					dist[x][y], level[x][y] = d, lerp(heights[cnt], heights[cnt+1], along)
+				}
+			}
+		}
+	}
+
+	// flatten the land under the road and blend back at the edges.
+	for x := range topo {
+		for y := range topo[x] {
+			switch d := dist[x][y]; {
+			case d <= r.Width/2:
+				topo[x][y] = level[x][y]
+			case d < reach:
+				blend := (reach - d) / r.Blend
+				topo[x][y] = lerp(topo[x][y], level[x][y], blend*blend*(3-2*blend))
+			}
+		}
+	}
+	return r.strip(centre, heights)
+}
+
+// centre returns evenly spaced road centre locations along the curve.
+func (r *Road) centre(path [][2]float64) [][2]float64 {
+	if len(path) < 2 || r.Step <= 0 {
+		return nil
+	}
+	at := func(i int) [2]float64 { return path[int(math.Max(0, math.Min(float64(len(path)-1), float64(i))))] }
+	const divisions = 16 // curve samples between path points.
+	centre := [][2]float64{path[0]}
+	last, travel := path[0], 0.0
+	for i := 0; i < len(path)-1; i++ {
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+		for d := 1; d <= divisions; d++ {
+			p := catmullRom(p0, p1, p2, p3, float64(d)/divisions)
+			step := math.Hypot(p[0]-last[0], p[1]-last[1])
+			for travel+step >= r.Step {
+				along := (r.Step - travel) / step
+				last = [2]float64{lerp(last[0], p[0], along), lerp(last[1], p[1], along)}
+				centre = append(centre, last)
+				step, travel = math.Hypot(p[0]-last[0], p[1]-last[1]), 0
+			}
+			last, travel = p, travel+step
+		}
+	}
+	if travel > 0 {
+		centre = append(centre, last)
+	}
+	return centre
+}
+
+// heights returns the land heights along the road centre,
+// averaged over the Smooth distance.
+func (r *Road) heights(topo [][]float64, centre [][2]float64) []float64 {
+	w, h := float64(len(topo)-1), float64(len(topo[0])-1)
+	land := make([]float64, len(centre))
+	for cnt, c := range centre {
+		x, y := math.Max(0, math.Min(w-1e-9, c[0])), math.Max(0, math.Min(h-1e-9, c[1]))
+		land[cnt], _, _ = slope(topo, x, y)
+	}
+	span := int(r.Smooth / r.Step / 2)
+	heights := make([]float64, len(centre))
+	for cnt := range heights {
+		total, count := 0.0, 0.0
+		for i := cnt - span; i <= cnt+span; i++ {
+			if i >= 0 && i < len(land) {
+				total, count = total+land[i], count+1
+			}
+		}
+		heights[cnt] = total / count
+	}
+	return heights
+}
+
+// strip returns the road mesh with a left and right vertex
+// for each road centre location.
+func (r *Road) strip(centre [][2]float64, heights []float64) (vb, nb, tb []float32, fb []uint16) {
+	travel := 0.0
+	for cnt, c := range centre {
+		if len(vb)/3 > math.MaxUint16-2 {
+			break
+		}
+		prev, next := centre[int(math.Max(0, float64(cnt-1)))], centre[int(math.Min(float64(len(centre)-1), float64(cnt+1)))]
+		dx, dy := next[0]-prev[0], next[1]-prev[1]
+		length := math.Hypot(dx, dy)
+		nx, ny := -dy/length*r.Width/2, dx/length*r.Width/2
+		if cnt > 0 {
+			travel += math.Hypot(c[0]-centre[cnt-1][0], c[1]-centre[cnt-1][1])
+		}
+		height, v := float32(heights[cnt]+r.Lift), float32(travel/r.Width)
+		vb = append(vb, float32(c[0]+nx), height, float32(c[1]+ny), float32(c[0]-nx), height, float32(c[1]-ny))
+		nb = append(nb, 0, 1, 0, 0, 1, 0)
+		tb = append(tb, 0, v, 1, v)
+		if cnt > 0 {
+			l0, r0 := uint16(len(vb)/3-4), uint16(len(vb)/3-3)
+			l1, r1 := l0+2, r0+2
+			fb = append(fb, l0, l1, r0, r0, l1, r1)
+		}
+	}
+	return vb, nb, tb, fb
+}
+
+// catmullRom returns the point at t from 0 to 1 between p1 and p2
+// on the uniform Catmull-Rom curve through p0, p1, p2, p3.
+func catmullRom(p0, p1, p2, p3 [2]float64, t float64) (p [2]float64) {
+	t2, t3 := t*t, t*t*t
+	for i := range p {
+		p[i] = 0.5 * (2*p1[i] + (p2[i]-p0[i])*t +
+			(2*p0[i]-5*p1[i]+4*p2[i]-p3[i])*t2 +
+			(3*p1[i]-p0[i]-3*p2[i]+p3[i])*t3)
+	}
+	return p
+}
+
+// closest returns the distance from x, y to the line segment a, b
+// and how far along the segment, from 0 to 1, the closest point is.
+func closest(a, b [2]float64, x, y float64) (dist, along float64) {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	if lsq := dx*dx + dy*dy; lsq > 0 {
+		along = math.Max(0, math.Min(1, ((x-a[0])*dx+(y-a[1])*dy)/lsq))
+	}
+	return math.Hypot(a[0]+dx*along-x, a[1]+dy*along-y), along
+}
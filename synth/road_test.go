@@ -0,0 +1,43 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+	"testing"
+)
+
+// Roads flatten the land they cross and leave distant land alone.
+func TestRoadCarve(t *testing.T) {
+	tile := newTile(32, 32, 0, 0, 0)
+	for x := range tile.topo {
+		for y := range tile.topo[x] {
+			tile.topo[x][y] = float64(y) * 0.1
+		}
+	}
+	tile.topo[16][8] = 3 // bump on the road.
+	road := NewRoad()
+	vb, nb, tb, fb := road.Carve(tile, [][2]float64{{0, 8}, {16, 8}, {31, 8}})
+	if tile.topo[16][8] > 1.1 || math.Abs(tile.topo[16][8]-tile.topo[16][7]) > 1e-9 {
+		t.Errorf("Expected flattened road %f %f", tile.topo[16][8], tile.topo[16][7])
+	}
+	if tile.topo[16][20] != 2 || tile.topo[4][10] == 1 {
+		t.Errorf("Expected carving only near the road %f %f", tile.topo[16][20], tile.topo[4][10])
+	}
+	sections := len(vb) / 6
+	if sections != 32 || len(nb) != len(vb) || len(tb) != sections*4 || len(fb) != (sections-1)*6 {
+		t.Fatalf("Expected road strip %d %d %d %d", sections, len(nb), len(tb), len(fb))
+	}
+	if vb[2] != 10 || vb[5] != 6 || tb[len(tb)-1] < 7.7 || tb[len(tb)-1] > 7.8 {
+		t.Errorf("Expected road width and texture length %f %f %f", vb[2], vb[5], tb[len(tb)-1])
+	}
+	for cnt := 0; cnt < len(fb); cnt += 3 {
+		a, b, c := fb[cnt], fb[cnt+1], fb[cnt+2]
+		ux, uz := vb[b*3]-vb[a*3], vb[b*3+2]-vb[a*3+2]
+		vx, vz := vb[c*3]-vb[a*3], vb[c*3+2]-vb[a*3+2]
+		if uz*vx-ux*vz <= 0 {
+			t.Fatalf("Expected upward facing triangle %d", cnt/3)
+		}
+	}
+}
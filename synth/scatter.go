@@ -0,0 +1,143 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Scatter holds the parameters for placing instances of objects like
+// trees, rocks, and grass over land tile heights. Instances are spread
+// out using Poisson-disk sampling so that no two instances are closer
+// than Spacing, and are then kept or dropped based on a density map and
+// the land height and slope at each instance. For example:
+//    trees := synth.NewScatter()
+//    trees.Spacing, trees.MaxSlope = 4, 0.5
+//    transforms := synth.Transforms(trees.Place(tile, density, seed))
+// Use a separate Scatter for each kind of object.
+type Scatter struct {
+	Spacing   float64 // Minimum distance between instances in cells. Default 2.
+	MinHeight float64 // Lowest land height. Default no limit.
+	MaxHeight float64 // Highest land height. Default no limit.
+	MinSlope  float64 // Lowest land height change per cell. Default 0.
+	MaxSlope  float64 // Highest land height change per cell. Default no limit.
+	MinScale  float64 // Smallest random instance scale. Default 0.8.
+	MaxScale  float64 // Largest random instance scale. Default 1.2.
+	Tries     int     // Candidates tried around each instance. Default 30.
+}
+
+// NewScatter returns scatter parameters with reasonable defaults.
+func NewScatter() *Scatter {
+	return &Scatter{Spacing: 2, MinHeight: math.Inf(-1), MaxHeight: math.Inf(1),
+		MaxSlope: math.Inf(1), MinScale: 0.8, MaxScale: 1.2, Tries: 30}
+}
+
+// Instance is the placement of one scattered object. Instances use
+// the tile cell coordinates with x, y on the tile at X, Z, and the
+// land height at Y. Yaw is the rotation about the Y axis in radians.
+type Instance struct {
+	X, Y, Z float64 // Location on the land.
+	Yaw     float64 // Random rotation about Y.
+	Scale   float64 // Random scale.
+}
+
+// Place returns instances scattered over the tile. The optional density
+// map has the same size as the tile with values from 0 to 1 giving the
+// chance of keeping an instance at each cell, ie: 0 for no trees in
+// rivers. Use nil for even coverage. The random seed is injected so that
+// identical results can be re-created.
+func (s *Scatter) Place(t Tile, density [][]float64, seed int64) []Instance {
+	topo := t.Topo()
+	w, h := t.Size()
+	if w < 2 || h < 2 || s.Spacing <= 0 {
+		return nil
+	}
+	rgen := rand.New(rand.NewSource(seed))
+	instances := []Instance{}
+	for _, p := range poissonDisk(float64(w-1), float64(h-1), s.Spacing, s.Tries, rgen) {
+		yaw := rgen.Float64() * 2 * math.Pi
+		scale := s.MinScale + rgen.Float64()*(s.MaxScale-s.MinScale)
+		keep := rgen.Float64()
+		if density != nil && keep >= density[int(p[0]+0.5)][int(p[1]+0.5)] {
+			continue
+		}
+		height, gx, gy := slope(topo, p[0], p[1])
+		grade := math.Sqrt(gx*gx + gy*gy)
+		if height < s.MinHeight || height > s.MaxHeight || grade < s.MinSlope || grade > s.MaxSlope {
+			continue
+		}
+		instances = append(instances, Instance{X: p[0], Y: height, Z: p[1], Yaw: yaw, Scale: scale})
+	}
+	return instances
+}
+
+// Transforms returns the instance transforms as 16 float32 values per
+// instance, ie: for uploading as instance data. Each transform uses the
+// lin.M4 layout with the scale and rotation in the first three rows and
+// the location in the last row.
+func Transforms(instances []Instance) []float32 {
+	m := make([]float32, 0, len(instances)*16)
+	for _, in := range instances {
+		sin, cos := math.Sincos(in.Yaw)
+		sin, cos = sin*in.Scale, cos*in.Scale
+		m = append(m,
+			float32(cos), 0, float32(sin), 0,
+			0, float32(in.Scale), 0, 0,
+			float32(-sin), 0, float32(cos), 0,
+			float32(in.X), float32(in.Y), float32(in.Z), 1)
+	}
+	return m
+}
+
+// poissonDisk returns random points from 0,0 up to, but not including,
+// w,h where no two points are closer than r. Candidates are tried
+// around existing points until tries candidates in a row are too close.
+// Uses the algorithm from:
+//    Bridson, "Fast Poisson Disk Sampling in Arbitrary Dimensions", 2007.
+func poissonDisk(w, h, r float64, tries int, rgen *rand.Rand) [][2]float64 {
+	size := r / math.Sqrt2 // grid cells hold at most one point.
+	gw, gh := int(math.Ceil(w/size)), int(math.Ceil(h/size))
+	cells := make([]int, gw*gh) // point index plus one, 0 for empty.
+	points := [][2]float64{}
+	add := func(x, y float64) {
+		points = append(points, [2]float64{x, y})
+		cells[int(y/size)*gw+int(x/size)] = len(points)
+	}
+	near := func(x, y float64) bool {
+		cx, cy := int(x/size), int(y/size)
+		for j := cy - 2; j <= cy+2; j++ {
+			for i := cx - 2; i <= cx+2; i++ {
+				if i < 0 || j < 0 || i >= gw || j >= gh || cells[j*gw+i] == 0 {
+					continue
+				}
+				p := points[cells[j*gw+i]-1]
+				if dx, dy := p[0]-x, p[1]-y; dx*dx+dy*dy < r*r {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	add(rgen.Float64()*w, rgen.Float64()*h)
+	active := []int{0}
+	for len(active) > 0 {
+		pick := rgen.Intn(len(active))
+		p := points[active[pick]]
+		found := false
+		for cnt := 0; cnt < tries && !found; cnt++ {
+			angle, dist := rgen.Float64()*2*math.Pi, r*(1+rgen.Float64())
+			x, y := p[0]+math.Cos(angle)*dist, p[1]+math.Sin(angle)*dist
+			if x >= 0 && y >= 0 && x < w && y < h && !near(x, y) {
+				add(x, y)
+				active, found = append(active, len(points)-1), true
+			}
+		}
+		if !found {
+			active[pick] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+	return points
+}
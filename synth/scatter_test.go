@@ -0,0 +1,69 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+	"testing"
+)
+
+// Instances are spaced apart and cover the tile.
+func TestScatterSpacing(t *testing.T) {
+	tile := newTile(32, 32, 0, 0, 0)
+	s := NewScatter()
+	s.Spacing = 3
+	in := s.Place(tile, nil, 123)
+	if len(in) < 50 {
+		t.Fatalf("Expected tile to be covered, got %d", len(in))
+	}
+	for i := range in {
+		for j := i + 1; j < len(in); j++ {
+			if dx, dz := in[i].X-in[j].X, in[i].Z-in[j].Z; math.Sqrt(dx*dx+dz*dz) < s.Spacing {
+				t.Fatalf("Expected spaced instances %v %v", in[i], in[j])
+			}
+		}
+		if in[i].Scale < s.MinScale || in[i].Scale > s.MaxScale {
+			t.Errorf("Expected scale in range, got %f", in[i].Scale)
+		}
+	}
+	if again := s.Place(tile, nil, 123); len(again) != len(in) || again[7] != in[7] {
+		t.Error("Expected repeatable placement")
+	}
+}
+
+// Instances are only placed where the density, height, and slope allow.
+func TestScatterLimits(t *testing.T) {
+	tile := newTile(32, 32, 0, 0, 0)
+	density := make([][]float64, 32)
+	for x := range tile.topo {
+		density[x] = make([]float64, 32)
+		for y := range tile.topo[x] {
+			tile.topo[x][y] = float64(x) * 0.1
+			if y < 16 {
+				tile.topo[x][y] = float64(x) * 0.5
+			}
+			if x < 16 {
+				density[x][y] = 1
+			}
+		}
+	}
+	s := NewScatter()
+	s.MaxSlope, s.MaxHeight = 0.2, 1
+	in := s.Place(tile, density, 123)
+	if len(in) == 0 {
+		t.Fatal("Expected some instances")
+	}
+	for _, i := range in {
+		if i.X > 15.5 || i.Z < 15 || i.Y > 1 {
+			t.Errorf("Expected limited placement %v", i)
+		}
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	m := Transforms([]Instance{{X: 1, Y: 2, Z: 3, Yaw: math.Pi / 2, Scale: 2}})
+	if len(m) != 16 || m[2] != 2 || m[5] != 2 || m[8] != -2 || m[12] != 1 || m[13] != 2 || m[14] != 3 || m[15] != 1 {
+		t.Errorf("Unexpected transform %v", m)
+	}
+}
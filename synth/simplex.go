@@ -11,7 +11,10 @@ import (
 
 // simplex is a gradient noise generator algorithm. Its purpose is to help
 // create random world maps and images. The random seed can be saved to
-// recreate a given map at a later date.
+// recreate a given map at a later date. Products are rounded using explicit
+// float64 conversions before they are added so that the compiler can't fuse
+// them into multiply-add instructions, ie: on arm64. This keeps the noise
+// values identical on all platforms.
 //
 // From and thanks to:
 //    http://staffwww.itn.liu.se/~stegu/simplexnoise/simplexnoise.pdf
@@ -94,12 +97,12 @@ func newSimplex(seed int64) *simplex {
 
 // dot2D provides a dot product of the point with the gradient.
 func (s *simplex) dot2D(g *gradient, x, y float64) float64 {
-	return g.x*x + g.y*y
+	return float64(g.x*x) + float64(g.y*y)
 }
 
 // dot3D provides a dot product of the point with the gradient.
 func (s *simplex) dot3D(g *gradient, x, y, z float64) float64 {
-	return g.x*x + g.y*y + g.z*z
+	return float64(g.x*x) + float64(g.y*y) + float64(g.z*z)
 }
 
 // floor was benchmarked to be a *lot* faster than (int)Math.floor(x).
@@ -117,10 +120,10 @@ func (s *simplex) Gen2D(xin, yin float64) float64 {
 	var n0, n1, n2 float64 // Noise contributions from the three corners
 
 	// Skew the input space to determine which simplex cell we're in
-	h := (xin + yin) * s.f2 // Hairy factor for 2D
+	h := float64((xin + yin) * s.f2) // Hairy factor for 2D
 	i := int(s.floor(xin + h))
 	j := int(s.floor(yin + h))
-	t := float64(i+j) * s.g2
+	t := float64(float64(i+j) * s.g2)
 	xs := float64(i) - t // Unskew the cell origin back to (x,y) space
 	ys := float64(j) - t
 	x0 := xin - xs // The x,y distances from the cell origin
@@ -153,26 +156,26 @@ func (s *simplex) Gen2D(xin, yin float64) float64 {
 	gi2 := s.permMod12[ii+1+int(s.perm[jj+1])]
 
 	// Calculate the contribution from the three corners
-	t0 := 0.5 - x0*x0 - y0*y0
+	t0 := 0.5 - float64(x0*x0) - float64(y0*y0)
 	if t0 < 0 {
 		n0 = 0.0
 	} else {
 		t0 *= t0
-		n0 = t0 * t0 * s.mag[gi0] * s.dot2D(s.gradients[gi0], x0, y0) // (x,y) of grad3 used for 2D gradient
+		n0 = float64(t0 * t0 * s.mag[gi0] * s.dot2D(s.gradients[gi0], x0, y0)) // (x,y) of grad3 used for 2D gradient
 	}
-	t1 := 0.5 - x1*x1 - y1*y1
+	t1 := 0.5 - float64(x1*x1) - float64(y1*y1)
 	if t1 < 0 {
 		n1 = 0.0
 	} else {
 		t1 *= t1
-		n1 = t1 * t1 * s.mag[gi1] * s.dot2D(s.gradients[gi1], x1, y1)
+		n1 = float64(t1 * t1 * s.mag[gi1] * s.dot2D(s.gradients[gi1], x1, y1))
 	}
-	t2 := 0.5 - x2*x2 - y2*y2
+	t2 := 0.5 - float64(x2*x2) - float64(y2*y2)
 	if t2 < 0 {
 		n2 = 0.0
 	} else {
 		t2 *= t2
-		n2 = t2 * t2 * s.mag[gi2] * s.dot2D(s.gradients[gi2], x2, y2)
+		n2 = float64(t2 * t2 * s.mag[gi2] * s.dot2D(s.gradients[gi2], x2, y2))
 	}
 
 	// Add contributions from each corner to get the final noise value.
@@ -192,11 +195,11 @@ func (s *simplex) Gen3D(xin, yin, zin float64) float64 {
 	var n0, n1, n2, n3 float64 // Noise contributions from the four corners
 
 	// Skew the input space to determine which simplex cell we're in
-	h := (xin + yin + zin) * s.f3 // Very nice and simple skew factor for 3D
+	h := float64((xin + yin + zin) * s.f3) // Very nice and simple skew factor for 3D
 	i := int(s.floor(xin + h))
 	j := int(s.floor(yin + h))
 	k := int(s.floor(zin + h))
-	t := float64(i+j+k) * s.g3
+	t := float64(float64(i+j+k) * s.g3)
 	xs := float64(i) - t // Unskew the cell origin back to (x,y,z) space
 	ys := float64(j) - t
 	zs := float64(k) - t
@@ -236,9 +239,9 @@ func (s *simplex) Gen3D(xin, yin, zin float64) float64 {
 	x2 := x0 - float64(i2) + 2.0*s.g3 // Offsets for third corner in (x,y,z) coords
 	y2 := y0 - float64(j2) + 2.0*s.g3
 	z2 := z0 - float64(k2) + 2.0*s.g3
-	x3 := x0 - 1.0 + 3.0*s.g3 // Offsets for last corner in (x,y,z) coords
-	y3 := y0 - 1.0 + 3.0*s.g3
-	z3 := z0 - 1.0 + 3.0*s.g3
+	x3 := x0 - 1.0 + float64(3.0*s.g3) // Offsets for last corner in (x,y,z) coords
+	y3 := y0 - 1.0 + float64(3.0*s.g3)
+	z3 := z0 - 1.0 + float64(3.0*s.g3)
 
 	// Work out the hashed gradient indices of the four simplex corners
 	ii := i & 255
@@ -250,33 +253,33 @@ func (s *simplex) Gen3D(xin, yin, zin float64) float64 {
 	gi3 := s.permMod12[ii+1+int(s.perm[jj+1+int(s.perm[kk+1])])]
 
 	// Calculate the contribution from the four corners
-	t0 := 0.5 - x0*x0 - y0*y0 - z0*z0
+	t0 := 0.5 - float64(x0*x0) - float64(y0*y0) - float64(z0*z0)
 	if t0 < 0 {
 		n0 = 0.0
 	} else {
 		t0 *= t0
-		n0 = t0 * t0 * s.mag[gi0] * s.dot3D(s.gradients[gi0], x0, y0, z0)
+		n0 = float64(t0 * t0 * s.mag[gi0] * s.dot3D(s.gradients[gi0], x0, y0, z0))
 	}
-	t1 := 0.5 - x1*x1 - y1*y1 - z1*z1
+	t1 := 0.5 - float64(x1*x1) - float64(y1*y1) - float64(z1*z1)
 	if t1 < 0 {
 		n1 = 0.0
 	} else {
 		t1 *= t1
-		n1 = t1 * t1 * s.mag[gi1] * s.dot3D(s.gradients[gi1], x1, y1, z1)
+		n1 = float64(t1 * t1 * s.mag[gi1] * s.dot3D(s.gradients[gi1], x1, y1, z1))
 	}
-	t2 := 0.5 - x2*x2 - y2*y2 - z2*z2
+	t2 := 0.5 - float64(x2*x2) - float64(y2*y2) - float64(z2*z2)
 	if t2 < 0 {
 		n2 = 0.0
 	} else {
 		t2 *= t2
-		n2 = t2 * t2 * s.mag[gi2] * s.dot3D(s.gradients[gi2], x2, y2, z2)
+		n2 = float64(t2 * t2 * s.mag[gi2] * s.dot3D(s.gradients[gi2], x2, y2, z2))
 	}
-	t3 := 0.5 - x3*x3 - y3*y3 - z3*z3
+	t3 := 0.5 - float64(x3*x3) - float64(y3*y3) - float64(z3*z3)
 	if t3 < 0 {
 		n3 = 0.0
 	} else {
 		t3 *= t3
-		n3 = t3 * t3 * s.mag[gi3] * s.dot3D(s.gradients[gi3], x3, y3, z3)
+		n3 = float64(t3 * t3 * s.mag[gi3] * s.dot3D(s.gradients[gi3], x3, y3, z3))
 	}
 
 	// Add contributions from each corner to get the final noise value.
@@ -78,15 +78,18 @@ func (t *tile) SetFace(face int) Tile {
 //   Zoom level 6 :  4096 topology sections indexed 0,0 to 63,63
 //   Zoom level 7 : 16384 topology sections indexed 0,0 to 127,127
 //   Zoom level 8 : 65536 topology sections indexed 0,0 to 255,255
-func (t *tile) gen2D(n *simplex) {
-	freq := 2.0           // overall size.
-	gain := 0.55          // range of heights.
-	octaves := 6 + t.zoom // feature sharpness
-	lacunarity := 2.0     // feature scatter
+func (t *tile) gen2D(n Noise) { t.octaves2D(n, 2.0, 0.55, 6+t.zoom) }
+
+// octaves2D generates the topology section by summing octaves of noise n.
+//    freq: overall size.
+//    gain: range of heights.
+//    octaves: feature sharpness.
+func (t *tile) octaves2D(n Noise, freq, gain float64, octaves int) {
+	lacunarity := 2.0 // feature scatter
 	zexp := 1.0 / math.Exp2(float64(t.zoom))
 	size := float64(len(t.topo))
 	flip := len(t.topo) - 1
-	for x := range t.topo {
+	parallel(len(t.topo), func(x int) { // each column on its own.
 		for y := range t.topo[x] {
 			total := 0.0
 			nfreq := freq / size
@@ -94,13 +97,13 @@ func (t *tile) gen2D(n *simplex) {
 			for o := 0; o < octaves; o++ {
 				xval := float64(x+t.ox) * nfreq
 				yval := float64(y+t.ox) * nfreq
-				total += n.Gen2D(xval*zexp, yval*zexp) * amplitude
+				total += float64(n.Gen2D(float64(xval*zexp), float64(yval*zexp)) * amplitude)
 				nfreq *= lacunarity
 				amplitude *= gain
 			}
 			t.topo[x][flip-y] = total // Put 0,0 at bottom left.
 		}
-	}
+	})
 }
 
 // gen3D is used to create 1 side of a 6 side cube map. The images
@@ -108,11 +111,12 @@ func (t *tile) gen2D(n *simplex) {
 // Face is one of XPos, XNeg, YPos, YNeg, ZPos, ZNeg. The given value
 // of xo,yo are applied based on the plane.
 // The images are generated with 0,0 in the bottom left corner.
-func (t *tile) gen3D(n *simplex) {
-	freq := 2.0           // overall size.
-	gain := 0.55          // range of heights.
-	octaves := 6 + t.zoom // feature sharpness
-	lacunarity := 2.0     // feature scatter
+func (t *tile) gen3D(n Noise) { t.octaves3D(n, 2.0, 0.55, 6+t.zoom) }
+
+// octaves3D generates the cube face by summing octaves of noise n.
+// See octaves2D.
+func (t *tile) octaves3D(n Noise, freq, gain float64, octaves int) {
+	lacunarity := 2.0 // feature scatter
 	exp := int(math.Exp2(float64(t.zoom)))
 	zexp := 1.0 / float64(exp<<1) // exp2(zoom)
 	size, flip := len(t.topo), len(t.topo)-1
@@ -144,7 +148,7 @@ func (t *tile) gen3D(n *simplex) {
 					xval := float64(x+xo) * nfreq
 					yval := float64(y+yo) * nfreq
 					zval := float64(z+zo) * nfreq
-					total += n.Gen3D(xval*zexp, yval*zexp, zval*zexp) * amplitude
+					total += float64(n.Gen3D(float64(xval*zexp), float64(yval*zexp), float64(zval*zexp)) * amplitude)
 					nfreq *= lacunarity
 					amplitude *= gain
 				}
@@ -0,0 +1,265 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"math"
+)
+
+// Volume is a 3D grid of density values used to create terrain with
+// caves and overhangs. Grid points with positive density are solid and
+// those with negative density are empty. The surface between them is
+// meshed using marching cubes. Volumes can be modified with Dig and Add,
+// ie: for digging tunnels, and then meshed again. For example:
+//
+//	v := synth.NewVolume(32, 32, 32)
+//	v.Fill(synth.NewSimplex(seed), 0.1, 16, 0.1)
+//	v.Dig(16, 16, 16, 4)
+//	vb, nb, fb := v.Mesh()
+//
+// Volumes are expected to be small enough, ie: 32x32x32, that the mesh
+// has less than 65,000 vertices. Use multiple volumes for larger areas.
+type Volume struct {
+	x, y, z int       // Grid points along each axis.
+	d       []float64 // Density values.
+}
+
+// NewVolume creates an empty volume with the given number of
+// grid points along each axis.
+func NewVolume(x, y, z int) *Volume {
+	v := &Volume{x: x, y: y, z: z, d: make([]float64, x*y*z)}
+	for cnt := range v.d {
+		v.d[cnt] = -1
+	}
+	return v
+}
+
+// Size returns the number of grid points along each axis.
+func (v *Volume) Size() (x, y, z int) { return v.x, v.y, v.z }
+
+// At returns the density at the given grid point.
+// Points outside the volume are empty.
+func (v *Volume) At(x, y, z int) float64 {
+	if x < 0 || y < 0 || z < 0 || x >= v.x || y >= v.y || z >= v.z {
+		return -1
+	}
+	return v.d[(z*v.y+y)*v.x+x]
+}
+
+// Set the density at the given grid point.
+// Points outside the volume are ignored.
+func (v *Volume) Set(x, y, z int, density float64) {
+	if x >= 0 && y >= 0 && z >= 0 && x < v.x && y < v.y && z < v.z {
+		v.d[(z*v.y+y)*v.x+x] = density
+	}
+}
+
+// Fill sets the density of each grid point using the 3D noise value
+// at the grid point multiplied by scale. The slope adds solid ground
+// below the ground height and empty space above it. Use 0 slope
+// for floating rock.
+func (v *Volume) Fill(n Noise, scale, ground, slope float64) {
+	for z := 0; z < v.z; z++ {
+		for y := 0; y < v.y; y++ {
+			for x := 0; x < v.x; x++ {
+				density := n.Gen3D(float64(x)*scale, float64(y)*scale, float64(z)*scale)
+				v.d[(z*v.y+y)*v.x+x] = density + (ground-float64(y))*slope
+			}
+		}
+	}
+}
+
+// Dig empties a sphere of the given radius at grid location x, y, z.
+func (v *Volume) Dig(x, y, z, radius float64) {
+	v.brush(x, y, z, radius, func(d, dist float64) float64 { return math.Min(d, dist-radius) })
+}
+
+// Add fills a sphere of the given radius at grid location x, y, z.
+func (v *Volume) Add(x, y, z, radius float64) {
+	v.brush(x, y, z, radius, func(d, dist float64) float64 { return math.Max(d, radius-dist) })
+}
+
+// brush updates the densities of the grid points near a sphere.
+func (v *Volume) brush(x, y, z, radius float64, update func(d, dist float64) float64) {
+	r := radius + 1
+	for k := int(math.Max(0, z-r)); k <= int(math.Min(float64(v.z-1), z+r)); k++ {
+		for j := int(math.Max(0, y-r)); j <= int(math.Min(float64(v.y-1), y+r)); j++ {
+			for i := int(math.Max(0, x-r)); i <= int(math.Min(float64(v.x-1), x+r)); i++ {
+				dx, dy, dz := float64(i)-x, float64(j)-y, float64(k)-z
+				index := (k*v.y+j)*v.x + i
+				v.d[index] = update(v.d[index], math.Sqrt(dx*dx+dy*dy+dz*dz))
+			}
+		}
+	}
+}
+
+// Mesh returns the surface between the solid and empty grid points
+// as vertex positions, vertex normals, and triangle faces, ie: for
+// Mesh.SetData and Mesh.SetFaces. Vertices are in grid units with
+// normals pointing towards the empty space. The volume edges are
+// treated as empty so that the mesh is closed. Meshing stops before
+// the vertex count overflows the faces.
+func (v *Volume) Mesh() (vb, nb []float32, fb []uint16) {
+	verts := map[[4]int]uint16{} // cube edge vertices shared by adjacent cubes.
+	var corners [8]float64
+	for z := -1; z < v.z; z++ {
+		for y := -1; y < v.y; y++ {
+			for x := -1; x < v.x; x++ {
+				config := 0
+				for c := range corners {
+					corners[c] = v.At(x+c&1, y+c>>1&1, z+c>>2&1)
+					if corners[c] > 0 {
+						config |= 1 << uint(c)
+					}
+				}
+				for _, loop := range cubeLoops[config] {
+					ids := make([]uint16, len(loop))
+					for cnt, e := range loop {
+						c0, c1 := cubeEdges[e][0], cubeEdges[e][1]
+						key := [4]int{x + c0&1, y + c0>>1&1, z + c0>>2&1, c0 ^ c1} // first corner and axis.
+						id, ok := verts[key]
+						if !ok {
+							if len(vb)/3 >= math.MaxUint16-1 {
+								return vb, nb, fb
+							}
+							id = uint16(len(vb) / 3)
+							verts[key] = id
+							vb, nb = v.vertex(vb, nb, key, corners[c0], corners[c1])
+						}
+						ids[cnt] = id
+					}
+					if len(ids) == 3 {
+						fb = append(fb, ids[0], ids[1], ids[2])
+						continue
+					}
+
+					// larger loops are split around a center vertex so that
+					// no triangle edge lies on a cube face.
+					center := uint16(len(vb) / 3)
+					vb, nb = v.center(vb, nb, ids)
+					for cnt := range ids {
+						fb = append(fb, center, ids[cnt], ids[(cnt+1)%len(ids)])
+					}
+				}
+			}
+		}
+	}
+	return vb, nb, fb
+}
+
+// vertex adds the surface vertex and normal on the cube edge starting
+// at grid point key[0:3] along axis key[3] with end densities d0, d1.
+func (v *Volume) vertex(vb, nb []float32, key [4]int, d0, d1 float64) ([]float32, []float32) {
+	x, y, z, axis := key[0], key[1], key[2], key[3]
+	ax, ay, az := axis&1, axis>>1&1, axis>>2&1
+	t := d0 / (d0 - d1)
+	vb = append(vb, float32(float64(x)+float64(ax)*t),
+		float32(float64(y)+float64(ay)*t), float32(float64(z)+float64(az)*t))
+
+	// normals point down the interpolated density gradient.
+	gx0, gy0, gz0 := v.gradient(x, y, z)
+	gx1, gy1, gz1 := v.gradient(x+ax, y+ay, z+az)
+	nx, ny, nz := -(gx0 + (gx1-gx0)*t), -(gy0 + (gy1-gy0)*t), -(gz0 + (gz1-gz0)*t)
+	if length := math.Sqrt(nx*nx + ny*ny + nz*nz); length > 0 {
+		nx, ny, nz = nx/length, ny/length, nz/length
+	}
+	return vb, append(nb, float32(nx), float32(ny), float32(nz))
+}
+
+// center adds a vertex at the average location and normal of the ids.
+func (v *Volume) center(vb, nb []float32, ids []uint16) ([]float32, []float32) {
+	var x, y, z, nx, ny, nz float32
+	for _, id := range ids {
+		x, y, z = x+vb[id*3], y+vb[id*3+1], z+vb[id*3+2]
+		nx, ny, nz = nx+nb[id*3], ny+nb[id*3+1], nz+nb[id*3+2]
+	}
+	count := float32(len(ids))
+	if length := float32(math.Sqrt(float64(nx*nx + ny*ny + nz*nz))); length > 0 {
+		nx, ny, nz = nx/length, ny/length, nz/length
+	}
+	return append(vb, x/count, y/count, z/count), append(nb, nx, ny, nz)
+}
+
+// gradient returns the central difference density gradient at a grid point.
+func (v *Volume) gradient(x, y, z int) (gx, gy, gz float64) {
+	return v.At(x+1, y, z) - v.At(x-1, y, z),
+		v.At(x, y+1, z) - v.At(x, y-1, z),
+		v.At(x, y, z+1) - v.At(x, y, z-1)
+}
+
+// marching cubes tables
+// =============================================================================
+
+// Cube corners are numbered using bit 0 for x, bit 1 for y, and bit 2
+// for z, ie: corner 5 is at x=1, y=0, z=1. The cube edges join the
+// corners that differ by one bit. The cube faces list their corners
+// counter clockwise when viewed from outside the cube.
+var (
+	cubeEdges [12][2]int
+	cubeFaces = [6][4]int{{0, 2, 3, 1}, {4, 5, 7, 6}, {0, 1, 5, 4}, {2, 6, 7, 3}, {0, 4, 6, 2}, {1, 3, 7, 5}}
+	cubeLoops [256][][]int // Cube edge loops for each corner configuration.
+)
+
+// init creates the marching cubes table instead of hard coding the
+// usual 256 entry triangle table. The surface crosses each face along
+// the face edges with one solid and one empty corner. Going counter
+// clockwise around a face, each crossing into the solid corners is
+// joined to the next crossing out of the solid corners. This keeps
+// solid corners that are diagonal from each other apart. Since each
+// face is handled using only its own corners, adjacent cubes agree on
+// their shared faces and the surface has no holes. The crossings are
+// chained into loops that are wound counter clockwise when viewed
+// from the empty side. Loops are split into triangles when the mesh
+// is created.
+func init() {
+	edges := map[[2]int]int{}
+	for c := 0; c < 8; c++ {
+		for _, bit := range []int{1, 2, 4} {
+			if c&bit == 0 {
+				edges[[2]int{c, c | bit}] = len(edges)
+				cubeEdges[len(edges)-1] = [2]int{c, c | bit}
+			}
+		}
+	}
+	edge := func(c0, c1 int) int {
+		if c0 > c1 {
+			c0, c1 = c1, c0
+		}
+		return edges[[2]int{c0, c1}]
+	}
+	for config := 1; config < 255; config++ {
+		solid := func(c int) bool { return config&(1<<uint(c)) != 0 }
+
+		// join the crossed edges of each face. Each crossed edge
+		// is a crossing in on one face and out on the other.
+		next := map[int]int{}
+		for _, f := range cubeFaces {
+			in := -1
+			for cnt := 0; cnt < 8; cnt++ { // twice around to match the last crossing in.
+				c0, c1 := f[cnt%4], f[(cnt+1)%4]
+				switch {
+				case !solid(c0) && solid(c1):
+					in = edge(c0, c1)
+				case solid(c0) && !solid(c1) && in >= 0:
+					next[in] = edge(c0, c1)
+					in = -1
+				}
+			}
+		}
+
+		// follow the joins around each loop.
+		visited := map[int]bool{}
+		for e := 0; e < 12; e++ {
+			if _, ok := next[e]; !ok || visited[e] {
+				continue
+			}
+			loop := []int{}
+			for at := e; !visited[at]; at = next[at] {
+				loop = append(loop, at)
+				visited[at] = true
+			}
+			cubeLoops[config] = append(cubeLoops[config], loop)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"testing"
+)
+
+// A solid sphere is a closed mesh facing outwards.
+func TestVolumeSphere(t *testing.T) {
+	v := NewVolume(17, 17, 17)
+	v.Add(8, 8, 8, 5)
+	vb, nb, fb := v.Mesh()
+	if len(fb) == 0 || len(vb) != len(nb) {
+		t.Fatalf("Expected sphere mesh %d %d %d", len(vb), len(nb), len(fb))
+	}
+	for f := 0; f < len(fb); f += 3 {
+		a, b, c := int(fb[f])*3, int(fb[f+1])*3, int(fb[f+2])*3
+		ux, uy, uz := vb[b]-vb[a], vb[b+1]-vb[a+1], vb[b+2]-vb[a+2]
+		wx, wy, wz := vb[c]-vb[a], vb[c+1]-vb[a+1], vb[c+2]-vb[a+2]
+		nx, ny, nz := uy*wz-uz*wy, uz*wx-ux*wz, ux*wy-uy*wx
+		if nx*(vb[a]-8)+ny*(vb[a+1]-8)+nz*(vb[a+2]-8) < 0 {
+			t.Fatalf("Expected outward facing triangle %d", f/3)
+		}
+	}
+	if e, ok := closed(fb); !ok {
+		t.Errorf("Expected closed mesh at edge %v", e)
+	}
+}
+
+// Noise volumes are closed meshes, even when they have been dug into.
+func TestVolumeNoise(t *testing.T) {
+	v := NewVolume(16, 16, 16)
+	v.Fill(NewSimplex(123), 0.2, 8, 0.1)
+	v.Dig(8, 8, 8, 3)
+	if v.At(8, 8, 8) > 0 || v.At(8, 0, 8) <= 0 {
+		t.Errorf("Expected dug out center and solid floor")
+	}
+	_, _, fb := v.Mesh()
+	if e, ok := closed(fb); !ok {
+		t.Errorf("Expected closed mesh at edge %v", e)
+	}
+}
+
+// closed returns true if each triangle edge is matched by
+// the reversed edge of a neighbouring triangle.
+func closed(fb []uint16) (edge [2]uint16, ok bool) {
+	directed := map[[2]uint16]int{}
+	for f := 0; f < len(fb); f += 3 {
+		for cnt := 0; cnt < 3; cnt++ {
+			directed[[2]uint16{fb[f+cnt], fb[f+(cnt+1)%3]}]++
+		}
+	}
+	for e, cnt := range directed {
+		if cnt != 1 || directed[[2]uint16{e[1], e[0]}] != 1 {
+			return e, false
+		}
+	}
+	return edge, true
+}
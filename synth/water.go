@@ -0,0 +1,219 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Hydrology holds the parameters for tracing rain water downhill over
+// land tile heights. Water runs off the tile edges. Pits that water can
+// not run out of are filled to make lakes and cells that drain enough
+// of the land are carved into river beds. For example:
+//    water := synth.NewHydrology().Apply(tile)
+//    vb, nb, fb := water.Mesh() // water surface.
+// Tiles are traced independently, so adjacent tiles will not have
+// matching rivers at their edges.
+type Hydrology struct {
+	Rivers float64 // Upstream cells needed to make a river. Default 100.
+	Carve  float64 // River bed depth for the Rivers flow. Default 0.02.
+	Fill   float64 // 0 to 1. Amount of the river bed filled with water.
+}
+
+// NewHydrology returns hydrology parameters with reasonable defaults.
+func NewHydrology() *Hydrology { return &Hydrology{Rivers: 100, Carve: 0.02, Fill: 0.8} }
+
+// Water is the result of tracing water over a land tile.
+// Cells with a positive Depth are under water.
+type Water struct {
+	Depth [][]float64 // Water depth above the land, 0 where dry.
+	Flow  [][]float64 // Number of upstream cells, including itself.
+	Lake  [][]bool    // True for standing water.
+	topo  [][]float64 // Land heights.
+}
+
+// Apply traces the water over the tile, carving river beds into
+// the tile heights. The returned water has the same size as the tile.
+func (h *Hydrology) Apply(t Tile) *Water {
+	topo := t.Topo()
+	w, ht := t.Size()
+	water := &Water{Depth: grid(w, ht), Flow: grid(w, ht), topo: topo}
+	water.Lake = make([][]bool, w)
+	for x := range water.Lake {
+		water.Lake[x] = make([]bool, ht)
+	}
+
+	// lakes fill pits to the height of the lowest outlet. Water is routed
+	// over slightly sloped lake surfaces so that lakes drain to their outlet.
+	level, route := flood(topo, 0), flood(topo, 1e-9)
+	order := make([]point, 0, w*ht)
+	for x := range topo {
+		for y := range topo[x] {
+			if depth := level[x][y] - topo[x][y]; depth > 0 {
+				water.Depth[x][y], water.Lake[x][y] = depth, true
+			}
+			order = append(order, point{x, y})
+		}
+	}
+
+	// pass the flow downhill starting from the highest cells.
+	sort.Slice(order, func(i, j int) bool {
+		return route[order[i].x][order[i].y] > route[order[j].x][order[j].y]
+	})
+	for _, p := range order {
+		water.Flow[p.x][p.y]++
+		if d, ok := downhill(route, p); ok {
+			water.Flow[d.x][d.y] += water.Flow[p.x][p.y]
+		}
+	}
+
+	// carve the rivers outside of the lakes.
+	for x := range topo {
+		for y := range topo[x] {
+			if flow := water.Flow[x][y]; flow >= h.Rivers && !water.Lake[x][y] {
+				bed := h.Carve * math.Min(2, math.Sqrt(flow/h.Rivers))
+				topo[x][y] -= bed
+				water.Depth[x][y] = bed * h.Fill
+			}
+		}
+	}
+	return water
+}
+
+// Mesh returns the water surface as vertex positions, normals, and
+// triangle faces, ie: for Mesh.SetData and Mesh.SetFaces. Tile cell x, y
+// is at vertex x, water height, y. The surface covers the grid squares
+// that touch water. Dry corners use the lowest neighbouring water height
+// so that the surface meets the land at the shore.
+func (w *Water) Mesh() (vb, nb []float32, fb []uint16) {
+	width, height := len(w.Depth), len(w.Depth[0])
+	ids := map[point]uint16{}
+	vertex := func(x, y int) uint16 {
+		p := point{x, y}
+		if id, ok := ids[p]; ok {
+			return id
+		}
+		id := uint16(len(vb) / 3)
+		ids[p] = id
+		vb = append(vb, float32(x), float32(w.level(x, y)), float32(y))
+		nb = append(nb, 0, 1, 0)
+		return id
+	}
+	for x := 0; x < width-1; x++ {
+		for y := 0; y < height-1; y++ {
+			wet := w.Depth[x][y] > 0 || w.Depth[x+1][y] > 0 || w.Depth[x][y+1] > 0 || w.Depth[x+1][y+1] > 0
+			if !wet || len(vb)/3 > math.MaxUint16-4 {
+				continue
+			}
+			v0, v1 := vertex(x, y), vertex(x, y+1)
+			v2, v3 := vertex(x+1, y), vertex(x+1, y+1)
+			fb = append(fb, v0, v1, v2, v1, v3, v2)
+		}
+	}
+	return vb, nb, fb
+}
+
+// level returns the water surface height at x, y, using the lowest
+// water surface of the neighbouring cells for dry cells.
+func (w *Water) level(x, y int) float64 {
+	if w.Depth[x][y] > 0 {
+		return w.topo[x][y] + w.Depth[x][y]
+	}
+	level := math.MaxFloat64
+	for _, n := range around {
+		nx, ny := x+n.x, y+n.y
+		if nx >= 0 && ny >= 0 && nx < len(w.Depth) && ny < len(w.Depth[0]) && w.Depth[nx][ny] > 0 {
+			level = math.Min(level, w.topo[nx][ny]+w.Depth[nx][ny])
+		}
+	}
+	return level
+}
+
+// around are the offsets to the 8 surrounding cells.
+var around = []point{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+// downhill returns the surrounding cell with the steepest drop from p.
+// Cells on the tile edges drain off the tile.
+func downhill(heights [][]float64, p point) (low point, ok bool) {
+	steepest := 0.0
+	for _, n := range around {
+		nx, ny := p.x+n.x, p.y+n.y
+		if nx < 0 || ny < 0 || nx >= len(heights) || ny >= len(heights[0]) {
+			return low, false // water leaves the tile.
+		}
+		drop := heights[p.x][p.y] - heights[nx][ny]
+		if n.x != 0 && n.y != 0 {
+			drop /= math.Sqrt2 // diagonals are further away.
+		}
+		if drop > steepest {
+			low, steepest, ok = point{nx, ny}, drop, true
+		}
+	}
+	return low, ok
+}
+
+// flood returns the heights with each pit filled to the height of its
+// lowest outlet to the tile edges. Filled cells are raised by rise above
+// the previous cell so that the filled surface slopes towards the outlet.
+// Uses the priority flood algorithm from:
+//    Barnes, Lehman, Mulla. "Priority-flood: An optimal depression-filling
+//    and watershed-labeling algorithm for digital elevation models", 2014.
+func flood(topo [][]float64, rise float64) [][]float64 {
+	w, h := len(topo), len(topo[0])
+	filled, done := grid(w, h), make([]bool, w*h)
+	open := &cells{}
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if x == 0 || y == 0 || x == w-1 || y == h-1 {
+				filled[x][y], done[x*h+y] = topo[x][y], true
+				heap.Push(open, cell{point{x, y}, topo[x][y]})
+			}
+		}
+	}
+	for open.Len() > 0 {
+		c := heap.Pop(open).(cell)
+		for _, n := range around {
+			nx, ny := c.p.x+n.x, c.p.y+n.y
+			if nx < 0 || ny < 0 || nx >= w || ny >= h || done[nx*h+ny] {
+				continue
+			}
+			done[nx*h+ny] = true
+			filled[nx][ny] = math.Max(topo[nx][ny], c.h+rise)
+			heap.Push(open, cell{point{nx, ny}, filled[nx][ny]})
+		}
+	}
+	return filled
+}
+
+// cell is a flood fill location and height.
+type cell struct {
+	p point
+	h float64
+}
+
+// cells is a lowest first priority queue used for flood filling.
+type cells []cell
+
+// Implement heap.Interface.
+func (c cells) Len() int            { return len(c) }
+func (c cells) Less(i, j int) bool  { return c[i].h < c[j].h }
+func (c cells) Swap(i, j int)       { c[i], c[j] = c[j], c[i] }
+func (c *cells) Push(x interface{}) { *c = append(*c, x.(cell)) }
+func (c *cells) Pop() interface{} {
+	old := *c
+	last := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return last
+}
+
+// grid allocates a w by h grid of values.
+func grid(w, h int) [][]float64 {
+	g := make([][]float64, w)
+	for x := range g {
+		g[x] = make([]float64, h)
+	}
+	return g
+}
@@ -0,0 +1,56 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"testing"
+)
+
+// Water runs down a slope into a river and fills a pit to its rim.
+// Water reaching the tile edges runs off the tile.
+func TestHydrology(t *testing.T) {
+	tile := newTile(32, 32, 0, 0, 0)
+	for x := range tile.topo {
+		for y := range tile.topo[x] {
+			tile.topo[x][y] = float64(x) * 0.1
+		}
+	}
+	tile.topo[20][16] = 0.5 // pit below its 1.9 to 2.1 neighbours.
+	before := tile.topo[5][5]
+	h := NewHydrology()
+	h.Rivers = 20
+	water := h.Apply(tile)
+	if !water.Lake[20][16] || water.Depth[20][16] < 1.39 || water.Depth[20][16] > 1.41 {
+		t.Errorf("Expected pit to fill to its lowest rim %t %f", water.Lake[20][16], water.Depth[20][16])
+	}
+	if flow := water.Flow[1][5]; flow != 30 {
+		t.Errorf("Expected row of upstream cells, got %f", flow)
+	}
+	if water.Depth[5][5] <= 0 || tile.topo[5][5] >= before {
+		t.Errorf("Expected carved river %f %f", water.Depth[5][5], tile.topo[5][5])
+	}
+	if water.Depth[25][5] != 0 {
+		t.Errorf("Expected dry upstream land %f", water.Depth[25][5])
+	}
+}
+
+// The water surface covers the grid squares that touch water.
+func TestWaterMesh(t *testing.T) {
+	tile := newTile(8, 8, 0, 0, 0)
+	for x := range tile.topo {
+		for y := range tile.topo[x] {
+			tile.topo[x][y] = 1
+		}
+	}
+	tile.topo[4][4] = 0
+	vb, nb, fb := NewHydrology().Apply(tile).Mesh()
+	if len(vb) != 9*3 || len(nb) != len(vb) || len(fb) != 4*6 {
+		t.Fatalf("Expected 4 squares around the pit %d %d", len(vb)/3, len(fb)/3)
+	}
+	for cnt := 1; cnt < len(vb); cnt += 3 {
+		if vb[cnt] != 1 {
+			t.Errorf("Expected flat water at the pit rim, got %f", vb[cnt])
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// World is a land and the land tiles that have been changed since they
+// were generated, ie: by erosion, roads, or players. A world can be
+// saved and loaded so that saved games and multiplayer clients agree on
+// the land. Only the seed, tile size, and changed tiles are saved since
+// the same seed generates bit-identical land on all platforms, see NewLand.
+// Changed tiles, ie: from erosion or water, are saved as they are since
+// they need not match on other platforms.
+// For example:
+//    w := synth.NewWorld(256, seed)
+//    t := w.Tile(2, 1, 3)
+//    synth.NewErosion().Thermal(t, 50)
+//    w.Changed(t)
+//    err := w.Save(file)
+type World struct {
+	Seed     int64            // Generates the land.
+	TileSize int              // Land tile width and height.
+	land     Land             // Generates unchanged tiles.
+	changed  map[[3]int]*tile // Changed tiles by zoom and origin.
+}
+
+// NewWorld creates a world for a land with the given tile size and seed.
+// A random seed is chosen when seed is 0. The chosen seed is available
+// as World.Seed.
+func NewWorld(tileSize int, seed int64) *World {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &World{Seed: seed, TileSize: tileSize, land: NewLand(tileSize, seed), changed: map[[3]int]*tile{}}
+}
+
+// Land returns the land used to generate unchanged tiles.
+func (w *World) Land() Land { return w.land }
+
+// Tile returns a new tile with the changed heights for the given zoom
+// and tile index, or with generated heights for unchanged tiles.
+func (w *World) Tile(zoom, tx, ty int) Tile {
+	if t, ok := w.changed[[3]int{zoom, tx, ty}]; ok {
+		return t.copy()
+	}
+	return w.land.NewTile(zoom, tx, ty)
+}
+
+// Changed saves a copy of the tile heights so that they are returned
+// by Tile and included when the world is saved. Call Changed again
+// after each change.
+func (w *World) Changed(t Tile) {
+	ox, oy := t.Origin()
+	width, height := t.Size()
+	saved := newTile(uint(width), uint(height), t.Zoom(), ox, oy)
+	for x, column := range t.Topo() {
+		copy(saved.topo[x], column)
+	}
+	w.changed[[3]int{t.Zoom(), ox, oy}] = saved
+}
+
+// Reset discards the changes to the given tile.
+func (w *World) Reset(zoom, tx, ty int) { delete(w.changed, [3]int{zoom, tx, ty}) }
+
+// worldMagic identifies saved worlds and the save format version.
+const worldMagic = "vuw1"
+
+// maxTileSize limits the tile size read from a saved world so that
+// a bad file can't allocate huge tiles.
+const maxTileSize = 4096
+
+// Save writes the world seed, tile size, and changed tiles. Values are
+// little endian and heights are saved bit for bit. Tiles are saved in
+// order so that the same world always saves the same bytes.
+func (w *World) Save(out io.Writer) error {
+	keys := [][3]int{}
+	for key := range w.changed {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		return a[0] < b[0] || (a[0] == b[0] && (a[1] < b[1] || (a[1] == b[1] && a[2] < b[2])))
+	})
+	bw := bufio.NewWriter(out)
+	header := []interface{}{[]byte(worldMagic), w.Seed, int32(w.TileSize), uint32(len(keys))}
+	for _, value := range header {
+		if err := binary.Write(bw, binary.LittleEndian, value); err != nil {
+			return fmt.Errorf("World.Save: %s", err)
+		}
+	}
+	for _, key := range keys {
+		t := w.changed[key]
+		width, height := t.Size()
+		info := []int32{int32(key[0]), int32(key[1]), int32(key[2]), int32(width), int32(height)}
+		if err := binary.Write(bw, binary.LittleEndian, info); err != nil {
+			return fmt.Errorf("World.Save: %s", err)
+		}
+		bits := make([]uint64, height)
+		for x := range t.topo {
+			for y, h := range t.topo[x] {
+				bits[y] = math.Float64bits(h)
+			}
+			if err := binary.Write(bw, binary.LittleEndian, bits); err != nil {
+				return fmt.Errorf("World.Save: %s", err)
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("World.Save: %s", err)
+	}
+	return nil
+}
+
+// LoadWorld reads a world written by World.Save.
+func LoadWorld(in io.Reader) (*World, error) {
+	br := bufio.NewReader(in)
+	var magic [4]byte
+	var seed int64
+	var size int32
+	var count uint32
+	for _, value := range []interface{}{&magic, &seed, &size, &count} {
+		if err := binary.Read(br, binary.LittleEndian, value); err != nil {
+			return nil, fmt.Errorf("LoadWorld: %s", err)
+		}
+	}
+	if string(magic[:]) != worldMagic {
+		return nil, fmt.Errorf("LoadWorld: unknown format %q", magic[:])
+	}
+	if size <= 0 || size > maxTileSize {
+		return nil, fmt.Errorf("LoadWorld: bad tile size %d", size)
+	}
+	w := NewWorld(int(size), seed)
+	for cnt := uint32(0); cnt < count; cnt++ {
+		info := make([]int32, 5)
+		if err := binary.Read(br, binary.LittleEndian, info); err != nil {
+			return nil, fmt.Errorf("LoadWorld: tile %d: %s", cnt, err)
+		}
+		if info[3] <= 0 || info[4] <= 0 || info[3] > size || info[4] > size {
+			return nil, fmt.Errorf("LoadWorld: tile %d: bad size %dx%d", cnt, info[3], info[4])
+		}
+		t := newTile(uint(info[3]), uint(info[4]), int(info[0]), int(info[1]), int(info[2]))
+		bits := make([]uint64, info[4])
+		for x := range t.topo {
+			if err := binary.Read(br, binary.LittleEndian, bits); err != nil {
+				return nil, fmt.Errorf("LoadWorld: tile %d: %s", cnt, err)
+			}
+			for y := range t.topo[x] {
+				t.topo[x][y] = math.Float64frombits(bits[y])
+			}
+		}
+		w.changed[[3]int{t.zoom, t.ox, t.oy}] = t
+	}
+	return w, nil
+}
+
+// copy returns a new tile with a copy of the tile heights.
+func (t *tile) copy() *tile {
+	c := &tile{topo: make([][]float64, len(t.topo)), zoom: t.zoom, ox: t.ox, oy: t.oy, face: t.face}
+	for x := range t.topo {
+		c.topo[x] = append([]float64{}, t.topo[x]...)
+	}
+	return c
+}
@@ -0,0 +1,74 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package synth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// The same seed generates the same land bit for bit. The expected
+// heights were generated on amd64 and must match on all platforms.
+func TestWorldDeterministic(t *testing.T) {
+	t0, t1 := NewWorld(32, 123).Tile(1, 1, 0), NewWorld(32, 123).Tile(1, 1, 0)
+	for x := range t0.Topo() {
+		for y, h := range t0.Topo()[x] {
+			if math.Float64bits(h) != math.Float64bits(t1.Topo()[x][y]) {
+				t.Fatalf("Expected identical heights at %d %d", x, y)
+			}
+		}
+	}
+	for _, want := range []struct {
+		x, y int
+		bits uint64
+	}{{3, 5, 0xbfb3df769bc05f70}, {20, 11, 0x3fb98693f43d4b85}} {
+		if got := math.Float64bits(t0.Topo()[want.x][want.y]); got != want.bits {
+			t.Errorf("Expected height bits %x at %d %d, got %x", want.bits, want.x, want.y, got)
+		}
+	}
+}
+
+// Changed tiles are kept when the world is saved and loaded.
+func TestWorldSave(t *testing.T) {
+	w := NewWorld(16, 123)
+	t0 := w.Tile(1, 1, 0)
+	t0.Topo()[3][4] = 42
+	w.Changed(t0)
+	t0.Topo()[3][4] = 7 // changes need to be saved again.
+	if w.Tile(1, 1, 0).Topo()[3][4] != 42 || w.Tile(1, 0, 0).Topo()[3][4] == 42 {
+		t.Fatal("Expected the saved change")
+	}
+	buff, again := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := w.Save(buff); err != nil {
+		t.Fatal(err)
+	}
+	saved := buff.Bytes()
+	loaded, err := LoadWorld(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Seed != 123 || loaded.TileSize != 16 || loaded.Tile(1, 1, 0).Topo()[3][4] != 42 {
+		t.Errorf("Expected loaded world %d %d", loaded.Seed, loaded.TileSize)
+	}
+	if loaded.Tile(1, 0, 0).Topo()[5][6] != w.Tile(1, 0, 0).Topo()[5][6] {
+		t.Error("Expected generated tiles to match")
+	}
+	if loaded.Save(again); !bytes.Equal(saved, again.Bytes()) {
+		t.Error("Expected identical saves")
+	}
+	if _, err := LoadWorld(bytes.NewReader(saved[:20])); err == nil {
+		t.Error("Expected truncated world to fail")
+	}
+	big := append([]byte{}, saved...)
+	binary.LittleEndian.PutUint32(big[32:], 1<<30) // first tile width.
+	if _, err := LoadWorld(bytes.NewReader(big)); err == nil {
+		t.Error("Expected oversized tile to fail")
+	}
+	binary.LittleEndian.PutUint32(big[12:], 1<<30) // world tile size.
+	if _, err := LoadWorld(bytes.NewReader(big)); err == nil {
+		t.Error("Expected oversized world tile size to fail")
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+// terrain.go pages generated land around a moving location.
+// DESIGN: Terrain is a helper that an application updates each frame.
+//         Chunk heights and mesh data are generated on background
+//         goroutines. Completed chunks are attached to Pov Models during
+//         Terrain.Update, reusing the Pov's of chunks that were paged out.
+//         Heights are sampled from world locations so that adjacent
+//         chunks share their border heights and normals. Distant chunks
+//         are geomipmapped, using every 2nd, 4th, ... height, with edge
+//         skirts instead of stitching so that chunks never need to be
+//         regenerated when their neighbours change detail.
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+
+	"github.com/gazed/vu/math/lin"
+	"github.com/gazed/vu/render"
+	"github.com/gazed/vu/synth"
+)
+
+// Terrain generates and renders land chunks around a location that is
+// expected to follow the camera, so large open worlds do not need
+// to be generated ahead of time. For example:
+//
+//	land := synth.Fbm(synth.NewSimplex(seed), 6, 1, 2, 0.5)
+//	terrain := vu.NewTerrain(eng.Root(), land, "phong", "mat:grass")
+//	...
+//	terrain.Update(cam.At()) // once per App.Update.
+//
+// Chunk meshes have vertex positions, normals, and uv texture coordinates
+// that cover each chunk once. Chunk settings are expected to be set
+// before the first Update.
+//
+// Distant chunks use fewer quads. Chunks within Detail of the location
+// have full detail, chunks within twice Detail have half detail, and
+// so on for the number of detail Levels. Chunk edges have skirts that
+// hide the cracks between chunks with different levels of detail.
+type Terrain struct {
+	Size     int     // Quads along each chunk side, 1 to 250. Default 32.
+	Spacing  float64 // World distance between heights. Default 1.
+	Height   float64 // World height for a noise value of 1. Default 10.
+	Scale    float64 // Noise distance for one world distance. Default 0.01.
+	Distance float64 // Chunks beyond the view distance are paged out.
+	Detail   float64 // Full detail distance. Default 64.
+	Levels   int     // Levels of detail. Default 4. Use 1 for full detail.
+
+	root   *Pov               // Parent of all chunks.
+	noise  synth.Noise        // Height generator.
+	shader string             // Chunk model shader.
+	attrs  []string           // Chunk model assets.
+	chunks map[chunkID]*chunk // Visible and generating chunks.
+	free   []*Pov             // Paged out chunks available for reuse.
+	reqs   chan *terrainData  // Chunks to generate.
+	done   chan *terrainData  // Generated chunks.
+	stop   chan bool          // Closed to stop the generators.
+}
+
+// NewTerrain creates a terrain whose heights come from the given noise.
+// The chunk Models use the given shader and assets, ie: NewModel.
+// The noise is sampled concurrently by the background generators.
+// Dispose the Terrain to stop the background generators.
+func NewTerrain(parent *Pov, n synth.Noise, shader string, attrs ...string) *Terrain {
+	t := &Terrain{Size: 32, Spacing: 1, Height: 10, Scale: 0.01, Distance: 200, Detail: 64, Levels: 4}
+	t.root = parent.NewPov()
+	t.noise, t.shader, t.attrs = n, shader, attrs
+	t.chunks = map[chunkID]*chunk{}
+	t.reqs = make(chan *terrainData, 64)
+	t.done = make(chan *terrainData, 64)
+	t.stop = make(chan bool)
+	for cnt := 0; cnt < runtime.NumCPU(); cnt++ {
+		go t.generator()
+	}
+	return t
+}
+
+// HeightAt returns the terrain height at the world location x, z,
+// ie: to place objects on the ground.
+func (t *Terrain) HeightAt(x, z float64) float64 {
+	return t.noise.Gen2D(x*t.Scale, z*t.Scale) * t.Height
+}
+
+// Chunks returns the number of visible chunks.
+func (t *Terrain) Chunks() (count int) {
+	for _, c := range t.chunks {
+		if c.pov != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Update pages chunks in and out around the world location x, z.
+// The height y is ignored.
+// Chunks within the view distance are requested nearest first and
+// attached when they have been generated. Chunks that need a different
+// level of detail are regenerated and replaced when ready. Expected to
+// be called once per App.Update, ie: terrain.Update(cam.At()).
+func (t *Terrain) Update(x, y, z float64) {
+	t.Size = int(lin.Clamp(float64(t.Size), 1, 250))
+	extent := float64(t.Size) * t.Spacing
+
+	// page out the chunks beyond the view distance.
+	for id, c := range t.chunks {
+		if id.distance(x, z, extent) > t.Distance+extent {
+			if c.pov != nil {
+				c.pov.Cull = true
+				t.free = append(t.free, c.pov)
+			}
+			delete(t.chunks, id)
+		}
+	}
+
+	// attach the generated chunks.
+	for attached := false; !attached; {
+		select {
+		case td := <-t.done:
+			t.attach(td)
+		default:
+			attached = true
+		}
+	}
+
+	// request the missing chunks and level of detail changes, nearest first.
+	reach := int(math.Ceil(t.Distance / extent))
+	cx, cz := int(math.Floor(x/extent)), int(math.Floor(z/extent))
+	missing := []chunkID{}
+	for i := cx - reach; i <= cx+reach; i++ {
+		for k := cz - reach; k <= cz+reach; k++ {
+			id := chunkID{i, k}
+			dist := id.distance(x, z, extent)
+			c, ok := t.chunks[id]
+			switch {
+			case !ok && dist <= t.Distance:
+				missing = append(missing, id)
+			case ok && !c.pending && c.level != t.level(dist):
+				missing = append(missing, id)
+			}
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].distance(x, z, extent) < missing[j].distance(x, z, extent)
+	})
+	coarse := 1 << uint(t.level(math.MaxFloat64))
+	for _, id := range missing {
+		level := t.level(id.distance(x, z, extent))
+		td := &terrainData{id: id, size: t.Size, step: 1 << uint(level), coarse: coarse, level: level,
+			spacing: t.Spacing, height: t.Height, scale: t.Scale}
+		select {
+		case t.reqs <- td:
+			if c, ok := t.chunks[id]; ok {
+				c.pending = true
+			} else {
+				t.chunks[id] = &chunk{level: level, pending: true}
+			}
+		default:
+			return // try again next update.
+		}
+	}
+}
+
+// level returns the level of detail for a chunk at the given distance.
+// Each level halves the number of quads along a chunk side, so levels
+// stop where the chunk quads can no longer be halved.
+func (t *Terrain) level(dist float64) (level int) {
+	reach := t.Detail
+	for level < t.Levels-1 && dist > reach && t.Size%(2<<uint(level)) == 0 {
+		level++
+		reach *= 2
+	}
+	return level
+}
+
+// Dispose stops the background generators and removes the
+// terrain chunks.
+func (t *Terrain) Dispose() {
+	close(t.stop)
+	povs := t.free
+	for _, c := range t.chunks {
+		if c.pov != nil {
+			povs = append(povs, c.pov)
+		}
+	}
+	for _, pov := range povs {
+		if m, ok := pov.Model().(*model); ok && m.msh != nil {
+			t.root.eng.release(&releaseData{data: m.msh})
+		}
+	}
+	t.root.Dispose(PovNode)
+	t.chunks, t.free = map[chunkID]*chunk{}, nil
+}
+
+// attach puts the generated chunk data into a chunk Model.
+// Chunks that were paged out while generating are dropped.
+func (t *Terrain) attach(td *terrainData) {
+	c, ok := t.chunks[td.id]
+	if !ok || !c.pending {
+		return
+	}
+	c.pending, c.level = false, td.level
+	var msh Mesh
+	switch last := len(t.free) - 1; {
+	case c.pov != nil:
+		msh = c.pov.Model().Mesh() // replace the level of detail.
+	case last >= 0:
+		c.pov, t.free = t.free[last], t.free[:last]
+		c.pov.Cull = false
+		msh = c.pov.Model().Mesh()
+	default:
+		c.pov = t.root.NewPov()
+		name := fmt.Sprintf("msh:terrain%d", c.pov.id)
+		msh = c.pov.NewModel(t.shader, t.attrs...).Make(name).Mesh()
+		msh.InitData(0, 3, render.DynamicDraw, false)
+		msh.InitData(1, 3, render.DynamicDraw, false)
+		msh.InitData(2, 2, render.DynamicDraw, false)
+		msh.InitFaces(render.DynamicDraw)
+	}
+	msh.SetData(0, td.vb)
+	msh.SetData(1, td.nb)
+	msh.SetData(2, td.tb)
+	msh.SetFaces(td.fb)
+	extent := float64(td.size) * td.spacing
+	c.pov.SetAt(float64(td.id.x)*extent, 0, float64(td.id.z)*extent)
+}
+
+// generator creates chunk mesh data until the terrain is disposed.
+// Run as a goroutine.
+func (t *Terrain) generator() {
+	for {
+		select {
+		case td := <-t.reqs:
+			td.generate(t.noise)
+			select {
+			case t.done <- td:
+			case <-t.stop:
+				return
+			}
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// chunk is a visible or generating terrain chunk.
+type chunk struct {
+	pov     *Pov // Nil until the chunk is first generated.
+	level   int  // Current or requested level of detail.
+	pending bool // True while the chunk is being generated.
+}
+
+// chunkID identifies a terrain chunk by its grid location.
+type chunkID struct{ x, z int }
+
+// distance returns the horizontal distance from the world location
+// x, z to the center of the chunk.
+func (id chunkID) distance(x, z, extent float64) float64 {
+	dx := (float64(id.x)+0.5)*extent - x
+	dz := (float64(id.z)+0.5)*extent - z
+	return math.Sqrt(dx*dx + dz*dz)
+}
+
+// terrainData is one chunk's settings and generated mesh data.
+// Vertex locations are relative to the chunk origin and the chunk
+// covers the world x, z area from its origin to origin+size*spacing.
+type terrainData struct {
+	id                     chunkID
+	size                   int // Quads along the full detail chunk side.
+	step                   int // Quads merged at this level of detail.
+	coarse                 int // Quads merged at the lowest level of detail.
+	level                  int // Level of detail, 0 for full detail.
+	spacing, height, scale float64
+	vb, nb, tb             []float32 // Vertex positions, normals, and uvs.
+	fb                     []uint16  // Triangle faces.
+}
+
+// generate creates the chunk mesh data from the noise. The normals
+// use heights from past the chunk edges so that they match the
+// normals of the adjacent chunks. Each chunk edge has a skirt that
+// hangs below the edge, deep enough to hide the cracks between
+// chunks with different levels of detail.
+func (td *terrainData) generate(n synth.Noise) {
+	quads := td.size / td.step
+	verts := quads + 1
+	gap := float64(td.step) * td.spacing
+	extent := float64(td.size) * td.spacing
+	ox, oz := float64(td.id.x)*extent, float64(td.id.z)*extent
+	heightAt := func(x, z float64) float64 {
+		return n.Gen2D((ox+x)*td.scale, (oz+z)*td.scale) * td.height
+	}
+	td.vb = make([]float32, 0, (verts+4)*verts*3)
+	td.nb = make([]float32, 0, (verts+4)*verts*3)
+	td.tb = make([]float32, 0, (verts+4)*verts*2)
+	for i := 0; i < verts; i++ {
+		for k := 0; k < verts; k++ {
+			x, z := float64(i)*gap, float64(k)*gap
+			td.vb = append(td.vb, float32(x), float32(heightAt(x, z)), float32(z))
+
+			// normal from the full detail central difference slopes.
+			dx := heightAt(x+td.spacing, z) - heightAt(x-td.spacing, z)
+			dz := heightAt(x, z+td.spacing) - heightAt(x, z-td.spacing)
+			nx, ny, nz := -dx, 2*td.spacing, -dz
+			length := math.Sqrt(nx*nx + ny*ny + nz*nz)
+			td.nb = append(td.nb, float32(nx/length), float32(ny/length), float32(nz/length))
+			td.tb = append(td.tb, float32(i)/float32(quads), float32(k)/float32(quads))
+		}
+	}
+
+	// two counter clockwise triangles, viewed from above, for each quad.
+	td.fb = make([]uint16, 0, (quads+4)*quads*6)
+	for i := 0; i < quads; i++ {
+		for k := 0; k < quads; k++ {
+			v0 := uint16(i*verts + k)
+			v1, v2, v3 := v0+1, v0+uint16(verts), v0+uint16(verts)+1
+			td.fb = append(td.fb, v0, v1, v2, v1, v3, v2)
+		}
+	}
+
+	// skirts face outwards. Edges are walked so that the outside
+	// is on the right when looking down.
+	depth := float32(2*td.deviation(heightAt) + 0.1*td.spacing)
+	edge := func(i, k int) int { return i*verts + k }
+	for _, walk := range [4][4]int{{0, 0, 0, 1}, {quads, quads, 0, -1}, {quads, 0, -1, 0}, {0, quads, 1, 0}} {
+		i, k, di, dk := walk[0], walk[1], walk[2], walk[3]
+		for cnt := 0; cnt < verts; cnt++ {
+			top := edge(i+di*cnt, k+dk*cnt)
+			td.vb = append(td.vb, td.vb[top*3], td.vb[top*3+1]-depth, td.vb[top*3+2])
+			td.nb = append(td.nb, td.nb[top*3:top*3+3]...)
+			td.tb = append(td.tb, td.tb[top*2:top*2+2]...)
+			if cnt > 0 {
+				t0, t1 := uint16(edge(i+di*(cnt-1), k+dk*(cnt-1))), uint16(top)
+				b1 := uint16(len(td.vb)/3 - 1)
+				td.fb = append(td.fb, t0, b1-1, t1, t1, b1-1, b1)
+			}
+		}
+	}
+}
+
+// deviation returns the largest height difference between the full
+// detail chunk edges and the edges at the lowest level of detail.
+func (td *terrainData) deviation(heightAt func(x, z float64) float64) (dev float64) {
+	if td.coarse <= 1 {
+		return 0
+	}
+	extent := float64(td.size) * td.spacing
+	for _, edge := range [4][4]float64{{0, 0, 0, 1}, {extent, 0, 0, 1}, {0, 0, 1, 0}, {0, extent, 1, 0}} {
+		for j := 0; j < td.size; j += td.coarse {
+			d0, d1 := float64(j)*td.spacing, float64(j+td.coarse)*td.spacing
+			h0 := heightAt(edge[0]+edge[2]*d0, edge[1]+edge[3]*d0)
+			h1 := heightAt(edge[0]+edge[2]*d1, edge[1]+edge[3]*d1)
+			for m := 1; m < td.coarse; m++ {
+				d, ratio := float64(j+m)*td.spacing, float64(m)/float64(td.coarse)
+				h := heightAt(edge[0]+edge[2]*d, edge[1]+edge[3]*d)
+				dev = math.Max(dev, math.Abs(h-(h0+(h1-h0)*ratio)))
+			}
+		}
+	}
+	return dev
+}
@@ -0,0 +1,83 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gazed/vu/synth"
+)
+
+// Adjacent chunks share their border heights and normals.
+func TestTerrainSeams(t *testing.T) {
+	n := synth.NewSimplex(123)
+	a := &terrainData{id: chunkID{0, 0}, size: 8, step: 1, coarse: 1, spacing: 2, height: 10, scale: 0.05}
+	b := &terrainData{id: chunkID{1, 0}, size: 8, step: 1, coarse: 1, spacing: 2, height: 10, scale: 0.05}
+	a.generate(n)
+	b.generate(n)
+	if len(a.vb) != (9*9+4*9)*3 || len(a.fb) != (8*8+4*8)*6 || len(a.tb) != (9*9+4*9)*2 {
+		t.Fatalf("Unexpected mesh sizes %d %d %d", len(a.vb), len(a.fb), len(a.tb))
+	}
+	for k := 0; k <= 8; k++ {
+		ea, eb := (8*9+k)*3, k*3 // a's last column and b's first column.
+		for i := 0; i < 3; i++ {
+			if a.nb[ea+i] != b.nb[eb+i] {
+				t.Fatalf("Mismatched normals at %d", k)
+			}
+		}
+		if a.vb[ea+1] != b.vb[eb+1] || a.vb[ea]-b.vb[eb] != 16 {
+			t.Fatalf("Mismatched heights at %d", k)
+		}
+	}
+}
+
+// Distant chunks have fewer quads and deeper skirts.
+func TestTerrainDetail(t *testing.T) {
+	terrain := &Terrain{Size: 8, Detail: 64, Levels: 5}
+	for dist, want := range map[float64]int{0: 0, 64: 0, 100: 1, 200: 2, 1000: 3} {
+		if got := terrain.level(dist); got != want {
+			t.Errorf("Expected level %d at %f, got %d", want, dist, got)
+		}
+	}
+	n := synth.NewSimplex(123)
+	fine := &terrainData{id: chunkID{0, 0}, size: 8, step: 1, coarse: 1, spacing: 2, height: 10, scale: 0.05}
+	coarse := &terrainData{id: chunkID{0, 0}, size: 8, step: 2, coarse: 8, level: 1, spacing: 2, height: 10, scale: 0.05}
+	fine.generate(n)
+	coarse.generate(n)
+	if len(coarse.vb) != (5*5+4*5)*3 || len(coarse.fb) != (4*4+4*4)*6 {
+		t.Fatalf("Unexpected mesh sizes %d %d", len(coarse.vb), len(coarse.fb))
+	}
+	skirt := func(td *terrainData, verts int) float32 { return td.vb[1] - td.vb[verts*verts*3+1] }
+	if skirt(coarse, 5) <= skirt(fine, 9) || coarse.vb[1] != fine.vb[1] {
+		t.Errorf("Expected a deeper coarse skirt %f %f", skirt(coarse, 5), skirt(fine, 9))
+	}
+}
+
+// Chunks are paged in around the location and out when it moves away.
+func TestTerrainPaging(t *testing.T) {
+	eng := newEngine(make(chan msg, 100))
+	terrain := NewTerrain(eng.Root(), synth.NewSimplex(123), "phong")
+	terrain.Size, terrain.Distance = 8, 20
+	want := func(x float64, count int) {
+		for cnt := 0; cnt < 100 && terrain.Chunks() != count; cnt++ {
+			time.Sleep(5 * time.Millisecond)
+			terrain.Update(x, 0, 0)
+		}
+		if terrain.Chunks() != count {
+			t.Fatalf("Expected %d chunks got %d", count, terrain.Chunks())
+		}
+	}
+	terrain.Update(0, 0, 0)
+	want(0, 16) // chunk centers within 20 of the origin.
+	terrain.Update(1000, 0, 0)
+	if terrain.Chunks() != 0 || len(terrain.free) != 16 {
+		t.Errorf("Expected chunks to be paged out %d %d", terrain.Chunks(), len(terrain.free))
+	}
+	want(1000, 16)
+	if len(terrain.free) != 0 {
+		t.Errorf("Expected chunks to be reused")
+	}
+	terrain.Dispose()
+}
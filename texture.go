@@ -35,6 +35,10 @@ type texture struct {
 	img  image.Image // Texture data.
 	tid  uint32      // Graphics card texture identifier.
 
+	// Cube map textures have six faces instead of one image.
+	cube  bool           // True for cube map textures.
+	faces [6]image.Image // Cube map faces: +X, -X, +Y, -Y, +Z, -Z.
+
 	// First face index and number of faces.
 	// Used for multiple uv textures for the same model.
 	f0, fn uint32 // Non-zero if texture only applies to particular faces.
@@ -45,6 +49,12 @@ func newTexture(name string) *texture {
 	return &texture{name: name, tag: assetID(tex, name)}
 }
 
+// newCubeTexture allocates space for a cube map texture object.
+// Cube maps are sampled by direction, ie: skyboxes and reflections.
+func newCubeTexture(name string) *texture {
+	return &texture{name: name, tag: assetID(cub, name), cube: true}
+}
+
 // aid is used to uniquely identify assets.
 func (t *texture) aid() aid      { return t.tag }  // hashed type and name.
 func (t *texture) label() string { return t.name } // asset name
@@ -0,0 +1,29 @@
+// Copyright © 2016 Galvanized Logic Inc.
+// Use is governed by a BSD-style license found in the LICENSE file.
+
+package vu
+
+import (
+	"testing"
+)
+
+// Cube maps are kept separate from the ordered model textures.
+func TestCubeTexture(t *testing.T) {
+	eng := newEngine(nil)
+	p := eng.root().NewPov()
+	p.NewModel("skybox", "msh:box", "tex:wood", "cube:sky")
+	m := eng.models.data[p.id]
+	cid := assetID(cub, "sky")
+	if _, ok := m.assets[cid]; !ok || cid == assetID(tex, "sky") {
+		t.Fatalf("Expected unique cube map asset %v", m.assets)
+	}
+	eng.models.queueLoads()
+	cube, wood := newCubeTexture("sky"), newTexture("wood")
+	eng.models.finishLoads(map[aid]asset{cid: cube, wood.aid(): wood})
+	if m.cube != cube || !m.cube.cube {
+		t.Errorf("Expected cube map texture")
+	}
+	if len(m.texs) != 1 || m.texs[0] != wood {
+		t.Errorf("Expected one ordered texture, got %d", len(m.texs))
+	}
+}
@@ -30,6 +30,8 @@ package vu
 
 import (
 	"fmt"
+	"image"
+	"io"
 	"log"
 	"os"
 	"runtime/debug"
@@ -70,7 +72,7 @@ func New(app App, name string, wx, wy, ww, wh int) (err error) {
 		m.shutdown()
 		return // failed to initialize graphics layer.
 	}
-	m.gc.Viewport(ww, wh)
+	m.gc.Viewport(m.dev.PixelSize()) // high resolution displays have more pixels.
 	m.dev.Open()
 	m.input = m.dev.Update()
 	m.frame1 = frame{} // Previous render frame.
@@ -102,19 +104,33 @@ const (
 	Points    = render.Points    // Used for particle effects.
 	Lines     = render.Lines     // Used for drawing squares and boxes.
 
+	// Per-model blend constants for Model BlendMode option.
+	BlendAlpha = render.BlendAlpha // Transparency using alpha values.
+	BlendAdd   = render.BlendAdd   // Additive blending for glows.
+
 	// KeyReleased indicator. Total time down, in update ticks,
 	// is key down ticks minus KeyReleased. See App.Update.
 	KeyReleased = device.KeyReleased
 
 	// Application created and controlled objects associated with
 	// the transform hierarchy. See Pov.Dispose.
-	PovNode  = iota // Transform hierarchy node, 3D location:orientation.
-	PovModel        // Rendered model attached to a Pov.
-	PovBody         // Physics body attached to a Pov.
-	PovCam          // Camera attached to a Pov.
-	PovSound        // Sound attached to a Pov.
-	PovLight        // Light attached to a Pov.
-	PovLayer        // Render pass layer attached to a Pov.
+	PovNode      = iota // Transform hierarchy node, 3D location:orientation.
+	PovModel            // Rendered model attached to a Pov.
+	PovBody             // Physics body attached to a Pov.
+	PovCam              // Camera attached to a Pov.
+	PovSound            // Sound attached to a Pov.
+	PovLight            // Light attached to a Pov.
+	PovLayer            // Render pass layer attached to a Pov.
+	PovProjector        // Texture projector attached to a Pov.
+)
+
+// Audio mixer buses for grouping sounds. See BusVolume, MuteBus,
+// PauseBus, and the bus statement in audio bank files.
+const (
+	MasterBus = audio.Master // Scales all other buses. See Volume.
+	MusicBus  = audio.Music  // Music tracks. See Eng.PlayMusic.
+	SfxBus    = audio.Sfx    // Sound effects. The default for sounds.
+	VoiceBus  = audio.Voice  // Dialog.
 )
 
 // vu
@@ -158,6 +174,7 @@ func (m *machine) run() {
 			return // exit immediately. User shutdown engine.
 		case *appData:
 			m.refreshAppData(t) // poll to refresh device input.
+			m.ac.Update()       // keep music streaming.
 		default:
 			switch t := req.(type) {
 			case *renderFrame:
@@ -172,16 +189,67 @@ func (m *machine) run() {
 				m.gc.SetTextureMode(t.tid, true)
 			case *toggleScreen:
 				m.dev.ToggleFullScreen()
-			case *setVolume:
-				m.ac.SetGain(t.gain)
+			case *setWindowMode:
+				m.dev.SetWindowMode(t.mode, t.monitor, t.dm)
 			case *setCursor:
 				m.dev.SetCursorAt(t.cx, t.cy)
 			case *showCursor:
 				m.dev.ShowCursor(t.enable)
+			case *lockCursor:
+				m.dev.LockCursor(t.lock)
+			case *cursorShape:
+				m.dev.SetCursorShape(t.shape)
+			case *cursorImage:
+				m.dev.SetCursorImage(t.img, t.hotX, t.hotY)
+			case *setTitle:
+				m.dev.SetTitle(t.title)
+			case *windowIcon:
+				m.dev.SetIcon(t.img)
+			case *requestAttention:
+				m.dev.Attention()
+			case *showProgress:
+				m.dev.Progress(t.p)
+			case *interceptClose:
+				m.dev.InterceptClose(t.intercept)
+			case *setClipboard:
+				m.dev.Paste(t.text)
+			case *getClipboard:
+				t.reply <- m.dev.Copy()
+			case *rumblePad:
+				m.dev.Rumble(t.pad, t.low, t.high, t.secs)
 			case *placeListener:
 				m.ac.PlaceListener(t.x, t.y, t.z)
-			case *playSound:
-				m.ac.PlaySound(t.sid, t.x, t.y, t.z)
+				m.ac.OrientListener(t.fx, t.fy, t.fz, t.ux, t.uy, t.uz)
+				m.ac.ListenerVelocity(t.vx, t.vy, t.vz)
+			case *placeSounds:
+				for cnt, sid := range t.sids {
+					at, vel := t.locs[cnt], t.vels[cnt]
+					m.ac.PlaceSound(sid, at[0], at[1], at[2])
+					m.ac.SoundVelocity(sid, vel[0], vel[1], vel[2])
+				}
+			case *setDoppler:
+				m.ac.SetDoppler(t.factor, t.speed)
+			case *setVoices:
+				m.ac.SetVoices(t.limit)
+			case *soundOps:
+				m.sound(t)
+			case *playMusic:
+				if err := m.ac.PlayMusic(t.data, t.stream, t.fadeIn); err != nil {
+					log.Printf("machine: %s", err)
+				}
+			case *stopMusic:
+				m.ac.StopMusic(t.fadeOut)
+			case *openCapture:
+				t.capture, t.err = m.ac.Capture(t.data, captureSeconds)
+				t.reply <- t
+			case *setBus:
+				m.ac.BusGain(t.bus, t.gain)
+				m.ac.MuteBus(t.bus, t.mute)
+				m.ac.PauseBus(t.bus, t.pause)
+			case *enableHRTF:
+				if err := m.ac.EnableHRTF(t.enable); err != nil {
+					log.Printf("machine: %s", err)
+				}
 			case *releaseData:
 				m.release(t)
 			case nil:
@@ -194,6 +262,36 @@ func (m *machine) run() {
 	close(m.stop) // The underlying device is gone, stop the engine.
 }
 
+// sound plays and controls sounds in the requested order.
+func (m *machine) sound(so *soundOps) {
+	for _, op := range so.ops {
+		switch t := op.(type) {
+		case *playSound:
+			m.ac.SetBus(t.sid, t.bus)
+			m.ac.SetSound(t.sid, t.gain, t.pitch, t.loop)
+			m.ac.LoopPoints(t.sid, t.points[0], t.points[1])
+			m.ac.SetRolloff(t.sid, t.ref, t.max, t.rolloff)
+			m.ac.SoundPriority(t.sid, t.priority)
+			m.ac.PlaySound(t.sid, t.x, t.y, t.z)
+		case *controlSound:
+			switch t.op {
+			case stopSound:
+				m.ac.StopSound(t.sid)
+			case pauseSound:
+				m.ac.PauseSound(t.sid)
+			case resumeSound:
+				m.ac.ResumeSound(t.sid)
+			case fadeSound:
+				m.ac.FadeSound(t.sid, t.value)
+			case gainSound:
+				m.ac.SoundGain(t.sid, t.value)
+			case pitchSound:
+				m.ac.SoundPitch(t.sid, t.value)
+			}
+		}
+	}
+}
+
 // shutdown properly cleans up and closes the device layers.
 func (m *machine) shutdown() {
 	if m.ac != nil {
@@ -237,11 +335,16 @@ func (m *machine) render(r *renderFrame) {
 // Expected to be called once per update tick.
 func (m *machine) refreshAppData(data *appData) {
 	data.input.convertInput(m.input, 0, 0) // refresh user data.
-	if data.input.Resized {
+	if data.input.Resized || data.state.Scale == 0 {
 		data.state.setScreen(m.dev.Size())
-		m.gc.Viewport(data.state.W, data.state.H)
+		data.state.setPixels(m.dev.PixelSize())
+		data.state.Scale = m.dev.Scale()
+		m.gc.Viewport(data.state.PW, data.state.PH)
 	}
 	data.state.FullScreen = m.dev.IsFullScreen()
+	if data.input.Resized || data.state.Monitors == nil {
+		data.state.Monitors = m.dev.Monitors()
+	}
 	data.reply <- data       // return refreshed app data.
 	m.input = m.dev.Update() // get latest user input for next refresh.
 }
@@ -310,11 +413,17 @@ func (m *machine) bindOne(a asset) error {
 		d.program, err = m.gc.BindShader(d.vsh, d.fsh, d.uniforms, d.layouts)
 		return err
 	case *texture:
+		if d.cube {
+			return m.gc.BindCubeMap(&d.tid, d.faces)
+		}
 		return m.gc.BindTexture(&d.tid, d.img)
 	case *sound:
+		if d.stream != nil {
+			return m.ac.BindStream(&d.sid, d.data, d.stream)
+		}
 		return m.ac.BindSound(&d.sid, &d.did, d.data)
 	case *layer:
-		return m.gc.BindFrame(d.attr, &d.bid, &d.tex.tid, &d.db)
+		return m.gc.BindFrame(d.attr, d.size, &d.bid, &d.tex.tid, &d.db.tid)
 	}
 	return fmt.Errorf("machine:bindOne. unhandled bind request")
 }
@@ -331,8 +440,8 @@ func (m *machine) release(rd *releaseData) {
 	case *sound:
 		m.ac.ReleaseSound(d.sid)
 	case *layer:
-		m.gc.ReleaseFrame(d.bid, d.tex.tid, d.db)
-		d.bid, d.tex.tid, d.db = 0, 0, 0
+		m.gc.ReleaseFrame(d.bid, d.tex.tid, d.db.tid)
+		d.bid, d.tex.tid, d.db.tid = 0, 0, 0
 	default:
 		log.Printf("machine.release: No bindings for %T", rd)
 	}
@@ -413,27 +522,127 @@ type renderFrame struct {
 	ut     uint64  // Counter for debugging.
 }
 
-// placeListener locates the sounds listener in world space.
+// placeListener locates and orients the sounds listener in world space.
 type placeListener struct {
-	x, y, z float64
+	x, y, z    float64 // location.
+	fx, fy, fz float64 // forward direction.
+	ux, uy, uz float64 // up direction.
+	vx, vy, vz float64 // velocity.
+}
+
+// placeSounds moves the given sounds to the given world locations
+// with the given velocities.
+type placeSounds struct {
+	sids []uint64
+	locs [][3]float64
+	vels [][3]float64
 }
 
-// playSound plays the given sound at the given world location.
+// setDoppler changes the doppler effect for moving sounds.
+type setDoppler struct{ factor, speed float64 }
+
+// setVoices changes the number of sounds that can play at once.
+type setVoices struct{ limit int }
+
+// playSound plays the given sound at the given world location
+// using the given playback and distance settings.
 type playSound struct {
-	sid     uint64
-	x, y, z float64
+	sid               uint64
+	bus               int
+	x, y, z           float64
+	gain, pitch       float64
+	loop              bool
+	points            [2]float64 // loop start and end seconds.
+	ref, max, rolloff float64
+	priority          int // voice priority.
 }
 
+// soundOps plays and controls sounds in order.
+// Each op is a *playSound or *controlSound.
+type soundOps struct{ ops []msg }
+
+// controlSound changes a played sound.
+type controlSound struct {
+	sid   uint64
+	op    int     // One of the sound control ops, ie: stopSound.
+	value float64 // Fade seconds, gain, or pitch.
+}
+
+// Sound control ops.
+const (
+	stopSound = iota
+	pauseSound
+	resumeSound
+	fadeSound
+	gainSound
+	pitchSound
+)
+
+// openCapture asks the machine to start recording from the microphone.
+// The machine replies with the opened capture, or an error.
+type openCapture struct {
+	data    *audio.Data       // recording format.
+	capture io.ReadCloser     // recorded sound.
+	err     error             // set if the capture could not be opened.
+	reply   chan *openCapture // for the opened capture.
+}
+
+// getClipboard asks the machine for the system clipboard text.
+type getClipboard struct{ reply chan string }
+
+// captureSeconds of recorded sound are kept until they are read.
+const captureSeconds = 1
+
+// playMusic streams a music track, crossfading from the current track.
+// Ownership of the stream passes to the machine.
+type playMusic struct {
+	data   *audio.Data   // stream format.
+	stream io.ReadCloser // music track data.
+	fadeIn float64       // crossfade seconds.
+}
+
+// stopMusic fades out the current music track.
+type stopMusic struct{ fadeOut float64 }
+
+// setBus changes the volume, mute, and pause settings for a mixer bus.
+type setBus struct {
+	bus         int
+	gain        float64
+	mute, pause bool
+}
+
+// enableHRTF turns head related transfer functions on or off.
+type enableHRTF struct{ enable bool }
+
 // state change messages. Engine to machine. Fire and forget.
 type enableAttr struct {
 	attr   uint32
 	enable bool
 }
 type setColor struct{ r, g, b, a float32 }
-type setVolume struct{ gain float64 }
 type setCursor struct{ cx, cy int }
 type showCursor struct{ enable bool }
+type lockCursor struct{ lock bool }
+type setClipboard struct{ text string }
+type interceptClose struct{ intercept bool }
+type cursorShape struct{ shape int }
+type rumblePad struct {
+	pad             int
+	low, high, secs float64
+}
+type cursorImage struct {
+	img        *image.NRGBA // copied by the engine.
+	hotX, hotY int          // cursor location within the image.
+}
+type setTitle struct{ title string }
+type windowIcon struct{ img *image.NRGBA }
+type requestAttention struct{}
+type showProgress struct{ p float64 }
 type toggleScreen struct{}
+type setWindowMode struct {
+	mode, monitor int
+	dm            device.Mode
+}
 type clampTex struct{ tid uint32 }
 
 // releaseData is used to request the removal a resources associated